@@ -83,5 +83,9 @@ export {
   setPublicDashboardVariables,
   getPublicDashboardVariables,
   fetchPublicDashboardVariableOptions,
+  setPublicDashboardCaptchaSolver,
   type MetricFindValue,
+  type PublicDashboardVariableTimeRange,
+  type PublicDashboardViewerChallenge,
+  type PublicDashboardCaptchaSolver,
 } from './utils/publicDashboardQueryHandler';
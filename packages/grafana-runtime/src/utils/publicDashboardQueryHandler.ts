@@ -1,4 +1,4 @@
-import { catchError, lastValueFrom, Observable, of, switchMap } from 'rxjs';
+import { catchError, from, lastValueFrom, Observable, of, switchMap } from 'rxjs';
 
 import { DataQuery, DataQueryRequest, DataQueryResponse, LoadingState } from '@grafana/data';
 
@@ -7,6 +7,15 @@ import { getBackendSrv } from '../services/backendSrv';
 
 import { BackendDataSourceResponse, toDataQueryResponse } from './queryResponse';
 
+/**
+ * Time range sent along with variable queries, in epoch milliseconds
+ */
+export interface PublicDashboardVariableTimeRange {
+  from: string;
+  to: string;
+  timezone?: string;
+}
+
 /**
  * Response type for variable options from the API
  */
@@ -15,6 +24,133 @@ export interface MetricFindValue {
   value: string;
 }
 
+/**
+ * Short-lived token the access token is exchanged for, required by the query and variable endpoints
+ */
+interface PublicDashboardViewerToken {
+  token: string;
+  expiresAt: string;
+}
+
+/**
+ * Challenge viewers solve before they get a viewer token, of type none when they aren't challenged
+ */
+export interface PublicDashboardViewerChallenge {
+  type: 'none' | 'proof_of_work' | 'captcha';
+  challenge?: string;
+  difficulty?: number;
+  provider?: string;
+  siteKey?: string;
+}
+
+/**
+ * Solves captcha challenges, returning the response of the captcha widget
+ */
+export type PublicDashboardCaptchaSolver = (challenge: PublicDashboardViewerChallenge) => Promise<string>;
+
+let captchaSolver: PublicDashboardCaptchaSolver | undefined;
+
+/**
+ * Set how captcha challenges are solved. The public dashboard page registers the captcha widget of the provider.
+ */
+export function setPublicDashboardCaptchaSolver(solver: PublicDashboardCaptchaSolver | undefined) {
+  captchaSolver = solver;
+}
+
+const viewerTokenHeader = 'X-Grafana-Public-Dashboard-Token';
+// Viewer tokens are renewed this long before they expire, so requests in flight don't carry expired tokens
+const viewerTokenRenewalMarginMs = 30 * 1000;
+
+let viewerToken: { accessToken: string; token: string; expiresAt: number } | undefined;
+let viewerTokenRequest: Promise<string> | undefined;
+
+/**
+ * Get a viewer token for the public dashboard, exchanging the access token for a new one when there is none or it's
+ * about to expire.
+ */
+export function getPublicDashboardViewerToken(accessToken: string): Promise<string> {
+  if (
+    viewerToken &&
+    viewerToken.accessToken === accessToken &&
+    viewerToken.expiresAt - viewerTokenRenewalMarginMs > Date.now()
+  ) {
+    return Promise.resolve(viewerToken.token);
+  }
+
+  if (!viewerTokenRequest) {
+    viewerTokenRequest = solveViewerChallenge(accessToken)
+      .then((solution) =>
+        lastValueFrom(
+          getBackendSrv().fetch<PublicDashboardViewerToken>({
+            url: `/api/public/dashboards/${accessToken}/viewer-token`,
+            method: 'POST',
+            data: { solution },
+          })
+        )
+      )
+      .then((response) => {
+        viewerToken = {
+          accessToken,
+          token: response.data.token,
+          expiresAt: new Date(response.data.expiresAt).getTime(),
+        };
+        return viewerToken.token;
+      })
+      .finally(() => {
+        viewerTokenRequest = undefined;
+      });
+  }
+  return viewerTokenRequest;
+}
+
+async function solveViewerChallenge(accessToken: string) {
+  const { data: challenge } = await lastValueFrom(
+    getBackendSrv().fetch<PublicDashboardViewerChallenge>({
+      url: `/api/public/dashboards/${accessToken}/viewer-challenge`,
+      method: 'GET',
+    })
+  );
+
+  switch (challenge.type) {
+    case 'proof_of_work':
+      return {
+        challenge: challenge.challenge!,
+        nonce: await solveProofOfWork(challenge.challenge!, challenge.difficulty!),
+      };
+    case 'captcha':
+      if (!captchaSolver) {
+        throw new Error('No captcha solver registered for public dashboards');
+      }
+      return { challenge: '', response: await captchaSolver(challenge) };
+    default:
+      return undefined;
+  }
+}
+
+/**
+ * Find a nonce so the SHA-256 of the challenge, a colon and the nonce starts with difficulty zero bits
+ */
+async function solveProofOfWork(challenge: string, difficulty: number): Promise<string> {
+  const encoder = new TextEncoder();
+  for (let nonce = 0; ; nonce++) {
+    const digest = new Uint8Array(await crypto.subtle.digest('SHA-256', encoder.encode(`${challenge}:${nonce}`)));
+    if (leadingZeroBits(digest) >= difficulty) {
+      return nonce.toString();
+    }
+  }
+}
+
+function leadingZeroBits(bytes: Uint8Array): number {
+  let zeros = 0;
+  for (const byte of bytes) {
+    if (byte !== 0) {
+      return zeros + Math.clz32(byte) - 24;
+    }
+    zeros += 8;
+  }
+  return zeros;
+}
+
 // Variable storage for public dashboard queries
 let publicDashboardVariables: Record<string, unknown> = {};
 
@@ -91,21 +227,24 @@ export function publicDashboardQueryHandler(request: DataQueryRequest<DataQuery>
     variables,
   };
 
-  return getBackendSrv()
-    .fetch<BackendDataSourceResponse>({
-      url: `/api/public/dashboards/${config.publicDashboardAccessToken!}/panels/${panelId}/query`,
-      method: 'POST',
-      data: body,
-      requestId,
-    })
-    .pipe(
-      switchMap((raw) => {
-        return of(toDataQueryResponse(raw, request.targets));
-      }),
-      catchError((err) => {
-        return of(toDataQueryResponse(err));
+  const accessToken = config.publicDashboardAccessToken!;
+  return from(getPublicDashboardViewerToken(accessToken)).pipe(
+    switchMap((token) =>
+      getBackendSrv().fetch<BackendDataSourceResponse>({
+        url: `/api/public/dashboards/${accessToken}/panels/${panelId}/query`,
+        method: 'POST',
+        data: body,
+        headers: { [viewerTokenHeader]: token },
+        requestId,
       })
-    );
+    ),
+    switchMap((raw) => {
+      return of(toDataQueryResponse(raw, request.targets));
+    }),
+    catchError((err) => {
+      return of(toDataQueryResponse(err));
+    })
+  );
 }
 
 /**
@@ -115,12 +254,14 @@ export function publicDashboardQueryHandler(request: DataQueryRequest<DataQuery>
  * @param variableName - The name of the variable to fetch options for
  * @param variables - Optional map of other variable values for cascading variables
  * @param searchFilter - Optional search filter to filter results
+ * @param timeRange - Optional time range of the viewer, used when the dashboard allows time selection
  * @returns Promise with array of options in {text, value} format
  */
 export async function fetchPublicDashboardVariableOptions(
   variableName: string,
   variables?: Record<string, unknown>,
-  searchFilter?: string
+  searchFilter?: string,
+  timeRange?: PublicDashboardVariableTimeRange
 ): Promise<MetricFindValue[]> {
   const accessToken = config.publicDashboardAccessToken;
 
@@ -130,6 +271,7 @@ export async function fetchPublicDashboardVariableOptions(
   }
 
   try {
+    const token = await getPublicDashboardViewerToken(accessToken);
     const response = await lastValueFrom(
       getBackendSrv().fetch<MetricFindValue[]>({
         url: `/api/public/dashboards/${accessToken}/variables/${encodeURIComponent(variableName)}/query`,
@@ -137,7 +279,9 @@ export async function fetchPublicDashboardVariableOptions(
         data: {
           variables: variables ?? {},
           searchFilter: searchFilter ?? '',
+          timeRange,
         },
+        headers: { [viewerTokenHeader]: token },
       })
     );
 
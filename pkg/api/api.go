@@ -182,18 +182,39 @@ func (hs *HTTPServer) registerRoutes() {
 		// anonymous view public dashboard
 		r.Get("/public-dashboards/:accessToken",
 			hs.PublicDashboardsApi.Middleware.HandleView,
+			publicdashboardsapi.RequiresBotCheck(hs.PublicDashboardsApi.BotDetector, hs.PublicDashboardsApi.PublicDashboardService, hs.PublicDashboardsApi.ClientIPs),
+			publicdashboardsapi.RequiresRateLimit(hs.PublicDashboardsApi.RateLimiter, hs.PublicDashboardsApi.ClientIPs),
 			publicdashboardsapi.SetPublicDashboardAccessToken,
 			publicdashboardsapi.SetPublicDashboardOrgIdOnContext(hs.PublicDashboardsApi.PublicDashboardService),
 			publicdashboardsapi.CountPublicDashboardRequest(),
+			publicdashboardsapi.RequiresAllowedCountry(hs.PublicDashboardsApi.PublicDashboardService, hs.PublicDashboardsApi.GeoIPProvider),
+			publicdashboardsapi.RequiresAllowedDomain(hs.PublicDashboardsApi.PublicDashboardService),
+			hs.Index,
+		)
+
+		// anonymous view public dashboard embedded in other sites
+		r.Get("/public-dashboards/:accessToken/embed",
+			hs.PublicDashboardsApi.Middleware.HandleView,
+			publicdashboardsapi.RequiresBotCheck(hs.PublicDashboardsApi.BotDetector, hs.PublicDashboardsApi.PublicDashboardService, hs.PublicDashboardsApi.ClientIPs),
+			publicdashboardsapi.RequiresRateLimit(hs.PublicDashboardsApi.RateLimiter, hs.PublicDashboardsApi.ClientIPs),
+			publicdashboardsapi.SetPublicDashboardAccessToken,
+			publicdashboardsapi.SetPublicDashboardOrgIdOnContext(hs.PublicDashboardsApi.PublicDashboardService),
+			publicdashboardsapi.CountPublicDashboardRequest(),
+			publicdashboardsapi.RequiresAllowedCountry(hs.PublicDashboardsApi.PublicDashboardService, hs.PublicDashboardsApi.GeoIPProvider),
+			publicdashboardsapi.SetPublicDashboardEmbedHeaders(hs.Cfg),
+			publicdashboardsapi.RequiresAllowedDomain(hs.PublicDashboardsApi.PublicDashboardService),
 			hs.Index,
 		)
 
 		r.Get("/bootdata/:accessToken",
 			reqNoAuth,
 			hs.PublicDashboardsApi.Middleware.HandleView,
+			publicdashboardsapi.RequiresBotCheck(hs.PublicDashboardsApi.BotDetector, hs.PublicDashboardsApi.PublicDashboardService, hs.PublicDashboardsApi.ClientIPs),
+			publicdashboardsapi.RequiresRateLimit(hs.PublicDashboardsApi.RateLimiter, hs.PublicDashboardsApi.ClientIPs),
 			publicdashboardsapi.SetPublicDashboardAccessToken,
 			publicdashboardsapi.SetPublicDashboardOrgIdOnContext(hs.PublicDashboardsApi.PublicDashboardService),
 			publicdashboardsapi.CountPublicDashboardRequest(),
+			publicdashboardsapi.RequiresAllowedCountry(hs.PublicDashboardsApi.PublicDashboardService, hs.PublicDashboardsApi.GeoIPProvider),
 			hs.GetBootdata,
 		)
 	}
@@ -36,6 +36,10 @@ type DashboardMeta struct {
 	ProvisionedExternalId  string                             `json:"provisionedExternalId"`
 	AnnotationsPermissions *dashboardsV1.AnnotationPermission `json:"annotationsPermissions"`
 	PublicDashboardEnabled bool                               `json:"publicDashboardEnabled,omitempty"`
+	// Variables viewers of a public dashboard can't change
+	PublicDashboardReadOnlyVariables []string `json:"publicDashboardReadOnlyVariables,omitempty"`
+	// Access token of a public dashboard viewed by its slug, to use in the requests of the viewer
+	PublicDashboardAccessToken string `json:"publicDashboardAccessToken,omitempty"`
 }
 
 type DashboardFullWithMeta struct {
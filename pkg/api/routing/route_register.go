@@ -31,6 +31,10 @@ type RouteRegister interface {
 	// Patch adds a list of handlers to a given route with a PATCH HTTP verb
 	Patch(string, ...web.Handler)
 
+	// Head adds a list of handlers to a given route with a HEAD HTTP verb. GET routes answer HEAD requests as well,
+	// unless a HEAD route with the same pattern is added before them
+	Head(string, ...web.Handler)
+
 	// Any adds a list of handlers to a given route with any HTTP verb
 	Any(string, ...web.Handler)
 
@@ -192,6 +196,10 @@ func (rr *RouteRegisterImpl) Patch(pattern string, handlers ...web.Handler) {
 	rr.route(pattern, http.MethodPatch, handlers...)
 }
 
+func (rr *RouteRegisterImpl) Head(pattern string, handlers ...web.Handler) {
+	rr.route(pattern, http.MethodHead, handlers...)
+}
+
 func (rr *RouteRegisterImpl) Any(pattern string, handlers ...web.Handler) {
 	rr.route(pattern, "*", handlers...)
 }
@@ -145,6 +145,11 @@ type UnauthorisedPublicError PublicErrorResponse
 // swagger:response forbiddenPublicError
 type ForbiddenPublicError PublicErrorResponse
 
+// TooManyRequestsPublicError is returned when too many requests were sent and the request should be retried later.
+//
+// swagger:response tooManyRequestsPublicError
+type TooManyRequestsPublicError PublicErrorResponse
+
 // InternalServerPublicError is a general error indicating something went wrong internally.
 //
 // swagger:response internalServerPublicError
@@ -93,6 +93,17 @@ func Conflict(msgID string, opts ...BaseOpt) Base {
 	return NewBase(StatusConflict, msgID, opts...)
 }
 
+// Gone initializes a new [Base] error with reason StatusGone
+// that is used to construct [Error]. The msgID is passed to the caller
+// to serve as the base for user facing error messages.
+//
+// msgID should be structured as component.errorBrief, for example
+//
+//	publicdashboards.expired
+func Gone(msgID string, opts ...BaseOpt) Base {
+	return NewBase(StatusGone, msgID, opts...)
+}
+
 // BadRequest initializes a new [Base] error with reason StatusBadRequest
 // that is used to construct [Error]. The msgID is passed to the caller
 // to serve as the base for user facing error messages.
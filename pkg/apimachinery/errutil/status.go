@@ -37,6 +37,10 @@ const (
 	// there is a conflict in the current state of a resource
 	// HTTP status code 409.
 	StatusConflict CoreStatus = CoreStatus(metav1.StatusReasonConflict)
+	// StatusGone means that the requested resource existed but is no
+	// longer available, and is not expected to become available again.
+	// HTTP status code 410.
+	StatusGone CoreStatus = CoreStatus(metav1.StatusReasonGone)
 	// StatusTooManyRequests means that the client is rate limited
 	// by the server and should back-off before trying again.
 	// HTTP status code 429.
@@ -115,6 +119,8 @@ func (s CoreStatus) HTTPStatus() int {
 		return http.StatusUnsupportedMediaType
 	case StatusConflict:
 		return http.StatusConflict
+	case StatusGone:
+		return http.StatusGone
 	case StatusTooManyRequests:
 		return http.StatusTooManyRequests
 	case StatusBadRequest, StatusValidationFailed:
@@ -149,6 +155,8 @@ func (s CoreStatus) LogLevel() LogLevel {
 		return LevelInfo
 	case StatusConflict:
 		return LevelInfo
+	case StatusGone:
+		return LevelInfo
 	case StatusTooManyRequests:
 		return LevelInfo
 	case StatusBadRequest:
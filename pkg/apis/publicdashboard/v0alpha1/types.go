@@ -0,0 +1,51 @@
+package v0alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type PublicDashboard struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PublicDashboardSpec   `json:"spec,omitempty"`
+	Status PublicDashboardStatus `json:"status,omitempty"`
+}
+
+type PublicDashboardSpec struct {
+	// The uid of the shared dashboard, it can't be changed once the public dashboard is created
+	DashboardUID string `json:"dashboardUID"`
+
+	// Viewers can open the public dashboard
+	IsEnabled bool `json:"isEnabled"`
+
+	// Viewers can see the annotations of the dashboard
+	AnnotationsEnabled bool `json:"annotationsEnabled"`
+
+	// Viewers can change the time range of the dashboard
+	TimeSelectionEnabled bool `json:"timeSelectionEnabled"`
+
+	// Who can open the public dashboard, public or email. Defaults to public
+	Share string `json:"share,omitempty"`
+
+	// The panel the public dashboard is limited to, zero shares the whole dashboard
+	PanelID int64 `json:"panelID,omitempty"`
+
+	// The panels of the dashboard viewers can't access
+	// +listType=set
+	HiddenPanelIDs []int64 `json:"hiddenPanelIDs,omitempty"`
+}
+
+type PublicDashboardStatus struct {
+	// The token of the public URL of the dashboard, generated when the public dashboard is created
+	AccessToken string `json:"accessToken,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type PublicDashboardList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []PublicDashboard `json:"items"`
+}
@@ -189,6 +189,8 @@ func (s *Service) collectSystemStats(ctx context.Context) (map[string]any, error
 	m["stats.data_keys.count"] = statsResult.DataKeys
 	m["stats.active_data_keys.count"] = statsResult.ActiveDataKeys
 	m["stats.public_dashboards.count"] = statsResult.PublicDashboards
+	m["stats.public_dashboards.views.count"] = statsResult.PublicDashboardViews
+	m["stats.public_dashboards.queries.count"] = statsResult.PublicDashboardQueries
 	m["stats.correlations.count"] = statsResult.Correlations
 	m["stats.repositories.count"] = statsResult.Repositories
 	if statsResult.DatabaseCreatedTime != nil {
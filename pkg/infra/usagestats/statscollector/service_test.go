@@ -178,6 +178,8 @@ func TestCollectingUsageStats(t *testing.T) {
 	assert.EqualValues(t, 11, metrics["stats.data_keys.count"])
 	assert.EqualValues(t, 3, metrics["stats.active_data_keys.count"])
 	assert.EqualValues(t, 5, metrics["stats.public_dashboards.count"])
+	assert.EqualValues(t, 120, metrics["stats.public_dashboards.views.count"])
+	assert.EqualValues(t, 480, metrics["stats.public_dashboards.queries.count"])
 	assert.EqualValues(t, 3, metrics["stats.correlations.count"])
 
 	assert.InDelta(t, int64(65), metrics["stats.uptime"], 6)
@@ -340,6 +342,8 @@ func mockSystemStats(statsService *statstest.FakeService) {
 		DataKeys:                  11,
 		ActiveDataKeys:            3,
 		PublicDashboards:          5,
+		PublicDashboardViews:      120,
+		PublicDashboardQueries:    480,
 		Correlations:              3,
 	}
 }
@@ -13,17 +13,20 @@ import (
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/apiserver/endpoints/request"
 	"github.com/grafana/grafana/pkg/services/live"
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
 )
 
 // dashboardStorageWrapper is a wrapper around the grafanarest.Storage so it will:
 // 1. support adds dashboard permissions handling
 // 2. broadcast changes to grafana live
+// 3. drop the cached public dashboards of changed dashboards
 // when running in single tenant mode
 type dashboardStorageWrapper struct {
 	grafanarest.Storage
 
 	dashboardPermissionsSvc accesscontrol.DashboardPermissionsService
 	live                    live.DashboardActivityChannel
+	publicDashboards        publicdashboards.Service
 }
 
 func (d dashboardStorageWrapper) Update(ctx context.Context, name string, objInfo rest.UpdatedObjectInfo, createValidation rest.ValidateObjectFunc, updateValidation rest.ValidateObjectUpdateFunc, forceAllowCreate bool, options *metav1.UpdateOptions) (runtime.Object, bool, error) {
@@ -33,6 +36,9 @@ func (d dashboardStorageWrapper) Update(ctx context.Context, name string, objInf
 	}
 
 	obj, created, err := d.Storage.Update(ctx, name, objInfo, createValidation, updateValidation, forceAllowCreate, options)
+	if err == nil && ns.OrgID > 0 && d.publicDashboards != nil {
+		d.publicDashboards.DashboardChanged(ns.OrgID, name)
+	}
 	if err == nil && ns.OrgID > 0 && d.live != nil {
 		m, err := utils.MetaAccessor(obj)
 		if err == nil {
@@ -53,6 +59,9 @@ func (d dashboardStorageWrapper) Delete(ctx context.Context, name string, delete
 	if err != nil {
 		return obj, async, err
 	}
+	if ns.OrgID > 0 && d.publicDashboards != nil {
+		d.publicDashboards.DashboardChanged(ns.OrgID, name)
+	}
 	if ns.OrgID > 0 && d.live != nil {
 		if err := d.live.DashboardDeleted(ns.OrgID, name); err != nil {
 			logging.FromContext(ctx).Info("live dashboard update failed", "err", err)
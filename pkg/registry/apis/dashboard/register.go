@@ -747,6 +747,7 @@ func (b *DashboardsAPIBuilder) storageForVersion(
 		Storage:                 dw,
 		dashboardPermissionsSvc: b.dashboardPermissionsSvc,
 		live:                    b.dashboardActivityChannel,
+		publicDashboards:        b.publicDashboardService,
 	}
 
 	// Register the DTO endpoint that will consolidate all dashboard bits
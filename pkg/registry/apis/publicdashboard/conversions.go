@@ -0,0 +1,80 @@
+package publicdashboard
+
+import (
+	"fmt"
+	"strconv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	claims "github.com/grafana/authlib/types"
+	"github.com/grafana/grafana/pkg/apimachinery/utils"
+	publicdashboard "github.com/grafana/grafana/pkg/apis/publicdashboard/v0alpha1"
+	"github.com/grafana/grafana/pkg/services/apiserver/endpoints/request"
+	gapiutil "github.com/grafana/grafana/pkg/services/apiserver/utils"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+// convertToK8sResource maps a public dashboard of the legacy store to the resource, the uid is the name of the
+// resource and the last update its resource version
+func convertToK8sResource(v *PublicDashboard, namespacer request.NamespaceMapper) (*publicdashboard.PublicDashboard, error) {
+	p := &publicdashboard.PublicDashboard{
+		TypeMeta: publicdashboard.PublicDashboardResourceInfo.TypeMeta(),
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              v.Uid,
+			ResourceVersion:   fmt.Sprintf("%d", v.UpdatedAt.UnixMilli()),
+			CreationTimestamp: metav1.NewTime(v.CreatedAt),
+			Namespace:         namespacer(v.OrgId),
+		},
+		Spec: publicdashboard.PublicDashboardSpec{
+			DashboardUID:         v.DashboardUid,
+			IsEnabled:            v.IsEnabled,
+			AnnotationsEnabled:   v.AnnotationsEnabled,
+			TimeSelectionEnabled: v.TimeSelectionEnabled,
+			Share:                string(v.Share),
+			PanelID:              v.PanelId,
+			HiddenPanelIDs:       v.HiddenPanelIds,
+		},
+		Status: publicdashboard.PublicDashboardStatus{
+			AccessToken: v.AccessToken,
+		},
+	}
+
+	meta, err := utils.MetaAccessor(p)
+	if err != nil {
+		return nil, err
+	}
+
+	meta.SetUpdatedTimestamp(&v.UpdatedAt)
+	if v.CreatedBy != 0 {
+		meta.SetCreatedBy(claims.NewTypeID(claims.TypeUser, strconv.FormatInt(v.CreatedBy, 10)))
+	}
+	if v.UpdatedBy != 0 {
+		meta.SetUpdatedBy(claims.NewTypeID(claims.TypeUser, strconv.FormatInt(v.UpdatedBy, 10)))
+	}
+	p.UID = gapiutil.CalculateClusterWideUID(p)
+	return p, nil
+}
+
+// convertToSaveDTO maps the resource to the command of the legacy service. The spec replaces the config of the public
+// dashboard: the hidden panels are cleared when the spec has none, the settings the resource doesn't have are kept
+func convertToSaveDTO(p *publicdashboard.PublicDashboard, orgId int64, userId int64) *SavePublicDashboardDTO {
+	hiddenPanelIds := p.Spec.HiddenPanelIDs
+	if hiddenPanelIds == nil {
+		hiddenPanelIds = []int64{}
+	}
+	return &SavePublicDashboardDTO{
+		Uid:          p.Name,
+		DashboardUid: p.Spec.DashboardUID,
+		OrgID:        orgId,
+		UserId:       userId,
+		PublicDashboard: &PublicDashboardDTO{
+			Uid:                  p.Name,
+			IsEnabled:            &p.Spec.IsEnabled,
+			AnnotationsEnabled:   &p.Spec.AnnotationsEnabled,
+			TimeSelectionEnabled: &p.Spec.TimeSelectionEnabled,
+			Share:                ShareType(p.Spec.Share),
+			PanelId:              &p.Spec.PanelID,
+			HiddenPanelIds:       hiddenPanelIds,
+		},
+	}
+}
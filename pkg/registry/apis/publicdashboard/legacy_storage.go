@@ -0,0 +1,279 @@
+package publicdashboard
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	publicdashboard "github.com/grafana/grafana/pkg/apis/publicdashboard/v0alpha1"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/apiserver/endpoints/request"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+var (
+	_ rest.Scoper               = (*legacyStorage)(nil)
+	_ rest.SingularNameProvider = (*legacyStorage)(nil)
+	_ rest.Getter               = (*legacyStorage)(nil)
+	_ rest.Lister               = (*legacyStorage)(nil)
+	_ rest.Watcher              = (*legacyStorage)(nil)
+	_ rest.Storage              = (*legacyStorage)(nil)
+	_ rest.Creater              = (*legacyStorage)(nil)
+	_ rest.Updater              = (*legacyStorage)(nil)
+	_ rest.GracefulDeleter      = (*legacyStorage)(nil)
+)
+
+// legacyStorage serves the resource from the public dashboards of the legacy store, through the same service as the
+// legacy HTTP API so both APIs run the same validation and see the same data
+type legacyStorage struct {
+	service        publicdashboards.Service
+	accessControl  accesscontrol.AccessControl
+	namespacer     request.NamespaceMapper
+	tableConverter rest.TableConvertor
+}
+
+func (s *legacyStorage) New() runtime.Object {
+	return resourceInfo.NewFunc()
+}
+
+func (s *legacyStorage) Destroy() {}
+
+func (s *legacyStorage) NamespaceScoped() bool {
+	return true // namespace == org
+}
+
+func (s *legacyStorage) GetSingularName() string {
+	return resourceInfo.GetSingularName()
+}
+
+func (s *legacyStorage) NewList() runtime.Object {
+	return resourceInfo.NewListFunc()
+}
+
+func (s *legacyStorage) ConvertToTable(ctx context.Context, object runtime.Object, tableOptions runtime.Object) (*metav1.Table, error) {
+	return s.tableConverter.ConvertToTable(ctx, object, tableOptions)
+}
+
+func (s *legacyStorage) List(ctx context.Context, options *internalversion.ListOptions) (runtime.Object, error) {
+	orgId, err := request.OrgIDForList(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := signedInUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	paging, err := readContinueToken(options)
+	if err != nil {
+		return nil, err
+	}
+
+	// the service leaves out the public dashboards of dashboards the user can't read
+	resp, err := s.service.FindAllWithPagination(ctx, &PublicDashboardListQuery{
+		OrgID: orgId,
+		User:  u,
+		Page:  int(paging.page),
+		Limit: int(paging.limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	list := &publicdashboard.PublicDashboardList{}
+	for _, item := range resp.PublicDashboards {
+		// the list response only has a summary of the config
+		pubdash, err := s.service.Find(ctx, item.Uid)
+		if err != nil {
+			return nil, err
+		}
+		if pubdash == nil {
+			continue // deleted since it was listed
+		}
+		r, err := convertToK8sResource(pubdash, s.namespacer)
+		if err != nil {
+			return nil, err
+		}
+		list.Items = append(list.Items, *r)
+	}
+	if int64(len(resp.PublicDashboards)) >= paging.limit {
+		list.Continue = paging.GetNextPageToken()
+	}
+	return list, nil
+}
+
+func (s *legacyStorage) Get(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+	info, err := request.NamespaceInfoFrom(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	pubdash, err := s.service.Find(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	// public dashboards of other orgs don't exist in this namespace
+	if pubdash == nil || pubdash.OrgId != info.OrgID {
+		return nil, resourceInfo.NewNotFound(name)
+	}
+
+	if err := s.authorize(ctx, dashboards.ActionDashboardsRead, pubdash.DashboardUid); err != nil {
+		return nil, err
+	}
+
+	return convertToK8sResource(pubdash, s.namespacer)
+}
+
+func (s *legacyStorage) Create(ctx context.Context,
+	obj runtime.Object,
+	createValidation rest.ValidateObjectFunc,
+	options *metav1.CreateOptions,
+) (runtime.Object, error) {
+	info, err := request.NamespaceInfoFrom(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := signedInUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p, ok := obj.(*publicdashboard.PublicDashboard)
+	if !ok {
+		return nil, fmt.Errorf("expected public dashboard?")
+	}
+
+	if err := s.authorize(ctx, dashboards.ActionDashboardsPublicWrite, p.Spec.DashboardUID); err != nil {
+		return nil, err
+	}
+
+	// the uid is generated when the name is empty
+	pubdash, err := s.service.Create(ctx, u, convertToSaveDTO(p, info.OrgID, u.UserID))
+	if err != nil {
+		return nil, err
+	}
+	return convertToK8sResource(pubdash, s.namespacer)
+}
+
+func (s *legacyStorage) Update(ctx context.Context,
+	name string,
+	objInfo rest.UpdatedObjectInfo,
+	createValidation rest.ValidateObjectFunc,
+	updateValidation rest.ValidateObjectUpdateFunc,
+	forceAllowCreate bool,
+	options *metav1.UpdateOptions,
+) (runtime.Object, bool, error) {
+	info, err := request.NamespaceInfoFrom(ctx, true)
+	if err != nil {
+		return nil, false, err
+	}
+
+	u, err := signedInUser(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	oldObj, err := s.Get(ctx, name, nil)
+	if err != nil {
+		return oldObj, false, err
+	}
+
+	obj, err := objInfo.UpdatedObject(ctx, oldObj)
+	if err != nil {
+		return oldObj, false, err
+	}
+	p, ok := obj.(*publicdashboard.PublicDashboard)
+	if !ok {
+		return nil, false, fmt.Errorf("expected public dashboard after update")
+	}
+	old, ok := oldObj.(*publicdashboard.PublicDashboard)
+	if !ok {
+		return nil, false, fmt.Errorf("expected old object to be a public dashboard also")
+	}
+
+	if p.ResourceVersion != "" && p.ResourceVersion != old.ResourceVersion {
+		return nil, false, apierrors.NewConflict(resourceInfo.GroupResource(), name,
+			fmt.Errorf("the public dashboard has been modified, apply the changes to the latest version and try again"))
+	}
+	if p.Spec.DashboardUID != old.Spec.DashboardUID {
+		return nil, false, apierrors.NewBadRequest("the dashboard of a public dashboard can't be changed")
+	}
+
+	if err := s.authorize(ctx, dashboards.ActionDashboardsPublicWrite, old.Spec.DashboardUID); err != nil {
+		return nil, false, err
+	}
+
+	pubdash, err := s.service.Update(ctx, u, convertToSaveDTO(p, info.OrgID, u.UserID))
+	if err != nil {
+		return nil, false, err
+	}
+	r, err := convertToK8sResource(pubdash, s.namespacer)
+	return r, false, err
+}
+
+// GracefulDeleter
+func (s *legacyStorage) Delete(ctx context.Context, name string, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions) (runtime.Object, bool, error) {
+	v, err := s.Get(ctx, name, &metav1.GetOptions{})
+	if err != nil {
+		return v, false, err // includes the not-found error
+	}
+	p, ok := v.(*publicdashboard.PublicDashboard)
+	if !ok {
+		return v, false, fmt.Errorf("expected a public dashboard response from Get")
+	}
+
+	if err := s.authorize(ctx, dashboards.ActionDashboardsPublicWrite, p.Spec.DashboardUID); err != nil {
+		return nil, false, err
+	}
+
+	err = s.service.Delete(ctx, name, p.Spec.DashboardUID)
+	return p, true, err // true is instant delete
+}
+
+// GracefulDeleter
+func (s *legacyStorage) DeleteCollection(ctx context.Context, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions, listOptions *internalversion.ListOptions) (runtime.Object, error) {
+	return nil, fmt.Errorf("DeleteCollection for public dashboards not implemented")
+}
+
+// authorize checks the permission on the dashboard of the public dashboard, like the routes of the legacy HTTP API
+func (s *legacyStorage) authorize(ctx context.Context, action string, dashboardUid string) error {
+	requester, err := identity.GetRequester(ctx)
+	if err != nil {
+		return err
+	}
+	ok, err := s.accessControl.Evaluate(ctx, requester,
+		accesscontrol.EvalPermission(action, dashboards.ScopeDashboardsProvider.GetResourceScopeUID(dashboardUid)))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return apierrors.NewForbidden(resourceInfo.GroupResource(), dashboardUid,
+			fmt.Errorf("missing %s permission on the dashboard", action))
+	}
+	return nil
+}
+
+// signedInUser returns the user of the request, the legacy service saves who created and updated public dashboards
+func signedInUser(ctx context.Context) (*user.SignedInUser, error) {
+	requester, err := identity.GetRequester(ctx)
+	if err != nil {
+		return nil, err
+	}
+	u, ok := requester.(*user.SignedInUser)
+	if !ok {
+		return nil, apierrors.NewForbidden(resourceInfo.GroupResource(), "",
+			fmt.Errorf("public dashboards can only be managed by signed in users"))
+	}
+	return u, nil
+}
@@ -0,0 +1,141 @@
+package publicdashboard
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/watch"
+	k8srequest "k8s.io/apiserver/pkg/endpoints/request"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	publicdashboard "github.com/grafana/grafana/pkg/apis/publicdashboard/v0alpha1"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/actest"
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+func TestConvertToK8sResource(t *testing.T) {
+	createdAt := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	updatedAt := createdAt.Add(time.Hour)
+	pubdash := &PublicDashboard{
+		Uid:                  "pubdash1",
+		OrgId:                1,
+		DashboardUid:         "dash1",
+		AccessToken:          "e71950f4b5fc4a9d8a34a2d1b2d7e5e0",
+		IsEnabled:            true,
+		TimeSelectionEnabled: true,
+		Share:                PublicShareType,
+		PanelId:              4,
+		HiddenPanelIds:       []int64{2},
+		CreatedAt:            createdAt,
+		UpdatedAt:            updatedAt,
+	}
+
+	r, err := convertToK8sResource(pubdash, func(_ int64) string { return "default" })
+	require.NoError(t, err)
+	assert.Equal(t, "pubdash1", r.Name)
+	assert.Equal(t, "default", r.Namespace)
+	assert.Equal(t, "1790845200000", r.ResourceVersion)
+	assert.Equal(t, createdAt, r.CreationTimestamp.Time)
+	assert.Equal(t, publicdashboard.PublicDashboardSpec{
+		DashboardUID:         "dash1",
+		IsEnabled:            true,
+		TimeSelectionEnabled: true,
+		Share:                "public",
+		PanelID:              4,
+		HiddenPanelIDs:       []int64{2},
+	}, r.Spec)
+	assert.Equal(t, "e71950f4b5fc4a9d8a34a2d1b2d7e5e0", r.Status.AccessToken)
+
+	t.Run("the spec replaces the config", func(t *testing.T) {
+		r.Spec.HiddenPanelIDs = nil
+		dto := convertToSaveDTO(r, 1, 7)
+		assert.Equal(t, "pubdash1", dto.Uid)
+		assert.Equal(t, "dash1", dto.DashboardUid)
+		assert.Equal(t, int64(7), dto.UserId)
+		assert.True(t, *dto.PublicDashboard.IsEnabled)
+		assert.False(t, *dto.PublicDashboard.AnnotationsEnabled)
+		assert.Equal(t, int64(4), *dto.PublicDashboard.PanelId)
+		assert.Equal(t, []int64{}, dto.PublicDashboard.HiddenPanelIds, "the hidden panels are cleared")
+		assert.Nil(t, dto.PublicDashboard.AllowedDomains, "the settings the resource doesn't have are kept")
+	})
+}
+
+func TestLegacyStorageGet(t *testing.T) {
+	ctx := k8srequest.WithNamespace(identity.WithRequester(context.Background(), &user.SignedInUser{UserID: 1, OrgID: 1}), "default")
+	setup := func(orgId int64, canRead bool) *legacyStorage {
+		service := &publicdashboards.FakePublicDashboardService{}
+		service.On("Find", mock.Anything, "pubdash1").Return(&PublicDashboard{Uid: "pubdash1", OrgId: orgId, DashboardUid: "dash1"}, nil)
+		service.On("Find", mock.Anything, mock.Anything).Return(nil, nil)
+		return &legacyStorage{
+			service:       service,
+			accessControl: actest.FakeAccessControl{ExpectedEvaluate: canRead},
+			namespacer:    func(_ int64) string { return "default" },
+		}
+	}
+
+	t.Run("returns the public dashboard", func(t *testing.T) {
+		obj, err := setup(1, true).Get(ctx, "pubdash1", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "dash1", obj.(*publicdashboard.PublicDashboard).Spec.DashboardUID)
+	})
+
+	t.Run("public dashboards of other orgs aren't found", func(t *testing.T) {
+		_, err := setup(2, true).Get(ctx, "pubdash1", nil)
+		assert.True(t, apierrors.IsNotFound(err))
+		_, err = setup(1, true).Get(ctx, "unknown", nil)
+		assert.True(t, apierrors.IsNotFound(err))
+	})
+
+	t.Run("requires reading the dashboard", func(t *testing.T) {
+		_, err := setup(1, false).Get(ctx, "pubdash1", nil)
+		assert.True(t, apierrors.IsForbidden(err))
+	})
+}
+
+func TestWatchEvents(t *testing.T) {
+	item := func(name string, created, updated int64) *publicdashboard.PublicDashboard {
+		p := &publicdashboard.PublicDashboard{}
+		p.Name = name
+		p.CreationTimestamp.Time = time.UnixMilli(created)
+		p.ResourceVersion = strconv.FormatInt(updated, 10)
+		return p
+	}
+	eventTypes := func(events []watch.Event) map[string]watch.EventType {
+		types := map[string]watch.EventType{}
+		for _, event := range events {
+			types[event.Object.(*publicdashboard.PublicDashboard).Name] = event.Type
+		}
+		return types
+	}
+
+	items := map[string]*publicdashboard.PublicDashboard{
+		"a": item("a", 100, 100),
+		"b": item("b", 100, 300),
+		"c": item("c", 250, 250),
+	}
+
+	t.Run("starts with every public dashboard without resource version", func(t *testing.T) {
+		assert.Equal(t, map[string]watch.EventType{"a": watch.Added, "b": watch.Added, "c": watch.Added}, eventTypes(initialEvents(items, 0)))
+	})
+
+	t.Run("starts with the public dashboards changed after the resource version", func(t *testing.T) {
+		assert.Equal(t, map[string]watch.EventType{"b": watch.Modified, "c": watch.Added}, eventTypes(initialEvents(items, 200)))
+	})
+
+	t.Run("sends the changes between two lists", func(t *testing.T) {
+		next := map[string]*publicdashboard.PublicDashboard{
+			"a": items["a"],
+			"b": item("b", 100, 400),
+			"d": item("d", 400, 400),
+		}
+		assert.Equal(t, map[string]watch.EventType{"b": watch.Modified, "c": watch.Deleted, "d": watch.Added}, eventTypes(diffEvents(items, next)))
+	})
+}
@@ -0,0 +1,108 @@
+package publicdashboard
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/registry/rest"
+	genericapiserver "k8s.io/apiserver/pkg/server"
+	"k8s.io/kube-openapi/pkg/common"
+
+	publicdashboard "github.com/grafana/grafana/pkg/apis/publicdashboard/v0alpha1"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	roleauthorizer "github.com/grafana/grafana/pkg/services/apiserver/auth/authorizer"
+	"github.com/grafana/grafana/pkg/services/apiserver/builder"
+	"github.com/grafana/grafana/pkg/services/apiserver/endpoints/request"
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+var _ builder.APIGroupBuilder = (*PublicDashboardAPIBuilder)(nil)
+
+var resourceInfo = publicdashboard.PublicDashboardResourceInfo
+
+// PublicDashboardAPIBuilder serves the public dashboards as resources, the legacy HTTP API stays available on top of
+// the same service
+type PublicDashboardAPIBuilder struct {
+	service       publicdashboards.Service
+	accessControl accesscontrol.AccessControl
+	namespacer    request.NamespaceMapper
+}
+
+func NewPublicDashboardAPIBuilder(cfg *setting.Cfg, service publicdashboards.Service, accessControl accesscontrol.AccessControl) *PublicDashboardAPIBuilder {
+	return &PublicDashboardAPIBuilder{
+		service:       service,
+		accessControl: accessControl,
+		namespacer:    request.GetNamespaceMapper(cfg),
+	}
+}
+
+func RegisterAPIService(cfg *setting.Cfg,
+	service publicdashboards.Service,
+	accessControl accesscontrol.AccessControl,
+	apiregistration builder.APIRegistrar,
+) *PublicDashboardAPIBuilder {
+	if !cfg.PublicDashboardsEnabled {
+		return nil // skip registration when public dashboards are disabled
+	}
+
+	builder := NewPublicDashboardAPIBuilder(cfg, service, accessControl)
+	apiregistration.RegisterAPI(builder)
+	return builder
+}
+
+func (b *PublicDashboardAPIBuilder) GetAuthorizer() authorizer.Authorizer {
+	// the storage checks the permissions on the dashboards of the public dashboards
+	//nolint:staticcheck // not yet migrated to Resource Authorizer
+	return roleauthorizer.NewRoleAuthorizer()
+}
+
+func (b *PublicDashboardAPIBuilder) GetGroupVersion() schema.GroupVersion {
+	return resourceInfo.GroupVersion()
+}
+
+func addKnownTypes(scheme *runtime.Scheme, gv schema.GroupVersion) {
+	scheme.AddKnownTypes(gv,
+		&publicdashboard.PublicDashboard{},
+		&publicdashboard.PublicDashboardList{},
+	)
+}
+
+func (b *PublicDashboardAPIBuilder) InstallSchema(scheme *runtime.Scheme) error {
+	gv := resourceInfo.GroupVersion()
+	err := publicdashboard.AddToScheme(scheme)
+	if err != nil {
+		return err
+	}
+
+	// Link this version to the internal representation.
+	// This is used for server-side-apply (PATCH), and avoids the error:
+	//   "no kind is registered for the type"
+	addKnownTypes(scheme, schema.GroupVersion{
+		Group:   publicdashboard.GROUP,
+		Version: runtime.APIVersionInternal,
+	})
+	metav1.AddToGroupVersion(scheme, gv)
+	return scheme.SetVersionPriority(gv)
+}
+
+func (b *PublicDashboardAPIBuilder) AllowedV0Alpha1Resources() []string {
+	return []string{builder.AllResourcesAllowed}
+}
+
+func (b *PublicDashboardAPIBuilder) UpdateAPIGroupInfo(apiGroupInfo *genericapiserver.APIGroupInfo, opts builder.APIGroupOptions) error {
+	storage := map[string]rest.Storage{}
+	storage[resourceInfo.StoragePath()] = &legacyStorage{
+		service:        b.service,
+		accessControl:  b.accessControl,
+		namespacer:     b.namespacer,
+		tableConverter: resourceInfo.TableConverter(),
+	}
+	apiGroupInfo.VersionedResourcesStorageMap[publicdashboard.VERSION] = storage
+	return nil
+}
+
+func (b *PublicDashboardAPIBuilder) GetOpenAPIDefinitions() common.GetOpenAPIDefinitions {
+	return publicdashboard.GetOpenAPIDefinitions
+}
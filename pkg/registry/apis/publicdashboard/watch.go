@@ -0,0 +1,175 @@
+package publicdashboard
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	"k8s.io/apimachinery/pkg/watch"
+
+	publicdashboard "github.com/grafana/grafana/pkg/apis/publicdashboard/v0alpha1"
+)
+
+// watchPollInterval is how often watches list the public dashboards again, the legacy store has no change feed
+const watchPollInterval = 5 * time.Second
+
+// Watch polls the public dashboards of the namespace and sends the changes between two lists. Deleted public
+// dashboards are sent with their last known state
+func (s *legacyStorage) Watch(ctx context.Context, options *internalversion.ListOptions) (watch.Interface, error) {
+	since := int64(0)
+	if rv := options.ResourceVersion; rv != "" {
+		var err error
+		if since, err = strconv.ParseInt(rv, 10, 64); err != nil {
+			return nil, apierrors.NewBadRequest("invalid resource version " + rv)
+		}
+	}
+
+	current, err := s.listAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &pollWatcher{
+		result: make(chan watch.Event),
+		done:   make(chan struct{}),
+	}
+	go func() {
+		defer close(w.result)
+		if !w.send(ctx, initialEvents(current, since)) {
+			return
+		}
+
+		ticker := time.NewTicker(watchPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-w.done:
+				return
+			case <-ticker.C:
+			}
+
+			next, err := s.listAll(ctx)
+			if err != nil {
+				status := apierrors.NewInternalError(err).ErrStatus
+				w.send(ctx, []watch.Event{{Type: watch.Error, Object: &status}})
+				return
+			}
+			if !w.send(ctx, diffEvents(current, next)) {
+				return
+			}
+			current = next
+		}
+	}()
+	return w, nil
+}
+
+// listAll lists every public dashboard of the namespace the user can read, by name
+func (s *legacyStorage) listAll(ctx context.Context) (map[string]*publicdashboard.PublicDashboard, error) {
+	items := map[string]*publicdashboard.PublicDashboard{}
+	options := &internalversion.ListOptions{}
+	for {
+		obj, err := s.List(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+		list := obj.(*publicdashboard.PublicDashboardList)
+		for i := range list.Items {
+			items[list.Items[i].Name] = &list.Items[i]
+		}
+		if list.Continue == "" {
+			return items, nil
+		}
+		options = &internalversion.ListOptions{Continue: list.Continue}
+	}
+}
+
+// initialEvents returns the events of the first list of a watch. Watches without resource version start with every
+// public dashboard, the others with the ones changed after it
+func initialEvents(items map[string]*publicdashboard.PublicDashboard, since int64) []watch.Event {
+	events := []watch.Event{}
+	for _, name := range sortedNames(items) {
+		item := items[name]
+		if since == 0 {
+			events = append(events, watch.Event{Type: watch.Added, Object: item})
+			continue
+		}
+		if resourceVersion(item) <= since {
+			continue
+		}
+		if item.CreationTimestamp.UnixMilli() > since {
+			events = append(events, watch.Event{Type: watch.Added, Object: item})
+		} else {
+			events = append(events, watch.Event{Type: watch.Modified, Object: item})
+		}
+	}
+	return events
+}
+
+// diffEvents returns the events turning the previous list into the next one
+func diffEvents(previous, next map[string]*publicdashboard.PublicDashboard) []watch.Event {
+	events := []watch.Event{}
+	for _, name := range sortedNames(next) {
+		item := next[name]
+		old, ok := previous[name]
+		switch {
+		case !ok:
+			events = append(events, watch.Event{Type: watch.Added, Object: item})
+		case old.ResourceVersion != item.ResourceVersion:
+			events = append(events, watch.Event{Type: watch.Modified, Object: item})
+		}
+	}
+	for _, name := range sortedNames(previous) {
+		if _, ok := next[name]; !ok {
+			events = append(events, watch.Event{Type: watch.Deleted, Object: previous[name]})
+		}
+	}
+	return events
+}
+
+func resourceVersion(item *publicdashboard.PublicDashboard) int64 {
+	rv, _ := strconv.ParseInt(item.ResourceVersion, 10, 64)
+	return rv
+}
+
+func sortedNames(items map[string]*publicdashboard.PublicDashboard) []string {
+	names := make([]string, 0, len(items))
+	for name := range items {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+type pollWatcher struct {
+	result   chan watch.Event
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func (w *pollWatcher) Stop() {
+	w.stopOnce.Do(func() { close(w.done) })
+}
+
+func (w *pollWatcher) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+// send sends the events until the watch is stopped, false when it was
+func (w *pollWatcher) send(ctx context.Context, events []watch.Event) bool {
+	for _, event := range events {
+		select {
+		case w.result <- event:
+		case <-ctx.Done():
+			return false
+		case <-w.done:
+			return false
+		}
+	}
+	return true
+}
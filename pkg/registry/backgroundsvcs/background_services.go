@@ -36,7 +36,9 @@ import (
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/plugininstaller"
 	pluginStore "github.com/grafana/grafana/pkg/services/pluginsintegration/pluginstore"
 	"github.com/grafana/grafana/pkg/services/provisioning"
+	publicdashboardsgrpc "github.com/grafana/grafana/pkg/services/publicdashboards/grpcapi"
 	publicdashboardsmetric "github.com/grafana/grafana/pkg/services/publicdashboards/metric"
+	publicdashboardsservice "github.com/grafana/grafana/pkg/services/publicdashboards/service"
 	"github.com/grafana/grafana/pkg/services/rendering"
 	secretsMigrations "github.com/grafana/grafana/pkg/services/secrets/kvstore/migrations"
 	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
@@ -61,6 +63,14 @@ func ProvideBackgroundServiceRegistry(
 	saService *samanager.ServiceAccountsService, grpcServerProvider grpcserver.Provider,
 	secretMigrationProvider secretsMigrations.SecretMigrationProvider, loginAttemptService *loginattemptimpl.Service,
 	bundleService *supportbundlesimpl.Service, publicDashboardsMetric *publicdashboardsmetric.Service,
+	publicDashboardsInactivity *publicdashboardsservice.InactivityService,
+	publicDashboardsLiveVariables *publicdashboardsservice.LiveVariablesService,
+	publicDashboardsExpiration *publicdashboardsservice.ExpirationService,
+	publicDashboardsAuditLogRetention *publicdashboardsservice.AuditLogRetentionService,
+	publicDashboardsUsage *publicdashboardsservice.UsageService,
+	publicDashboardsOrphanedCleanup *publicdashboardsservice.OrphanedCleanupService,
+	publicDashboardsAccessTokenRotation *publicdashboardsservice.AccessTokenRotationService,
+	publicDashboardsFolderRestriction *publicdashboardsservice.FolderRestrictionService,
 	keyRetriever *dynamic.KeyRetriever, dynamicAngularDetectorsProvider *angulardetectorsprovider.Dynamic,
 	grafanaAPIServer grafanaapiserver.Service,
 	anon *anonimpl.AnonDeviceService,
@@ -77,7 +87,7 @@ func ProvideBackgroundServiceRegistry(
 	// Need to make sure these are initialized, is there a better place to put them?
 	_ dashboardsnapshots.Service,
 	_ serviceaccounts.Service,
-	_ *grpcserver.HealthService, _ *grpcserver.ReflectionService,
+	_ *grpcserver.HealthService, _ *grpcserver.ReflectionService, _ *publicdashboardsgrpc.Service,
 	_ *ldapapi.Service, _ *apiregistry.Service, _ auth.IDService, _ *teamapi.TeamAPI, _ ssosettings.Service,
 	_ cloudmigration.Service, _ authnimpl.Registration,
 ) *BackgroundServiceRegistry {
@@ -108,6 +118,14 @@ func ProvideBackgroundServiceRegistry(
 		loginAttemptService,
 		bundleService,
 		publicDashboardsMetric,
+		publicDashboardsInactivity,
+		publicDashboardsLiveVariables,
+		publicDashboardsExpiration,
+		publicDashboardsAuditLogRetention,
+		publicDashboardsUsage,
+		publicDashboardsOrphanedCleanup,
+		publicDashboardsAccessTokenRotation,
+		publicDashboardsFolderRestriction,
 		keyRetriever,
 		dynamicAngularDetectorsProvider,
 		grafanaAPIServer,
@@ -133,6 +133,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/publicdashboards"
 	publicdashboardsApi "github.com/grafana/grafana/pkg/services/publicdashboards/api"
 	publicdashboardsStore "github.com/grafana/grafana/pkg/services/publicdashboards/database"
+	publicdashboardsGrpc "github.com/grafana/grafana/pkg/services/publicdashboards/grpcapi"
 	publicdashboardsmetric "github.com/grafana/grafana/pkg/services/publicdashboards/metric"
 	publicdashboardsService "github.com/grafana/grafana/pkg/services/publicdashboards/service"
 	"github.com/grafana/grafana/pkg/services/query"
@@ -380,10 +381,20 @@ var wireBasicSet = wire.NewSet(
 	dashverimpl.ProvideService,
 	publicdashboardsService.ProvideService,
 	wire.Bind(new(publicdashboards.Service), new(*publicdashboardsService.PublicDashboardServiceImpl)),
+	publicdashboardsService.ProvideReadReplica,
 	publicdashboardsStore.ProvideStore,
 	wire.Bind(new(publicdashboards.Store), new(*publicdashboardsStore.PublicDashboardStoreImpl)),
 	publicdashboardsmetric.ProvideService,
+	publicdashboardsService.ProvideInactivityService,
+	publicdashboardsService.ProvideExpirationService,
+	publicdashboardsService.ProvideAuditLogRetentionService,
+	publicdashboardsService.ProvideUsageService,
+	publicdashboardsService.ProvideOrphanedCleanupService,
+	publicdashboardsService.ProvideAccessTokenRotationService,
+	publicdashboardsService.ProvideFolderRestrictionService,
+	publicdashboardsService.ProvideLiveVariablesService,
 	publicdashboardsApi.ProvideApi,
+	publicdashboardsGrpc.ProvideService,
 	starApi.ProvideApi,
 	userimpl.ProvideService,
 	orgimpl.ProvideService,
@@ -130,6 +130,10 @@ var wireExtsBasicSet = wire.NewSet(
 	pluginsintegration.WireExtensionSet,
 	publicdashboardsApi.ProvideMiddleware,
 	wire.Bind(new(publicdashboards.Middleware), new(*publicdashboardsApi.Middleware)),
+	publicdashboardsApi.ProvideGeoIPProvider,
+	wire.Bind(new(publicdashboards.GeoIPProvider), new(*publicdashboardsApi.HeaderGeoIPProvider)),
+	publicdashboardsService.ProvideViewerChallenger,
+	wire.Bind(new(publicdashboards.ViewerChallenger), new(*publicdashboardsService.ConfigViewerChallenger)),
 	publicdashboardsService.ProvideServiceWrapper,
 	wire.Bind(new(publicdashboards.ServiceWrapper), new(*publicdashboardsService.PublicDashboardServiceWrapperImpl)),
 	caching.ProvideCachingService,
@@ -38,6 +38,16 @@ const (
 	ActionSnapshotsRead              = "snapshots:read"
 )
 
+// Actions that loosen the constraints of a public dashboard. They're required on top of ActionDashboardsPublicWrite,
+// tightening the constraints only requires ActionDashboardsPublicWrite
+const (
+	ActionDashboardsPublicShare                  = "dashboards.public:share"
+	ActionDashboardsPublicTimeSelectionWrite     = "dashboards.public.timeselection:write"
+	ActionDashboardsPublicAnnotationsWrite       = "dashboards.public.annotations:write"
+	ActionDashboardsPublicVariableOverridesWrite = "dashboards.public.variableoverrides:write"
+	ActionDashboardsPublicExpirationWrite        = "dashboards.public.expiration:write"
+)
+
 var (
 	ScopeFoldersProvider    = ac.NewScopeProvider(ScopeFoldersRoot)
 	ScopeFoldersAll         = ScopeFoldersProvider.GetResourceAllScope()
@@ -2,6 +2,8 @@ package live
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -50,6 +52,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/plugincontext"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/pluginstore"
+	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/web"
@@ -61,6 +64,9 @@ var (
 	tracer   = otel.Tracer("github.com/grafana/grafana/pkg/services/live")
 )
 
+// PublicDashboardNamespace is the namespace of the grafana scope public dashboards publish their events to
+const PublicDashboardNamespace = "public-dashboard"
+
 // CoreGrafanaScope list of core features
 type CoreGrafanaScope struct {
 	Features map[string]model.ChannelHandlerFactory
@@ -368,6 +374,20 @@ func ProvideService(cfg *setting.Cfg, routeRegister routing.RouteRegister, plugC
 		wsHandler.ServeHTTP(ctx.Resp, r)
 	}
 
+	g.publicDashboardWebsocketHandler = func(ctx *contextmodel.ReqContext, accessToken string) {
+		// Viewers of public dashboards are anonymous, they get an identity without any role in the org of the
+		// public dashboard and can only subscribe to its channel. Their connections share a user id derived from the
+		// access token, so they can be disconnected when it's revoked
+		publicUser := &user.SignedInUser{OrgID: ctx.OrgID, OrgRole: org.RoleNone, IsAnonymous: true}
+		newCtx := centrifuge.SetCredentials(ctx.Req.Context(), &centrifuge.Credentials{
+			UserID: publicDashboardViewerID(accessToken),
+		})
+		newCtx = identity.WithRequester(newCtx, publicUser)
+		newCtx = livecontext.SetContextPublicDashboardAccessToken(newCtx, accessToken)
+		r := ctx.Req.WithContext(newCtx)
+		wsHandler.ServeHTTP(ctx.Resp, r)
+	}
+
 	g.pushWebsocketHandler = func(ctx *contextmodel.ReqContext) {
 		user := ctx.SignedInUser
 		newCtx := identity.WithRequester(ctx.Req.Context(), user)
@@ -461,6 +481,9 @@ type GrafanaLive struct {
 	pushWebsocketHandler         interface{}
 	pushPipelineWebsocketHandler interface{}
 
+	// Websocket handler of anonymous public dashboard viewers
+	publicDashboardWebsocketHandler func(ctx *contextmodel.ReqContext, accessToken string)
+
 	// Full channel handler
 	channels   map[string]model.ChannelHandler
 	channelsMu sync.RWMutex
@@ -646,6 +669,9 @@ func (g *GrafanaLive) handleOnRPC(clientContextWithSpan context.Context, client
 	if e.Method != "grafana.query" {
 		return centrifuge.RPCReply{}, centrifuge.ErrorMethodNotFound
 	}
+	if _, ok := livecontext.GetContextPublicDashboardAccessToken(clientContextWithSpan); ok {
+		return centrifuge.RPCReply{}, centrifuge.ErrorPermissionDenied
+	}
 	user, err := identity.GetRequester(clientContextWithSpan)
 	if err != nil {
 		logger.Error("No user found in context", "user", client.UserID(), "client", client.ID(), "method", e.Method)
@@ -687,6 +713,14 @@ func (g *GrafanaLive) handleOnSubscribe(clientContextWithSpan context.Context, c
 		return centrifuge.SubscribeReply{}, centrifuge.ErrorPermissionDenied
 	}
 
+	// Viewers of a public dashboard can only subscribe to the channel of the public dashboard and the streams bridged
+	// below it
+	if accessToken, ok := livecontext.GetContextPublicDashboardAccessToken(clientContextWithSpan); ok &&
+		channel != PublicDashboardChannel(accessToken) && !strings.HasPrefix(channel, PublicDashboardChannel(accessToken)+"/") {
+		logger.Info("Error subscribing: channel not available to public dashboard viewers", "client", client.ID(), "channel", e.Channel)
+		return centrifuge.SubscribeReply{}, centrifuge.ErrorPermissionDenied
+	}
+
 	var reply model.SubscribeReply
 	var status backend.SubscribeStreamStatus
 	var ruleFound bool
@@ -781,6 +815,10 @@ func (g *GrafanaLive) handleOnPublish(clientCtxWithSpan context.Context, client
 		return centrifuge.PublishReply{}, centrifuge.ErrorExpired
 	}
 
+	if _, ok := livecontext.GetContextPublicDashboardAccessToken(clientCtxWithSpan); ok {
+		return centrifuge.PublishReply{}, centrifuge.ErrorPermissionDenied
+	}
+
 	// See a detailed comment for StripOrgID about orgID management in Live.
 	orgID, channel, err := orgchannel.StripOrgID(e.Channel)
 	if err != nil {
@@ -1007,6 +1045,55 @@ func (g *GrafanaLive) handleDatasourceScope(ctx context.Context, user identity.R
 	), nil
 }
 
+// HandlePublicDashboardWebsocket opens a Live connection for an anonymous viewer of the public dashboard with the
+// access token. The connection can only subscribe to the channel of the public dashboard and its bridged streams
+func (g *GrafanaLive) HandlePublicDashboardWebsocket(ctx *contextmodel.ReqContext, accessToken string) {
+	g.publicDashboardWebsocketHandler(ctx, accessToken)
+}
+
+// DisconnectPublicDashboardViewers closes the Live connections of the viewers of the public dashboard with the access
+// token on every Grafana instance, and tells them not to reconnect
+func (g *GrafanaLive) DisconnectPublicDashboardViewers(accessToken string) error {
+	return g.node.Disconnect(publicDashboardViewerID(accessToken), centrifuge.WithCustomDisconnect(centrifuge.DisconnectForceNoReconnect))
+}
+
+// OnPublicDashboardRevoked sets the function invalidating the state of a revoked public dashboard on this node, called
+// for the revocations broadcast by any node
+func (g *GrafanaLive) OnPublicDashboardRevoked(h func(uid string, accessTokenHashes []string)) {
+	g.surveyCaller.SetPublicDashboardRevokedHandler(h)
+}
+
+// BroadcastPublicDashboardRevoked invalidates the state of a revoked public dashboard on every node, this one included.
+// Access tokens are identified by their hashes, so they don't go through the broker. Without HA engine there is only
+// this node
+func (g *GrafanaLive) BroadcastPublicDashboardRevoked(uid string, accessTokenHashes []string) error {
+	return g.surveyCaller.CallPublicDashboardRevoked(uid, accessTokenHashes)
+}
+
+// OnPublicDashboardChanged sets the function invalidating the cached state of a changed public dashboard on this node,
+// called for the changes broadcast by any node
+func (g *GrafanaLive) OnPublicDashboardChanged(h func(uid string)) {
+	g.surveyCaller.SetPublicDashboardChangedHandler(h)
+}
+
+// BroadcastPublicDashboardChanged invalidates the cached state of a changed public dashboard on every node, this one
+// included. Without HA engine there is only this node
+func (g *GrafanaLive) BroadcastPublicDashboardChanged(uid string) error {
+	return g.surveyCaller.CallPublicDashboardChanged(uid)
+}
+
+// publicDashboardViewerID is the user id of the Live connections of the viewers of a public dashboard. It's a hash of
+// the access token, so the access token doesn't end up in the logs of Live
+func publicDashboardViewerID(accessToken string) string {
+	hash := sha256.Sum256([]byte(accessToken))
+	return "public-dashboard-" + hex.EncodeToString(hash[:8])
+}
+
+// PublicDashboardChannel returns the channel of the public dashboard with the access token, without org id
+func PublicDashboardChannel(accessToken string) string {
+	return "grafana/" + PublicDashboardNamespace + "/" + accessToken
+}
+
 // Publish sends the data to the channel without checking permissions etc.
 func (g *GrafanaLive) Publish(orgID int64, channel string, data []byte) error {
 	_, err := g.node.Publish(orgchannel.PrependOrgID(orgID, channel), data)
@@ -33,3 +33,20 @@ func GetContextChannelID(ctx context.Context) (string, bool) {
 	}
 	return "", false
 }
+
+type publicDashboardAccessTokenContextKey struct{}
+
+// SetContextPublicDashboardAccessToken marks the connection as opened by an anonymous viewer of the public dashboard
+// with the access token
+func SetContextPublicDashboardAccessToken(ctx context.Context, accessToken string) context.Context {
+	ctx = context.WithValue(ctx, publicDashboardAccessTokenContextKey{}, accessToken)
+	return ctx
+}
+
+func GetContextPublicDashboardAccessToken(ctx context.Context) (string, bool) {
+	if val := ctx.Value(publicDashboardAccessTokenContextKey{}); val != nil {
+		values, ok := val.(string)
+		return values, ok
+	}
+	return "", false
+}
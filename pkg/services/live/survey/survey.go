@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/centrifugal/centrifuge"
@@ -17,10 +18,16 @@ import (
 type Caller struct {
 	managedStreamRunner *managedstream.Runner
 	node                *centrifuge.Node
+
+	mu                     sync.RWMutex
+	publicDashboardRevoked func(uid string, accessTokenHashes []string)
+	publicDashboardChanged func(uid string)
 }
 
 const (
-	managedStreamsCall = "managed_streams"
+	managedStreamsCall         = "managed_streams"
+	publicDashboardRevokedCall = "public_dashboard_revoked"
+	publicDashboardChangedCall = "public_dashboard_changed"
 )
 
 func NewCaller(managedStreamRunner *managedstream.Runner, node *centrifuge.Node) *Caller {
@@ -40,6 +47,15 @@ type NodeManagedChannelsResponse struct {
 	Channels []*managedstream.ManagedChannel `json:"channels"`
 }
 
+type PublicDashboardRevokedRequest struct {
+	Uid               string   `json:"uid"`
+	AccessTokenHashes []string `json:"accessTokenHashes"`
+}
+
+type PublicDashboardChangedRequest struct {
+	Uid string `json:"uid"`
+}
+
 func (c *Caller) handleSurvey(e centrifuge.SurveyEvent, cb centrifuge.SurveyCallback) {
 	var (
 		resp any
@@ -48,6 +64,10 @@ func (c *Caller) handleSurvey(e centrifuge.SurveyEvent, cb centrifuge.SurveyCall
 	switch e.Op {
 	case managedStreamsCall:
 		resp, err = c.handleManagedStreams(e.Data)
+	case publicDashboardRevokedCall:
+		resp, err = c.handlePublicDashboardRevoked(e.Data)
+	case publicDashboardChangedCall:
+		resp, err = c.handlePublicDashboardChanged(e.Data)
 	default:
 		err = errors.New("method not found")
 	}
@@ -81,6 +101,99 @@ func (c *Caller) handleManagedStreams(data []byte) (any, error) {
 	}, nil
 }
 
+// SetPublicDashboardRevokedHandler sets the function invalidating the state of a revoked public dashboard on this node
+func (c *Caller) SetPublicDashboardRevokedHandler(h func(uid string, accessTokenHashes []string)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.publicDashboardRevoked = h
+}
+
+func (c *Caller) handlePublicDashboardRevoked(data []byte) (any, error) {
+	var req PublicDashboardRevokedRequest
+	err := json.Unmarshal(data, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	h := c.publicDashboardRevoked
+	c.mu.RUnlock()
+	if h != nil {
+		h(req.Uid, req.AccessTokenHashes)
+	}
+	return struct{}{}, nil
+}
+
+// CallPublicDashboardRevoked invalidates the state of a revoked public dashboard on every node, it returns an error
+// when a node didn't reply
+func (c *Caller) CallPublicDashboardRevoked(uid string, accessTokenHashes []string) error {
+	req := PublicDashboardRevokedRequest{Uid: uid, AccessTokenHashes: accessTokenHashes}
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	resp, err := c.node.Survey(ctx, publicDashboardRevokedCall, jsonData, "")
+	if err != nil {
+		return err
+	}
+	for _, result := range resp {
+		if result.Code != 0 {
+			return fmt.Errorf("unexpected survey code: %d", result.Code)
+		}
+	}
+	return nil
+}
+
+// SetPublicDashboardChangedHandler sets the function invalidating the cached state of a changed public dashboard on
+// this node
+func (c *Caller) SetPublicDashboardChangedHandler(h func(uid string)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.publicDashboardChanged = h
+}
+
+func (c *Caller) handlePublicDashboardChanged(data []byte) (any, error) {
+	var req PublicDashboardChangedRequest
+	err := json.Unmarshal(data, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	h := c.publicDashboardChanged
+	c.mu.RUnlock()
+	if h != nil {
+		h(req.Uid)
+	}
+	return struct{}{}, nil
+}
+
+// CallPublicDashboardChanged invalidates the cached state of a changed public dashboard on every node, it returns an
+// error when a node didn't reply
+func (c *Caller) CallPublicDashboardChanged(uid string) error {
+	req := PublicDashboardChangedRequest{Uid: uid}
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	resp, err := c.node.Survey(ctx, publicDashboardChangedCall, jsonData, "")
+	if err != nil {
+		return err
+	}
+	for _, result := range resp {
+		if result.Code != 0 {
+			return fmt.Errorf("unexpected survey code: %d", result.Code)
+		}
+	}
+	return nil
+}
+
 func (c *Caller) CallManagedStreams(orgID int64) ([]*managedstream.ManagedChannel, error) {
 	req := NodeManagedChannelsRequest{OrgID: orgID}
 	jsonData, err := json.Marshal(req)
@@ -34,6 +34,8 @@ import (
 	"github.com/grafana/grafana/pkg/services/provisioning/dashboards"
 	"github.com/grafana/grafana/pkg/services/provisioning/datasources"
 	"github.com/grafana/grafana/pkg/services/provisioning/plugins"
+	prov_publicdashboards "github.com/grafana/grafana/pkg/services/provisioning/publicdashboards"
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
 	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/secrets"
 	"github.com/grafana/grafana/pkg/setting"
@@ -64,6 +66,7 @@ func ProvideService(
 	dual dualwrite.Service,
 	promTypeMigrationProvider promtypemigration.PromTypeMigrationProvider,
 	serverLockService *serverlock.ServerLockService,
+	publicDashboardService publicdashboards.Service,
 ) (*ProvisioningServiceImpl, error) {
 	s := &ProvisioningServiceImpl{
 		Cfg:                          cfg,
@@ -77,6 +80,8 @@ func ProvideService(
 		provisionDatasources:         datasources.Provision,
 		provisionPlugins:             plugins.Provision,
 		provisionAlerting:            prov_alerting.Provision,
+		provisionPublicDashboards:    prov_publicdashboards.Provision,
+		pollPublicDashboards:         prov_publicdashboards.PollChanges,
 		dashboardProvisioningService: dashboardProvisioningService,
 		dashboardService:             dashboardService,
 		datasourceService:            datasourceService,
@@ -92,6 +97,7 @@ func ProvideService(
 		migratePrometheusType:        promTypeMigrationProvider.Run,
 		dual:                         dual,
 		serverLock:                   serverLockService,
+		publicDashboardService:       publicDashboardService,
 	}
 
 	s.NamedService = services.NewBasicService(s.starting, s.running, nil).WithName(ServiceName)
@@ -135,10 +141,20 @@ func (ps *ProvisioningServiceImpl) starting(ctx context.Context) error {
 			return err
 		}
 	}
+
+	// Public dashboards share provisioned dashboards, so they're provisioned last
+	if err := ps.ProvisionPublicDashboards(ctx); err != nil {
+		ps.log.Error("Failed to provision public dashboards", "error", err)
+		return err
+	}
 	return nil
 }
 
 func (ps *ProvisioningServiceImpl) running(ctx context.Context) error {
+	if ps.pollPublicDashboards != nil {
+		go ps.pollPublicDashboards(ctx, filepath.Join(ps.Cfg.ProvisioningPath, "public-dashboards"), ps.publicDashboardService, ps.orgService)
+	}
+
 	for {
 		// Wait for unlock. This is tied to new dashboardProvisioner to be instantiated before we start polling.
 		ps.mutex.Lock()
@@ -178,6 +194,7 @@ type ProvisioningService interface {
 	ProvisionPlugins(ctx context.Context) error
 	ProvisionDashboards(ctx context.Context) error
 	ProvisionAlerting(ctx context.Context) error
+	ProvisionPublicDashboards(ctx context.Context) error
 	GetDashboardProvisionerResolvedPath(name string) string
 	GetAllowUIUpdatesFromConfig(name string) bool
 }
@@ -224,6 +241,8 @@ type ProvisioningServiceImpl struct {
 	provisionDatasources         func(context.Context, string, datasources.BaseDataSourceService, datasources.CorrelationsStore, org.Service) error
 	provisionPlugins             func(context.Context, string, pluginstore.Store, pluginsettings.Service, org.Service) error
 	provisionAlerting            func(context.Context, prov_alerting.ProvisionerConfig) error
+	provisionPublicDashboards    func(context.Context, string, publicdashboards.Service, org.Service) error
+	pollPublicDashboards         func(context.Context, string, publicdashboards.Service, org.Service)
 	mutex                        sync.Mutex
 	dashboardProvisioningService dashboardservice.DashboardProvisioningService
 	dashboardService             dashboardservice.DashboardService
@@ -238,6 +257,7 @@ type ProvisioningServiceImpl struct {
 	dual                         dualwrite.Service
 	serverLock                   *serverlock.ServerLockService
 	migratePrometheusType        func(context.Context) error
+	publicDashboardService       publicdashboards.Service
 }
 
 func (ps *ProvisioningServiceImpl) RunInitProvisioners(ctx context.Context) error {
@@ -348,6 +368,22 @@ func (ps *ProvisioningServiceImpl) ProvisionAlerting(ctx context.Context) error
 	return ps.provisionAlerting(ctx, cfg)
 }
 
+func (ps *ProvisioningServiceImpl) ProvisionPublicDashboards(ctx context.Context) error {
+	if ps.provisionPublicDashboards == nil {
+		return nil
+	}
+
+	ps.mutex.Lock()
+	defer ps.mutex.Unlock()
+	publicDashboardsPath := filepath.Join(ps.Cfg.ProvisioningPath, "public-dashboards")
+	if err := ps.provisionPublicDashboards(ctx, publicDashboardsPath, ps.publicDashboardService, ps.orgService); err != nil {
+		err = fmt.Errorf("%v: %w", "Public dashboard provisioning error", err)
+		ps.log.Error("Failed to provision public dashboards", "error", err)
+		return err
+	}
+	return nil
+}
+
 func (ps *ProvisioningServiceImpl) GetDashboardProvisionerResolvedPath(name string) string {
 	return ps.dashboardProvisioner.GetProvisionerResolvedPath(name)
 }
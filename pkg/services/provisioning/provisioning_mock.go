@@ -8,6 +8,7 @@ type Calls struct {
 	ProvisionPlugins                    []any
 	ProvisionDashboards                 []any
 	ProvisionAlerting                   []any
+	ProvisionPublicDashboards           []any
 	GetDashboardProvisionerResolvedPath []any
 	GetAllowUIUpdatesFromConfig         []any
 	Run                                 []any
@@ -19,6 +20,7 @@ type ProvisioningServiceMock struct {
 	ProvisionDatasourcesFunc                func(ctx context.Context) error
 	ProvisionPluginsFunc                    func() error
 	ProvisionDashboardsFunc                 func() error
+	ProvisionPublicDashboardsFunc           func(ctx context.Context) error
 	GetDashboardProvisionerResolvedPathFunc func(name string) string
 	GetAllowUIUpdatesFromConfigFunc         func(name string) bool
 	RunFunc                                 func(ctx context.Context) error
@@ -67,6 +69,14 @@ func (mock *ProvisioningServiceMock) ProvisionAlerting(ctx context.Context) erro
 	return nil
 }
 
+func (mock *ProvisioningServiceMock) ProvisionPublicDashboards(ctx context.Context) error {
+	mock.Calls.ProvisionPublicDashboards = append(mock.Calls.ProvisionPublicDashboards, nil)
+	if mock.ProvisionPublicDashboardsFunc != nil {
+		return mock.ProvisionPublicDashboardsFunc(ctx)
+	}
+	return nil
+}
+
 func (mock *ProvisioningServiceMock) GetDashboardProvisionerResolvedPath(name string) string {
 	mock.Calls.GetDashboardProvisionerResolvedPath = append(mock.Calls.GetDashboardProvisionerResolvedPath, name)
 	if mock.GetDashboardProvisionerResolvedPathFunc != nil {
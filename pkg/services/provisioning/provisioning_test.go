@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -20,6 +21,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/provisioning/dashboards"
 	"github.com/grafana/grafana/pkg/services/provisioning/datasources"
 	"github.com/grafana/grafana/pkg/services/provisioning/utils"
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/storage/legacysql/dualwrite"
 )
@@ -127,6 +129,27 @@ func TestProvisioningServiceImpl(t *testing.T) {
 
 		assert.Equal(t, 2, serviceTest.dashboardProvisionerInstantiations)
 	})
+
+	t.Run("Should provision public dashboards after dashboards", func(t *testing.T) {
+		serviceTest := setup(t)
+		var provisioned []string
+		serviceTest.mock.ProvisionFunc = func(ctx context.Context) error {
+			provisioned = append(provisioned, "dashboards")
+			return nil
+		}
+		serviceTest.service.provisionPublicDashboards = func(_ context.Context, path string, _ publicdashboards.Service, _ org.Service) error {
+			provisioned = append(provisioned, filepath.Base(path))
+			return nil
+		}
+		serviceTest.startService()
+		serviceTest.waitForPollChanges()
+
+		serviceTest.cancel()
+		serviceTest.waitForStop()
+
+		assert.Equal(t, []string{"dashboards", "public-dashboards"}, provisioned)
+		assert.NoError(t, serviceTest.serviceError)
+	})
 }
 
 type serviceTestStruct struct {
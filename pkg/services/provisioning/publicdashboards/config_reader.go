@@ -0,0 +1,169 @@
+package publicdashboards
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
+)
+
+type configReader interface {
+	readConfig(path string) ([]*publicDashboardsAsConfig, error)
+}
+
+type configReaderImpl struct {
+	log log.Logger
+}
+
+func newConfigReader(logger log.Logger) configReader {
+	return &configReaderImpl{log: logger}
+}
+
+func (cr *configReaderImpl) readConfig(path string) ([]*publicDashboardsAsConfig, error) {
+	var configs []*publicDashboardsAsConfig
+	cr.log.Debug("Looking for public dashboard provisioning files", "path", path)
+
+	files, err := os.ReadDir(path)
+	if err != nil {
+		cr.log.Error("Failed to read public dashboard provisioning files from directory", "path", path, "error", err)
+		return configs, nil
+	}
+
+	for _, file := range files {
+		if isConfigFile(file) {
+			cr.log.Debug("Parsing public dashboard provisioning file", "path", path, "file.Name", file.Name())
+			cfg, err := cr.parsePublicDashboardsConfig(path, file)
+			if err != nil {
+				return nil, err
+			}
+
+			if cfg != nil {
+				configs = append(configs, cfg)
+			}
+		}
+	}
+
+	cr.log.Debug("Validating public dashboards")
+	if err := validatePublicDashboards(configs); err != nil {
+		return nil, err
+	}
+
+	checkOrgIDAndOrgName(configs)
+
+	return configs, nil
+}
+
+func (cr *configReaderImpl) parsePublicDashboardsConfig(path string, file fs.DirEntry) (*publicDashboardsAsConfig, error) {
+	filename, err := filepath.Abs(filepath.Join(path, file.Name()))
+	if err != nil {
+		return nil, err
+	}
+
+	// nolint:gosec
+	// We can ignore the gosec G304 warning on this one because `filename` comes from ps.Cfg.ProvisioningPath
+	yamlFile, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg *publicDashboardsAsConfigV0
+	err = yaml.Unmarshal(yamlFile, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg.mapToPublicDashboardsFromConfig(), nil
+}
+
+func validatePublicDashboards(configs []*publicDashboardsAsConfig) error {
+	errs := []error{}
+	for i := range configs {
+		for index, pubdash := range configs[i].PublicDashboards {
+			if pubdash.DashboardUID == "" {
+				errs = append(errs, fmt.Errorf("public dashboard item %d in configuration doesn't contain required field dashboardUid", index+1))
+				continue
+			}
+			if pubdash.AccessToken != "" && !validation.IsValidAccessToken(pubdash.AccessToken) {
+				errs = append(errs, fmt.Errorf("public dashboard of dashboard %s has an invalid access token", pubdash.DashboardUID))
+			}
+			if !slices.Contains(models.ValidShareTypes, pubdash.Share) {
+				errs = append(errs, fmt.Errorf("public dashboard of dashboard %s has an invalid share type %q", pubdash.DashboardUID, pubdash.Share))
+			}
+		}
+
+		for index, pubdash := range configs[i].DeletePublicDashboards {
+			if pubdash.DashboardUID == "" {
+				errs = append(errs, fmt.Errorf("deleted public dashboard item %d in configuration doesn't contain required field dashboardUid", index+1))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func checkOrgIDAndOrgName(configs []*publicDashboardsAsConfig) {
+	for i := range configs {
+		for _, pubdash := range configs[i].PublicDashboards {
+			pubdash.OrgID = defaultOrgID(pubdash.OrgID, pubdash.OrgName)
+		}
+		for _, pubdash := range configs[i].DeletePublicDashboards {
+			pubdash.OrgID = defaultOrgID(pubdash.OrgID, pubdash.OrgName)
+		}
+	}
+}
+
+// defaultOrgID returns the main org when neither the org id nor the org name is set, the org name is resolved when
+// the config is applied
+func defaultOrgID(orgID int64, orgName string) int64 {
+	if orgID >= 1 {
+		return orgID
+	}
+	if orgName == "" {
+		return 1
+	}
+	return 0
+}
+
+// configChecksum returns a checksum of the provisioning files in the directory, to tell when they change
+func configChecksum(path string) (string, error) {
+	files, err := os.ReadDir(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	hash := sha256.New()
+	for _, file := range files {
+		if !isConfigFile(file) {
+			continue
+		}
+
+		// nolint:gosec
+		// We can ignore the gosec G304 warning on this one because `path` comes from ps.Cfg.ProvisioningPath
+		content, err := os.ReadFile(filepath.Join(path, file.Name()))
+		if err != nil {
+			return "", err
+		}
+		_, _ = hash.Write([]byte(file.Name()))
+		_, _ = hash.Write(content)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func isConfigFile(file fs.DirEntry) bool {
+	return !file.IsDir() && (strings.HasSuffix(file.Name(), ".yaml") || strings.HasSuffix(file.Name(), ".yml"))
+}
@@ -0,0 +1,83 @@
+package publicdashboards
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+const (
+	brokenYaml          = "./testdata/test-configs/broken-yaml"
+	emptyFolder         = "./testdata/test-configs/empty_folder"
+	incorrectProperties = "./testdata/test-configs/incorrect-properties"
+	correctProperties   = "./testdata/test-configs/correct-properties"
+)
+
+func TestConfigReader(t *testing.T) {
+	t.Run("Broken yaml should return error", func(t *testing.T) {
+		reader := newConfigReader(log.New("test logger"))
+		_, err := reader.readConfig(brokenYaml)
+		require.Error(t, err)
+	})
+
+	t.Run("Skip invalid directory", func(t *testing.T) {
+		reader := newConfigReader(log.New("test logger"))
+		cfg, err := reader.readConfig(emptyFolder)
+		require.NoError(t, err)
+		require.Len(t, cfg, 0)
+	})
+
+	t.Run("Read incorrect properties", func(t *testing.T) {
+		reader := newConfigReader(log.New("test logger"))
+		_, err := reader.readConfig(incorrectProperties)
+		require.Error(t, err)
+		require.Equal(t, "public dashboard item 1 in configuration doesn't contain required field dashboardUid\n"+
+			"public dashboard of dashboard home has an invalid access token\n"+
+			"public dashboard of dashboard overview has an invalid share type \"everyone\"", err.Error())
+	})
+
+	t.Run("Can read correct properties", func(t *testing.T) {
+		t.Setenv("STATUS_DASHBOARD_UID", "status")
+
+		reader := newConfigReader(log.New("test logger"))
+		cfg, err := reader.readConfig(correctProperties)
+		require.NoError(t, err)
+		require.Len(t, cfg, 1)
+
+		require.Equal(t, []*publicDashboardFromConfig{
+			{OrgID: 2, DashboardUID: "status", AccessToken: "e71950f4b5fc4a9d8a34a2d1b2d7e5e0", IsEnabled: true, TimeSelectionEnabled: true, Share: models.PublicShareType},
+			{OrgID: 0, OrgName: "Org 3", DashboardUID: "overview", IsEnabled: false, AnnotationsEnabled: true, Share: models.EmailShareType},
+			{OrgID: 1, DashboardUID: "home", IsEnabled: true, Share: models.PublicShareType},
+		}, cfg[0].PublicDashboards)
+		require.Equal(t, []*deletePublicDashboardFromConfig{
+			{OrgID: 2, DashboardUID: "retired"},
+		}, cfg[0].DeletePublicDashboards)
+	})
+}
+
+func TestConfigChecksum(t *testing.T) {
+	dir := t.TempDir()
+
+	empty, err := configChecksum(filepath.Join(dir, "missing"))
+	require.NoError(t, err)
+	require.Empty(t, empty)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public-dashboards.yaml"), []byte("publicDashboards: []"), 0o600))
+	first, err := configChecksum(dir)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o600))
+	unchanged, err := configChecksum(dir)
+	require.NoError(t, err)
+	require.Equal(t, first, unchanged, "only provisioning files are checked")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "public-dashboards.yaml"), []byte("publicDashboards:\n  - dashboardUid: home"), 0o600))
+	changed, err := configChecksum(dir)
+	require.NoError(t, err)
+	require.NotEqual(t, first, changed)
+}
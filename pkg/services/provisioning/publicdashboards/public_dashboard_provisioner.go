@@ -0,0 +1,216 @@
+package publicdashboards
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+// pollInterval is how often the provisioning files are checked for changes
+const pollInterval = 10 * time.Second
+
+// Provision scans a directory for provisioning config files
+// and provisions the public dashboards in those files.
+func Provision(ctx context.Context, configDirectory string, publicDashboardService publicdashboards.Service, orgService org.Service) error {
+	return newPublicDashboardProvisioner(publicDashboardService, orgService).applyChanges(ctx, configDirectory)
+}
+
+// PollChanges provisions the public dashboards again whenever the config files of the directory change, until the
+// context is cancelled
+func PollChanges(ctx context.Context, configDirectory string, publicDashboardService publicdashboards.Service, orgService org.Service) {
+	newPublicDashboardProvisioner(publicDashboardService, orgService).pollChanges(ctx, configDirectory, pollInterval)
+}
+
+func newPublicDashboardProvisioner(publicDashboardService publicdashboards.Service, orgService org.Service) *PublicDashboardProvisioner {
+	logger := log.New("provisioning.publicdashboards")
+	return &PublicDashboardProvisioner{
+		log:                    logger,
+		cfgProvider:            newConfigReader(logger),
+		publicDashboardService: publicDashboardService,
+		orgService:             orgService,
+	}
+}
+
+// PublicDashboardProvisioner is responsible for provisioning public dashboards based on
+// configuration read by the `configReader`
+type PublicDashboardProvisioner struct {
+	log                    log.Logger
+	cfgProvider            configReader
+	publicDashboardService publicdashboards.Service
+	orgService             org.Service
+}
+
+func (pp *PublicDashboardProvisioner) apply(ctx context.Context, cfg *publicDashboardsAsConfig) error {
+	for _, pubdash := range cfg.DeletePublicDashboards {
+		orgID, err := pp.resolveOrgID(ctx, pubdash.OrgID, pubdash.OrgName)
+		if err != nil {
+			return err
+		}
+
+		existing, err := pp.findExisting(ctx, orgID, pubdash.DashboardUID)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			continue
+		}
+
+		pp.log.Info("Deleting public dashboard from configuration", "dashboardUid", pubdash.DashboardUID, "orgId", orgID)
+		if err := pp.publicDashboardService.Delete(ctx, existing.Uid, pubdash.DashboardUID); err != nil {
+			return fmt.Errorf("%w: %s", err, pubdash.DashboardUID)
+		}
+	}
+
+	for _, pubdash := range cfg.PublicDashboards {
+		orgID, err := pp.resolveOrgID(ctx, pubdash.OrgID, pubdash.OrgName)
+		if err != nil {
+			return err
+		}
+
+		existing, err := pp.findExisting(ctx, orgID, pubdash.DashboardUID)
+		if err != nil {
+			return err
+		}
+
+		// the access token of a public dashboard can't be changed, the public dashboard is created again with the
+		// fixed one
+		if existing != nil && pubdash.AccessToken != "" && existing.AccessToken != pubdash.AccessToken {
+			pp.log.Info("Deleting public dashboard to change its access token", "dashboardUid", pubdash.DashboardUID, "orgId", orgID)
+			if err := pp.publicDashboardService.Delete(ctx, existing.Uid, pubdash.DashboardUID); err != nil {
+				return fmt.Errorf("%w: %s", err, pubdash.DashboardUID)
+			}
+			existing = nil
+		}
+
+		u := provisionerUser(orgID)
+		dto := &models.SavePublicDashboardDTO{
+			DashboardUid: pubdash.DashboardUID,
+			OrgID:        orgID,
+			UserId:       u.UserID,
+			PublicDashboard: &models.PublicDashboardDTO{
+				IsEnabled:            &pubdash.IsEnabled,
+				TimeSelectionEnabled: &pubdash.TimeSelectionEnabled,
+				AnnotationsEnabled:   &pubdash.AnnotationsEnabled,
+				Share:                pubdash.Share,
+			},
+		}
+
+		if existing == nil {
+			pp.log.Info("Creating public dashboard from configuration", "dashboardUid", pubdash.DashboardUID, "orgId", orgID, "enabled", pubdash.IsEnabled)
+			dto.PublicDashboard.AccessToken = pubdash.AccessToken
+			if _, err := pp.publicDashboardService.Create(ctx, u, dto); err != nil {
+				return fmt.Errorf("%w: %s", err, pubdash.DashboardUID)
+			}
+			continue
+		}
+
+		pp.log.Info("Updating public dashboard from configuration", "dashboardUid", pubdash.DashboardUID, "orgId", orgID, "enabled", pubdash.IsEnabled)
+		dto.Uid = existing.Uid
+		if _, err := pp.publicDashboardService.Update(ctx, u, dto); err != nil {
+			return fmt.Errorf("%w: %s", err, pubdash.DashboardUID)
+		}
+	}
+
+	return nil
+}
+
+func (pp *PublicDashboardProvisioner) applyChanges(ctx context.Context, configPath string) error {
+	configs, err := pp.cfgProvider.readConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	for _, cfg := range configs {
+		if err := pp.apply(ctx, cfg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (pp *PublicDashboardProvisioner) pollChanges(ctx context.Context, configPath string, interval time.Duration) {
+	checksum, err := configChecksum(configPath)
+	if err != nil {
+		pp.log.Warn("Failed to read public dashboard provisioning files", "path", configPath, "error", err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			current, err := configChecksum(configPath)
+			if err != nil {
+				pp.log.Warn("Failed to read public dashboard provisioning files", "path", configPath, "error", err)
+				continue
+			}
+			if current == checksum {
+				continue
+			}
+			checksum = current
+
+			pp.log.Info("Public dashboard provisioning files changed", "path", configPath)
+			if err := pp.applyChanges(ctx, configPath); err != nil {
+				pp.log.Error("Failed to provision public dashboards", "error", err)
+			}
+		}
+	}
+}
+
+func (pp *PublicDashboardProvisioner) findExisting(ctx context.Context, orgID int64, dashboardUID string) (*models.PublicDashboard, error) {
+	existing, err := pp.publicDashboardService.FindByDashboardUid(ctx, orgID, dashboardUID)
+	if errors.Is(err, models.ErrPublicDashboardNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, dashboardUID)
+	}
+	return existing, nil
+}
+
+func (pp *PublicDashboardProvisioner) resolveOrgID(ctx context.Context, orgID int64, orgName string) (int64, error) {
+	if orgID == 0 && orgName != "" {
+		res, err := pp.orgService.GetByName(ctx, &org.GetOrgByNameQuery{Name: orgName})
+		if err != nil {
+			return 0, err
+		}
+		return res.ID, nil
+	}
+	if orgID < 1 {
+		return 1, nil
+	}
+	return orgID, nil
+}
+
+// provisionerUser is allowed to share the dashboards of the org with every setting, provisioned public dashboards
+// aren't limited by the permissions of a user
+func provisionerUser(orgID int64) *user.SignedInUser {
+	return &user.SignedInUser{
+		OrgID:   orgID,
+		OrgRole: org.RoleAdmin,
+		Login:   "grafana_public_dashboard_provisioner",
+		Permissions: map[int64]map[string][]string{
+			orgID: accesscontrol.GroupScopesByAction([]accesscontrol.Permission{
+				{Action: dashboards.ActionDashboardsPublicWrite, Scope: dashboards.ScopeDashboardsAll},
+				{Action: dashboards.ActionDashboardsPublicShare, Scope: dashboards.ScopeDashboardsAll},
+				{Action: dashboards.ActionDashboardsPublicTimeSelectionWrite, Scope: dashboards.ScopeDashboardsAll},
+				{Action: dashboards.ActionDashboardsPublicAnnotationsWrite, Scope: dashboards.ScopeDashboardsAll},
+				{Action: dashboards.ActionDashboardsPublicVariableOverridesWrite, Scope: dashboards.ScopeDashboardsAll},
+				{Action: dashboards.ActionDashboardsPublicExpirationWrite, Scope: dashboards.ScopeDashboardsAll},
+			}),
+		},
+	}
+}
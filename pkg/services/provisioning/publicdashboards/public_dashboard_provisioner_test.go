@@ -0,0 +1,129 @@
+package publicdashboards
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/org/orgtest"
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+func TestPublicDashboardProvisioner(t *testing.T) {
+	t.Run("Should return error when config reader returns error", func(t *testing.T) {
+		expectedErr := errors.New("test")
+		pp := PublicDashboardProvisioner{log: log.New("test"), cfgProvider: &testConfigReader{err: expectedErr}}
+		err := pp.applyChanges(context.Background(), "")
+		require.Equal(t, expectedErr, err)
+	})
+
+	t.Run("Should create missing public dashboards with the fixed access token", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("FindByDashboardUid", mock.Anything, int64(2), "status").
+			Return(nil, models.ErrPublicDashboardNotFound.Errorf("not found"))
+		service.On("Create", mock.Anything, mock.MatchedBy(func(u *user.SignedInUser) bool { return u.OrgID == 2 }), mock.MatchedBy(func(dto *models.SavePublicDashboardDTO) bool {
+			return dto.OrgID == 2 && dto.DashboardUid == "status" && dto.PublicDashboard.AccessToken == "e71950f4b5fc4a9d8a34a2d1b2d7e5e0" &&
+				*dto.PublicDashboard.IsEnabled && *dto.PublicDashboard.TimeSelectionEnabled && !*dto.PublicDashboard.AnnotationsEnabled
+		})).Return(&models.PublicDashboard{Uid: "pubdash1"}, nil)
+
+		pp := newTestProvisioner(service, &publicDashboardsAsConfig{
+			PublicDashboards: []*publicDashboardFromConfig{
+				{OrgID: 2, DashboardUID: "status", AccessToken: "e71950f4b5fc4a9d8a34a2d1b2d7e5e0", IsEnabled: true, TimeSelectionEnabled: true, Share: models.PublicShareType},
+			},
+		})
+		require.NoError(t, pp.applyChanges(context.Background(), ""))
+	})
+
+	t.Run("Should update existing public dashboards of orgs found by name", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("FindByDashboardUid", mock.Anything, int64(4), "overview").
+			Return(&models.PublicDashboard{Uid: "pubdash1", AccessToken: "e71950f4b5fc4a9d8a34a2d1b2d7e5e0"}, nil)
+		service.On("Update", mock.Anything, mock.Anything, mock.MatchedBy(func(dto *models.SavePublicDashboardDTO) bool {
+			return dto.Uid == "pubdash1" && dto.OrgID == 4 && !*dto.PublicDashboard.IsEnabled && dto.PublicDashboard.Share == models.EmailShareType
+		})).Return(&models.PublicDashboard{Uid: "pubdash1"}, nil)
+
+		pp := newTestProvisioner(service, &publicDashboardsAsConfig{
+			PublicDashboards: []*publicDashboardFromConfig{
+				{OrgName: "Org 4", DashboardUID: "overview", AccessToken: "e71950f4b5fc4a9d8a34a2d1b2d7e5e0", IsEnabled: false, Share: models.EmailShareType},
+			},
+		})
+		require.NoError(t, pp.applyChanges(context.Background(), ""))
+	})
+
+	t.Run("Should create public dashboards again to change their access token", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("FindByDashboardUid", mock.Anything, int64(1), "home").
+			Return(&models.PublicDashboard{Uid: "pubdash1", AccessToken: "a3b1e6f5c7d84b2e9f0a1c2d3e4f5a6b"}, nil)
+		service.On("Delete", mock.Anything, "pubdash1", "home").Return(nil)
+		service.On("Create", mock.Anything, mock.Anything, mock.MatchedBy(func(dto *models.SavePublicDashboardDTO) bool {
+			return dto.Uid == "" && dto.PublicDashboard.AccessToken == "e71950f4b5fc4a9d8a34a2d1b2d7e5e0"
+		})).Return(&models.PublicDashboard{Uid: "pubdash2"}, nil)
+
+		pp := newTestProvisioner(service, &publicDashboardsAsConfig{
+			PublicDashboards: []*publicDashboardFromConfig{
+				{OrgID: 1, DashboardUID: "home", AccessToken: "e71950f4b5fc4a9d8a34a2d1b2d7e5e0", IsEnabled: true, Share: models.PublicShareType},
+			},
+		})
+		require.NoError(t, pp.applyChanges(context.Background(), ""))
+	})
+
+	t.Run("Should delete public dashboards that exist", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("FindByDashboardUid", mock.Anything, int64(1), "retired").
+			Return(&models.PublicDashboard{Uid: "pubdash1"}, nil)
+		service.On("FindByDashboardUid", mock.Anything, int64(1), "gone").
+			Return(nil, models.ErrPublicDashboardNotFound.Errorf("not found"))
+		service.On("Delete", mock.Anything, "pubdash1", "retired").Return(nil)
+
+		pp := newTestProvisioner(service, &publicDashboardsAsConfig{
+			DeletePublicDashboards: []*deletePublicDashboardFromConfig{
+				{OrgID: 1, DashboardUID: "retired"},
+				{OrgID: 1, DashboardUID: "gone"},
+			},
+		})
+		require.NoError(t, pp.applyChanges(context.Background(), ""))
+		service.AssertNumberOfCalls(t, "Delete", 1)
+	})
+
+	t.Run("Should return the errors of the public dashboard service", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("FindByDashboardUid", mock.Anything, int64(1), "home").
+			Return(nil, models.ErrPublicDashboardNotFound.Errorf("not found"))
+		service.On("Create", mock.Anything, mock.Anything, mock.Anything).
+			Return(nil, models.ErrDashboardNotFound.Errorf("dashboard not found"))
+
+		pp := newTestProvisioner(service, &publicDashboardsAsConfig{
+			PublicDashboards: []*publicDashboardFromConfig{
+				{OrgID: 1, DashboardUID: "home", IsEnabled: true, Share: models.PublicShareType},
+			},
+		})
+		require.ErrorIs(t, pp.applyChanges(context.Background(), ""), models.ErrDashboardNotFound)
+	})
+}
+
+func newTestProvisioner(service publicdashboards.Service, cfg *publicDashboardsAsConfig) *PublicDashboardProvisioner {
+	orgService := orgtest.NewOrgServiceFake()
+	orgService.ExpectedOrg = &org.Org{ID: 4}
+	return &PublicDashboardProvisioner{
+		log:                    log.New("test"),
+		cfgProvider:            &testConfigReader{result: []*publicDashboardsAsConfig{cfg}},
+		publicDashboardService: service,
+		orgService:             orgService,
+	}
+}
+
+type testConfigReader struct {
+	result []*publicDashboardsAsConfig
+	err    error
+}
+
+func (tcr *testConfigReader) readConfig(path string) ([]*publicDashboardsAsConfig, error) {
+	return tcr.result, tcr.err
+}
@@ -0,0 +1,90 @@
+package publicdashboards
+
+import (
+	"github.com/grafana/grafana/pkg/services/provisioning/values"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+// publicDashboardsAsConfig is a normalized data object for public dashboards config data. Any config version should
+// be mappable to this type.
+type publicDashboardsAsConfig struct {
+	PublicDashboards       []*publicDashboardFromConfig
+	DeletePublicDashboards []*deletePublicDashboardFromConfig
+}
+
+type publicDashboardFromConfig struct {
+	OrgID                int64
+	OrgName              string
+	DashboardUID         string
+	AccessToken          string
+	IsEnabled            bool
+	TimeSelectionEnabled bool
+	AnnotationsEnabled   bool
+	Share                models.ShareType
+}
+
+type deletePublicDashboardFromConfig struct {
+	OrgID        int64
+	OrgName      string
+	DashboardUID string
+}
+
+type publicDashboardFromConfigV0 struct {
+	OrgID                values.Int64Value  `json:"orgId" yaml:"orgId"`
+	OrgName              values.StringValue `json:"orgName" yaml:"orgName"`
+	DashboardUID         values.StringValue `json:"dashboardUid" yaml:"dashboardUid"`
+	AccessToken          values.StringValue `json:"accessToken" yaml:"accessToken"`
+	Disabled             values.BoolValue   `json:"disabled" yaml:"disabled"`
+	TimeSelectionEnabled values.BoolValue   `json:"timeSelectionEnabled" yaml:"timeSelectionEnabled"`
+	AnnotationsEnabled   values.BoolValue   `json:"annotationsEnabled" yaml:"annotationsEnabled"`
+	Share                values.StringValue `json:"share" yaml:"share"`
+}
+
+type deletePublicDashboardFromConfigV0 struct {
+	OrgID        values.Int64Value  `json:"orgId" yaml:"orgId"`
+	OrgName      values.StringValue `json:"orgName" yaml:"orgName"`
+	DashboardUID values.StringValue `json:"dashboardUid" yaml:"dashboardUid"`
+}
+
+// publicDashboardsAsConfigV0 is a mapping for zero version configs. This is mapped to its normalised version.
+type publicDashboardsAsConfigV0 struct {
+	PublicDashboards       []*publicDashboardFromConfigV0       `json:"publicDashboards" yaml:"publicDashboards"`
+	DeletePublicDashboards []*deletePublicDashboardFromConfigV0 `json:"deletePublicDashboards" yaml:"deletePublicDashboards"`
+}
+
+// mapToPublicDashboardsFromConfig maps config syntax to a normalized publicDashboardsAsConfig object. Every version
+// of the config syntax should have this function.
+func (cfg *publicDashboardsAsConfigV0) mapToPublicDashboardsFromConfig() *publicDashboardsAsConfig {
+	r := &publicDashboardsAsConfig{}
+	if cfg == nil {
+		return r
+	}
+
+	for _, pubdash := range cfg.PublicDashboards {
+		share := models.ShareType(pubdash.Share.Value())
+		if share == "" {
+			share = models.PublicShareType
+		}
+
+		r.PublicDashboards = append(r.PublicDashboards, &publicDashboardFromConfig{
+			OrgID:                pubdash.OrgID.Value(),
+			OrgName:              pubdash.OrgName.Value(),
+			DashboardUID:         pubdash.DashboardUID.Value(),
+			AccessToken:          pubdash.AccessToken.Value(),
+			IsEnabled:            !pubdash.Disabled.Value(),
+			TimeSelectionEnabled: pubdash.TimeSelectionEnabled.Value(),
+			AnnotationsEnabled:   pubdash.AnnotationsEnabled.Value(),
+			Share:                share,
+		})
+	}
+
+	for _, pubdash := range cfg.DeletePublicDashboards {
+		r.DeletePublicDashboards = append(r.DeletePublicDashboards, &deletePublicDashboardFromConfig{
+			OrgID:        pubdash.OrgID.Value(),
+			OrgName:      pubdash.OrgName.Value(),
+			DashboardUID: pubdash.DashboardUID.Value(),
+		})
+	}
+
+	return r
+}
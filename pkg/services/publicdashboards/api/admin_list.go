@@ -0,0 +1,73 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+// swagger:route GET /admin/public-dashboards dashboards dashboard_public listPublicDashboardsAcrossOrgs
+//
+//	Get list of the public dashboards of every organization
+//
+// Lists the public dashboards of every organization with their organization, dashboard, enabled state and when they
+// were last viewed, to audit what an instance shares. The list can be filtered by organization and enabled state and
+// is sorted by organization then uid. Only Grafana admins can list them.
+//
+// Produces:
+// - application/json
+//
+// Responses:
+// 200: listPublicDashboardsAcrossOrgsResponse
+// 400: badRequestPublicError
+// 401: unauthorisedPublicError
+// 403: forbiddenPublicError
+// 500: internalServerPublicError
+func (api *Api) ListPublicDashboardsAcrossOrgs(c *contextmodel.ReqContext) response.Response {
+	perPage := c.QueryInt("perPage")
+	if perPage <= 0 {
+		perPage = 1000
+	}
+
+	page := c.QueryInt("page")
+	if page < 1 {
+		page = 1
+	}
+
+	isEnabled, err := queryBool(c, "isEnabled")
+	if err != nil {
+		return response.Err(ErrBadRequest.Errorf("ListPublicDashboardsAcrossOrgs: invalid isEnabled filter: %v", err))
+	}
+
+	resp, err := api.PublicDashboardService.FindAllAcrossOrgsWithPagination(c.Req.Context(), &AdminPublicDashboardListQuery{
+		OrgID:     c.QueryInt64("orgId"),
+		IsEnabled: isEnabled,
+		Page:      page,
+		Limit:     perPage,
+	})
+	if err != nil {
+		return response.Err(err)
+	}
+
+	return response.JSON(http.StatusOK, resp)
+}
+
+// swagger:parameters listPublicDashboardsAcrossOrgs
+type ListPublicDashboardsAcrossOrgsParams struct {
+	// in:query
+	Page int `json:"page"`
+	// in:query
+	PerPage int `json:"perPage"`
+	// in:query
+	OrgId int64 `json:"orgId"`
+	// in:query
+	IsEnabled *bool `json:"isEnabled"`
+}
+
+// swagger:response listPublicDashboardsAcrossOrgsResponse
+type ListPublicDashboardsAcrossOrgsResponse struct {
+	// in: body
+	Body AdminPublicDashboardListResponseWithPagination `json:"body"`
+}
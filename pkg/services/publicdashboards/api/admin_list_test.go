@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+func TestAPIListPublicDashboardsAcrossOrgs(t *testing.T) {
+	path := "/api/admin/public-dashboards"
+	grafanaAdmin := &user.SignedInUser{UserID: 1, OrgID: 1, OrgRole: org.RoleAdmin, Login: "admin", IsGrafanaAdmin: true}
+
+	t.Run("Returns the public dashboards of every org", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("FindAllAcrossOrgsWithPagination", mock.Anything, mock.MatchedBy(func(query *AdminPublicDashboardListQuery) bool {
+			return query.OrgID == 2 && query.IsEnabled != nil && *query.IsEnabled && query.Page == 2 && query.Limit == 10
+		})).Return(&AdminPublicDashboardListResponseWithPagination{
+			PublicDashboards: []*AdminPublicDashboardListResponse{{Uid: "pubdash1", OrgId: 2, OrgName: "Org 2", DashboardUid: "dash1", Title: "Dashboard", IsEnabled: true}},
+			TotalCount:       11,
+			Page:             2,
+			PerPage:          10,
+		}, nil)
+		server := setupTestServer(t, nil, service, grafanaAdmin)
+
+		resp := callAPI(server, http.MethodGet, path+"?orgId=2&isEnabled=true&page=2&perPage=10", nil, t)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"orgName":"Org 2"`)
+		assert.Contains(t, resp.Body.String(), `"totalCount":11`)
+	})
+
+	t.Run("Status code is 400 when isEnabled isn't a boolean", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		server := setupTestServer(t, nil, service, grafanaAdmin)
+
+		resp := callAPI(server, http.MethodGet, path+"?isEnabled=maybe", nil, t)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		service.AssertNotCalled(t, "FindAllAcrossOrgsWithPagination")
+	})
+
+	t.Run("Status code is 403 for org admins", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		server := setupTestServer(t, nil, service, userAdmin)
+
+		resp := callAPI(server, http.MethodGet, path, nil, t)
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		service.AssertNotCalled(t, "FindAllAcrossOrgsWithPagination")
+	})
+}
@@ -2,19 +2,25 @@ package api
 
 import (
 	"context"
+	"encoding/json"
+	"io"
 	"net/http"
+	"sort"
+	"strconv"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/api/routing"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
 	"github.com/grafana/grafana/pkg/middleware"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/licensing"
+	"github.com/grafana/grafana/pkg/services/live"
 	"github.com/grafana/grafana/pkg/services/publicdashboards"
 	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
 	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
@@ -25,11 +31,16 @@ import (
 type Api struct {
 	PublicDashboardService publicdashboards.Service
 	Middleware             publicdashboards.Middleware
+	GeoIPProvider          publicdashboards.GeoIPProvider
+	RateLimiter            *RequestRateLimiter
+	BotDetector            *BotDetector
+	ClientIPs              *ClientIPResolver
 
 	accessControl accesscontrol.AccessControl
 	cfg           *setting.Cfg
 	features      featuremgmt.FeatureToggles
 	license       licensing.Licensing
+	live          *live.GrafanaLive
 	log           log.Logger
 	routeRegister routing.RouteRegister
 }
@@ -42,14 +53,22 @@ func ProvideApi(
 	md publicdashboards.Middleware,
 	cfg *setting.Cfg,
 	license licensing.Licensing,
+	geoIP publicdashboards.GeoIPProvider,
+	liveService *live.GrafanaLive,
+	remoteCache remotecache.CacheStorage,
 ) *Api {
 	api := &Api{
 		PublicDashboardService: pd,
 		Middleware:             md,
+		GeoIPProvider:          geoIP,
+		RateLimiter:            NewRequestRateLimiter(cfg, remoteCache),
+		BotDetector:            NewBotDetector(cfg),
+		ClientIPs:              NewClientIPResolver(cfg),
 		accessControl:          ac,
 		cfg:                    cfg,
 		features:               features,
 		license:                license,
+		live:                   liveService,
 		log:                    log.New("publicdashboards.api"),
 		routeRegister:          rr,
 	}
@@ -69,11 +88,29 @@ func (api *Api) RegisterAPIEndpoints() {
 	// because it is deeply dependent on the HTTPServer.Index() method and would result in a
 	// circular dependency
 	api.routeRegister.Group("/api/public/dashboards/:accessToken", func(apiRoute routing.RouteRegister) {
+		// registered before the view route, which would answer HEAD requests otherwise
+		apiRoute.Head("/", routing.Wrap(api.CheckPublicDashboardAccessToken))
 		apiRoute.Get("/", routing.Wrap(api.ViewPublicDashboard))
-		apiRoute.Get("/annotations", routing.Wrap(api.GetPublicAnnotations))
-		apiRoute.Post("/panels/:panelId/query", routing.Wrap(api.QueryPublicDashboard))
-		apiRoute.Post("/variables/:variableName/query", routing.Wrap(api.QueryPublicDashboardVariable))
-	}, api.Middleware.HandleApi)
+		apiRoute.Get("/annotations", RequiresViewerToken(api.PublicDashboardService, api.ClientIPs), routing.Wrap(api.GetPublicAnnotations))
+		apiRoute.Get("/panels/:panelId/query", RequiresViewerToken(api.PublicDashboardService, api.ClientIPs), routing.Wrap(api.QueryPublicDashboardWithParams))
+		apiRoute.Get("/panels/:panelId/query/stream", RequiresViewerToken(api.PublicDashboardService, api.ClientIPs), routing.Wrap(api.StreamPublicDashboardQuery))
+		apiRoute.Post("/query", RequiresViewerToken(api.PublicDashboardService, api.ClientIPs), routing.Wrap(api.QueryPublicDashboardPanels))
+		apiRoute.Post("/panels/:panelId/query", RequiresViewerToken(api.PublicDashboardService, api.ClientIPs), routing.Wrap(api.QueryPublicDashboard))
+		apiRoute.Post("/panels/:panelId/content", RequiresViewerToken(api.PublicDashboardService, api.ClientIPs), routing.Wrap(api.GetPublicDashboardPanelContent))
+		apiRoute.Get("/panels/:panelId/export/csv", RequiresViewerToken(api.PublicDashboardService, api.ClientIPs), routing.Wrap(api.ExportPublicDashboardPanelCSV))
+		apiRoute.Get("/panels/:panelId/export/xlsx", RequiresViewerToken(api.PublicDashboardService, api.ClientIPs), routing.Wrap(api.ExportPublicDashboardPanelXLSX))
+		apiRoute.Get("/panels/:panelId/render", RequiresViewerToken(api.PublicDashboardService, api.ClientIPs), routing.Wrap(api.RenderPublicDashboardPanel))
+		apiRoute.Get("/export/pdf", RequiresViewerToken(api.PublicDashboardService, api.ClientIPs), routing.Wrap(api.ExportPublicDashboardPDF))
+		apiRoute.Get("/metadata", RequiresViewerToken(api.PublicDashboardService, api.ClientIPs), routing.Wrap(api.GetPublicDashboardMetadata))
+		apiRoute.Get("/variables", RequiresViewerToken(api.PublicDashboardService, api.ClientIPs), routing.Wrap(api.ListPublicDashboardVariables))
+		apiRoute.Post("/variables/:variableName/query", RequiresViewerToken(api.PublicDashboardService, api.ClientIPs), routing.Wrap(api.QueryPublicDashboardVariable))
+		apiRoute.Post("/heartbeat", routing.Wrap(api.PublicDashboardHeartbeat))
+		apiRoute.Get("/viewer-challenge", routing.Wrap(api.GetPublicDashboardViewerChallenge))
+		apiRoute.Post("/viewer-token", routing.Wrap(api.IssuePublicDashboardViewerToken))
+		if api.live != nil {
+			apiRoute.Get("/live/ws", RequiresExistingAccessToken(api.PublicDashboardService), RequiresViewerToken(api.PublicDashboardService, api.ClientIPs), SetPublicDashboardOrgIdOnContext(api.PublicDashboardService), api.PublicDashboardLiveWebsocket)
+		}
+	}, RequiresBotCheck(api.BotDetector, api.PublicDashboardService, api.ClientIPs), RequiresRateLimit(api.RateLimiter, api.ClientIPs), api.Middleware.HandleApi, RequiresAllowedCountry(api.PublicDashboardService, api.GeoIPProvider), RequiresAllowedDomain(api.PublicDashboardService))
 
 	// Auth endpoints
 	auth := accesscontrol.Middleware(api.accessControl)
@@ -91,28 +128,96 @@ func (api *Api) RegisterAPIEndpoints() {
 		auth(accesscontrol.EvalPermission(dashboards.ActionDashboardsPublicWrite, uidScope)),
 		routing.Wrap(api.CreatePublicDashboard))
 
+	// Create or update the public dashboard of a dashboard
+	api.routeRegister.Put("/api/dashboards/uid/:dashboardUid/public-dashboards",
+		auth(accesscontrol.EvalPermission(dashboards.ActionDashboardsPublicWrite, uidScope)),
+		routing.Wrap(api.UpsertPublicDashboard))
+
 	// Update Public Dashboard
 	api.routeRegister.Patch("/api/dashboards/uid/:dashboardUid/public-dashboards/:uid",
 		auth(accesscontrol.EvalPermission(dashboards.ActionDashboardsPublicWrite, uidScope)),
 		routing.Wrap(api.UpdatePublicDashboard))
 
+	// Patch the config of a public dashboard
+	api.routeRegister.Patch("/api/dashboards/uid/:dashboardUid/public-dashboards/:uid/config",
+		auth(accesscontrol.EvalPermission(dashboards.ActionDashboardsPublicWrite, uidScope)),
+		routing.Wrap(api.PatchPublicDashboard))
+
+	// Get usage statistics of public dashboard
+	api.routeRegister.Get("/api/dashboards/uid/:dashboardUid/public-dashboards/:uid/stats",
+		auth(accesscontrol.EvalPermission(dashboards.ActionDashboardsRead, uidScope)),
+		routing.Wrap(api.GetPublicDashboardStats))
+
+	// Get the audit log of public dashboard
+	api.routeRegister.Get("/api/dashboards/uid/:dashboardUid/public-dashboards/:uid/audit-log",
+		auth(accesscontrol.EvalPermission(dashboards.ActionDashboardsPublicWrite, uidScope)),
+		routing.Wrap(api.GetPublicDashboardAuditLog))
+
+	// Check the health of the datasources of a public dashboard
+	api.routeRegister.Get("/api/dashboards/uid/:dashboardUid/public-dashboards/:uid/health",
+		auth(accesscontrol.EvalPermission(dashboards.ActionDashboardsPublicWrite, uidScope)),
+		routing.Wrap(api.CheckPublicDashboardHealth))
+
+	// Inspect the queries of a panel of a public dashboard
+	api.routeRegister.Post("/api/dashboards/uid/:dashboardUid/public-dashboards/:uid/panels/:panelId/inspect",
+		auth(accesscontrol.EvalPermission(dashboards.ActionDashboardsPublicWrite, uidScope)),
+		routing.Wrap(api.InspectPublicDashboardQuery))
+
+	// Rotate the access token of a public dashboard
+	api.routeRegister.Post("/api/dashboards/uid/:dashboardUid/public-dashboards/:uid/rotate-token",
+		auth(accesscontrol.EvalPermission(dashboards.ActionDashboardsPublicWrite, uidScope)),
+		routing.Wrap(api.RotatePublicDashboardAccessToken))
+
+	// Export the share config of a public dashboard
+	api.routeRegister.Get("/api/dashboards/uid/:dashboardUid/public-dashboards/:uid/export",
+		auth(accesscontrol.EvalPermission(dashboards.ActionDashboardsPublicWrite, uidScope)),
+		routing.Wrap(api.ExportPublicDashboardConfig))
+
+	// Import the share config of a public dashboard exported from another dashboard, org or instance
+	api.routeRegister.Post("/api/dashboards/uid/:dashboardUid/public-dashboards/import",
+		auth(accesscontrol.EvalPermission(dashboards.ActionDashboardsPublicWrite, uidScope)),
+		routing.Wrap(api.ImportPublicDashboardConfig))
+
+	// Revoke a public dashboard, invalidating the caches and sessions of its viewers
+	api.routeRegister.Post("/api/dashboards/uid/:dashboardUid/public-dashboards/:uid/revoke",
+		auth(accesscontrol.EvalPermission(dashboards.ActionDashboardsPublicWrite, uidScope)),
+		routing.Wrap(api.RevokePublicDashboard))
+
 	// Delete Public dashboard
 	api.routeRegister.Delete("/api/dashboards/uid/:dashboardUid/public-dashboards/:uid",
 		auth(accesscontrol.EvalPermission(dashboards.ActionDashboardsPublicWrite, uidScope)),
 		routing.Wrap(api.DeletePublicDashboard))
+
+	// List the public dashboards of every org
+	api.routeRegister.Get("/api/admin/public-dashboards", middleware.ReqGrafanaAdmin,
+		routing.Wrap(api.ListPublicDashboardsAcrossOrgs))
+
+	// Delete the public dashboards of every org whose dashboards were deleted
+	api.routeRegister.Post("/api/admin/public-dashboards/cleanup-orphaned", middleware.ReqGrafanaAdmin,
+		routing.Wrap(api.CleanupOrphanedPublicDashboards))
 }
 
 // swagger:route GET /dashboards/public-dashboards dashboards dashboard_public listPublicDashboards
 //
 //	Get list of public dashboards
 //
+// The list can be filtered by a substring of the dashboard title, the folder of the dashboard and the config of the
+// public dashboards: whether they, their annotations, their time selection and their variables are enabled, and their
+// share type. It can be sorted by title, created, updated, lastViewed or views, in asc or desc direction, and is
+// sorted by title in ascending order by default. The total count is the number of public dashboards the user can see.
+//
 // Responses:
 // 200: listPublicDashboardsResponse
+// 400: badRequestPublicError
 // 401: unauthorisedPublicError
 // 403: forbiddenPublicError
 // 500: internalServerPublicError
 func (api *Api) ListPublicDashboards(c *contextmodel.ReqContext) response.Response {
-	perPage := c.QueryInt("perpage")
+	perPage := c.QueryInt("perPage")
+	if perPage <= 0 {
+		// perpage is kept for backwards compatibility
+		perPage = c.QueryInt("perpage")
+	}
 	if perPage <= 0 {
 		perPage = 1000
 	}
@@ -122,13 +227,37 @@ func (api *Api) ListPublicDashboards(c *contextmodel.ReqContext) response.Respon
 		page = 1
 	}
 
-	resp, err := api.PublicDashboardService.FindAllWithPagination(c.Req.Context(), &PublicDashboardListQuery{
-		OrgID: c.GetOrgID(),
-		Query: c.Query("query"),
-		Page:  page,
-		Limit: perPage,
-		User:  c.SignedInUser,
-	})
+	query := &PublicDashboardListQuery{
+		OrgID:     c.GetOrgID(),
+		Query:     c.Query("query"),
+		Page:      page,
+		Limit:     perPage,
+		Sort:      PublicDashboardListSort(c.Query("sort")),
+		Direction: SortDirection(c.Query("direction")),
+		FolderUID: c.Query("folderUid"),
+		Share:     ShareType(c.Query("share")),
+		User:      c.SignedInUser,
+	}
+
+	var err error
+	if query.IsEnabled, err = queryBool(c, "isEnabled"); err != nil {
+		return response.Err(ErrBadRequest.Errorf("ListPublicDashboards: invalid isEnabled filter: %v", err))
+	}
+	if query.AnnotationsEnabled, err = queryBool(c, "annotationsEnabled"); err != nil {
+		return response.Err(ErrBadRequest.Errorf("ListPublicDashboards: invalid annotationsEnabled filter: %v", err))
+	}
+	if query.TimeSelectionEnabled, err = queryBool(c, "timeSelectionEnabled"); err != nil {
+		return response.Err(ErrBadRequest.Errorf("ListPublicDashboards: invalid timeSelectionEnabled filter: %v", err))
+	}
+	if query.VariablesEnabled, err = queryBool(c, "variablesEnabled"); err != nil {
+		return response.Err(ErrBadRequest.Errorf("ListPublicDashboards: invalid variablesEnabled filter: %v", err))
+	}
+
+	if err = validation.ValidatePublicDashboardListQuery(query); err != nil {
+		return response.Err(err)
+	}
+
+	resp, err := api.PublicDashboardService.FindAllWithPagination(c.Req.Context(), query)
 
 	if err != nil {
 		return response.Err(err)
@@ -136,6 +265,20 @@ func (api *Api) ListPublicDashboards(c *contextmodel.ReqContext) response.Respon
 	return response.JSON(http.StatusOK, resp)
 }
 
+// queryBool returns the boolean value of a query param, or nil when it isn't set
+func queryBool(c *contextmodel.ReqContext, name string) (*bool, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
 // swagger:route GET /dashboards/uid/{dashboardUid}/public-dashboards dashboards dashboard_public getPublicDashboard
 //
 //	Get public dashboard by dashboardUid
@@ -220,6 +363,62 @@ func (api *Api) CreatePublicDashboard(c *contextmodel.ReqContext) response.Respo
 	return response.JSON(http.StatusOK, pd)
 }
 
+// swagger:route PUT /dashboards/uid/{dashboardUid}/public-dashboards dashboards dashboard_public upsertPublicDashboard
+//
+//	Create or update the public dashboard of a dashboard
+//
+// Creates the public dashboard of the dashboard, or updates it when the dashboard already has one, so the request can
+// be repeated by infrastructure as code tools. The uid and access token of the request are used to create the public
+// dashboard, they have to match the ones of the existing public dashboard otherwise.
+//
+// Produces:
+// - application/json
+//
+// Responses:
+// 200: upsertPublicDashboardResponse
+// 201: upsertPublicDashboardResponse
+// 400: badRequestPublicError
+// 401: unauthorisedPublicError
+// 403: forbiddenPublicError
+// 500: internalServerPublicError
+func (api *Api) UpsertPublicDashboard(c *contextmodel.ReqContext) response.Response {
+	dashboardUid := web.Params(c.Req)[":dashboardUid"]
+	if !validation.IsValidShortUID(dashboardUid) {
+		return response.Err(ErrInvalidUid.Errorf("UpsertPublicDashboard: invalid Uid %s", dashboardUid))
+	}
+
+	pdDTO := &PublicDashboardDTO{}
+	if err := web.Bind(c.Req, pdDTO); err != nil {
+		return response.Err(ErrBadRequest.Errorf("UpsertPublicDashboard: bad request data %v", err))
+	}
+
+	if pdDTO.Uid != "" && !validation.IsValidShortUID(pdDTO.Uid) {
+		return response.Err(ErrInvalidUid.Errorf("UpsertPublicDashboard: invalid Uid %s", pdDTO.Uid))
+	}
+
+	if pdDTO.AccessToken != "" && !validation.IsValidAccessToken(pdDTO.AccessToken) {
+		return response.Err(ErrInvalidAccessToken.Errorf("UpsertPublicDashboard: invalid Access Token %s", pdDTO.AccessToken))
+	}
+
+	// Always set the orgID and userID from the session
+	dto := &SavePublicDashboardDTO{
+		UserId:          c.UserID,
+		OrgID:           c.GetOrgID(),
+		DashboardUid:    dashboardUid,
+		PublicDashboard: pdDTO,
+	}
+
+	pd, created, err := api.PublicDashboardService.Upsert(c.Req.Context(), c.SignedInUser, dto)
+	if err != nil {
+		return response.Err(err)
+	}
+
+	if created {
+		return response.JSON(http.StatusCreated, pd)
+	}
+	return response.JSON(http.StatusOK, pd)
+}
+
 // swagger:route PATCH /dashboards/uid/{dashboardUid}/public-dashboards/{uid} dashboards dashboard_public updatePublicDashboard
 //
 //	Update public dashboard for a dashboard
@@ -268,6 +467,123 @@ func (api *Api) UpdatePublicDashboard(c *contextmodel.ReqContext) response.Respo
 	return response.JSON(http.StatusOK, pd)
 }
 
+// swagger:route POST /dashboards/uid/{dashboardUid}/public-dashboards/{uid}/rotate-token dashboards dashboard_public rotatePublicDashboardAccessToken
+//
+//	Rotate the access token of a public dashboard
+//
+// The previous access token keeps working for `gracePeriodSeconds`, at most 7 days, and stops working right away
+// without it.
+//
+// Produces:
+// - application/json
+//
+// Responses:
+// 200: rotatePublicDashboardAccessTokenResponse
+// 400: badRequestPublicError
+// 401: unauthorisedPublicError
+// 403: forbiddenPublicError
+// 404: notFoundPublicError
+// 409: conflictError
+// 500: internalServerPublicError
+func (api *Api) RotatePublicDashboardAccessToken(c *contextmodel.ReqContext) response.Response {
+	dashboardUid := web.Params(c.Req)[":dashboardUid"]
+	if !validation.IsValidShortUID(dashboardUid) {
+		return response.Err(ErrInvalidUid.Errorf("RotatePublicDashboardAccessToken: invalid dashboard Uid %s", dashboardUid))
+	}
+
+	uid := web.Params(c.Req)[":uid"]
+	if !validation.IsValidShortUID(uid) {
+		return response.Err(ErrInvalidUid.Errorf("RotatePublicDashboardAccessToken: invalid Uid %s", uid))
+	}
+
+	// the body is optional, the previous access token stops working right away without it
+	dto := RotateAccessTokenDTO{}
+	if c.Req.ContentLength != 0 {
+		if err := web.Bind(c.Req, &dto); err != nil {
+			return response.Err(ErrBadRequest.Errorf("RotatePublicDashboardAccessToken: bad request data %v", err))
+		}
+	}
+
+	pd, err := api.PublicDashboardService.RotateAccessToken(c.Req.Context(), c.SignedInUser, uid, dashboardUid, dto)
+	if err != nil {
+		return response.Err(err)
+	}
+
+	return response.JSON(http.StatusOK, pd)
+}
+
+// swagger:route PATCH /dashboards/uid/{dashboardUid}/public-dashboards/{uid}/config dashboards dashboard_public patchPublicDashboard
+//
+//	Patch the config of a public dashboard
+//
+// The body is a JSON merge patch: only the fields in the body are updated, and fields set to null are reset to their
+// default. Other fields are left untouched, so concurrent patches of different fields don't overwrite each other.
+//
+// Consumes:
+// - application/merge-patch+json
+// - application/json
+//
+// Produces:
+// - application/json
+//
+// Responses:
+// 200: patchPublicDashboardResponse
+// 400: badRequestPublicError
+// 401: unauthorisedPublicError
+// 403: forbiddenPublicError
+// 404: notFoundPublicError
+// 500: internalServerPublicError
+func (api *Api) PatchPublicDashboard(c *contextmodel.ReqContext) response.Response {
+	dashboardUid := web.Params(c.Req)[":dashboardUid"]
+	if !validation.IsValidShortUID(dashboardUid) {
+		return response.Err(ErrInvalidUid.Errorf("PatchPublicDashboard: invalid dashboard Uid %s", dashboardUid))
+	}
+
+	uid := web.Params(c.Req)[":uid"]
+	if !validation.IsValidShortUID(uid) {
+		return response.Err(ErrInvalidUid.Errorf("PatchPublicDashboard: invalid Uid %s", uid))
+	}
+
+	patch, err := readPublicDashboardPatch(c.Req.Body)
+	if err != nil {
+		return response.Err(ErrBadRequest.Errorf("PatchPublicDashboard: bad request data %v", err))
+	}
+
+	pd, err := api.PublicDashboardService.Patch(c.Req.Context(), c.SignedInUser, uid, dashboardUid, patch)
+	if err != nil {
+		return response.Err(err)
+	}
+
+	return response.JSON(http.StatusOK, pd)
+}
+
+// readPublicDashboardPatch reads a JSON merge patch of the config of a public dashboard. The fields of the patch are
+// the top level keys of the body, sorted to keep the order of the updated columns stable
+func readPublicDashboardPatch(body io.Reader) (PublicDashboardPatch, error) {
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return PublicDashboardPatch{}, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return PublicDashboardPatch{}, err
+	}
+
+	dto := &PublicDashboardDTO{}
+	if err := json.Unmarshal(raw, dto); err != nil {
+		return PublicDashboardPatch{}, err
+	}
+
+	patch := PublicDashboardPatch{PublicDashboard: dto}
+	for field := range fields {
+		patch.Fields = append(patch.Fields, field)
+	}
+	sort.Strings(patch.Fields)
+
+	return patch, nil
+}
+
 // swagger:route DELETE /dashboards/uid/{dashboardUid}/public-dashboards/{uid} dashboards dashboard_public deletePublicDashboard
 //
 //	Delete public dashboard for a dashboard
@@ -310,6 +626,36 @@ func toJsonStreamingResponse(ctx context.Context, features featuremgmt.FeatureTo
 	return response.JSONStreaming(statusCode, qdr)
 }
 
+// swagger:parameters listPublicDashboards
+type ListPublicDashboardsParams struct {
+	// in:query
+	Page int `json:"page"`
+	// in:query
+	PerPage int `json:"perPage"`
+	// in:query
+	// enum: title,created,updated,lastViewed,views
+	Sort string `json:"sort"`
+	// in:query
+	// enum: asc,desc
+	Direction string `json:"direction"`
+	// Substring of the dashboard title
+	// in:query
+	Query string `json:"query"`
+	// in:query
+	FolderUid string `json:"folderUid"`
+	// in:query
+	IsEnabled *bool `json:"isEnabled"`
+	// in:query
+	AnnotationsEnabled *bool `json:"annotationsEnabled"`
+	// in:query
+	TimeSelectionEnabled *bool `json:"timeSelectionEnabled"`
+	// in:query
+	VariablesEnabled *bool `json:"variablesEnabled"`
+	// in:query
+	// enum: public,email
+	Share string `json:"share"`
+}
+
 // swagger:response listPublicDashboardsResponse
 type ListPublicDashboardsResponse struct {
 	// in: body
@@ -344,6 +690,22 @@ type CreatePublicDashboardResponse struct {
 	Body PublicDashboard `json:"body"`
 }
 
+// swagger:parameters upsertPublicDashboard
+type UpsertPublicDashboardParams struct {
+	// in:path
+	// required:true
+	DashboardUid string `json:"dashboardUid"`
+	// in:body
+	// required:true
+	Body PublicDashboardDTO
+}
+
+// swagger:response upsertPublicDashboardResponse
+type UpsertPublicDashboardResponse struct {
+	// in: body
+	Body PublicDashboard `json:"body"`
+}
+
 // swagger:parameters updatePublicDashboard
 type UpdatePublicDashboardParams struct {
 	// in:path
@@ -363,6 +725,43 @@ type UpdatePublicDashboardResponse struct {
 	Body PublicDashboard `json:"body"`
 }
 
+// swagger:parameters rotatePublicDashboardAccessToken
+type RotatePublicDashboardAccessTokenParams struct {
+	// in:path
+	// required:true
+	DashboardUid string `json:"dashboardUid"`
+	// in:path
+	// required:true
+	Uid string `json:"uid"`
+	// in:body
+	Body RotateAccessTokenDTO
+}
+
+// swagger:response rotatePublicDashboardAccessTokenResponse
+type RotatePublicDashboardAccessTokenResponse struct {
+	// in: body
+	Body PublicDashboard `json:"body"`
+}
+
+// swagger:parameters patchPublicDashboard
+type PatchPublicDashboardParams struct {
+	// in:path
+	// required:true
+	DashboardUid string `json:"dashboardUid"`
+	// in:path
+	// required:true
+	Uid string `json:"uid"`
+	// in:body
+	// required:true
+	Body PublicDashboardDTO
+}
+
+// swagger:response patchPublicDashboardResponse
+type PatchPublicDashboardResponse struct {
+	// in: body
+	Body PublicDashboard `json:"body"`
+}
+
 // swagger:parameters deletePublicDashboard
 type DeletePublicDashboardParams struct {
 	// in:path
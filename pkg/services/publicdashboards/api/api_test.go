@@ -151,6 +151,72 @@ func TestAPIListPublicDashboard(t *testing.T) {
 			}
 		})
 	}
+
+	t.Run("Passes pagination and sort params to the service", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("FindAllWithPagination", mock.Anything, mock.MatchedBy(func(query *PublicDashboardListQuery) bool {
+			return query.Page == 2 && query.Limit == 10 && query.Sort == PublicDashboardListSortLastViewed && query.Direction == SortDirectionDesc
+		})).Return(successResp, nil)
+
+		testServer := setupTestServer(t, nil, service, userViewer)
+
+		response := callAPI(testServer, http.MethodGet, "/api/dashboards/public-dashboards?page=2&perPage=10&sort=lastViewed&direction=desc", nil, t)
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Passes filters to the service", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("FindAllWithPagination", mock.Anything, mock.MatchedBy(func(query *PublicDashboardListQuery) bool {
+			return query.Query == "sales" && query.FolderUID == "folder1" &&
+				query.IsEnabled != nil && *query.IsEnabled &&
+				query.AnnotationsEnabled != nil && !*query.AnnotationsEnabled &&
+				query.TimeSelectionEnabled == nil
+		})).Return(successResp, nil)
+
+		testServer := setupTestServer(t, nil, service, userViewer)
+
+		response := callAPI(testServer, http.MethodGet, "/api/dashboards/public-dashboards?query=sales&folderUid=folder1&isEnabled=true&annotationsEnabled=false", nil, t)
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Rejects invalid boolean filters", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		testServer := setupTestServer(t, nil, service, userViewer)
+
+		response := callAPI(testServer, http.MethodGet, "/api/dashboards/public-dashboards?isEnabled=maybe", nil, t)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		service.AssertNotCalled(t, "FindAllWithPagination")
+	})
+
+	t.Run("Passes the variables and share type filters to the service", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("FindAllWithPagination", mock.Anything, mock.MatchedBy(func(query *PublicDashboardListQuery) bool {
+			return query.VariablesEnabled != nil && !*query.VariablesEnabled && query.Share == EmailShareType
+		})).Return(successResp, nil)
+
+		testServer := setupTestServer(t, nil, service, userViewer)
+
+		response := callAPI(testServer, http.MethodGet, "/api/dashboards/public-dashboards?variablesEnabled=false&share=email", nil, t)
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Rejects unknown share types", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		testServer := setupTestServer(t, nil, service, userViewer)
+
+		response := callAPI(testServer, http.MethodGet, "/api/dashboards/public-dashboards?share=everyone", nil, t)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		service.AssertNotCalled(t, "FindAllWithPagination")
+	})
+
+	t.Run("Rejects unknown sort fields", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		testServer := setupTestServer(t, nil, service, userViewer)
+
+		response := callAPI(testServer, http.MethodGet, "/api/dashboards/public-dashboards?sort=accessToken", nil, t)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		service.AssertNotCalled(t, "FindAllWithPagination")
+	})
 }
 
 func TestAPIDeletePublicDashboard(t *testing.T) {
@@ -280,6 +346,114 @@ func TestAPIDeletePublicDashboard(t *testing.T) {
 	}
 }
 
+func TestAPIRotatePublicDashboardAccessToken(t *testing.T) {
+	dashboardUid := "abc1234"
+	publicDashboardUid := "1234asdfasdf"
+	path := fmt.Sprintf("/api/dashboards/uid/%s/public-dashboards/%s/rotate-token", dashboardUid, publicDashboardUid)
+
+	t.Run("User viewer cannot rotate the access token", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		testServer := setupTestServer(t, nil, service, userViewer)
+
+		response := callAPI(testServer, http.MethodPost, path, nil, t)
+		assert.Equal(t, http.StatusForbidden, response.Code)
+		service.AssertNotCalled(t, "RotateAccessToken")
+	})
+
+	t.Run("Rotates the access token right away without a body", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("RotateAccessToken", mock.Anything, mock.Anything, publicDashboardUid, dashboardUid, RotateAccessTokenDTO{}).
+			Return(&PublicDashboard{Uid: publicDashboardUid, AccessToken: "newaccesstoken"}, nil)
+		testServer := setupTestServer(t, nil, service, userAdmin)
+
+		response := callAPI(testServer, http.MethodPost, path, nil, t)
+		require.Equal(t, http.StatusOK, response.Code)
+
+		var pubdash PublicDashboard
+		require.NoError(t, json.Unmarshal(response.Body.Bytes(), &pubdash))
+		assert.Equal(t, "newaccesstoken", pubdash.AccessToken)
+	})
+
+	t.Run("Passes the grace period of the previous access token", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("RotateAccessToken", mock.Anything, mock.Anything, publicDashboardUid, dashboardUid, RotateAccessTokenDTO{GracePeriodSeconds: 3600}).
+			Return(&PublicDashboard{Uid: publicDashboardUid, AccessToken: "newaccesstoken"}, nil)
+		testServer := setupTestServer(t, nil, service, userAdmin)
+
+		response := callAPI(testServer, http.MethodPost, path, strings.NewReader(`{"gracePeriodSeconds": 3600}`), t)
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Returns the error of concurrent rotations", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("RotateAccessToken", mock.Anything, mock.Anything, publicDashboardUid, dashboardUid, mock.Anything).
+			Return(nil, ErrAccessTokenRotationConflict.Errorf(""))
+		testServer := setupTestServer(t, nil, service, userAdmin)
+
+		response := callAPI(testServer, http.MethodPost, path, nil, t)
+		assert.Equal(t, http.StatusConflict, response.Code)
+	})
+
+	t.Run("Invalid publicDashboardUid throws an error", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		testServer := setupTestServer(t, nil, service, userAdmin)
+
+		response := callAPI(testServer, http.MethodPost, fmt.Sprintf("/api/dashboards/uid/%s/public-dashboards/inv@lid-uid!/rotate-token", dashboardUid), nil, t)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+}
+
+func TestAPIPatchPublicDashboard(t *testing.T) {
+	dashboardUid := "abc1234"
+	publicDashboardUid := "1234asdfasdf"
+	path := fmt.Sprintf("/api/dashboards/uid/%s/public-dashboards/%s/config", dashboardUid, publicDashboardUid)
+
+	t.Run("User viewer cannot patch the config", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		testServer := setupTestServer(t, nil, service, userViewer)
+
+		response := callAPI(testServer, http.MethodPatch, path, strings.NewReader(`{"isEnabled": false}`), t)
+		assert.Equal(t, http.StatusForbidden, response.Code)
+		service.AssertNotCalled(t, "Patch")
+	})
+
+	t.Run("Passes the fields of the body as the patch", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		var patch PublicDashboardPatch
+		service.On("Patch", mock.Anything, mock.Anything, publicDashboardUid, dashboardUid, mock.Anything).
+			Run(func(args mock.Arguments) { patch = args.Get(4).(PublicDashboardPatch) }).
+			Return(&PublicDashboard{Uid: publicDashboardUid, IsEnabled: false}, nil)
+		testServer := setupTestServer(t, nil, service, userAdmin)
+
+		response := callAPI(testServer, http.MethodPatch, path, strings.NewReader(`{"isEnabled": false, "slug": null}`), t)
+		require.Equal(t, http.StatusOK, response.Code)
+
+		assert.Equal(t, []string{"isEnabled", "slug"}, patch.Fields)
+		require.NotNil(t, patch.PublicDashboard.IsEnabled)
+		assert.False(t, *patch.PublicDashboard.IsEnabled)
+		assert.Nil(t, patch.PublicDashboard.Slug)
+	})
+
+	t.Run("Rejects bodies that aren't a JSON object", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		testServer := setupTestServer(t, nil, service, userAdmin)
+
+		response := callAPI(testServer, http.MethodPatch, path, strings.NewReader(`[{"isEnabled": false}]`), t)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		service.AssertNotCalled(t, "Patch")
+	})
+
+	t.Run("Returns the error of invalid patches", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("Patch", mock.Anything, mock.Anything, publicDashboardUid, dashboardUid, mock.Anything).
+			Return(nil, ErrInvalidPatch.Errorf(""))
+		testServer := setupTestServer(t, nil, service, userAdmin)
+
+		response := callAPI(testServer, http.MethodPatch, path, strings.NewReader(`{"accessToken": "abc"}`), t)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+	})
+}
+
 func TestAPIGetPublicDashboard(t *testing.T) {
 	pubdash := &PublicDashboard{IsEnabled: true}
 
@@ -478,6 +652,52 @@ func TestApiCreatePublicDashboard(t *testing.T) {
 	}
 }
 
+func TestAPIUpsertPublicDashboard(t *testing.T) {
+	path := "/api/dashboards/uid/abc1234/public-dashboards"
+
+	t.Run("Returns 201 when the public dashboard is created", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("Upsert", mock.Anything, mock.Anything, mock.MatchedBy(func(dto *SavePublicDashboardDTO) bool {
+			return dto.DashboardUid == "abc1234" && dto.OrgID == userAdmin.OrgID && dto.PublicDashboard.Uid == "pubdash1"
+		})).Return(&PublicDashboard{Uid: "pubdash1", DashboardUid: "abc1234"}, true, nil)
+		testServer := setupTestServer(t, nil, service, userAdmin)
+
+		response := callAPI(testServer, http.MethodPut, path, strings.NewReader(`{"uid": "pubdash1", "isEnabled": true}`), t)
+		require.Equal(t, http.StatusCreated, response.Code)
+		assert.Contains(t, response.Body.String(), `"uid":"pubdash1"`)
+	})
+
+	t.Run("Returns 200 when the public dashboard is updated", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("Upsert", mock.Anything, mock.Anything, mock.Anything).
+			Return(&PublicDashboard{Uid: "pubdash1", DashboardUid: "abc1234"}, false, nil)
+		testServer := setupTestServer(t, nil, service, userAdmin)
+
+		response := callAPI(testServer, http.MethodPut, path, strings.NewReader(`{"isEnabled": false}`), t)
+		assert.Equal(t, http.StatusOK, response.Code)
+	})
+
+	t.Run("Returns 400 for invalid uids and access tokens", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		testServer := setupTestServer(t, nil, service, userAdmin)
+
+		response := callAPI(testServer, http.MethodPut, path, strings.NewReader(`{"uid": "."}`), t)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		response = callAPI(testServer, http.MethodPut, path, strings.NewReader(`{"accessToken": "not a token"}`), t)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		service.AssertNotCalled(t, "Upsert")
+	})
+
+	t.Run("Returns 403 for viewers", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		testServer := setupTestServer(t, nil, service, userViewer)
+
+		response := callAPI(testServer, http.MethodPut, path, strings.NewReader(`{}`), t)
+		assert.Equal(t, http.StatusForbidden, response.Code)
+		service.AssertNotCalled(t, "Upsert")
+	})
+}
+
 func TestAPIUpdatePublicDashboard(t *testing.T) {
 	dashboardUid := "abc1234"
 	publicDashboardUid := "1234asdfasdf"
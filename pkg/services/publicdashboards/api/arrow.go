@@ -0,0 +1,115 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+// arrowMediaType is the media type clients accept to receive query results as Arrow IPC files instead of JSON. The
+// response is a multipart/mixed body with one Arrow file per data frame, named after the refId of its query. Grafana
+// metadata of the frames is kept in the Arrow schema metadata like in plugin responses
+const arrowMediaType = "application/vnd.apache.arrow.file"
+
+// acceptsArrow reports whether the client asked for query results in the Arrow format
+func acceptsArrow(req *http.Request) bool {
+	for _, accepted := range strings.Split(req.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err == nil && mediaType == arrowMediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// toArrowResponse writes the query response in the Arrow format. Responses with errors are written as JSON, like for
+// any other client
+func toArrowResponse(qdr *backend.QueryDataResponse) response.Response {
+	for _, res := range qdr.Responses {
+		if res.Error != nil {
+			return response.JSON(http.StatusBadRequest, qdr)
+		}
+	}
+
+	body, contentType, err := encodeArrow(qdr)
+	if err != nil {
+		return response.Err(ErrInternalServerError.Errorf("toArrowResponse: failed to encode response: %w", err))
+	}
+
+	return response.Respond(http.StatusOK, body).SetHeader("Content-Type", contentType)
+}
+
+// encodeQueryDataResponse encodes the query response in the format accepted by the client and returns its content type
+func encodeQueryDataResponse(req *http.Request, qdr *backend.QueryDataResponse) ([]byte, string, error) {
+	if acceptsArrow(req) {
+		return encodeArrow(qdr)
+	}
+
+	body, err := json.Marshal(qdr)
+	return body, "application/json", err
+}
+
+// encodeArrow writes every frame of the response as an Arrow file in its own part, ordered by refId. The boundary is
+// derived from the content, so equal responses are encoded the same way and keep the same ETag
+func encodeArrow(qdr *backend.QueryDataResponse) ([]byte, string, error) {
+	refIDs := make([]string, 0, len(qdr.Responses))
+	for refID := range qdr.Responses {
+		refIDs = append(refIDs, refID)
+	}
+	sort.Strings(refIDs)
+
+	type part struct {
+		refID string
+		data  []byte
+	}
+	parts := make([]part, 0, len(refIDs))
+	hash := sha256.New()
+	for _, refID := range refIDs {
+		for _, frame := range qdr.Responses[refID].Frames {
+			data, err := frame.MarshalArrow()
+			if err != nil {
+				return nil, "", err
+			}
+			hash.Write(data)
+			parts = append(parts, part{refID: refID, data: data})
+		}
+	}
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	if err := w.SetBoundary("grafana-" + hex.EncodeToString(hash.Sum(nil))[:32]); err != nil {
+		return nil, "", err
+	}
+
+	for _, p := range parts {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", arrowMediaType)
+		header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"name": p.refID}))
+
+		pw, err := w.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := pw.Write(p.data); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return buf.Bytes(), mime.FormatMediaType("multipart/mixed", map[string]string{"boundary": w.Boundary()}), nil
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+)
+
+func TestAcceptsArrow(t *testing.T) {
+	testCases := []struct {
+		accept   string
+		expected bool
+	}{
+		{accept: "", expected: false},
+		{accept: "application/json", expected: false},
+		{accept: arrowMediaType, expected: true},
+		{accept: "application/json;q=0.5, application/vnd.apache.arrow.file;q=0.9", expected: true},
+		{accept: "application/vnd.apache.arrow.stream", expected: false},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", tc.accept)
+		assert.Equal(t, tc.expected, acceptsArrow(req), tc.accept)
+	}
+}
+
+func TestEncodeArrow(t *testing.T) {
+	qdr := &backend.QueryDataResponse{Responses: map[string]backend.DataResponse{
+		"B": {Frames: data.Frames{data.NewFrame("b", data.NewField("value", nil, []float64{2}))}},
+		"A": {Frames: data.Frames{
+			data.NewFrame("a1", data.NewField("value", nil, []float64{1})),
+			data.NewFrame("a2", data.NewField("value", nil, []string{"x"})),
+		}},
+	}}
+
+	body, contentType, err := encodeArrow(qdr)
+	require.NoError(t, err)
+
+	t.Run("Writes a part per frame ordered by refId", func(t *testing.T) {
+		frames, refIDs := readArrowParts(t, body, contentType)
+		assert.Equal(t, []string{"A", "A", "B"}, refIDs)
+		assert.Equal(t, "a1", frames[0].Name)
+		assert.Equal(t, "a2", frames[1].Name)
+		assert.Equal(t, "b", frames[2].Name)
+		assert.Equal(t, 2.0, frames[2].Fields[0].At(0))
+	})
+
+	t.Run("Encodes equal responses the same way", func(t *testing.T) {
+		again, againContentType, err := encodeArrow(qdr)
+		require.NoError(t, err)
+		assert.Equal(t, contentType, againContentType)
+		assert.Equal(t, body, again)
+	})
+}
+
+func TestAPIQueryPublicDashboardArrow(t *testing.T) {
+	service := publicdashboards.NewFakePublicDashboardService(t)
+	service.On("GetQueryDataResponse", mock.Anything, true, mock.Anything, int64(2), validAccessToken).
+		Return(&backend.QueryDataResponse{Responses: map[string]backend.DataResponse{
+			"A": {Frames: data.Frames{data.NewFrame("a", data.NewField("value", nil, []float64{1}))}},
+		}}, nil)
+	service.On("RecordAuditLogEntry", mock.Anything, mock.Anything, mock.Anything)
+	expectViewerAccess(service, validAccessToken)
+	server := setupTestServer(t, nil, service, anonymousUser)
+
+	req, err := http.NewRequest(http.MethodPost, getValidQueryPath(validAccessToken), bytes.NewReader([]byte("{}")))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(viewerTokenHeader, testViewerToken)
+	req.Header.Set("Accept", arrowMediaType)
+	resp := httptest.NewRecorder()
+	server.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	frames, refIDs := readArrowParts(t, resp.Body.Bytes(), resp.Header().Get("Content-Type"))
+	assert.Equal(t, []string{"A"}, refIDs)
+	assert.Equal(t, "a", frames[0].Name)
+}
+
+func readArrowParts(t *testing.T, body []byte, contentType string) ([]*data.Frame, []string) {
+	t.Helper()
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	require.NoError(t, err)
+	require.Equal(t, "multipart/mixed", mediaType)
+
+	var frames []*data.Frame
+	var refIDs []string
+	r := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := r.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		require.Equal(t, arrowMediaType, part.Header.Get("Content-Type"))
+
+		raw, err := io.ReadAll(part)
+		require.NoError(t, err)
+		frame, err := data.UnmarshalArrowFrame(raw)
+		require.NoError(t, err)
+
+		_, disposition, err := mime.ParseMediaType(part.Header.Get("Content-Disposition"))
+		require.NoError(t, err)
+
+		frames = append(frames, frame)
+		refIDs = append(refIDs, disposition["name"])
+	}
+	return frames, refIDs
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// swagger:route GET /dashboards/uid/{dashboardUid}/public-dashboards/{uid}/audit-log dashboards dashboard_public getPublicDashboardAuditLog
+//
+//	Get the audit log of a public dashboard
+//
+// Returns the views and queries of the public dashboard recorded while the audit log was enabled, newest first. The
+// IP addresses of viewers are returned as keyed hashes. Use page and perPage to paginate, perPage is 100 by default
+// and at most 1000.
+//
+// Responses:
+// 200: getPublicDashboardAuditLogResponse
+// 400: badRequestPublicError
+// 401: unauthorisedPublicError
+// 403: forbiddenPublicError
+// 404: notFoundPublicError
+// 500: internalServerPublicError
+func (api *Api) GetPublicDashboardAuditLog(c *contextmodel.ReqContext) response.Response {
+	dashboardUid := web.Params(c.Req)[":dashboardUid"]
+	if !validation.IsValidShortUID(dashboardUid) {
+		return response.Err(ErrInvalidUid.Errorf("GetPublicDashboardAuditLog: invalid dashboard Uid %s", dashboardUid))
+	}
+
+	uid := web.Params(c.Req)[":uid"]
+	if !validation.IsValidShortUID(uid) {
+		return response.Err(ErrInvalidUid.Errorf("GetPublicDashboardAuditLog: invalid Uid %s", uid))
+	}
+
+	query := &AuditLogQuery{
+		Page:  c.QueryInt("page"),
+		Limit: c.QueryInt("perPage"),
+	}
+	auditLog, err := api.PublicDashboardService.GetAuditLog(c.Req.Context(), c.GetOrgID(), dashboardUid, uid, query)
+	if err != nil {
+		return response.Err(err)
+	}
+
+	return response.JSON(http.StatusOK, auditLog)
+}
+
+// swagger:parameters getPublicDashboardAuditLog
+type GetPublicDashboardAuditLogParams struct {
+	// in:path
+	// required:true
+	DashboardUid string `json:"dashboardUid"`
+	// in:path
+	// required:true
+	Uid string `json:"uid"`
+	// in:query
+	// required:false
+	Page int `json:"page"`
+	// in:query
+	// required:false
+	PerPage int `json:"perPage"`
+}
+
+// swagger:response getPublicDashboardAuditLogResponse
+type GetPublicDashboardAuditLogResponse struct {
+	// in: body
+	Body AuditLogResponseWithPagination `json:"body"`
+}
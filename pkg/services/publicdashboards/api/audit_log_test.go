@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+func TestAPIGetPublicDashboardAuditLog(t *testing.T) {
+	path := "/api/dashboards/uid/abc123/public-dashboards/pubdash1/audit-log"
+
+	t.Run("Returns a page of the audit log", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("GetAuditLog", mock.Anything, int64(1), "abc123", "pubdash1", &AuditLogQuery{Page: 2, Limit: 1}).
+			Return(&AuditLogResponseWithPagination{
+				Entries: []*AuditLogEntry{{
+					Id:                 7,
+					OrgId:              1,
+					PublicDashboardUid: "pubdash1",
+					AccessTokenHash:    "9c1f",
+					IPHash:             "3a5f",
+					UserAgent:          "Mozilla/5.0",
+					Action:             AuditLogActionQuery,
+					PanelIds:           []int64{2},
+					TimeFrom:           "now-6h",
+					TimeTo:             "now",
+					CreatedAt:          time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				}},
+				TotalCount: 2,
+				Page:       2,
+				PerPage:    1,
+			}, nil)
+		server := setupTestServer(t, nil, service, userAdmin)
+
+		resp := callAPI(server, http.MethodGet, path+"?page=2&perPage=1", nil, t)
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.JSONEq(t, `{
+			"entries": [{
+				"id": 7,
+				"publicDashboardUid": "pubdash1",
+				"accessTokenHash": "9c1f",
+				"ipHash": "3a5f",
+				"userAgent": "Mozilla/5.0",
+				"action": "query",
+				"panelIds": [2],
+				"timeFrom": "now-6h",
+				"timeTo": "now",
+				"createdAt": "2024-01-01T00:00:00Z"
+			}],
+			"totalCount": 2,
+			"page": 2,
+			"perPage": 1
+		}`, resp.Body.String())
+	})
+
+	t.Run("Status code is 403 for viewers", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		server := setupTestServer(t, nil, service, userViewer)
+
+		resp := callAPI(server, http.MethodGet, path, nil, t)
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		service.AssertNotCalled(t, "GetAuditLog")
+	})
+}
@@ -0,0 +1,338 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/metric"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+const (
+	botSignalMissingUserAgent      = "missing_user_agent"
+	botSignalAutomationUserAgent   = "automation_user_agent"
+	botSignalMissingBrowserHeaders = "missing_browser_headers"
+	botSignalRequestBurst          = "request_burst"
+	botSignalRegularInterval       = "regular_interval"
+	botSignalTokenEnumeration      = "token_enumeration"
+
+	botActionThrottled = "throttled"
+	botActionBlocked   = "blocked"
+
+	// botScoreHalfLife is how fast scores decay, so clients recover once they behave
+	botScoreHalfLife = 5 * time.Minute
+	// botBurstRequests is how many requests a client can send in botBurstWindow before each request is a burst.
+	// Dashboards with many panels send a lot of requests at once, so it's well above what a page load needs
+	botBurstRequests = 200
+	botBurstWindow   = 10 * time.Second
+	// botIntervalRequests is how many of the latest requests are checked for a machine-like regular interval
+	botIntervalRequests = 10
+	// botMinRegularInterval and botMaxRegularInterval bound the intervals considered machine-like. The queries of a
+	// page load arrive within milliseconds of each other, and dashboards refresh every 5s at most
+	botMinRegularInterval = 100 * time.Millisecond
+	botMaxRegularInterval = 2 * time.Second
+	// botMaxUnknownTokens caps the unknown access tokens remembered for each client
+	botMaxUnknownTokens = 100
+)
+
+// botSignalScores are the scores added by each signal of a request
+var botSignalScores = map[string]float64{
+	botSignalMissingUserAgent:      20,
+	botSignalAutomationUserAgent:   10,
+	botSignalMissingBrowserHeaders: 5,
+	botSignalRequestBurst:          1,
+	botSignalRegularInterval:       10,
+	botSignalTokenEnumeration:      25,
+}
+
+// automationUserAgents are parts of the user agents of HTTP libraries, headless browsers and crawlers
+var automationUserAgents = []string{
+	"curl/", "wget/", "python-requests", "python-urllib", "aiohttp", "go-http-client", "java/", "okhttp", "libwww-perl",
+	"scrapy", "httpclient", "headlesschrome", "phantomjs", "selenium", "puppeteer", "playwright", "crawler", "spider",
+}
+
+// botWordPattern matches bot as a word of the user agent, like the bot.html links of crawlers. A bare substring would
+// also match browsers of devices like the Cubot phones
+var botWordPattern = regexp.MustCompile(`\bbot\b`)
+
+// isAutomationUserAgent reports whether the lowercase user agent is one of an HTTP library, headless browser or crawler
+func isAutomationUserAgent(userAgent string) bool {
+	for _, automation := range automationUserAgents {
+		if strings.Contains(userAgent, automation) {
+			return true
+		}
+	}
+	return botWordPattern.MatchString(userAgent)
+}
+
+// BotDetector scores the requests of each client IP by heuristics: missing browser headers, automation user agents,
+// bursts and machine-like regular intervals of requests, and attempts to enumerate access tokens. Scores decay over
+// time. Clients above the throttle score are limited to a few requests per minute, and clients above the block score
+// are blocked for a while. Each Grafana instance keeps its own scores. A nil detector doesn't detect anything
+type BotDetector struct {
+	throttleScore float64
+	throttleRate  int
+	blockScore    float64
+	blockDuration time.Duration
+	log           log.Logger
+
+	mu        sync.Mutex
+	clients   map[string]*botClient
+	lastPrune time.Time
+}
+
+type botClient struct {
+	score        float64
+	updatedAt    time.Time
+	blockedUntil time.Time
+	// requests in the current burst window
+	windowStart   time.Time
+	windowCount   int
+	recent        []time.Time
+	unknownTokens map[string]struct{}
+	throttle      *rate.Limiter
+}
+
+// NewBotDetector returns the bot detector configured in the public dashboards settings, nil when it's disabled
+func NewBotDetector(cfg *setting.Cfg) *BotDetector {
+	if !cfg.PublicDashboardsBotDetectionEnabled {
+		return nil
+	}
+
+	return &BotDetector{
+		throttleScore: float64(cfg.PublicDashboardsBotDetectionThrottleScore),
+		throttleRate:  cfg.PublicDashboardsBotDetectionThrottleRate,
+		blockScore:    float64(cfg.PublicDashboardsBotDetectionBlockScore),
+		blockDuration: cfg.PublicDashboardsBotDetectionBlockDuration,
+		log:           log.New("publicdashboards.botdetection"),
+		clients:       map[string]*botClient{},
+	}
+}
+
+// RequiresBotCheck Middleware to score public dashboard requests by the bot heuristics of the detector. Requests of
+// blocked clients, and requests of throttled clients above their rate, are rejected with a 429 and a Retry-After
+// header. Access tokens are only looked up to detect enumerations, unknown slugs don't count
+func RequiresBotCheck(detector *BotDetector, publicDashboardService publicdashboards.Service, clientIPs *ClientIPResolver) func(c *contextmodel.ReqContext) {
+	return func(c *contextmodel.ReqContext) {
+		accessToken := web.Params(c.Req)[":accessToken"]
+		if retryAfter, err := detector.Check(c.Req.Context(), publicDashboardService, c.Req, clientIPs.ClientIP(c.Req), accessToken); err != nil {
+			c.Resp.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			c.WriteErr(err)
+		}
+	}
+}
+
+// Check scores the request of the client IP for the access token or slug. Requests of blocked clients, and requests
+// of throttled clients above their rate, return an error and how long until the client can try again
+func (d *BotDetector) Check(ctx context.Context, publicDashboardService publicdashboards.Service, req *http.Request, ip string, accessToken string) (time.Duration, error) {
+	if d == nil {
+		return 0, nil
+	}
+
+	now := time.Now()
+	// blocked clients don't get to look up access tokens
+	if retryAfter, blocked := d.blockedFor(ip, now); blocked {
+		return retryAfter, botRequestError(ip, botActionBlocked)
+	}
+
+	unknownToken := ""
+	switch {
+	case accessToken == "":
+	case validation.IsValidAccessToken(accessToken):
+		orgID, err := publicDashboardService.GetOrgIdByAccessToken(ctx, accessToken)
+		if err == nil && orgID == 0 {
+			unknownToken = accessToken
+		}
+	case !validation.IsValidSlug(accessToken):
+		unknownToken = accessToken
+	}
+
+	if action, retryAfter := d.check(ip, req, unknownToken, now); action != "" {
+		return retryAfter, botRequestError(ip, action)
+	}
+	return 0, nil
+}
+
+func botRequestError(ip string, action string) error {
+	metric.BotDetectionRequestsRejectedTotal.WithLabelValues(action).Inc()
+	if action == botActionBlocked {
+		return models.ErrClientBlocked.Errorf("RequiresBotCheck: client %s is blocked", ip)
+	}
+	return models.ErrRateLimited.Errorf("RequiresBotCheck: client %s is throttled", ip)
+}
+
+// blockedFor returns how long the client is still blocked, and false when it isn't
+func (d *BotDetector) blockedFor(ip string, now time.Time) (time.Duration, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	client, ok := d.clients[ip]
+	if !ok || !now.Before(client.blockedUntil) {
+		return 0, false
+	}
+	return client.blockedUntil.Sub(now), true
+}
+
+// check scores the request and returns whether it's throttled or blocked, empty when it's let through, and how long
+// until the client can try again
+func (d *BotDetector) check(ip string, req *http.Request, unknownToken string, now time.Time) (string, time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.prune(now)
+
+	client, ok := d.clients[ip]
+	if !ok {
+		client = &botClient{updatedAt: now, windowStart: now}
+		d.clients[ip] = client
+	}
+
+	if now.Before(client.blockedUntil) {
+		return botActionBlocked, client.blockedUntil.Sub(now)
+	}
+
+	client.decay(now)
+	for _, signal := range client.signals(req, unknownToken, now) {
+		metric.BotDetectionSignalsTotal.WithLabelValues(signal).Inc()
+		client.score += botSignalScores[signal]
+	}
+
+	if client.score >= d.blockScore {
+		client.blockedUntil = now.Add(d.blockDuration)
+		metric.BotDetectionClientsBlockedTotal.Inc()
+		d.log.Warn("Blocked client of public dashboards", "remoteAddr", ip, "score", client.score, "until", client.blockedUntil)
+		return botActionBlocked, d.blockDuration
+	}
+
+	if client.score < d.throttleScore {
+		client.throttle = nil
+		return "", 0
+	}
+
+	if client.throttle == nil {
+		client.throttle = rate.NewLimiter(rate.Every(time.Minute/time.Duration(d.throttleRate)), 1)
+	}
+	reservation := client.throttle.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return botActionThrottled, delay
+	}
+	return "", 0
+}
+
+// prune forgets the clients whose score decayed and which aren't blocked
+func (d *BotDetector) prune(now time.Time) {
+	if now.Sub(d.lastPrune) < time.Minute {
+		return
+	}
+
+	for ip, client := range d.clients {
+		client.decay(now)
+		if client.score < 1 && !now.Before(client.blockedUntil) && now.Sub(client.windowStart) > botBurstWindow {
+			delete(d.clients, ip)
+		}
+	}
+	d.lastPrune = now
+}
+
+func (c *botClient) decay(now time.Time) {
+	if elapsed := now.Sub(c.updatedAt); elapsed > 0 {
+		c.score *= math.Pow(0.5, float64(elapsed)/float64(botScoreHalfLife))
+		c.updatedAt = now
+	}
+}
+
+// signals returns the bot signals of the request, and records it in the history of the client
+func (c *botClient) signals(req *http.Request, unknownToken string, now time.Time) []string {
+	var signals []string
+
+	userAgent := strings.ToLower(req.UserAgent())
+	if userAgent == "" {
+		signals = append(signals, botSignalMissingUserAgent)
+	} else if isAutomationUserAgent(userAgent) {
+		signals = append(signals, botSignalAutomationUserAgent)
+	}
+
+	// browsers send both, HTTP libraries usually neither
+	if req.Header.Get("Accept-Language") == "" && req.Header.Get("Sec-Fetch-Mode") == "" {
+		signals = append(signals, botSignalMissingBrowserHeaders)
+	}
+
+	if now.Sub(c.windowStart) > botBurstWindow {
+		c.windowStart = now
+		c.windowCount = 0
+	}
+	c.windowCount++
+	if c.windowCount > botBurstRequests {
+		signals = append(signals, botSignalRequestBurst)
+	}
+
+	c.recent = append(c.recent, now)
+	if len(c.recent) > botIntervalRequests {
+		c.recent = c.recent[len(c.recent)-botIntervalRequests:]
+	}
+	if isRegularInterval(c.recent) {
+		signals = append(signals, botSignalRegularInterval)
+	}
+
+	if unknownToken != "" {
+		sum := sha256.Sum256([]byte(unknownToken))
+		key := hex.EncodeToString(sum[:8])
+		if _, seen := c.unknownTokens[key]; !seen {
+			if c.unknownTokens == nil {
+				c.unknownTokens = map[string]struct{}{}
+			}
+			if len(c.unknownTokens) < botMaxUnknownTokens {
+				c.unknownTokens[key] = struct{}{}
+			}
+			signals = append(signals, botSignalTokenEnumeration)
+		}
+	}
+
+	return signals
+}
+
+// isRegularInterval returns true when the requests were sent at a short and almost constant interval, which browsers
+// don't do
+func isRegularInterval(requests []time.Time) bool {
+	if len(requests) < botIntervalRequests {
+		return false
+	}
+
+	intervals := make([]float64, 0, len(requests)-1)
+	var sum float64
+	for i := 1; i < len(requests); i++ {
+		interval := float64(requests[i].Sub(requests[i-1]))
+		intervals = append(intervals, interval)
+		sum += interval
+	}
+	mean := sum / float64(len(intervals))
+	if mean < float64(botMinRegularInterval) || mean > float64(botMaxRegularInterval) {
+		return false
+	}
+
+	var variance float64
+	for _, interval := range intervals {
+		variance += (interval - mean) * (interval - mean)
+	}
+	variance /= float64(len(intervals))
+
+	// the coefficient of variation of machine-like intervals is below 10%
+	return math.Sqrt(variance)/mean < 0.1
+}
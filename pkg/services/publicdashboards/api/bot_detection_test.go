@@ -0,0 +1,203 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func newTestBotDetector(t *testing.T) *BotDetector {
+	t.Helper()
+	cfg := setting.NewCfg()
+	cfg.PublicDashboardsBotDetectionEnabled = true
+	cfg.PublicDashboardsBotDetectionThrottleScore = 50
+	cfg.PublicDashboardsBotDetectionThrottleRate = 10
+	cfg.PublicDashboardsBotDetectionBlockScore = 100
+	cfg.PublicDashboardsBotDetectionBlockDuration = 15 * time.Minute
+	detector := NewBotDetector(cfg)
+	require.NotNil(t, detector)
+	return detector
+}
+
+func browserRequest(t *testing.T) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, "/api/public/dashboards/"+validAccessToken+"/annotations", nil)
+	require.NoError(t, err)
+	req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64; rv:131.0) Gecko/20100101 Firefox/131.0")
+	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
+	req.Header.Set("Sec-Fetch-Mode", "cors")
+	return req
+}
+
+func TestBotDetectorCheck(t *testing.T) {
+	now := time.Now()
+
+	t.Run("lets browsers through", func(t *testing.T) {
+		detector := newTestBotDetector(t)
+		for i := 0; i < 50; i++ {
+			// dashboards send their panel queries at once
+			action, _ := detector.check("10.0.0.1", browserRequest(t), "", now.Add(time.Duration(i)*time.Millisecond))
+			require.Empty(t, action)
+		}
+	})
+
+	t.Run("throttles and then blocks scripts", func(t *testing.T) {
+		detector := newTestBotDetector(t)
+		req := browserRequest(t)
+		req.Header = http.Header{"User-Agent": []string{"python-requests/2.32.3"}}
+
+		var actions []string
+		for i := 0; i < 7; i++ {
+			action, _ := detector.check("10.0.0.1", req, "", now.Add(time.Duration(i)*time.Second))
+			actions = append(actions, action)
+		}
+		assert.Equal(t, []string{"", "", "", "", botActionThrottled, botActionThrottled, botActionBlocked}, actions)
+
+		action, retryAfter := detector.check("10.0.0.1", browserRequest(t), "", now.Add(time.Minute))
+		assert.Equal(t, botActionBlocked, action)
+		assert.InDelta(t, 14*time.Minute, retryAfter, float64(10*time.Second))
+
+		action, _ = detector.check("10.0.0.2", browserRequest(t), "", now.Add(time.Minute))
+		assert.Empty(t, action, "other clients aren't blocked")
+	})
+
+	t.Run("blocks clients enumerating access tokens", func(t *testing.T) {
+		detector := newTestBotDetector(t)
+		for i := 0; i < 4; i++ {
+			action, _ := detector.check("10.0.0.1", browserRequest(t), fmt.Sprintf("unknown%d", i), now.Add(time.Duration(i)*time.Second))
+			require.NotEqual(t, botActionBlocked, action)
+		}
+
+		action, _ := detector.check("10.0.0.1", browserRequest(t), "unknown0", now.Add(4*time.Second))
+		require.NotEqual(t, botActionBlocked, action, "the same unknown access token only counts once")
+
+		action, _ = detector.check("10.0.0.1", browserRequest(t), "unknown4", now.Add(5*time.Second))
+		assert.Equal(t, botActionBlocked, action)
+	})
+
+	t.Run("scores decay over time", func(t *testing.T) {
+		detector := newTestBotDetector(t)
+		req := browserRequest(t)
+		req.Header.Del("User-Agent")
+		for i := 0; i < 3; i++ {
+			detector.check("10.0.0.1", req, "", now.Add(time.Duration(i)*5*time.Second))
+		}
+		score := detector.clients["10.0.0.1"].score
+
+		detector.check("10.0.0.1", browserRequest(t), "", now.Add(10*time.Second+botScoreHalfLife))
+		assert.InDelta(t, score/2, detector.clients["10.0.0.1"].score, 1)
+	})
+}
+
+func TestIsRegularInterval(t *testing.T) {
+	now := time.Now()
+	requests := func(intervals ...time.Duration) []time.Time {
+		times := []time.Time{now}
+		for _, interval := range intervals {
+			times = append(times, times[len(times)-1].Add(interval))
+		}
+		return times
+	}
+	repeat := func(interval time.Duration) []time.Duration {
+		intervals := make([]time.Duration, botIntervalRequests-1)
+		for i := range intervals {
+			intervals[i] = interval
+		}
+		return intervals
+	}
+
+	assert.True(t, isRegularInterval(requests(repeat(500*time.Millisecond)...)))
+	assert.False(t, isRegularInterval(requests(repeat(5*time.Second)...)), "dashboard refreshes are regular but slow")
+	assert.False(t, isRegularInterval(requests(repeat(500 * time.Millisecond)[1:]...)), "too few requests")
+
+	irregular := repeat(500 * time.Millisecond)
+	irregular[2], irregular[5] = 10*time.Millisecond, 1500*time.Millisecond
+	assert.False(t, isRegularInterval(requests(irregular...)))
+}
+
+func TestRequiresBotCheck(t *testing.T) {
+	request := func(detector *BotDetector, service publicdashboards.Service, accessToken string, userAgent string) *httptest.ResponseRecorder {
+		params := map[string]string{":accessToken": accessToken}
+		mw := func(c *contextmodel.ReqContext) {
+			c.Req.RemoteAddr = "10.0.0.1:51234"
+			c.Req.Header.Set("User-Agent", userAgent)
+			RequiresBotCheck(detector, service, nil)(c)
+		}
+		_, resp := runMw(t, nil, "GET", "/api/public/dashboards/"+accessToken+"/annotations", params, mw)
+		return resp
+	}
+
+	t.Run("doesn't check anything when disabled", func(t *testing.T) {
+		require.Nil(t, NewBotDetector(setting.NewCfg()))
+		for i := 0; i < 10; i++ {
+			require.Equal(t, http.StatusOK, request(nil, nil, validAccessToken, "").Code)
+		}
+	})
+
+	t.Run("rejects the requests of blocked clients with a retry after", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("GetOrgIdByAccessToken", mock.Anything, validAccessToken).Return(int64(1), nil).Maybe()
+		service.On("GetOrgIdByAccessToken", mock.Anything, mock.Anything).Return(int64(0), nil)
+		detector := newTestBotDetector(t)
+
+		codes := []int{}
+		for i := 0; i < 4; i++ {
+			codes = append(codes, request(detector, service, fmt.Sprintf("%031d%d", 0, i), "curl/8.5.0").Code)
+		}
+		assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests, http.StatusTooManyRequests}, codes)
+
+		resp := request(detector, service, validAccessToken, "curl/8.5.0")
+		require.Equal(t, http.StatusTooManyRequests, resp.Code)
+		assert.Equal(t, "900", resp.Header().Get("Retry-After"))
+		assert.Contains(t, resp.Body.String(), "publicdashboards.clientBlocked")
+		service.AssertNotCalled(t, "GetOrgIdByAccessToken", mock.Anything, validAccessToken)
+	})
+
+	t.Run("ignores the forwarded addresses of untrusted clients", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("GetOrgIdByAccessToken", mock.Anything, mock.Anything).Return(int64(0), nil)
+		detector := newTestBotDetector(t)
+
+		codes := []int{}
+		for i := 0; i < 4; i++ {
+			accessToken := fmt.Sprintf("%031d%d", 0, i)
+			mw := func(c *contextmodel.ReqContext) {
+				c.Req.RemoteAddr = "10.0.0.1:51234"
+				c.Req.Header.Set("User-Agent", "curl/8.5.0")
+				c.Req.Header.Set("X-Forwarded-For", fmt.Sprintf("203.0.113.%d", i))
+				RequiresBotCheck(detector, service, NewClientIPResolver(setting.NewCfg()))(c)
+			}
+			_, resp := runMw(t, nil, "GET", "/api/public/dashboards/"+accessToken+"/annotations", map[string]string{":accessToken": accessToken}, mw)
+			codes = append(codes, resp.Code)
+		}
+		assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests, http.StatusTooManyRequests}, codes)
+	})
+}
+
+func TestIsAutomationUserAgent(t *testing.T) {
+	testCases := []struct {
+		userAgent string
+		expected  bool
+	}{
+		{userAgent: "curl/8.5.0", expected: true},
+		{userAgent: "python-requests/2.32.3", expected: true},
+		{userAgent: "mozilla/5.0 (compatible; googlebot/2.1; +http://www.google.com/bot.html)", expected: true},
+		{userAgent: "mozilla/5.0 (compatible; examplebot; bot)", expected: true},
+		{userAgent: "mozilla/5.0 (x11; linux x86_64; rv:131.0) gecko/20100101 firefox/131.0", expected: false},
+		{userAgent: "mozilla/5.0 (linux; android 10; cubot x30) applewebkit/537.36 (khtml, like gecko) chrome/129.0.0.0 mobile safari/537.36", expected: false},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, isAutomationUserAgent(tc.userAgent), tc.userAgent)
+	}
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// ClientIPResolver resolves the IP address of the clients of public dashboards, which the rate limits, the bot checks
+// and the viewer tokens are keyed on. The X-Real-IP and X-Forwarded-For headers are only read from the trusted
+// proxies, other clients could set them to escape their limits. A nil resolver trusts no proxy
+type ClientIPResolver struct {
+	trustedProxies []*net.IPNet
+}
+
+// NewClientIPResolver returns the resolver trusting the proxies of the public dashboards settings
+func NewClientIPResolver(cfg *setting.Cfg) *ClientIPResolver {
+	logger := log.New("publicdashboards.api")
+
+	r := &ClientIPResolver{}
+	for _, proxy := range cfg.PublicDashboardsTrustedProxies {
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			logger.Warn("Ignoring invalid trusted proxy of public dashboards", "proxy", proxy, "error", err)
+			continue
+		}
+		r.trustedProxies = append(r.trustedProxies, network)
+	}
+
+	return r
+}
+
+// ClientIP returns the IP address of the client of the request. Requests of trusted proxies are attributed to the
+// address in their X-Real-IP header, or to the last address of X-Forwarded-For which isn't a trusted proxy. Other
+// requests are attributed to the address of their connection
+func (r *ClientIPResolver) ClientIP(req *http.Request) string {
+	addr := connectionIP(req)
+	if !r.trusts(addr) {
+		return addr
+	}
+
+	if ip := net.ParseIP(strings.TrimSpace(req.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+
+	// proxies append the address they got the request from, so the addresses right of the first untrusted one were
+	// added by trusted proxies
+	forwarded := strings.Split(req.Header.Get("X-Forwarded-For"), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if ip == nil {
+			break
+		}
+		if !r.trusts(ip.String()) {
+			return ip.String()
+		}
+	}
+
+	return addr
+}
+
+// trusts reports whether the address belongs to a trusted proxy
+func (r *ClientIPResolver) trusts(addr string) bool {
+	if r == nil {
+		return false
+	}
+
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range r.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// connectionIP returns the IP address the request was received from
+func connectionIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestClientIPResolver(t *testing.T) {
+	testCases := []struct {
+		name           string
+		trustedProxies []string
+		remoteAddr     string
+		realIP         string
+		forwardedFor   string
+		expected       string
+	}{
+		{name: "uses the connection address without trusted proxies", remoteAddr: "10.0.0.1:51234", realIP: "203.0.113.1", forwardedFor: "203.0.113.1", expected: "10.0.0.1"},
+		{name: "uses the connection address of untrusted clients", trustedProxies: []string{"10.0.1.0/24"}, remoteAddr: "10.0.0.1:51234", forwardedFor: "203.0.113.1", expected: "10.0.0.1"},
+		{name: "reads X-Real-IP of trusted proxies", trustedProxies: []string{"10.0.0.1"}, remoteAddr: "10.0.0.1:51234", realIP: "203.0.113.1", forwardedFor: "203.0.113.2", expected: "203.0.113.1"},
+		{name: "reads X-Forwarded-For of trusted proxies", trustedProxies: []string{"10.0.0.0/8"}, remoteAddr: "10.0.0.1:51234", forwardedFor: "203.0.113.1", expected: "203.0.113.1"},
+		{name: "skips the trusted proxies in X-Forwarded-For", trustedProxies: []string{"10.0.0.0/8"}, remoteAddr: "10.0.0.1:51234", forwardedFor: "198.51.100.1, 203.0.113.1, 10.0.0.2", expected: "203.0.113.1"},
+		{name: "stops at invalid addresses in X-Forwarded-For", trustedProxies: []string{"10.0.0.0/8"}, remoteAddr: "10.0.0.1:51234", forwardedFor: "203.0.113.1, unknown, 10.0.0.2", expected: "10.0.0.1"},
+		{name: "uses the connection address of trusted proxies without headers", trustedProxies: []string{"10.0.0.1"}, remoteAddr: "10.0.0.1:51234", expected: "10.0.0.1"},
+		{name: "trusts IPv6 proxies", trustedProxies: []string{"::1"}, remoteAddr: "[::1]:51234", forwardedFor: "203.0.113.1", expected: "203.0.113.1"},
+		{name: "ignores invalid trusted proxies", trustedProxies: []string{"not-an-ip", "10.0.0.1/99"}, remoteAddr: "10.0.0.1:51234", forwardedFor: "203.0.113.1", expected: "10.0.0.1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := setting.NewCfg()
+			cfg.PublicDashboardsTrustedProxies = tc.trustedProxies
+			resolver := NewClientIPResolver(cfg)
+
+			req, err := http.NewRequest(http.MethodGet, "/", nil)
+			require.NoError(t, err)
+			req.RemoteAddr = tc.remoteAddr
+			if tc.realIP != "" {
+				req.Header.Set("X-Real-IP", tc.realIP)
+			}
+			if tc.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tc.forwardedFor)
+			}
+
+			assert.Equal(t, tc.expected, resolver.ClientIP(req))
+		})
+	}
+
+	t.Run("trusts no proxy when nil", func(t *testing.T) {
+		var resolver *ClientIPResolver
+
+		req, err := http.NewRequest(http.MethodGet, "/", nil)
+		require.NoError(t, err)
+		req.RemoteAddr = "10.0.0.1:51234"
+		req.Header.Set("X-Forwarded-For", "203.0.113.1")
+
+		assert.Equal(t, "10.0.0.1", resolver.ClientIP(req))
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/grafana/pkg/api/routing"
@@ -13,10 +14,12 @@ import (
 	"github.com/grafana/grafana/pkg/services/accesscontrol/acimpl"
 	"github.com/grafana/grafana/pkg/services/contexthandler/ctxkey"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/licensing/licensingtest"
 	"github.com/grafana/grafana/pkg/services/publicdashboards"
 	publicdashboardModels "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/tests/testsuite"
@@ -56,7 +59,7 @@ func setupTestServer(
 	// build api, this will mount the routes at the same time if the feature is enabled
 	license := licensingtest.NewFakeLicensing()
 	license.On("FeatureEnabled", publicdashboardModels.FeaturePublicDashboardsEmailSharing).Return(false)
-	ProvideApi(service, rr, ac, features, &Middleware{}, cfg, license)
+	ProvideApi(service, rr, ac, features, &Middleware{}, cfg, license, &HeaderGeoIPProvider{}, nil, nil)
 
 	// connect routes to mux
 	rr.Register(m.Router)
@@ -82,10 +85,32 @@ func contextProvider(tc *testContext) web.Handler {
 	}
 }
 
+// expectPublicDashboardLookup expects the geo and domain restriction middlewares to look up the public dashboard of
+// the access token or slug, which has no restrictions
+func expectPublicDashboardLookup(service *publicdashboards.FakePublicDashboardService, accessToken string) {
+	pubdash := &publicdashboardModels.PublicDashboard{Uid: "pubdash1"}
+	if validation.IsValidSlug(accessToken) {
+		service.On("FindEnabledPublicDashboardAndDashboardByAccessToken", mock.Anything, accessToken).Return(pubdash, &dashboards.Dashboard{}, nil)
+		return
+	}
+	service.On("FindByAccessToken", mock.Anything, accessToken).Return(pubdash, nil)
+}
+
+// expectViewerAccess expects the lookups of expectPublicDashboardLookup and the validation of the viewer token sent
+// by callAPI
+func expectViewerAccess(service *publicdashboards.FakePublicDashboardService, accessToken string) {
+	expectPublicDashboardLookup(service, accessToken)
+	service.On("ValidateViewerToken", mock.Anything, accessToken, testViewerToken, mock.Anything).Return(nil)
+}
+
+// testViewerToken is the viewer token callAPI sends
+const testViewerToken = "test-viewer-token"
+
 func callAPI(server *web.Mux, method, path string, body io.Reader, t *testing.T) *httptest.ResponseRecorder {
 	req, err := http.NewRequest(method, path, body)
 	require.NoError(t, err)
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(viewerTokenHeader, testViewerToken)
 	recorder := httptest.NewRecorder()
 	server.ServeHTTP(recorder, req)
 	return recorder
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"strings"
+
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	pref "github.com/grafana/grafana/pkg/services/preference"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// embedContentSecurityPolicy is the policy of embedded public dashboards when no Content-Security-Policy is configured.
+// It only restricts what the page never needs, so it doesn't break the frontend
+const embedContentSecurityPolicy = "object-src 'none'; base-uri 'self'; form-action 'self'"
+
+// SetPublicDashboardEmbedHeaders Middleware to serve public dashboards to embed in other sites. The page can only be
+// framed by the configured frame ancestors, with Content-Security-Policy frame-ancestors and a matching
+// X-Frame-Options for older browsers, regardless of the allow_embedding setting. The theme of the page can be picked
+// with the theme query param
+func SetPublicDashboardEmbedHeaders(cfg *setting.Cfg) func(c *contextmodel.ReqContext) {
+	frameAncestors := embedFrameAncestors(cfg.PublicDashboardsEmbedFrameAncestors)
+
+	return func(c *contextmodel.ReqContext) {
+		if theme := c.Query("theme"); theme != "" && !pref.IsValidThemeID(theme) {
+			c.WriteErr(ErrBadRequest.Errorf("SetPublicDashboardEmbedHeaders: invalid theme %s", theme))
+			return
+		}
+
+		header := c.Resp.Header()
+		header.Set("Content-Security-Policy", withFrameAncestors(header.Get("Content-Security-Policy"), frameAncestors))
+		// the frame ancestors protect the page instead of the X-Frame-Options deny of allow_embedding
+		header.Set("X-Allow-Embedding", "allow")
+		if xFrameOptions := embedXFrameOptions(frameAncestors); xFrameOptions != "" {
+			header.Set("X-Frame-Options", xFrameOptions)
+		} else {
+			header.Del("X-Frame-Options")
+		}
+		// the access token is in the url of the page
+		header.Set("Referrer-Policy", "same-origin")
+		header.Set("X-Content-Type-Options", "nosniff")
+	}
+}
+
+// embedFrameAncestors returns the frame-ancestors sources of the configured frame ancestors, only the origin of
+// Grafana by default
+func embedFrameAncestors(configured []string) []string {
+	if len(configured) == 0 {
+		return []string{"'self'"}
+	}
+
+	sources := make([]string, 0, len(configured))
+	for _, source := range configured {
+		switch source {
+		case "self", "none":
+			source = "'" + source + "'"
+		}
+		sources = append(sources, source)
+	}
+	return sources
+}
+
+// embedXFrameOptions returns the X-Frame-Options matching the frame ancestors. X-Frame-Options can't list origins, so
+// it's left out when other sites can frame the page, browsers supporting frame-ancestors ignore it either way
+func embedXFrameOptions(frameAncestors []string) string {
+	switch {
+	case len(frameAncestors) == 1 && frameAncestors[0] == "'self'":
+		return "SAMEORIGIN"
+	case len(frameAncestors) == 1 && frameAncestors[0] == "'none'":
+		return "DENY"
+	default:
+		return ""
+	}
+}
+
+// withFrameAncestors replaces the frame-ancestors directive of the policy, and falls back to the embed policy when
+// there is no policy
+func withFrameAncestors(policy string, frameAncestors []string) string {
+	if strings.TrimSpace(policy) == "" {
+		policy = embedContentSecurityPolicy
+	}
+
+	directives := []string{}
+	for _, directive := range strings.Split(policy, ";") {
+		directive = strings.TrimSpace(directive)
+		if directive == "" || strings.EqualFold(strings.Fields(directive)[0], "frame-ancestors") {
+			continue
+		}
+		directives = append(directives, directive)
+	}
+	directives = append(directives, "frame-ancestors "+strings.Join(frameAncestors, " "))
+
+	return strings.Join(directives, "; ")
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestSetPublicDashboardEmbedHeaders(t *testing.T) {
+	params := map[string]string{":accessToken": validAccessToken}
+
+	t.Run("Only allows Grafana to frame the page by default", func(t *testing.T) {
+		cfg := setting.NewCfg()
+
+		_, resp := runMw(t, nil, "GET", "/public-dashboards/"+validAccessToken+"/embed", params, SetPublicDashboardEmbedHeaders(cfg))
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		assert.Equal(t, "object-src 'none'; base-uri 'self'; form-action 'self'; frame-ancestors 'self'", resp.Header().Get("Content-Security-Policy"))
+		assert.Equal(t, "SAMEORIGIN", resp.Header().Get("X-Frame-Options"))
+		assert.Equal(t, "allow", resp.Header().Get("X-Allow-Embedding"))
+		assert.Equal(t, "same-origin", resp.Header().Get("Referrer-Policy"))
+	})
+
+	t.Run("Allows the configured frame ancestors", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.PublicDashboardsEmbedFrameAncestors = []string{"self", "https://status.example.com"}
+
+		_, resp := runMw(t, nil, "GET", "/public-dashboards/"+validAccessToken+"/embed?theme=light", params, SetPublicDashboardEmbedHeaders(cfg))
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		assert.Contains(t, resp.Header().Get("Content-Security-Policy"), "frame-ancestors 'self' https://status.example.com")
+		assert.Empty(t, resp.Header().Get("X-Frame-Options"))
+	})
+
+	t.Run("Rejects unknown themes", func(t *testing.T) {
+		cfg := setting.NewCfg()
+
+		_, resp := runMw(t, nil, "GET", "/public-dashboards/"+validAccessToken+"/embed?theme=neon", params, SetPublicDashboardEmbedHeaders(cfg))
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
+func TestWithFrameAncestors(t *testing.T) {
+	t.Run("Replaces the frame ancestors of the configured policy", func(t *testing.T) {
+		policy := "script-src 'self' 'nonce-abc'; frame-ancestors *; img-src *"
+		assert.Equal(t, "script-src 'self' 'nonce-abc'; img-src *; frame-ancestors 'none'", withFrameAncestors(policy, []string{"'none'"}))
+	})
+
+	t.Run("Uses the embed policy without a configured policy", func(t *testing.T) {
+		assert.Equal(t, embedContentSecurityPolicy+"; frame-ancestors 'self'", withFrameAncestors("", []string{"'self'"}))
+	})
+}
@@ -0,0 +1,242 @@
+package api
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// swagger:route GET /public/dashboards/{accessToken}/panels/{panelId}/export/csv dashboards dashboard_public exportPublicDashboardPanelCSV
+//
+//	Export the data of a panel on a public dashboard as CSV
+//
+// The time range and variables are passed in the query string like for queryPublicDashboardWithParams. Numbers and
+// dates are formatted with `locale`, or with the export locale of the public dashboard when it isn't set.
+//
+// Produces:
+// - text/csv
+//
+// Responses:
+// 200: exportPublicDashboardPanelCSVResponse
+// 400: badRequestPublicError
+// 401: unauthorisedPublicError
+// 404: panelNotFoundPublicError
+// 404: notFoundPublicError
+// 403: forbiddenPublicError
+// 500: internalServerPublicError
+func (api *Api) ExportPublicDashboardPanelCSV(c *contextmodel.ReqContext) response.Response {
+	accessToken := web.Params(c.Req)[":accessToken"]
+	if !validation.IsValidAccessToken(accessToken) {
+		return response.Err(ErrInvalidAccessToken.Errorf("ExportPublicDashboardPanelCSV: invalid access token"))
+	}
+
+	panelId, err := strconv.ParseInt(web.Params(c.Req)[":panelId"], 10, 64)
+	if err != nil {
+		return response.Err(ErrInvalidPanelId.Errorf("ExportPublicDashboardPanelCSV: error parsing panelId %v", err))
+	}
+
+	params := c.Req.URL.Query()
+	reqDTO, err := queryDTOFromParams(params)
+	if err != nil {
+		return response.Err(ErrBadRequest.Errorf("ExportPublicDashboardPanelCSV: error parsing query string: %v", err))
+	}
+
+	export, err := api.PublicDashboardService.ExportPanelCSV(c.Req.Context(), c.SkipDSCache, reqDTO, panelId, accessToken, params.Get("locale"))
+	if err != nil {
+		return response.Err(err)
+	}
+
+	return exportResponse{export: export}
+}
+
+// swagger:route GET /public/dashboards/{accessToken}/panels/{panelId}/export/xlsx dashboards dashboard_public exportPublicDashboardPanelXLSX
+//
+//	Export the data of a panel on a public dashboard as an Excel workbook
+//
+// The time range and variables are passed in the query string like for queryPublicDashboardWithParams. Each query of
+// the panel gets its own sheet, named after its refId.
+//
+// Produces:
+// - application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+//
+// Responses:
+// 200: exportPublicDashboardPanelXLSXResponse
+// 400: badRequestPublicError
+// 401: unauthorisedPublicError
+// 404: panelNotFoundPublicError
+// 404: notFoundPublicError
+// 403: forbiddenPublicError
+// 500: internalServerPublicError
+func (api *Api) ExportPublicDashboardPanelXLSX(c *contextmodel.ReqContext) response.Response {
+	accessToken := web.Params(c.Req)[":accessToken"]
+	if !validation.IsValidAccessToken(accessToken) {
+		return response.Err(ErrInvalidAccessToken.Errorf("ExportPublicDashboardPanelXLSX: invalid access token"))
+	}
+
+	panelId, err := strconv.ParseInt(web.Params(c.Req)[":panelId"], 10, 64)
+	if err != nil {
+		return response.Err(ErrInvalidPanelId.Errorf("ExportPublicDashboardPanelXLSX: error parsing panelId %v", err))
+	}
+
+	reqDTO, err := queryDTOFromParams(c.Req.URL.Query())
+	if err != nil {
+		return response.Err(ErrBadRequest.Errorf("ExportPublicDashboardPanelXLSX: error parsing query string: %v", err))
+	}
+
+	export, err := api.PublicDashboardService.ExportPanelXLSX(c.Req.Context(), c.SkipDSCache, reqDTO, panelId, accessToken)
+	if err != nil {
+		return response.Err(err)
+	}
+
+	return exportResponse{export: export}
+}
+
+// swagger:route GET /public/dashboards/{accessToken}/export/pdf dashboards dashboard_public exportPublicDashboardPDF
+//
+//	Export a public dashboard as PDF
+//
+// The dashboard is rendered by the image renderer with the time range and variables passed in the query string like
+// for queryPublicDashboardWithParams. Exports of a public dashboard are rate limited, and the page size and the size of
+// the file are capped.
+//
+// Produces:
+// - application/pdf
+//
+// Responses:
+// 200: exportPublicDashboardPDFResponse
+// 400: badRequestPublicError
+// 401: unauthorisedPublicError
+// 404: notFoundPublicError
+// 403: forbiddenPublicError
+// 429: tooManyRequestsPublicError
+// 500: internalServerPublicError
+func (api *Api) ExportPublicDashboardPDF(c *contextmodel.ReqContext) response.Response {
+	accessToken := web.Params(c.Req)[":accessToken"]
+	if !validation.IsValidAccessToken(accessToken) {
+		return response.Err(ErrInvalidAccessToken.Errorf("ExportPublicDashboardPDF: invalid access token"))
+	}
+
+	reqDTO, err := renderDTOFromParams(c.Req.URL.Query())
+	if err != nil {
+		return response.Err(ErrInvalidRenderOptions.Errorf("ExportPublicDashboardPDF: error parsing query string: %v", err))
+	}
+
+	export, err := api.PublicDashboardService.ExportDashboardPDF(c.Req.Context(), reqDTO, accessToken)
+	if err != nil {
+		return response.Err(err)
+	}
+
+	return exportResponse{export: export}
+}
+
+// inlineExportMaxAge is how long clients can reuse inline exports, so embedded panel images don't render the panel on
+// every page view
+const inlineExportMaxAge = time.Minute
+
+// exportResponse streams an export of panel data to the client as an attachment. Inline exports are shown by the
+// client, like images embedded in other pages, and can be cached for a short time
+type exportResponse struct {
+	export *PanelExport
+	inline bool
+}
+
+func (r exportResponse) Status() int {
+	return http.StatusOK
+}
+
+func (r exportResponse) Body() []byte {
+	return nil
+}
+
+func (r exportResponse) WriteTo(ctx *contextmodel.ReqContext) {
+	header := ctx.Resp.Header()
+	header.Set("Content-Type", r.export.ContentType)
+	if r.inline {
+		header.Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": r.export.Filename}))
+		header.Set("Cache-Control", fmt.Sprintf("max-age=%d", int(inlineExportMaxAge.Seconds())))
+	} else {
+		header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": r.export.Filename}))
+		header.Set("Cache-Control", "no-store")
+	}
+	ctx.Resp.WriteHeader(http.StatusOK)
+
+	// the status is already sent, failures can only be logged
+	if err := r.export.WriteTo(ctx.Resp); err != nil {
+		ctx.Logger.Error("Error writing panel export", "filename", r.export.Filename, "error", err)
+	}
+}
+
+// swagger:response exportPublicDashboardPanelCSVResponse
+type ExportPublicDashboardPanelCSVResponse struct {
+	// in: body
+	Body string `json:"body"`
+}
+
+// swagger:parameters exportPublicDashboardPanelCSV
+type ExportPublicDashboardPanelCSVParams struct {
+	// in: path
+	AccessToken string `json:"accessToken"`
+	// in: path
+	PanelId int64 `json:"panelId"`
+	// in: query
+	From string `json:"from"`
+	// in: query
+	To string `json:"to"`
+	// in: query
+	Timezone string `json:"timezone"`
+	// in: query
+	Locale string `json:"locale"`
+}
+
+// swagger:response exportPublicDashboardPanelXLSXResponse
+type ExportPublicDashboardPanelXLSXResponse struct {
+	// in: body
+	Body []byte `json:"body"`
+}
+
+// swagger:parameters exportPublicDashboardPanelXLSX
+type ExportPublicDashboardPanelXLSXParams struct {
+	// in: path
+	AccessToken string `json:"accessToken"`
+	// in: path
+	PanelId int64 `json:"panelId"`
+	// in: query
+	From string `json:"from"`
+	// in: query
+	To string `json:"to"`
+	// in: query
+	Timezone string `json:"timezone"`
+}
+
+// swagger:response exportPublicDashboardPDFResponse
+type ExportPublicDashboardPDFResponse struct {
+	// in: body
+	Body []byte `json:"body"`
+}
+
+// swagger:parameters exportPublicDashboardPDF
+type ExportPublicDashboardPDFParams struct {
+	// in: path
+	AccessToken string `json:"accessToken"`
+	// in: query
+	From string `json:"from"`
+	// in: query
+	To string `json:"to"`
+	// in: query
+	Timezone string `json:"timezone"`
+	// in: query
+	Width int `json:"width"`
+	// in: query
+	Height int `json:"height"`
+	// in: query
+	// enum: light,dark
+	Theme string `json:"theme"`
+}
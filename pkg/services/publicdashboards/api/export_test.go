@@ -0,0 +1,155 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func TestAPIExportPublicDashboardPanelCSV(t *testing.T) {
+	setup := func() (*web.Mux, *publicdashboards.FakePublicDashboardService) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		testServer := setupTestServer(t, nil, service, anonymousUser)
+
+		return testServer, service
+	}
+
+	path := fmt.Sprintf("/api/public/dashboards/%s/panels/2/export/csv", validAccessToken)
+
+	t.Run("Status code is 400 when the access token is invalid", func(t *testing.T) {
+		server, _ := setup()
+		resp := callAPI(server, http.MethodGet, "/api/public/dashboards/SomeInvalidAccessToken/panels/2/export/csv", nil, t)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("Status code is 400 when the panel ID is invalid", func(t *testing.T) {
+		server, service := setup()
+		expectViewerAccess(service, validAccessToken)
+		resp := callAPI(server, http.MethodGet, fmt.Sprintf("/api/public/dashboards/%s/panels/notanumber/export/csv", validAccessToken), nil, t)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("Streams the CSV export with the time range, variables and locale of the query string", func(t *testing.T) {
+		server, service := setup()
+		expectViewerAccess(service, validAccessToken)
+		expectedDTO := PublicDashboardQueryDTO{
+			TimeRange: TimeRangeDTO{From: "now-6h", To: "now"},
+			Variables: map[string]interface{}{"env": "prod"},
+		}
+		service.On("ExportPanelCSV", mock.Anything, mock.Anything, expectedDTO, int64(2), validAccessToken, "de-DE").
+			Return(&PanelExport{
+				Filename:    "dashboard-panel-2.csv",
+				ContentType: "text/csv; charset=utf-8",
+				WriteTo: func(w io.Writer) error {
+					_, err := io.WriteString(w, "Time;Value\n")
+					return err
+				},
+			}, nil)
+
+		resp := callAPI(server, http.MethodGet, path+"?from=now-6h&to=now&var-env=prod&locale=de-DE", nil, t)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", resp.Header().Get("Content-Type"))
+		assert.Equal(t, "attachment; filename=dashboard-panel-2.csv", resp.Header().Get("Content-Disposition"))
+		assert.Equal(t, "Time;Value\n", resp.Body.String())
+	})
+
+	t.Run("Returns the error of the export", func(t *testing.T) {
+		server, service := setup()
+		expectViewerAccess(service, validAccessToken)
+		service.On("ExportPanelCSV", mock.Anything, mock.Anything, mock.Anything, int64(2), validAccessToken, "").
+			Return(nil, ErrPanelQueryFailed.Errorf(""))
+
+		resp := callAPI(server, http.MethodGet, path, nil, t)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("Streams the Excel export", func(t *testing.T) {
+		server, service := setup()
+		expectViewerAccess(service, validAccessToken)
+		service.On("ExportPanelXLSX", mock.Anything, mock.Anything, PublicDashboardQueryDTO{}, int64(2), validAccessToken).
+			Return(&PanelExport{
+				Filename:    "dashboard-panel-2.xlsx",
+				ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+				WriteTo: func(w io.Writer) error {
+					_, err := io.WriteString(w, "PK")
+					return err
+				},
+			}, nil)
+
+		resp := callAPI(server, http.MethodGet, fmt.Sprintf("/api/public/dashboards/%s/panels/2/export/xlsx", validAccessToken), nil, t)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", resp.Header().Get("Content-Type"))
+		assert.Equal(t, "attachment; filename=dashboard-panel-2.xlsx", resp.Header().Get("Content-Disposition"))
+		assert.Equal(t, "PK", resp.Body.String())
+	})
+}
+
+func TestAPIExportPublicDashboardPDF(t *testing.T) {
+	setup := func() (*web.Mux, *publicdashboards.FakePublicDashboardService) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		testServer := setupTestServer(t, nil, service, anonymousUser)
+
+		return testServer, service
+	}
+
+	path := fmt.Sprintf("/api/public/dashboards/%s/export/pdf", validAccessToken)
+
+	t.Run("Status code is 400 when the access token is invalid", func(t *testing.T) {
+		server, _ := setup()
+		resp := callAPI(server, http.MethodGet, "/api/public/dashboards/SomeInvalidAccessToken/export/pdf", nil, t)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("Status code is 400 when the width is invalid", func(t *testing.T) {
+		server, service := setup()
+		expectViewerAccess(service, validAccessToken)
+		resp := callAPI(server, http.MethodGet, path+"?width=wide", nil, t)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("Streams the PDF export with the time range, variables and page size of the query string", func(t *testing.T) {
+		server, service := setup()
+		expectViewerAccess(service, validAccessToken)
+		expectedDTO := PublicDashboardRenderDTO{
+			TimeRange: TimeRangeDTO{From: "now-6h", To: "now"},
+			Variables: map[string]interface{}{"env": "prod"},
+			Width:     1200,
+			Height:    800,
+			Theme:     "dark",
+		}
+		service.On("ExportDashboardPDF", mock.Anything, expectedDTO, validAccessToken).
+			Return(&PanelExport{
+				Filename:    "dashboard.pdf",
+				ContentType: "application/pdf",
+				WriteTo: func(w io.Writer) error {
+					_, err := io.WriteString(w, "%PDF")
+					return err
+				},
+			}, nil)
+
+		resp := callAPI(server, http.MethodGet, path+"?from=now-6h&to=now&var-env=prod&width=1200&height=800&theme=dark", nil, t)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "application/pdf", resp.Header().Get("Content-Type"))
+		assert.Equal(t, "attachment; filename=dashboard.pdf", resp.Header().Get("Content-Disposition"))
+		assert.Equal(t, "%PDF", resp.Body.String())
+	})
+
+	t.Run("Status code is 429 when the export is rate limited", func(t *testing.T) {
+		server, service := setup()
+		expectViewerAccess(service, validAccessToken)
+		service.On("ExportDashboardPDF", mock.Anything, PublicDashboardRenderDTO{}, validAccessToken).
+			Return(nil, ErrRenderRateLimited.Errorf(""))
+
+		resp := callAPI(server, http.MethodGet, path, nil, t)
+		require.Equal(t, http.StatusTooManyRequests, resp.Code)
+	})
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// HeaderGeoIPProvider reads the country of the client from a header set by a trusted proxy or CDN
+type HeaderGeoIPProvider struct {
+	header string
+}
+
+var _ publicdashboards.GeoIPProvider = (*HeaderGeoIPProvider)(nil)
+
+func ProvideGeoIPProvider(cfg *setting.Cfg) *HeaderGeoIPProvider {
+	return &HeaderGeoIPProvider{header: cfg.PublicDashboardsGeoIPCountryHeader}
+}
+
+// LookupCountry returns the country of the configured header. Values that aren't two letter country codes, like the
+// "XX" or "T1" markers some CDNs send for unknown clients, are reported as unknown
+func (p *HeaderGeoIPProvider) LookupCountry(_ context.Context, req *http.Request) (string, error) {
+	if p.header == "" {
+		return "", nil
+	}
+
+	country := strings.ToUpper(strings.TrimSpace(req.Header.Get(p.header)))
+	if len(country) != 2 || country == "XX" || !isASCIILetters(country) {
+		return "", nil
+	}
+
+	return country, nil
+}
+
+func isASCIILetters(s string) bool {
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
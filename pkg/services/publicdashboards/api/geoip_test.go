@@ -0,0 +1,43 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestHeaderGeoIPProvider(t *testing.T) {
+	testCases := []struct {
+		name     string
+		header   string
+		value    string
+		expected string
+	}{
+		{name: "reads the country from the header", header: "CF-IPCountry", value: "de", expected: "DE"},
+		{name: "reports unknown countries", header: "CF-IPCountry", value: "XX", expected: ""},
+		{name: "ignores values that are not country codes", header: "CF-IPCountry", value: "T1", expected: ""},
+		{name: "ignores missing headers", header: "CF-IPCountry", value: "", expected: ""},
+		{name: "reports unknown countries without a configured header", header: "", value: "DE", expected: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := setting.NewCfg()
+			cfg.PublicDashboardsGeoIPCountryHeader = tc.header
+			provider := ProvideGeoIPProvider(cfg)
+
+			req, err := http.NewRequest(http.MethodGet, "/", nil)
+			require.NoError(t, err)
+			req.Header.Set("CF-IPCountry", tc.value)
+
+			country, err := provider.LookupCountry(context.Background(), req)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, country)
+		})
+	}
+}
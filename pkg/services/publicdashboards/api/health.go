@@ -0,0 +1,61 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// swagger:route GET /dashboards/uid/{dashboardUid}/public-dashboards/{uid}/health dashboards dashboard_public checkPublicDashboardHealth
+//
+//	Check the health of the datasources of a public dashboard
+//
+// Runs the health check of every datasource queried by the panels of the public dashboard, with the identity the
+// queries of viewers run with, and returns the panels that will fail publicly. Datasources set by a variable are
+// reported with an UNKNOWN status.
+//
+// Responses:
+// 200: checkPublicDashboardHealthResponse
+// 400: badRequestPublicError
+// 401: unauthorisedPublicError
+// 403: forbiddenPublicError
+// 404: notFoundPublicError
+// 500: internalServerPublicError
+func (api *Api) CheckPublicDashboardHealth(c *contextmodel.ReqContext) response.Response {
+	dashboardUid := web.Params(c.Req)[":dashboardUid"]
+	if !validation.IsValidShortUID(dashboardUid) {
+		return response.Err(ErrInvalidUid.Errorf("CheckPublicDashboardHealth: invalid dashboard Uid %s", dashboardUid))
+	}
+
+	uid := web.Params(c.Req)[":uid"]
+	if !validation.IsValidShortUID(uid) {
+		return response.Err(ErrInvalidUid.Errorf("CheckPublicDashboardHealth: invalid Uid %s", uid))
+	}
+
+	health, err := api.PublicDashboardService.CheckHealth(c.Req.Context(), c.SignedInUser, uid, dashboardUid)
+	if err != nil {
+		return response.Err(err)
+	}
+
+	return response.JSON(http.StatusOK, health)
+}
+
+// swagger:parameters checkPublicDashboardHealth
+type CheckPublicDashboardHealthParams struct {
+	// in:path
+	// required:true
+	DashboardUid string `json:"dashboardUid"`
+	// in:path
+	// required:true
+	Uid string `json:"uid"`
+}
+
+// swagger:response checkPublicDashboardHealthResponse
+type CheckPublicDashboardHealthResponse struct {
+	// in: body
+	Body PublicDashboardHealth `json:"body"`
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+func TestAPICheckPublicDashboardHealth(t *testing.T) {
+	path := "/api/dashboards/uid/abc123/public-dashboards/pubdash1/health"
+
+	t.Run("Returns the failing panels", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("CheckHealth", mock.Anything, mock.Anything, "pubdash1", "abc123").
+			Return(&PublicDashboardHealth{
+				Datasources:     []DatasourceHealth{{Uid: "ds1", Status: "ERROR", Message: "connection refused", PanelIds: []int64{2}}},
+				FailingPanelIds: []int64{2},
+			}, nil)
+		server := setupTestServer(t, nil, service, userAdmin)
+
+		resp := callAPI(server, http.MethodGet, path, nil, t)
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.JSONEq(t, `{"healthy":false,"datasources":[{"uid":"ds1","status":"ERROR","message":"connection refused","panelIds":[2]}],"failingPanelIds":[2]}`, resp.Body.String())
+	})
+
+	t.Run("Status code is 403 for viewers", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		server := setupTestServer(t, nil, service, userViewer)
+
+		resp := callAPI(server, http.MethodGet, path, nil, t)
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		service.AssertNotCalled(t, "CheckHealth")
+	})
+}
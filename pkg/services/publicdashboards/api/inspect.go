@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// swagger:route POST /dashboards/uid/{dashboardUid}/public-dashboards/{uid}/panels/{panelId}/inspect dashboards dashboard_public inspectPublicDashboardQuery
+//
+//	Inspect the queries of a panel on a public dashboard
+//
+// Runs the queries of the panel exactly like viewers of the public dashboard do, with the same variables,
+// sanitization and safe interval, and returns the queries sent to the datasources with the full metadata of the
+// frames, including the executed query strings that are removed for viewers. Works while the public dashboard is
+// disabled.
+//
+// Responses:
+// 200: inspectPublicDashboardQueryResponse
+// 400: badRequestPublicError
+// 401: unauthorisedPublicError
+// 403: forbiddenPublicError
+// 404: notFoundPublicError
+// 500: internalServerPublicError
+func (api *Api) InspectPublicDashboardQuery(c *contextmodel.ReqContext) response.Response {
+	dashboardUid := web.Params(c.Req)[":dashboardUid"]
+	if !validation.IsValidShortUID(dashboardUid) {
+		return response.Err(ErrInvalidUid.Errorf("InspectPublicDashboardQuery: invalid dashboard Uid %s", dashboardUid))
+	}
+
+	uid := web.Params(c.Req)[":uid"]
+	if !validation.IsValidShortUID(uid) {
+		return response.Err(ErrInvalidUid.Errorf("InspectPublicDashboardQuery: invalid Uid %s", uid))
+	}
+
+	panelId, err := strconv.ParseInt(web.Params(c.Req)[":panelId"], 10, 64)
+	if err != nil {
+		return response.Err(ErrInvalidPanelId.Errorf("InspectPublicDashboardQuery: error parsing panelId %v", err))
+	}
+
+	reqDTO := PublicDashboardQueryDTO{}
+	if err = web.Bind(c.Req, &reqDTO); err != nil {
+		return response.Err(ErrBadRequest.Errorf("InspectPublicDashboardQuery: error parsing request: %v", err))
+	}
+
+	inspection, err := api.PublicDashboardService.InspectPanelQuery(c.Req.Context(), c.SignedInUser, uid, dashboardUid, panelId, c.SkipDSCache, reqDTO)
+	if err != nil {
+		return response.Err(err)
+	}
+
+	return response.JSON(http.StatusOK, inspection)
+}
+
+// swagger:parameters inspectPublicDashboardQuery
+type InspectPublicDashboardQueryParams struct {
+	// in:path
+	// required:true
+	DashboardUid string `json:"dashboardUid"`
+	// in:path
+	// required:true
+	Uid string `json:"uid"`
+	// in:path
+	// required:true
+	PanelId int64 `json:"panelId"`
+	// in:body
+	// required:true
+	Body PublicDashboardQueryDTO
+}
+
+// swagger:response inspectPublicDashboardQueryResponse
+type InspectPublicDashboardQueryResponse struct {
+	// in: body
+	Body PublicDashboardQueryInspection `json:"body"`
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+func TestAPIInspectPublicDashboardQuery(t *testing.T) {
+	path := "/api/dashboards/uid/abc123/public-dashboards/pubdash1/panels/2/inspect"
+
+	t.Run("Returns the executed query strings", func(t *testing.T) {
+		frame := data.NewFrame("A").SetMeta(&data.FrameMeta{ExecutedQueryString: "SELECT 1"})
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("InspectPanelQuery", mock.Anything, mock.Anything, "pubdash1", "abc123", int64(2), false, mock.Anything).
+			Return(&PublicDashboardQueryInspection{
+				Response: &backend.QueryDataResponse{Responses: backend.Responses{"A": {Frames: data.Frames{frame}}}},
+			}, nil)
+		server := setupTestServer(t, nil, service, userAdmin)
+
+		resp := callAPI(server, http.MethodPost, path, strings.NewReader(`{}`), t)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"executedQueryString":"SELECT 1"`)
+	})
+
+	t.Run("Status code is 403 for viewers", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		server := setupTestServer(t, nil, service, userViewer)
+
+		resp := callAPI(server, http.MethodPost, path, strings.NewReader(`{}`), t)
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		service.AssertNotCalled(t, "InspectPanelQuery")
+	})
+
+	t.Run("Status code is 400 for invalid panel ids", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		server := setupTestServer(t, nil, service, userAdmin)
+
+		resp := callAPI(server, http.MethodPost, "/api/dashboards/uid/abc123/public-dashboards/pubdash1/panels/notanumber/inspect", strings.NewReader(`{}`), t)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
@@ -0,0 +1,14 @@
+package api
+
+import (
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// PublicDashboardLiveWebsocket opens a Grafana Live connection for an anonymous viewer of a public dashboard. The
+// connection can only subscribe to the grafana/public-dashboard/<accessToken> channel of the public dashboard, which
+// pushes refreshed variable options and tells viewers to reload the dashboard when it changed, and to the streams of
+// its panels bridged below that channel
+func (api *Api) PublicDashboardLiveWebsocket(c *contextmodel.ReqContext) {
+	api.live.HandlePublicDashboardWebsocket(c, web.Params(c.Req)[":accessToken"])
+}
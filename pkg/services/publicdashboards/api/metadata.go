@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// swagger:route GET /public/dashboards/{accessToken}/metadata dashboards dashboard_public getPublicDashboardMetadata
+//
+//	Get the metadata of a public dashboard
+//
+// Returns the title, panels, time settings, enabled features and variables of the public dashboard, for embedders
+// building their own UI without the full dashboard JSON.
+//
+// Responses:
+// 200: getPublicDashboardMetadataResponse
+// 400: badRequestPublicError
+// 401: unauthorisedPublicError
+// 404: notFoundPublicError
+// 403: forbiddenPublicError
+// 500: internalServerPublicError
+func (api *Api) GetPublicDashboardMetadata(c *contextmodel.ReqContext) response.Response {
+	accessToken := web.Params(c.Req)[":accessToken"]
+	if !validation.IsValidAccessToken(accessToken) {
+		return response.Err(ErrInvalidAccessToken.Errorf("GetPublicDashboardMetadata: invalid access token"))
+	}
+
+	metadata, err := api.PublicDashboardService.GetMetadata(c.Req.Context(), accessToken)
+	if err != nil {
+		return response.Err(err)
+	}
+
+	return response.JSON(http.StatusOK, metadata)
+}
+
+// swagger:response getPublicDashboardMetadataResponse
+type GetPublicDashboardMetadataResponse struct {
+	// in: body
+	Body PublicDashboardMetadata `json:"body"`
+}
+
+// swagger:parameters getPublicDashboardMetadata
+type GetPublicDashboardMetadataParams struct {
+	// in: path
+	AccessToken string `json:"accessToken"`
+}
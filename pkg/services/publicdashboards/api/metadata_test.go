@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+func TestAPIGetPublicDashboardMetadata(t *testing.T) {
+	path := fmt.Sprintf("/api/public/dashboards/%s/metadata", validAccessToken)
+
+	t.Run("Returns the metadata of the public dashboard", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("GetMetadata", mock.Anything, validAccessToken).Return(&PublicDashboardMetadata{
+			Title:                "Service health",
+			Panels:               []PublicDashboardPanel{{Id: 1, Title: "Requests", Type: "timeseries"}},
+			Time:                 PublicDashboardTime{From: "now-6h", To: "now"},
+			TimeSelectionEnabled: true,
+			Variables:            []PublicDashboardVariable{{Name: "env", Type: "custom"}},
+		}, nil)
+		expectViewerAccess(service, validAccessToken)
+		server := setupTestServer(t, nil, service, anonymousUser)
+
+		resp := callAPI(server, http.MethodGet, path, nil, t)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var metadata PublicDashboardMetadata
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &metadata))
+		assert.Equal(t, "Service health", metadata.Title)
+		assert.Equal(t, []PublicDashboardPanel{{Id: 1, Title: "Requests", Type: "timeseries"}}, metadata.Panels)
+		assert.Equal(t, "now-6h", metadata.Time.From)
+		assert.True(t, metadata.TimeSelectionEnabled)
+		assert.False(t, metadata.AnnotationsEnabled)
+		require.Len(t, metadata.Variables, 1)
+		assert.Equal(t, "env", metadata.Variables[0].Name)
+	})
+
+	t.Run("Status code is 400 when the access token is invalid", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		server := setupTestServer(t, nil, service, anonymousUser)
+
+		resp := callAPI(server, http.MethodGet, "/api/public/dashboards/Invalid-Token/metadata", nil, t)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("Status code is 404 when the public dashboard is not found", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("GetMetadata", mock.Anything, validAccessToken).Return(nil, ErrPublicDashboardNotFound.Errorf(""))
+		expectViewerAccess(service, validAccessToken)
+		server := setupTestServer(t, nil, service, anonymousUser)
+
+		resp := callAPI(server, http.MethodGet, path, nil, t)
+		require.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
@@ -1,11 +1,16 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"net/url"
 
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/metrics"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
 	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
 	"github.com/grafana/grafana/pkg/web"
 )
@@ -14,7 +19,7 @@ import (
 func SetPublicDashboardOrgIdOnContext(publicDashboardService publicdashboards.Service) func(c *contextmodel.ReqContext) {
 	return func(c *contextmodel.ReqContext) {
 		accessToken, ok := web.Params(c.Req)[":accessToken"]
-		if !ok || !validation.IsValidAccessToken(accessToken) {
+		if !ok || !validation.IsValidAccessTokenOrSlug(accessToken) {
 			return
 		}
 
@@ -45,12 +50,17 @@ func RequiresExistingAccessToken(publicDashboardService publicdashboards.Service
 			return
 		}
 
-		if !validation.IsValidAccessToken(accessToken) {
+		if !validation.IsValidAccessTokenOrSlug(accessToken) {
 			c.JsonApiErr(http.StatusBadRequest, "Invalid access token", nil)
+			return
 		}
 
 		// Check that the access token references an enabled public dashboard
 		exists, err := publicDashboardService.ExistsEnabledByAccessToken(c.Req.Context(), accessToken)
+		if errors.Is(err, models.ErrPublicDashboardExpired) {
+			c.WriteErr(err)
+			return
+		}
 		if err != nil {
 			c.JsonApiErr(http.StatusInternalServerError, "Failed to query access token", nil)
 			return
@@ -62,6 +72,159 @@ func RequiresExistingAccessToken(publicDashboardService publicdashboards.Service
 	}
 }
 
+// RequiresAllowedCountry Middleware to enforce the geo restriction of a public dashboard. Requests from countries the
+// public dashboard isn't available in are rejected with a 403 and recorded in the audit log. Unknown access tokens and
+// slugs are left to the handlers
+func RequiresAllowedCountry(publicDashboardService publicdashboards.Service, geoIPProvider publicdashboards.GeoIPProvider) func(c *contextmodel.ReqContext) {
+	auditLog := log.New("publicdashboards.audit")
+
+	return func(c *contextmodel.ReqContext) {
+		pubdash := findRequestPublicDashboard(c, publicDashboardService)
+		if pubdash == nil || pubdash.GeoRestriction == nil {
+			return
+		}
+
+		country, err := geoIPProvider.LookupCountry(c.Req.Context(), c.Req)
+		if err != nil {
+			auditLog.Warn("Failed to look up the country of a public dashboard request", "publicDashboardUid", pubdash.Uid, "error", err)
+			country = ""
+		}
+
+		if pubdash.GeoRestriction.Allows(country) {
+			return
+		}
+
+		auditLog.Info("Denied public dashboard access by geo restriction",
+			"publicDashboardUid", pubdash.Uid,
+			"dashboardUid", pubdash.DashboardUid,
+			"orgId", pubdash.OrgId,
+			"country", country,
+			"mode", pubdash.GeoRestriction.Mode,
+			"remoteAddr", c.RemoteAddr(),
+			"path", c.Req.URL.Path,
+		)
+		c.WriteErr(models.ErrPublicDashboardGeoRestricted.Errorf("RequiresAllowedCountry: access from country %q is not allowed", country))
+	}
+}
+
+// RequiresAllowedDomain Middleware to enforce the allowed domains of a public dashboard. Requests are checked by their
+// Origin header, or their Referer header without Origin, and are rejected with a 403 unless they come from Grafana
+// itself or from one of the allowed domains. Cross-origin requests from allowed domains get CORS headers for their
+// origin. Unknown access tokens and slugs are left to the handlers
+func RequiresAllowedDomain(publicDashboardService publicdashboards.Service) func(c *contextmodel.ReqContext) {
+	auditLog := log.New("publicdashboards.audit")
+
+	return func(c *contextmodel.ReqContext) {
+		pubdash := findRequestPublicDashboard(c, publicDashboardService)
+		if pubdash == nil || pubdash.AllowedDomains == nil {
+			return
+		}
+
+		origin := requestOrigin(c.Req)
+		if origin != nil && origin.Host == c.Req.Host {
+			return
+		}
+
+		if origin != nil && pubdash.AllowsDomain(origin.Hostname()) {
+			if c.Req.Header.Get("Origin") != "" {
+				header := c.Resp.Header()
+				header.Set("Access-Control-Allow-Origin", origin.Scheme+"://"+origin.Host)
+				header.Add("Vary", "Origin")
+			}
+			return
+		}
+
+		var host string
+		if origin != nil {
+			host = origin.Host
+		}
+		auditLog.Info("Denied public dashboard access by domain restriction",
+			"publicDashboardUid", pubdash.Uid,
+			"dashboardUid", pubdash.DashboardUid,
+			"orgId", pubdash.OrgId,
+			"origin", host,
+			"remoteAddr", c.RemoteAddr(),
+			"path", c.Req.URL.Path,
+		)
+		c.WriteErr(models.ErrPublicDashboardDomainRestricted.Errorf("RequiresAllowedDomain: access from origin %q is not allowed", host))
+	}
+}
+
+const (
+	viewerTokenHeader = "X-Grafana-Public-Dashboard-Token"
+	// EventSource can't set headers, so streams send the viewer token in the query string
+	viewerTokenQueryParam = "viewerToken"
+)
+
+// RequiresViewerToken Middleware to enforce that requests carry a valid viewer token for the access token and the
+// client, in the X-Grafana-Public-Dashboard-Token header or the viewerToken query parameter. Requests without one are
+// rejected with a 401, and requests of viewers above the limit of concurrent viewers with a 429
+func RequiresViewerToken(publicDashboardService publicdashboards.Service, clientIPs *ClientIPResolver) func(c *contextmodel.ReqContext) {
+	return func(c *contextmodel.ReqContext) {
+		accessToken, ok := web.Params(c.Req)[":accessToken"]
+		if !ok || !validation.IsValidAccessTokenOrSlug(accessToken) {
+			return
+		}
+
+		token := c.Req.Header.Get(viewerTokenHeader)
+		if token == "" {
+			token = c.Req.URL.Query().Get(viewerTokenQueryParam)
+		}
+
+		if err := publicDashboardService.ValidateViewerToken(c.Req.Context(), accessToken, token, viewerClient(c, clientIPs)); err != nil {
+			if retryAfter := viewerLimitRetryAfter(err); retryAfter != "" {
+				c.Resp.Header().Set("Retry-After", retryAfter)
+			}
+			c.WriteErr(err)
+		}
+	}
+}
+
+// requestOrigin returns the origin of the request, from the Origin header or the Referer header. Nil when the request
+// has neither or they can't be parsed
+func requestOrigin(req *http.Request) *url.URL {
+	value := req.Header.Get("Origin")
+	if value == "" || value == "null" {
+		value = req.Referer()
+	}
+
+	origin, err := url.Parse(value)
+	if err != nil || origin.Scheme == "" || origin.Host == "" {
+		return nil
+	}
+	return origin
+}
+
+// findRequestPublicDashboard returns the public dashboard of the access token or slug of the request, nil when there
+// is none
+func findRequestPublicDashboard(c *contextmodel.ReqContext, publicDashboardService publicdashboards.Service) *models.PublicDashboard {
+	accessToken, ok := web.Params(c.Req)[":accessToken"]
+	if !ok {
+		return nil
+	}
+	return FindPublicDashboard(c.Req.Context(), publicDashboardService, accessToken)
+}
+
+// FindPublicDashboard returns the public dashboard of the access token or slug, nil when there is none. Access tokens
+// also find paused and expired public dashboards, slugs only find viewable ones
+func FindPublicDashboard(ctx context.Context, publicDashboardService publicdashboards.Service, accessToken string) *models.PublicDashboard {
+	if !validation.IsValidAccessTokenOrSlug(accessToken) {
+		return nil
+	}
+
+	var pubdash *models.PublicDashboard
+	var err error
+	if validation.IsValidAccessToken(accessToken) {
+		pubdash, err = publicDashboardService.FindByAccessToken(ctx, accessToken)
+	} else {
+		pubdash, _, err = publicDashboardService.FindEnabledPublicDashboardAndDashboardByAccessToken(ctx, accessToken)
+	}
+	if err != nil {
+		return nil
+	}
+	return pubdash
+}
+
 func CountPublicDashboardRequest() func(c *contextmodel.ReqContext) {
 	return func(c *contextmodel.ReqContext) {
 		metrics.MPublicDashboardRequestCount.Inc()
@@ -10,6 +10,7 @@ import (
 
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	publicdashboardModels "github.com/grafana/grafana/pkg/services/publicdashboards/models"
 	"github.com/grafana/grafana/pkg/services/publicdashboards/service"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/web"
@@ -61,6 +62,22 @@ func TestRequiresExistingAccessToken(t *testing.T) {
 			AccessToken:          validAccessToken,
 			ExpectedResponseCode: http.StatusNotFound,
 		},
+		{
+			Name:                 "Returns 200 when public dashboard with slug exists",
+			Path:                 "/api/public/ma/events/status-page",
+			AccessTokenExists:    true,
+			AccessTokenExistsErr: nil,
+			AccessToken:          "status-page",
+			ExpectedResponseCode: http.StatusOK,
+		},
+		{
+			Name:                 "Returns 410 when public dashboard expired",
+			Path:                 "/api/public/ma/events/myAccesstoken",
+			AccessTokenExists:    false,
+			AccessTokenExistsErr: publicdashboardModels.ErrPublicDashboardExpired.Errorf("expired"),
+			AccessToken:          validAccessToken,
+			ExpectedResponseCode: http.StatusGone,
+		},
 		{
 			Name:                 "Returns 500 when public dashboard service gives an error",
 			Path:                 "/api/public/ma/events/myAccesstoken",
@@ -98,6 +115,13 @@ func TestSetPublicDashboardOrgIdOnContext(t *testing.T) {
 			ErrorResp:     nil,
 			ExpectedOrgId: 7,
 		},
+		{
+			Name:          "Adds orgId for public dashboard slug",
+			AccessToken:   "status-page",
+			OrgIdResp:     7,
+			ErrorResp:     nil,
+			ExpectedOrgId: 7,
+		},
 		{
 			Name:          "Does not set orgId or fail with invalid accessToken",
 			AccessToken:   "invalidAccessToken",
@@ -144,6 +168,237 @@ func TestSetPublicDashboardOrgIdOnContext(t *testing.T) {
 	}
 }
 
+func TestRequiresAllowedCountry(t *testing.T) {
+	tests := []struct {
+		Name                 string
+		AccessToken          string
+		GeoRestriction       *publicdashboardModels.GeoRestriction
+		FindErr              error
+		Country              string
+		ExpectedResponseCode int
+	}{
+		{
+			Name:                 "Allows requests to public dashboards without geo restriction",
+			AccessToken:          validAccessToken,
+			Country:              "DE",
+			ExpectedResponseCode: http.StatusOK,
+		},
+		{
+			Name:                 "Allows requests from allowed countries",
+			AccessToken:          validAccessToken,
+			GeoRestriction:       &publicdashboardModels.GeoRestriction{Mode: publicdashboardModels.GeoRestrictionModeAllow, Countries: []string{"DE", "FR"}},
+			Country:              "FR",
+			ExpectedResponseCode: http.StatusOK,
+		},
+		{
+			Name:                 "Returns 403 for countries outside the allow list",
+			AccessToken:          validAccessToken,
+			GeoRestriction:       &publicdashboardModels.GeoRestriction{Mode: publicdashboardModels.GeoRestrictionModeAllow, Countries: []string{"DE", "FR"}},
+			Country:              "US",
+			ExpectedResponseCode: http.StatusForbidden,
+		},
+		{
+			Name:                 "Returns 403 for unknown countries with an allow list",
+			AccessToken:          validAccessToken,
+			GeoRestriction:       &publicdashboardModels.GeoRestriction{Mode: publicdashboardModels.GeoRestrictionModeAllow, Countries: []string{"DE"}},
+			Country:              "",
+			ExpectedResponseCode: http.StatusForbidden,
+		},
+		{
+			Name:                 "Returns 403 for blocked countries",
+			AccessToken:          validAccessToken,
+			GeoRestriction:       &publicdashboardModels.GeoRestriction{Mode: publicdashboardModels.GeoRestrictionModeDeny, Countries: []string{"US"}},
+			Country:              "US",
+			ExpectedResponseCode: http.StatusForbidden,
+		},
+		{
+			Name:                 "Allows unknown countries with a block list",
+			AccessToken:          validAccessToken,
+			GeoRestriction:       &publicdashboardModels.GeoRestriction{Mode: publicdashboardModels.GeoRestrictionModeDeny, Countries: []string{"US"}},
+			Country:              "",
+			ExpectedResponseCode: http.StatusOK,
+		},
+		{
+			Name:                 "Leaves unknown public dashboards to the handlers",
+			AccessToken:          validAccessToken,
+			FindErr:              publicdashboardModels.ErrPublicDashboardNotFound.Errorf(""),
+			ExpectedResponseCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			var pubdash *publicdashboardModels.PublicDashboard
+			if tt.FindErr == nil {
+				pubdash = &publicdashboardModels.PublicDashboard{Uid: "pubdash", GeoRestriction: tt.GeoRestriction}
+			}
+			publicdashboardService := &publicdashboards.FakePublicDashboardService{}
+			publicdashboardService.On("FindByAccessToken", mock.Anything, tt.AccessToken).Return(pubdash, tt.FindErr)
+			geoIPProvider := &publicdashboards.FakeGeoIPProvider{}
+			geoIPProvider.On("LookupCountry", mock.Anything, mock.Anything).Return(tt.Country, nil)
+
+			params := map[string]string{":accessToken": tt.AccessToken}
+			mw := RequiresAllowedCountry(publicdashboardService, geoIPProvider)
+			_, resp := runMw(t, nil, "GET", "/api/public/dashboards/myAccesstoken", params, mw)
+			require.Equal(t, tt.ExpectedResponseCode, resp.Code)
+		})
+	}
+}
+
+func TestRequiresAllowedDomain(t *testing.T) {
+	tests := []struct {
+		Name                 string
+		AllowedDomains       []string
+		Origin               string
+		Referer              string
+		ExpectedResponseCode int
+		ExpectedAllowOrigin  string
+	}{
+		{
+			Name:                 "Allows requests to public dashboards without allowed domains",
+			Referer:              "https://other.example.org/page",
+			ExpectedResponseCode: http.StatusOK,
+		},
+		{
+			Name:                 "Allows requests from allowed domains with CORS headers",
+			AllowedDomains:       []string{"portal.example.com"},
+			Origin:               "https://portal.example.com",
+			ExpectedResponseCode: http.StatusOK,
+			ExpectedAllowOrigin:  "https://portal.example.com",
+		},
+		{
+			Name:                 "Allows subdomains of wildcard domains",
+			AllowedDomains:       []string{"*.example.com"},
+			Origin:               "https://eu.portal.example.com:8443",
+			ExpectedResponseCode: http.StatusOK,
+			ExpectedAllowOrigin:  "https://eu.portal.example.com:8443",
+		},
+		{
+			Name:                 "Allows requests referred by allowed domains without CORS headers",
+			AllowedDomains:       []string{"portal.example.com"},
+			Referer:              "https://portal.example.com/dashboards",
+			ExpectedResponseCode: http.StatusOK,
+		},
+		{
+			Name:                 "Allows requests of Grafana itself",
+			AllowedDomains:       []string{"portal.example.com"},
+			Referer:              "https://grafana.example.com/public-dashboards/abc/embed",
+			ExpectedResponseCode: http.StatusOK,
+		},
+		{
+			Name:                 "Returns 403 for other domains",
+			AllowedDomains:       []string{"portal.example.com"},
+			Origin:               "https://portal.example.com.attacker.net",
+			ExpectedResponseCode: http.StatusForbidden,
+		},
+		{
+			Name:                 "Returns 403 without Origin and Referer",
+			AllowedDomains:       []string{"portal.example.com"},
+			ExpectedResponseCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			pubdash := &publicdashboardModels.PublicDashboard{Uid: "pubdash", AllowedDomains: tt.AllowedDomains}
+			publicdashboardService := &publicdashboards.FakePublicDashboardService{}
+			publicdashboardService.On("FindByAccessToken", mock.Anything, validAccessToken).Return(pubdash, nil)
+
+			params := map[string]string{":accessToken": validAccessToken}
+			mw := func(c *contextmodel.ReqContext) {
+				c.Req.Host = "grafana.example.com"
+				if tt.Origin != "" {
+					c.Req.Header.Set("Origin", tt.Origin)
+				}
+				if tt.Referer != "" {
+					c.Req.Header.Set("Referer", tt.Referer)
+				}
+				RequiresAllowedDomain(publicdashboardService)(c)
+			}
+			_, resp := runMw(t, nil, "GET", "/api/public/dashboards/myAccesstoken", params, mw)
+			require.Equal(t, tt.ExpectedResponseCode, resp.Code)
+			assert.Equal(t, tt.ExpectedAllowOrigin, resp.Header().Get("Access-Control-Allow-Origin"))
+		})
+	}
+}
+
+func TestRequiresViewerToken(t *testing.T) {
+	client := publicdashboardModels.ViewerClient{IP: "10.0.0.1", UserAgent: "Mozilla/5.0"}
+	tests := []struct {
+		Name                 string
+		Header               string
+		Path                 string
+		ExpectedToken        string
+		ValidateErr          error
+		ExpectedResponseCode int
+		ExpectedRetryAfter   string
+	}{
+		{
+			Name:                 "Allows requests with a valid token in the header",
+			Header:               "token",
+			Path:                 "/api/public/dashboards/myAccesstoken",
+			ExpectedToken:        "token",
+			ExpectedResponseCode: http.StatusOK,
+		},
+		{
+			Name:                 "Allows requests with a valid token in the query string",
+			Path:                 "/api/public/dashboards/myAccesstoken?viewerToken=token",
+			ExpectedToken:        "token",
+			ExpectedResponseCode: http.StatusOK,
+		},
+		{
+			Name:                 "Returns 401 for invalid tokens",
+			Header:               "token",
+			Path:                 "/api/public/dashboards/myAccesstoken",
+			ExpectedToken:        "token",
+			ValidateErr:          publicdashboardModels.ErrInvalidViewerToken.Errorf("invalid"),
+			ExpectedResponseCode: http.StatusUnauthorized,
+		},
+		{
+			Name:                 "Returns 401 without a token",
+			Path:                 "/api/public/dashboards/myAccesstoken",
+			ExpectedToken:        "",
+			ValidateErr:          publicdashboardModels.ErrInvalidViewerToken.Errorf("missing"),
+			ExpectedResponseCode: http.StatusUnauthorized,
+		},
+		{
+			Name:                 "Returns 429 with a retry after when the viewer limit is reached",
+			Header:               "token",
+			Path:                 "/api/public/dashboards/myAccesstoken",
+			ExpectedToken:        "token",
+			ValidateErr:          viewerLimitReached(42),
+			ExpectedResponseCode: http.StatusTooManyRequests,
+			ExpectedRetryAfter:   "42",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			publicdashboardService := publicdashboards.NewFakePublicDashboardService(t)
+			publicdashboardService.On("ValidateViewerToken", mock.Anything, validAccessToken, tt.ExpectedToken, client).Return(tt.ValidateErr)
+
+			params := map[string]string{":accessToken": validAccessToken}
+			mw := func(c *contextmodel.ReqContext) {
+				c.Req.RemoteAddr = client.IP + ":51234"
+				c.Req.Header.Set("User-Agent", client.UserAgent)
+				if tt.Header != "" {
+					c.Req.Header.Set("X-Grafana-Public-Dashboard-Token", tt.Header)
+				}
+				RequiresViewerToken(publicdashboardService, nil)(c)
+			}
+			_, resp := runMw(t, nil, "GET", tt.Path, params, mw)
+			require.Equal(t, tt.ExpectedResponseCode, resp.Code)
+			assert.Equal(t, tt.ExpectedRetryAfter, resp.Header().Get("Retry-After"))
+		})
+	}
+}
+
+func viewerLimitReached(retryAfterSeconds int) error {
+	err := publicdashboardModels.ErrViewerLimitReached.Errorf("limit reached")
+	err.PublicPayload = map[string]any{"retryAfterSeconds": retryAfterSeconds}
+	return err
+}
+
 func TestSetPublicDashboardFlag(t *testing.T) {
 	t.Run("Adds context.PublicDashboardAccessToken to request", func(t *testing.T) {
 		ctx := &contextmodel.ReqContext{Context: &web.Context{Req: web.SetURLParams(&http.Request{}, map[string]string{":accessToken": "asdfasdfasdfsadfasdfsfd"})}}
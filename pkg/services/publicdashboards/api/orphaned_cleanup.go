@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+// swagger:route POST /admin/public-dashboards/cleanup-orphaned dashboards dashboard_public cleanupOrphanedPublicDashboards
+//
+//	Delete orphaned public dashboards
+//
+// Deletes the public dashboards of every organization whose dashboards were deleted, like the scheduled cleanup
+// configured with orphaned_cleanup_interval. With dryRun the orphaned public dashboards are only returned. Only
+// Grafana admins can run the cleanup.
+//
+// Produces:
+// - application/json
+//
+// Responses:
+// 200: cleanupOrphanedPublicDashboardsResponse
+// 400: badRequestPublicError
+// 401: unauthorisedPublicError
+// 403: forbiddenPublicError
+// 500: internalServerPublicError
+func (api *Api) CleanupOrphanedPublicDashboards(c *contextmodel.ReqContext) response.Response {
+	dryRun, err := queryBool(c, "dryRun")
+	if err != nil {
+		return response.Err(ErrBadRequest.Errorf("CleanupOrphanedPublicDashboards: invalid dryRun: %v", err))
+	}
+
+	result, err := api.PublicDashboardService.CleanupOrphaned(c.Req.Context(), dryRun != nil && *dryRun)
+	if err != nil {
+		return response.Err(err)
+	}
+
+	return response.JSON(http.StatusOK, result)
+}
+
+// swagger:parameters cleanupOrphanedPublicDashboards
+type CleanupOrphanedPublicDashboardsParams struct {
+	// in:query
+	// required:false
+	DryRun bool `json:"dryRun"`
+}
+
+// swagger:response cleanupOrphanedPublicDashboardsResponse
+type CleanupOrphanedPublicDashboardsResponse struct {
+	// in: body
+	Body OrphanedCleanupResult `json:"body"`
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+func TestAPICleanupOrphanedPublicDashboards(t *testing.T) {
+	path := "/api/admin/public-dashboards/cleanup-orphaned"
+	grafanaAdmin := &user.SignedInUser{UserID: 1, OrgID: 1, OrgRole: org.RoleAdmin, Login: "admin", IsGrafanaAdmin: true}
+
+	t.Run("Returns the orphaned public dashboards found in a dry run", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("CleanupOrphaned", mock.Anything, true).Return(&OrphanedCleanupResult{
+			DryRun:   true,
+			Orphaned: []OrphanedPublicDashboard{{Uid: "pubdash1", OrgId: 2, DashboardUid: "deleted"}},
+		}, nil)
+		server := setupTestServer(t, nil, service, grafanaAdmin)
+
+		resp := callAPI(server, http.MethodPost, path+"?dryRun=true", nil, t)
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.JSONEq(t, `{"dryRun": true, "orphaned": [{"uid": "pubdash1", "orgId": 2, "dashboardUid": "deleted"}], "deleted": 0}`, resp.Body.String())
+	})
+
+	t.Run("Deletes the orphaned public dashboards by default", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("CleanupOrphaned", mock.Anything, false).Return(&OrphanedCleanupResult{Orphaned: []OrphanedPublicDashboard{}}, nil)
+		server := setupTestServer(t, nil, service, grafanaAdmin)
+
+		resp := callAPI(server, http.MethodPost, path, nil, t)
+		assert.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("Status code is 400 when dryRun isn't a boolean", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		server := setupTestServer(t, nil, service, grafanaAdmin)
+
+		resp := callAPI(server, http.MethodPost, path+"?dryRun=maybe", nil, t)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		service.AssertNotCalled(t, "CleanupOrphaned")
+	})
+
+	t.Run("Status code is 403 for org admins", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		server := setupTestServer(t, nil, service, userAdmin)
+
+		resp := callAPI(server, http.MethodPost, path, nil, t)
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		service.AssertNotCalled(t, "CleanupOrphaned")
+	})
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// swagger:route POST /public/dashboards/{accessToken}/heartbeat dashboards dashboard_public publicDashboardHeartbeat
+//
+//	Report that an anonymous viewer is looking at a public dashboard
+//
+// Viewers send a heartbeat every 30 seconds with a random session id, and are no longer counted as present
+// after missing heartbeats for 90 seconds.
+//
+// Responses:
+// 204: okResponse
+// 400: badRequestPublicError
+// 403: forbiddenPublicError
+// 404: notFoundPublicError
+// 500: internalServerPublicError
+func (api *Api) PublicDashboardHeartbeat(c *contextmodel.ReqContext) response.Response {
+	accessToken := web.Params(c.Req)[":accessToken"]
+	if !validation.IsValidAccessToken(accessToken) {
+		return response.Err(ErrInvalidAccessToken.Errorf("PublicDashboardHeartbeat: invalid access token"))
+	}
+
+	reqDTO := PublicDashboardHeartbeatDTO{}
+	if err := web.Bind(c.Req, &reqDTO); err != nil {
+		return response.Err(ErrBadRequest.Errorf("PublicDashboardHeartbeat: error parsing request: %v", err))
+	}
+
+	if !validation.IsValidViewerSessionId(reqDTO.SessionId) {
+		return response.Err(ErrInvalidViewerSession.Errorf("PublicDashboardHeartbeat: invalid session id"))
+	}
+
+	if err := api.PublicDashboardService.RecordViewerHeartbeat(c.Req.Context(), accessToken, reqDTO.SessionId); err != nil {
+		return response.Err(err)
+	}
+
+	return response.Empty(http.StatusNoContent)
+}
+
+// swagger:parameters publicDashboardHeartbeat
+type PublicDashboardHeartbeatParams struct {
+	// in:path
+	// required:true
+	AccessToken string `json:"accessToken"`
+	// in:body
+	// required:true
+	Body PublicDashboardHeartbeatDTO
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+func TestAPIPublicDashboardHeartbeat(t *testing.T) {
+	path := fmt.Sprintf("/api/public/dashboards/%s/heartbeat", validAccessToken)
+	sessionId := "8a5b2d3e-1c4f-4e7a-9b6d-0f1e2d3c4b5a"
+
+	t.Run("Records the heartbeat of the viewer session", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		expectPublicDashboardLookup(service, validAccessToken)
+		service.On("RecordViewerHeartbeat", mock.Anything, validAccessToken, sessionId).Return(nil)
+		server := setupTestServer(t, nil, service, anonymousUser)
+
+		resp := callAPI(server, http.MethodPost, path, strings.NewReader(fmt.Sprintf(`{"sessionId":%q}`, sessionId)), t)
+		require.Equal(t, http.StatusNoContent, resp.Code)
+	})
+
+	t.Run("Status code is 400 when the session id is invalid", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		expectPublicDashboardLookup(service, validAccessToken)
+		server := setupTestServer(t, nil, service, anonymousUser)
+
+		resp := callAPI(server, http.MethodPost, path, strings.NewReader(`{"sessionId":"not-a-session"}`), t)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("Status code is 404 when the public dashboard is not found", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		expectPublicDashboardLookup(service, validAccessToken)
+		service.On("RecordViewerHeartbeat", mock.Anything, validAccessToken, sessionId).Return(ErrPublicDashboardNotFound.Errorf(""))
+		server := setupTestServer(t, nil, service, anonymousUser)
+
+		resp := callAPI(server, http.MethodPost, path, strings.NewReader(fmt.Sprintf(`{"sessionId":%q}`, sessionId)), t)
+		require.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
@@ -1,8 +1,15 @@
 package api
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 
@@ -14,10 +21,16 @@ import (
 	"github.com/grafana/grafana/pkg/web"
 )
 
+// variableParamPrefix is the prefix of template variables in dashboard URLs
+const variableParamPrefix = "var-"
+
 // swagger:route GET /public/dashboards/{accessToken} dashboards dashboard_public viewPublicDashboard
 //
 //	Get public dashboard for view
 //
+// The public dashboard can be identified by its slug instead of its access token, the access token is then returned in
+// the meta of the dashboard.
+//
 // Responses:
 // 200: viewPublicDashboardResponse
 // 400: badRequestPublicError
@@ -27,7 +40,7 @@ import (
 // 500: internalServerPublicError
 func (api *Api) ViewPublicDashboard(c *contextmodel.ReqContext) response.Response {
 	accessToken := web.Params(c.Req)[":accessToken"]
-	if !validation.IsValidAccessToken(accessToken) {
+	if !validation.IsValidAccessTokenOrSlug(accessToken) {
 		return response.Err(ErrInvalidAccessToken.Errorf("ViewPublicDashboard: invalid access token"))
 	}
 
@@ -36,13 +49,51 @@ func (api *Api) ViewPublicDashboard(c *contextmodel.ReqContext) response.Respons
 		return response.Err(err)
 	}
 
+	api.PublicDashboardService.RecordAuditLogEntry(c.Req.Context(), accessToken, AuditLogEvent{
+		Action: AuditLogActionView,
+		Client: viewerClient(c, api.ClientIPs),
+	})
+
 	return response.JSON(http.StatusOK, dto)
 }
 
+// swagger:route HEAD /public/dashboards/{accessToken} dashboards dashboard_public checkPublicDashboardAccessToken
+//
+//	Check whether the access token of a public dashboard can be viewed
+//
+// Lets embedding applications check an access token before rendering an iframe, without loading the dashboard for
+// view or running any query. Paused public dashboards, including the ones paused for inactivity, and expired public
+// dashboards answer 410. Slugs can be checked like access tokens.
+//
+// Responses:
+// 200: okResponse
+// 400: badRequestPublicError
+// 404: notFoundPublicError
+// 410: goneError
+// 500: internalServerPublicError
+func (api *Api) CheckPublicDashboardAccessToken(c *contextmodel.ReqContext) response.Response {
+	accessToken := web.Params(c.Req)[":accessToken"]
+	if !validation.IsValidAccessTokenOrSlug(accessToken) {
+		return response.Err(ErrInvalidAccessToken.Errorf("CheckPublicDashboardAccessToken: invalid access token"))
+	}
+
+	_, _, err := api.PublicDashboardService.FindEnabledPublicDashboardAndDashboardByAccessToken(c.Req.Context(), accessToken)
+	switch {
+	case errors.Is(err, ErrPublicDashboardNotEnabled), errors.Is(err, ErrPublicDashboardExpired):
+		return response.Empty(http.StatusGone).SetHeader("Cache-Control", "no-store")
+	case err != nil:
+		return response.Err(err)
+	}
+
+	return response.Empty(http.StatusOK).SetHeader("Cache-Control", "no-store")
+}
+
 // swagger:route POST /public/dashboards/{accessToken}/panels/{panelId}/query dashboards dashboard_public queryPublicDashboard
 //
 //	Get results for a given panel on a public dashboard
 //
+// Send `Accept: application/vnd.apache.arrow.file` to receive a multipart/mixed body with one Arrow IPC file per frame.
+//
 // Responses:
 // 200: queryPublicDashboardResponse
 // 400: badRequestPublicError
@@ -72,9 +123,291 @@ func (api *Api) QueryPublicDashboard(c *contextmodel.ReqContext) response.Respon
 		return response.Err(err)
 	}
 
+	api.recordQuery(c, accessToken, []int64{panelId}, reqDTO.TimeRange)
+
+	if acceptsArrow(c.Req) {
+		return toArrowResponse(resp)
+	}
+
 	return toJsonStreamingResponse(c.Req.Context(), api.features, resp)
 }
 
+// swagger:route POST /public/dashboards/{accessToken}/query dashboards dashboard_public queryPublicDashboardPanels
+//
+//	Get results for several panels on a public dashboard
+//
+// Queries the panels of `panelIds`, a list of panel ids or "all" for every panel with queries, with the same time
+// range and variables. Panels are queried concurrently and a panel failing or timing out is reported with its result,
+// along with the results of the other panels.
+//
+// Responses:
+// 200: queryPublicDashboardPanelsResponse
+// 400: badRequestPublicError
+// 401: unauthorisedPublicError
+// 404: notFoundPublicError
+// 403: forbiddenPublicError
+// 500: internalServerPublicError
+func (api *Api) QueryPublicDashboardPanels(c *contextmodel.ReqContext) response.Response {
+	accessToken := web.Params(c.Req)[":accessToken"]
+	if !validation.IsValidAccessToken(accessToken) {
+		return response.Err(ErrInvalidAccessToken.Errorf("QueryPublicDashboardPanels: invalid access token"))
+	}
+
+	reqDTO := PublicDashboardBatchQueryDTO{}
+	if err := web.Bind(c.Req, &reqDTO); err != nil {
+		return response.Err(ErrBadRequest.Errorf("QueryPublicDashboardPanels: error parsing request: %v", err))
+	}
+
+	resp, err := api.PublicDashboardService.GetQueryDataResponses(c.Req.Context(), c.SkipDSCache, reqDTO, accessToken)
+	if err != nil {
+		return response.Err(err)
+	}
+
+	panelIds := make([]int64, 0, len(resp.Panels))
+	for panelId := range resp.Panels {
+		panelIds = append(panelIds, panelId)
+	}
+	slices.Sort(panelIds)
+	api.recordQuery(c, accessToken, panelIds, reqDTO.TimeRange)
+
+	return response.JSON(http.StatusOK, resp)
+}
+
+// swagger:route GET /public/dashboards/{accessToken}/panels/{panelId}/query dashboards dashboard_public queryPublicDashboardWithParams
+//
+//	Get results for a given panel on a public dashboard using the time range and variables of the query string
+//
+// Variables are passed with the `var-` prefix like in dashboard URLs, repeat a parameter to pass multiple values.
+// Requests are redirected to a canonical query string and responses carry ETag and Cache-Control headers
+// matching queryCachingTTL, so they can be cached by a CDN.
+// Send `Accept: application/vnd.apache.arrow.file` to receive a multipart/mixed body with one Arrow IPC file per frame.
+//
+// Responses:
+// 200: queryPublicDashboardResponse
+// 400: badRequestPublicError
+// 401: unauthorisedPublicError
+// 404: panelNotFoundPublicError
+// 404: notFoundPublicError
+// 403: forbiddenPublicError
+// 500: internalServerPublicError
+func (api *Api) QueryPublicDashboardWithParams(c *contextmodel.ReqContext) response.Response {
+	accessToken := web.Params(c.Req)[":accessToken"]
+	if !validation.IsValidAccessToken(accessToken) {
+		return response.Err(ErrInvalidAccessToken.Errorf("QueryPublicDashboardWithParams: invalid access token"))
+	}
+
+	panelId, err := strconv.ParseInt(web.Params(c.Req)[":panelId"], 10, 64)
+	if err != nil {
+		return response.Err(ErrInvalidPanelId.Errorf("QueryPublicDashboardWithParams: error parsing panelId %v", err))
+	}
+
+	params := c.Req.URL.Query()
+	reqDTO, err := queryDTOFromParams(params)
+	if err != nil {
+		return response.Err(ErrBadRequest.Errorf("QueryPublicDashboardWithParams: error parsing query string: %v", err))
+	}
+
+	// Equal requests are redirected to a single url, so a CDN in front of Grafana caches them under the same key. The
+	// viewer token is kept in the redirect, the request would be rejected without it
+	if canonical := withViewerTokenParam(canonicalQueryParams(params), params); canonical != c.Req.URL.RawQuery {
+		return response.Empty(http.StatusMovedPermanently).SetHeader("Location", "?"+canonical)
+	}
+
+	resp, err := api.PublicDashboardService.GetQueryDataResponse(c.Req.Context(), c.SkipDSCache, reqDTO, panelId, accessToken)
+	if err != nil {
+		return response.Err(err)
+	}
+
+	api.recordQuery(c, accessToken, []int64{panelId}, reqDTO.TimeRange)
+
+	return toCacheableResponse(c, resp, accessToken, reqDTO.QueryCachingTTL)
+}
+
+// recordQuery records the query of the panels in the audit log of the public dashboard
+func (api *Api) recordQuery(c *contextmodel.ReqContext, accessToken string, panelIds []int64, timeRange TimeRangeDTO) {
+	api.PublicDashboardService.RecordAuditLogEntry(c.Req.Context(), accessToken, AuditLogEvent{
+		Action:    AuditLogActionQuery,
+		Client:    viewerClient(c, api.ClientIPs),
+		PanelIds:  panelIds,
+		TimeRange: timeRange,
+	})
+}
+
+// canonicalQueryParams encodes the known query parameters with sorted keys and sorted variable values
+func canonicalQueryParams(params url.Values) string {
+	canonical := url.Values{}
+	for _, key := range []string{"from", "to", "timezone", "intervalMs", "maxDataPoints", "queryCachingTTL"} {
+		if value := params.Get(key); value != "" {
+			canonical.Set(key, value)
+		}
+	}
+
+	for key, values := range params {
+		if !strings.HasPrefix(key, variableParamPrefix) || key == variableParamPrefix {
+			continue
+		}
+
+		sorted := slices.Clone(values)
+		slices.Sort(sorted)
+		canonical[key] = sorted
+	}
+
+	// Encode sorts by key
+	return canonical.Encode()
+}
+
+// withViewerTokenParam appends the viewer token query parameter to the canonical query string, after the parameters
+// of the query so it doesn't change their order
+func withViewerTokenParam(canonical string, params url.Values) string {
+	token := params.Get(viewerTokenQueryParam)
+	if token == "" {
+		return canonical
+	}
+
+	encoded := url.Values{viewerTokenQueryParam: {token}}.Encode()
+	if canonical == "" {
+		return encoded
+	}
+	return canonical + "&" + encoded
+}
+
+// toCacheableResponse writes the query response in the format accepted by the client with an ETag and Cache-Control
+// headers matching the query cache ttl. Responses with errors are never cached. The Surrogate-Key header lets a CDN
+// purge the responses of the access token when it's revoked. Responses vary by viewer token, so a CDN never serves
+// them to clients without one.
+func toCacheableResponse(c *contextmodel.ReqContext, qdr *backend.QueryDataResponse, accessToken string, queryCachingTTL int64) response.Response {
+	for _, res := range qdr.Responses {
+		if res.Error != nil {
+			return response.JSON(http.StatusBadRequest, qdr).SetHeader("Cache-Control", "no-store")
+		}
+	}
+
+	body, contentType, err := encodeQueryDataResponse(c.Req, qdr)
+	if err != nil {
+		return response.Err(ErrInternalServerError.Errorf("toCacheableResponse: failed to encode response: %w", err))
+	}
+
+	hash := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(hash[:]) + `"`
+
+	surrogateKey := SurrogateKey(accessToken)
+	varyHeader := "Accept, " + viewerTokenHeader
+	cacheControl := "public, no-cache"
+	if queryCachingTTL > 0 {
+		cacheControl = fmt.Sprintf("public, max-age=%d", max(queryCachingTTL/1000, 1))
+	}
+
+	if c.Req.Header.Get("If-None-Match") == etag {
+		return response.Empty(http.StatusNotModified).
+			SetHeader("ETag", etag).
+			SetHeader("Cache-Control", cacheControl).
+			SetHeader("Surrogate-Key", surrogateKey).
+			SetHeader("Vary", varyHeader)
+	}
+
+	return response.Respond(http.StatusOK, body).
+		SetHeader("Content-Type", contentType).
+		SetHeader("ETag", etag).
+		SetHeader("Cache-Control", cacheControl).
+		SetHeader("Surrogate-Key", surrogateKey).
+		SetHeader("Vary", varyHeader)
+}
+
+// queryDTOFromParams builds a query DTO from url parameters following the semantics of dashboard URLs
+func queryDTOFromParams(params url.Values) (PublicDashboardQueryDTO, error) {
+	reqDTO := PublicDashboardQueryDTO{
+		TimeRange: TimeRangeDTO{
+			From:     params.Get("from"),
+			To:       params.Get("to"),
+			Timezone: params.Get("timezone"),
+		},
+	}
+
+	var err error
+	if reqDTO.IntervalMs, err = parseInt64Param(params, "intervalMs"); err != nil {
+		return reqDTO, err
+	}
+	if reqDTO.MaxDataPoints, err = parseInt64Param(params, "maxDataPoints"); err != nil {
+		return reqDTO, err
+	}
+	if reqDTO.QueryCachingTTL, err = parseInt64Param(params, "queryCachingTTL"); err != nil {
+		return reqDTO, err
+	}
+
+	for key, values := range params {
+		name, ok := strings.CutPrefix(key, variableParamPrefix)
+		if !ok || name == "" || len(values) == 0 {
+			continue
+		}
+
+		if reqDTO.Variables == nil {
+			reqDTO.Variables = make(map[string]interface{})
+		}
+
+		if len(values) == 1 {
+			reqDTO.Variables[name] = values[0]
+			continue
+		}
+
+		multi := make([]interface{}, 0, len(values))
+		for _, v := range values {
+			multi = append(multi, v)
+		}
+		reqDTO.Variables[name] = multi
+	}
+
+	return reqDTO, nil
+}
+
+func parseInt64Param(params url.Values, key string) (int64, error) {
+	value := params.Get(key)
+	if value == "" {
+		return 0, nil
+	}
+
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return parsed, nil
+}
+
+// swagger:route POST /public/dashboards/{accessToken}/panels/{panelId}/content dashboards dashboard_public getPublicDashboardPanelContent
+//
+//	Get the sanitized rich text content of a panel on a public dashboard
+//
+// Responses:
+// 200: getPublicDashboardPanelContentResponse
+// 400: badRequestPublicError
+// 401: unauthorisedPublicError
+// 404: panelNotFoundPublicError
+// 404: notFoundPublicError
+// 403: forbiddenPublicError
+// 500: internalServerPublicError
+func (api *Api) GetPublicDashboardPanelContent(c *contextmodel.ReqContext) response.Response {
+	accessToken := web.Params(c.Req)[":accessToken"]
+	if !validation.IsValidAccessToken(accessToken) {
+		return response.Err(ErrInvalidAccessToken.Errorf("GetPublicDashboardPanelContent: invalid access token"))
+	}
+
+	panelId, err := strconv.ParseInt(web.Params(c.Req)[":panelId"], 10, 64)
+	if err != nil {
+		return response.Err(ErrInvalidPanelId.Errorf("GetPublicDashboardPanelContent: error parsing panelId %v", err))
+	}
+
+	reqDTO := PublicDashboardPanelContentDTO{}
+	if err = web.Bind(c.Req, &reqDTO); err != nil {
+		return response.Err(ErrBadRequest.Errorf("GetPublicDashboardPanelContent: error parsing request: %v", err))
+	}
+
+	content, err := api.PublicDashboardService.GetPanelContent(c.Req.Context(), accessToken, panelId, reqDTO)
+	if err != nil {
+		return response.Err(err)
+	}
+
+	return response.JSON(http.StatusOK, content)
+}
+
 // swagger:route GET /public/dashboards/{accessToken}/annotations dashboards annotations dashboard_public getPublicAnnotations
 //
 //	Get annotations for a public dashboard
@@ -93,8 +426,12 @@ func (api *Api) GetPublicAnnotations(c *contextmodel.ReqContext) response.Respon
 	}
 
 	reqDTO := AnnotationsQueryDTO{
-		From: c.QueryInt64("from"),
-		To:   c.QueryInt64("to"),
+		From:    c.QueryInt64("from"),
+		To:      c.QueryInt64("to"),
+		PanelId: c.QueryInt64("panelId"),
+	}
+	if reqDTO.PanelId < 0 {
+		return response.Err(ErrInvalidPanelId.Errorf("GetPublicAnnotations: invalid panelId %d", reqDTO.PanelId))
 	}
 
 	annotations, err := api.PublicDashboardService.FindAnnotations(c.Req.Context(), reqDTO, accessToken)
@@ -131,6 +468,56 @@ type QueryPublicDashboardParams struct {
 	PanelId int64 `json:"panelId"`
 }
 
+// swagger:response queryPublicDashboardPanelsResponse
+type QueryPublicDashboardPanelsResponse struct {
+	// in: body
+	Body PublicDashboardBatchQueryResponse `json:"body"`
+}
+
+// swagger:parameters queryPublicDashboardPanels
+type QueryPublicDashboardPanelsParams struct {
+	// in: path
+	AccessToken string `json:"accessToken"`
+	// in: body
+	Body PublicDashboardBatchQueryDTO
+}
+
+// swagger:parameters queryPublicDashboardWithParams
+type QueryPublicDashboardWithParamsParams struct {
+	// in: path
+	AccessToken string `json:"accessToken"`
+	// in: path
+	PanelId int64 `json:"panelId"`
+	// in: query
+	From string `json:"from"`
+	// in: query
+	To string `json:"to"`
+	// in: query
+	Timezone string `json:"timezone"`
+	// in: query
+	IntervalMs int64 `json:"intervalMs"`
+	// in: query
+	MaxDataPoints int64 `json:"maxDataPoints"`
+	// in: query
+	QueryCachingTTL int64 `json:"queryCachingTTL"`
+}
+
+// swagger:response getPublicDashboardPanelContentResponse
+type GetPublicDashboardPanelContentResponse struct {
+	// in: body
+	Body PanelContent `json:"body"`
+}
+
+// swagger:parameters getPublicDashboardPanelContent
+type GetPublicDashboardPanelContentParams struct {
+	// in: path
+	AccessToken string `json:"accessToken"`
+	// in: path
+	PanelId int64 `json:"panelId"`
+	// in: body
+	Body PublicDashboardPanelContentDTO
+}
+
 // swagger:response getPublicAnnotationsResponse
 type GetPublicAnnotationsResponse struct {
 	// in: body
@@ -141,4 +528,11 @@ type GetPublicAnnotationsResponse struct {
 type GetPublicAnnotationsParams struct {
 	// in: path
 	AccessToken string `json:"accessToken"`
+	// in: query
+	From int64 `json:"from"`
+	// in: query
+	To int64 `json:"to"`
+	// Only return the annotations shown on this panel
+	// in: query
+	PanelId int64 `json:"panelId"`
 }
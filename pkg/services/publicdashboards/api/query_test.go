@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 
@@ -22,6 +24,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/publicdashboards"
 	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
 	"github.com/grafana/grafana/pkg/web"
 )
 
@@ -58,6 +61,23 @@ func TestAPIViewPublicDashboard(t *testing.T) {
 			Err:                nil,
 			FixedErrorResponse: "",
 		},
+		{
+			Name:                 "It gets a public dashboard by slug",
+			AccessToken:          "status-page",
+			ExpectedHttpResponse: http.StatusOK,
+			DashboardResult: &dtos.DashboardFullWithMeta{
+				Dashboard: simplejson.NewFromAny(map[string]any{
+					"Uid": DashboardUid,
+				}),
+				Meta: dtos.DashboardMeta{
+					Type:                       dashboards.DashTypeDB,
+					PublicDashboardEnabled:     true,
+					PublicDashboardAccessToken: validAccessToken,
+				},
+			},
+			Err:                nil,
+			FixedErrorResponse: "",
+		},
 		{
 			Name:                 "It should return 404 if no public dashboard",
 			AccessToken:          validAccessToken,
@@ -81,6 +101,12 @@ func TestAPIViewPublicDashboard(t *testing.T) {
 			service := publicdashboards.NewFakePublicDashboardService(t)
 			service.On("GetPublicDashboardForView", mock.Anything, mock.AnythingOfType("string")).
 				Return(test.DashboardResult, test.Err).Maybe()
+			service.On("RecordAuditLogEntry", mock.Anything, test.AccessToken, mock.MatchedBy(func(event AuditLogEvent) bool {
+				return event.Action == AuditLogActionView
+			})).Maybe()
+			if validation.IsValidAccessTokenOrSlug(test.AccessToken) {
+				expectPublicDashboardLookup(service, test.AccessToken)
+			}
 
 			testServer := setupTestServer(t, nil, service, anonymousUser)
 
@@ -115,6 +141,63 @@ func TestAPIViewPublicDashboard(t *testing.T) {
 	}
 }
 
+func TestAPICheckPublicDashboardAccessToken(t *testing.T) {
+	testCases := []struct {
+		Name                 string
+		AccessToken          string
+		Err                  error
+		ExpectedHttpResponse int
+	}{
+		{
+			Name:                 "It returns 200 for an enabled public dashboard",
+			AccessToken:          validAccessToken,
+			ExpectedHttpResponse: http.StatusOK,
+		},
+		{
+			Name:                 "It returns 404 if no public dashboard",
+			AccessToken:          validAccessToken,
+			Err:                  ErrPublicDashboardNotFound.Errorf(""),
+			ExpectedHttpResponse: http.StatusNotFound,
+		},
+		{
+			Name:                 "It returns 410 if the public dashboard is paused",
+			AccessToken:          validAccessToken,
+			Err:                  ErrPublicDashboardNotEnabled.Errorf(""),
+			ExpectedHttpResponse: http.StatusGone,
+		},
+		{
+			Name:                 "It returns 410 if the public dashboard expired",
+			AccessToken:          validAccessToken,
+			Err:                  ErrPublicDashboardExpired.Errorf(""),
+			ExpectedHttpResponse: http.StatusGone,
+		},
+		{
+			Name:                 "It returns 400 if it is an invalid access token",
+			AccessToken:          "SomeInvalidAccessToken",
+			ExpectedHttpResponse: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			service := publicdashboards.NewFakePublicDashboardService(t)
+			service.On("FindEnabledPublicDashboardAndDashboardByAccessToken", mock.Anything, test.AccessToken).
+				Return(&PublicDashboard{}, &dashboards.Dashboard{}, test.Err).Maybe()
+			if validation.IsValidAccessToken(test.AccessToken) {
+				expectPublicDashboardLookup(service, test.AccessToken)
+			}
+
+			testServer := setupTestServer(t, nil, service, anonymousUser)
+
+			response := callAPI(testServer, http.MethodHead, fmt.Sprintf("/api/public/dashboards/%s", test.AccessToken), nil, t)
+
+			assert.Equal(t, test.ExpectedHttpResponse, response.Code)
+			// the dashboard is never loaded for view
+			service.AssertNotCalled(t, "GetPublicDashboardForView", mock.Anything, mock.Anything)
+		})
+	}
+}
+
 // `/public/dashboards/:uid/query“ endpoint test
 func TestAPIQueryPublicDashboard(t *testing.T) {
 	mockedResponse := &backend.QueryDataResponse{
@@ -177,7 +260,8 @@ func TestAPIQueryPublicDashboard(t *testing.T) {
 	}
 
 	t.Run("Status code is 400 when the panel ID is invalid", func(t *testing.T) {
-		server, _ := setup(true)
+		server, service := setup(true)
+		expectViewerAccess(service, validAccessToken)
 		path := fmt.Sprintf("/api/public/dashboards/%s/panels/notanumber/query", validAccessToken)
 		resp := callAPI(server, http.MethodPost, path, strings.NewReader("{}"), t)
 		require.Equal(t, http.StatusBadRequest, resp.Code)
@@ -192,6 +276,7 @@ func TestAPIQueryPublicDashboard(t *testing.T) {
 
 	t.Run("Status code is 400 when the intervalMS is lesser than 0", func(t *testing.T) {
 		server, fakeDashboardService := setup(true)
+		expectViewerAccess(fakeDashboardService, validAccessToken)
 		fakeDashboardService.On("GetQueryDataResponse", mock.Anything, true, mock.Anything, int64(2), validAccessToken).Return(&backend.QueryDataResponse{}, ErrBadRequest.Errorf(""))
 		resp := callAPI(server, http.MethodPost, getValidQueryPath(validAccessToken), strings.NewReader(`{"intervalMs":-100,"maxDataPoints":1000}`), t)
 		require.Equal(t, http.StatusBadRequest, resp.Code)
@@ -199,6 +284,7 @@ func TestAPIQueryPublicDashboard(t *testing.T) {
 
 	t.Run("Status code is 400 when the maxDataPoints is lesser than 0", func(t *testing.T) {
 		server, fakeDashboardService := setup(true)
+		expectViewerAccess(fakeDashboardService, validAccessToken)
 		fakeDashboardService.On("GetQueryDataResponse", mock.Anything, true, mock.Anything, int64(2), validAccessToken).Return(&backend.QueryDataResponse{}, ErrBadRequest.Errorf(""))
 		resp := callAPI(server, http.MethodPost, getValidQueryPath(validAccessToken), strings.NewReader(`{"intervalMs":100,"maxDataPoints":-1000}`), t)
 		require.Equal(t, http.StatusBadRequest, resp.Code)
@@ -206,7 +292,9 @@ func TestAPIQueryPublicDashboard(t *testing.T) {
 
 	t.Run("Returns query data when feature toggle is enabled", func(t *testing.T) {
 		server, fakeDashboardService := setup(true)
+		expectViewerAccess(fakeDashboardService, validAccessToken)
 		fakeDashboardService.On("GetQueryDataResponse", mock.Anything, true, mock.Anything, int64(2), validAccessToken).Return(mockedResponse, nil)
+		fakeDashboardService.On("RecordAuditLogEntry", mock.Anything, mock.Anything, mock.Anything)
 
 		resp := callAPI(server, http.MethodPost, getValidQueryPath(validAccessToken), strings.NewReader("{}"), t)
 
@@ -220,6 +308,7 @@ func TestAPIQueryPublicDashboard(t *testing.T) {
 
 	t.Run("Status code is 500 when the query fails", func(t *testing.T) {
 		server, fakeDashboardService := setup(true)
+		expectViewerAccess(fakeDashboardService, validAccessToken)
 		fakeDashboardService.On("GetQueryDataResponse", mock.Anything, true, mock.Anything, int64(2), validAccessToken).Return(&backend.QueryDataResponse{}, fmt.Errorf("error"))
 
 		resp := callAPI(server, http.MethodPost, getValidQueryPath(validAccessToken), strings.NewReader("{}"), t)
@@ -227,6 +316,231 @@ func TestAPIQueryPublicDashboard(t *testing.T) {
 	})
 }
 
+func TestAPIQueryPublicDashboardWithParams(t *testing.T) {
+	setup := func() (*web.Mux, *publicdashboards.FakePublicDashboardService) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		testServer := setupTestServer(t, nil, service, anonymousUser)
+
+		return testServer, service
+	}
+
+	t.Run("Status code is 400 when the access token is invalid", func(t *testing.T) {
+		server, _ := setup()
+		resp := callAPI(server, http.MethodGet, "/api/public/dashboards/SomeInvalidAccessToken/panels/2/query", nil, t)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("Status code is 400 when a numeric parameter is invalid", func(t *testing.T) {
+		server, service := setup()
+		expectViewerAccess(service, validAccessToken)
+		resp := callAPI(server, http.MethodGet, getValidQueryPath(validAccessToken)+"?maxDataPoints=many", nil, t)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("Parses the time range and variables of the query string", func(t *testing.T) {
+		server, service := setup()
+		expectViewerAccess(service, validAccessToken)
+		expectedDTO := PublicDashboardQueryDTO{
+			IntervalMs:    1000,
+			MaxDataPoints: 500,
+			TimeRange:     TimeRangeDTO{From: "now-6h", To: "now", Timezone: "utc"},
+			Variables: map[string]interface{}{
+				"env":    "prod",
+				"server": []interface{}{"a", "b"},
+			},
+		}
+		service.On("GetQueryDataResponse", mock.Anything, true, expectedDTO, int64(2), validAccessToken).
+			Return(&backend.QueryDataResponse{}, nil)
+		service.On("RecordAuditLogEntry", mock.Anything, validAccessToken, mock.MatchedBy(func(event AuditLogEvent) bool {
+			return event.Action == AuditLogActionQuery && assert.ObjectsAreEqual([]int64{2}, event.PanelIds) && event.TimeRange == expectedDTO.TimeRange
+		}))
+
+		path := getValidQueryPath(validAccessToken) + "?from=now-6h&intervalMs=1000&maxDataPoints=500&timezone=utc&to=now&var-env=prod&var-server=a&var-server=b"
+		resp := callAPI(server, http.MethodGet, path, nil, t)
+		require.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("Redirects to the canonical query string", func(t *testing.T) {
+		server, service := setup()
+		expectViewerAccess(service, validAccessToken)
+
+		path := getValidQueryPath(validAccessToken) + "?var-server=b&to=now&other=ignored&from=now-6h&var-server=a"
+		resp := callAPI(server, http.MethodGet, path, nil, t)
+		require.Equal(t, http.StatusMovedPermanently, resp.Code)
+		require.Equal(t, "?from=now-6h&to=now&var-server=a&var-server=b", resp.Header().Get("Location"))
+	})
+
+	t.Run("Keeps the viewer token in the redirect", func(t *testing.T) {
+		server, service := setup()
+		expectViewerAccess(service, validAccessToken)
+
+		path := getValidQueryPath(validAccessToken) + "?viewerToken=abc&to=now&from=now-6h"
+		resp := callAPI(server, http.MethodGet, path, nil, t)
+		require.Equal(t, http.StatusMovedPermanently, resp.Code)
+		require.Equal(t, "?from=now-6h&to=now&viewerToken=abc", resp.Header().Get("Location"))
+	})
+
+	t.Run("Doesn't redirect canonical query strings with a viewer token", func(t *testing.T) {
+		server, service := setup()
+		expectViewerAccess(service, validAccessToken)
+		service.On("GetQueryDataResponse", mock.Anything, true, mock.Anything, int64(2), validAccessToken).
+			Return(&backend.QueryDataResponse{}, nil)
+		service.On("RecordAuditLogEntry", mock.Anything, mock.Anything, mock.Anything)
+
+		resp := callAPI(server, http.MethodGet, getValidQueryPath(validAccessToken)+"?from=now-6h&to=now&viewerToken=abc", nil, t)
+		require.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("Sets cache headers matching the query caching ttl", func(t *testing.T) {
+		server, service := setup()
+		expectViewerAccess(service, validAccessToken)
+		service.On("GetQueryDataResponse", mock.Anything, true, mock.Anything, int64(2), validAccessToken).
+			Return(&backend.QueryDataResponse{Responses: map[string]backend.DataResponse{"A": {}}}, nil)
+		service.On("RecordAuditLogEntry", mock.Anything, mock.Anything, mock.Anything)
+
+		path := getValidQueryPath(validAccessToken) + "?from=now-6h&queryCachingTTL=60000&to=now"
+		resp := callAPI(server, http.MethodGet, path, nil, t)
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.Equal(t, "public, max-age=60", resp.Header().Get("Cache-Control"))
+		require.Equal(t, "Accept, X-Grafana-Public-Dashboard-Token", resp.Header().Get("Vary"))
+		require.Equal(t, SurrogateKey(validAccessToken), resp.Header().Get("Surrogate-Key"))
+		etag := resp.Header().Get("ETag")
+		require.NotEmpty(t, etag)
+
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+		req.Header.Set(viewerTokenHeader, testViewerToken)
+		req.Header.Set("If-None-Match", etag)
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, req)
+		require.Equal(t, http.StatusNotModified, recorder.Code)
+		require.Empty(t, recorder.Body.String())
+	})
+
+	t.Run("Responses with errors are not cached", func(t *testing.T) {
+		server, service := setup()
+		expectViewerAccess(service, validAccessToken)
+		service.On("GetQueryDataResponse", mock.Anything, true, mock.Anything, int64(2), validAccessToken).
+			Return(&backend.QueryDataResponse{Responses: map[string]backend.DataResponse{"A": {Error: errors.New("failed")}}}, nil)
+		service.On("RecordAuditLogEntry", mock.Anything, mock.Anything, mock.Anything)
+
+		resp := callAPI(server, http.MethodGet, getValidQueryPath(validAccessToken), nil, t)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+		require.Equal(t, "no-store", resp.Header().Get("Cache-Control"))
+	})
+
+	t.Run("Canonical query string sorts keys and variable values", func(t *testing.T) {
+		params := url.Values{
+			"to":            {"now"},
+			"from":          {"now-1h"},
+			"var-b":         {"2", "1"},
+			"var-a":         {"x"},
+			"unknown":       {"dropped"},
+			"viewerToken":   {"abc"},
+			"maxDataPoints": {"100"},
+		}
+		assert.Equal(t, "from=now-1h&maxDataPoints=100&to=now&var-a=x&var-b=1&var-b=2", canonicalQueryParams(params))
+	})
+}
+
+func TestAPIGetPublicDashboardPanelContent(t *testing.T) {
+	setup := func() (*web.Mux, *publicdashboards.FakePublicDashboardService) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		testServer := setupTestServer(t, nil, service, anonymousUser)
+
+		return testServer, service
+	}
+
+	path := fmt.Sprintf("/api/public/dashboards/%s/panels/2/content", validAccessToken)
+
+	t.Run("Status code is 400 when the panel ID is invalid", func(t *testing.T) {
+		server, service := setup()
+		expectViewerAccess(service, validAccessToken)
+		path := fmt.Sprintf("/api/public/dashboards/%s/panels/notanumber/content", validAccessToken)
+		resp := callAPI(server, http.MethodPost, path, strings.NewReader("{}"), t)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("Status code is 400 when the access token is invalid", func(t *testing.T) {
+		server, _ := setup()
+		resp := callAPI(server, http.MethodPost, "/api/public/dashboards/SomeInvalidAccessToken/panels/2/content", strings.NewReader("{}"), t)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("Returns the panel content with the requested variables", func(t *testing.T) {
+		server, service := setup()
+		expectViewerAccess(service, validAccessToken)
+		reqDTO := PublicDashboardPanelContentDTO{Variables: map[string]interface{}{"env": "prod"}}
+		service.On("GetPanelContent", mock.Anything, validAccessToken, int64(2), reqDTO).
+			Return(&PanelContent{PanelId: 2, Title: "Notes", Content: "<p>prod</p>"}, nil)
+
+		resp := callAPI(server, http.MethodPost, path, strings.NewReader(`{"variables":{"env":"prod"}}`), t)
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.JSONEq(t, `{"panelId":2,"title":"Notes","content":"<p>prod</p>"}`, resp.Body.String())
+	})
+
+	t.Run("Status code is 404 when the panel is not found", func(t *testing.T) {
+		server, service := setup()
+		expectViewerAccess(service, validAccessToken)
+		service.On("GetPanelContent", mock.Anything, validAccessToken, int64(2), mock.Anything).
+			Return(nil, ErrPanelNotFound.Errorf(""))
+
+		resp := callAPI(server, http.MethodPost, path, strings.NewReader("{}"), t)
+		require.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
+func TestAPIQueryPublicDashboardPanels(t *testing.T) {
+	setup := func() (*web.Mux, *publicdashboards.FakePublicDashboardService) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		testServer := setupTestServer(t, nil, service, anonymousUser)
+
+		return testServer, service
+	}
+
+	path := fmt.Sprintf("/api/public/dashboards/%s/query", validAccessToken)
+
+	t.Run("Status code is 400 when the access token is invalid", func(t *testing.T) {
+		server, _ := setup()
+		resp := callAPI(server, http.MethodPost, "/api/public/dashboards/SomeInvalidAccessToken/query", strings.NewReader(`{"panelIds":"all"}`), t)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("Status code is 400 when the panels can't be parsed", func(t *testing.T) {
+		server, service := setup()
+		expectViewerAccess(service, validAccessToken)
+		resp := callAPI(server, http.MethodPost, path, strings.NewReader(`{"panelIds":"some"}`), t)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("Returns the result of each panel", func(t *testing.T) {
+		server, service := setup()
+		expectViewerAccess(service, validAccessToken)
+		reqDTO := PublicDashboardBatchQueryDTO{
+			PublicDashboardQueryDTO: PublicDashboardQueryDTO{Variables: map[string]interface{}{"env": "prod"}},
+			PanelIds:                BatchQueryPanels{Ids: []int64{1, 2}},
+		}
+		failure := ErrPanelNotFound.Errorf("").Public()
+		service.On("GetQueryDataResponses", mock.Anything, mock.Anything, reqDTO, validAccessToken).
+			Return(&PublicDashboardBatchQueryResponse{Panels: map[int64]PanelQueryResult{
+				1: {Response: &backend.QueryDataResponse{Responses: backend.Responses{"A": backend.DataResponse{}}}},
+				2: {Error: &failure},
+			}}, nil)
+		service.On("RecordAuditLogEntry", mock.Anything, validAccessToken, mock.MatchedBy(func(event AuditLogEvent) bool {
+			return event.Action == AuditLogActionQuery && assert.ObjectsAreEqual([]int64{1, 2}, event.PanelIds)
+		}))
+
+		resp := callAPI(server, http.MethodPost, path, strings.NewReader(`{"panelIds":[1,2],"variables":{"env":"prod"}}`), t)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var body PublicDashboardBatchQueryResponse
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+		require.Len(t, body.Panels, 2)
+		assert.Contains(t, body.Panels[1].Response.Responses, "A")
+		assert.Equal(t, http.StatusNotFound, body.Panels[2].Error.StatusCode)
+	})
+}
+
 func getValidQueryPath(accessToken string) string {
 	return fmt.Sprintf("/api/public/dashboards/%s/panels/2/query", accessToken)
 }
@@ -240,6 +554,7 @@ func TestAPIGetAnnotations(t *testing.T) {
 		AccessToken           string
 		From                  string
 		To                    string
+		PanelId               string
 		ExpectedServiceCalled bool
 	}{
 		{
@@ -262,6 +577,17 @@ func TestAPIGetAnnotations(t *testing.T) {
 			To:                    "123",
 			ExpectedServiceCalled: true,
 		},
+		{
+			Name:                  "will return 400 when the panelId is negative",
+			ExpectedHttpResponse:  http.StatusBadRequest,
+			Annotations:           nil,
+			ServiceError:          nil,
+			AccessToken:           validAccessToken,
+			From:                  "123",
+			To:                    "123",
+			PanelId:               "-1",
+			ExpectedServiceCalled: false,
+		},
 		{
 			Name:                  "will return 400 when has an incorrect Access Token",
 			ExpectedHttpResponse:  http.StatusBadRequest,
@@ -281,10 +607,13 @@ func TestAPIGetAnnotations(t *testing.T) {
 				service.On("FindAnnotations", mock.Anything, mock.Anything, mock.AnythingOfType("string")).
 					Return(test.Annotations, test.ServiceError).Once()
 			}
+			if validation.IsValidAccessToken(test.AccessToken) {
+				expectViewerAccess(service, test.AccessToken)
+			}
 
 			testServer := setupTestServer(t, nil, service, anonymousUser)
 
-			path := fmt.Sprintf("/api/public/dashboards/%s/annotations?from=%s&to=%s", test.AccessToken, test.From, test.To)
+			path := fmt.Sprintf("/api/public/dashboards/%s/annotations?from=%s&to=%s&panelId=%s", test.AccessToken, test.From, test.To, test.PanelId)
 			response := callAPI(testServer, http.MethodGet, path, nil, t)
 
 			assert.Equal(t, test.ExpectedHttpResponse, response.Code)
@@ -2,6 +2,7 @@ package api
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/grafana/grafana/pkg/api/response"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
@@ -37,16 +38,26 @@ func (api *Api) QueryPublicDashboardVariable(c *contextmodel.ReqContext) respons
 		return response.Err(ErrBadRequest.Errorf("QueryPublicDashboardVariable: error parsing request: %v", err))
 	}
 
-	options, err := api.PublicDashboardService.GetVariableQueryResponse(c.Req.Context(), accessToken, variableName, reqDTO)
+	resp, err := api.PublicDashboardService.GetVariableQueryResponse(c.Req.Context(), accessToken, variableName, reqDTO)
 	if err != nil {
 		return response.Err(err)
 	}
 
-	return response.JSON(http.StatusOK, options)
+	res := response.JSON(http.StatusOK, resp.Options)
+	if resp.RefreshedAt != nil {
+		res.SetHeader(refreshedAtHeader, resp.RefreshedAt.UTC().Format(time.RFC3339))
+	}
+	return res
 }
 
+// refreshedAtHeader is the header holding when the options of a query variable were queried
+const refreshedAtHeader = "X-Grafana-Variable-Refreshed-At"
+
 // swagger:response queryPublicDashboardVariableResponse
 type QueryPublicDashboardVariableResponse struct {
+	// When the options of query variables were queried
+	// in: header
+	RefreshedAt string `json:"X-Grafana-Variable-Refreshed-At"`
 	// in: body
 	Body []MetricFindValue `json:"body"`
 }
@@ -60,3 +71,40 @@ type QueryPublicDashboardVariableParams struct {
 	// in: body
 	Body PublicDashboardVariableQueryDTO
 }
+
+// swagger:route GET /public/dashboards/{accessToken}/variables dashboards dashboard_public listPublicDashboardVariables
+//
+//	List the variables of a public dashboard
+//
+// Responses:
+// 200: listPublicDashboardVariablesResponse
+// 400: badRequestPublicError
+// 401: unauthorisedPublicError
+// 404: notFoundPublicError
+// 403: forbiddenPublicError
+// 500: internalServerPublicError
+func (api *Api) ListPublicDashboardVariables(c *contextmodel.ReqContext) response.Response {
+	accessToken := web.Params(c.Req)[":accessToken"]
+	if !validation.IsValidAccessToken(accessToken) {
+		return response.Err(ErrInvalidAccessToken.Errorf("ListPublicDashboardVariables: invalid access token"))
+	}
+
+	variables, err := api.PublicDashboardService.ListVariables(c.Req.Context(), accessToken)
+	if err != nil {
+		return response.Err(err)
+	}
+
+	return response.JSON(http.StatusOK, variables)
+}
+
+// swagger:response listPublicDashboardVariablesResponse
+type ListPublicDashboardVariablesResponse struct {
+	// in: body
+	Body []PublicDashboardVariable `json:"body"`
+}
+
+// swagger:parameters listPublicDashboardVariables
+type ListPublicDashboardVariablesParams struct {
+	// in: path
+	AccessToken string `json:"accessToken"`
+}
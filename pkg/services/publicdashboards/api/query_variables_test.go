@@ -57,6 +57,7 @@ func TestQueryPublicDashboardWithVariables(t *testing.T) {
 						},
 					},
 				}, nil)
+				service.On("RecordAuditLogEntry", mock.Anything, mock.Anything, mock.Anything)
 			},
 			expectedStatusCode: 200,
 		},
@@ -86,6 +87,7 @@ func TestQueryPublicDashboardWithVariables(t *testing.T) {
 						},
 					},
 				}, nil)
+				service.On("RecordAuditLogEntry", mock.Anything, mock.Anything, mock.Anything)
 			},
 			expectedStatusCode: 200,
 		},
@@ -112,6 +114,7 @@ func TestQueryPublicDashboardWithVariables(t *testing.T) {
 						},
 					},
 				}, nil)
+				service.On("RecordAuditLogEntry", mock.Anything, mock.Anything, mock.Anything)
 			},
 			expectedStatusCode: 200,
 		},
@@ -121,6 +124,7 @@ func TestQueryPublicDashboardWithVariables(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			service := publicdashboards.NewFakePublicDashboardService(t)
 			tc.mockSetup(service)
+			expectViewerAccess(service, tc.accessToken)
 
 			testServer := setupTestServer(t, nil, service, anonymousUser)
 
@@ -262,3 +266,44 @@ func TestPublicDashboardQueryDTOSerialization(t *testing.T) {
 		assert.Contains(t, unmarshaled.Variables, key)
 	}
 }
+
+func TestAPIListPublicDashboardVariables(t *testing.T) {
+	path := fmt.Sprintf("/api/public/dashboards/%s/variables", testValidAccessToken)
+
+	t.Run("Returns the variables of the public dashboard", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("ListVariables", mock.Anything, testValidAccessToken).Return([]PublicDashboardVariable{
+			{Name: "env", Type: "custom", Multi: true, Current: VariableSnapshotValue{Text: "prod", Value: "prod"}},
+		}, nil)
+		expectViewerAccess(service, testValidAccessToken)
+		server := setupTestServer(t, nil, service, anonymousUser)
+
+		resp := callAPI(server, http.MethodGet, path, nil, t)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var variables []PublicDashboardVariable
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &variables))
+		require.Len(t, variables, 1)
+		assert.Equal(t, "env", variables[0].Name)
+		assert.True(t, variables[0].Multi)
+		assert.Equal(t, "prod", variables[0].Current.Value)
+	})
+
+	t.Run("Status code is 400 when the access token is invalid", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		server := setupTestServer(t, nil, service, anonymousUser)
+
+		resp := callAPI(server, http.MethodGet, "/api/public/dashboards/Invalid-Token/variables", nil, t)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("Status code is 404 when the public dashboard is not found", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("ListVariables", mock.Anything, testValidAccessToken).Return(nil, ErrPublicDashboardNotFound.Errorf(""))
+		expectViewerAccess(service, testValidAccessToken)
+		server := setupTestServer(t, nil, service, anonymousUser)
+
+		resp := callAPI(server, http.MethodGet, path, nil, t)
+		require.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
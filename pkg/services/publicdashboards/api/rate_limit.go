@@ -0,0 +1,212 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/metric"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+const (
+	rateLimitDimensionAccessToken = "access_token"
+	rateLimitDimensionIP          = "ip"
+
+	rateLimitBackendRemoteCache = "remote_cache"
+	rateLimitCacheKeyPrefix     = "publicdashboards-ratelimit-"
+)
+
+// rateLimitBuckets are token buckets refilled at perMin tokens per minute up to perMin tokens, keyed by the dimension
+// and value they limit
+type rateLimitBuckets interface {
+	// take takes a token from the bucket of the key. When the bucket is empty it returns false and how long until it
+	// has a token again
+	take(ctx context.Context, key string, perMin int, now time.Time) (bool, time.Duration, error)
+}
+
+// RequestRateLimiter limits the number of public dashboard requests per minute for each access token and each client
+// IP. A nil limiter doesn't limit anything
+type RequestRateLimiter struct {
+	perAccessToken int
+	perIP          int
+	buckets        rateLimitBuckets
+	log            log.Logger
+}
+
+// NewRequestRateLimiter returns the rate limiter configured in the public dashboards settings, nil when no limit is set
+func NewRequestRateLimiter(cfg *setting.Cfg, remoteCache remotecache.CacheStorage) *RequestRateLimiter {
+	if cfg.PublicDashboardsRateLimitPerAccessToken <= 0 && cfg.PublicDashboardsRateLimitPerIP <= 0 {
+		return nil
+	}
+
+	var buckets rateLimitBuckets = newMemoryRateLimitBuckets()
+	if cfg.PublicDashboardsRateLimitBackend == rateLimitBackendRemoteCache && remoteCache != nil {
+		buckets = &remoteCacheRateLimitBuckets{cache: remoteCache}
+	}
+
+	return &RequestRateLimiter{
+		perAccessToken: cfg.PublicDashboardsRateLimitPerAccessToken,
+		perIP:          cfg.PublicDashboardsRateLimitPerIP,
+		buckets:        buckets,
+		log:            log.New("publicdashboards.ratelimit"),
+	}
+}
+
+// RequiresRateLimit Middleware to enforce the rate limits of public dashboard requests. Requests above the limit of
+// their access token or client IP are rejected with a 429 and a Retry-After header
+func RequiresRateLimit(limiter *RequestRateLimiter, clientIPs *ClientIPResolver) func(c *contextmodel.ReqContext) {
+	return func(c *contextmodel.ReqContext) {
+		if retryAfter, err := limiter.Allow(c.Req.Context(), web.Params(c.Req)[":accessToken"], clientIPs.ClientIP(c.Req)); err != nil {
+			c.Resp.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			c.WriteErr(err)
+		}
+	}
+}
+
+// Allow takes a token from the buckets of the access token and the client IP. Requests above the limit of either
+// return an error and how long until they can be sent again. Requests are let through when the buckets can't be
+// read, so an unavailable remote cache doesn't take public dashboards down
+func (l *RequestRateLimiter) Allow(ctx context.Context, accessToken string, ip string) (time.Duration, error) {
+	if l == nil {
+		return 0, nil
+	}
+
+	limits := []struct {
+		dimension string
+		value     string
+		perMin    int
+	}{
+		{rateLimitDimensionAccessToken, accessToken, l.perAccessToken},
+		{rateLimitDimensionIP, ip, l.perIP},
+	}
+
+	now := time.Now()
+	for _, limit := range limits {
+		if limit.perMin <= 0 || limit.value == "" {
+			continue
+		}
+
+		allowed, retryAfter, err := l.buckets.take(ctx, rateLimitKey(limit.dimension, limit.value), limit.perMin, now)
+		if err != nil {
+			l.log.Warn("Failed to check the rate limit of a public dashboard request", "dimension", limit.dimension, "error", err)
+			continue
+		}
+		if allowed {
+			continue
+		}
+
+		metric.RequestsRateLimitedTotal.WithLabelValues(limit.dimension).Inc()
+		return retryAfter, models.ErrRateLimited.Errorf("RequiresRateLimit: rate limit of %d requests per minute by %s reached", limit.perMin, limit.dimension)
+	}
+	return 0, nil
+}
+
+// rateLimitKey hashes the value, so access tokens don't end up in the remote cache
+func rateLimitKey(dimension string, value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return dimension + "-" + hex.EncodeToString(sum[:16])
+}
+
+// memoryRateLimitBuckets keep the buckets in memory, so each Grafana instance has its own limits. Full buckets are
+// forgotten, they're the same as new ones
+type memoryRateLimitBuckets struct {
+	mu        sync.Mutex
+	limiters  map[string]*rate.Limiter
+	lastPrune time.Time
+}
+
+func newMemoryRateLimitBuckets() *memoryRateLimitBuckets {
+	return &memoryRateLimitBuckets{limiters: map[string]*rate.Limiter{}}
+}
+
+func (b *memoryRateLimitBuckets) take(_ context.Context, key string, perMin int, now time.Time) (bool, time.Duration, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if now.Sub(b.lastPrune) > time.Minute {
+		for k, limiter := range b.limiters {
+			if limiter.TokensAt(now) >= float64(limiter.Burst()) {
+				delete(b.limiters, k)
+			}
+		}
+		b.lastPrune = now
+	}
+
+	limiter, ok := b.limiters[key]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Every(time.Minute/time.Duration(perMin)), perMin)
+		b.limiters[key] = limiter
+	}
+
+	reservation := limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay, nil
+	}
+	return true, 0, nil
+}
+
+// remoteCacheRateLimitBuckets keep the buckets in the remote cache, so the limits are shared by Grafana instances.
+// The remote cache has no atomic updates, so concurrent requests can take the same token and the limits are
+// approximate
+type remoteCacheRateLimitBuckets struct {
+	cache remotecache.CacheStorage
+}
+
+type remoteCacheBucket struct {
+	Tokens    float64 `json:"tokens"`
+	UpdatedAt int64   `json:"updatedAt"`
+}
+
+func (b *remoteCacheRateLimitBuckets) take(ctx context.Context, key string, perMin int, now time.Time) (bool, time.Duration, error) {
+	key = rateLimitCacheKeyPrefix + key
+	bucket := remoteCacheBucket{Tokens: float64(perMin), UpdatedAt: now.UnixMilli()}
+
+	value, err := b.cache.Get(ctx, key)
+	switch {
+	case errors.Is(err, remotecache.ErrCacheItemNotFound):
+	case err != nil:
+		return false, 0, err
+	default:
+		if err := json.Unmarshal(value, &bucket); err != nil {
+			return false, 0, err
+		}
+	}
+
+	// refill the bucket for the time since it was last updated
+	perMs := float64(perMin) / float64(time.Minute.Milliseconds())
+	elapsed := now.UnixMilli() - bucket.UpdatedAt
+	if elapsed > 0 {
+		bucket.Tokens = math.Min(float64(perMin), bucket.Tokens+float64(elapsed)*perMs)
+		bucket.UpdatedAt = now.UnixMilli()
+	}
+
+	if bucket.Tokens < 1 {
+		return false, time.Duration((1-bucket.Tokens)/perMs) * time.Millisecond, nil
+	}
+
+	bucket.Tokens--
+	value, err = json.Marshal(bucket)
+	if err != nil {
+		return false, 0, err
+	}
+	// the bucket is full again after a minute, when it's the same as a missing one
+	if err := b.cache.Set(ctx, key, value, time.Minute); err != nil {
+		return false, 0, err
+	}
+	return true, 0, nil
+}
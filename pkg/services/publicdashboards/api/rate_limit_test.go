@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/remotecache"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestRateLimitBuckets(t *testing.T) {
+	now := time.Now()
+	backends := map[string]func() rateLimitBuckets{
+		"memory":       func() rateLimitBuckets { return newMemoryRateLimitBuckets() },
+		"remote cache": func() rateLimitBuckets { return &remoteCacheRateLimitBuckets{cache: remotecache.NewFakeCacheStorage()} },
+	}
+
+	for name, newBuckets := range backends {
+		t.Run(name, func(t *testing.T) {
+			t.Run("allows bursts up to the limit", func(t *testing.T) {
+				buckets := newBuckets()
+				for i := 0; i < 3; i++ {
+					allowed, _, err := buckets.take(context.Background(), "key", 3, now)
+					require.NoError(t, err)
+					require.True(t, allowed)
+				}
+
+				allowed, retryAfter, err := buckets.take(context.Background(), "key", 3, now)
+				require.NoError(t, err)
+				assert.False(t, allowed)
+				assert.InDelta(t, 20*time.Second, retryAfter, float64(time.Second))
+			})
+
+			t.Run("refills the bucket over time", func(t *testing.T) {
+				buckets := newBuckets()
+				for i := 0; i < 3; i++ {
+					_, _, err := buckets.take(context.Background(), "key", 3, now)
+					require.NoError(t, err)
+				}
+
+				allowed, _, err := buckets.take(context.Background(), "key", 3, now.Add(20*time.Second))
+				require.NoError(t, err)
+				assert.True(t, allowed)
+			})
+
+			t.Run("keeps a bucket per key", func(t *testing.T) {
+				buckets := newBuckets()
+				allowed, _, err := buckets.take(context.Background(), "key", 1, now)
+				require.NoError(t, err)
+				require.True(t, allowed)
+
+				allowed, _, err = buckets.take(context.Background(), "other", 1, now)
+				require.NoError(t, err)
+				assert.True(t, allowed)
+			})
+		})
+	}
+}
+
+func TestRequiresRateLimit(t *testing.T) {
+	newLimiter := func(perAccessToken, perIP int) *RequestRateLimiter {
+		cfg := setting.NewCfg()
+		cfg.PublicDashboardsRateLimitPerAccessToken = perAccessToken
+		cfg.PublicDashboardsRateLimitPerIP = perIP
+		cfg.PublicDashboardsRateLimitBackend = "memory"
+		return NewRequestRateLimiter(cfg, nil)
+	}
+	request := func(limiter *RequestRateLimiter, accessToken string, ip string) *httptest.ResponseRecorder {
+		params := map[string]string{":accessToken": accessToken}
+		mw := func(c *contextmodel.ReqContext) {
+			c.Req.RemoteAddr = ip + ":51234"
+			RequiresRateLimit(limiter, nil)(c)
+		}
+		_, resp := runMw(t, nil, "GET", "/api/public/dashboards/"+accessToken+"/annotations", params, mw)
+		return resp
+	}
+
+	t.Run("doesn't limit anything without limits", func(t *testing.T) {
+		limiter := newLimiter(0, 0)
+		require.Nil(t, limiter)
+		for i := 0; i < 10; i++ {
+			require.Equal(t, http.StatusOK, request(limiter, validAccessToken, "10.0.0.1").Code)
+		}
+	})
+
+	t.Run("limits requests by access token", func(t *testing.T) {
+		limiter := newLimiter(2, 0)
+		require.Equal(t, http.StatusOK, request(limiter, validAccessToken, "10.0.0.1").Code)
+		require.Equal(t, http.StatusOK, request(limiter, validAccessToken, "10.0.0.2").Code)
+
+		resp := request(limiter, validAccessToken, "10.0.0.3")
+		require.Equal(t, http.StatusTooManyRequests, resp.Code)
+		assert.Equal(t, "30", resp.Header().Get("Retry-After"))
+
+		require.Equal(t, http.StatusOK, request(limiter, "e71950f4b5fc4a9d8a34a2d1b2d7e5e0", "10.0.0.3").Code)
+	})
+
+	t.Run("limits requests by client IP", func(t *testing.T) {
+		limiter := newLimiter(0, 1)
+		require.Equal(t, http.StatusOK, request(limiter, validAccessToken, "10.0.0.1").Code)
+		require.Equal(t, http.StatusTooManyRequests, request(limiter, "e71950f4b5fc4a9d8a34a2d1b2d7e5e0", "10.0.0.1").Code)
+		require.Equal(t, http.StatusOK, request(limiter, validAccessToken, "10.0.0.2").Code)
+	})
+
+	t.Run("ignores the forwarded addresses of untrusted clients", func(t *testing.T) {
+		limiter := newLimiter(0, 1)
+		params := map[string]string{":accessToken": validAccessToken}
+		for i, forwarded := range []string{"203.0.113.1", "203.0.113.2"} {
+			mw := func(c *contextmodel.ReqContext) {
+				c.Req.RemoteAddr = "10.0.0.1:51234"
+				c.Req.Header.Set("X-Forwarded-For", forwarded)
+				c.Req.Header.Set("X-Real-IP", forwarded)
+				RequiresRateLimit(limiter, NewClientIPResolver(setting.NewCfg()))(c)
+			}
+			_, resp := runMw(t, nil, "GET", "/api/public/dashboards/"+validAccessToken+"/annotations", params, mw)
+			if i == 0 {
+				require.Equal(t, http.StatusOK, resp.Code)
+			} else {
+				require.Equal(t, http.StatusTooManyRequests, resp.Code)
+			}
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// swagger:route GET /public/dashboards/{accessToken}/panels/{panelId}/render dashboards dashboard_public renderPublicDashboardPanel
+//
+//	Render a panel of a public dashboard as a PNG image
+//
+// The panel is rendered by the image renderer with the time range and variables passed in the query string like for
+// queryPublicDashboardWithParams, so it can be embedded as a static image in other pages. Renders of a public dashboard
+// are rate limited and the size of the image is capped.
+//
+// Produces:
+// - image/png
+//
+// Responses:
+// 200: renderPublicDashboardPanelResponse
+// 400: badRequestPublicError
+// 401: unauthorisedPublicError
+// 404: panelNotFoundPublicError
+// 404: notFoundPublicError
+// 403: forbiddenPublicError
+// 429: tooManyRequestsPublicError
+// 500: internalServerPublicError
+func (api *Api) RenderPublicDashboardPanel(c *contextmodel.ReqContext) response.Response {
+	accessToken := web.Params(c.Req)[":accessToken"]
+	if !validation.IsValidAccessToken(accessToken) {
+		return response.Err(ErrInvalidAccessToken.Errorf("RenderPublicDashboardPanel: invalid access token"))
+	}
+
+	panelId, err := strconv.ParseInt(web.Params(c.Req)[":panelId"], 10, 64)
+	if err != nil {
+		return response.Err(ErrInvalidPanelId.Errorf("RenderPublicDashboardPanel: error parsing panelId %v", err))
+	}
+
+	reqDTO, err := renderDTOFromParams(c.Req.URL.Query())
+	if err != nil {
+		return response.Err(ErrInvalidRenderOptions.Errorf("RenderPublicDashboardPanel: error parsing query string: %v", err))
+	}
+
+	image, err := api.PublicDashboardService.RenderPanelPNG(c.Req.Context(), reqDTO, panelId, accessToken)
+	if err != nil {
+		return response.Err(err)
+	}
+
+	return exportResponse{export: image, inline: true}
+}
+
+// renderDTOFromParams parses the time range and variables of a render like queryDTOFromParams, with the size and theme
+func renderDTOFromParams(params url.Values) (PublicDashboardRenderDTO, error) {
+	queryDTO, err := queryDTOFromParams(params)
+	if err != nil {
+		return PublicDashboardRenderDTO{}, err
+	}
+
+	reqDTO := PublicDashboardRenderDTO{
+		TimeRange: queryDTO.TimeRange,
+		Variables: queryDTO.Variables,
+		Theme:     params.Get("theme"),
+	}
+
+	width, err := parseInt64Param(params, "width")
+	if err != nil {
+		return reqDTO, err
+	}
+	height, err := parseInt64Param(params, "height")
+	if err != nil {
+		return reqDTO, err
+	}
+	reqDTO.Width, reqDTO.Height = int(width), int(height)
+
+	return reqDTO, nil
+}
+
+// swagger:response renderPublicDashboardPanelResponse
+type RenderPublicDashboardPanelResponse struct {
+	// in: body
+	Body []byte `json:"body"`
+}
+
+// swagger:parameters renderPublicDashboardPanel
+type RenderPublicDashboardPanelParams struct {
+	// in: path
+	AccessToken string `json:"accessToken"`
+	// in: path
+	PanelId int64 `json:"panelId"`
+	// in: query
+	From string `json:"from"`
+	// in: query
+	To string `json:"to"`
+	// in: query
+	Timezone string `json:"timezone"`
+	// in: query
+	Width int `json:"width"`
+	// in: query
+	Height int `json:"height"`
+	// in: query
+	// enum: light,dark
+	Theme string `json:"theme"`
+}
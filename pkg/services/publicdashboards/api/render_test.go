@@ -0,0 +1,84 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func TestAPIRenderPublicDashboardPanel(t *testing.T) {
+	setup := func() (*web.Mux, *publicdashboards.FakePublicDashboardService) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		testServer := setupTestServer(t, nil, service, anonymousUser)
+
+		return testServer, service
+	}
+
+	path := fmt.Sprintf("/api/public/dashboards/%s/panels/2/render", validAccessToken)
+
+	t.Run("Status code is 400 when the access token is invalid", func(t *testing.T) {
+		server, _ := setup()
+		resp := callAPI(server, http.MethodGet, "/api/public/dashboards/SomeInvalidAccessToken/panels/2/render", nil, t)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("Status code is 400 when the panel ID is invalid", func(t *testing.T) {
+		server, service := setup()
+		expectViewerAccess(service, validAccessToken)
+		resp := callAPI(server, http.MethodGet, fmt.Sprintf("/api/public/dashboards/%s/panels/notanumber/render", validAccessToken), nil, t)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("Status code is 400 when the height is invalid", func(t *testing.T) {
+		server, service := setup()
+		expectViewerAccess(service, validAccessToken)
+		resp := callAPI(server, http.MethodGet, path+"?height=tall", nil, t)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("Serves the image inline with the time range, variables and size of the query string", func(t *testing.T) {
+		server, service := setup()
+		expectViewerAccess(service, validAccessToken)
+		expectedDTO := PublicDashboardRenderDTO{
+			TimeRange: TimeRangeDTO{From: "now-1h", To: "now"},
+			Variables: map[string]interface{}{"env": "prod"},
+			Width:     600,
+			Height:    300,
+		}
+		service.On("RenderPanelPNG", mock.Anything, expectedDTO, int64(2), validAccessToken).
+			Return(&PanelExport{
+				Filename:    "dashboard-panel-2.png",
+				ContentType: "image/png",
+				WriteTo: func(w io.Writer) error {
+					_, err := io.WriteString(w, "PNG")
+					return err
+				},
+			}, nil)
+
+		resp := callAPI(server, http.MethodGet, path+"?from=now-1h&to=now&var-env=prod&width=600&height=300", nil, t)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "image/png", resp.Header().Get("Content-Type"))
+		assert.Equal(t, "inline; filename=dashboard-panel-2.png", resp.Header().Get("Content-Disposition"))
+		assert.Equal(t, "max-age=60", resp.Header().Get("Cache-Control"))
+		assert.Equal(t, "PNG", resp.Body.String())
+	})
+
+	t.Run("Status code is 404 when the panel isn't found", func(t *testing.T) {
+		server, service := setup()
+		expectViewerAccess(service, validAccessToken)
+		service.On("RenderPanelPNG", mock.Anything, PublicDashboardRenderDTO{}, int64(2), validAccessToken).
+			Return(nil, ErrPanelNotFound.Errorf(""))
+
+		resp := callAPI(server, http.MethodGet, path, nil, t)
+		require.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// swagger:route POST /dashboards/uid/{dashboardUid}/public-dashboards/{uid}/revoke dashboards dashboard_public revokePublicDashboard
+//
+//	Revoke a public dashboard
+//
+// Deletes the public dashboard and stops its access tokens from serving anything right away: the caches of the
+// access tokens are cleared, the running queries of viewers are cancelled, their Live connections are closed and,
+// when `cdn_purge_url` is configured, the responses cached by the CDN are purged. The response reports which
+// invalidations succeeded.
+//
+// Produces:
+// - application/json
+//
+// Responses:
+// 200: revokePublicDashboardResponse
+// 400: badRequestPublicError
+// 401: unauthorisedPublicError
+// 403: forbiddenPublicError
+// 404: notFoundPublicError
+// 500: internalServerPublicError
+func (api *Api) RevokePublicDashboard(c *contextmodel.ReqContext) response.Response {
+	dashboardUid := web.Params(c.Req)[":dashboardUid"]
+	if !validation.IsValidShortUID(dashboardUid) {
+		return response.Err(ErrInvalidUid.Errorf("RevokePublicDashboard: invalid dashboard Uid %s", dashboardUid))
+	}
+
+	uid := web.Params(c.Req)[":uid"]
+	if !validation.IsValidShortUID(uid) {
+		return response.Err(ErrInvalidUid.Errorf("RevokePublicDashboard: invalid Uid %s", uid))
+	}
+
+	revocation, err := api.PublicDashboardService.Revoke(c.Req.Context(), c.SignedInUser, uid, dashboardUid)
+	if err != nil {
+		return response.Err(err)
+	}
+
+	return response.JSON(http.StatusOK, revocation)
+}
+
+// swagger:parameters revokePublicDashboard
+type RevokePublicDashboardParams struct {
+	// in:path
+	// required:true
+	DashboardUid string `json:"dashboardUid"`
+	// in:path
+	// required:true
+	Uid string `json:"uid"`
+}
+
+// swagger:response revokePublicDashboardResponse
+type RevokePublicDashboardResponse struct {
+	// in: body
+	Body PublicDashboardRevocation `json:"body"`
+}
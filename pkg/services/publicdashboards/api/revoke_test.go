@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+func TestAPIRevokePublicDashboard(t *testing.T) {
+	path := "/api/dashboards/uid/abc123/public-dashboards/pubdash1/revoke"
+
+	t.Run("Returns what was invalidated", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("Revoke", mock.Anything, mock.Anything, "pubdash1", "abc123").
+			Return(&PublicDashboardRevocation{Uid: "pubdash1", CancelledQueries: 2, LiveViewersDisconnected: true}, nil)
+		server := setupTestServer(t, nil, service, userAdmin)
+
+		resp := callAPI(server, http.MethodPost, path, nil, t)
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.JSONEq(t, `{"uid": "pubdash1", "cancelledQueries": 2, "liveViewersDisconnected": true, "otherInstancesInvalidated": false, "cdnPurged": false}`, resp.Body.String())
+	})
+
+	t.Run("Status code is 404 when the public dashboard doesn't exist", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("Revoke", mock.Anything, mock.Anything, "pubdash1", "abc123").
+			Return(nil, ErrPublicDashboardNotFound.Errorf(""))
+		server := setupTestServer(t, nil, service, userAdmin)
+
+		resp := callAPI(server, http.MethodPost, path, nil, t)
+		assert.Equal(t, http.StatusNotFound, resp.Code)
+	})
+
+	t.Run("Status code is 403 for viewers", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		server := setupTestServer(t, nil, service, userViewer)
+
+		resp := callAPI(server, http.MethodPost, path, nil, t)
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		service.AssertNotCalled(t, "Revoke")
+	})
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// swagger:route GET /dashboards/uid/{dashboardUid}/public-dashboards/{uid}/export dashboards dashboard_public exportPublicDashboardConfig
+//
+//	Export the share config of a public dashboard
+//
+// Returns every setting of the public dashboard as JSON, including its access token, allowed variables and
+// expiration time, to import it against another dashboard or in another organization or instance.
+//
+// Produces:
+// - application/json
+//
+// Responses:
+// 200: exportPublicDashboardConfigResponse
+// 400: badRequestPublicError
+// 401: unauthorisedPublicError
+// 403: forbiddenPublicError
+// 404: notFoundPublicError
+// 500: internalServerPublicError
+func (api *Api) ExportPublicDashboardConfig(c *contextmodel.ReqContext) response.Response {
+	dashboardUid := web.Params(c.Req)[":dashboardUid"]
+	if !validation.IsValidShortUID(dashboardUid) {
+		return response.Err(ErrInvalidUid.Errorf("ExportPublicDashboardConfig: invalid dashboard Uid %s", dashboardUid))
+	}
+
+	uid := web.Params(c.Req)[":uid"]
+	if !validation.IsValidShortUID(uid) {
+		return response.Err(ErrInvalidUid.Errorf("ExportPublicDashboardConfig: invalid Uid %s", uid))
+	}
+
+	export, err := api.PublicDashboardService.ExportConfig(c.Req.Context(), c.SignedInUser, uid, dashboardUid)
+	if err != nil {
+		return response.Err(err)
+	}
+
+	return response.JSON(http.StatusOK, export)
+}
+
+// swagger:route POST /dashboards/uid/{dashboardUid}/public-dashboards/import dashboards dashboard_public importPublicDashboardConfig
+//
+//	Import the share config of a public dashboard
+//
+// Creates a public dashboard of the dashboard with a share config returned by the export endpoint, in the
+// organization of the user. The access token of the exported config is kept when preserveAccessToken is set, so
+// the URLs of the public dashboard keep working after it's promoted to another instance, and a new one is
+// generated otherwise. The dashboard can't have a public dashboard already.
+//
+// Produces:
+// - application/json
+//
+// Responses:
+// 200: importPublicDashboardConfigResponse
+// 400: badRequestPublicError
+// 401: unauthorisedPublicError
+// 403: forbiddenPublicError
+// 404: notFoundPublicError
+// 500: internalServerPublicError
+func (api *Api) ImportPublicDashboardConfig(c *contextmodel.ReqContext) response.Response {
+	dashboardUid := web.Params(c.Req)[":dashboardUid"]
+	if !validation.IsValidShortUID(dashboardUid) {
+		return response.Err(ErrInvalidUid.Errorf("ImportPublicDashboardConfig: invalid dashboard Uid %s", dashboardUid))
+	}
+
+	dto := &PublicDashboardConfigImportDTO{}
+	if err := web.Bind(c.Req, dto); err != nil {
+		return response.Err(ErrBadRequest.Errorf("ImportPublicDashboardConfig: bad request data %v", err))
+	}
+
+	pubdash, err := api.PublicDashboardService.ImportConfig(c.Req.Context(), c.SignedInUser, dashboardUid, dto)
+	if err != nil {
+		return response.Err(err)
+	}
+
+	return response.JSON(http.StatusOK, pubdash)
+}
+
+// swagger:parameters exportPublicDashboardConfig
+type ExportPublicDashboardConfigParams struct {
+	// in:path
+	// required:true
+	DashboardUid string `json:"dashboardUid"`
+	// in:path
+	// required:true
+	Uid string `json:"uid"`
+}
+
+// swagger:response exportPublicDashboardConfigResponse
+type ExportPublicDashboardConfigResponse struct {
+	// in: body
+	Body PublicDashboardConfigExport `json:"body"`
+}
+
+// swagger:parameters importPublicDashboardConfig
+type ImportPublicDashboardConfigParams struct {
+	// in:path
+	// required:true
+	DashboardUid string `json:"dashboardUid"`
+	// in:body
+	// required:true
+	Body PublicDashboardConfigImportDTO
+}
+
+// swagger:response importPublicDashboardConfigResponse
+type ImportPublicDashboardConfigResponse struct {
+	// in: body
+	Body PublicDashboard `json:"body"`
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+func TestAPIExportPublicDashboardConfig(t *testing.T) {
+	path := "/api/dashboards/uid/abc123/public-dashboards/pubdash1/export"
+
+	t.Run("Returns the share config", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("ExportConfig", mock.Anything, mock.Anything, "pubdash1", "abc123").
+			Return(&PublicDashboardConfigExport{Version: 1, DashboardUid: "abc123", Config: PublicDashboardDTO{AccessToken: "e71950f4b5fc4a9d8a34a2d1b2d7e5e0"}}, nil)
+		server := setupTestServer(t, nil, service, userAdmin)
+
+		resp := callAPI(server, http.MethodGet, path, nil, t)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"accessToken":"e71950f4b5fc4a9d8a34a2d1b2d7e5e0"`)
+	})
+
+	t.Run("Status code is 403 for viewers", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		server := setupTestServer(t, nil, service, userViewer)
+
+		resp := callAPI(server, http.MethodGet, path, nil, t)
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		service.AssertNotCalled(t, "ExportConfig")
+	})
+}
+
+func TestAPIImportPublicDashboardConfig(t *testing.T) {
+	path := "/api/dashboards/uid/abc123/public-dashboards/import"
+
+	t.Run("Creates the public dashboard with the config", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("ImportConfig", mock.Anything, mock.Anything, "abc123", mock.MatchedBy(func(dto *PublicDashboardConfigImportDTO) bool {
+			return dto.PreserveAccessToken && dto.Export.Config.AccessToken == "e71950f4b5fc4a9d8a34a2d1b2d7e5e0"
+		})).Return(&PublicDashboard{Uid: "pubdash2", DashboardUid: "abc123"}, nil)
+		server := setupTestServer(t, nil, service, userAdmin)
+
+		body := `{"export": {"version": 1, "config": {"accessToken": "e71950f4b5fc4a9d8a34a2d1b2d7e5e0"}}, "preserveAccessToken": true}`
+		resp := callAPI(server, http.MethodPost, path, strings.NewReader(body), t)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Contains(t, resp.Body.String(), `"uid":"pubdash2"`)
+	})
+
+	t.Run("Status code is 400 for configs of unsupported versions", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("ImportConfig", mock.Anything, mock.Anything, "abc123", mock.Anything).
+			Return(nil, ErrBadRequest.Errorf("unsupported export version"))
+		server := setupTestServer(t, nil, service, userAdmin)
+
+		resp := callAPI(server, http.MethodPost, path, strings.NewReader(`{"export": {"version": 2}}`), t)
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("Status code is 403 for viewers", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		server := setupTestServer(t, nil, service, userViewer)
+
+		resp := callAPI(server, http.MethodPost, path, strings.NewReader(`{}`), t)
+		assert.Equal(t, http.StatusForbidden, resp.Code)
+		service.AssertNotCalled(t, "ImportConfig")
+	})
+}
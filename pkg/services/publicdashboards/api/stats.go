@@ -0,0 +1,66 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// swagger:route GET /dashboards/uid/{dashboardUid}/public-dashboards/{uid}/stats dashboards dashboard_public getPublicDashboardStats
+//
+//	Get usage statistics of a public dashboard
+//
+// Returns the current and peak number of concurrent anonymous viewers and the most requested values of each
+// variable. Use topN to set the number of values returned per variable, 10 by default and at most 100.
+// Values requested fewer than 5 times aren't returned. Counts are kept in memory by each Grafana instance
+// and reset on restart. The usage has the total number of views and queries and when the public dashboard was last
+// viewed and queried, which are stored in batches and kept across restarts.
+//
+// Responses:
+// 200: getPublicDashboardStatsResponse
+// 400: badRequestPublicError
+// 401: unauthorisedPublicError
+// 403: forbiddenPublicError
+// 404: notFoundPublicError
+// 500: internalServerPublicError
+func (api *Api) GetPublicDashboardStats(c *contextmodel.ReqContext) response.Response {
+	dashboardUid := web.Params(c.Req)[":dashboardUid"]
+	if !validation.IsValidShortUID(dashboardUid) {
+		return response.Err(ErrInvalidUid.Errorf("GetPublicDashboardStats: invalid dashboard Uid %s", dashboardUid))
+	}
+
+	uid := web.Params(c.Req)[":uid"]
+	if !validation.IsValidShortUID(uid) {
+		return response.Err(ErrInvalidUid.Errorf("GetPublicDashboardStats: invalid Uid %s", uid))
+	}
+
+	stats, err := api.PublicDashboardService.GetStats(c.Req.Context(), c.GetOrgID(), dashboardUid, uid, c.QueryInt("topN"))
+	if err != nil {
+		return response.Err(err)
+	}
+
+	return response.JSON(http.StatusOK, stats)
+}
+
+// swagger:parameters getPublicDashboardStats
+type GetPublicDashboardStatsParams struct {
+	// in:path
+	// required:true
+	DashboardUid string `json:"dashboardUid"`
+	// in:path
+	// required:true
+	Uid string `json:"uid"`
+	// in:query
+	// required:false
+	TopN int `json:"topN"`
+}
+
+// swagger:response getPublicDashboardStatsResponse
+type GetPublicDashboardStatsResponse struct {
+	// in: body
+	Body PublicDashboardStats `json:"body"`
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+func TestAPIGetPublicDashboardStats(t *testing.T) {
+	path := "/api/dashboards/uid/abc123/public-dashboards/pubdash1/stats"
+
+	t.Run("Viewer can get the stats", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("GetStats", mock.Anything, int64(1), "abc123", "pubdash1", 3).
+			Return(&PublicDashboardStats{
+				ViewerStats:       ViewerStats{CurrentViewers: 3, PeakViewers: 12},
+				TopVariableValues: map[string][]VariableValueUsage{"region": {{Value: "eu", Requests: 42}}},
+			}, nil)
+		server := setupTestServer(t, nil, service, userViewer)
+
+		resp := callAPI(server, http.MethodGet, path+"?topN=3", nil, t)
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.JSONEq(t, `{"currentViewers":3,"peakViewers":12,"topVariableValues":{"region":[{"value":"eu","requests":42}]}}`, resp.Body.String())
+	})
+
+	t.Run("Status code is 403 without dashboard read permission", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		server := setupTestServer(t, nil, service, userNoRBACPerms)
+
+		resp := callAPI(server, http.MethodGet, path, nil, t)
+		require.Equal(t, http.StatusForbidden, resp.Code)
+	})
+}
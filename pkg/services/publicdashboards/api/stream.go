@@ -0,0 +1,219 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// streamKeepAliveInterval is how often a comment is sent while the queries of a streamed panel run, so proxies and
+// gateways don't time out the connection of slow datasources
+const streamKeepAliveInterval = 15 * time.Second
+
+// Events of a streamed panel query. Every frame is sent in its own frame event, failed queries in an error event, and
+// the stream ends with a status event
+const (
+	streamEventFrame  = "frame"
+	streamEventError  = "error"
+	streamEventStatus = "status"
+)
+
+// swagger:route GET /public/dashboards/{accessToken}/panels/{panelId}/query/stream dashboards dashboard_public streamPublicDashboardQuery
+//
+//	Stream the results of a panel on a public dashboard as server-sent events
+//
+// The time range and variables are passed in the query string like for queryPublicDashboardWithParams. The connection
+// is kept alive while the queries run, then every frame is sent in a `frame` event with the refId of its query, every
+// failed query in an `error` event, and the stream ends with a `status` event.
+//
+// Produces:
+// - text/event-stream
+//
+// Responses:
+// 200: streamPublicDashboardQueryResponse
+// 400: badRequestPublicError
+// 401: unauthorisedPublicError
+// 403: forbiddenPublicError
+// 500: internalServerPublicError
+func (api *Api) StreamPublicDashboardQuery(c *contextmodel.ReqContext) response.Response {
+	accessToken := web.Params(c.Req)[":accessToken"]
+	if !validation.IsValidAccessToken(accessToken) {
+		return response.Err(ErrInvalidAccessToken.Errorf("StreamPublicDashboardQuery: invalid access token"))
+	}
+
+	panelId, err := strconv.ParseInt(web.Params(c.Req)[":panelId"], 10, 64)
+	if err != nil {
+		return response.Err(ErrInvalidPanelId.Errorf("StreamPublicDashboardQuery: error parsing panelId %v", err))
+	}
+
+	reqDTO, err := queryDTOFromParams(c.Req.URL.Query())
+	if err != nil {
+		return response.Err(ErrBadRequest.Errorf("StreamPublicDashboardQuery: error parsing query string: %v", err))
+	}
+
+	return streamResponse{
+		keepAlive: streamKeepAliveInterval,
+		query: func(ctx context.Context) (*backend.QueryDataResponse, error) {
+			return api.PublicDashboardService.GetQueryDataResponse(ctx, c.SkipDSCache, reqDTO, panelId, accessToken)
+		},
+	}
+}
+
+// streamResponse runs the queries of a panel and streams their results as server-sent events. The status is sent
+// before the queries run, so failures of the panel are reported in the final status event
+type streamResponse struct {
+	keepAlive time.Duration
+	query     func(ctx context.Context) (*backend.QueryDataResponse, error)
+}
+
+type streamFrameEvent struct {
+	RefID string      `json:"refId"`
+	Frame *data.Frame `json:"frame"`
+}
+
+type streamErrorEvent struct {
+	RefID  string `json:"refId"`
+	Error  string `json:"error"`
+	Status int    `json:"status,omitempty"`
+}
+
+type streamStatusEvent struct {
+	Status string               `json:"status"`
+	Error  *errutil.PublicError `json:"error,omitempty"`
+}
+
+func (r streamResponse) Status() int {
+	return http.StatusOK
+}
+
+func (r streamResponse) Body() []byte {
+	return nil
+}
+
+func (r streamResponse) WriteTo(ctx *contextmodel.ReqContext) {
+	header := ctx.Resp.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-store")
+	// disables the buffering of nginx, which would hold the events until the stream ends
+	header.Set("X-Accel-Buffering", "no")
+	ctx.Resp.WriteHeader(http.StatusOK)
+	ctx.Resp.Flush()
+
+	type result struct {
+		resp *backend.QueryDataResponse
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		resp, err := r.query(ctx.Req.Context())
+		done <- result{resp: resp, err: err}
+	}()
+
+	ticker := time.NewTicker(r.keepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Req.Context().Done():
+			return
+		case <-ticker.C:
+			if _, err := io.WriteString(ctx.Resp, ": keepalive\n\n"); err != nil {
+				return
+			}
+			ctx.Resp.Flush()
+		case res := <-done:
+			// the status is already sent, failures can only be logged
+			if err := writeStreamEvents(ctx.Resp, res.resp, res.err); err != nil {
+				ctx.Logger.Error("Error writing streamed panel query", "error", err)
+			}
+			ctx.Resp.Flush()
+			return
+		}
+	}
+}
+
+// writeStreamEvents writes the frames and errors of the query response ordered by refId, then the final status
+func writeStreamEvents(w io.Writer, resp *backend.QueryDataResponse, queryErr error) error {
+	if queryErr != nil {
+		var gfErr errutil.Error
+		if !errors.As(queryErr, &gfErr) {
+			gfErr = ErrInternalServerError.Errorf("writeStreamEvents: panel query failed: %w", queryErr)
+		}
+		public := gfErr.Public()
+		return writeStreamEvent(w, streamEventStatus, streamStatusEvent{Status: "error", Error: &public})
+	}
+
+	refIDs := make([]string, 0, len(resp.Responses))
+	for refID := range resp.Responses {
+		refIDs = append(refIDs, refID)
+	}
+	sort.Strings(refIDs)
+
+	for _, refID := range refIDs {
+		res := resp.Responses[refID]
+		if res.Error != nil {
+			if err := writeStreamEvent(w, streamEventError, streamErrorEvent{RefID: refID, Error: res.Error.Error(), Status: int(res.Status)}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, frame := range res.Frames {
+			if err := writeStreamEvent(w, streamEventFrame, streamFrameEvent{RefID: refID, Frame: frame}); err != nil {
+				return err
+			}
+			if f, ok := w.(http.Flusher); ok {
+				f.Flush()
+			}
+		}
+	}
+
+	return writeStreamEvent(w, streamEventStatus, streamStatusEvent{Status: "done"})
+}
+
+// writeStreamEvent writes a server-sent event with the JSON encoded data. JSON doesn't contain newlines, so the data
+// always fits on a single data line
+func writeStreamEvent(w io.Writer, event string, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, body)
+	return err
+}
+
+// swagger:response streamPublicDashboardQueryResponse
+type StreamPublicDashboardQueryResponse struct {
+	// in: body
+	Body string `json:"body"`
+}
+
+// swagger:parameters streamPublicDashboardQuery
+type StreamPublicDashboardQueryParams struct {
+	// in: path
+	AccessToken string `json:"accessToken"`
+	// in: path
+	PanelId int64 `json:"panelId"`
+	// in: query
+	From string `json:"from"`
+	// in: query
+	To string `json:"to"`
+	// in: query
+	Timezone string `json:"timezone"`
+}
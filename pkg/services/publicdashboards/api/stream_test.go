@@ -0,0 +1,83 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func TestAPIStreamPublicDashboardQuery(t *testing.T) {
+	setup := func() (*web.Mux, *publicdashboards.FakePublicDashboardService) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		testServer := setupTestServer(t, nil, service, anonymousUser)
+
+		return testServer, service
+	}
+
+	path := fmt.Sprintf("/api/public/dashboards/%s/panels/2/query/stream", validAccessToken)
+
+	t.Run("Status code is 400 when the access token is invalid", func(t *testing.T) {
+		server, _ := setup()
+		resp := callAPI(server, http.MethodGet, "/api/public/dashboards/SomeInvalidAccessToken/panels/2/query/stream", nil, t)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("Status code is 400 when the panel ID is invalid", func(t *testing.T) {
+		server, service := setup()
+		expectViewerAccess(service, validAccessToken)
+		resp := callAPI(server, http.MethodGet, fmt.Sprintf("/api/public/dashboards/%s/panels/notanumber/query/stream", validAccessToken), nil, t)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+
+	t.Run("Streams the frames and errors of the queries followed by the status", func(t *testing.T) {
+		server, service := setup()
+		expectViewerAccess(service, validAccessToken)
+		expectedDTO := PublicDashboardQueryDTO{
+			TimeRange: TimeRangeDTO{From: "now-1h", To: "now"},
+			Variables: map[string]interface{}{"env": "prod"},
+		}
+		service.On("GetQueryDataResponse", mock.Anything, mock.Anything, expectedDTO, int64(2), validAccessToken).
+			Return(&backend.QueryDataResponse{Responses: backend.Responses{
+				"B": backend.DataResponse{Error: errors.New("query timed out"), Status: backend.StatusTimeout},
+				"A": backend.DataResponse{Frames: data.Frames{data.NewFrame("requests", data.NewField("value", nil, []float64{1}))}},
+			}}, nil)
+
+		resp := callAPI(server, http.MethodGet, path+"?from=now-1h&to=now&var-env=prod", nil, t)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "text/event-stream", resp.Header().Get("Content-Type"))
+		assert.Equal(t, "no-store", resp.Header().Get("Cache-Control"))
+
+		events := strings.Split(strings.TrimSpace(resp.Body.String()), "\n\n")
+		require.Len(t, events, 3)
+		assert.True(t, strings.HasPrefix(events[0], "event: frame\ndata: {\"refId\":\"A\",\"frame\":"))
+		assert.Equal(t, "event: error\ndata: {\"refId\":\"B\",\"error\":\"query timed out\",\"status\":504}", events[1])
+		assert.Equal(t, "event: status\ndata: {\"status\":\"done\"}", events[2])
+	})
+
+	t.Run("Ends the stream with the public error when the panel can't be queried", func(t *testing.T) {
+		server, service := setup()
+		expectViewerAccess(service, validAccessToken)
+		service.On("GetQueryDataResponse", mock.Anything, mock.Anything, mock.Anything, int64(2), validAccessToken).
+			Return(nil, ErrPanelNotFound.Errorf("panel not found"))
+
+		resp := callAPI(server, http.MethodGet, path, nil, t)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		body := resp.Body.String()
+		assert.Contains(t, body, "event: status\n")
+		assert.Contains(t, body, `"status":"error"`)
+		assert.Contains(t, body, `"messageId":"publicdashboards.panelNotFound"`)
+	})
+}
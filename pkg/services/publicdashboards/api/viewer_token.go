@@ -0,0 +1,127 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// swagger:route GET /public/dashboards/{accessToken}/viewer-challenge dashboards dashboard_public getPublicDashboardViewerChallenge
+//
+//	Get the challenge viewers solve before they get a viewer token
+//
+// The challenge is a proof-of-work or a captcha, or of type none when viewers aren't challenged. Proof-of-work
+// challenges are bound to the IP address and user agent of the viewer.
+//
+// Responses:
+// 200: getPublicDashboardViewerChallengeResponse
+// 400: badRequestPublicError
+// 403: forbiddenPublicError
+// 404: notFoundPublicError
+// 500: internalServerPublicError
+func (api *Api) GetPublicDashboardViewerChallenge(c *contextmodel.ReqContext) response.Response {
+	accessToken := web.Params(c.Req)[":accessToken"]
+	if !validation.IsValidAccessTokenOrSlug(accessToken) {
+		return response.Err(ErrInvalidAccessToken.Errorf("GetPublicDashboardViewerChallenge: invalid access token"))
+	}
+
+	challenge, err := api.PublicDashboardService.GetViewerChallenge(c.Req.Context(), accessToken, viewerClient(c, api.ClientIPs))
+	if err != nil {
+		return response.Err(err)
+	}
+
+	return response.JSON(http.StatusOK, challenge)
+}
+
+// swagger:route POST /public/dashboards/{accessToken}/viewer-token dashboards dashboard_public issuePublicDashboardViewerToken
+//
+//	Exchange the access token of a public dashboard for a short-lived viewer token
+//
+// The viewer token is bound to the IP address and user agent of the viewer, and is required by the query and
+// variable endpoints in the X-Grafana-Public-Dashboard-Token header. Viewers get a new token before it expires.
+// When viewers are challenged the request contains the solution of the viewer challenge. When the public dashboard
+// has reached its maximum number of concurrent viewers, the response is a 429 with a Retry-After header telling the
+// viewer when to try again.
+//
+// Responses:
+// 200: issuePublicDashboardViewerTokenResponse
+// 400: badRequestPublicError
+// 403: forbiddenPublicError
+// 404: notFoundPublicError
+// 429: tooManyRequestsPublicError
+// 500: internalServerPublicError
+func (api *Api) IssuePublicDashboardViewerToken(c *contextmodel.ReqContext) response.Response {
+	accessToken := web.Params(c.Req)[":accessToken"]
+	if !validation.IsValidAccessTokenOrSlug(accessToken) {
+		return response.Err(ErrInvalidAccessToken.Errorf("IssuePublicDashboardViewerToken: invalid access token"))
+	}
+
+	reqDTO := ViewerTokenRequestDTO{}
+	if err := web.Bind(c.Req, &reqDTO); err != nil {
+		return response.Err(ErrBadRequest.Errorf("IssuePublicDashboardViewerToken: error parsing request: %v", err))
+	}
+
+	token, err := api.PublicDashboardService.IssueViewerToken(c.Req.Context(), accessToken, viewerClient(c, api.ClientIPs), reqDTO.Solution)
+	if err != nil {
+		if retryAfter := viewerLimitRetryAfter(err); retryAfter != "" {
+			return response.Err(err).SetHeader("Retry-After", retryAfter)
+		}
+		return response.Err(err)
+	}
+
+	return response.JSON(http.StatusOK, token)
+}
+
+// viewerClient identifies the client of the request viewer tokens are bound to
+func viewerClient(c *contextmodel.ReqContext, clientIPs *ClientIPResolver) ViewerClient {
+	return ViewerClient{IP: clientIPs.ClientIP(c.Req), UserAgent: c.Req.UserAgent()}
+}
+
+// viewerLimitRetryAfter returns the Retry-After header of the error of a public dashboard which reached its maximum
+// number of concurrent viewers, empty for other errors
+func viewerLimitRetryAfter(err error) string {
+	var limitErr errutil.Error
+	if !errors.Is(err, ErrViewerLimitReached) || !errors.As(err, &limitErr) {
+		return ""
+	}
+	seconds, ok := limitErr.PublicPayload["retryAfterSeconds"].(int)
+	if !ok {
+		return ""
+	}
+	return strconv.Itoa(seconds)
+}
+
+// swagger:parameters getPublicDashboardViewerChallenge
+type GetPublicDashboardViewerChallengeParams struct {
+	// in:path
+	// required:true
+	AccessToken string `json:"accessToken"`
+}
+
+// swagger:response getPublicDashboardViewerChallengeResponse
+type GetPublicDashboardViewerChallengeResponse struct {
+	// in: body
+	Body ViewerChallenge `json:"body"`
+}
+
+// swagger:parameters issuePublicDashboardViewerToken
+type IssuePublicDashboardViewerTokenParams struct {
+	// in:path
+	// required:true
+	AccessToken string `json:"accessToken"`
+	// in:body
+	Body ViewerTokenRequestDTO
+}
+
+// swagger:response issuePublicDashboardViewerTokenResponse
+type IssuePublicDashboardViewerTokenResponse struct {
+	// in: body
+	Body ViewerToken `json:"body"`
+}
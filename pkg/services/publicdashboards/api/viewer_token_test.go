@@ -0,0 +1,146 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+func TestAPIIssuePublicDashboardViewerToken(t *testing.T) {
+	path := fmt.Sprintf("/api/public/dashboards/%s/viewer-token", validAccessToken)
+
+	t.Run("Returns a viewer token for the client", func(t *testing.T) {
+		expiresAt := time.Date(2026, 10, 16, 12, 10, 0, 0, time.UTC)
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("IssueViewerToken", mock.Anything, validAccessToken, mock.AnythingOfType("models.ViewerClient"), (*ViewerChallengeSolution)(nil)).Return(&ViewerToken{Token: "signed", ExpiresAt: expiresAt}, nil)
+		expectPublicDashboardLookup(service, validAccessToken)
+		server := setupTestServer(t, nil, service, anonymousUser)
+
+		resp := callAPI(server, http.MethodPost, path, nil, t)
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		var token ViewerToken
+		require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &token))
+		assert.Equal(t, "signed", token.Token)
+		assert.True(t, expiresAt.Equal(token.ExpiresAt))
+	})
+
+	t.Run("Passes the solution of the viewer challenge", func(t *testing.T) {
+		solution := &ViewerChallengeSolution{Challenge: "challenge", Nonce: "42"}
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("IssueViewerToken", mock.Anything, validAccessToken, mock.Anything, solution).Return(&ViewerToken{Token: "signed"}, nil)
+		expectPublicDashboardLookup(service, validAccessToken)
+		server := setupTestServer(t, nil, service, anonymousUser)
+
+		resp := callAPI(server, http.MethodPost, path, strings.NewReader(`{"solution":{"challenge":"challenge","nonce":"42"}}`), t)
+		require.Equal(t, http.StatusOK, resp.Code)
+	})
+
+	t.Run("Status code is 403 when the viewer challenge failed", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("IssueViewerToken", mock.Anything, validAccessToken, mock.Anything, mock.Anything).Return(nil, ErrViewerChallengeFailed.Errorf(""))
+		expectPublicDashboardLookup(service, validAccessToken)
+		server := setupTestServer(t, nil, service, anonymousUser)
+
+		resp := callAPI(server, http.MethodPost, path, strings.NewReader(`{"solution":{"challenge":"challenge","nonce":"1"}}`), t)
+		require.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("Status code is 403 when the public dashboard is not enabled", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("IssueViewerToken", mock.Anything, validAccessToken, mock.Anything, mock.Anything).Return(nil, ErrPublicDashboardNotEnabled.Errorf(""))
+		expectPublicDashboardLookup(service, validAccessToken)
+		server := setupTestServer(t, nil, service, anonymousUser)
+
+		resp := callAPI(server, http.MethodPost, path, nil, t)
+		require.Equal(t, http.StatusForbidden, resp.Code)
+	})
+
+	t.Run("Status code is 429 with a retry after when the viewer limit is reached", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("IssueViewerToken", mock.Anything, validAccessToken, mock.Anything, mock.Anything).Return(nil, viewerLimitReached(30))
+		expectPublicDashboardLookup(service, validAccessToken)
+		server := setupTestServer(t, nil, service, anonymousUser)
+
+		resp := callAPI(server, http.MethodPost, path, nil, t)
+		require.Equal(t, http.StatusTooManyRequests, resp.Code)
+		assert.Equal(t, "30", resp.Header().Get("Retry-After"))
+		assert.Contains(t, resp.Body.String(), `"retryAfterSeconds":30`)
+	})
+
+	t.Run("Status code is 400 when the access token is invalid", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		server := setupTestServer(t, nil, service, anonymousUser)
+
+		resp := callAPI(server, http.MethodPost, "/api/public/dashboards/SomeInvalidAccessToken/viewer-token", nil, t)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
+func TestAPIGetPublicDashboardViewerChallenge(t *testing.T) {
+	path := fmt.Sprintf("/api/public/dashboards/%s/viewer-challenge", validAccessToken)
+
+	t.Run("Returns the viewer challenge", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("GetViewerChallenge", mock.Anything, validAccessToken, mock.Anything).Return(&ViewerChallenge{Type: ViewerChallengeTypeProofOfWork, Challenge: "challenge", Difficulty: 16}, nil)
+		expectPublicDashboardLookup(service, validAccessToken)
+		server := setupTestServer(t, nil, service, anonymousUser)
+
+		resp := callAPI(server, http.MethodGet, path, nil, t)
+		require.Equal(t, http.StatusOK, resp.Code)
+		assert.JSONEq(t, `{"type":"proof_of_work","challenge":"challenge","difficulty":16}`, resp.Body.String())
+	})
+
+	t.Run("Status code is 404 when the public dashboard is not found", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		service.On("GetViewerChallenge", mock.Anything, validAccessToken, mock.Anything).Return(nil, ErrPublicDashboardNotFound.Errorf(""))
+		expectPublicDashboardLookup(service, validAccessToken)
+		server := setupTestServer(t, nil, service, anonymousUser)
+
+		resp := callAPI(server, http.MethodGet, path, nil, t)
+		require.Equal(t, http.StatusNotFound, resp.Code)
+	})
+}
+
+func TestAPIRequiresViewerToken(t *testing.T) {
+	path := fmt.Sprintf("/api/public/dashboards/%s/metadata", validAccessToken)
+
+	t.Run("Status code is 401 without a viewer token", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		expectPublicDashboardLookup(service, validAccessToken)
+		service.On("ValidateViewerToken", mock.Anything, validAccessToken, "", mock.AnythingOfType("models.ViewerClient")).
+			Return(ErrInvalidViewerToken.Errorf("missing viewer token"))
+		server := setupTestServer(t, nil, service, anonymousUser)
+
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, req)
+
+		require.Equal(t, http.StatusUnauthorized, resp.Code)
+		service.AssertNotCalled(t, "GetMetadata", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Status code is 401 with an invalid viewer token", func(t *testing.T) {
+		service := publicdashboards.NewFakePublicDashboardService(t)
+		expectPublicDashboardLookup(service, validAccessToken)
+		service.On("ValidateViewerToken", mock.Anything, validAccessToken, testViewerToken, mock.AnythingOfType("models.ViewerClient")).
+			Return(ErrInvalidViewerToken.Errorf("token issued to another client"))
+		server := setupTestServer(t, nil, service, anonymousUser)
+
+		resp := callAPI(server, http.MethodGet, path, nil, t)
+		require.Equal(t, http.StatusUnauthorized, resp.Code)
+		service.AssertNotCalled(t, "GetMetadata", mock.Anything, mock.Anything)
+	})
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -12,6 +14,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/publicdashboards"
 	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
@@ -53,14 +56,21 @@ func (d *PublicDashboardStoreImpl) FindAll(ctx context.Context, query *PublicDas
 	}
 
 	pubdashBuilder := db.NewSqlBuilder(d.cfg, d.features, d.sqlStore.GetDialect(), recursiveQueriesAreSupported)
-	pubdashBuilder.Write("SELECT uid, access_token, dashboard_uid, is_enabled")
+	pubdashBuilder.Write("SELECT uid, access_token, dashboard_uid, is_enabled, created_at, updated_at, last_accessed_at, expires_at, panel_id,")
+	// variables are enabled for public dashboards saved before they could be disabled
+	pubdashBuilder.Write(" annotations_enabled, time_selection_enabled, COALESCE(variables_enabled, ?) AS variables_enabled, share,", true)
+	pubdashBuilder.Write(" COALESCE(dashboard_public_usage.views, 0) AS views, COALESCE(dashboard_public_usage.queries, 0) AS queries")
 	pubdashBuilder.Write(" FROM dashboard_public")
-	pubdashBuilder.Write(` WHERE org_id = ?`, query.OrgID)
+	pubdashBuilder.Write(" LEFT JOIN dashboard_public_usage ON dashboard_public_usage.public_dashboard_uid = dashboard_public.uid")
+	pubdashBuilder.Write(` WHERE dashboard_public.org_id = ?`, query.OrgID)
+
+	writeListFilters(&pubdashBuilder, query)
 
 	counterBuilder := db.NewSqlBuilder(d.cfg, d.features, d.sqlStore.GetDialect(), recursiveQueriesAreSupported)
 	counterBuilder.Write("SELECT COUNT(*)")
 	counterBuilder.Write(" FROM dashboard_public")
 	counterBuilder.Write(` WHERE org_id = ?`, query.OrgID)
+	writeListFilters(&counterBuilder, query)
 
 	err = d.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
 		err := sess.SQL(pubdashBuilder.GetSQLString(), pubdashBuilder.GetParams()...).Find(&resp.PublicDashboards)
@@ -79,6 +89,30 @@ func (d *PublicDashboardStoreImpl) FindAll(ctx context.Context, query *PublicDas
 	return resp, nil
 }
 
+// writeListFilters adds the filters of the list query on the config of public dashboards. Filters on the dashboard
+// are applied when the dashboards are joined in
+func writeListFilters(builder *db.SQLBuilder, query *PublicDashboardListQuery) {
+	if query.IsEnabled != nil {
+		builder.Write(" AND is_enabled = ?", *query.IsEnabled)
+	}
+	if query.AnnotationsEnabled != nil {
+		builder.Write(" AND annotations_enabled = ?", *query.AnnotationsEnabled)
+	}
+	if query.TimeSelectionEnabled != nil {
+		builder.Write(" AND time_selection_enabled = ?", *query.TimeSelectionEnabled)
+	}
+	if query.VariablesEnabled != nil {
+		if *query.VariablesEnabled {
+			builder.Write(" AND (variables_enabled IS NULL OR variables_enabled = ?)", true)
+		} else {
+			builder.Write(" AND variables_enabled = ?", false)
+		}
+	}
+	if query.Share != "" {
+		builder.Write(" AND share = ?", query.Share)
+	}
+}
+
 // Find Returns public dashboard by Uid or nil if not found
 func (d *PublicDashboardStoreImpl) Find(ctx context.Context, uid string) (*PublicDashboard, error) {
 	if uid == "" {
@@ -104,17 +138,51 @@ func (d *PublicDashboardStoreImpl) Find(ctx context.Context, uid string) (*Publi
 	return publicDashboard, nil
 }
 
-// FindByAccessToken Returns public dashboard by access token or nil if not found
+// accessTokenCondition matches the public dashboard with the access token, or with the previous access token until
+// its grace period ends. Its args are given by accessTokenArgs
+const accessTokenCondition = "(access_token = ? OR (previous_access_token = ? AND previous_access_token_expires_at > ?))"
+
+func accessTokenArgs(accessToken string) []any {
+	return []any{accessToken, accessToken, time.Now().UTC()}
+}
+
+// FindByAccessToken Returns public dashboard by access token or nil if not found. Previous access tokens are found
+// until their grace period ends
 func (d *PublicDashboardStoreImpl) FindByAccessToken(ctx context.Context, accessToken string) (*PublicDashboard, error) {
 	if accessToken == "" {
 		return nil, nil
 	}
 
 	var found bool
-	publicDashboard := &PublicDashboard{AccessToken: accessToken}
+	publicDashboard := &PublicDashboard{}
 	err := d.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
 		var err error
-		found, err = sess.Get(publicDashboard)
+		found, err = sess.Where(accessTokenCondition, accessTokenArgs(accessToken)...).Get(publicDashboard)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	return publicDashboard, nil
+}
+
+// FindBySlug Returns the public dashboard with the slug or nil if not found. Slugs are unique across orgs
+func (d *PublicDashboardStoreImpl) FindBySlug(ctx context.Context, slug string) (*PublicDashboard, error) {
+	if slug == "" {
+		return nil, nil
+	}
+
+	var found bool
+	publicDashboard := &PublicDashboard{}
+	err := d.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		var err error
+		found, err = sess.Where("slug = ?", slug).Get(publicDashboard)
 		return err
 	})
 
@@ -175,13 +243,14 @@ func (d *PublicDashboardStoreImpl) ExistsEnabledByDashboardUid(ctx context.Conte
 	return hasPublicDashboard, err
 }
 
-// ExistsEnabledByAccessToken Responds true if the accessToken exists and the public dashboard is enabled
+// ExistsEnabledByAccessToken Responds true if the accessToken exists and the public dashboard is enabled and not
+// expired
 func (d *PublicDashboardStoreImpl) ExistsEnabledByAccessToken(ctx context.Context, accessToken string) (bool, error) {
 	hasPublicDashboard := false
 	err := d.sqlStore.WithDbSession(ctx, func(dbSession *db.Session) error {
-		sql := "SELECT COUNT(*) FROM dashboard_public WHERE access_token=? AND is_enabled=true"
+		sql := "SELECT COUNT(*) FROM dashboard_public WHERE " + accessTokenCondition + " AND is_enabled=true AND (expires_at IS NULL OR expires_at > ?)"
 
-		result, err := dbSession.SQL(sql, accessToken).Count()
+		result, err := dbSession.SQL(sql, append(accessTokenArgs(accessToken), time.Now().UTC())...).Count()
 		if err != nil {
 			return err
 		}
@@ -197,9 +266,9 @@ func (d *PublicDashboardStoreImpl) ExistsEnabledByAccessToken(ctx context.Contex
 func (d *PublicDashboardStoreImpl) GetOrgIdByAccessToken(ctx context.Context, accessToken string) (int64, error) {
 	var orgId int64
 	err := d.sqlStore.WithDbSession(ctx, func(dbSession *db.Session) error {
-		sql := "SELECT org_id FROM dashboard_public WHERE access_token=?"
+		sql := "SELECT org_id FROM dashboard_public WHERE " + accessTokenCondition
 
-		_, err := dbSession.SQL(sql, accessToken).Get(&orgId)
+		_, err := dbSession.SQL(sql, accessTokenArgs(accessToken)...).Get(&orgId)
 		if err != nil {
 			return err
 		}
@@ -217,9 +286,18 @@ func (d *PublicDashboardStoreImpl) Create(ctx context.Context, cmd SavePublicDas
 	}
 
 	var affectedRows int64
-	err := d.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+	err := d.sqlStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		sess.UseBool("is_enabled")
+		// public dashboards without a slug leave it NULL, the unique index on slugs allows several NULLs
+		if cmd.PublicDashboard.Slug == "" {
+			sess.Omit("slug")
+		}
+
 		var err error
-		affectedRows, err = sess.UseBool("is_enabled").Insert(&cmd.PublicDashboard)
+		affectedRows, err = sess.Insert(&cmd.PublicDashboard)
+		if err != nil && cmd.PublicDashboard.Slug != "" && d.sqlStore.GetDialect().IsUniqueConstraintViolation(err) {
+			return ErrPublicDashboardSlugExists.Errorf("Create: slug %s already exists", cmd.PublicDashboard.Slug)
+		}
 		return err
 	})
 
@@ -228,43 +306,450 @@ func (d *PublicDashboardStoreImpl) Create(ctx context.Context, cmd SavePublicDas
 
 // Updates existing public dashboard
 func (d *PublicDashboardStoreImpl) Update(ctx context.Context, cmd SavePublicDashboardCommand) (int64, error) {
+	var affectedRows int64
+	err := d.sqlStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		var err error
+		affectedRows, err = d.updateColumns(sess, "Update", configColumns, &cmd.PublicDashboard)
+		return err
+	})
+
+	return affectedRows, err
+}
+
+// Patch updates the given config columns of an existing public dashboard, leaving the others untouched
+func (d *PublicDashboardStoreImpl) Patch(ctx context.Context, cmd PatchPublicDashboardCommand) (int64, error) {
+	for _, column := range cmd.Columns {
+		if !slices.Contains(configColumns, column) {
+			return 0, fmt.Errorf("Patch: unknown column %s", column)
+		}
+	}
+
+	var affectedRows int64
+	err := d.sqlStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		var err error
+		affectedRows, err = d.updateColumns(sess, "Patch", cmd.Columns, &cmd.PublicDashboard)
+		return err
+	})
+
+	return affectedRows, err
+}
+
+// configColumns are the columns of the config of a public dashboard, written by Update
+var configColumns = []string{
+	"is_enabled",
+	"annotations_enabled",
+	"time_selection_enabled",
+	"share",
+	"query_caching_mode",
+	"export_locale",
+	"geo_restriction",
+	"annotation_settings",
+	"allowed_domains",
+	"variable_constraints",
+	"variables_enabled",
+	"variable_overrides_allowed",
+	"pinned_variables",
+	"variable_defaults",
+	"variable_snapshot",
+	"slug",
+	"expires_at",
+	"panel_id",
+	"max_concurrent_viewers",
+	"hidden_panel_ids",
+	"time_settings",
+}
+
+// updateColumns writes the columns of the public dashboard along with who updated it and when. Slugs used by another
+// public dashboard are rejected by the unique index on slugs
+func (d *PublicDashboardStoreImpl) updateColumns(sess *db.Session, caller string, columns []string, pubdash *PublicDashboard) (int64, error) {
+	values, err := configColumnValues(pubdash)
+	if err != nil {
+		return 0, err
+	}
+
+	assignments := make([]string, 0, len(columns)+2)
+	args := make([]any, 0, len(columns)+4)
+	for _, column := range columns {
+		assignments = append(assignments, column+" = ?")
+		args = append(args, values[column])
+	}
+	assignments = append(assignments, "updated_by = ?", "updated_at = ?")
+	args = append(args, pubdash.UpdatedBy, pubdash.UpdatedAt.UTC(), pubdash.Uid)
+
+	sqlResult, err := sess.Exec(append([]any{"UPDATE dashboard_public SET " + strings.Join(assignments, ", ") + " WHERE uid = ?"}, args...)...)
+	if err != nil {
+		if slices.Contains(columns, "slug") && pubdash.Slug != "" && d.sqlStore.GetDialect().IsUniqueConstraintViolation(err) {
+			return 0, ErrPublicDashboardSlugExists.Errorf("%s: slug %s already exists", caller, pubdash.Slug)
+		}
+		return 0, err
+	}
+
+	return sqlResult.RowsAffected()
+}
+
+// configColumnValues returns the values of the config columns of the public dashboard, JSON columns are encoded and
+// unset values are NULL
+func configColumnValues(pubdash *PublicDashboard) (map[string]any, error) {
+	timeSettingsJSON, err := json.Marshal(pubdash.TimeSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]any{
+		"is_enabled":             pubdash.IsEnabled,
+		"annotations_enabled":    pubdash.AnnotationsEnabled,
+		"time_selection_enabled": pubdash.TimeSelectionEnabled,
+		"variables_enabled":      pubdash.AllowsVariables(),
+		"share":                  pubdash.Share,
+		"query_caching_mode":     pubdash.QueryCachingMode,
+		"export_locale":          pubdash.ExportLocale,
+		"time_settings":          string(timeSettingsJSON),
+		"slug":                   nil,
+		"panel_id":               pubdash.PanelId,
+		"max_concurrent_viewers": pubdash.MaxConcurrentViewers,
+	}
+	if pubdash.Slug != "" {
+		values["slug"] = pubdash.Slug
+	}
+	values["expires_at"] = nil
+	if !pubdash.ExpiresAt.IsZero() {
+		values["expires_at"] = pubdash.ExpiresAt.UTC()
+	}
+
+	columns := []struct {
+		name  string
+		set   bool
+		value any
+	}{
+		{"geo_restriction", pubdash.GeoRestriction != nil, pubdash.GeoRestriction},
+		{"annotation_settings", pubdash.AnnotationSettings != nil, pubdash.AnnotationSettings},
+		{"allowed_domains", pubdash.AllowedDomains != nil, pubdash.AllowedDomains},
+		{"variable_constraints", pubdash.VariableConstraints != nil, pubdash.VariableConstraints},
+		{"variable_overrides_allowed", pubdash.VariableOverridesAllowed != nil, pubdash.VariableOverridesAllowed},
+		{"pinned_variables", pubdash.PinnedVariables != nil, pubdash.PinnedVariables},
+		{"variable_defaults", pubdash.VariableDefaults != nil, pubdash.VariableDefaults},
+		{"variable_snapshot", pubdash.VariableSnapshot != nil, pubdash.VariableSnapshot},
+		{"hidden_panel_ids", pubdash.HiddenPanelIds != nil, pubdash.HiddenPanelIds},
+	}
+	for _, column := range columns {
+		values[column.name] = nil
+		if !column.set {
+			continue
+		}
+
+		valueJSON, err := json.Marshal(column.value)
+		if err != nil {
+			return nil, err
+		}
+		values[column.name] = string(valueJSON)
+	}
+
+	return values, nil
+}
+
+// RotateAccessToken replaces the access token of a public dashboard, only if it wasn't changed in the meantime
+func (d *PublicDashboardStoreImpl) RotateAccessToken(ctx context.Context, cmd RotateAccessTokenCommand) (int64, error) {
 	var affectedRows int64
 	err := d.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
-		timeSettingsJSON, err := json.Marshal(cmd.PublicDashboard.TimeSettings)
+		var previousAccessToken, previousAccessTokenExpiresAt any
+		if !cmd.PreviousAccessTokenExpiresAt.IsZero() {
+			previousAccessToken = cmd.AccessToken
+			previousAccessTokenExpiresAt = cmd.PreviousAccessTokenExpiresAt.UTC()
+		}
+
+		sqlResult, err := sess.Exec("UPDATE dashboard_public SET access_token = ?, previous_access_token = ?, previous_access_token_expires_at = ?, access_token_created_at = ?, access_token_rotation_due = ?, updated_by = ?, updated_at = ? WHERE uid = ? AND access_token = ?",
+			cmd.NewAccessToken,
+			previousAccessToken,
+			previousAccessTokenExpiresAt,
+			cmd.UpdatedAt.UTC(),
+			false,
+			cmd.UpdatedBy,
+			cmd.UpdatedAt.UTC(),
+			cmd.Uid,
+			cmd.AccessToken)
+		if err != nil {
+			return err
+		}
+
+		affectedRows, err = sqlResult.RowsAffected()
+		return err
+	})
+
+	return affectedRows, err
+}
+
+// UpdateLastAccessedAt records when a public dashboard was last accessed
+func (d *PublicDashboardStoreImpl) UpdateLastAccessedAt(ctx context.Context, uid string, lastAccessedAt time.Time) error {
+	return d.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Exec("UPDATE dashboard_public SET last_accessed_at = ? WHERE uid = ?", lastAccessedAt.UTC(), uid)
+		return err
+	})
+}
+
+// FindEnabledInactiveSince Returns the enabled public dashboards that haven't been created, updated or accessed since
+// the given time
+func (d *PublicDashboardStoreImpl) FindEnabledInactiveSince(ctx context.Context, since time.Time) ([]*PublicDashboard, error) {
+	pubdashes := make([]*PublicDashboard, 0)
+	err := d.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		since := since.UTC()
+		return sess.Where("is_enabled = ? AND created_at < ? AND updated_at < ? AND (last_accessed_at IS NULL OR last_accessed_at < ?)", true, since, since, since).
+			Find(&pubdashes)
+	})
+
+	return pubdashes, err
+}
+
+// FindEnabled Returns all enabled public dashboards
+func (d *PublicDashboardStoreImpl) FindEnabled(ctx context.Context) ([]*PublicDashboard, error) {
+	pubdashes := make([]*PublicDashboard, 0)
+	err := d.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Where("is_enabled = ?", true).Find(&pubdashes)
+	})
+
+	return pubdashes, err
+}
+
+// FindAllAcrossOrgs Returns the public dashboards of every org
+func (d *PublicDashboardStoreImpl) FindAllAcrossOrgs(ctx context.Context) ([]*PublicDashboard, error) {
+	pubdashes := make([]*PublicDashboard, 0)
+	err := d.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.OrderBy("org_id, uid").Find(&pubdashes)
+	})
+
+	return pubdashes, err
+}
+
+// FindAllAcrossOrgsWithPagination Returns a page of the public dashboards of every org with the name of their org and
+// their usage, sorted by org then uid. The titles of the dashboards aren't stored with public dashboards, so they're
+// left empty
+func (d *PublicDashboardStoreImpl) FindAllAcrossOrgsWithPagination(ctx context.Context, query *AdminPublicDashboardListQuery) (*AdminPublicDashboardListResponseWithPagination, error) {
+	resp := &AdminPublicDashboardListResponseWithPagination{
+		PublicDashboards: make([]*AdminPublicDashboardListResponse, 0),
+	}
+
+	recursiveQueriesAreSupported, err := d.sqlStore.RecursiveQueriesAreSupported()
+	if err != nil {
+		return nil, err
+	}
+
+	writeFilters := func(builder *db.SQLBuilder) {
+		builder.Write(" WHERE 1 = 1")
+		if query.OrgID != 0 {
+			builder.Write(" AND dashboard_public.org_id = ?", query.OrgID)
+		}
+		if query.IsEnabled != nil {
+			builder.Write(" AND dashboard_public.is_enabled = ?", *query.IsEnabled)
+		}
+	}
+
+	pubdashBuilder := db.NewSqlBuilder(d.cfg, d.features, d.sqlStore.GetDialect(), recursiveQueriesAreSupported)
+	pubdashBuilder.Write("SELECT dashboard_public.uid, dashboard_public.org_id, org.name AS org_name, dashboard_public.dashboard_uid,")
+	pubdashBuilder.Write(" dashboard_public.is_enabled, dashboard_public.share, dashboard_public.created_by, dashboard_public.created_at,")
+	pubdashBuilder.Write(" dashboard_public.last_accessed_at AS last_viewed_at, COALESCE(dashboard_public_usage.views, 0) AS views")
+	pubdashBuilder.Write(" FROM dashboard_public")
+	pubdashBuilder.Write(" LEFT JOIN org ON org.id = dashboard_public.org_id")
+	pubdashBuilder.Write(" LEFT JOIN dashboard_public_usage ON dashboard_public_usage.public_dashboard_uid = dashboard_public.uid")
+	writeFilters(&pubdashBuilder)
+	pubdashBuilder.Write(" ORDER BY dashboard_public.org_id, dashboard_public.uid")
+	pubdashBuilder.Write(d.sqlStore.GetDialect().LimitOffset(int64(query.Limit), int64(query.Offset)))
+
+	counterBuilder := db.NewSqlBuilder(d.cfg, d.features, d.sqlStore.GetDialect(), recursiveQueriesAreSupported)
+	counterBuilder.Write("SELECT COUNT(*) FROM dashboard_public")
+	writeFilters(&counterBuilder)
+
+	err = d.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		err := sess.SQL(pubdashBuilder.GetSQLString(), pubdashBuilder.GetParams()...).Find(&resp.PublicDashboards)
 		if err != nil {
 			return err
 		}
 
-		sqlResult, err := sess.Exec("UPDATE dashboard_public SET is_enabled = ?, annotations_enabled = ?, time_selection_enabled = ?, share = ?, time_settings = ?, updated_by = ?, updated_at = ? WHERE uid = ?",
-			cmd.PublicDashboard.IsEnabled,
-			cmd.PublicDashboard.AnnotationsEnabled,
-			cmd.PublicDashboard.TimeSelectionEnabled,
-			cmd.PublicDashboard.Share,
-			string(timeSettingsJSON),
-			cmd.PublicDashboard.UpdatedBy,
-			cmd.PublicDashboard.UpdatedAt.UTC(),
-			cmd.PublicDashboard.Uid)
+		_, err = sess.SQL(counterBuilder.GetSQLString(), counterBuilder.GetParams()...).Get(&resp.TotalCount)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// FindEnabledExpiredBefore Returns the enabled public dashboards that expired before the given time
+func (d *PublicDashboardStoreImpl) FindEnabledExpiredBefore(ctx context.Context, before time.Time) ([]*PublicDashboard, error) {
+	pubdashes := make([]*PublicDashboard, 0)
+	err := d.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Where("is_enabled = ? AND expires_at IS NOT NULL AND expires_at <= ?", true, before.UTC()).
+			Find(&pubdashes)
+	})
+
+	return pubdashes, err
+}
+
+// FindEnabledWithAccessTokenCreatedBefore Returns the enabled public dashboards whose access token was created or
+// rotated before the given time
+func (d *PublicDashboardStoreImpl) FindEnabledWithAccessTokenCreatedBefore(ctx context.Context, before time.Time) ([]*PublicDashboard, error) {
+	pubdashes := make([]*PublicDashboard, 0)
+	err := d.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		before := before.UTC()
+		return sess.Where("is_enabled = ? AND (access_token_created_at < ? OR (access_token_created_at IS NULL AND created_at < ?))", true, before, before).
+			Find(&pubdashes)
+	})
+
+	return pubdashes, err
+}
 
+// FlagAccessTokenRotationDue flags the access token of a public dashboard as due for rotation. It returns 0 when the
+// access token was already flagged or changed
+func (d *PublicDashboardStoreImpl) FlagAccessTokenRotationDue(ctx context.Context, uid string, accessToken string) (int64, error) {
+	var affectedRows int64
+	err := d.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		sqlResult, err := sess.Exec("UPDATE dashboard_public SET access_token_rotation_due = ? WHERE uid = ? AND access_token = ? AND access_token_rotation_due = ?", true, uid, accessToken, false)
 		if err != nil {
 			return err
 		}
 
 		affectedRows, err = sqlResult.RowsAffected()
+		return err
+	})
+
+	return affectedRows, err
+}
 
+// InsertAuditLogEntry records a view or a query of a public dashboard
+func (d *PublicDashboardStoreImpl) InsertAuditLogEntry(ctx context.Context, entry *AuditLogEntry) error {
+	return d.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Insert(entry)
+		return err
+	})
+}
+
+// FindAuditLog Returns a page of the audit log of a public dashboard, newest entries first
+func (d *PublicDashboardStoreImpl) FindAuditLog(ctx context.Context, query *AuditLogQuery) (*AuditLogResponseWithPagination, error) {
+	resp := &AuditLogResponseWithPagination{
+		Entries: make([]*AuditLogEntry, 0),
+		Page:    query.Page,
+		PerPage: query.Limit,
+	}
+
+	err := d.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		var err error
+		resp.TotalCount, err = sess.Where("org_id = ? AND public_dashboard_uid = ?", query.OrgId, query.PublicDashboardUid).
+			Count(&AuditLogEntry{})
+		if err != nil {
+			return err
+		}
+
+		return sess.Where("org_id = ? AND public_dashboard_uid = ?", query.OrgId, query.PublicDashboardUid).
+			Desc("created_at", "id").
+			Limit(query.Limit, query.Offset).
+			Find(&resp.Entries)
+	})
+
+	return resp, err
+}
+
+// DeleteAuditLogBefore deletes the entries of the audit log recorded before the given time
+func (d *PublicDashboardStoreImpl) DeleteAuditLogBefore(ctx context.Context, before time.Time) (int64, error) {
+	var affectedRows int64
+	err := d.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		sqlResult, err := sess.Exec("DELETE FROM dashboard_public_audit_log WHERE created_at < ?", before.UTC())
+		if err != nil {
+			return err
+		}
+
+		affectedRows, err = sqlResult.RowsAffected()
 		return err
 	})
 
 	return affectedRows, err
 }
 
-// Delete deletes a public dashboard
+// AddUsage adds views and queries to the usage of public dashboards, in a single transaction. The last view is the
+// last access of the public dashboard, it isn't written with the usage
+func (d *PublicDashboardStoreImpl) AddUsage(ctx context.Context, usage []PublicDashboardUsage) error {
+	if len(usage) == 0 {
+		return nil
+	}
+
+	return d.sqlStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		for _, u := range usage {
+			sql := strings.Builder{}
+			sql.WriteString("UPDATE dashboard_public_usage SET views = views + ?, queries = queries + ?")
+			args := []any{u.Views, u.Queries}
+			if !u.LastQueriedAt.IsZero() {
+				sql.WriteString(", last_queried_at = ?")
+				args = append(args, u.LastQueriedAt.UTC())
+			}
+			sql.WriteString(" WHERE public_dashboard_uid = ?")
+			args = append(args, u.PublicDashboardUid)
+
+			sqlResult, err := sess.Exec(append([]any{sql.String()}, args...)...)
+			if err != nil {
+				return err
+			}
+			affectedRows, err := sqlResult.RowsAffected()
+			if err != nil {
+				return err
+			}
+			if affectedRows > 0 {
+				continue
+			}
+
+			// first usage of the public dashboard
+			_, err = sess.Exec("INSERT INTO dashboard_public_usage (org_id, public_dashboard_uid, views, queries, last_queried_at) VALUES (?, ?, ?, ?, ?)",
+				u.OrgId, u.PublicDashboardUid, u.Views, u.Queries, nullableTime(u.LastQueriedAt))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FindUsage Returns the usage of a public dashboard, nil when it was never viewed or queried. The last view is the
+// last access of the public dashboard
+func (d *PublicDashboardStoreImpl) FindUsage(ctx context.Context, uid string) (*PublicDashboardUsage, error) {
+	var found bool
+	usage := &PublicDashboardUsage{}
+	err := d.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		var err error
+		found, err = sess.SQL("SELECT dashboard_public_usage.*, dashboard_public.last_accessed_at AS last_viewed_at"+
+			" FROM dashboard_public_usage"+
+			" LEFT JOIN dashboard_public ON dashboard_public.uid = dashboard_public_usage.public_dashboard_uid"+
+			" WHERE dashboard_public_usage.public_dashboard_uid = ?", uid).Get(usage)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	return usage, nil
+}
+
+func nullableTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC()
+}
+
+// Delete deletes a public dashboard and its usage
 func (d *PublicDashboardStoreImpl) Delete(ctx context.Context, uid string) (int64, error) {
 	dashboard := &PublicDashboard{Uid: uid}
 	var affectedRows int64
 	err := d.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
 		var err error
 		affectedRows, err = sess.Delete(dashboard)
+		if err != nil {
+			return err
+		}
 
+		_, err = sess.Exec("DELETE FROM dashboard_public_usage WHERE public_dashboard_uid = ?", uid)
 		return err
 	})
 
@@ -278,23 +763,75 @@ func (d *PublicDashboardStoreImpl) DeleteByDashboardUIDs(ctx context.Context, or
 	}
 
 	return d.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
-		s := strings.Builder{}
-		s.WriteString("DELETE FROM dashboard_public WHERE org_id = ? AND ")
-		s.WriteString(fmt.Sprintf("dashboard_uid IN (%s)", strings.Repeat("?,", len(dashboardUIDs)-1)+"?"))
-		sql := s.String()
-		args := make([]any, 0, len(dashboardUIDs)+2)
-		args = append(args, sql)
-		args = append(args, orgId)
+		condition := fmt.Sprintf("org_id = ? AND dashboard_uid IN (%s)", strings.Repeat("?,", len(dashboardUIDs)-1)+"?")
+		params := make([]any, 0, len(dashboardUIDs)+1)
+		params = append(params, orgId)
 		for _, dashboardUID := range dashboardUIDs {
-			args = append(args, dashboardUID)
+			params = append(params, dashboardUID)
+		}
+
+		// the usage goes first, it's found through the public dashboards
+		_, err := sess.Exec(append([]any{"DELETE FROM dashboard_public_usage WHERE public_dashboard_uid IN (SELECT uid FROM dashboard_public WHERE " + condition + ")"}, params...)...)
+		if err != nil {
+			return err
 		}
 
-		_, err := sess.Exec(args...)
+		_, err = sess.Exec(append([]any{"DELETE FROM dashboard_public WHERE " + condition}, params...)...)
 
 		return err
 	})
 }
 
+// Count returns the number of public dashboards for the quota service, in total and, when set in the scope
+// parameters, of the org and created by the user
+func (d *PublicDashboardStoreImpl) Count(ctx context.Context, scopeParams *quota.ScopeParameters) (*quota.Map, error) {
+	u := &quota.Map{}
+	err := d.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		total, err := sess.Table("dashboard_public").Count()
+		if err != nil {
+			return err
+		}
+		if err := setQuotaUsage(u, quota.GlobalScope, total); err != nil {
+			return err
+		}
+
+		if scopeParams != nil && scopeParams.OrgID != 0 {
+			orgTotal, err := sess.Table("dashboard_public").Where("org_id = ?", scopeParams.OrgID).Count()
+			if err != nil {
+				return err
+			}
+			if err := setQuotaUsage(u, quota.OrgScope, orgTotal); err != nil {
+				return err
+			}
+		}
+
+		if scopeParams != nil && scopeParams.UserID != 0 {
+			userTotal, err := sess.Table("dashboard_public").Where("created_by = ?", scopeParams.UserID).Count()
+			if err != nil {
+				return err
+			}
+			if err := setQuotaUsage(u, quota.UserScope, userTotal); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+func setQuotaUsage(u *quota.Map, scope quota.Scope, used int64) error {
+	tag, err := quota.NewTag(QuotaTargetSrv, QuotaTarget, scope)
+	if err != nil {
+		return err
+	}
+	u.Set(tag, used)
+	return nil
+}
+
 func (d *PublicDashboardStoreImpl) GetMetrics(ctx context.Context) (*Metrics, error) {
 	metrics := &Metrics{
 		TotalPublicDashboards: []*TotalPublicDashboard{},
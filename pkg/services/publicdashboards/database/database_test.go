@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"testing"
 	"time"
@@ -19,6 +20,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
 	"github.com/grafana/grafana/pkg/services/publicdashboards/service"
+	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/tag/tagimpl"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
@@ -112,6 +114,65 @@ func TestIntegrationListPublicDashboard(t *testing.T) {
 		assert.Contains(t, uids, cPublicDash.Uid)
 		assert.Equal(t, resp.TotalCount, int64(3))
 	})
+
+	t.Run("FindAll filters by the config of public dashboards", func(t *testing.T) {
+		setup()
+
+		usr := &user.SignedInUser{UserID: 1, OrgID: orgId}
+		enabled := true
+		query := &PublicDashboardListQuery{
+			User:      usr,
+			OrgID:     orgId,
+			Page:      1,
+			Limit:     50,
+			IsEnabled: &enabled,
+		}
+		resp, err := publicdashboardStore.FindAll(context.Background(), query)
+		require.NoError(t, err)
+
+		uids := make([]string, len(resp.PublicDashboards))
+		for i, pubdash := range resp.PublicDashboards {
+			uids[i] = pubdash.Uid
+		}
+		assert.ElementsMatch(t, []string{bPublicDash.Uid, cPublicDash.Uid}, uids)
+		assert.Equal(t, int64(2), resp.TotalCount)
+	})
+
+	t.Run("FindAll filters by annotations, variables and share type", func(t *testing.T) {
+		setup()
+
+		_, err := publicdashboardStore.Patch(context.Background(), PatchPublicDashboardCommand{
+			PublicDashboard: PublicDashboard{Uid: bPublicDash.Uid, OrgId: orgId, AnnotationsEnabled: true, VariablesEnabled: util.Pointer(false), Share: EmailShareType, UpdatedAt: time.Now()},
+			Columns:         []string{"annotations_enabled", "variables_enabled", "share"},
+		})
+		require.NoError(t, err)
+		// like public dashboards saved before variables could be disabled
+		err = sqlStore.WithDbSession(context.Background(), func(sess *db.Session) error {
+			_, err := sess.Exec("UPDATE dashboard_public SET variables_enabled = NULL WHERE uid = ?", aPublicDash.Uid)
+			return err
+		})
+		require.NoError(t, err)
+
+		findUids := func(query *PublicDashboardListQuery) []string {
+			query.OrgID = orgId
+			query.Limit = 50
+			resp, err := publicdashboardStore.FindAll(context.Background(), query)
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(resp.PublicDashboards)), resp.TotalCount)
+
+			uids := make([]string, len(resp.PublicDashboards))
+			for i, pubdash := range resp.PublicDashboards {
+				uids[i] = pubdash.Uid
+			}
+			return uids
+		}
+
+		assert.ElementsMatch(t, []string{bPublicDash.Uid}, findUids(&PublicDashboardListQuery{AnnotationsEnabled: util.Pointer(true)}))
+		assert.ElementsMatch(t, []string{bPublicDash.Uid}, findUids(&PublicDashboardListQuery{VariablesEnabled: util.Pointer(false)}))
+		assert.ElementsMatch(t, []string{aPublicDash.Uid, cPublicDash.Uid}, findUids(&PublicDashboardListQuery{VariablesEnabled: util.Pointer(true)}))
+		assert.ElementsMatch(t, []string{aPublicDash.Uid, cPublicDash.Uid}, findUids(&PublicDashboardListQuery{Share: PublicShareType}))
+		assert.Empty(t, findUids(&PublicDashboardListQuery{Share: EmailShareType, AnnotationsEnabled: util.Pointer(false)}))
+	})
 }
 
 func TestIntegrationExistsEnabledByAccessToken(t *testing.T) {
@@ -381,6 +442,97 @@ func TestIntegrationFindByAccessToken(t *testing.T) {
 	})
 }
 
+func TestIntegrationSlug(t *testing.T) {
+	testutil.SkipIntegrationTestInShortMode(t)
+
+	var sqlStore db.DB
+	var cfg *setting.Cfg
+	var dashboardStore dashboards.Store
+	var publicdashboardStore *PublicDashboardStoreImpl
+	var err error
+
+	setup := func() {
+		sqlStore, cfg = db.InitTestDBWithCfg(t)
+		dashboardStore, err = dashboardsDB.ProvideDashboardStore(sqlStore, cfg, featuremgmt.WithFeatures(), tagimpl.ProvideService(sqlStore))
+		require.NoError(t, err)
+		publicdashboardStore = ProvideStore(sqlStore, cfg, featuremgmt.WithFeatures())
+	}
+
+	setSlug := func(t *testing.T, pubdash *PublicDashboard, slug string) error {
+		pubdash.Slug = slug
+		_, err := publicdashboardStore.Update(context.Background(), SavePublicDashboardCommand{PublicDashboard: *pubdash})
+		return err
+	}
+
+	t.Run("finds the public dashboard of a slug", func(t *testing.T) {
+		setup()
+		dashboard := insertTestDashboard(t, dashboardStore, "testDashie", 1, "", true)
+		pubdash := insertPublicDashboard(t, publicdashboardStore, dashboard.UID, dashboard.OrgID, true, PublicShareType)
+		require.NoError(t, setSlug(t, pubdash, "status-page"))
+
+		found, err := publicdashboardStore.FindBySlug(context.Background(), "status-page")
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, pubdash.Uid, found.Uid)
+	})
+
+	t.Run("rejects slugs already used in another org", func(t *testing.T) {
+		setup()
+		dashboard1 := insertTestDashboard(t, dashboardStore, "testDashie", 1, "", true)
+		dashboard2 := insertTestDashboard(t, dashboardStore, "testDashie", 2, "", true)
+		pubdash1 := insertPublicDashboard(t, publicdashboardStore, dashboard1.UID, dashboard1.OrgID, true, PublicShareType)
+		pubdash2 := insertPublicDashboard(t, publicdashboardStore, dashboard2.UID, dashboard2.OrgID, true, PublicShareType)
+		require.NoError(t, setSlug(t, pubdash1, "status-page"))
+
+		assert.ErrorIs(t, setSlug(t, pubdash2, "status-page"), ErrPublicDashboardSlugExists)
+	})
+
+	t.Run("rejects slugs already used when creating a public dashboard", func(t *testing.T) {
+		setup()
+		dashboard1 := insertTestDashboard(t, dashboardStore, "testDashie", 1, "", true)
+		dashboard2 := insertTestDashboard(t, dashboardStore, "testDashie2", 1, "", true)
+		pubdash1 := insertPublicDashboard(t, publicdashboardStore, dashboard1.UID, dashboard1.OrgID, true, PublicShareType)
+		require.NoError(t, setSlug(t, pubdash1, "status-page"))
+
+		_, err := publicdashboardStore.Create(context.Background(), SavePublicDashboardCommand{PublicDashboard: PublicDashboard{
+			Uid:          "pubdash2",
+			DashboardUid: dashboard2.UID,
+			OrgId:        dashboard2.OrgID,
+			AccessToken:  "8f1b2c3d4e5f60718293a4b5c6d7e8f9",
+			Slug:         "status-page",
+			TimeSettings: &TimeSettings{},
+			Share:        PublicShareType,
+			CreatedAt:    time.Now(),
+		}})
+		assert.ErrorIs(t, err, ErrPublicDashboardSlugExists)
+	})
+
+	t.Run("rejects slugs already used in the org", func(t *testing.T) {
+		setup()
+		dashboard1 := insertTestDashboard(t, dashboardStore, "testDashie", 1, "", true)
+		dashboard2 := insertTestDashboard(t, dashboardStore, "testDashie2", 1, "", true)
+		pubdash1 := insertPublicDashboard(t, publicdashboardStore, dashboard1.UID, dashboard1.OrgID, true, PublicShareType)
+		pubdash2 := insertPublicDashboard(t, publicdashboardStore, dashboard2.UID, dashboard2.OrgID, true, PublicShareType)
+		require.NoError(t, setSlug(t, pubdash1, "status-page"))
+
+		assert.ErrorIs(t, setSlug(t, pubdash2, "status-page"), ErrPublicDashboardSlugExists)
+		// saving the public dashboard that uses the slug again is fine
+		assert.NoError(t, setSlug(t, pubdash1, "status-page"))
+	})
+
+	t.Run("removes the slug when empty", func(t *testing.T) {
+		setup()
+		dashboard := insertTestDashboard(t, dashboardStore, "testDashie", 1, "", true)
+		pubdash := insertPublicDashboard(t, publicdashboardStore, dashboard.UID, dashboard.OrgID, true, PublicShareType)
+		require.NoError(t, setSlug(t, pubdash, "status-page"))
+		require.NoError(t, setSlug(t, pubdash, ""))
+
+		found, err := publicdashboardStore.FindBySlug(context.Background(), "status-page")
+		require.NoError(t, err)
+		assert.Nil(t, found)
+	})
+}
+
 func TestIntegrationCreatePublicDashboard(t *testing.T) {
 	testutil.SkipIntegrationTestInShortMode(t)
 
@@ -521,16 +673,29 @@ func TestIntegrationUpdatePublicDashboard(t *testing.T) {
 		assert.EqualValues(t, affectedRows, 1)
 
 		updatedPublicDashboard := PublicDashboard{
-			Uid:                  pdUid,
-			DashboardUid:         savedDashboard.UID,
-			OrgId:                savedDashboard.OrgID,
-			IsEnabled:            false,
-			AnnotationsEnabled:   true,
-			TimeSelectionEnabled: true,
-			Share:                EmailShareType,
-			TimeSettings:         &TimeSettings{From: "now-8", To: "now"},
-			UpdatedAt:            time.Now().UTC().Round(time.Second),
-			UpdatedBy:            8,
+			Uid:                      pdUid,
+			DashboardUid:             savedDashboard.UID,
+			OrgId:                    savedDashboard.OrgID,
+			IsEnabled:                false,
+			AnnotationsEnabled:       true,
+			TimeSelectionEnabled:     true,
+			Share:                    EmailShareType,
+			QueryCachingMode:         QueryCachingModeBypass,
+			ExportLocale:             "de-DE",
+			GeoRestriction:           &GeoRestriction{Mode: GeoRestrictionModeAllow, Countries: []string{"DE", "FR"}},
+			AnnotationSettings:       &AnnotationSettings{HiddenQueries: []string{"Incidents"}, HiddenPanelIds: []int64{3}},
+			VariablesEnabled:         util.Pointer(false),
+			VariableConstraints:      VariableConstraints{"search": {Pattern: "[a-z]+", MaxLength: 32}},
+			VariableOverridesAllowed: []string{"env", "search"},
+			PinnedVariables:          PinnedVariables{"tenant": "acme", "regions": []interface{}{"eu", "us"}},
+			VariableDefaults:         VariableDefaults{"env": "stage"},
+			TimeSettings:             &TimeSettings{From: "now-8", To: "now"},
+			UpdatedAt:                time.Now().UTC().Round(time.Second),
+			UpdatedBy:                8,
+			VariableSnapshot: VariableSnapshot{"env": {
+				Options: []MetricFindValue{{Text: "prod", Value: "prod"}, {Text: "stage", Value: "stage"}},
+				Current: VariableSnapshotValue{Text: "prod", Value: "prod"},
+			}},
 		}
 
 		// update initial record
@@ -550,6 +715,16 @@ func TestIntegrationUpdatePublicDashboard(t *testing.T) {
 		assert.Equal(t, updatedPublicDashboard.AnnotationsEnabled, pdRetrieved.AnnotationsEnabled)
 		assert.Equal(t, updatedPublicDashboard.TimeSelectionEnabled, pdRetrieved.TimeSelectionEnabled)
 		assert.Equal(t, updatedPublicDashboard.Share, pdRetrieved.Share)
+		assert.Equal(t, updatedPublicDashboard.QueryCachingMode, pdRetrieved.QueryCachingMode)
+		assert.Equal(t, updatedPublicDashboard.ExportLocale, pdRetrieved.ExportLocale)
+		assert.Equal(t, updatedPublicDashboard.GeoRestriction, pdRetrieved.GeoRestriction)
+		assert.Equal(t, updatedPublicDashboard.AnnotationSettings, pdRetrieved.AnnotationSettings)
+		assert.Equal(t, updatedPublicDashboard.VariablesEnabled, pdRetrieved.VariablesEnabled)
+		assert.Equal(t, updatedPublicDashboard.VariableConstraints, pdRetrieved.VariableConstraints)
+		assert.Equal(t, updatedPublicDashboard.VariableOverridesAllowed, pdRetrieved.VariableOverridesAllowed)
+		assert.Equal(t, updatedPublicDashboard.PinnedVariables, pdRetrieved.PinnedVariables)
+		assert.Equal(t, updatedPublicDashboard.VariableDefaults, pdRetrieved.VariableDefaults)
+		assert.Equal(t, updatedPublicDashboard.VariableSnapshot, pdRetrieved.VariableSnapshot)
 
 		// not updated dashboard shouldn't have changed
 		pdNotUpdatedRetrieved, err := publicdashboardStore.FindByDashboardUid(context.Background(), anotherSavedDashboard.OrgID, anotherSavedDashboard.UID)
@@ -561,6 +736,71 @@ func TestIntegrationUpdatePublicDashboard(t *testing.T) {
 	})
 }
 
+func TestIntegrationPatchPublicDashboard(t *testing.T) {
+	testutil.SkipIntegrationTestInShortMode(t)
+
+	var sqlStore db.DB
+	var cfg *setting.Cfg
+	var dashboardStore dashboards.Store
+	var publicdashboardStore *PublicDashboardStoreImpl
+	var err error
+
+	setup := func() {
+		sqlStore, cfg = db.InitTestDBWithCfg(t)
+		dashboardStore, err = dashboardsDB.ProvideDashboardStore(sqlStore, cfg, featuremgmt.WithFeatures(), tagimpl.ProvideService(sqlStore))
+		require.NoError(t, err)
+		publicdashboardStore = ProvideStore(sqlStore, cfg, featuremgmt.WithFeatures())
+	}
+
+	t.Run("updates only the columns of the patch", func(t *testing.T) {
+		setup()
+		dashboard := insertTestDashboard(t, dashboardStore, "testDashie", 1, "", true)
+		pubdash := insertPublicDashboard(t, publicdashboardStore, dashboard.UID, dashboard.OrgID, true, PublicShareType)
+
+		// a concurrent writer enabled annotations after the patch was built
+		pubdash.AnnotationsEnabled = true
+		_, err := publicdashboardStore.Update(context.Background(), SavePublicDashboardCommand{PublicDashboard: *pubdash})
+		require.NoError(t, err)
+
+		affectedRows, err := publicdashboardStore.Patch(context.Background(), PatchPublicDashboardCommand{
+			Columns:         []string{"is_enabled"},
+			PublicDashboard: PublicDashboard{Uid: pubdash.Uid, IsEnabled: false, UpdatedBy: 7, UpdatedAt: time.Now()},
+		})
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, affectedRows)
+
+		updated, err := publicdashboardStore.Find(context.Background(), pubdash.Uid)
+		require.NoError(t, err)
+		assert.False(t, updated.IsEnabled)
+		assert.True(t, updated.AnnotationsEnabled)
+		assert.Equal(t, PublicShareType, updated.Share)
+		assert.Equal(t, int64(7), updated.UpdatedBy)
+	})
+
+	t.Run("rejects columns that can't be patched", func(t *testing.T) {
+		setup()
+		dashboard := insertTestDashboard(t, dashboardStore, "testDashie", 1, "", true)
+		pubdash := insertPublicDashboard(t, publicdashboardStore, dashboard.UID, dashboard.OrgID, true, PublicShareType)
+
+		_, err := publicdashboardStore.Patch(context.Background(), PatchPublicDashboardCommand{
+			Columns:         []string{"access_token"},
+			PublicDashboard: PublicDashboard{Uid: pubdash.Uid},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("returns no affected rows when the public dashboard doesn't exist", func(t *testing.T) {
+		setup()
+
+		affectedRows, err := publicdashboardStore.Patch(context.Background(), PatchPublicDashboardCommand{
+			Columns:         []string{"is_enabled"},
+			PublicDashboard: PublicDashboard{Uid: "doesnotexist", UpdatedAt: time.Now()},
+		})
+		require.NoError(t, err)
+		assert.EqualValues(t, 0, affectedRows)
+	})
+}
+
 func TestIntegrationGetOrgIdByAccessToken(t *testing.T) {
 	testutil.SkipIntegrationTestInShortMode(t)
 
@@ -786,6 +1026,317 @@ func TestIntegrationGetMetrics(t *testing.T) {
 	})
 }
 
+func TestIntegrationCount(t *testing.T) {
+	testutil.SkipIntegrationTestInShortMode(t)
+
+	sqlStore, cfg := db.InitTestDBWithCfg(t, db.InitTestDBOpt{})
+	dashboardStore, err := dashboardsDB.ProvideDashboardStore(sqlStore, cfg, featuremgmt.WithFeatures(), tagimpl.ProvideService(sqlStore))
+	require.NoError(t, err)
+	publicdashboardStore := ProvideStore(sqlStore, cfg, featuremgmt.WithFeatures())
+	for i, orgID := range []int64{1, 1, 2} {
+		dashboard := insertTestDashboard(t, dashboardStore, fmt.Sprintf("testDashie%d", i), orgID, "", false)
+		insertPublicDashboard(t, publicdashboardStore, dashboard.UID, dashboard.OrgID, true, PublicShareType)
+	}
+
+	usage := func(u *quota.Map, scope quota.Scope) int64 {
+		tag, err := quota.NewTag(QuotaTargetSrv, QuotaTarget, scope)
+		require.NoError(t, err)
+		used, ok := u.Get(tag)
+		require.True(t, ok)
+		return used
+	}
+
+	t.Run("counts the public dashboards in total", func(t *testing.T) {
+		u, err := publicdashboardStore.Count(context.Background(), nil)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), usage(u, quota.GlobalScope))
+	})
+
+	t.Run("counts the public dashboards of the org and of the user", func(t *testing.T) {
+		u, err := publicdashboardStore.Count(context.Background(), &quota.ScopeParameters{OrgID: 1, UserID: 1})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), usage(u, quota.GlobalScope))
+		assert.Equal(t, int64(2), usage(u, quota.OrgScope))
+		assert.Equal(t, int64(3), usage(u, quota.UserScope))
+
+		u, err = publicdashboardStore.Count(context.Background(), &quota.ScopeParameters{OrgID: 3, UserID: 2})
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), usage(u, quota.OrgScope))
+		assert.Equal(t, int64(0), usage(u, quota.UserScope))
+	})
+}
+
+func TestIntegrationFindAllAcrossOrgsWithPagination(t *testing.T) {
+	testutil.SkipIntegrationTestInShortMode(t)
+
+	sqlStore, cfg := db.InitTestDBWithCfg(t, db.InitTestDBOpt{})
+	dashboardStore, err := dashboardsDB.ProvideDashboardStore(sqlStore, cfg, featuremgmt.WithFeatures(), tagimpl.ProvideService(sqlStore))
+	require.NoError(t, err)
+	publicdashboardStore := ProvideStore(sqlStore, cfg, featuremgmt.WithFeatures())
+
+	dashboard1 := insertTestDashboard(t, dashboardStore, "testDashie1", 1, "", false)
+	dashboard2 := insertTestDashboard(t, dashboardStore, "testDashie2", 1, "", false)
+	dashboard3 := insertTestDashboard(t, dashboardStore, "testDashie3", 2, "", false)
+	pubdash1 := insertPublicDashboard(t, publicdashboardStore, dashboard1.UID, dashboard1.OrgID, true, PublicShareType)
+	insertPublicDashboard(t, publicdashboardStore, dashboard2.UID, dashboard2.OrgID, false, PublicShareType)
+	pubdash3 := insertPublicDashboard(t, publicdashboardStore, dashboard3.UID, dashboard3.OrgID, true, EmailShareType)
+
+	lastViewedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	err = publicdashboardStore.AddUsage(context.Background(), []PublicDashboardUsage{{OrgId: 1, PublicDashboardUid: pubdash1.Uid, Views: 3}})
+	require.NoError(t, err)
+	err = publicdashboardStore.UpdateLastAccessedAt(context.Background(), pubdash1.Uid, lastViewedAt)
+	require.NoError(t, err)
+
+	t.Run("returns the public dashboards of every org sorted by org", func(t *testing.T) {
+		resp, err := publicdashboardStore.FindAllAcrossOrgsWithPagination(context.Background(), &AdminPublicDashboardListQuery{Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, resp.PublicDashboards, 3)
+		assert.Equal(t, int64(3), resp.TotalCount)
+		assert.Equal(t, int64(2), resp.PublicDashboards[2].OrgId)
+		assert.Equal(t, pubdash3.Uid, resp.PublicDashboards[2].Uid)
+		assert.Equal(t, EmailShareType, resp.PublicDashboards[2].Share)
+
+		for _, pubdash := range resp.PublicDashboards {
+			if pubdash.Uid == pubdash1.Uid {
+				assert.Equal(t, int64(3), pubdash.Views)
+				assert.True(t, lastViewedAt.Equal(pubdash.LastViewedAt))
+				assert.NotEmpty(t, pubdash.OrgName)
+			}
+		}
+	})
+
+	t.Run("filters by org and enabled state", func(t *testing.T) {
+		isEnabled := true
+		resp, err := publicdashboardStore.FindAllAcrossOrgsWithPagination(context.Background(), &AdminPublicDashboardListQuery{OrgID: 1, IsEnabled: &isEnabled, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, resp.PublicDashboards, 1)
+		assert.Equal(t, pubdash1.Uid, resp.PublicDashboards[0].Uid)
+		assert.Equal(t, int64(1), resp.TotalCount)
+	})
+
+	t.Run("paginates the public dashboards", func(t *testing.T) {
+		resp, err := publicdashboardStore.FindAllAcrossOrgsWithPagination(context.Background(), &AdminPublicDashboardListQuery{Limit: 2, Offset: 2})
+		require.NoError(t, err)
+		require.Len(t, resp.PublicDashboards, 1)
+		assert.Equal(t, pubdash3.Uid, resp.PublicDashboards[0].Uid)
+		assert.Equal(t, int64(3), resp.TotalCount)
+	})
+}
+
+func TestIntegrationAccessTokenMaxAge(t *testing.T) {
+	testutil.SkipIntegrationTestInShortMode(t)
+
+	sqlStore, cfg := db.InitTestDBWithCfg(t)
+	dashboardStore, err := dashboardsDB.ProvideDashboardStore(sqlStore, cfg, featuremgmt.WithFeatures(), tagimpl.ProvideService(sqlStore))
+	require.NoError(t, err)
+	publicdashboardStore := ProvideStore(sqlStore, cfg, featuremgmt.WithFeatures())
+	ctx := context.Background()
+
+	dashboard := insertTestDashboard(t, dashboardStore, "testDashie", 1, "", true)
+	pubdash := insertPublicDashboard(t, publicdashboardStore, dashboard.UID, dashboard.OrgID, true, PublicShareType)
+	now := time.Now()
+
+	t.Run("finds public dashboards by the creation time of their access token", func(t *testing.T) {
+		pubdashes, err := publicdashboardStore.FindEnabledWithAccessTokenCreatedBefore(ctx, now.Add(time.Hour))
+		require.NoError(t, err)
+		require.Len(t, pubdashes, 1)
+		assert.Equal(t, pubdash.Uid, pubdashes[0].Uid)
+
+		pubdashes, err = publicdashboardStore.FindEnabledWithAccessTokenCreatedBefore(ctx, now.Add(-time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, pubdashes)
+	})
+
+	t.Run("flags the access token once", func(t *testing.T) {
+		affectedRows, err := publicdashboardStore.FlagAccessTokenRotationDue(ctx, pubdash.Uid, pubdash.AccessToken)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, affectedRows)
+
+		affectedRows, err = publicdashboardStore.FlagAccessTokenRotationDue(ctx, pubdash.Uid, pubdash.AccessToken)
+		require.NoError(t, err)
+		assert.EqualValues(t, 0, affectedRows)
+
+		flagged, err := publicdashboardStore.Find(ctx, pubdash.Uid)
+		require.NoError(t, err)
+		assert.True(t, flagged.AccessTokenRotationDue)
+	})
+
+	t.Run("rotating the access token clears the flag", func(t *testing.T) {
+		newAccessToken := strings.Repeat("a1B2", 16)
+		affectedRows, err := publicdashboardStore.RotateAccessToken(ctx, RotateAccessTokenCommand{
+			Uid:            pubdash.Uid,
+			AccessToken:    pubdash.AccessToken,
+			NewAccessToken: newAccessToken,
+			UpdatedAt:      now.Add(time.Hour),
+		})
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, affectedRows)
+
+		rotated, err := publicdashboardStore.Find(ctx, pubdash.Uid)
+		require.NoError(t, err)
+		assert.Equal(t, newAccessToken, rotated.AccessToken)
+		assert.False(t, rotated.AccessTokenRotationDue)
+		assert.WithinDuration(t, now.Add(time.Hour), rotated.AccessTokenCreatedAt, time.Second)
+
+		pubdashes, err := publicdashboardStore.FindEnabledWithAccessTokenCreatedBefore(ctx, now.Add(time.Minute))
+		require.NoError(t, err)
+		assert.Empty(t, pubdashes)
+	})
+}
+
+func TestIntegrationAuditLog(t *testing.T) {
+	testutil.SkipIntegrationTestInShortMode(t)
+
+	var publicdashboardStore *PublicDashboardStoreImpl
+
+	insertEntry := func(t *testing.T, orgId int64, uid string, createdAt time.Time) {
+		err := publicdashboardStore.InsertAuditLogEntry(context.Background(), &AuditLogEntry{
+			OrgId:              orgId,
+			PublicDashboardUid: uid,
+			AccessTokenHash:    "9c1f",
+			IPHash:             "3a5f",
+			UserAgent:          "Mozilla/5.0",
+			Action:             AuditLogActionQuery,
+			PanelIds:           []int64{1, 2},
+			TimeFrom:           "now-6h",
+			TimeTo:             "now",
+			CreatedAt:          createdAt,
+		})
+		require.NoError(t, err)
+	}
+
+	setup := func(t *testing.T) {
+		sqlStore, cfg := db.InitTestDBWithCfg(t)
+		publicdashboardStore = ProvideStore(sqlStore, cfg, featuremgmt.WithFeatures())
+
+		insertEntry(t, 1, "pubdash1", DefaultTime.Add(-2*time.Hour))
+		insertEntry(t, 1, "pubdash1", DefaultTime.Add(-time.Hour))
+		insertEntry(t, 1, "pubdash1", DefaultTime)
+		insertEntry(t, 1, "pubdash2", DefaultTime)
+		insertEntry(t, 2, "pubdash1", DefaultTime)
+	}
+
+	t.Run("finds a page of the entries of the public dashboard, newest first", func(t *testing.T) {
+		setup(t)
+
+		resp, err := publicdashboardStore.FindAuditLog(context.Background(), &AuditLogQuery{OrgId: 1, PublicDashboardUid: "pubdash1", Page: 2, Limit: 2, Offset: 2})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), resp.TotalCount)
+		require.Len(t, resp.Entries, 1)
+		assert.Equal(t, DefaultTime.Add(-2*time.Hour), resp.Entries[0].CreatedAt.UTC())
+		assert.Equal(t, []int64{1, 2}, resp.Entries[0].PanelIds)
+		assert.Equal(t, "Mozilla/5.0", resp.Entries[0].UserAgent)
+
+		resp, err = publicdashboardStore.FindAuditLog(context.Background(), &AuditLogQuery{OrgId: 1, PublicDashboardUid: "pubdash1", Page: 1, Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, resp.Entries, 2)
+		assert.Equal(t, DefaultTime, resp.Entries[0].CreatedAt.UTC())
+	})
+
+	t.Run("deletes the entries recorded before the given time", func(t *testing.T) {
+		setup(t)
+
+		deleted, err := publicdashboardStore.DeleteAuditLogBefore(context.Background(), DefaultTime.Add(-30*time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), deleted)
+
+		resp, err := publicdashboardStore.FindAuditLog(context.Background(), &AuditLogQuery{OrgId: 1, PublicDashboardUid: "pubdash1", Page: 1, Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), resp.TotalCount)
+	})
+}
+
+func TestIntegrationUsage(t *testing.T) {
+	testutil.SkipIntegrationTestInShortMode(t)
+
+	var sqlStore db.DB
+	var publicdashboardStore *PublicDashboardStoreImpl
+	var savedDashboard *dashboards.Dashboard
+	var savedPublicDashboard *PublicDashboard
+
+	setup := func(t *testing.T) {
+		var cfg *setting.Cfg
+		sqlStore, cfg = db.InitTestDBWithCfg(t)
+		dashboardStore, err := dashboardsDB.ProvideDashboardStore(sqlStore, cfg, featuremgmt.WithFeatures(), tagimpl.ProvideService(sqlStore))
+		require.NoError(t, err)
+		publicdashboardStore = ProvideStore(sqlStore, cfg, featuremgmt.WithFeatures())
+		savedDashboard = insertTestDashboard(t, dashboardStore, "testDashie", 1, "", false)
+		savedPublicDashboard = insertPublicDashboard(t, publicdashboardStore, savedDashboard.UID, savedDashboard.OrgID, true, PublicShareType)
+	}
+
+	addUsage := func(t *testing.T, usage PublicDashboardUsage) {
+		usage.OrgId = savedPublicDashboard.OrgId
+		usage.PublicDashboardUid = savedPublicDashboard.Uid
+		err := publicdashboardStore.AddUsage(context.Background(), []PublicDashboardUsage{usage})
+		require.NoError(t, err)
+	}
+
+	t.Run("adds the usage to the stored usage", func(t *testing.T) {
+		setup(t)
+
+		addUsage(t, PublicDashboardUsage{Views: 2, Queries: 1, LastQueriedAt: DefaultTime})
+		addUsage(t, PublicDashboardUsage{Views: 1})
+		addUsage(t, PublicDashboardUsage{Queries: 4, LastQueriedAt: DefaultTime.Add(time.Minute)})
+
+		usage, err := publicdashboardStore.FindUsage(context.Background(), savedPublicDashboard.Uid)
+		require.NoError(t, err)
+		require.NotNil(t, usage)
+		assert.Equal(t, int64(3), usage.Views)
+		assert.Equal(t, int64(5), usage.Queries)
+		assert.Equal(t, DefaultTime.Add(time.Minute), usage.LastQueriedAt.UTC(), "times are only updated when they're set")
+		assert.True(t, usage.LastViewedAt.IsZero())
+	})
+
+	t.Run("finds the last view of the usage in the last access of the public dashboard", func(t *testing.T) {
+		setup(t)
+		addUsage(t, PublicDashboardUsage{Views: 1})
+		err := publicdashboardStore.UpdateLastAccessedAt(context.Background(), savedPublicDashboard.Uid, DefaultTime)
+		require.NoError(t, err)
+
+		usage, err := publicdashboardStore.FindUsage(context.Background(), savedPublicDashboard.Uid)
+		require.NoError(t, err)
+		require.NotNil(t, usage)
+		assert.Equal(t, DefaultTime, usage.LastViewedAt.UTC())
+	})
+
+	t.Run("finds no usage of public dashboards without views", func(t *testing.T) {
+		setup(t)
+
+		usage, err := publicdashboardStore.FindUsage(context.Background(), savedPublicDashboard.Uid)
+		require.NoError(t, err)
+		assert.Nil(t, usage)
+	})
+
+	t.Run("lists the public dashboards with their usage", func(t *testing.T) {
+		setup(t)
+		addUsage(t, PublicDashboardUsage{Views: 4, Queries: 9})
+
+		permissions := []accesscontrol.Permission{
+			{Action: dashboards.ActionDashboardsRead, Scope: fmt.Sprintf("dashboards:uid:%s", savedDashboard.UID)},
+		}
+		usr := &user.SignedInUser{UserID: 1, OrgID: 1, Permissions: map[int64]map[string][]string{1: accesscontrol.GroupScopesByActionContext(context.Background(), permissions)}}
+		actest.AddUserPermissionToDB(t, sqlStore, usr)
+
+		resp, err := publicdashboardStore.FindAll(context.Background(), &PublicDashboardListQuery{User: usr, OrgID: 1, Page: 1, Limit: 50})
+		require.NoError(t, err)
+		require.Len(t, resp.PublicDashboards, 1)
+		assert.Equal(t, int64(4), resp.PublicDashboards[0].Views)
+		assert.Equal(t, int64(9), resp.PublicDashboards[0].Queries)
+	})
+
+	t.Run("deletes the usage with the public dashboard", func(t *testing.T) {
+		setup(t)
+		addUsage(t, PublicDashboardUsage{Views: 1})
+
+		_, err := publicdashboardStore.Delete(context.Background(), savedPublicDashboard.Uid)
+		require.NoError(t, err)
+
+		usage, err := publicdashboardStore.FindUsage(context.Background(), savedPublicDashboard.Uid)
+		require.NoError(t, err)
+		assert.Nil(t, usage)
+	})
+}
+
 // helper function to insert a dashboard
 func insertTestDashboard(t *testing.T, dashboardStore dashboards.Store, title string, orgID int64,
 	folderUID string, isFolder bool, tags ...any) *dashboards.Dashboard {
@@ -0,0 +1,346 @@
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/grpcserver"
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/api"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// viewerTokenMetadataKey is the metadata key of the viewer token, the gRPC counterpart of the
+// X-Grafana-Public-Dashboard-Token header of the HTTP API
+const viewerTokenMetadataKey = "x-grafana-public-dashboard-token"
+
+// Service serves the panel queries and the variables of public dashboards over gRPC, keyed by access token, so
+// external systems like status pages and bots can consume them without scraping the HTTP API
+type Service struct {
+	log           log.Logger
+	service       publicdashboards.Service
+	geoIPProvider publicdashboards.GeoIPProvider
+	rateLimiter   *api.RequestRateLimiter
+	botDetector   *api.BotDetector
+	clientIPs     *api.ClientIPResolver
+}
+
+var _ PublicDashboardServiceServer = (*Service)(nil)
+
+func ProvideService(cfg *setting.Cfg, grpcServerProvider grpcserver.Provider, pd publicdashboards.Service, geoIP publicdashboards.GeoIPProvider, pdAPI *api.Api) *Service {
+	// the limits and the bot scores are shared with the HTTP API, so clients can't escape them by switching protocol
+	s := &Service{
+		log:           log.New("publicdashboards.grpc"),
+		service:       pd,
+		geoIPProvider: geoIP,
+		rateLimiter:   pdAPI.RateLimiter,
+		botDetector:   pdAPI.BotDetector,
+		clientIPs:     pdAPI.ClientIPs,
+	}
+
+	// register the service if the feature is enabled
+	if cfg.PublicDashboardsEnabled {
+		grpcServerProvider.GetServer().RegisterService(&serviceDesc, s)
+	}
+
+	return s
+}
+
+// AuthFuncOverride skips the authentication of the gRPC server, public dashboards are accessed by access token
+func (s *Service) AuthFuncOverride(ctx context.Context, _ string) (context.Context, error) {
+	return ctx, nil
+}
+
+type queryPanelRequest struct {
+	AccessToken string                         `json:"accessToken"`
+	PanelId     int64                          `json:"panelId"`
+	Query       models.PublicDashboardQueryDTO `json:"query"`
+}
+
+func (s *Service) QueryPanel(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	req := queryPanelRequest{}
+	if err := decodeRequest(in, &req); err != nil {
+		return nil, err
+	}
+	if req.PanelId <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid panel id")
+	}
+	if err := s.checkAccess(ctx, req.AccessToken); err != nil {
+		return nil, err
+	}
+
+	res, err := s.service.GetQueryDataResponse(ctx, false, req.Query, req.PanelId, req.AccessToken)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return encodeResponse(res)
+}
+
+type listVariablesRequest struct {
+	AccessToken string `json:"accessToken"`
+}
+
+func (s *Service) ListVariables(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	req := listVariablesRequest{}
+	if err := decodeRequest(in, &req); err != nil {
+		return nil, err
+	}
+	if err := s.checkAccess(ctx, req.AccessToken); err != nil {
+		return nil, err
+	}
+
+	variables, err := s.service.ListVariables(ctx, req.AccessToken)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return encodeResponse(map[string]any{"variables": variables})
+}
+
+type queryVariableRequest struct {
+	AccessToken  string                                 `json:"accessToken"`
+	VariableName string                                 `json:"variableName"`
+	Query        models.PublicDashboardVariableQueryDTO `json:"query"`
+}
+
+func (s *Service) QueryVariable(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	req := queryVariableRequest{}
+	if err := decodeRequest(in, &req); err != nil {
+		return nil, err
+	}
+	if req.VariableName == "" {
+		return nil, status.Error(codes.InvalidArgument, "variable name is required")
+	}
+	if err := s.checkAccess(ctx, req.AccessToken); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.service.GetVariableQueryResponse(ctx, req.AccessToken, req.VariableName, req.Query)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return encodeResponse(resp)
+}
+
+type getViewerChallengeRequest struct {
+	AccessToken string `json:"accessToken"`
+}
+
+// GetViewerChallenge returns the challenge clients solve before they get a viewer token
+func (s *Service) GetViewerChallenge(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	req := getViewerChallengeRequest{}
+	if err := decodeRequest(in, &req); err != nil {
+		return nil, err
+	}
+	if err := s.checkViewerTokenAccess(ctx, req.AccessToken); err != nil {
+		return nil, err
+	}
+
+	challenge, err := s.service.GetViewerChallenge(ctx, req.AccessToken, s.viewerClient(requestFromContext(ctx)))
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return encodeResponse(challenge)
+}
+
+type issueViewerTokenRequest struct {
+	AccessToken string                          `json:"accessToken"`
+	Solution    *models.ViewerChallengeSolution `json:"solution"`
+}
+
+// IssueViewerToken exchanges the access token for a viewer token, sent by the other calls in the
+// x-grafana-public-dashboard-token metadata
+func (s *Service) IssueViewerToken(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	req := issueViewerTokenRequest{}
+	if err := decodeRequest(in, &req); err != nil {
+		return nil, err
+	}
+	if err := s.checkViewerTokenAccess(ctx, req.AccessToken); err != nil {
+		return nil, err
+	}
+
+	token, err := s.service.IssueViewerToken(ctx, req.AccessToken, s.viewerClient(requestFromContext(ctx)), req.Solution)
+	if err != nil {
+		var limitErr errutil.Error
+		if errors.Is(err, models.ErrViewerLimitReached) && errors.As(err, &limitErr) {
+			if seconds, ok := limitErr.PublicPayload["retryAfterSeconds"].(int); ok {
+				setRetryAfter(ctx, time.Duration(seconds)*time.Second)
+			}
+		}
+		return nil, toStatusError(err)
+	}
+
+	return encodeResponse(token)
+}
+
+// checkAccess checks the client and validates the viewer token of the call, and enforces the geo restriction of the
+// public dashboard, like the middlewares of the HTTP API. Unknown access tokens are left to the service
+func (s *Service) checkAccess(ctx context.Context, accessToken string) error {
+	if err := s.checkClient(ctx, accessToken); err != nil {
+		return err
+	}
+
+	req := requestFromContext(ctx)
+	if err := s.service.ValidateViewerToken(ctx, accessToken, req.Header.Get(viewerTokenMetadataKey), s.viewerClient(req)); err != nil {
+		return toStatusError(err)
+	}
+
+	return s.checkCountry(ctx, accessToken)
+}
+
+// checkViewerTokenAccess checks the calls clients get viewer tokens with, like checkAccess without the viewer token
+func (s *Service) checkViewerTokenAccess(ctx context.Context, accessToken string) error {
+	if err := s.checkClient(ctx, accessToken); err != nil {
+		return err
+	}
+	return s.checkCountry(ctx, accessToken)
+}
+
+// checkClient validates the access token, and enforces the bot checks and the rate limits
+func (s *Service) checkClient(ctx context.Context, accessToken string) error {
+	req := requestFromContext(ctx)
+	client := s.viewerClient(req)
+	// checked before the access token, so enumerating access tokens counts towards the score of the client
+	if retryAfter, err := s.botDetector.Check(ctx, s.service, req, client.IP, accessToken); err != nil {
+		setRetryAfter(ctx, retryAfter)
+		return toStatusError(err)
+	}
+
+	if !validation.IsValidAccessTokenOrSlug(accessToken) {
+		return status.Error(codes.InvalidArgument, "invalid access token")
+	}
+
+	if retryAfter, err := s.rateLimiter.Allow(ctx, accessToken, client.IP); err != nil {
+		setRetryAfter(ctx, retryAfter)
+		return toStatusError(err)
+	}
+	return nil
+}
+
+// checkCountry enforces the geo restriction of the public dashboard
+func (s *Service) checkCountry(ctx context.Context, accessToken string) error {
+	pubdash := api.FindPublicDashboard(ctx, s.service, accessToken)
+	if pubdash == nil || pubdash.GeoRestriction == nil {
+		return nil
+	}
+
+	country, err := s.geoIPProvider.LookupCountry(ctx, requestFromContext(ctx))
+	if err != nil {
+		s.log.Warn("Failed to look up the country of a public dashboard request", "publicDashboardUid", pubdash.Uid, "error", err)
+		country = ""
+	}
+
+	if pubdash.GeoRestriction.Allows(country) {
+		return nil
+	}
+
+	s.log.Info("Denied public dashboard access by geo restriction", "publicDashboardUid", pubdash.Uid, "country", country)
+	return toStatusError(models.ErrPublicDashboardGeoRestricted.Errorf("checkCountry: access from country %q is not allowed", country))
+}
+
+// viewerClient identifies the client of the call viewer tokens are bound to. gRPC clients are bound to their address
+// only, their user agent is the one of their gRPC library and doesn't tell clients apart
+func (s *Service) viewerClient(req *http.Request) models.ViewerClient {
+	return models.ViewerClient{IP: s.clientIPs.ClientIP(req)}
+}
+
+// requestFromContext returns an HTTP request with the metadata and the address of the gRPC call, for the GeoIP
+// providers reading the headers set by a proxy
+func requestFromContext(ctx context.Context) *http.Request {
+	req := &http.Request{Header: http.Header{}}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for key, values := range md {
+			for _, value := range values {
+				req.Header.Add(key, value)
+			}
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		req.RemoteAddr = p.Addr.String()
+	}
+	return req.WithContext(ctx)
+}
+
+// setRetryAfter sends how long until the call can be retried in the retry-after header of the response, like the
+// Retry-After header of the HTTP API
+func setRetryAfter(ctx context.Context, retryAfter time.Duration) {
+	_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))))
+}
+
+func decodeRequest(in *structpb.Struct, v any) error {
+	b, err := protojson.Marshal(in)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "error parsing request: %v", err)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return status.Errorf(codes.InvalidArgument, "error parsing request: %v", err)
+	}
+	return nil
+}
+
+func encodeResponse(v any) (*structpb.Struct, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error encoding response: %v", err)
+	}
+
+	out := &structpb.Struct{}
+	if err := protojson.Unmarshal(b, out); err != nil {
+		return nil, status.Errorf(codes.Internal, "error encoding response: %v", err)
+	}
+	return out, nil
+}
+
+// toStatusError converts the errors of the service to gRPC status errors with their public message
+func toStatusError(err error) error {
+	var grafanaErr errutil.Error
+	if !errors.As(err, &grafanaErr) {
+		return status.Error(codes.Internal, "internal server error")
+	}
+
+	code := codes.Internal
+	switch grafanaErr.Reason.Status() {
+	case errutil.StatusBadRequest, errutil.StatusValidationFailed, errutil.StatusUnprocessableEntity:
+		code = codes.InvalidArgument
+	case errutil.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case errutil.StatusForbidden:
+		code = codes.PermissionDenied
+	case errutil.StatusNotFound:
+		code = codes.NotFound
+	case errutil.StatusConflict:
+		code = codes.AlreadyExists
+	case errutil.StatusGone:
+		code = codes.FailedPrecondition
+	case errutil.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	case errutil.StatusTimeout, errutil.StatusGatewayTimeout:
+		code = codes.DeadlineExceeded
+	case errutil.StatusNotImplemented:
+		code = codes.Unimplemented
+	case errutil.StatusBadGateway:
+		code = codes.Unavailable
+	}
+
+	return status.Error(code, grafanaErr.Public().Message)
+}
@@ -0,0 +1,67 @@
+package grpcapi
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ServiceName is the full name of the gRPC service of public dashboards
+const ServiceName = "grafana.publicdashboards.v1.PublicDashboardService"
+
+// PublicDashboardServiceServer is the server API of the gRPC service of public dashboards. Requests and responses are
+// protobuf Structs with the same fields as the JSON bodies of the HTTP API of public dashboards, so it's served without
+// generated code and clients only need the well known types
+type PublicDashboardServiceServer interface {
+	// QueryPanel runs the queries of a panel. The request has the accessToken, the panelId and the query, like the body
+	// of the query endpoint of the HTTP API. The response has the results by refId
+	QueryPanel(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error)
+	// ListVariables returns the variables of a public dashboard. The request has the accessToken
+	ListVariables(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error)
+	// QueryVariable returns the options of a variable. The request has the accessToken, the variableName and the
+	// query, like the body of the variable query endpoint of the HTTP API
+	QueryVariable(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error)
+	// GetViewerChallenge returns the challenge clients solve before they get a viewer token. The request has the
+	// accessToken
+	GetViewerChallenge(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error)
+	// IssueViewerToken returns a viewer token for the other methods, bound to the address of the client. The request
+	// has the accessToken and the solution of the viewer challenge, like the body of the viewer token endpoint of the
+	// HTTP API
+	IssueViewerToken(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error)
+}
+
+// serviceDesc describes the gRPC service of public dashboards, like protoc-gen-go-grpc would
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*PublicDashboardServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "QueryPanel", Handler: unaryHandler("QueryPanel", PublicDashboardServiceServer.QueryPanel)},
+		{MethodName: "ListVariables", Handler: unaryHandler("ListVariables", PublicDashboardServiceServer.ListVariables)},
+		{MethodName: "QueryVariable", Handler: unaryHandler("QueryVariable", PublicDashboardServiceServer.QueryVariable)},
+		{MethodName: "GetViewerChallenge", Handler: unaryHandler("GetViewerChallenge", PublicDashboardServiceServer.GetViewerChallenge)},
+		{MethodName: "IssueViewerToken", Handler: unaryHandler("IssueViewerToken", PublicDashboardServiceServer.IssueViewerToken)},
+	},
+	Streams: []grpc.StreamDesc{},
+}
+
+// unaryHandler returns the handler of a unary method taking and returning a Struct
+func unaryHandler(method string, fn func(PublicDashboardServiceServer, context.Context, *structpb.Struct) (*structpb.Struct, error)) grpc.MethodHandler {
+	fullMethod := "/" + ServiceName + "/" + method
+
+	return func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+		in := new(structpb.Struct)
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+		if interceptor == nil {
+			return fn(srv.(PublicDashboardServiceServer), ctx, in)
+		}
+
+		info := &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod}
+		handler := func(ctx context.Context, req any) (any, error) {
+			return fn(srv.(PublicDashboardServiceServer), ctx, req.(*structpb.Struct))
+		}
+		return interceptor(ctx, in, info, handler)
+	}
+}
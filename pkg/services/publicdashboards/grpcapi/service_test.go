@@ -0,0 +1,280 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/api"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const accessToken = "e6d56d4b5d7d4ed6bd4b6c1a2b3e4f50"
+
+const viewerToken = "viewer-token"
+
+func newTestService(t *testing.T, pd publicdashboards.Service, geoIP publicdashboards.GeoIPProvider) *Service {
+	t.Helper()
+	return &Service{log: log.NewNopLogger(), service: pd, geoIPProvider: geoIP}
+}
+
+// withViewerToken returns a context sending the viewer token with the metadata pairs, and expects the service to
+// accept it for the access token
+func withViewerToken(t *testing.T, pd *publicdashboards.FakePublicDashboardService, accessToken string, kv ...string) context.Context {
+	t.Helper()
+	pd.On("ValidateViewerToken", mock.Anything, accessToken, viewerToken, ViewerClient{}).Return(nil)
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(append([]string{viewerTokenMetadataKey, viewerToken}, kv...)...))
+}
+
+// fromPeer returns a context of a call from the address
+func fromPeer(ctx context.Context, ip string) context.Context {
+	return peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 51234}})
+}
+
+func newStruct(t *testing.T, v map[string]any) *structpb.Struct {
+	t.Helper()
+	s, err := structpb.NewStruct(v)
+	require.NoError(t, err)
+	return s
+}
+
+func TestQueryPanel(t *testing.T) {
+	t.Run("Returns the results of the panel", func(t *testing.T) {
+		pd := publicdashboards.NewFakePublicDashboardService(t)
+		pd.On("FindByAccessToken", mock.Anything, accessToken).Return(&PublicDashboard{Uid: "pubdash1"}, nil)
+		pd.On("GetQueryDataResponse", mock.Anything, false, PublicDashboardQueryDTO{IntervalMs: 1000, MaxDataPoints: 100}, int64(2), accessToken).
+			Return(&backend.QueryDataResponse{Responses: backend.Responses{
+				"A": {Frames: data.Frames{data.NewFrame("A", data.NewField("value", nil, []float64{1}))}},
+			}}, nil)
+		s := newTestService(t, pd, nil)
+
+		res, err := s.QueryPanel(withViewerToken(t, pd, accessToken), newStruct(t, map[string]any{
+			"accessToken": accessToken,
+			"panelId":     2,
+			"query":       map[string]any{"intervalMs": 1000, "maxDataPoints": 100},
+		}))
+		require.NoError(t, err)
+		assert.Contains(t, res.GetFields()["results"].GetStructValue().GetFields(), "A")
+	})
+
+	t.Run("Converts the errors of the service to status errors", func(t *testing.T) {
+		pd := publicdashboards.NewFakePublicDashboardService(t)
+		pd.On("FindByAccessToken", mock.Anything, accessToken).Return(&PublicDashboard{Uid: "pubdash1"}, nil)
+		pd.On("GetQueryDataResponse", mock.Anything, false, mock.Anything, int64(2), accessToken).
+			Return(nil, ErrPanelNotFound.Errorf("panel not found"))
+		s := newTestService(t, pd, nil)
+
+		_, err := s.QueryPanel(withViewerToken(t, pd, accessToken), newStruct(t, map[string]any{"accessToken": accessToken, "panelId": 2}))
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+
+	t.Run("Fails for invalid access tokens", func(t *testing.T) {
+		pd := publicdashboards.NewFakePublicDashboardService(t)
+		s := newTestService(t, pd, nil)
+
+		_, err := s.QueryPanel(context.Background(), newStruct(t, map[string]any{"accessToken": "not a token", "panelId": 2}))
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		pd.AssertNotCalled(t, "GetQueryDataResponse")
+	})
+
+	t.Run("Requires a viewer token issued to the client", func(t *testing.T) {
+		pd := publicdashboards.NewFakePublicDashboardService(t)
+		// gRPC clients are bound to their address, their user agent is the one of their gRPC library
+		client := ViewerClient{IP: "10.0.0.1"}
+		pd.On("ValidateViewerToken", mock.Anything, accessToken, viewerToken, client).
+			Return(ErrInvalidViewerToken.Errorf("token issued to another client"))
+		s := newTestService(t, pd, nil)
+
+		// forwarded addresses are only read from trusted proxies
+		ctx := metadata.NewIncomingContext(fromPeer(context.Background(), client.IP), metadata.Pairs(
+			"x-grafana-public-dashboard-token", viewerToken,
+			"x-real-ip", "10.0.0.9",
+			"user-agent", "grpc-go/1.64.0",
+		))
+		_, err := s.QueryPanel(ctx, newStruct(t, map[string]any{"accessToken": accessToken, "panelId": 2}))
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		pd.AssertNotCalled(t, "FindByAccessToken")
+		pd.AssertNotCalled(t, "GetQueryDataResponse")
+	})
+
+	t.Run("Rejects calls without a viewer token", func(t *testing.T) {
+		pd := publicdashboards.NewFakePublicDashboardService(t)
+		pd.On("ValidateViewerToken", mock.Anything, accessToken, "", ViewerClient{}).
+			Return(ErrInvalidViewerToken.Errorf("missing viewer token"))
+		s := newTestService(t, pd, nil)
+
+		_, err := s.QueryPanel(context.Background(), newStruct(t, map[string]any{"accessToken": accessToken, "panelId": 2}))
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		pd.AssertNotCalled(t, "GetQueryDataResponse")
+	})
+
+	t.Run("Enforces the rate limits shared with the HTTP API", func(t *testing.T) {
+		pd := publicdashboards.NewFakePublicDashboardService(t)
+		pd.On("FindByAccessToken", mock.Anything, accessToken).Return(&PublicDashboard{Uid: "pubdash1"}, nil)
+		pd.On("GetQueryDataResponse", mock.Anything, false, mock.Anything, int64(2), accessToken).
+			Return(&backend.QueryDataResponse{}, nil).Once()
+		cfg := setting.NewCfg()
+		cfg.PublicDashboardsRateLimitPerAccessToken = 1
+		s := newTestService(t, pd, nil)
+		s.rateLimiter = api.NewRequestRateLimiter(cfg, nil)
+
+		ctx := withViewerToken(t, pd, accessToken)
+		in := newStruct(t, map[string]any{"accessToken": accessToken, "panelId": 2})
+		_, err := s.QueryPanel(ctx, in)
+		require.NoError(t, err)
+		_, err = s.QueryPanel(ctx, in)
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
+
+	t.Run("Blocks the clients detected as bots", func(t *testing.T) {
+		pd := publicdashboards.NewFakePublicDashboardService(t)
+		cfg := setting.NewCfg()
+		cfg.PublicDashboardsBotDetectionEnabled = true
+		cfg.PublicDashboardsBotDetectionThrottleScore = 1
+		cfg.PublicDashboardsBotDetectionThrottleRate = 1
+		cfg.PublicDashboardsBotDetectionBlockScore = 1
+		cfg.PublicDashboardsBotDetectionBlockDuration = time.Minute
+		s := newTestService(t, pd, nil)
+		s.botDetector = api.NewBotDetector(cfg)
+
+		_, err := s.QueryPanel(context.Background(), newStruct(t, map[string]any{"accessToken": "not a token", "panelId": 2}))
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		pd.AssertNotCalled(t, "GetQueryDataResponse")
+	})
+
+	t.Run("Enforces the geo restriction of the public dashboard", func(t *testing.T) {
+		pd := publicdashboards.NewFakePublicDashboardService(t)
+		pd.On("FindByAccessToken", mock.Anything, accessToken).Return(&PublicDashboard{
+			Uid:            "pubdash1",
+			GeoRestriction: &GeoRestriction{Mode: GeoRestrictionModeAllow, Countries: []string{"FI"}},
+		}, nil)
+		geoIP := publicdashboards.NewFakeGeoIPProvider(t)
+		geoIP.On("LookupCountry", mock.Anything, mock.MatchedBy(func(req *http.Request) bool {
+			return req.Header.Get("X-Country") == "US"
+		})).Return("US", nil)
+		s := newTestService(t, pd, geoIP)
+
+		ctx := withViewerToken(t, pd, accessToken, "x-country", "US")
+		_, err := s.QueryPanel(ctx, newStruct(t, map[string]any{"accessToken": accessToken, "panelId": 2}))
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		pd.AssertNotCalled(t, "GetQueryDataResponse")
+	})
+
+	t.Run("Enforces the geo restriction of public dashboards queried by slug", func(t *testing.T) {
+		pd := publicdashboards.NewFakePublicDashboardService(t)
+		pd.On("FindEnabledPublicDashboardAndDashboardByAccessToken", mock.Anything, "status-page").Return(&PublicDashboard{
+			Uid:            "pubdash1",
+			GeoRestriction: &GeoRestriction{Mode: GeoRestrictionModeAllow, Countries: []string{"FI"}},
+		}, nil, nil)
+		geoIP := publicdashboards.NewFakeGeoIPProvider(t)
+		geoIP.On("LookupCountry", mock.Anything, mock.Anything).Return("US", nil)
+		s := newTestService(t, pd, geoIP)
+
+		_, err := s.QueryPanel(withViewerToken(t, pd, "status-page"), newStruct(t, map[string]any{"accessToken": "status-page", "panelId": 2}))
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		pd.AssertNotCalled(t, "GetQueryDataResponse")
+	})
+}
+
+func TestQueryVariable(t *testing.T) {
+	refreshedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	pd := publicdashboards.NewFakePublicDashboardService(t)
+	pd.On("FindByAccessToken", mock.Anything, accessToken).Return(&PublicDashboard{Uid: "pubdash1"}, nil)
+	pd.On("GetVariableQueryResponse", mock.Anything, accessToken, "region", PublicDashboardVariableQueryDTO{SearchFilter: "eu"}).
+		Return(&PublicDashboardVariableQueryResponse{Options: []MetricFindValue{{Text: "eu-west-1", Value: "eu-west-1"}}, RefreshedAt: &refreshedAt}, nil)
+	s := newTestService(t, pd, nil)
+
+	res, err := s.QueryVariable(withViewerToken(t, pd, accessToken), newStruct(t, map[string]any{
+		"accessToken":  accessToken,
+		"variableName": "region",
+		"query":        map[string]any{"searchFilter": "eu"},
+	}))
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(newStruct(t, map[string]any{
+		"options":     []any{map[string]any{"text": "eu-west-1", "value": "eu-west-1"}},
+		"refreshedAt": "2024-05-01T10:00:00Z",
+	}), res))
+}
+
+func TestIssueViewerToken(t *testing.T) {
+	t.Run("Issues viewer tokens bound to the address of the client", func(t *testing.T) {
+		expiresAt := time.Date(2024, 5, 1, 10, 5, 0, 0, time.UTC)
+		solution := &ViewerChallengeSolution{Challenge: "challenge", Nonce: "42"}
+		pd := publicdashboards.NewFakePublicDashboardService(t)
+		pd.On("FindByAccessToken", mock.Anything, accessToken).Return(&PublicDashboard{Uid: "pubdash1"}, nil)
+		pd.On("IssueViewerToken", mock.Anything, accessToken, ViewerClient{IP: "10.0.0.1"}, solution).
+			Return(&ViewerToken{Token: viewerToken, ExpiresAt: expiresAt}, nil)
+		s := newTestService(t, pd, nil)
+
+		ctx := metadata.NewIncomingContext(fromPeer(context.Background(), "10.0.0.1"), metadata.Pairs("user-agent", "grpc-go/1.64.0"))
+		res, err := s.IssueViewerToken(ctx, newStruct(t, map[string]any{
+			"accessToken": accessToken,
+			"solution":    map[string]any{"challenge": "challenge", "nonce": "42"},
+		}))
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(newStruct(t, map[string]any{
+			"token":     viewerToken,
+			"expiresAt": "2024-05-01T10:05:00Z",
+		}), res))
+		pd.AssertNotCalled(t, "ValidateViewerToken")
+	})
+
+	t.Run("Fails when the public dashboard has reached its maximum of viewers", func(t *testing.T) {
+		pd := publicdashboards.NewFakePublicDashboardService(t)
+		pd.On("FindByAccessToken", mock.Anything, accessToken).Return(&PublicDashboard{Uid: "pubdash1"}, nil)
+		pd.On("IssueViewerToken", mock.Anything, accessToken, ViewerClient{}, (*ViewerChallengeSolution)(nil)).
+			Return(nil, ErrViewerLimitReached.Errorf("limit reached"))
+		s := newTestService(t, pd, nil)
+
+		_, err := s.IssueViewerToken(context.Background(), newStruct(t, map[string]any{"accessToken": accessToken}))
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
+
+	t.Run("Returns the challenge of the client", func(t *testing.T) {
+		pd := publicdashboards.NewFakePublicDashboardService(t)
+		pd.On("FindByAccessToken", mock.Anything, accessToken).Return(&PublicDashboard{Uid: "pubdash1"}, nil)
+		pd.On("GetViewerChallenge", mock.Anything, accessToken, ViewerClient{}).
+			Return(&ViewerChallenge{Type: ViewerChallengeTypeProofOfWork, Challenge: "challenge", Difficulty: 16}, nil)
+		s := newTestService(t, pd, nil)
+
+		res, err := s.GetViewerChallenge(context.Background(), newStruct(t, map[string]any{"accessToken": accessToken}))
+		require.NoError(t, err)
+		assert.Equal(t, "challenge", res.GetFields()["challenge"].GetStringValue())
+	})
+}
+
+func TestServiceDesc(t *testing.T) {
+	pd := publicdashboards.NewFakePublicDashboardService(t)
+	pd.On("FindByAccessToken", mock.Anything, accessToken).Return(&PublicDashboard{Uid: "pubdash1"}, nil)
+	pd.On("ListVariables", mock.Anything, accessToken).Return([]PublicDashboardVariable{{Name: "region", Type: "query"}}, nil)
+	s := newTestService(t, pd, nil)
+
+	require.Equal(t, "ListVariables", serviceDesc.Methods[1].MethodName)
+	dec := func(in any) error {
+		proto.Merge(in.(*structpb.Struct), newStruct(t, map[string]any{"accessToken": accessToken}))
+		return nil
+	}
+
+	res, err := serviceDesc.Methods[1].Handler(s, withViewerToken(t, pd, accessToken), dec, nil)
+	require.NoError(t, err)
+	variables := res.(*structpb.Struct).GetFields()["variables"].GetListValue().GetValues()
+	require.Len(t, variables, 1)
+	assert.Equal(t, "region", variables[0].GetStructValue().GetFields()["name"].GetStringValue())
+}
@@ -35,15 +35,38 @@ func ProvideService(
 }
 
 func (s *Service) registerMetrics(prom prometheus.Registerer) error {
-	err := prom.Register(s.Metrics.PublicDashboardsAmount)
-	var alreadyRegisterErr prometheus.AlreadyRegisteredError
-	if errors.As(err, &alreadyRegisterErr) {
-		if alreadyRegisterErr.ExistingCollector == alreadyRegisterErr.NewCollector {
-			err = nil
+	collectors := []prometheus.Collector{
+		s.Metrics.PublicDashboardsAmount,
+		QueryQueueDepth,
+		QueriesInFlight,
+		QueriesShedTotal,
+		VariableOptionsCacheRequestsTotal,
+		AccessTokenCacheRequestsTotal,
+		RequestsRateLimitedTotal,
+		BotDetectionSignalsTotal,
+		BotDetectionRequestsRejectedTotal,
+		BotDetectionClientsBlockedTotal,
+		OrphanedFound,
+		OrphanedDeletedTotal,
+		ReadReplicaFallbacksTotal,
+		BatchQueryPanelsInFlight,
+		BatchQueryPanelTimeoutsTotal,
+	}
+
+	for _, collector := range collectors {
+		err := prom.Register(collector)
+		var alreadyRegisterErr prometheus.AlreadyRegisteredError
+		if errors.As(err, &alreadyRegisterErr) {
+			if alreadyRegisterErr.ExistingCollector == alreadyRegisterErr.NewCollector {
+				err = nil
+			}
+		}
+		if err != nil {
+			return err
 		}
 	}
 
-	return err
+	return nil
 }
 
 func (s *Service) Run(ctx context.Context) error {
@@ -8,6 +8,93 @@ const (
 	namespace = "grafana"
 )
 
+// Query limiter, rate limiter, bot detection and variable options cache metrics are shared by the public dashboard service, they are registered together with Metrics
+var (
+	QueryQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "public_dashboards_query_queue_depth",
+		Help:      "Number of public dashboard queries waiting for an execution slot",
+	})
+
+	QueriesInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "public_dashboards_queries_in_flight",
+		Help:      "Number of public dashboard queries currently executing",
+	})
+
+	QueriesShedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "public_dashboards_queries_shed_total",
+		Help:      "Total amount of public dashboard queries rejected by the query limiter",
+	}, []string{"reason"})
+
+	VariableOptionsCacheRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "public_dashboards_variable_options_cache_requests_total",
+		Help:      "Total amount of public dashboard variable options looked up in the cache, by hit or miss",
+	}, []string{"result"})
+
+	AccessTokenCacheRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "public_dashboards_access_token_cache_requests_total",
+		Help:      "Total amount of public dashboards looked up by access token in the cache, by hit or miss",
+	}, []string{"result"})
+
+	RequestsRateLimitedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "public_dashboards_requests_rate_limited_total",
+		Help:      "Total amount of public dashboard requests rejected by the rate limiter, by the limit that was reached",
+	}, []string{"dimension"})
+
+	BotDetectionSignalsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "public_dashboards_bot_detection_signals_total",
+		Help:      "Total amount of bot signals detected in public dashboard requests, by signal",
+	}, []string{"signal"})
+
+	BotDetectionRequestsRejectedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "public_dashboards_bot_detection_requests_rejected_total",
+		Help:      "Total amount of public dashboard requests rejected by the bot detection, by whether the client was throttled or blocked",
+	}, []string{"action"})
+
+	BotDetectionClientsBlockedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "public_dashboards_bot_detection_clients_blocked_total",
+		Help:      "Total amount of times a client IP was temporarily blocked by the bot detection",
+	})
+
+	OrphanedFound = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "public_dashboards_orphaned_found",
+		Help:      "Number of public dashboards whose dashboards were deleted, found by the last orphaned cleanup",
+	})
+
+	OrphanedDeletedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "public_dashboards_orphaned_deleted_total",
+		Help:      "Total amount of public dashboards deleted by the orphaned cleanup because their dashboards were deleted",
+	})
+
+	ReadReplicaFallbacksTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "public_dashboards_read_replica_fallbacks_total",
+		Help:      "Total amount of public dashboard reads that fell back to the primary database because the read replica failed or didn't have the data yet, by read",
+	}, []string{"read"})
+
+	BatchQueryPanelsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "public_dashboards_batch_query_panels_in_flight",
+		Help:      "Number of panels of public dashboard batch queries currently queried",
+	})
+
+	BatchQueryPanelTimeoutsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "public_dashboards_batch_query_panel_timeouts_total",
+		Help:      "Total amount of panels of public dashboard batch queries that timed out, waiting for a slot or querying",
+	})
+)
+
 type Metrics struct {
 	PublicDashboardsAmount *prometheus.GaugeVec
 }
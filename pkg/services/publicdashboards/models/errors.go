@@ -3,7 +3,8 @@ package models
 import "github.com/grafana/grafana/pkg/apimachinery/errutil"
 
 var (
-	ErrInternalServerError = errutil.Internal("publicdashboards.internalServerError", errutil.WithPublicMessage("Internal server error"))
+	ErrInternalServerError           = errutil.Internal("publicdashboards.internalServerError", errutil.WithPublicMessage("Internal server error"))
+	ErrPublicDatasourceMisconfigured = errutil.Internal("publicdashboards.publicDatasourceMisconfigured", errutil.WithPublicMessage("Data source is not configured correctly for public dashboards"))
 
 	ErrPublicDashboardNotFound = errutil.NotFound("publicdashboards.notFound", errutil.WithPublicMessage("Dashboard not found"))
 	ErrDashboardNotFound       = errutil.NotFound("publicdashboards.dashboardNotFound", errutil.WithPublicMessage("Dashboard not found"))
@@ -12,6 +13,7 @@ var (
 
 	ErrBadRequest                          = errutil.BadRequest("publicdashboards.badRequest")
 	ErrPanelQueriesNotFound                = errutil.BadRequest("publicdashboards.panelQueriesNotFound", errutil.WithPublicMessage("Failed to extract queries from panel"))
+	ErrPanelQueryFailed                    = errutil.BadRequest("publicdashboards.panelQueryFailed", errutil.WithPublicMessage("Panel query failed"))
 	ErrInvalidAccessToken                  = errutil.BadRequest("publicdashboards.invalidAccessToken", errutil.WithPublicMessage("Invalid access token"))
 	ErrInvalidPanelId                      = errutil.BadRequest("publicdashboards.invalidPanelId", errutil.WithPublicMessage("Invalid panel id"))
 	ErrInvalidUid                          = errutil.BadRequest("publicdashboards.invalidUid", errutil.WithPublicMessage("Invalid Uid"))
@@ -21,9 +23,60 @@ var (
 	ErrInvalidMaxDataPoints                = errutil.BadRequest("publicdashboards.maxDataPoints", errutil.WithPublicMessage("maxDataPoints should be greater than 0"))
 	ErrInvalidTimeRange                    = errutil.BadRequest("publicdashboards.invalidTimeRange", errutil.WithPublicMessage("Invalid time range"))
 	ErrInvalidShareType                    = errutil.BadRequest("publicdashboards.invalidShareType", errutil.WithPublicMessage("Invalid share type"))
+	ErrInvalidQueryCachingMode             = errutil.BadRequest("publicdashboards.invalidQueryCachingMode", errutil.WithPublicMessage("Invalid query caching mode"))
+	ErrInvalidExportLocale                 = errutil.BadRequest("publicdashboards.invalidExportLocale", errutil.WithPublicMessage("Invalid export locale"))
+	ErrInvalidGeoRestriction               = errutil.BadRequest("publicdashboards.invalidGeoRestriction", errutil.WithPublicMessage("Invalid geo restriction"))
+	ErrInvalidAllowedDomains               = errutil.BadRequest("publicdashboards.invalidAllowedDomains", errutil.WithPublicMessage("Invalid allowed domains"))
+	ErrInvalidVariableConstraint           = errutil.BadRequest("publicdashboards.invalidVariableConstraint", errutil.WithPublicMessage("Invalid variable constraint"))
+	ErrInvalidVariableOverridesAllowed     = errutil.BadRequest("publicdashboards.invalidVariableOverridesAllowed", errutil.WithPublicMessage("Invalid variable overrides allowlist"))
+	ErrInvalidPinnedVariables              = errutil.BadRequest("publicdashboards.invalidPinnedVariables", errutil.WithPublicMessage("Invalid pinned variables"))
+	ErrInvalidVariableDefaults             = errutil.BadRequest("publicdashboards.invalidVariableDefaults", errutil.WithPublicMessage("Invalid variable defaults"))
+	ErrInvalidViewerSession                = errutil.BadRequest("publicdashboards.invalidViewerSession", errutil.WithPublicMessage("Invalid viewer session"))
+	ErrInvalidVariableValue                = errutil.BadRequest("publicdashboards.invalidVariableValue", errutil.WithPublicMessage("Invalid variable value"))
+	ErrInvalidAdhocFilter                  = errutil.BadRequest("publicdashboards.invalidAdhocFilter", errutil.WithPublicMessage("Invalid ad hoc filter"))
+	ErrInvalidPagination                   = errutil.BadRequest("publicdashboards.invalidPagination", errutil.WithPublicMessage("Invalid limit or page"))
+	ErrInvalidListSort                     = errutil.BadRequest("publicdashboards.invalidListSort", errutil.WithPublicMessage("Invalid sort or direction"))
+	ErrInvalidBatchQueryPanels             = errutil.BadRequest("publicdashboards.invalidBatchQueryPanels", errutil.WithPublicMessage("Invalid panels of batch query"))
+	ErrInvalidRenderOptions                = errutil.BadRequest("publicdashboards.invalidRenderOptions", errutil.WithPublicMessage("Invalid width, height or theme"))
+	ErrInvalidGracePeriod                  = errutil.BadRequest("publicdashboards.invalidGracePeriod", errutil.WithPublicMessage("Invalid grace period of the previous access token"))
+	ErrInvalidSlug                         = errutil.BadRequest("publicdashboards.invalidSlug", errutil.WithPublicMessage("Invalid slug"))
+	ErrInvalidPatch                        = errutil.BadRequest("publicdashboards.invalidPatch", errutil.WithPublicMessage("Invalid patch of public dashboard"))
+	ErrInvalidExpiresAt                    = errutil.BadRequest("publicdashboards.invalidExpiresAt", errutil.WithPublicMessage("Invalid expiration time"))
+	ErrInvalidMaxConcurrentViewers         = errutil.BadRequest("publicdashboards.invalidMaxConcurrentViewers", errutil.WithPublicMessage("Invalid maximum number of concurrent viewers"))
+	ErrInvalidHiddenPanelIds               = errutil.BadRequest("publicdashboards.invalidHiddenPanelIds", errutil.WithPublicMessage("Invalid hidden panel ids"))
+	ErrInvalidAnnotationSettings           = errutil.BadRequest("publicdashboards.invalidAnnotationSettings", errutil.WithPublicMessage("Invalid annotation settings"))
+	ErrQueryOverrideNotAllowed             = errutil.BadRequest("publicdashboards.queryOverrideNotAllowed", errutil.WithPublicMessage("Query override not allowed"))
 	ErrDashboardIsPublic                   = errutil.BadRequest("publicdashboards.dashboardIsPublic", errutil.WithPublicMessage("Dashboard is already public"))
 	ErrPublicDashboardUidExists            = errutil.BadRequest("publicdashboards.uidExists", errutil.WithPublicMessage("Dashboard Uid already exists"))
 	ErrPublicDashboardAccessTokenExists    = errutil.BadRequest("publicdashboards.accessTokenExists", errutil.WithPublicMessage("Dashboard Access Token already exists"))
+	ErrPublicDashboardSlugExists           = errutil.BadRequest("publicdashboards.slugExists", errutil.WithPublicMessage("Slug is already used by another public dashboard"))
 
-	ErrPublicDashboardNotEnabled = errutil.Forbidden("publicdashboards.notEnabled", errutil.WithPublicMessage("Dashboard paused"))
+	ErrViewerChallengeRequired = errutil.Forbidden("publicdashboards.viewerChallengeRequired", errutil.WithPublicMessage("Viewer challenge required"))
+	ErrViewerChallengeFailed   = errutil.Forbidden("publicdashboards.viewerChallengeFailed", errutil.WithPublicMessage("Viewer challenge failed"))
+
+	ErrLoosenConstraintsForbidden  = errutil.Forbidden("publicdashboards.loosenConstraintsForbidden", errutil.WithPublicMessage("You are not allowed to loosen the constraints of this public dashboard"))
+	ErrFolderNotShareable          = errutil.Forbidden("publicdashboards.folderNotShareable", errutil.WithPublicMessage("Dashboards in this folder can't be shared publicly"))
+	ErrPublicDashboardQuotaReached = errutil.Forbidden("publicdashboards.quotaReached", errutil.WithPublicMessage("Quota reached"))
+
+	ErrInvalidViewerToken = errutil.Unauthorized("publicdashboards.invalidViewerToken", errutil.WithPublicMessage("Invalid or expired viewer token"))
+
+	ErrPublicDashboardNotEnabled       = errutil.Forbidden("publicdashboards.notEnabled", errutil.WithPublicMessage("Dashboard paused"))
+	ErrPublicDashboardGeoRestricted    = errutil.Forbidden("publicdashboards.geoRestricted", errutil.WithPublicMessage("This dashboard is not available in your country or region"))
+	ErrPublicDashboardDomainRestricted = errutil.Forbidden("publicdashboards.domainRestricted", errutil.WithPublicMessage("This dashboard can't be embedded in this site"))
+
+	ErrAccessTokenRotationConflict = errutil.Conflict("publicdashboards.accessTokenRotationConflict", errutil.WithPublicMessage("Access token was changed concurrently, please try again"))
+
+	ErrPublicDashboardExpired = errutil.Gone("publicdashboards.expired", errutil.WithPublicMessage("Dashboard expired"))
+
+	ErrPanelQueryTimeout = errutil.Timeout("publicdashboards.panelQueryTimeout", errutil.WithPublicMessage("Panel query timed out"))
+
+	ErrQueryShed          = errutil.TooManyRequests("publicdashboards.queryShed", errutil.WithPublicMessage("Too many requests, please try again later"))
+	ErrRenderRateLimited  = errutil.TooManyRequests("publicdashboards.renderRateLimited", errutil.WithPublicMessage("Too many renders of this dashboard, please try again later"))
+	ErrRateLimited        = errutil.TooManyRequests("publicdashboards.rateLimited", errutil.WithPublicMessage("Too many requests, please try again later"))
+	ErrClientBlocked      = errutil.TooManyRequests("publicdashboards.clientBlocked", errutil.WithPublicMessage("Too many suspicious requests, please try again later"))
+	ErrViewerLimitReached = errutil.TooManyRequests("publicdashboards.viewerLimitReached", errutil.WithPublicMessage("This dashboard has reached its maximum number of viewers, please try again in a moment"))
+
+	ErrPDFExportTooLarge = errutil.UnprocessableEntity("publicdashboards.pdfExportTooLarge", errutil.WithPublicMessage("Dashboard is too large to export as PDF"))
+	ErrRenderUnavailable = errutil.NotImplemented("publicdashboards.renderUnavailable", errutil.WithPublicMessage("Rendering is not available"))
+	ErrRenderFailed      = errutil.Internal("publicdashboards.renderFailed", errutil.WithPublicMessage("Failed to render the dashboard"))
 )
@@ -1,10 +1,21 @@
 package models
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/kinds/dashboard"
+	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/user"
 )
 
@@ -31,13 +42,66 @@ const (
 	FeaturePublicDashboardsEmailSharing           = "publicDashboardsEmailSharing"
 )
 
+const (
+	QuotaTargetSrv quota.TargetSrv = "public_dashboard"
+	QuotaTarget    quota.Target    = "public_dashboard"
+)
+
+const (
+	// QueryCachingModeNormal honours the cache directives sent along with the request
+	QueryCachingModeNormal QueryCachingMode = "normal"
+	// QueryCachingModeForce always reads from the query cache, ignoring any client request to skip it
+	QueryCachingModeForce QueryCachingMode = "force"
+	// QueryCachingModeBypass never reads from the query cache
+	QueryCachingModeBypass QueryCachingMode = "bypass"
+)
+
+const (
+	// GeoRestrictionModeAllow only allows access from the listed countries
+	GeoRestrictionModeAllow GeoRestrictionMode = "allow"
+	// GeoRestrictionModeDeny blocks access from the listed countries
+	GeoRestrictionModeDeny GeoRestrictionMode = "deny"
+)
+
+const (
+	// PublicDashboardListSortTitle sorts public dashboards by the title of their dashboard
+	PublicDashboardListSortTitle PublicDashboardListSort = "title"
+	// PublicDashboardListSortCreated sorts public dashboards by when they were created
+	PublicDashboardListSortCreated PublicDashboardListSort = "created"
+	// PublicDashboardListSortUpdated sorts public dashboards by when they were last updated
+	PublicDashboardListSortUpdated PublicDashboardListSort = "updated"
+	// PublicDashboardListSortLastViewed sorts public dashboards by when they were last viewed
+	PublicDashboardListSortLastViewed PublicDashboardListSort = "lastViewed"
+	// PublicDashboardListSortViews sorts public dashboards by their number of views
+	PublicDashboardListSortViews PublicDashboardListSort = "views"
+
+	SortDirectionAsc  SortDirection = "asc"
+	SortDirectionDesc SortDirection = "desc"
+)
+
 var (
-	QueryResultStatuses = []string{QuerySuccess, QueryFailure}
-	ValidShareTypes     = []ShareType{EmailShareType, PublicShareType}
+	QueryResultStatuses      = []string{QuerySuccess, QueryFailure}
+	ValidShareTypes          = []ShareType{EmailShareType, PublicShareType}
+	ValidQueryCachingModes   = []QueryCachingMode{QueryCachingModeNormal, QueryCachingModeForce, QueryCachingModeBypass}
+	ValidGeoRestrictionModes = []GeoRestrictionMode{GeoRestrictionModeAllow, GeoRestrictionModeDeny}
+	ValidListSorts           = []PublicDashboardListSort{PublicDashboardListSortTitle, PublicDashboardListSortCreated, PublicDashboardListSortUpdated, PublicDashboardListSortLastViewed, PublicDashboardListSortViews}
+	ValidSortDirections      = []SortDirection{SortDirectionAsc, SortDirectionDesc}
 )
 
 type ShareType string
 
+// QueryCachingMode controls how queries executed for a public dashboard interact with the query cache
+type QueryCachingMode string
+
+// GeoRestrictionMode controls whether the countries of a geo restriction are allowed or blocked
+type GeoRestrictionMode string
+
+// PublicDashboardListSort is the field the list of public dashboards is sorted by
+type PublicDashboardListSort string
+
+// SortDirection is the direction the list of public dashboards is sorted in
+type SortDirection string
+
 type PublicDashboard struct {
 	Uid          string    `json:"uid" xorm:"pk uid"`
 	DashboardUid string    `json:"dashboardUid" xorm:"dashboard_uid"`
@@ -47,22 +111,91 @@ type PublicDashboard struct {
 	UpdatedBy    int64     `json:"updatedBy" xorm:"updated_by"`
 	CreatedAt    time.Time `json:"createdAt" xorm:"created_at"`
 	UpdatedAt    time.Time `json:"updatedAt" xorm:"updated_at"`
+	// LastAccessedAt is updated at most once per hour when the public dashboard is viewed
+	LastAccessedAt time.Time `json:"lastAccessedAt" xorm:"last_accessed_at"`
+	// PreviousAccessToken keeps working until PreviousAccessTokenExpiresAt after the access token was rotated
+	PreviousAccessToken          string    `json:"-" xorm:"previous_access_token"`
+	PreviousAccessTokenExpiresAt time.Time `json:"-" xorm:"previous_access_token_expires_at"`
+	// AccessTokenCreatedAt is when the access token was created or rotated, zero for access tokens created before it
+	// was tracked, whose age starts at CreatedAt
+	AccessTokenCreatedAt time.Time `json:"accessTokenCreatedAt" xorm:"access_token_created_at"`
+	// AccessTokenRotationDue is set once the access token is older than the max age of the org, until it's rotated
+	AccessTokenRotationDue bool `json:"accessTokenRotationDue" xorm:"access_token_rotation_due"`
+	// Slug is a human-friendly alternative to the access token in public dashboard URLs, unique across orgs
+	Slug string `json:"slug,omitempty" xorm:"slug"`
+	// ExpiresAt is when the public dashboard stops being viewable and gets disabled, zero if it never expires
+	ExpiresAt time.Time `json:"expiresAt" xorm:"expires_at"`
+	// PanelId limits the public dashboard to a single panel, zero shares the whole dashboard
+	PanelId int64 `json:"panelId" xorm:"panel_id"`
+	// MaxConcurrentViewers limits how many viewers can watch the public dashboard at once, zero doesn't limit them
+	MaxConcurrentViewers int64 `json:"maxConcurrentViewers" xorm:"max_concurrent_viewers"`
+	// HiddenPanelIds are the panels of the dashboard viewers can't access, along with their repeated instances
+	HiddenPanelIds []int64 `json:"hiddenPanelIds,omitempty" xorm:"hidden_panel_ids"`
 	//config fields
-	TimeSettings         *TimeSettings `json:"-" xorm:"time_settings"`
-	TimeSelectionEnabled bool          `json:"timeSelectionEnabled" xorm:"time_selection_enabled"`
-	IsEnabled            bool          `json:"isEnabled" xorm:"is_enabled"`
-	AnnotationsEnabled   bool          `json:"annotationsEnabled" xorm:"annotations_enabled"`
-	Share                ShareType     `json:"share" xorm:"share"`
-	Recipients           []EmailDTO    `json:"recipients,omitempty" xorm:"-"`
+	TimeSettings         *TimeSettings    `json:"-" xorm:"time_settings"`
+	TimeSelectionEnabled bool             `json:"timeSelectionEnabled" xorm:"time_selection_enabled"`
+	IsEnabled            bool             `json:"isEnabled" xorm:"is_enabled"`
+	AnnotationsEnabled   bool             `json:"annotationsEnabled" xorm:"annotations_enabled"`
+	Share                ShareType        `json:"share" xorm:"share"`
+	QueryCachingMode     QueryCachingMode `json:"queryCachingMode" xorm:"query_caching_mode"`
+	ExportLocale         string           `json:"exportLocale" xorm:"export_locale"`
+	GeoRestriction       *GeoRestriction  `json:"geoRestriction,omitempty" xorm:"geo_restriction"`
+	// AnnotationSettings limits the annotations shared when annotations are enabled, nil shares every annotation
+	AnnotationSettings *AnnotationSettings `json:"annotationSettings,omitempty" xorm:"annotation_settings"`
+	// AllowedDomains limits the sites embedding the public dashboard, nil allows every site
+	AllowedDomains []string `json:"allowedDomains,omitempty" xorm:"allowed_domains"`
+	// VariableConstraints limits the values viewers can type in text box variables
+	VariableConstraints VariableConstraints `json:"variableConstraints,omitempty" xorm:"variable_constraints"`
+	// VariablesEnabled allows viewers to change variables, limited by VariableOverridesAllowed. Variables keep the
+	// values of the dashboard otherwise. Nil allows it, like for public dashboards saved before it could be disabled
+	VariablesEnabled *bool `json:"variablesEnabled" xorm:"variables_enabled"`
+	// VariableOverridesAllowed lists the variables viewers can change, nil allows every variable
+	VariableOverridesAllowed []string `json:"variableOverridesAllowed" xorm:"variable_overrides_allowed"`
+	// PinnedVariables are variable values set by the owner, used whatever viewers send
+	PinnedVariables PinnedVariables `json:"pinnedVariables,omitempty" xorm:"pinned_variables"`
+	// VariableDefaults are the variable values viewers start with, instead of the values saved in the dashboard
+	VariableDefaults VariableDefaults `json:"variableDefaults,omitempty" xorm:"variable_defaults"`
+	// VariableSnapshot is the state of the variables when the public dashboard was last saved, served to viewers as
+	// the initial state and used when variable queries fail
+	VariableSnapshot VariableSnapshot `json:"variableSnapshot,omitempty" xorm:"variable_snapshot"`
+	Recipients       []EmailDTO       `json:"recipients,omitempty" xorm:"-"`
 }
 
 type PublicDashboardDTO struct {
-	Uid                  string    `json:"uid"`
-	AccessToken          string    `json:"accessToken"`
-	TimeSelectionEnabled *bool     `json:"timeSelectionEnabled"`
-	IsEnabled            *bool     `json:"isEnabled"`
-	AnnotationsEnabled   *bool     `json:"annotationsEnabled"`
-	Share                ShareType `json:"share"`
+	Uid                  string           `json:"uid"`
+	AccessToken          string           `json:"accessToken"`
+	TimeSelectionEnabled *bool            `json:"timeSelectionEnabled"`
+	IsEnabled            *bool            `json:"isEnabled"`
+	AnnotationsEnabled   *bool            `json:"annotationsEnabled"`
+	Share                ShareType        `json:"share"`
+	QueryCachingMode     QueryCachingMode `json:"queryCachingMode"`
+	ExportLocale         string           `json:"exportLocale"`
+	GeoRestriction       *GeoRestriction  `json:"geoRestriction"`
+	// AnnotationSettings replaces the annotation settings when set, an empty object shares every annotation again
+	AnnotationSettings *AnnotationSettings `json:"annotationSettings"`
+	// AllowedDomains replaces the domains of the sites embedding the public dashboard when set, an empty list removes
+	// the restriction
+	AllowedDomains []string `json:"allowedDomains"`
+	// VariableConstraints replaces the constraints of the text box variables when set, an empty object removes them
+	VariableConstraints VariableConstraints `json:"variableConstraints"`
+	// VariablesEnabled replaces whether viewers can change variables when set, new public dashboards allow it
+	VariablesEnabled *bool `json:"variablesEnabled"`
+	// VariableOverridesAllowed replaces the variables viewers can change when set, an empty list doesn't allow any
+	VariableOverridesAllowed []string `json:"variableOverridesAllowed"`
+	// PinnedVariables replaces the pinned variable values when set, an empty object removes them
+	PinnedVariables PinnedVariables `json:"pinnedVariables"`
+	// VariableDefaults replaces the default variable values when set, an empty object removes them
+	VariableDefaults VariableDefaults `json:"variableDefaults"`
+	// Slug replaces the slug when set, an empty string removes it
+	Slug *string `json:"slug"`
+	// ExpiresAt replaces the expiration time when set, the zero time removes it
+	ExpiresAt *time.Time `json:"expiresAt"`
+	// PanelId replaces the shared panel when set, zero shares the whole dashboard
+	PanelId *int64 `json:"panelId"`
+	// MaxConcurrentViewers replaces the limit of concurrent viewers when set, zero removes it
+	MaxConcurrentViewers *int64 `json:"maxConcurrentViewers"`
+	// HiddenPanelIds replaces the hidden panels when set, an empty list shows every panel again
+	HiddenPanelIds []int64 `json:"hiddenPanelIds"`
 }
 
 type EmailDTO struct {
@@ -80,7 +213,8 @@ type AnnotationsDto struct {
 }
 
 type AnnotationEvent struct {
-	Id           int64                     `json:"id"`
+	Id int64 `json:"id"`
+	// Deprecated: use DashboardUID instead. Only set for annotations of the public dashboard itself
 	DashboardId  int64                     `json:"dashboardId"`
 	DashboardUID string                    `json:"dashboardUID"`
 	PanelId      int64                     `json:"panelId"`
@@ -97,13 +231,88 @@ func (pd PublicDashboard) TableName() string {
 	return "dashboard_public"
 }
 
+// IsExpired reports whether the public dashboard has expired at the given time
+func (pd PublicDashboard) IsExpired(now time.Time) bool {
+	return !pd.ExpiresAt.IsZero() && !now.Before(pd.ExpiresAt)
+}
+
+// IsPanelShare reports whether a single panel of the dashboard is shared rather than the whole dashboard
+func (pd PublicDashboard) IsPanelShare() bool {
+	return pd.PanelId != 0
+}
+
+// HidesPanel reports whether the panel is one of the hidden panels of the public dashboard. Instances of hidden
+// repeated panels aren't listed, see sharesPanel in the service
+func (pd PublicDashboard) HidesPanel(panelId int64) bool {
+	return slices.Contains(pd.HiddenPanelIds, panelId)
+}
+
+// AllowsDomain reports whether a site of the given host name can embed the public dashboard. Domains starting with
+// "*." allow their subdomains
+func (pd PublicDashboard) AllowsDomain(host string) bool {
+	if pd.AllowedDomains == nil {
+		return true
+	}
+
+	host = strings.ToLower(host)
+	for _, domain := range pd.AllowedDomains {
+		if parent, ok := strings.CutPrefix(domain, "*."); ok {
+			if strings.HasSuffix(host, "."+parent) {
+				return true
+			}
+			continue
+		}
+		if host == domain {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsVariables reports whether viewers can change variables at all
+func (pd PublicDashboard) AllowsVariables() bool {
+	return pd.VariablesEnabled == nil || *pd.VariablesEnabled
+}
+
+// AllowsVariableOverride reports whether viewers can change the value of the variable
+func (pd PublicDashboard) AllowsVariableOverride(name string) bool {
+	if !pd.AllowsVariables() {
+		return false
+	}
+	if _, ok := pd.PinnedVariables[name]; ok {
+		return false
+	}
+	if pd.VariableOverridesAllowed == nil {
+		return true
+	}
+	for _, allowed := range pd.VariableOverridesAllowed {
+		if allowed == name {
+			return true
+		}
+	}
+	return false
+}
+
 type PublicDashboardListQuery struct {
-	OrgID  int64
-	Query  string
-	Page   int
-	Limit  int
-	Offset int
-	User   *user.SignedInUser
+	OrgID int64
+	// Query filters by a substring of the dashboard title
+	Query     string
+	Page      int
+	Limit     int
+	Offset    int
+	Sort      PublicDashboardListSort
+	Direction SortDirection
+	// IsEnabled, AnnotationsEnabled, TimeSelectionEnabled and VariablesEnabled filter by the config of the public
+	// dashboard when set
+	IsEnabled            *bool
+	AnnotationsEnabled   *bool
+	TimeSelectionEnabled *bool
+	VariablesEnabled     *bool
+	// Share filters by the share type when set
+	Share ShareType
+	// FolderUID filters by the folder of the dashboard
+	FolderUID string
+	User      *user.SignedInUser
 }
 
 type PublicDashboardListResponseWithPagination struct {
@@ -111,6 +320,20 @@ type PublicDashboardListResponseWithPagination struct {
 	TotalCount       int64                          `json:"totalCount"`
 	Page             int                            `json:"page"`
 	PerPage          int                            `json:"perPage"`
+	// Quota is the usage and limit of the public dashboards quota, it's only set when quotas are enabled
+	Quota *PublicDashboardQuota `json:"quota,omitempty"`
+}
+
+// PublicDashboardQuota is the usage and limit of the public dashboards quota of the org and of the signed in user, a
+// limit of -1 is unlimited
+type PublicDashboardQuota struct {
+	Org  QuotaUsage `json:"org"`
+	User QuotaUsage `json:"user"`
+}
+
+type QuotaUsage struct {
+	Used  int64 `json:"used"`
+	Limit int64 `json:"limit"`
 }
 
 type PublicDashboardListResponse struct {
@@ -120,6 +343,55 @@ type PublicDashboardListResponse struct {
 	DashboardUid string `json:"dashboardUid" xorm:"dashboard_uid"`
 	IsEnabled    bool   `json:"isEnabled" xorm:"is_enabled"`
 	Slug         string `json:"slug" xorm:"slug"`
+	// AnnotationsEnabled, TimeSelectionEnabled, VariablesEnabled and Share are the config the list can be filtered by
+	AnnotationsEnabled   bool      `json:"annotationsEnabled" xorm:"annotations_enabled"`
+	TimeSelectionEnabled bool      `json:"timeSelectionEnabled" xorm:"time_selection_enabled"`
+	VariablesEnabled     bool      `json:"variablesEnabled" xorm:"variables_enabled"`
+	Share                ShareType `json:"share" xorm:"share"`
+	// CreatedAt, UpdatedAt and LastAccessedAt are the fields the list can be sorted by, besides the title
+	CreatedAt      time.Time `json:"createdAt" xorm:"created_at"`
+	UpdatedAt      time.Time `json:"updatedAt" xorm:"updated_at"`
+	LastAccessedAt time.Time `json:"lastAccessedAt" xorm:"last_accessed_at"`
+	// ExpiresAt is zero when the public dashboard never expires
+	ExpiresAt time.Time `json:"expiresAt" xorm:"expires_at"`
+	// PanelId is zero when the whole dashboard is shared
+	PanelId int64 `json:"panelId" xorm:"panel_id"`
+	// Views and Queries count the views and queries of the public dashboard, recorded in batches
+	Views   int64 `json:"views" xorm:"views"`
+	Queries int64 `json:"queries" xorm:"queries"`
+}
+
+// AdminPublicDashboardListQuery lists the public dashboards of every org, for Grafana admins to audit the instance
+type AdminPublicDashboardListQuery struct {
+	// OrgID and IsEnabled filter the public dashboards when set
+	OrgID     int64
+	IsEnabled *bool
+	Page      int
+	Limit     int
+	Offset    int
+}
+
+type AdminPublicDashboardListResponseWithPagination struct {
+	PublicDashboards []*AdminPublicDashboardListResponse `json:"publicDashboards"`
+	TotalCount       int64                               `json:"totalCount"`
+	Page             int                                 `json:"page"`
+	PerPage          int                                 `json:"perPage"`
+}
+
+type AdminPublicDashboardListResponse struct {
+	Uid          string `json:"uid" xorm:"uid"`
+	OrgId        int64  `json:"orgId" xorm:"org_id"`
+	OrgName      string `json:"orgName" xorm:"org_name"`
+	DashboardUid string `json:"dashboardUid" xorm:"dashboard_uid"`
+	// Title is empty when the dashboard was deleted
+	Title     string    `json:"title" xorm:"-"`
+	IsEnabled bool      `json:"isEnabled" xorm:"is_enabled"`
+	Share     ShareType `json:"share" xorm:"share"`
+	CreatedBy int64     `json:"createdBy" xorm:"created_by"`
+	CreatedAt time.Time `json:"createdAt" xorm:"created_at"`
+	// LastViewedAt is the last access of the public dashboard, zero until it's first viewed
+	LastViewedAt time.Time `json:"lastViewedAt" xorm:"last_viewed_at"`
+	Views        int64     `json:"views" xorm:"views"`
 }
 
 type TimeSettings struct {
@@ -135,6 +407,141 @@ func (ts *TimeSettings) ToDB() ([]byte, error) {
 	return json.Marshal(ts)
 }
 
+// GeoRestriction limits the countries a public dashboard can be accessed from. Countries are ISO 3166-1 alpha-2 codes
+type GeoRestriction struct {
+	Mode      GeoRestrictionMode `json:"mode"`
+	Countries []string           `json:"countries"`
+}
+
+func (gr *GeoRestriction) FromDB(data []byte) error {
+	return json.Unmarshal(data, gr)
+}
+
+func (gr *GeoRestriction) ToDB() ([]byte, error) {
+	return json.Marshal(gr)
+}
+
+// Allows reports whether a client from the given country can access the public dashboard. An unknown country, passed
+// as an empty string, is only allowed when the restriction blocks specific countries
+func (gr *GeoRestriction) Allows(country string) bool {
+	if gr == nil {
+		return true
+	}
+
+	listed := false
+	for _, c := range gr.Countries {
+		if strings.EqualFold(c, country) {
+			listed = true
+			break
+		}
+	}
+
+	switch gr.Mode {
+	case GeoRestrictionModeAllow:
+		return country != "" && listed
+	case GeoRestrictionModeDeny:
+		return !listed
+	default:
+		return true
+	}
+}
+
+// AnnotationSettings hides annotations of the dashboard from the viewers of a public dashboard. HiddenQueries are the
+// names of the annotation queries that aren't shared, HiddenPanelIds the panels whose annotations aren't shared
+type AnnotationSettings struct {
+	HiddenQueries  []string `json:"hiddenQueries,omitempty"`
+	HiddenPanelIds []int64  `json:"hiddenPanelIds,omitempty"`
+}
+
+func (as *AnnotationSettings) FromDB(data []byte) error {
+	return json.Unmarshal(data, as)
+}
+
+func (as *AnnotationSettings) ToDB() ([]byte, error) {
+	return json.Marshal(as)
+}
+
+// HidesQuery reports whether the annotations of the annotation query with the given name aren't shared
+func (as *AnnotationSettings) HidesQuery(name string) bool {
+	return as != nil && slices.Contains(as.HiddenQueries, name)
+}
+
+// HidesPanel reports whether the annotations of the panel aren't shared
+func (as *AnnotationSettings) HidesPanel(panelId int64) bool {
+	return as != nil && slices.Contains(as.HiddenPanelIds, panelId)
+}
+
+// MaxTextboxVariableLength is the longest value a viewer can type in a text box variable, and the default max length
+// of variables without constraint
+const MaxTextboxVariableLength = 256
+
+// VariableConstraints are the constraints of the text box variables of a public dashboard, by variable name
+type VariableConstraints map[string]VariableConstraint
+
+// VariableConstraint limits the values of a text box variable. Pattern is a regular expression values must match
+// entirely, an empty pattern accepts any value. A MaxLength of 0 uses the default limit
+type VariableConstraint struct {
+	Pattern   string `json:"pattern,omitempty"`
+	MaxLength int    `json:"maxLength,omitempty"`
+}
+
+func (vc *VariableConstraints) FromDB(data []byte) error {
+	return json.Unmarshal(data, vc)
+}
+
+func (vc *VariableConstraints) ToDB() ([]byte, error) {
+	return json.Marshal(vc)
+}
+
+// PinnedVariables are variable values pinned by the owner of a public dashboard, by variable name. Values are a string
+// or a list of strings for multi value variables
+type PinnedVariables map[string]interface{}
+
+func (pv *PinnedVariables) FromDB(data []byte) error {
+	return json.Unmarshal(data, pv)
+}
+
+func (pv *PinnedVariables) ToDB() ([]byte, error) {
+	return json.Marshal(pv)
+}
+
+// VariableDefaults are the values viewers of a public dashboard start with, by variable name. Values are a string or a
+// list of strings for multi value variables
+type VariableDefaults map[string]interface{}
+
+func (vd *VariableDefaults) FromDB(data []byte) error {
+	return json.Unmarshal(data, vd)
+}
+
+func (vd *VariableDefaults) ToDB() ([]byte, error) {
+	return json.Marshal(vd)
+}
+
+// VariableSnapshot is the state of the variables of a public dashboard, by variable name
+type VariableSnapshot map[string]VariableSnapshotEntry
+
+// VariableSnapshotEntry holds the options and the current value of a variable, and when the options of query
+// variables were queried
+type VariableSnapshotEntry struct {
+	Options     []MetricFindValue     `json:"options"`
+	Current     VariableSnapshotValue `json:"current"`
+	RefreshedAt time.Time             `json:"refreshedAt,omitempty"`
+}
+
+// VariableSnapshotValue is the current value of a variable, text and value are lists for multi value variables
+type VariableSnapshotValue struct {
+	Text  interface{} `json:"text"`
+	Value interface{} `json:"value"`
+}
+
+func (vs *VariableSnapshot) FromDB(data []byte) error {
+	return json.Unmarshal(data, vs)
+}
+
+func (vs *VariableSnapshot) ToDB() ([]byte, error) {
+	return json.Marshal(vs)
+}
+
 // DTO for transforming user input in the api
 type SavePublicDashboardDTO struct {
 	Uid             string
@@ -156,25 +563,441 @@ type PublicDashboardQueryDTO struct {
 	QueryCachingTTL int64
 	TimeRange       TimeRangeDTO
 	Variables       map[string]interface{} `json:"variables,omitempty"`
+	// AdhocFilters are the filters of the ad hoc filters variables, by variable name
+	AdhocFilters map[string][]AdhocFilterDTO `json:"adhocFilters,omitempty"`
+}
+
+// PublicDashboardQueryInspection is the result of running the queries of a panel the way viewers of the public
+// dashboard do, with the metadata of the frames that is removed for viewers, like the executed query strings
+type PublicDashboardQueryInspection struct {
+	// Queries are the queries sent to the datasources, with their variables interpolated
+	Queries  []*simplejson.Json         `json:"queries"`
+	From     string                     `json:"from"`
+	To       string                     `json:"to"`
+	Response *backend.QueryDataResponse `json:"response"`
+}
+
+// PublicDashboardHealth reports whether the datasources of the panels of a public dashboard are healthy when checked
+// with the identity the queries of viewers run with
+type PublicDashboardHealth struct {
+	Healthy     bool               `json:"healthy"`
+	Datasources []DatasourceHealth `json:"datasources"`
+	// FailingPanelIds are the panels with queries of datasources that aren't healthy
+	FailingPanelIds []int64 `json:"failingPanelIds"`
+}
+
+// DatasourceHealth is the result of the health check of a datasource queried by panels of a public dashboard
+type DatasourceHealth struct {
+	Uid  string `json:"uid"`
+	Name string `json:"name,omitempty"`
+	Type string `json:"type,omitempty"`
+	// Status is OK, ERROR or UNKNOWN, for datasources without health check or set by a variable
+	Status   string  `json:"status"`
+	Message  string  `json:"message,omitempty"`
+	PanelIds []int64 `json:"panelIds"`
+}
+
+// MaxConcurrentViewersLimit is the largest limit of concurrent viewers of a public dashboard
+const MaxConcurrentViewersLimit = 10000
+
+// MaxBatchQueryPanels is the largest number of panels a batch query can select
+const MaxBatchQueryPanels = 200
+
+// PublicDashboardBatchQueryDTO queries several panels of a public dashboard with the same time range and variables
+type PublicDashboardBatchQueryDTO struct {
+	PublicDashboardQueryDTO
+	// PanelIds selects the queried panels, either a list of panel ids or "all" for every panel with queries
+	PanelIds BatchQueryPanels `json:"panelIds"`
+}
+
+// BatchQueryPanels selects the panels of a batch query, decoded from a list of panel ids or from "all"
+type BatchQueryPanels struct {
+	All bool
+	Ids []int64
+}
+
+func (p *BatchQueryPanels) UnmarshalJSON(data []byte) error {
+	var all string
+	if err := json.Unmarshal(data, &all); err == nil {
+		if all != "all" {
+			return fmt.Errorf("invalid panelIds %q, expected a list of panel ids or \"all\"", all)
+		}
+		*p = BatchQueryPanels{All: true}
+		return nil
+	}
+
+	*p = BatchQueryPanels{}
+	return json.Unmarshal(data, &p.Ids)
+}
+
+func (p BatchQueryPanels) MarshalJSON() ([]byte, error) {
+	if p.All {
+		return json.Marshal("all")
+	}
+	return json.Marshal(p.Ids)
+}
+
+// PublicDashboardBatchQueryResponse holds the result of each panel of a batch query, by panel id
+type PublicDashboardBatchQueryResponse struct {
+	Panels map[int64]PanelQueryResult `json:"panels"`
+}
+
+// PanelQueryResult is either the query data response of a panel or the error its query failed with
+type PanelQueryResult struct {
+	Response *backend.QueryDataResponse `json:"response,omitempty"`
+	Error    *errutil.PublicError       `json:"error,omitempty"`
+}
+
+// AdhocFilterDTO is a filter of an ad hoc filters variable. Values is only used by the one of operators
+type AdhocFilterDTO struct {
+	Key      string   `json:"key"`
+	Operator string   `json:"operator"`
+	Value    string   `json:"value"`
+	Values   []string `json:"values,omitempty"`
 }
 
 type AnnotationsQueryDTO struct {
 	From int64
 	To   int64
+	// PanelId limits the annotations to the ones shown on the panel, 0 returns the annotations of every panel
+	PanelId int64
+}
+
+// PublicDashboardVariable describes a variable of a public dashboard as viewers get it. Hidden variables aren't listed
+type PublicDashboardVariable struct {
+	Name       string `json:"name"`
+	Label      string `json:"label,omitempty"`
+	Type       string `json:"type"`
+	Multi      bool   `json:"multi"`
+	IncludeAll bool   `json:"includeAll"`
+	// Hide is 1 when the label of the variable is hidden
+	Hide int `json:"hide"`
+	// ReadOnly is set for the variables viewers can't change, because they are pinned or not overridable
+	ReadOnly bool                  `json:"readOnly"`
+	Current  VariableSnapshotValue `json:"current"`
+}
+
+// PublicDashboardMetadata describes a public dashboard for embedders building their own UI, without its full JSON
+type PublicDashboardMetadata struct {
+	Title                string                    `json:"title"`
+	Panels               []PublicDashboardPanel    `json:"panels"`
+	Time                 PublicDashboardTime       `json:"time"`
+	AnnotationsEnabled   bool                      `json:"annotationsEnabled"`
+	TimeSelectionEnabled bool                      `json:"timeSelectionEnabled"`
+	Variables            []PublicDashboardVariable `json:"variables"`
+}
+
+// PublicDashboardPanel describes a panel of a public dashboard. Rows aren't listed, their panels are
+type PublicDashboardPanel struct {
+	Id    int64  `json:"id"`
+	Title string `json:"title"`
+	Type  string `json:"type"`
+}
+
+// PublicDashboardTime is the default time range, timezone and refresh interval of a public dashboard
+type PublicDashboardTime struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Timezone string `json:"timezone,omitempty"`
+	Refresh  string `json:"refresh,omitempty"`
+}
+
+// Actions of the events pushed to the Live channel of a public dashboard
+const (
+	// LiveActionVariablesRefreshed carries the refreshed options of the variables
+	LiveActionVariablesRefreshed = "variables-refreshed"
+	// LiveActionInvalidated tells viewers to load the public dashboard again, it changed or is no longer available
+	LiveActionInvalidated = "invalidated"
+)
+
+// PublicDashboardLiveEvent is an event pushed to the viewers of a public dashboard over Grafana Live. Variables holds
+// the options of each variable, keyed by variable name
+type PublicDashboardLiveEvent struct {
+	Action    string                       `json:"action"`
+	Variables map[string][]MetricFindValue `json:"variables,omitempty"`
 }
 
 // PublicDashboardVariableQueryDTO is the request DTO for querying variable options
 type PublicDashboardVariableQueryDTO struct {
 	Variables    map[string]interface{} `json:"variables,omitempty"`
 	SearchFilter string                 `json:"searchFilter,omitempty"`
+	// TimeRange is the time range of the dashboard, used by variables refreshed on time range change and by macros
+	// like $__timeFilter. It's only taken into account when the time selection is enabled
+	TimeRange TimeRangeDTO `json:"timeRange"`
+	// Limit is the number of options returned, 0 returns every option. Options are paginated after the search filter
+	// is applied, a page shorter than the limit is the last one
+	Limit int `json:"limit,omitempty"`
+	// Page is the page of options returned, starting at 1
+	Page int `json:"page,omitempty"`
 }
 
+// PublicDashboardVariableQueryResponse holds the options of a variable for the viewer
+type PublicDashboardVariableQueryResponse struct {
+	Options []MetricFindValue `json:"options"`
+	// RefreshedAt is when the options were queried from the datasource, the time of the snapshot for variables
+	// refreshed on load. Nil for variables whose options don't come from a query
+	RefreshedAt *time.Time `json:"refreshedAt,omitempty"`
+}
+
+// MaxVariableOptionsLimit is the largest page of variable options a viewer can request
+const MaxVariableOptionsLimit = 1000
+
 // MetricFindValue represents a single option value for template variables
 type MetricFindValue struct {
 	Text  string `json:"text"`
 	Value string `json:"value"`
 }
 
+// PublicDashboardPanelContentDTO is the request DTO for rendering the rich text content of a panel
+type PublicDashboardPanelContentDTO struct {
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+// PanelContent holds the rendered and sanitized rich text of a panel. Description and Content are HTML
+type PanelContent struct {
+	PanelId     int64  `json:"panelId"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Content     string `json:"content,omitempty"`
+}
+
+// PanelExport is the data of a panel, or a rendering of a panel or dashboard, exported as a file. WriteTo streams the
+// file, so large results aren't buffered
+type PanelExport struct {
+	Filename    string
+	ContentType string
+	WriteTo     func(w io.Writer) error
+}
+
+// PublicDashboardRenderDTO holds the time range and variables a viewer renders a public dashboard or panel with, and
+// the size of the pages or image. A zero width or height uses the default size of the image renderer
+type PublicDashboardRenderDTO struct {
+	TimeRange TimeRangeDTO
+	Variables map[string]interface{}
+	Width     int
+	Height    int
+	Theme     string
+}
+
+// RotateAccessTokenDTO is the request DTO for rotating the access token of a public dashboard. The previous access
+// token keeps working for GracePeriodSeconds, it stops working right away when it's 0
+type RotateAccessTokenDTO struct {
+	GracePeriodSeconds int64 `json:"gracePeriodSeconds"`
+}
+
+// PublicDashboardHeartbeatDTO is sent periodically by anonymous viewers while they have the public dashboard open
+type PublicDashboardHeartbeatDTO struct {
+	SessionId string `json:"sessionId"`
+}
+
+// ViewerToken is a short-lived signed token viewers exchange the access token for, required by the query and variable
+// endpoints. It's bound to the client it was issued to
+type ViewerToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// ViewerClient identifies the client a viewer token is issued to
+type ViewerClient struct {
+	IP        string
+	UserAgent string
+}
+
+const (
+	ViewerChallengeTypeNone        = "none"
+	ViewerChallengeTypeProofOfWork = "proof_of_work"
+	ViewerChallengeTypeCaptcha     = "captcha"
+)
+
+// ViewerChallenge is the challenge viewers solve before they get a viewer token
+type ViewerChallenge struct {
+	// Type is none when viewers aren't challenged
+	Type string `json:"type"`
+	// Challenge is sent back with the solution
+	Challenge string `json:"challenge,omitempty"`
+	// Difficulty is the number of leading zero bits of the proof-of-work hash
+	Difficulty int `json:"difficulty,omitempty"`
+	// Provider and SiteKey configure the captcha widget
+	Provider string `json:"provider,omitempty"`
+	SiteKey  string `json:"siteKey,omitempty"`
+}
+
+// ViewerChallengeSolution is the solution of a viewer challenge
+type ViewerChallengeSolution struct {
+	Challenge string `json:"challenge"`
+	// Nonce solves proof-of-work challenges, the SHA-256 of the challenge, a colon and the nonce has to start with
+	// the leading zero bits of the difficulty
+	Nonce string `json:"nonce"`
+	// Response is the response of the captcha widget
+	Response string `json:"response"`
+}
+
+type ViewerTokenRequestDTO struct {
+	Solution *ViewerChallengeSolution `json:"solution"`
+}
+
+// ViewerStats holds the number of anonymous viewers currently looking at a public dashboard and the highest number
+// seen at once
+type ViewerStats struct {
+	CurrentViewers int        `json:"currentViewers"`
+	PeakViewers    int        `json:"peakViewers"`
+	PeakAt         *time.Time `json:"peakAt,omitempty"`
+}
+
+// VariableValueUsage is the number of queries of a public dashboard that used a variable value
+type VariableValueUsage struct {
+	Value    string `json:"value"`
+	Requests int64  `json:"requests"`
+}
+
+// PublicDashboardStats holds the usage of a public dashboard. TopVariableValues holds the most requested values of
+// each variable, keyed by variable name
+type PublicDashboardStats struct {
+	ViewerStats
+	Usage             PublicDashboardUsage            `json:"usage"`
+	TopVariableValues map[string][]VariableValueUsage `json:"topVariableValues"`
+}
+
+// PublicDashboardUsage counts the views and queries of a public dashboard since it was created. The last view and
+// query times are zero until the public dashboard is first viewed or queried, the last view is stored as the last
+// access of the public dashboard
+type PublicDashboardUsage struct {
+	Id                 int64     `json:"-" xorm:"pk autoincr 'id'"`
+	OrgId              int64     `json:"-" xorm:"org_id"`
+	PublicDashboardUid string    `json:"-" xorm:"public_dashboard_uid"`
+	Views              int64     `json:"views" xorm:"views"`
+	Queries            int64     `json:"queries" xorm:"queries"`
+	LastViewedAt       time.Time `json:"lastViewedAt" xorm:"last_viewed_at"`
+	LastQueriedAt      time.Time `json:"lastQueriedAt" xorm:"last_queried_at"`
+}
+
+func (u PublicDashboardUsage) TableName() string {
+	return "dashboard_public_usage"
+}
+
+// Add adds the views and queries of other to the usage and keeps the latest view and query times
+func (u *PublicDashboardUsage) Add(other PublicDashboardUsage) {
+	u.Views += other.Views
+	u.Queries += other.Queries
+	if other.LastViewedAt.After(u.LastViewedAt) {
+		u.LastViewedAt = other.LastViewedAt
+	}
+	if other.LastQueriedAt.After(u.LastQueriedAt) {
+		u.LastQueriedAt = other.LastQueriedAt
+	}
+}
+
+const (
+	AuditLogActionView  = "view"
+	AuditLogActionQuery = "query"
+)
+
+// AuditLogEntry records a view or a query of a public dashboard. The IP address of the viewer is only kept as a keyed
+// hash, so entries of the same viewer can be told apart from the others without storing the address
+type AuditLogEntry struct {
+	Id                 int64  `json:"id" xorm:"pk autoincr 'id'"`
+	OrgId              int64  `json:"-" xorm:"org_id"`
+	PublicDashboardUid string `json:"publicDashboardUid" xorm:"public_dashboard_uid"`
+	// AccessTokenHash is the SHA-256 hash of the access token or slug the viewer used, so the audit log doesn't hold
+	// working access tokens
+	AccessTokenHash string `json:"accessTokenHash" xorm:"access_token"`
+	IPHash          string `json:"ipHash" xorm:"ip_hash"`
+	UserAgent       string `json:"userAgent" xorm:"user_agent"`
+	Action          string `json:"action" xorm:"action"`
+	// PanelIds are the queried panels, empty for views
+	PanelIds  []int64   `json:"panelIds" xorm:"panel_ids"`
+	TimeFrom  string    `json:"timeFrom" xorm:"time_from"`
+	TimeTo    string    `json:"timeTo" xorm:"time_to"`
+	CreatedAt time.Time `json:"createdAt" xorm:"created_at"`
+}
+
+func (e AuditLogEntry) TableName() string {
+	return "dashboard_public_audit_log"
+}
+
+// AuditLogEvent is a view or a query of a public dashboard to record in the audit log
+type AuditLogEvent struct {
+	Action    string
+	Client    ViewerClient
+	PanelIds  []int64
+	TimeRange TimeRangeDTO
+}
+
+type AuditLogQuery struct {
+	OrgId              int64
+	PublicDashboardUid string
+	Page               int
+	Limit              int
+	Offset             int
+}
+
+type AuditLogResponseWithPagination struct {
+	Entries    []*AuditLogEntry `json:"entries"`
+	TotalCount int64            `json:"totalCount"`
+	Page       int              `json:"page"`
+	PerPage    int              `json:"perPage"`
+}
+
+// PublicDashboardRevocation reports what was invalidated when a public dashboard was revoked
+type PublicDashboardRevocation struct {
+	Uid string `json:"uid"`
+	// CancelledQueries is the number of running queries of viewers cancelled on the instance serving the request
+	CancelledQueries int `json:"cancelledQueries"`
+	// LiveViewersDisconnected is false when the Live connections of viewers couldn't be closed
+	LiveViewersDisconnected bool `json:"liveViewersDisconnected"`
+	// OtherInstancesInvalidated is false when the other instances couldn't be reached, they then serve the public
+	// dashboard from their caches until the access token cache ttl expires
+	OtherInstancesInvalidated bool `json:"otherInstancesInvalidated"`
+	// CDNPurged is true when the CDN purge webhook accepted the surrogate keys of the access tokens
+	CDNPurged bool `json:"cdnPurged"`
+}
+
+// OrphanedCleanupResult reports the public dashboards whose dashboards were deleted, found by a cleanup
+type OrphanedCleanupResult struct {
+	// DryRun is true when the orphaned public dashboards were only found, not deleted
+	DryRun   bool                      `json:"dryRun"`
+	Orphaned []OrphanedPublicDashboard `json:"orphaned"`
+	Deleted  int                       `json:"deleted"`
+}
+
+type OrphanedPublicDashboard struct {
+	Uid          string `json:"uid"`
+	OrgId        int64  `json:"orgId"`
+	DashboardUid string `json:"dashboardUid"`
+}
+
+// PublicDashboardConfigExportVersion is the version of the exported share configs, configs of other versions can't be
+// imported
+const PublicDashboardConfigExportVersion = 1
+
+// PublicDashboardConfigExport is the share config of a public dashboard, to import it against another dashboard or in
+// another org or instance
+type PublicDashboardConfigExport struct {
+	Version int `json:"version"`
+	// Uid is the public dashboard the config was exported from, its access token is only preserved by the instance
+	// where this public dashboard owns it
+	Uid string `json:"uid"`
+	// DashboardUid is the dashboard the config was exported from, configs can be imported against any dashboard
+	DashboardUid string    `json:"dashboardUid"`
+	ExportedAt   time.Time `json:"exportedAt"`
+	// Config has every setting of the public dashboard, but not its uid
+	Config PublicDashboardDTO `json:"config"`
+}
+
+type PublicDashboardConfigImportDTO struct {
+	Export PublicDashboardConfigExport `json:"export"`
+	// PreserveAccessToken keeps the access token of the exported config when the exported public dashboard owns it in
+	// this instance. A new access token is generated otherwise, so imported access tokens follow the settings
+	PreserveAccessToken bool `json:"preserveAccessToken"`
+}
+
+// SurrogateKey returns the key tagging the cacheable responses of the access token, so CDNs can purge them. It's a
+// hash of the access token, so CDNs don't keep the access token
+func SurrogateKey(accessToken string) string {
+	hash := sha256.Sum256([]byte(accessToken))
+	return "public-dashboard-" + hex.EncodeToString(hash[:8])
+}
+
 //
 // COMMANDS
 //
@@ -182,3 +1005,29 @@ type MetricFindValue struct {
 type SavePublicDashboardCommand struct {
 	PublicDashboard PublicDashboard
 }
+
+// PublicDashboardPatch is a JSON merge patch of the config of a public dashboard. Only the fields of the patch are
+// written, fields set to null are reset to their default
+type PublicDashboardPatch struct {
+	// Fields are the JSON names of the fields of the patch
+	Fields          []string
+	PublicDashboard *PublicDashboardDTO
+}
+
+// PatchPublicDashboardCommand writes the Columns of the public dashboard and leaves the others untouched, so writers of
+// other columns don't overwrite each other
+type PatchPublicDashboardCommand struct {
+	Columns         []string
+	PublicDashboard PublicDashboard
+}
+
+// RotateAccessTokenCommand replaces AccessToken with NewAccessToken, only if AccessToken is still the access token of
+// the public dashboard. The replaced access token keeps working until PreviousAccessTokenExpiresAt when it's set
+type RotateAccessTokenCommand struct {
+	Uid                          string
+	AccessToken                  string
+	NewAccessToken               string
+	PreviousAccessTokenExpiresAt time.Time
+	UpdatedBy                    int64
+	UpdatedAt                    time.Time
+}
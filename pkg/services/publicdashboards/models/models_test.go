@@ -1,11 +1,60 @@
 package models
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPublicDashboardTableName(t *testing.T) {
 	assert.Equal(t, "dashboard_public", PublicDashboard{}.TableName())
 }
+
+func TestPublicDashboardAllowsVariableOverride(t *testing.T) {
+	assert.True(t, PublicDashboard{}.AllowsVariableOverride("env"))
+
+	allowlist := PublicDashboard{VariableOverridesAllowed: []string{"env"}}
+	assert.True(t, allowlist.AllowsVariableOverride("env"))
+	assert.False(t, allowlist.AllowsVariableOverride("server"))
+
+	assert.False(t, PublicDashboard{VariableOverridesAllowed: []string{}}.AllowsVariableOverride("env"))
+
+	// pinned variables can't be changed even when allowed
+	pinned := PublicDashboard{VariableOverridesAllowed: []string{"env"}, PinnedVariables: PinnedVariables{"env": "prod"}}
+	assert.False(t, pinned.AllowsVariableOverride("env"))
+	assert.False(t, PublicDashboard{PinnedVariables: PinnedVariables{"env": "prod"}}.AllowsVariableOverride("env"))
+}
+
+func TestGeoRestrictionAllows(t *testing.T) {
+	allow := &GeoRestriction{Mode: GeoRestrictionModeAllow, Countries: []string{"DE", "FR"}}
+	deny := &GeoRestriction{Mode: GeoRestrictionModeDeny, Countries: []string{"US"}}
+
+	assert.True(t, allow.Allows("DE"))
+	assert.True(t, allow.Allows("fr"))
+	assert.False(t, allow.Allows("US"))
+	assert.False(t, allow.Allows(""))
+
+	assert.False(t, deny.Allows("US"))
+	assert.True(t, deny.Allows("DE"))
+	assert.True(t, deny.Allows(""))
+
+	var none *GeoRestriction
+	assert.True(t, none.Allows("US"))
+}
+
+func TestPublicDashboardBatchQueryDTOUnmarshal(t *testing.T) {
+	var all PublicDashboardBatchQueryDTO
+	require.NoError(t, json.Unmarshal([]byte(`{"panelIds": "all", "maxDataPoints": 100}`), &all))
+	assert.Equal(t, BatchQueryPanels{All: true}, all.PanelIds)
+	assert.Equal(t, int64(100), all.MaxDataPoints)
+
+	var list PublicDashboardBatchQueryDTO
+	require.NoError(t, json.Unmarshal([]byte(`{"panelIds": [1, 2], "variables": {"env": "prod"}}`), &list))
+	assert.Equal(t, BatchQueryPanels{Ids: []int64{1, 2}}, list.PanelIds)
+	assert.Equal(t, map[string]interface{}{"env": "prod"}, list.Variables)
+
+	var invalid PublicDashboardBatchQueryDTO
+	assert.Error(t, json.Unmarshal([]byte(`{"panelIds": "some"}`), &invalid))
+}
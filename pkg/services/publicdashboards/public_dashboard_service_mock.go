@@ -22,6 +22,66 @@ type FakePublicDashboardService struct {
 	mock.Mock
 }
 
+// CheckHealth provides a mock function with given fields: ctx, u, uid, dashboardUid
+func (_m *FakePublicDashboardService) CheckHealth(ctx context.Context, u *user.SignedInUser, uid string, dashboardUid string) (*models.PublicDashboardHealth, error) {
+	ret := _m.Called(ctx, u, uid, dashboardUid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CheckHealth")
+	}
+
+	var r0 *models.PublicDashboardHealth
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *user.SignedInUser, string, string) (*models.PublicDashboardHealth, error)); ok {
+		return rf(ctx, u, uid, dashboardUid)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *user.SignedInUser, string, string) *models.PublicDashboardHealth); ok {
+		r0 = rf(ctx, u, uid, dashboardUid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PublicDashboardHealth)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *user.SignedInUser, string, string) error); ok {
+		r1 = rf(ctx, u, uid, dashboardUid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CleanupOrphaned provides a mock function with given fields: ctx, dryRun
+func (_m *FakePublicDashboardService) CleanupOrphaned(ctx context.Context, dryRun bool) (*models.OrphanedCleanupResult, error) {
+	ret := _m.Called(ctx, dryRun)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CleanupOrphaned")
+	}
+
+	var r0 *models.OrphanedCleanupResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, bool) (*models.OrphanedCleanupResult, error)); ok {
+		return rf(ctx, dryRun)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, bool) *models.OrphanedCleanupResult); ok {
+		r0 = rf(ctx, dryRun)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.OrphanedCleanupResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, bool) error); ok {
+		r1 = rf(ctx, dryRun)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Create provides a mock function with given fields: ctx, u, dto
 func (_m *FakePublicDashboardService) Create(ctx context.Context, u *user.SignedInUser, dto *models.SavePublicDashboardDTO) (*models.PublicDashboard, error) {
 	ret := _m.Called(ctx, u, dto)
@@ -52,6 +112,11 @@ func (_m *FakePublicDashboardService) Create(ctx context.Context, u *user.Signed
 	return r0, r1
 }
 
+// DashboardChanged provides a mock function with given fields: orgId, dashboardUid
+func (_m *FakePublicDashboardService) DashboardChanged(orgId int64, dashboardUid string) {
+	_m.Called(orgId, dashboardUid)
+}
+
 // Delete provides a mock function with given fields: ctx, uid, dashboardUid
 func (_m *FakePublicDashboardService) Delete(ctx context.Context, uid string, dashboardUid string) error {
 	ret := _m.Called(ctx, uid, dashboardUid)
@@ -126,6 +191,126 @@ func (_m *FakePublicDashboardService) ExistsEnabledByDashboardUid(ctx context.Co
 	return r0, r1
 }
 
+// ExportConfig provides a mock function with given fields: ctx, u, uid, dashboardUid
+func (_m *FakePublicDashboardService) ExportConfig(ctx context.Context, u *user.SignedInUser, uid string, dashboardUid string) (*models.PublicDashboardConfigExport, error) {
+	ret := _m.Called(ctx, u, uid, dashboardUid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExportConfig")
+	}
+
+	var r0 *models.PublicDashboardConfigExport
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *user.SignedInUser, string, string) (*models.PublicDashboardConfigExport, error)); ok {
+		return rf(ctx, u, uid, dashboardUid)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *user.SignedInUser, string, string) *models.PublicDashboardConfigExport); ok {
+		r0 = rf(ctx, u, uid, dashboardUid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PublicDashboardConfigExport)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *user.SignedInUser, string, string) error); ok {
+		r1 = rf(ctx, u, uid, dashboardUid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExportDashboardPDF provides a mock function with given fields: ctx, reqDTO, accessToken
+func (_m *FakePublicDashboardService) ExportDashboardPDF(ctx context.Context, reqDTO models.PublicDashboardRenderDTO, accessToken string) (*models.PanelExport, error) {
+	ret := _m.Called(ctx, reqDTO, accessToken)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExportDashboardPDF")
+	}
+
+	var r0 *models.PanelExport
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.PublicDashboardRenderDTO, string) (*models.PanelExport, error)); ok {
+		return rf(ctx, reqDTO, accessToken)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.PublicDashboardRenderDTO, string) *models.PanelExport); ok {
+		r0 = rf(ctx, reqDTO, accessToken)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PanelExport)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.PublicDashboardRenderDTO, string) error); ok {
+		r1 = rf(ctx, reqDTO, accessToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExportPanelCSV provides a mock function with given fields: ctx, skipDSCache, reqDTO, panelId, accessToken, locale
+func (_m *FakePublicDashboardService) ExportPanelCSV(ctx context.Context, skipDSCache bool, reqDTO models.PublicDashboardQueryDTO, panelId int64, accessToken string, locale string) (*models.PanelExport, error) {
+	ret := _m.Called(ctx, skipDSCache, reqDTO, panelId, accessToken, locale)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExportPanelCSV")
+	}
+
+	var r0 *models.PanelExport
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, bool, models.PublicDashboardQueryDTO, int64, string, string) (*models.PanelExport, error)); ok {
+		return rf(ctx, skipDSCache, reqDTO, panelId, accessToken, locale)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, bool, models.PublicDashboardQueryDTO, int64, string, string) *models.PanelExport); ok {
+		r0 = rf(ctx, skipDSCache, reqDTO, panelId, accessToken, locale)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PanelExport)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, bool, models.PublicDashboardQueryDTO, int64, string, string) error); ok {
+		r1 = rf(ctx, skipDSCache, reqDTO, panelId, accessToken, locale)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExportPanelXLSX provides a mock function with given fields: ctx, skipDSCache, reqDTO, panelId, accessToken
+func (_m *FakePublicDashboardService) ExportPanelXLSX(ctx context.Context, skipDSCache bool, reqDTO models.PublicDashboardQueryDTO, panelId int64, accessToken string) (*models.PanelExport, error) {
+	ret := _m.Called(ctx, skipDSCache, reqDTO, panelId, accessToken)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExportPanelXLSX")
+	}
+
+	var r0 *models.PanelExport
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, bool, models.PublicDashboardQueryDTO, int64, string) (*models.PanelExport, error)); ok {
+		return rf(ctx, skipDSCache, reqDTO, panelId, accessToken)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, bool, models.PublicDashboardQueryDTO, int64, string) *models.PanelExport); ok {
+		r0 = rf(ctx, skipDSCache, reqDTO, panelId, accessToken)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PanelExport)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, bool, models.PublicDashboardQueryDTO, int64, string) error); ok {
+		r1 = rf(ctx, skipDSCache, reqDTO, panelId, accessToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Find provides a mock function with given fields: ctx, uid
 func (_m *FakePublicDashboardService) Find(ctx context.Context, uid string) (*models.PublicDashboard, error) {
 	ret := _m.Called(ctx, uid)
@@ -156,6 +341,36 @@ func (_m *FakePublicDashboardService) Find(ctx context.Context, uid string) (*mo
 	return r0, r1
 }
 
+// FindAllAcrossOrgsWithPagination provides a mock function with given fields: ctx, query
+func (_m *FakePublicDashboardService) FindAllAcrossOrgsWithPagination(ctx context.Context, query *models.AdminPublicDashboardListQuery) (*models.AdminPublicDashboardListResponseWithPagination, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindAllAcrossOrgsWithPagination")
+	}
+
+	var r0 *models.AdminPublicDashboardListResponseWithPagination
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.AdminPublicDashboardListQuery) (*models.AdminPublicDashboardListResponseWithPagination, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *models.AdminPublicDashboardListQuery) *models.AdminPublicDashboardListResponseWithPagination); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.AdminPublicDashboardListResponseWithPagination)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *models.AdminPublicDashboardListQuery) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindAllWithPagination provides a mock function with given fields: ctx, query
 func (_m *FakePublicDashboardService) FindAllWithPagination(ctx context.Context, query *models.PublicDashboardListQuery) (*models.PublicDashboardListResponseWithPagination, error) {
 	ret := _m.Called(ctx, query)
@@ -384,6 +599,66 @@ func (_m *FakePublicDashboardService) FindPublicDashboardAndDashboardByAccessTok
 	return r0, r1, r2
 }
 
+// GetAuditLog provides a mock function with given fields: ctx, orgId, dashboardUid, uid, query
+func (_m *FakePublicDashboardService) GetAuditLog(ctx context.Context, orgId int64, dashboardUid string, uid string, query *models.AuditLogQuery) (*models.AuditLogResponseWithPagination, error) {
+	ret := _m.Called(ctx, orgId, dashboardUid, uid, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetAuditLog")
+	}
+
+	var r0 *models.AuditLogResponseWithPagination
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, string, *models.AuditLogQuery) (*models.AuditLogResponseWithPagination, error)); ok {
+		return rf(ctx, orgId, dashboardUid, uid, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, string, *models.AuditLogQuery) *models.AuditLogResponseWithPagination); ok {
+		r0 = rf(ctx, orgId, dashboardUid, uid, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.AuditLogResponseWithPagination)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string, string, *models.AuditLogQuery) error); ok {
+		r1 = rf(ctx, orgId, dashboardUid, uid, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMetadata provides a mock function with given fields: ctx, accessToken
+func (_m *FakePublicDashboardService) GetMetadata(ctx context.Context, accessToken string) (*models.PublicDashboardMetadata, error) {
+	ret := _m.Called(ctx, accessToken)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMetadata")
+	}
+
+	var r0 *models.PublicDashboardMetadata
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.PublicDashboardMetadata, error)); ok {
+		return rf(ctx, accessToken)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.PublicDashboardMetadata); ok {
+		r0 = rf(ctx, accessToken)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PublicDashboardMetadata)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, accessToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetMetricRequest provides a mock function with given fields: ctx, dashboard, publicDashboard, panelId, reqDTO
 func (_m *FakePublicDashboardService) GetMetricRequest(ctx context.Context, dashboard *dashboards.Dashboard, publicDashboard *models.PublicDashboard, panelId int64, reqDTO models.PublicDashboardQueryDTO) (dtos.MetricRequest, error) {
 	ret := _m.Called(ctx, dashboard, publicDashboard, panelId, reqDTO)
@@ -440,6 +715,36 @@ func (_m *FakePublicDashboardService) GetOrgIdByAccessToken(ctx context.Context,
 	return r0, r1
 }
 
+// GetPanelContent provides a mock function with given fields: ctx, accessToken, panelId, reqDTO
+func (_m *FakePublicDashboardService) GetPanelContent(ctx context.Context, accessToken string, panelId int64, reqDTO models.PublicDashboardPanelContentDTO) (*models.PanelContent, error) {
+	ret := _m.Called(ctx, accessToken, panelId, reqDTO)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPanelContent")
+	}
+
+	var r0 *models.PanelContent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, models.PublicDashboardPanelContentDTO) (*models.PanelContent, error)); ok {
+		return rf(ctx, accessToken, panelId, reqDTO)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, models.PublicDashboardPanelContentDTO) *models.PanelContent); ok {
+		r0 = rf(ctx, accessToken, panelId, reqDTO)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PanelContent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64, models.PublicDashboardPanelContentDTO) error); ok {
+		r1 = rf(ctx, accessToken, panelId, reqDTO)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetPublicDashboardForView provides a mock function with given fields: ctx, accessToken
 func (_m *FakePublicDashboardService) GetPublicDashboardForView(ctx context.Context, accessToken string) (*dtos.DashboardFullWithMeta, error) {
 	ret := _m.Called(ctx, accessToken)
@@ -500,24 +805,84 @@ func (_m *FakePublicDashboardService) GetQueryDataResponse(ctx context.Context,
 	return r0, r1
 }
 
+// GetQueryDataResponses provides a mock function with given fields: ctx, skipDSCache, reqDTO, accessToken
+func (_m *FakePublicDashboardService) GetQueryDataResponses(ctx context.Context, skipDSCache bool, reqDTO models.PublicDashboardBatchQueryDTO, accessToken string) (*models.PublicDashboardBatchQueryResponse, error) {
+	ret := _m.Called(ctx, skipDSCache, reqDTO, accessToken)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetQueryDataResponses")
+	}
+
+	var r0 *models.PublicDashboardBatchQueryResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, bool, models.PublicDashboardBatchQueryDTO, string) (*models.PublicDashboardBatchQueryResponse, error)); ok {
+		return rf(ctx, skipDSCache, reqDTO, accessToken)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, bool, models.PublicDashboardBatchQueryDTO, string) *models.PublicDashboardBatchQueryResponse); ok {
+		r0 = rf(ctx, skipDSCache, reqDTO, accessToken)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PublicDashboardBatchQueryResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, bool, models.PublicDashboardBatchQueryDTO, string) error); ok {
+		r1 = rf(ctx, skipDSCache, reqDTO, accessToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStats provides a mock function with given fields: ctx, orgId, dashboardUid, uid, topN
+func (_m *FakePublicDashboardService) GetStats(ctx context.Context, orgId int64, dashboardUid string, uid string, topN int) (*models.PublicDashboardStats, error) {
+	ret := _m.Called(ctx, orgId, dashboardUid, uid, topN)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetStats")
+	}
+
+	var r0 *models.PublicDashboardStats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, string, int) (*models.PublicDashboardStats, error)); ok {
+		return rf(ctx, orgId, dashboardUid, uid, topN)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, string, int) *models.PublicDashboardStats); ok {
+		r0 = rf(ctx, orgId, dashboardUid, uid, topN)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PublicDashboardStats)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string, string, int) error); ok {
+		r1 = rf(ctx, orgId, dashboardUid, uid, topN)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetVariableQueryResponse provides a mock function with given fields: ctx, accessToken, variableName, reqDTO
-func (_m *FakePublicDashboardService) GetVariableQueryResponse(ctx context.Context, accessToken string, variableName string, reqDTO models.PublicDashboardVariableQueryDTO) ([]models.MetricFindValue, error) {
+func (_m *FakePublicDashboardService) GetVariableQueryResponse(ctx context.Context, accessToken string, variableName string, reqDTO models.PublicDashboardVariableQueryDTO) (*models.PublicDashboardVariableQueryResponse, error) {
 	ret := _m.Called(ctx, accessToken, variableName, reqDTO)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetVariableQueryResponse")
 	}
 
-	var r0 []models.MetricFindValue
+	var r0 *models.PublicDashboardVariableQueryResponse
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, models.PublicDashboardVariableQueryDTO) ([]models.MetricFindValue, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, models.PublicDashboardVariableQueryDTO) (*models.PublicDashboardVariableQueryResponse, error)); ok {
 		return rf(ctx, accessToken, variableName, reqDTO)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, models.PublicDashboardVariableQueryDTO) []models.MetricFindValue); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, models.PublicDashboardVariableQueryDTO) *models.PublicDashboardVariableQueryResponse); ok {
 		r0 = rf(ctx, accessToken, variableName, reqDTO)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]models.MetricFindValue)
+			r0 = ret.Get(0).(*models.PublicDashboardVariableQueryResponse)
 		}
 	}
 
@@ -530,6 +895,156 @@ func (_m *FakePublicDashboardService) GetVariableQueryResponse(ctx context.Conte
 	return r0, r1
 }
 
+// GetViewerChallenge provides a mock function with given fields: ctx, accessToken, client
+func (_m *FakePublicDashboardService) GetViewerChallenge(ctx context.Context, accessToken string, client models.ViewerClient) (*models.ViewerChallenge, error) {
+	ret := _m.Called(ctx, accessToken, client)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetViewerChallenge")
+	}
+
+	var r0 *models.ViewerChallenge
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.ViewerClient) (*models.ViewerChallenge, error)); ok {
+		return rf(ctx, accessToken, client)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.ViewerClient) *models.ViewerChallenge); ok {
+		r0 = rf(ctx, accessToken, client)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.ViewerChallenge)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, models.ViewerClient) error); ok {
+		r1 = rf(ctx, accessToken, client)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ImportConfig provides a mock function with given fields: ctx, u, dashboardUid, dto
+func (_m *FakePublicDashboardService) ImportConfig(ctx context.Context, u *user.SignedInUser, dashboardUid string, dto *models.PublicDashboardConfigImportDTO) (*models.PublicDashboard, error) {
+	ret := _m.Called(ctx, u, dashboardUid, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ImportConfig")
+	}
+
+	var r0 *models.PublicDashboard
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *user.SignedInUser, string, *models.PublicDashboardConfigImportDTO) (*models.PublicDashboard, error)); ok {
+		return rf(ctx, u, dashboardUid, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *user.SignedInUser, string, *models.PublicDashboardConfigImportDTO) *models.PublicDashboard); ok {
+		r0 = rf(ctx, u, dashboardUid, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PublicDashboard)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *user.SignedInUser, string, *models.PublicDashboardConfigImportDTO) error); ok {
+		r1 = rf(ctx, u, dashboardUid, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// InspectPanelQuery provides a mock function with given fields: ctx, u, uid, dashboardUid, panelId, skipDSCache, reqDTO
+func (_m *FakePublicDashboardService) InspectPanelQuery(ctx context.Context, u *user.SignedInUser, uid string, dashboardUid string, panelId int64, skipDSCache bool, reqDTO models.PublicDashboardQueryDTO) (*models.PublicDashboardQueryInspection, error) {
+	ret := _m.Called(ctx, u, uid, dashboardUid, panelId, skipDSCache, reqDTO)
+
+	if len(ret) == 0 {
+		panic("no return value specified for InspectPanelQuery")
+	}
+
+	var r0 *models.PublicDashboardQueryInspection
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *user.SignedInUser, string, string, int64, bool, models.PublicDashboardQueryDTO) (*models.PublicDashboardQueryInspection, error)); ok {
+		return rf(ctx, u, uid, dashboardUid, panelId, skipDSCache, reqDTO)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *user.SignedInUser, string, string, int64, bool, models.PublicDashboardQueryDTO) *models.PublicDashboardQueryInspection); ok {
+		r0 = rf(ctx, u, uid, dashboardUid, panelId, skipDSCache, reqDTO)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PublicDashboardQueryInspection)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *user.SignedInUser, string, string, int64, bool, models.PublicDashboardQueryDTO) error); ok {
+		r1 = rf(ctx, u, uid, dashboardUid, panelId, skipDSCache, reqDTO)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IssueViewerToken provides a mock function with given fields: ctx, accessToken, client, solution
+func (_m *FakePublicDashboardService) IssueViewerToken(ctx context.Context, accessToken string, client models.ViewerClient, solution *models.ViewerChallengeSolution) (*models.ViewerToken, error) {
+	ret := _m.Called(ctx, accessToken, client, solution)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IssueViewerToken")
+	}
+
+	var r0 *models.ViewerToken
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.ViewerClient, *models.ViewerChallengeSolution) (*models.ViewerToken, error)); ok {
+		return rf(ctx, accessToken, client, solution)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, models.ViewerClient, *models.ViewerChallengeSolution) *models.ViewerToken); ok {
+		r0 = rf(ctx, accessToken, client, solution)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.ViewerToken)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, models.ViewerClient, *models.ViewerChallengeSolution) error); ok {
+		r1 = rf(ctx, accessToken, client, solution)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListVariables provides a mock function with given fields: ctx, accessToken
+func (_m *FakePublicDashboardService) ListVariables(ctx context.Context, accessToken string) ([]models.PublicDashboardVariable, error) {
+	ret := _m.Called(ctx, accessToken)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListVariables")
+	}
+
+	var r0 []models.PublicDashboardVariable
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]models.PublicDashboardVariable, error)); ok {
+		return rf(ctx, accessToken)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []models.PublicDashboardVariable); ok {
+		r0 = rf(ctx, accessToken)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]models.PublicDashboardVariable)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, accessToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewPublicDashboardAccessToken provides a mock function with given fields: ctx
 func (_m *FakePublicDashboardService) NewPublicDashboardAccessToken(ctx context.Context) (string, error) {
 	ret := _m.Called(ctx)
@@ -586,6 +1101,154 @@ func (_m *FakePublicDashboardService) NewPublicDashboardUid(ctx context.Context)
 	return r0, r1
 }
 
+// Patch provides a mock function with given fields: ctx, u, uid, dashboardUid, patch
+func (_m *FakePublicDashboardService) Patch(ctx context.Context, u *user.SignedInUser, uid string, dashboardUid string, patch models.PublicDashboardPatch) (*models.PublicDashboard, error) {
+	ret := _m.Called(ctx, u, uid, dashboardUid, patch)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Patch")
+	}
+
+	var r0 *models.PublicDashboard
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *user.SignedInUser, string, string, models.PublicDashboardPatch) (*models.PublicDashboard, error)); ok {
+		return rf(ctx, u, uid, dashboardUid, patch)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *user.SignedInUser, string, string, models.PublicDashboardPatch) *models.PublicDashboard); ok {
+		r0 = rf(ctx, u, uid, dashboardUid, patch)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PublicDashboard)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *user.SignedInUser, string, string, models.PublicDashboardPatch) error); ok {
+		r1 = rf(ctx, u, uid, dashboardUid, patch)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PublicDashboardChanged provides a mock function with given fields: uid
+func (_m *FakePublicDashboardService) PublicDashboardChanged(uid string) {
+	_m.Called(uid)
+}
+
+// RecordAuditLogEntry provides a mock function with given fields: ctx, accessToken, event
+func (_m *FakePublicDashboardService) RecordAuditLogEntry(ctx context.Context, accessToken string, event models.AuditLogEvent) {
+	_m.Called(ctx, accessToken, event)
+}
+
+// RecordViewerHeartbeat provides a mock function with given fields: ctx, accessToken, sessionId
+func (_m *FakePublicDashboardService) RecordViewerHeartbeat(ctx context.Context, accessToken string, sessionId string) error {
+	ret := _m.Called(ctx, accessToken, sessionId)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RecordViewerHeartbeat")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, accessToken, sessionId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RenderPanelPNG provides a mock function with given fields: ctx, reqDTO, panelId, accessToken
+func (_m *FakePublicDashboardService) RenderPanelPNG(ctx context.Context, reqDTO models.PublicDashboardRenderDTO, panelId int64, accessToken string) (*models.PanelExport, error) {
+	ret := _m.Called(ctx, reqDTO, panelId, accessToken)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RenderPanelPNG")
+	}
+
+	var r0 *models.PanelExport
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.PublicDashboardRenderDTO, int64, string) (*models.PanelExport, error)); ok {
+		return rf(ctx, reqDTO, panelId, accessToken)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.PublicDashboardRenderDTO, int64, string) *models.PanelExport); ok {
+		r0 = rf(ctx, reqDTO, panelId, accessToken)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PanelExport)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.PublicDashboardRenderDTO, int64, string) error); ok {
+		r1 = rf(ctx, reqDTO, panelId, accessToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Revoke provides a mock function with given fields: ctx, u, uid, dashboardUid
+func (_m *FakePublicDashboardService) Revoke(ctx context.Context, u *user.SignedInUser, uid string, dashboardUid string) (*models.PublicDashboardRevocation, error) {
+	ret := _m.Called(ctx, u, uid, dashboardUid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Revoke")
+	}
+
+	var r0 *models.PublicDashboardRevocation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *user.SignedInUser, string, string) (*models.PublicDashboardRevocation, error)); ok {
+		return rf(ctx, u, uid, dashboardUid)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *user.SignedInUser, string, string) *models.PublicDashboardRevocation); ok {
+		r0 = rf(ctx, u, uid, dashboardUid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PublicDashboardRevocation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *user.SignedInUser, string, string) error); ok {
+		r1 = rf(ctx, u, uid, dashboardUid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RotateAccessToken provides a mock function with given fields: ctx, u, uid, dashboardUid, dto
+func (_m *FakePublicDashboardService) RotateAccessToken(ctx context.Context, u *user.SignedInUser, uid string, dashboardUid string, dto models.RotateAccessTokenDTO) (*models.PublicDashboard, error) {
+	ret := _m.Called(ctx, u, uid, dashboardUid, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RotateAccessToken")
+	}
+
+	var r0 *models.PublicDashboard
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *user.SignedInUser, string, string, models.RotateAccessTokenDTO) (*models.PublicDashboard, error)); ok {
+		return rf(ctx, u, uid, dashboardUid, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *user.SignedInUser, string, string, models.RotateAccessTokenDTO) *models.PublicDashboard); ok {
+		r0 = rf(ctx, u, uid, dashboardUid, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PublicDashboard)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *user.SignedInUser, string, string, models.RotateAccessTokenDTO) error); ok {
+		r1 = rf(ctx, u, uid, dashboardUid, dto)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Update provides a mock function with given fields: ctx, u, dto
 func (_m *FakePublicDashboardService) Update(ctx context.Context, u *user.SignedInUser, dto *models.SavePublicDashboardDTO) (*models.PublicDashboard, error) {
 	ret := _m.Called(ctx, u, dto)
@@ -616,6 +1279,61 @@ func (_m *FakePublicDashboardService) Update(ctx context.Context, u *user.Signed
 	return r0, r1
 }
 
+// Upsert provides a mock function with given fields: ctx, u, dto
+func (_m *FakePublicDashboardService) Upsert(ctx context.Context, u *user.SignedInUser, dto *models.SavePublicDashboardDTO) (*models.PublicDashboard, bool, error) {
+	ret := _m.Called(ctx, u, dto)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Upsert")
+	}
+
+	var r0 *models.PublicDashboard
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, *user.SignedInUser, *models.SavePublicDashboardDTO) (*models.PublicDashboard, bool, error)); ok {
+		return rf(ctx, u, dto)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *user.SignedInUser, *models.SavePublicDashboardDTO) *models.PublicDashboard); ok {
+		r0 = rf(ctx, u, dto)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PublicDashboard)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *user.SignedInUser, *models.SavePublicDashboardDTO) bool); ok {
+		r1 = rf(ctx, u, dto)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, *user.SignedInUser, *models.SavePublicDashboardDTO) error); ok {
+		r2 = rf(ctx, u, dto)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ValidateViewerToken provides a mock function with given fields: ctx, accessToken, token, client
+func (_m *FakePublicDashboardService) ValidateViewerToken(ctx context.Context, accessToken string, token string, client models.ViewerClient) error {
+	ret := _m.Called(ctx, accessToken, token, client)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ValidateViewerToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, models.ViewerClient) error); ok {
+		r0 = rf(ctx, accessToken, token, client)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 func (_m *FakePublicDashboardService) GetSQLSchemas(ctx context.Context, user identity.Requester, reqDTO dtos.MetricRequest) (queryV0.SQLSchemas, error) {
 	return nil, fmt.Errorf("not implemented in public dashboards")
 }
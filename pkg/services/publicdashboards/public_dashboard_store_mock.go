@@ -7,6 +7,10 @@ import (
 
 	models "github.com/grafana/grafana/pkg/services/publicdashboards/models"
 	mock "github.com/stretchr/testify/mock"
+
+	quota "github.com/grafana/grafana/pkg/services/quota"
+
+	time "time"
 )
 
 // FakePublicDashboardStore is an autogenerated mock type for the Store type
@@ -14,6 +18,54 @@ type FakePublicDashboardStore struct {
 	mock.Mock
 }
 
+// AddUsage provides a mock function with given fields: ctx, usage
+func (_m *FakePublicDashboardStore) AddUsage(ctx context.Context, usage []models.PublicDashboardUsage) error {
+	ret := _m.Called(ctx, usage)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddUsage")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []models.PublicDashboardUsage) error); ok {
+		r0 = rf(ctx, usage)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Count provides a mock function with given fields: ctx, scopeParams
+func (_m *FakePublicDashboardStore) Count(ctx context.Context, scopeParams *quota.ScopeParameters) (*quota.Map, error) {
+	ret := _m.Called(ctx, scopeParams)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Count")
+	}
+
+	var r0 *quota.Map
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *quota.ScopeParameters) (*quota.Map, error)); ok {
+		return rf(ctx, scopeParams)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *quota.ScopeParameters) *quota.Map); ok {
+		r0 = rf(ctx, scopeParams)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*quota.Map)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *quota.ScopeParameters) error); ok {
+		r1 = rf(ctx, scopeParams)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Create provides a mock function with given fields: ctx, cmd
 func (_m *FakePublicDashboardStore) Create(ctx context.Context, cmd models.SavePublicDashboardCommand) (int64, error) {
 	ret := _m.Called(ctx, cmd)
@@ -70,6 +122,34 @@ func (_m *FakePublicDashboardStore) Delete(ctx context.Context, uid string) (int
 	return r0, r1
 }
 
+// DeleteAuditLogBefore provides a mock function with given fields: ctx, before
+func (_m *FakePublicDashboardStore) DeleteAuditLogBefore(ctx context.Context, before time.Time) (int64, error) {
+	ret := _m.Called(ctx, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteAuditLogBefore")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int64, error)); ok {
+		return rf(ctx, before)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteByDashboardUIDs provides a mock function with given fields: ctx, orgId, dashboardUIDs
 func (_m *FakePublicDashboardStore) DeleteByDashboardUIDs(ctx context.Context, orgId int64, dashboardUIDs []string) error {
 	ret := _m.Called(ctx, orgId, dashboardUIDs)
@@ -204,6 +284,96 @@ func (_m *FakePublicDashboardStore) FindAll(ctx context.Context, query *models.P
 	return r0, r1
 }
 
+// FindAllAcrossOrgs provides a mock function with given fields: ctx
+func (_m *FakePublicDashboardStore) FindAllAcrossOrgs(ctx context.Context) ([]*models.PublicDashboard, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindAllAcrossOrgs")
+	}
+
+	var r0 []*models.PublicDashboard
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]*models.PublicDashboard, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []*models.PublicDashboard); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.PublicDashboard)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindAllAcrossOrgsWithPagination provides a mock function with given fields: ctx, query
+func (_m *FakePublicDashboardStore) FindAllAcrossOrgsWithPagination(ctx context.Context, query *models.AdminPublicDashboardListQuery) (*models.AdminPublicDashboardListResponseWithPagination, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindAllAcrossOrgsWithPagination")
+	}
+
+	var r0 *models.AdminPublicDashboardListResponseWithPagination
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.AdminPublicDashboardListQuery) (*models.AdminPublicDashboardListResponseWithPagination, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *models.AdminPublicDashboardListQuery) *models.AdminPublicDashboardListResponseWithPagination); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.AdminPublicDashboardListResponseWithPagination)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *models.AdminPublicDashboardListQuery) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindAuditLog provides a mock function with given fields: ctx, query
+func (_m *FakePublicDashboardStore) FindAuditLog(ctx context.Context, query *models.AuditLogQuery) (*models.AuditLogResponseWithPagination, error) {
+	ret := _m.Called(ctx, query)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindAuditLog")
+	}
+
+	var r0 *models.AuditLogResponseWithPagination
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.AuditLogQuery) (*models.AuditLogResponseWithPagination, error)); ok {
+		return rf(ctx, query)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *models.AuditLogQuery) *models.AuditLogResponseWithPagination); ok {
+		r0 = rf(ctx, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.AuditLogResponseWithPagination)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *models.AuditLogQuery) error); ok {
+		r1 = rf(ctx, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindByAccessToken provides a mock function with given fields: ctx, accessToken
 func (_m *FakePublicDashboardStore) FindByAccessToken(ctx context.Context, accessToken string) (*models.PublicDashboard, error) {
 	ret := _m.Called(ctx, accessToken)
@@ -264,6 +434,214 @@ func (_m *FakePublicDashboardStore) FindByDashboardUid(ctx context.Context, orgI
 	return r0, r1
 }
 
+// FindBySlug provides a mock function with given fields: ctx, slug
+func (_m *FakePublicDashboardStore) FindBySlug(ctx context.Context, slug string) (*models.PublicDashboard, error) {
+	ret := _m.Called(ctx, slug)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindBySlug")
+	}
+
+	var r0 *models.PublicDashboard
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.PublicDashboard, error)); ok {
+		return rf(ctx, slug)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.PublicDashboard); ok {
+		r0 = rf(ctx, slug)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PublicDashboard)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, slug)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindEnabled provides a mock function with given fields: ctx
+func (_m *FakePublicDashboardStore) FindEnabled(ctx context.Context) ([]*models.PublicDashboard, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindEnabled")
+	}
+
+	var r0 []*models.PublicDashboard
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]*models.PublicDashboard, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []*models.PublicDashboard); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.PublicDashboard)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindEnabledExpiredBefore provides a mock function with given fields: ctx, before
+func (_m *FakePublicDashboardStore) FindEnabledExpiredBefore(ctx context.Context, before time.Time) ([]*models.PublicDashboard, error) {
+	ret := _m.Called(ctx, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindEnabledExpiredBefore")
+	}
+
+	var r0 []*models.PublicDashboard
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) ([]*models.PublicDashboard, error)); ok {
+		return rf(ctx, before)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) []*models.PublicDashboard); ok {
+		r0 = rf(ctx, before)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.PublicDashboard)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindEnabledInactiveSince provides a mock function with given fields: ctx, since
+func (_m *FakePublicDashboardStore) FindEnabledInactiveSince(ctx context.Context, since time.Time) ([]*models.PublicDashboard, error) {
+	ret := _m.Called(ctx, since)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindEnabledInactiveSince")
+	}
+
+	var r0 []*models.PublicDashboard
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) ([]*models.PublicDashboard, error)); ok {
+		return rf(ctx, since)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) []*models.PublicDashboard); ok {
+		r0 = rf(ctx, since)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.PublicDashboard)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindEnabledWithAccessTokenCreatedBefore provides a mock function with given fields: ctx, before
+func (_m *FakePublicDashboardStore) FindEnabledWithAccessTokenCreatedBefore(ctx context.Context, before time.Time) ([]*models.PublicDashboard, error) {
+	ret := _m.Called(ctx, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindEnabledWithAccessTokenCreatedBefore")
+	}
+
+	var r0 []*models.PublicDashboard
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) ([]*models.PublicDashboard, error)); ok {
+		return rf(ctx, before)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) []*models.PublicDashboard); ok {
+		r0 = rf(ctx, before)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.PublicDashboard)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindUsage provides a mock function with given fields: ctx, uid
+func (_m *FakePublicDashboardStore) FindUsage(ctx context.Context, uid string) (*models.PublicDashboardUsage, error) {
+	ret := _m.Called(ctx, uid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindUsage")
+	}
+
+	var r0 *models.PublicDashboardUsage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*models.PublicDashboardUsage, error)); ok {
+		return rf(ctx, uid)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *models.PublicDashboardUsage); ok {
+		r0 = rf(ctx, uid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PublicDashboardUsage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, uid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FlagAccessTokenRotationDue provides a mock function with given fields: ctx, uid, accessToken
+func (_m *FakePublicDashboardStore) FlagAccessTokenRotationDue(ctx context.Context, uid string, accessToken string) (int64, error) {
+	ret := _m.Called(ctx, uid, accessToken)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FlagAccessTokenRotationDue")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (int64, error)); ok {
+		return rf(ctx, uid, accessToken)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) int64); ok {
+		r0 = rf(ctx, uid, accessToken)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, uid, accessToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetMetrics provides a mock function with given fields: ctx
 func (_m *FakePublicDashboardStore) GetMetrics(ctx context.Context) (*models.Metrics, error) {
 	ret := _m.Called(ctx)
@@ -322,6 +700,80 @@ func (_m *FakePublicDashboardStore) GetOrgIdByAccessToken(ctx context.Context, a
 	return r0, r1
 }
 
+// InsertAuditLogEntry provides a mock function with given fields: ctx, entry
+func (_m *FakePublicDashboardStore) InsertAuditLogEntry(ctx context.Context, entry *models.AuditLogEntry) error {
+	ret := _m.Called(ctx, entry)
+
+	if len(ret) == 0 {
+		panic("no return value specified for InsertAuditLogEntry")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.AuditLogEntry) error); ok {
+		r0 = rf(ctx, entry)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Patch provides a mock function with given fields: ctx, cmd
+func (_m *FakePublicDashboardStore) Patch(ctx context.Context, cmd models.PatchPublicDashboardCommand) (int64, error) {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Patch")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.PatchPublicDashboardCommand) (int64, error)); ok {
+		return rf(ctx, cmd)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.PatchPublicDashboardCommand) int64); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.PatchPublicDashboardCommand) error); ok {
+		r1 = rf(ctx, cmd)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RotateAccessToken provides a mock function with given fields: ctx, cmd
+func (_m *FakePublicDashboardStore) RotateAccessToken(ctx context.Context, cmd models.RotateAccessTokenCommand) (int64, error) {
+	ret := _m.Called(ctx, cmd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RotateAccessToken")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, models.RotateAccessTokenCommand) (int64, error)); ok {
+		return rf(ctx, cmd)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, models.RotateAccessTokenCommand) int64); ok {
+		r0 = rf(ctx, cmd)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, models.RotateAccessTokenCommand) error); ok {
+		r1 = rf(ctx, cmd)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Update provides a mock function with given fields: ctx, cmd
 func (_m *FakePublicDashboardStore) Update(ctx context.Context, cmd models.SavePublicDashboardCommand) (int64, error) {
 	ret := _m.Called(ctx, cmd)
@@ -350,6 +802,24 @@ func (_m *FakePublicDashboardStore) Update(ctx context.Context, cmd models.SaveP
 	return r0, r1
 }
 
+// UpdateLastAccessedAt provides a mock function with given fields: ctx, uid, lastAccessedAt
+func (_m *FakePublicDashboardStore) UpdateLastAccessedAt(ctx context.Context, uid string, lastAccessedAt time.Time) error {
+	ret := _m.Called(ctx, uid, lastAccessedAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateLastAccessedAt")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) error); ok {
+		r0 = rf(ctx, uid, lastAccessedAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewFakePublicDashboardStore creates a new instance of FakePublicDashboardStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewFakePublicDashboardStore(t interface {
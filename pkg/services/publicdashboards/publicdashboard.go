@@ -2,6 +2,8 @@ package publicdashboards
 
 import (
 	"context"
+	"net/http"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/api/dtos"
@@ -10,6 +12,7 @@ import (
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/user"
 )
 
@@ -26,15 +29,42 @@ type Service interface {
 	FindAnnotations(ctx context.Context, reqDTO AnnotationsQueryDTO, accessToken string) ([]AnnotationEvent, error)
 	FindDashboard(ctx context.Context, orgId int64, dashboardUid string) (*dashboards.Dashboard, error)
 	FindAllWithPagination(ctx context.Context, query *PublicDashboardListQuery) (*PublicDashboardListResponseWithPagination, error)
+	FindAllAcrossOrgsWithPagination(ctx context.Context, query *AdminPublicDashboardListQuery) (*AdminPublicDashboardListResponseWithPagination, error)
 	Find(ctx context.Context, uid string) (*PublicDashboard, error)
 	Create(ctx context.Context, u *user.SignedInUser, dto *SavePublicDashboardDTO) (*PublicDashboard, error)
 	Update(ctx context.Context, u *user.SignedInUser, dto *SavePublicDashboardDTO) (*PublicDashboard, error)
+	Upsert(ctx context.Context, u *user.SignedInUser, dto *SavePublicDashboardDTO) (*PublicDashboard, bool, error)
+	Patch(ctx context.Context, u *user.SignedInUser, uid string, dashboardUid string, patch PublicDashboardPatch) (*PublicDashboard, error)
 	Delete(ctx context.Context, uid string, dashboardUid string) error
+	CleanupOrphaned(ctx context.Context, dryRun bool) (*OrphanedCleanupResult, error)
+	Revoke(ctx context.Context, u *user.SignedInUser, uid string, dashboardUid string) (*PublicDashboardRevocation, error)
+	RotateAccessToken(ctx context.Context, u *user.SignedInUser, uid string, dashboardUid string, dto RotateAccessTokenDTO) (*PublicDashboard, error)
+	ExportConfig(ctx context.Context, u *user.SignedInUser, uid string, dashboardUid string) (*PublicDashboardConfigExport, error)
+	ImportConfig(ctx context.Context, u *user.SignedInUser, dashboardUid string, dto *PublicDashboardConfigImportDTO) (*PublicDashboard, error)
+	CheckHealth(ctx context.Context, u *user.SignedInUser, uid string, dashboardUid string) (*PublicDashboardHealth, error)
 
 	GetMetricRequest(ctx context.Context, dashboard *dashboards.Dashboard, publicDashboard *PublicDashboard, panelId int64, reqDTO PublicDashboardQueryDTO) (dtos.MetricRequest, error)
 	GetQueryDataResponse(ctx context.Context, skipDSCache bool, reqDTO PublicDashboardQueryDTO, panelId int64, accessToken string) (*backend.QueryDataResponse, error)
-	GetVariableQueryResponse(ctx context.Context, accessToken string, variableName string, reqDTO PublicDashboardVariableQueryDTO) ([]MetricFindValue, error)
+	GetQueryDataResponses(ctx context.Context, skipDSCache bool, reqDTO PublicDashboardBatchQueryDTO, accessToken string) (*PublicDashboardBatchQueryResponse, error)
+	InspectPanelQuery(ctx context.Context, u *user.SignedInUser, uid string, dashboardUid string, panelId int64, skipDSCache bool, reqDTO PublicDashboardQueryDTO) (*PublicDashboardQueryInspection, error)
+	ExportPanelCSV(ctx context.Context, skipDSCache bool, reqDTO PublicDashboardQueryDTO, panelId int64, accessToken string, locale string) (*PanelExport, error)
+	ExportPanelXLSX(ctx context.Context, skipDSCache bool, reqDTO PublicDashboardQueryDTO, panelId int64, accessToken string) (*PanelExport, error)
+	ExportDashboardPDF(ctx context.Context, reqDTO PublicDashboardRenderDTO, accessToken string) (*PanelExport, error)
+	RenderPanelPNG(ctx context.Context, reqDTO PublicDashboardRenderDTO, panelId int64, accessToken string) (*PanelExport, error)
+	GetVariableQueryResponse(ctx context.Context, accessToken string, variableName string, reqDTO PublicDashboardVariableQueryDTO) (*PublicDashboardVariableQueryResponse, error)
+	ListVariables(ctx context.Context, accessToken string) ([]PublicDashboardVariable, error)
+	GetMetadata(ctx context.Context, accessToken string) (*PublicDashboardMetadata, error)
+	GetPanelContent(ctx context.Context, accessToken string, panelId int64, reqDTO PublicDashboardPanelContentDTO) (*PanelContent, error)
 	GetOrgIdByAccessToken(ctx context.Context, accessToken string) (int64, error)
+	RecordViewerHeartbeat(ctx context.Context, accessToken string, sessionId string) error
+	GetViewerChallenge(ctx context.Context, accessToken string, client ViewerClient) (*ViewerChallenge, error)
+	IssueViewerToken(ctx context.Context, accessToken string, client ViewerClient, solution *ViewerChallengeSolution) (*ViewerToken, error)
+	ValidateViewerToken(ctx context.Context, accessToken string, token string, client ViewerClient) error
+	GetStats(ctx context.Context, orgId int64, dashboardUid string, uid string, topN int) (*PublicDashboardStats, error)
+	RecordAuditLogEntry(ctx context.Context, accessToken string, event AuditLogEvent)
+	DashboardChanged(orgId int64, dashboardUid string)
+	PublicDashboardChanged(uid string)
+	GetAuditLog(ctx context.Context, orgId int64, dashboardUid string, uid string, query *AuditLogQuery) (*AuditLogResponseWithPagination, error)
 	NewPublicDashboardAccessToken(ctx context.Context) (string, error)
 	NewPublicDashboardUid(ctx context.Context) (string, error)
 
@@ -58,9 +88,15 @@ type Store interface {
 	Find(ctx context.Context, uid string) (*PublicDashboard, error)
 	FindByAccessToken(ctx context.Context, accessToken string) (*PublicDashboard, error)
 	FindByDashboardUid(ctx context.Context, orgId int64, dashboardUid string) (*PublicDashboard, error)
+	FindBySlug(ctx context.Context, slug string) (*PublicDashboard, error)
 	FindAll(ctx context.Context, query *PublicDashboardListQuery) (*PublicDashboardListResponseWithPagination, error)
+	FindAllAcrossOrgs(ctx context.Context) ([]*PublicDashboard, error)
+	FindAllAcrossOrgsWithPagination(ctx context.Context, query *AdminPublicDashboardListQuery) (*AdminPublicDashboardListResponseWithPagination, error)
+	Count(ctx context.Context, scopeParams *quota.ScopeParameters) (*quota.Map, error)
 	Create(ctx context.Context, cmd SavePublicDashboardCommand) (int64, error)
 	Update(ctx context.Context, cmd SavePublicDashboardCommand) (int64, error)
+	Patch(ctx context.Context, cmd PatchPublicDashboardCommand) (int64, error)
+	RotateAccessToken(ctx context.Context, cmd RotateAccessTokenCommand) (int64, error)
 	Delete(ctx context.Context, uid string) (int64, error)
 	DeleteByDashboardUIDs(ctx context.Context, orgId int64, dashboardUIDs []string) error
 
@@ -68,6 +104,17 @@ type Store interface {
 	ExistsEnabledByAccessToken(ctx context.Context, accessToken string) (bool, error)
 	ExistsEnabledByDashboardUid(ctx context.Context, dashboardUid string) (bool, error)
 	GetMetrics(ctx context.Context) (*Metrics, error)
+	UpdateLastAccessedAt(ctx context.Context, uid string, lastAccessedAt time.Time) error
+	FindEnabled(ctx context.Context) ([]*PublicDashboard, error)
+	FindEnabledInactiveSince(ctx context.Context, since time.Time) ([]*PublicDashboard, error)
+	FindEnabledExpiredBefore(ctx context.Context, before time.Time) ([]*PublicDashboard, error)
+	FindEnabledWithAccessTokenCreatedBefore(ctx context.Context, before time.Time) ([]*PublicDashboard, error)
+	FlagAccessTokenRotationDue(ctx context.Context, uid string, accessToken string) (int64, error)
+	InsertAuditLogEntry(ctx context.Context, entry *AuditLogEntry) error
+	FindAuditLog(ctx context.Context, query *AuditLogQuery) (*AuditLogResponseWithPagination, error)
+	DeleteAuditLogBefore(ctx context.Context, before time.Time) (int64, error)
+	AddUsage(ctx context.Context, usage []PublicDashboardUsage) error
+	FindUsage(ctx context.Context, uid string) (*PublicDashboardUsage, error)
 }
 
 //go:generate mockery --name Middleware --structname FakePublicDashboardMiddleware --inpackage --filename public_dashboard_middleware_mock.go
@@ -77,3 +124,24 @@ type Middleware interface {
 	HandleAccessView(c *contextmodel.ReqContext)
 	HandleConfirmAccessView(c *contextmodel.ReqContext)
 }
+
+// GeoIPProvider resolves the country a request comes from. It's used to enforce the geo restrictions of public
+// dashboards and can be backed by a GeoIP database or by headers set by a trusted proxy
+//
+//go:generate mockery --name GeoIPProvider --structname FakeGeoIPProvider --inpackage --filename geoip_provider_mock.go
+type GeoIPProvider interface {
+	// LookupCountry returns the ISO 3166-1 alpha-2 code of the country of the client, or an empty string when unknown
+	LookupCountry(ctx context.Context, req *http.Request) (string, error)
+}
+
+// ViewerChallenger challenges viewers before they get viewer tokens, to keep bots from scraping public dashboards and
+// running up the cost of their datasources. It can be backed by a proof-of-work or by a captcha service
+//
+//go:generate mockery --name ViewerChallenger --structname FakeViewerChallenger --inpackage --filename viewer_challenger_mock.go
+type ViewerChallenger interface {
+	// NewChallenge returns the challenge viewers of the public dashboard solve, of type none when they aren't challenged
+	NewChallenge(ctx context.Context, pubdash *PublicDashboard, client ViewerClient) (*ViewerChallenge, error)
+	// VerifySolution checks the solution of a challenge returned by NewChallenge. It accepts any solution when
+	// viewers of the public dashboard aren't challenged
+	VerifySolution(ctx context.Context, pubdash *PublicDashboard, client ViewerClient, solution *ViewerChallengeSolution) error
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/metric"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+const maxCachedAccessTokens = 10000
+
+// accessTokenCache keeps the public dashboards found by access token or slug with their dashboards for a short time,
+// so the queries, annotations and variables of popular public dashboards don't look them up again. Entries are removed
+// when the public dashboard or its dashboard changes, a TTL of 0 or a nil cache doesn't keep anything
+type accessTokenCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cachedPublicDashboard
+	// generation changes with every removal, lookups started before it aren't cached as they may have read the
+	// removed state
+	generation uint64
+}
+
+type cachedPublicDashboard struct {
+	pubdash   *PublicDashboard
+	dashboard *dashboards.Dashboard
+	expires   time.Time
+}
+
+func newAccessTokenCache(ttl time.Duration) *accessTokenCache {
+	return &accessTokenCache{
+		ttl:     ttl,
+		entries: map[string]cachedPublicDashboard{},
+	}
+}
+
+// get returns copies of the cached public dashboard and dashboard, callers change the dashboard before serving it
+func (c *accessTokenCache) get(key string, now time.Time) (*PublicDashboard, *dashboards.Dashboard, bool) {
+	if c == nil || c.ttl <= 0 {
+		return nil, nil, false
+	}
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+
+	if !ok || now.After(entry.expires) {
+		metric.AccessTokenCacheRequestsTotal.WithLabelValues(cacheResultMiss).Inc()
+		return nil, nil, false
+	}
+	metric.AccessTokenCacheRequestsTotal.WithLabelValues(cacheResultHit).Inc()
+	pubdash, dashboard := copyPublicDashboardAndDashboard(entry.pubdash, entry.dashboard)
+	return pubdash, dashboard, true
+}
+
+// currentGeneration returns the generation to pass to set for a lookup starting now
+func (c *accessTokenCache) currentGeneration() uint64 {
+	if c == nil {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// set caches copies of the public dashboard and dashboard found by a lookup started at the generation, unless
+// entries were removed since
+func (c *accessTokenCache) set(key string, pubdash *PublicDashboard, dashboard *dashboards.Dashboard, generation uint64, now time.Time) {
+	if c == nil || c.ttl <= 0 {
+		return
+	}
+	pubdash, dashboard = copyPublicDashboardAndDashboard(pubdash, dashboard)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+	if len(c.entries) >= maxCachedAccessTokens {
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= maxCachedAccessTokens {
+			c.entries = map[string]cachedPublicDashboard{}
+		}
+	}
+	expires := now.Add(c.ttl)
+	// the previous access token of a rotation stops working at the end of its grace period
+	if key == pubdash.PreviousAccessToken && pubdash.PreviousAccessTokenExpiresAt.Before(expires) {
+		expires = pubdash.PreviousAccessTokenExpiresAt
+	}
+	c.entries[key] = cachedPublicDashboard{pubdash: pubdash, dashboard: dashboard, expires: expires}
+}
+
+// forgetPublicDashboard removes the public dashboard from the cache, under all its access tokens and its slug
+func (c *accessTokenCache) forgetPublicDashboard(uid string) {
+	c.forget(func(entry cachedPublicDashboard) bool {
+		return entry.pubdash.Uid == uid
+	})
+}
+
+// touchPublicDashboard updates when the cached public dashboard was last accessed, without removing it from the cache
+func (c *accessTokenCache) touchPublicDashboard(uid string, lastAccessedAt time.Time) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// cached public dashboards are replaced rather than changed, get copies them after releasing the lock
+	for k, entry := range c.entries {
+		if entry.pubdash.Uid == uid {
+			pubdash := *entry.pubdash
+			pubdash.LastAccessedAt = lastAccessedAt
+			entry.pubdash = &pubdash
+			c.entries[k] = entry
+		}
+	}
+}
+
+// forgetDashboard removes the public dashboards of the dashboard from the cache
+func (c *accessTokenCache) forgetDashboard(orgId int64, dashboardUid string) {
+	c.forget(func(entry cachedPublicDashboard) bool {
+		return entry.pubdash.OrgId == orgId && entry.pubdash.DashboardUid == dashboardUid
+	})
+}
+
+func (c *accessTokenCache) forget(match func(entry cachedPublicDashboard) bool) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for k, entry := range c.entries {
+		if match(entry) {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// DashboardChanged removes the cached public dashboards of the dashboard after it was saved or deleted
+func (pd *PublicDashboardServiceImpl) DashboardChanged(orgId int64, dashboardUid string) {
+	pd.accessTokens.forgetDashboard(orgId, dashboardUid)
+}
+
+// PublicDashboardChanged removes the public dashboard from the cache of every instance after it was changed, so other
+// instances don't keep serving it as it was until the TTL expires
+func (pd *PublicDashboardServiceImpl) PublicDashboardChanged(uid string) {
+	pd.accessTokens.forgetPublicDashboard(uid)
+
+	if pd.liveBroadcastChanged != nil {
+		if err := pd.liveBroadcastChanged(uid); err != nil {
+			pd.log.Warn("Failed to invalidate changed public dashboard on other instances", "publicDashboardUid", uid, "error", err)
+		}
+	}
+}
+
+func copyPublicDashboardAndDashboard(pubdash *PublicDashboard, dashboard *dashboards.Dashboard) (*PublicDashboard, *dashboards.Dashboard) {
+	p := *pubdash
+	d := *dashboard
+	d.Data = dashboard.Data.DeepCopy()
+	return &p, &d
+}
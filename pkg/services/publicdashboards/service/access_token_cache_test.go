@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/licensing/licensingtest"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestAccessTokenCache(t *testing.T) {
+	now := time.Now()
+	pubdash := &PublicDashboard{Uid: "pubdash1", OrgId: 1, DashboardUid: "dash1", AccessToken: "token1", IsEnabled: true}
+	dashboard := &dashboards.Dashboard{UID: "dash1", OrgID: 1, Data: simplejson.NewFromAny(map[string]any{"title": "dash"})}
+
+	t.Run("returns copies of the cached public dashboard until it expires", func(t *testing.T) {
+		cache := newAccessTokenCache(time.Minute)
+		cache.set("token1", pubdash, dashboard, cache.currentGeneration(), now)
+
+		p, d, ok := cache.get("token1", now.Add(30*time.Second))
+		require.True(t, ok)
+		assert.Equal(t, pubdash, p)
+		assert.Equal(t, "dash", d.Data.Get("title").MustString())
+
+		d.Data.Set("title", "changed")
+		_, d, _ = cache.get("token1", now)
+		assert.Equal(t, "dash", d.Data.Get("title").MustString(), "changes of callers aren't cached")
+
+		_, _, ok = cache.get("token1", now.Add(2*time.Minute))
+		assert.False(t, ok)
+	})
+
+	t.Run("previous access tokens expire with their grace period", func(t *testing.T) {
+		cache := newAccessTokenCache(time.Minute)
+		rotated := &PublicDashboard{Uid: "pubdash1", AccessToken: "token2", PreviousAccessToken: "token1", PreviousAccessTokenExpiresAt: now.Add(10 * time.Second)}
+		cache.set("token1", rotated, dashboard, cache.currentGeneration(), now)
+
+		_, _, ok := cache.get("token1", now.Add(5*time.Second))
+		assert.True(t, ok)
+		_, _, ok = cache.get("token1", now.Add(20*time.Second))
+		assert.False(t, ok)
+	})
+
+	t.Run("forgets changed public dashboards and dashboards", func(t *testing.T) {
+		cache := newAccessTokenCache(time.Minute)
+		cache.set("token1", pubdash, dashboard, cache.currentGeneration(), now)
+		cache.set("status-page", pubdash, dashboard, cache.currentGeneration(), now)
+		other := &PublicDashboard{Uid: "pubdash2", OrgId: 1, DashboardUid: "dash2", AccessToken: "token2"}
+		cache.set("token2", other, dashboard, cache.currentGeneration(), now)
+
+		cache.forgetPublicDashboard("pubdash1")
+		_, _, ok := cache.get("token1", now)
+		assert.False(t, ok)
+		_, _, ok = cache.get("status-page", now)
+		assert.False(t, ok, "the slug is forgotten with the access token")
+
+		cache.forgetDashboard(2, "dash2")
+		_, _, ok = cache.get("token2", now)
+		assert.True(t, ok, "dashboards are forgotten by org")
+		cache.forgetDashboard(1, "dash2")
+		_, _, ok = cache.get("token2", now)
+		assert.False(t, ok)
+	})
+
+	t.Run("updates the last access of cached public dashboards in place", func(t *testing.T) {
+		cache := newAccessTokenCache(time.Minute)
+		cache.set("token1", pubdash, dashboard, cache.currentGeneration(), now)
+		cache.set("status-page", pubdash, dashboard, cache.currentGeneration(), now)
+
+		cache.touchPublicDashboard("pubdash1", now)
+		for _, key := range []string{"token1", "status-page"} {
+			p, _, ok := cache.get(key, now)
+			require.True(t, ok)
+			assert.Equal(t, now, p.LastAccessedAt)
+		}
+		assert.True(t, pubdash.LastAccessedAt.IsZero(), "the public dashboard of the caller isn't changed")
+	})
+
+	t.Run("doesn't cache lookups started before a change", func(t *testing.T) {
+		cache := newAccessTokenCache(time.Minute)
+		generation := cache.currentGeneration()
+		cache.forgetPublicDashboard("pubdash1")
+		cache.set("token1", pubdash, dashboard, generation, now)
+
+		_, _, ok := cache.get("token1", now)
+		assert.False(t, ok)
+	})
+
+	t.Run("disabled and nil caches don't keep anything", func(t *testing.T) {
+		cache := newAccessTokenCache(0)
+		cache.set("token1", pubdash, dashboard, cache.currentGeneration(), now)
+		_, _, ok := cache.get("token1", now)
+		assert.False(t, ok)
+
+		var nilCache *accessTokenCache
+		nilCache.set("token1", pubdash, dashboard, nilCache.currentGeneration(), now)
+		nilCache.forgetPublicDashboard("pubdash1")
+		_, _, ok = nilCache.get("token1", now)
+		assert.False(t, ok)
+	})
+}
+
+func TestFindEnabledPublicDashboardAndDashboardByAccessTokenCached(t *testing.T) {
+	pubdash := &PublicDashboard{Uid: "pubdash1", OrgId: 1, DashboardUid: "dash1", AccessToken: "token1", IsEnabled: true}
+	dashboard := &dashboards.Dashboard{UID: "dash1", OrgID: 1, Data: simplejson.New()}
+
+	fakeStore := &FakePublicDashboardStore{}
+	fakeStore.On("FindByAccessToken", mock.Anything, "token1").Return(pubdash, nil)
+	fakeDashboardService := &dashboards.FakeDashboardService{}
+	fakeDashboardService.On("GetDashboard", mock.Anything, mock.Anything).Return(dashboard, nil)
+	license := licensingtest.NewFakeLicensing()
+	license.On("FeatureEnabled", FeaturePublicDashboardsEmailSharing).Return(false)
+
+	service := &PublicDashboardServiceImpl{
+		log:              log.NewNopLogger(),
+		cfg:              setting.NewCfg(),
+		store:            fakeStore,
+		dashboardService: fakeDashboardService,
+		license:          license,
+		accessTokens:     newAccessTokenCache(time.Minute),
+	}
+
+	for i := 0; i < 3; i++ {
+		_, _, err := service.FindEnabledPublicDashboardAndDashboardByAccessToken(context.Background(), "token1")
+		require.NoError(t, err)
+	}
+	fakeStore.AssertNumberOfCalls(t, "FindByAccessToken", 1)
+	fakeDashboardService.AssertNumberOfCalls(t, "GetDashboard", 1)
+
+	service.DashboardChanged(1, "dash1")
+	_, _, err := service.FindEnabledPublicDashboardAndDashboardByAccessToken(context.Background(), "token1")
+	require.NoError(t, err)
+	fakeStore.AssertNumberOfCalls(t, "FindByAccessToken", 2)
+}
+
+func TestPublicDashboardChanged(t *testing.T) {
+	pubdash := &PublicDashboard{Uid: "pubdash1", OrgId: 1, DashboardUid: "dash1", AccessToken: "token1", IsEnabled: true}
+	dashboard := &dashboards.Dashboard{UID: "dash1", OrgID: 1, Data: simplejson.New()}
+
+	t.Run("removes the public dashboard from the cache and broadcasts the change", func(t *testing.T) {
+		service := &PublicDashboardServiceImpl{log: log.NewNopLogger(), accessTokens: newAccessTokenCache(time.Minute)}
+		now := time.Now()
+		service.accessTokens.set("token1", pubdash, dashboard, service.accessTokens.currentGeneration(), now)
+
+		var broadcast []string
+		service.liveBroadcastChanged = func(uid string) error {
+			broadcast = append(broadcast, uid)
+			return nil
+		}
+
+		service.PublicDashboardChanged("pubdash1")
+
+		_, _, ok := service.accessTokens.get("token1", now)
+		assert.False(t, ok)
+		assert.Equal(t, []string{"pubdash1"}, broadcast)
+	})
+
+	t.Run("removes the public dashboard from the cache when the broadcast fails", func(t *testing.T) {
+		service := &PublicDashboardServiceImpl{log: log.NewNopLogger(), accessTokens: newAccessTokenCache(time.Minute)}
+		now := time.Now()
+		service.accessTokens.set("token1", pubdash, dashboard, service.accessTokens.currentGeneration(), now)
+		service.liveBroadcastChanged = func(string) error { return errors.New("survey timeout") }
+
+		service.PublicDashboardChanged("pubdash1")
+
+		_, _, ok := service.accessTokens.get("token1", now)
+		assert.False(t, ok)
+	})
+}
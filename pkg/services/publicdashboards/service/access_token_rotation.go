@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	accessTokenRotationCheckInterval = time.Hour
+	accessTokenRotationLockName      = "rotate old public dashboard access tokens"
+	accessTokenRotationEmailTemplate = "public_dashboard_access_token_rotation"
+	// accessTokenRotationLogin is the login the rotations of the policy are recorded with
+	accessTokenRotationLogin = "access_token_max_age_policy"
+)
+
+// AccessTokenRotationService flags the access tokens of public dashboards older than the max age configured in
+// [public_dashboards], rotates them when the policy says so, and notifies the creator of the public dashboards by email
+type AccessTokenRotationService struct {
+	log              log.Logger
+	cfg              *setting.Cfg
+	store            publicdashboards.Store
+	service          publicdashboards.Service
+	dashboardService dashboards.DashboardService
+	userService      user.Service
+	emailSender      notifications.EmailSender
+	serverLock       serverLocker
+}
+
+func ProvideAccessTokenRotationService(
+	cfg *setting.Cfg,
+	store publicdashboards.Store,
+	service publicdashboards.Service,
+	dashboardService dashboards.DashboardService,
+	userService user.Service,
+	emailSender notifications.EmailSender,
+	serverLock *serverlock.ServerLockService,
+) *AccessTokenRotationService {
+	return &AccessTokenRotationService{
+		log:              log.New("publicdashboards.accesstokenrotation"),
+		cfg:              cfg,
+		store:            store,
+		service:          service,
+		dashboardService: dashboardService,
+		userService:      userService,
+		emailSender:      emailSender,
+		serverLock:       serverLock,
+	}
+}
+
+// IsDisabled returns true when neither a global nor an org policy limits the age of access tokens
+func (s *AccessTokenRotationService) IsDisabled() bool {
+	if !s.cfg.PublicDashboardsEnabled {
+		return true
+	}
+	_, enabled := s.shortestMaxAge()
+	return !enabled
+}
+
+func (s *AccessTokenRotationService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(accessTokenRotationCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.serverLock.LockAndExecute(ctx, accessTokenRotationLockName, accessTokenRotationCheckInterval/2, s.enforceMaxAge); err != nil {
+			s.log.Error("Failed to enforce the max age of public dashboard access tokens", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *AccessTokenRotationService) enforceMaxAge(ctx context.Context) {
+	shortest, enabled := s.shortestMaxAge()
+	if !enabled {
+		return
+	}
+
+	now := time.Now()
+	pubdashes, err := s.store.FindEnabledWithAccessTokenCreatedBefore(ctx, now.Add(-shortest))
+	if err != nil {
+		s.log.Error("Failed to find public dashboards with old access tokens", "error", err)
+		return
+	}
+
+	for _, pubdash := range pubdashes {
+		maxAge := s.maxAge(pubdash.OrgId)
+		if maxAge <= 0 || accessTokenCreatedAt(pubdash).After(now.Add(-maxAge)) {
+			continue
+		}
+
+		if s.cfg.PublicDashboardsAccessTokenMaxAgeAction == "rotate" {
+			s.rotate(ctx, pubdash, maxAge)
+			continue
+		}
+		s.flag(ctx, pubdash, maxAge)
+	}
+}
+
+// flag flags the access token as due for rotation, its creator is only notified the first time
+func (s *AccessTokenRotationService) flag(ctx context.Context, pubdash *PublicDashboard, maxAge time.Duration) {
+	affectedRows, err := s.store.FlagAccessTokenRotationDue(ctx, pubdash.Uid, pubdash.AccessToken)
+	if err != nil {
+		s.log.Error("Failed to flag old public dashboard access token", "publicDashboardUid", pubdash.Uid, "error", err)
+		return
+	}
+	if affectedRows == 0 {
+		return
+	}
+	s.service.PublicDashboardChanged(pubdash.Uid)
+
+	s.log.Info("Flagged old public dashboard access token", "publicDashboardUid", pubdash.Uid, "dashboardUid", pubdash.DashboardUid, "orgId", pubdash.OrgId, "maxAge", maxAge)
+	s.notifyOwner(ctx, pubdash, maxAge, false)
+}
+
+// rotate replaces the access token, the previous one keeps working during the longest grace period so embeds can be
+// updated
+func (s *AccessTokenRotationService) rotate(ctx context.Context, pubdash *PublicDashboard, maxAge time.Duration) {
+	rotator := &user.SignedInUser{OrgID: pubdash.OrgId, Login: accessTokenRotationLogin}
+	dto := RotateAccessTokenDTO{GracePeriodSeconds: int64(maxAccessTokenGracePeriod / time.Second)}
+	if _, err := s.service.RotateAccessToken(ctx, rotator, pubdash.Uid, pubdash.DashboardUid, dto); err != nil {
+		s.log.Error("Failed to rotate old public dashboard access token", "publicDashboardUid", pubdash.Uid, "error", err)
+		return
+	}
+
+	s.log.Info("Rotated old public dashboard access token", "publicDashboardUid", pubdash.Uid, "dashboardUid", pubdash.DashboardUid, "orgId", pubdash.OrgId, "maxAge", maxAge)
+	s.notifyOwner(ctx, pubdash, maxAge, true)
+}
+
+// notifyOwner sends an email to the creator of the public dashboard. Failures are only logged
+func (s *AccessTokenRotationService) notifyOwner(ctx context.Context, pubdash *PublicDashboard, maxAge time.Duration, rotated bool) {
+	owner, err := s.userService.GetByID(ctx, &user.GetUserByIDQuery{ID: pubdash.CreatedBy})
+	if err != nil || owner.Email == "" {
+		s.log.Warn("Can't notify the creator of a public dashboard with an old access token", "publicDashboardUid", pubdash.Uid, "userId", pubdash.CreatedBy, "error", err)
+		return
+	}
+
+	dash, err := identity.WithServiceIdentityFn(ctx, pubdash.OrgId, func(ctx context.Context) (*dashboards.Dashboard, error) {
+		return s.dashboardService.GetDashboard(ctx, &dashboards.GetDashboardQuery{UID: pubdash.DashboardUid, OrgID: pubdash.OrgId})
+	})
+	if err != nil {
+		s.log.Warn("Can't find the dashboard of a public dashboard with an old access token", "publicDashboardUid", pubdash.Uid, "error", err)
+		return
+	}
+
+	err = s.emailSender.SendEmailCommandHandler(ctx, &notifications.SendEmailCommand{
+		To:       []string{owner.Email},
+		Template: accessTokenRotationEmailTemplate,
+		Data: map[string]any{
+			"Name":            owner.NameOrFallback(),
+			"DashboardTitle":  dash.Title,
+			"DashboardUrl":    dashboards.GetFullDashboardURL(dash.UID, dash.Slug),
+			"MaxAgeDays":      int(maxAge.Hours() / 24),
+			"Rotated":         rotated,
+			"GracePeriodDays": int(maxAccessTokenGracePeriod.Hours() / 24),
+		},
+	})
+	if err != nil {
+		s.log.Warn("Failed to notify the creator of a public dashboard with an old access token", "publicDashboardUid", pubdash.Uid, "error", err)
+	}
+}
+
+// maxAge returns the max age of the access tokens of the org, 0 if they never get too old
+func (s *AccessTokenRotationService) maxAge(orgID int64) time.Duration {
+	if maxAge, ok := s.cfg.PublicDashboardsAccessTokenMaxAgeByOrg[orgID]; ok {
+		return maxAge
+	}
+	return s.cfg.PublicDashboardsAccessTokenMaxAge
+}
+
+func (s *AccessTokenRotationService) shortestMaxAge() (time.Duration, bool) {
+	shortest := s.cfg.PublicDashboardsAccessTokenMaxAge
+	for _, maxAge := range s.cfg.PublicDashboardsAccessTokenMaxAgeByOrg {
+		if maxAge > 0 && (shortest <= 0 || maxAge < shortest) {
+			shortest = maxAge
+		}
+	}
+
+	return shortest, shortest > 0
+}
+
+// accessTokenCreatedAt is when the access token was created or rotated, access tokens created before it was tracked
+// are as old as their public dashboard
+func accessTokenCreatedAt(pubdash *PublicDashboard) time.Time {
+	if pubdash.AccessTokenCreatedAt.IsZero() {
+		return pubdash.CreatedAt
+	}
+	return pubdash.AccessTokenCreatedAt
+}
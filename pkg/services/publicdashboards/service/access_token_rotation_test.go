@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/user/usertest"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestAccessTokenRotationServiceEnforceMaxAge(t *testing.T) {
+	now := time.Now()
+	daysAgo := func(days int) time.Time { return now.AddDate(0, 0, -days) }
+
+	setup := func(t *testing.T, cfg *setting.Cfg, pubdashes []*PublicDashboard) (*AccessTokenRotationService, *FakePublicDashboardStore, *FakePublicDashboardService, *notifications.NotificationServiceMock) {
+		store := &FakePublicDashboardStore{}
+		store.On("FindEnabledWithAccessTokenCreatedBefore", mock.Anything, mock.Anything).Return(pubdashes, nil)
+
+		pdService := NewFakePublicDashboardService(t)
+
+		dashboardService := &dashboards.FakeDashboardService{}
+		dashboardService.On("GetDashboard", mock.Anything, mock.Anything).Return(&dashboards.Dashboard{UID: "dash", Slug: "sales", Title: "Sales"}, nil).Maybe()
+
+		userService := usertest.NewUserServiceFake()
+		userService.ExpectedUser = &user.User{ID: 7, Email: "owner@example.com", Name: "Owner"}
+
+		emailSender := notifications.MockNotificationService()
+
+		return &AccessTokenRotationService{
+			log:              log.NewNopLogger(),
+			cfg:              cfg,
+			store:            store,
+			service:          pdService,
+			dashboardService: dashboardService,
+			userService:      userService,
+			emailSender:      emailSender,
+		}, store, pdService, emailSender
+	}
+
+	t.Run("flags access tokens older than the global policy and notifies the creator", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.PublicDashboardsAccessTokenMaxAge = 90 * 24 * time.Hour
+		cfg.PublicDashboardsAccessTokenMaxAgeAction = "notify"
+
+		old := &PublicDashboard{Uid: "old", OrgId: 1, DashboardUid: "dash", AccessToken: "token1", IsEnabled: true, CreatedBy: 7,
+			CreatedAt: daysAgo(120)}
+		rotated := &PublicDashboard{Uid: "rotated", OrgId: 1, DashboardUid: "dash", AccessToken: "token2", IsEnabled: true, CreatedBy: 7,
+			CreatedAt: daysAgo(120), AccessTokenCreatedAt: daysAgo(10)}
+
+		service, store, pdService, emailSender := setup(t, cfg, []*PublicDashboard{old, rotated})
+		store.On("FlagAccessTokenRotationDue", mock.Anything, "old", "token1").Return(int64(1), nil)
+		pdService.On("PublicDashboardChanged", "old").Return()
+
+		service.enforceMaxAge(context.Background())
+
+		store.AssertNumberOfCalls(t, "FlagAccessTokenRotationDue", 1)
+		assert.Equal(t, []string{"owner@example.com"}, emailSender.Email.To)
+		assert.Equal(t, "public_dashboard_access_token_rotation", emailSender.Email.Template)
+		assert.Equal(t, "Sales", emailSender.Email.Data["DashboardTitle"])
+		assert.Equal(t, 90, emailSender.Email.Data["MaxAgeDays"])
+		assert.Equal(t, false, emailSender.Email.Data["Rotated"])
+	})
+
+	t.Run("notifies the creator only once", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.PublicDashboardsAccessTokenMaxAge = 90 * 24 * time.Hour
+		cfg.PublicDashboardsAccessTokenMaxAgeAction = "notify"
+
+		flagged := &PublicDashboard{Uid: "flagged", OrgId: 1, AccessToken: "token1", IsEnabled: true, CreatedBy: 7,
+			CreatedAt: daysAgo(120), AccessTokenRotationDue: true}
+
+		service, store, _, emailSender := setup(t, cfg, []*PublicDashboard{flagged})
+		store.On("FlagAccessTokenRotationDue", mock.Anything, "flagged", "token1").Return(int64(0), nil)
+
+		service.enforceMaxAge(context.Background())
+
+		assert.Empty(t, emailSender.Email.To)
+	})
+
+	t.Run("rotates access tokens with a grace period when the policy says so", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.PublicDashboardsAccessTokenMaxAge = 90 * 24 * time.Hour
+		cfg.PublicDashboardsAccessTokenMaxAgeAction = "rotate"
+
+		old := &PublicDashboard{Uid: "old", OrgId: 1, DashboardUid: "dash", AccessToken: "token1", IsEnabled: true, CreatedBy: 7,
+			CreatedAt: daysAgo(120)}
+
+		service, store, pdService, emailSender := setup(t, cfg, []*PublicDashboard{old})
+		pdService.On("RotateAccessToken", mock.Anything, mock.Anything, "old", "dash", RotateAccessTokenDTO{GracePeriodSeconds: 7 * 24 * 60 * 60}).
+			Return(&PublicDashboard{Uid: "old"}, nil)
+
+		service.enforceMaxAge(context.Background())
+
+		rotator := pdService.Calls[0].Arguments.Get(1).(*user.SignedInUser)
+		assert.Equal(t, int64(1), rotator.OrgID)
+		store.AssertNotCalled(t, "FlagAccessTokenRotationDue", mock.Anything, mock.Anything, mock.Anything)
+		assert.Equal(t, true, emailSender.Email.Data["Rotated"])
+		assert.Equal(t, 7, emailSender.Email.Data["GracePeriodDays"])
+	})
+
+	t.Run("applies org overrides", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.PublicDashboardsAccessTokenMaxAge = 90 * 24 * time.Hour
+		cfg.PublicDashboardsAccessTokenMaxAgeByOrg = map[int64]time.Duration{2: 0, 3: 7 * 24 * time.Hour}
+		cfg.PublicDashboardsAccessTokenMaxAgeAction = "notify"
+
+		exempt := &PublicDashboard{Uid: "exempt", OrgId: 2, AccessToken: "token1", IsEnabled: true, CreatedAt: daysAgo(120)}
+		strict := &PublicDashboard{Uid: "strict", OrgId: 3, AccessToken: "token2", IsEnabled: true, CreatedAt: daysAgo(10)}
+
+		service, store, pdService, _ := setup(t, cfg, []*PublicDashboard{exempt, strict})
+		store.On("FlagAccessTokenRotationDue", mock.Anything, "strict", "token2").Return(int64(1), nil)
+		pdService.On("PublicDashboardChanged", "strict").Return()
+
+		service.enforceMaxAge(context.Background())
+
+		store.AssertNumberOfCalls(t, "FlagAccessTokenRotationDue", 1)
+		before := store.Calls[0].Arguments.Get(1).(time.Time)
+		assert.WithinDuration(t, daysAgo(7), before, time.Minute)
+	})
+
+	t.Run("does nothing without a policy", func(t *testing.T) {
+		service, store, _, _ := setup(t, setting.NewCfg(), nil)
+
+		service.enforceMaxAge(context.Background())
+
+		store.AssertNotCalled(t, "FindEnabledWithAccessTokenCreatedBefore", mock.Anything, mock.Anything)
+		require.True(t, service.IsDisabled())
+	})
+}
+
+func TestGenerateAccessTokenWithSettings(t *testing.T) {
+	testCases := []struct {
+		name     string
+		length   int
+		alphabet string
+		pattern  string
+	}{
+		{name: "default settings generate uuids", length: 32, alphabet: "hex", pattern: `^[0-9a-f]{32}$`},
+		{name: "longer hex access tokens", length: 48, alphabet: "hex", pattern: `^[0-9a-f]{48}$`},
+		{name: "base62 access tokens", length: 40, alphabet: "base62", pattern: `^[0-9A-Za-z]{40}$`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := setting.NewCfg()
+			cfg.PublicDashboardsAccessTokenLength = tc.length
+			cfg.PublicDashboardsAccessTokenAlphabet = tc.alphabet
+			service := &PublicDashboardServiceImpl{cfg: cfg}
+
+			accessToken, err := service.generateAccessToken()
+			require.NoError(t, err)
+			assert.Regexp(t, tc.pattern, accessToken)
+			assert.True(t, validation.IsValidAccessToken(accessToken))
+		})
+	}
+}
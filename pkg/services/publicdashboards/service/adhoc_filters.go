@@ -0,0 +1,156 @@
+package service
+
+import (
+	"encoding/json"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+// maxAdhocFilters is the number of filters a viewer can set on an ad hoc filters variable
+const maxAdhocFilters = 20
+
+// adhocScopeOperators maps the operators of ad hoc filters to the operators of the filters sent in the query model of
+// datasources applying them like scope filters
+var adhocScopeOperators = map[string]string{
+	"=":   "equals",
+	"!=":  "not-equals",
+	"=~":  "regex-match",
+	"!~":  "regex-not-match",
+	"=|":  "one-of",
+	"!=|": "not-one-of",
+}
+
+// scopeFilterDatasourceTypes are the datasources reading ad hoc filters with the operators of scope filters
+var scopeFilterDatasourceTypes = map[string]bool{
+	"prometheus": true,
+}
+
+// adhocVariable holds the parts of an ad hoc filters variable used to validate and apply filters
+type adhocVariable struct {
+	Name        string                  `json:"name"`
+	Type        string                  `json:"type"`
+	Datasource  map[string]interface{}  `json:"datasource"`
+	Filters     []models.AdhocFilterDTO `json:"filters"`
+	BaseFilters []models.AdhocFilterDTO `json:"baseFilters"`
+	DefaultKeys []variableOption        `json:"defaultKeys"`
+}
+
+// adhocVariables returns the ad hoc filters variables of the dashboard in the order of the dashboard
+func adhocVariables(dashboard *simplejson.Json) []adhocVariable {
+	var variables []adhocVariable
+	for _, v := range dashboardVariableList(dashboard) {
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			continue
+		}
+		var variable adhocVariable
+		if err := json.Unmarshal(encoded, &variable); err != nil || variable.Type != adhocVariableType || variable.Name == "" {
+			continue
+		}
+		variables = append(variables, variable)
+	}
+	return variables
+}
+
+// tagKeys returns the keys viewers can filter on: the static keys of the variable when it defines them, otherwise the
+// keys of the filters saved in the dashboard. Public dashboards can't list the keys of the datasource on behalf of
+// anonymous viewers, so keys are limited to the ones the dashboard author picked
+func (v adhocVariable) tagKeys() map[string]bool {
+	keys := map[string]bool{}
+	for _, k := range v.DefaultKeys {
+		key := convertInterfaceToString(k.Value)
+		if key == "" {
+			key = convertInterfaceToString(k.Text)
+		}
+		keys[key] = true
+	}
+	if len(keys) > 0 {
+		return keys
+	}
+
+	for _, f := range v.Filters {
+		keys[f.Key] = true
+	}
+	for _, f := range v.BaseFilters {
+		keys[f.Key] = true
+	}
+	return keys
+}
+
+// validateAdhocFilters checks the ad hoc filters sent by a viewer are set on ad hoc filters variables of the
+// dashboard, and only use known operators and the tag keys of their variable
+func validateAdhocFilters(dashboard *simplejson.Json, filters map[string][]models.AdhocFilterDTO) error {
+	variables := map[string]adhocVariable{}
+	for _, variable := range adhocVariables(dashboard) {
+		variables[variable.Name] = variable
+	}
+
+	for name, list := range filters {
+		variable, ok := variables[name]
+		if !ok {
+			return models.ErrInvalidAdhocFilter.Errorf("validateAdhocFilters: %s is not an ad hoc filters variable", name)
+		}
+		if len(list) > maxAdhocFilters {
+			return models.ErrInvalidAdhocFilter.Errorf("validateAdhocFilters: variable %s has more than %d filters", name, maxAdhocFilters)
+		}
+
+		keys := variable.tagKeys()
+		for _, f := range list {
+			if _, ok := adhocScopeOperators[f.Operator]; !ok {
+				return models.ErrInvalidAdhocFilter.Errorf("validateAdhocFilters: unknown operator %q", f.Operator)
+			}
+			if !keys[f.Key] {
+				return models.ErrInvalidAdhocFilter.Errorf("validateAdhocFilters: key %q is not a tag key of variable %s", f.Key, name)
+			}
+		}
+	}
+	return nil
+}
+
+// applyAdhocFilters adds the filters of the ad hoc filters variables to the queries of their datasource, like the
+// dashboard does before sending queries. Variables keep their saved filters unless the viewer sent filters for them,
+// and base filters always apply
+func applyAdhocFilters(dashboard *simplejson.Json, queries []*simplejson.Json, interpolator *templateInterpolator, filters map[string][]models.AdhocFilterDTO) {
+	for _, variable := range adhocVariables(dashboard) {
+		applied := variable.Filters
+		if sent, ok := filters[variable.Name]; ok {
+			applied = sent
+		}
+		applied = append(append([]models.AdhocFilterDTO{}, variable.BaseFilters...), applied...)
+		if len(applied) == 0 {
+			continue
+		}
+
+		uid, _ := variable.Datasource["uid"].(string)
+		uid = interpolator.interpolate(uid, nil, "")
+		if uid == "" {
+			continue
+		}
+		dsType, _ := variable.Datasource["type"].(string)
+
+		for _, query := range queries {
+			if getDataSourceUidFromJson(query) != uid {
+				continue
+			}
+
+			queryFilters := query.Get("adhocFilters").MustArray()
+			for _, f := range applied {
+				operator := f.Operator
+				if scopeOperator, ok := adhocScopeOperators[f.Operator]; ok && scopeFilterDatasourceTypes[dsType] {
+					operator = scopeOperator
+				}
+				filter := map[string]interface{}{"key": f.Key, "operator": operator, "value": f.Value}
+				if len(f.Values) > 0 {
+					values := make([]interface{}, len(f.Values))
+					for i, v := range f.Values {
+						values[i] = v
+					}
+					filter["values"] = values
+				}
+				queryFilters = append(queryFilters, filter)
+			}
+			query.Set("adhocFilters", queryFilters)
+		}
+	}
+}
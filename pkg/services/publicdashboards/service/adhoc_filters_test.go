@@ -0,0 +1,144 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+const adhocFiltersDashboard = `{
+	"templating": {
+		"list": [
+			{
+				"name": "ds",
+				"type": "datasource",
+				"query": "prometheus",
+				"current": {"text": "prom", "value": "prom"}
+			},
+			{
+				"name": "filters",
+				"type": "adhoc",
+				"datasource": {"uid": "${ds}", "type": "prometheus"},
+				"filters": [{"key": "job", "operator": "=", "value": "node"}],
+				"baseFilters": [{"key": "cluster", "operator": "=", "value": "eu"}]
+			},
+			{
+				"name": "static",
+				"type": "adhoc",
+				"datasource": {"uid": "influx", "type": "influxdb"},
+				"defaultKeys": [{"text": "host", "value": "host"}, {"text": "region"}],
+				"filters": []
+			}
+		]
+	}
+}`
+
+func TestValidateAdhocFilters(t *testing.T) {
+	dashboard, err := simplejson.NewJson([]byte(adhocFiltersDashboard))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name    string
+		filters map[string][]AdhocFilterDTO
+		valid   bool
+	}{
+		{
+			name:    "accepts the keys of the saved filters",
+			filters: map[string][]AdhocFilterDTO{"filters": {{Key: "job", Operator: "!=", Value: "api"}, {Key: "cluster", Operator: "=|", Values: []string{"eu", "us"}}}},
+			valid:   true,
+		},
+		{
+			name:    "accepts the static keys of the variable",
+			filters: map[string][]AdhocFilterDTO{"static": {{Key: "region", Operator: "=~", Value: "eu-.*"}}},
+			valid:   true,
+		},
+		{
+			name:    "accepts removing every filter",
+			filters: map[string][]AdhocFilterDTO{"filters": {}},
+			valid:   true,
+		},
+		{
+			name:    "rejects other keys",
+			filters: map[string][]AdhocFilterDTO{"static": {{Key: "job", Operator: "=", Value: "node"}}},
+		},
+		{
+			name:    "rejects unknown operators",
+			filters: map[string][]AdhocFilterDTO{"filters": {{Key: "job", Operator: "<", Value: "node"}}},
+		},
+		{
+			name:    "rejects variables that aren't ad hoc filters",
+			filters: map[string][]AdhocFilterDTO{"ds": {{Key: "job", Operator: "=", Value: "node"}}},
+		},
+		{
+			name:    "rejects too many filters",
+			filters: map[string][]AdhocFilterDTO{"filters": make([]AdhocFilterDTO, maxAdhocFilters+1)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateAdhocFilters(dashboard, tc.filters)
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidAdhocFilter)
+			}
+		})
+	}
+}
+
+func TestApplyAdhocFilters(t *testing.T) {
+	dashboard, err := simplejson.NewJson([]byte(adhocFiltersDashboard))
+	require.NoError(t, err)
+
+	newQueries := func() []*simplejson.Json {
+		return []*simplejson.Json{
+			simplejson.NewFromAny(map[string]interface{}{"refId": "A", "datasource": map[string]interface{}{"uid": "prom"}}),
+			simplejson.NewFromAny(map[string]interface{}{"refId": "B", "datasource": map[string]interface{}{"uid": "influx"}}),
+			simplejson.NewFromAny(map[string]interface{}{"refId": "C", "datasource": map[string]interface{}{"uid": "loki"}}),
+		}
+	}
+	interpolator := newTemplateInterpolator(dashboard, nil)
+
+	t.Run("applies the saved filters", func(t *testing.T) {
+		queries := newQueries()
+		applyAdhocFilters(dashboard, queries, interpolator, nil)
+
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"key": "cluster", "operator": "equals", "value": "eu"},
+			map[string]interface{}{"key": "job", "operator": "equals", "value": "node"},
+		}, queries[0].Get("adhocFilters").Interface())
+		assert.Nil(t, queries[1].Get("adhocFilters").Interface())
+		assert.Nil(t, queries[2].Get("adhocFilters").Interface())
+	})
+
+	t.Run("applies the filters sent over the saved filters", func(t *testing.T) {
+		queries := newQueries()
+		applyAdhocFilters(dashboard, queries, interpolator, map[string][]AdhocFilterDTO{
+			"filters": {{Key: "job", Operator: "=|", Values: []string{"node", "api"}}},
+			"static":  {{Key: "host", Operator: "!=", Value: "web-1"}},
+		})
+
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"key": "cluster", "operator": "equals", "value": "eu"},
+			map[string]interface{}{"key": "job", "operator": "one-of", "value": "", "values": []interface{}{"node", "api"}},
+		}, queries[0].Get("adhocFilters").Interface())
+		// other datasources keep the operators of the dashboard
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"key": "host", "operator": "!=", "value": "web-1"},
+		}, queries[1].Get("adhocFilters").Interface())
+	})
+
+	t.Run("keeps only the base filters when every filter is removed", func(t *testing.T) {
+		queries := newQueries()
+		applyAdhocFilters(dashboard, queries, interpolator, map[string][]AdhocFilterDTO{"filters": {}})
+
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"key": "cluster", "operator": "equals", "value": "eu"},
+		}, queries[0].Get("adhocFilters").Interface())
+	})
+}
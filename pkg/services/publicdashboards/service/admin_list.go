@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore/searchstore"
+)
+
+// FindAllAcrossOrgsWithPagination returns a page of the public dashboards of every org, for Grafana admins to audit
+// the instance. Dashboards are looked up as the service identity of their org so every title is found, public
+// dashboards of deleted dashboards are kept with an empty title
+func (pd *PublicDashboardServiceImpl) FindAllAcrossOrgsWithPagination(ctx context.Context, query *AdminPublicDashboardListQuery) (*AdminPublicDashboardListResponseWithPagination, error) {
+	ctx, span := tracer.Start(ctx, "publicdashboards.FindAllAcrossOrgsWithPagination")
+	defer span.End()
+
+	query.Offset = query.Limit * (query.Page - 1)
+	resp, err := pd.store.FindAllAcrossOrgsWithPagination(ctx, query)
+	if err != nil {
+		return nil, ErrInternalServerError.Errorf("FindAllAcrossOrgsWithPagination: failed to find public dashboards: %w", err)
+	}
+
+	dashUIDsByOrg := make(map[int64][]string)
+	for _, pubdash := range resp.PublicDashboards {
+		dashUIDsByOrg[pubdash.OrgId] = append(dashUIDsByOrg[pubdash.OrgId], pubdash.DashboardUid)
+	}
+
+	titlesByOrg := make(map[int64]map[string]string, len(dashUIDsByOrg))
+	for orgID, dashUIDs := range dashUIDsByOrg {
+		titles, err := pd.findDashboardTitles(ctx, orgID, dashUIDs)
+		if err != nil {
+			return nil, ErrInternalServerError.Errorf("FindAllAcrossOrgsWithPagination: failed to find the dashboards of org %d: %w", orgID, err)
+		}
+		titlesByOrg[orgID] = titles
+	}
+
+	for _, pubdash := range resp.PublicDashboards {
+		pubdash.Title = titlesByOrg[pubdash.OrgId][pubdash.DashboardUid]
+	}
+
+	resp.Page = query.Page
+	resp.PerPage = query.Limit
+	return resp, nil
+}
+
+// findDashboardTitles returns the titles of the dashboards of the org by uid, deleted dashboards are left out
+func (pd *PublicDashboardServiceImpl) findDashboardTitles(ctx context.Context, orgID int64, dashUIDs []string) (map[string]string, error) {
+	svcCtx, svcIdent := identity.WithServiceIdentity(ctx, orgID)
+	found, err := pd.dashboardService.FindDashboards(svcCtx, &dashboards.FindPersistedDashboardsQuery{
+		OrgId:         orgID,
+		DashboardUIDs: dashUIDs,
+		SignedInUser:  svcIdent,
+		Limit:         int64(len(dashUIDs)),
+		Type:          searchstore.TypeDashboard,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	titles := make(map[string]string, len(found))
+	for _, dash := range found {
+		titles[dash.UID] = dash.Title
+	}
+	return titles, nil
+}
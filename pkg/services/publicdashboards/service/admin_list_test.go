@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestFindAllAcrossOrgsWithPagination(t *testing.T) {
+	setup := func(t *testing.T) (*PublicDashboardServiceImpl, *FakePublicDashboardStore, *dashboards.FakeDashboardService) {
+		store := &FakePublicDashboardStore{}
+		store.Test(t)
+		store.On("FindAllAcrossOrgsWithPagination", mock.Anything, mock.Anything).Return(&AdminPublicDashboardListResponseWithPagination{
+			PublicDashboards: []*AdminPublicDashboardListResponse{
+				{Uid: "pubdash1", OrgId: 1, DashboardUid: "dash1"},
+				{Uid: "pubdash2", OrgId: 1, DashboardUid: "deleted"},
+				{Uid: "pubdash3", OrgId: 2, DashboardUid: "dash1"},
+			},
+			TotalCount: 5,
+		}, nil)
+		dashboardService := &dashboards.FakeDashboardService{}
+		dashboardService.Test(t)
+		service := &PublicDashboardServiceImpl{
+			log:              log.NewNopLogger(),
+			cfg:              setting.NewCfg(),
+			store:            store,
+			dashboardService: dashboardService,
+		}
+		return service, store, dashboardService
+	}
+
+	findDashboards := func(dashboardService *dashboards.FakeDashboardService, orgID int64, found ...dashboards.DashboardSearchProjection) {
+		dashboardService.On("FindDashboards", mock.Anything, mock.MatchedBy(func(query *dashboards.FindPersistedDashboardsQuery) bool {
+			return query.OrgId == orgID
+		})).Return(found, nil)
+	}
+
+	t.Run("joins in the titles of the dashboards of each org", func(t *testing.T) {
+		service, store, dashboardService := setup(t)
+		findDashboards(dashboardService, 1, dashboards.DashboardSearchProjection{UID: "dash1", Title: "Org 1 dashboard"})
+		findDashboards(dashboardService, 2, dashboards.DashboardSearchProjection{UID: "dash1", Title: "Org 2 dashboard"})
+
+		query := &AdminPublicDashboardListQuery{Page: 2, Limit: 3}
+		resp, err := service.FindAllAcrossOrgsWithPagination(context.Background(), query)
+		require.NoError(t, err)
+
+		assert.Equal(t, "Org 1 dashboard", resp.PublicDashboards[0].Title)
+		assert.Empty(t, resp.PublicDashboards[1].Title)
+		assert.Equal(t, "Org 2 dashboard", resp.PublicDashboards[2].Title)
+		assert.Equal(t, int64(5), resp.TotalCount)
+		assert.Equal(t, 2, resp.Page)
+		assert.Equal(t, 3, resp.PerPage)
+		store.AssertCalled(t, "FindAllAcrossOrgsWithPagination", mock.Anything, mock.MatchedBy(func(query *AdminPublicDashboardListQuery) bool {
+			return query.Offset == 3
+		}))
+	})
+
+	t.Run("returns an error when the dashboards can't be found", func(t *testing.T) {
+		service, _, dashboardService := setup(t)
+		dashboardService.On("FindDashboards", mock.Anything, mock.Anything).Return(nil, errors.New("db error"))
+
+		_, err := service.FindAllAcrossOrgsWithPagination(context.Background(), &AdminPublicDashboardListQuery{Page: 1, Limit: 10})
+		assert.ErrorIs(t, err, ErrInternalServerError)
+	})
+}
@@ -2,8 +2,10 @@ package service
 
 import (
 	"encoding/json"
+	"slices"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
 )
 
@@ -20,3 +22,47 @@ func UnmarshalDashboardAnnotations(sj *simplejson.Json) (*models.AnnotationsDto,
 
 	return dto, err
 }
+
+// validateAnnotationSettings checks the annotation queries and the panels whose annotations are hidden from the
+// viewers of a public dashboard exist in the dashboard
+func validateAnnotationSettings(dashboard *dashboards.Dashboard, as *models.AnnotationSettings) error {
+	if as == nil {
+		return nil
+	}
+
+	if len(as.HiddenQueries) > 0 {
+		annoDto, err := UnmarshalDashboardAnnotations(dashboard.Data)
+		if err != nil {
+			return models.ErrInternalServerError.Errorf("validateAnnotationSettings: failed to unmarshal dashboard annotations: %w", err)
+		}
+		for _, name := range as.HiddenQueries {
+			if !slices.ContainsFunc(annoDto.Annotations.List, func(anno models.DashAnnotation) bool { return anno.Name == name }) {
+				return models.ErrInvalidAnnotationSettings.Errorf("validateAnnotationSettings: annotation query %s not found in dashboard %s", name, dashboard.UID)
+			}
+		}
+	}
+
+	for _, panelId := range as.HiddenPanelIds {
+		if findPanelContent(dashboard, panelId) == nil {
+			return models.ErrInvalidAnnotationSettings.Errorf("validateAnnotationSettings: panel %d not found in dashboard %s", panelId, dashboard.UID)
+		}
+	}
+	return nil
+}
+
+// normalizeAnnotationSettings sorts the hidden queries and panels and drops duplicates, settings hiding nothing share
+// every annotation
+func normalizeAnnotationSettings(as *models.AnnotationSettings) *models.AnnotationSettings {
+	if as == nil || (len(as.HiddenQueries) == 0 && len(as.HiddenPanelIds) == 0) {
+		return nil
+	}
+
+	var hiddenQueries []string
+	if len(as.HiddenQueries) > 0 {
+		hiddenQueries = slices.Clone(as.HiddenQueries)
+		slices.Sort(hiddenQueries)
+		hiddenQueries = slices.Compact(hiddenQueries)
+	}
+
+	return &models.AnnotationSettings{HiddenQueries: hiddenQueries, HiddenPanelIds: normalizeHiddenPanelIds(as.HiddenPanelIds)}
+}
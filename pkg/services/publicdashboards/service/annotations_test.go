@@ -0,0 +1,28 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+func TestValidateAnnotationSettings(t *testing.T) {
+	dashboard := AddAnnotationsToDashboard(t, panelScopeDashboard(t), []DashAnnotation{{Name: "Deployments"}, {Name: "Incidents"}})
+
+	require.NoError(t, validateAnnotationSettings(dashboard, nil))
+	require.NoError(t, validateAnnotationSettings(dashboard, &AnnotationSettings{HiddenQueries: []string{"Incidents"}, HiddenPanelIds: []int64{1, 4}}))
+	require.ErrorIs(t, validateAnnotationSettings(dashboard, &AnnotationSettings{HiddenQueries: []string{"Outages"}}), ErrInvalidAnnotationSettings)
+	require.ErrorIs(t, validateAnnotationSettings(dashboard, &AnnotationSettings{HiddenPanelIds: []int64{99}}), ErrInvalidAnnotationSettings)
+}
+
+func TestNormalizeAnnotationSettings(t *testing.T) {
+	assert.Nil(t, normalizeAnnotationSettings(nil))
+	assert.Nil(t, normalizeAnnotationSettings(&AnnotationSettings{HiddenQueries: []string{}}))
+	assert.Equal(t,
+		&AnnotationSettings{HiddenQueries: []string{"Incidents", "Outages"}, HiddenPanelIds: []int64{2, 3}},
+		normalizeAnnotationSettings(&AnnotationSettings{HiddenQueries: []string{"Outages", "Incidents", "Outages"}, HiddenPanelIds: []int64{3, 2, 3}}),
+	)
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+	"unicode/utf8"
+
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
+)
+
+const (
+	// maxAuditLogUserAgentLength is the length of the user_agent column
+	maxAuditLogUserAgentLength = 255
+	defaultAuditLogPageSize    = 100
+	maxAuditLogPageSize        = 1000
+)
+
+// RecordAuditLogEntry records a view or a query of the public dashboard of the access token or slug when the audit
+// log is enabled. Failures are only logged, so they don't fail the requests of viewers
+func (pd *PublicDashboardServiceImpl) RecordAuditLogEntry(ctx context.Context, accessToken string, event AuditLogEvent) {
+	if !pd.cfg.PublicDashboardsAuditLogEnabled {
+		return
+	}
+
+	ctx, span := tracer.Start(ctx, "publicdashboards.RecordAuditLogEntry")
+	defer span.End()
+
+	pubdash, err := pd.findByAccessTokenOrSlug(ctx, accessToken)
+	if err != nil {
+		pd.log.Warn("Failed to find public dashboard of audit log entry", "error", err)
+		return
+	}
+
+	entry := &AuditLogEntry{
+		OrgId:              pubdash.OrgId,
+		PublicDashboardUid: pubdash.Uid,
+		AccessTokenHash:    accessTokenHash(accessToken),
+		IPHash:             pd.auditLogIPHash(event.Client.IP),
+		UserAgent:          truncateUserAgent(event.Client.UserAgent),
+		Action:             event.Action,
+		PanelIds:           event.PanelIds,
+		TimeFrom:           event.TimeRange.From,
+		TimeTo:             event.TimeRange.To,
+		CreatedAt:          time.Now().UTC(),
+	}
+	if err := pd.store.InsertAuditLogEntry(ctx, entry); err != nil {
+		pd.log.Error("Failed to record audit log entry", "publicDashboardUid", pubdash.Uid, "action", event.Action, "error", err)
+	}
+}
+
+// GetAuditLog returns a page of the audit log of a public dashboard, newest entries first
+func (pd *PublicDashboardServiceImpl) GetAuditLog(ctx context.Context, orgId int64, dashboardUid string, uid string, query *AuditLogQuery) (*AuditLogResponseWithPagination, error) {
+	ctx, span := tracer.Start(ctx, "publicdashboards.GetAuditLog")
+	defer span.End()
+
+	pubdash, err := pd.store.Find(ctx, uid)
+	if err != nil {
+		return nil, ErrInternalServerError.Errorf("GetAuditLog: failed to find public dashboard by uid: %s: %w", uid, err)
+	}
+	if pubdash == nil || pubdash.OrgId != orgId {
+		return nil, ErrPublicDashboardNotFound.Errorf("GetAuditLog: public dashboard not found by uid: %s", uid)
+	}
+	if pubdash.DashboardUid != dashboardUid {
+		return nil, ErrInvalidUid.Errorf("GetAuditLog: the public dashboard does not belong to the dashboard")
+	}
+
+	if query.Limit <= 0 {
+		query.Limit = defaultAuditLogPageSize
+	}
+	if query.Limit > maxAuditLogPageSize {
+		query.Limit = maxAuditLogPageSize
+	}
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	query.OrgId = orgId
+	query.PublicDashboardUid = uid
+	query.Offset = query.Limit * (query.Page - 1)
+
+	resp, err := pd.store.FindAuditLog(ctx, query)
+	if err != nil {
+		return nil, ErrInternalServerError.Errorf("GetAuditLog: failed to find audit log: %w", err)
+	}
+	return resp, nil
+}
+
+// findByAccessTokenOrSlug finds the public dashboard of the access token or slug, without its dashboard
+func (pd *PublicDashboardServiceImpl) findByAccessTokenOrSlug(ctx context.Context, accessToken string) (*PublicDashboard, error) {
+	pubdash, err := pd.store.FindByAccessToken(ctx, accessToken)
+	if err != nil {
+		return nil, ErrInternalServerError.Errorf("findByAccessTokenOrSlug: failed to find a public dashboard: %w", err)
+	}
+	if pubdash != nil {
+		return pubdash, nil
+	}
+
+	if validation.IsValidSlug(accessToken) {
+		pubdash, err := pd.store.FindBySlug(ctx, accessToken)
+		if err != nil {
+			return nil, ErrInternalServerError.Errorf("findByAccessTokenOrSlug: failed to find public dashboard by slug: %w", err)
+		}
+		if pubdash != nil {
+			return pubdash, nil
+		}
+	}
+
+	return nil, ErrPublicDashboardNotFound.Errorf("findByAccessTokenOrSlug: Public dashboard not found accessToken: %s", accessToken)
+}
+
+// accessTokenHash hashes the access token or slug, owners can tell which one viewers used by hashing theirs. It also
+// identifies the access tokens of revocations broadcast to the other instances
+func accessTokenHash(accessToken string) string {
+	sum := sha256.Sum256([]byte(accessToken))
+	return hex.EncodeToString(sum[:])
+}
+
+// auditLogIPHash hashes the IP address with a key derived from the secret key, so the addresses can't be recovered by
+// hashing every address
+func (pd *PublicDashboardServiceImpl) auditLogIPHash(ip string) string {
+	key := hmac.New(sha256.New, []byte(pd.cfg.SecretKey))
+	key.Write([]byte("publicdashboards.auditLog"))
+
+	h := hmac.New(sha256.New, key.Sum(nil))
+	h.Write([]byte(ip))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// truncateUserAgent truncates the user agent to the length of the column, without splitting a character
+func truncateUserAgent(userAgent string) string {
+	if len(userAgent) <= maxAuditLogUserAgentLength {
+		return userAgent
+	}
+
+	userAgent = userAgent[:maxAuditLogUserAgentLength]
+	for len(userAgent) > 0 && !utf8.ValidString(userAgent) {
+		userAgent = userAgent[:len(userAgent)-1]
+	}
+	return userAgent
+}
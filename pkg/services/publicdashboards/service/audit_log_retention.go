@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	auditLogRetentionInterval = time.Hour
+	auditLogRetentionLockName = "delete old public dashboard audit log entries"
+)
+
+// AuditLogRetentionService deletes the entries of the audit log of public dashboards once they are older than the
+// retention
+type AuditLogRetentionService struct {
+	log        log.Logger
+	cfg        *setting.Cfg
+	store      publicdashboards.Store
+	serverLock serverLocker
+}
+
+func ProvideAuditLogRetentionService(cfg *setting.Cfg, store publicdashboards.Store, serverLock *serverlock.ServerLockService) *AuditLogRetentionService {
+	return &AuditLogRetentionService{
+		log:        log.New("publicdashboards.auditlog"),
+		cfg:        cfg,
+		store:      store,
+		serverLock: serverLock,
+	}
+}
+
+// IsDisabled returns true when public dashboards are disabled. Entries recorded before the audit log was disabled are
+// still deleted
+func (s *AuditLogRetentionService) IsDisabled() bool {
+	return !s.cfg.PublicDashboardsEnabled
+}
+
+func (s *AuditLogRetentionService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(auditLogRetentionInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.serverLock.LockAndExecute(ctx, auditLogRetentionLockName, auditLogRetentionInterval/2, s.deleteOldEntries); err != nil {
+			s.log.Error("Failed to delete old audit log entries", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *AuditLogRetentionService) deleteOldEntries(ctx context.Context) {
+	before := time.Now().Add(-s.cfg.PublicDashboardsAuditLogRetention)
+	deleted, err := s.store.DeleteAuditLogBefore(ctx, before)
+	if err != nil {
+		s.log.Error("Failed to delete old audit log entries", "before", before, "error", err)
+		return
+	}
+
+	if deleted > 0 {
+		s.log.Debug("Deleted old audit log entries", "count", deleted, "before", before)
+	}
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestAuditLogRetentionServiceDeleteOldEntries(t *testing.T) {
+	setup := func() (*AuditLogRetentionService, *FakePublicDashboardStore) {
+		store := &FakePublicDashboardStore{}
+		cfg := setting.NewCfg()
+		cfg.PublicDashboardsAuditLogRetention = 24 * time.Hour
+
+		return &AuditLogRetentionService{
+			log:   log.NewNopLogger(),
+			cfg:   cfg,
+			store: store,
+		}, store
+	}
+
+	t.Run("deletes the entries older than the retention", func(t *testing.T) {
+		service, store := setup()
+		store.On("DeleteAuditLogBefore", mock.Anything, mock.Anything).Return(int64(3), nil)
+
+		service.deleteOldEntries(context.Background())
+
+		before := store.Calls[0].Arguments.Get(1).(time.Time)
+		assert.WithinDuration(t, time.Now().Add(-24*time.Hour), before, time.Minute)
+	})
+
+	t.Run("logs failures", func(t *testing.T) {
+		service, store := setup()
+		store.On("DeleteAuditLogBefore", mock.Anything, mock.Anything).Return(int64(0), errors.New("db error"))
+
+		service.deleteOldEntries(context.Background())
+
+		store.AssertNumberOfCalls(t, "DeleteAuditLogBefore", 1)
+	})
+
+	t.Run("is disabled with public dashboards", func(t *testing.T) {
+		service, _ := setup()
+		service.cfg.PublicDashboardsEnabled = false
+		require.True(t, service.IsDisabled())
+	})
+}
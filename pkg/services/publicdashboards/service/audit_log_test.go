@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestRecordAuditLogEntry(t *testing.T) {
+	accessToken := "e71950f4b5fc4a9d8a34a2d1b2d7e5e0"
+	pubdash := &PublicDashboard{Uid: "uid1", OrgId: 1, DashboardUid: "dash1", AccessToken: accessToken, Slug: "status-page"}
+	event := AuditLogEvent{
+		Action:    AuditLogActionQuery,
+		Client:    ViewerClient{IP: "10.0.0.1", UserAgent: "Mozilla/5.0"},
+		PanelIds:  []int64{2},
+		TimeRange: TimeRangeDTO{From: "now-6h", To: "now"},
+	}
+
+	setup := func(t *testing.T, enabled bool) (*PublicDashboardServiceImpl, *FakePublicDashboardStore) {
+		store := &FakePublicDashboardStore{}
+		store.Test(t)
+		cfg := setting.NewCfg()
+		cfg.SecretKey = "secret"
+		cfg.PublicDashboardsAuditLogEnabled = enabled
+		return &PublicDashboardServiceImpl{log: log.NewNopLogger(), cfg: cfg, store: store}, store
+	}
+
+	t.Run("records the event with a hash of the IP address", func(t *testing.T) {
+		service, store := setup(t, true)
+		store.On("FindByAccessToken", mock.Anything, accessToken).Return(pubdash, nil)
+		store.On("InsertAuditLogEntry", mock.Anything, mock.Anything).Return(nil)
+
+		service.RecordAuditLogEntry(context.Background(), accessToken, event)
+
+		entry := store.Calls[1].Arguments.Get(1).(*AuditLogEntry)
+		assert.Equal(t, int64(1), entry.OrgId)
+		assert.Equal(t, "uid1", entry.PublicDashboardUid)
+		assert.Len(t, entry.AccessTokenHash, 64)
+		assert.NotContains(t, entry.AccessTokenHash, accessToken)
+		assert.Equal(t, accessTokenHash(accessToken), entry.AccessTokenHash)
+		assert.Equal(t, "Mozilla/5.0", entry.UserAgent)
+		assert.Equal(t, AuditLogActionQuery, entry.Action)
+		assert.Equal(t, []int64{2}, entry.PanelIds)
+		assert.Equal(t, "now-6h", entry.TimeFrom)
+		assert.Equal(t, "now", entry.TimeTo)
+		assert.False(t, entry.CreatedAt.IsZero())
+
+		assert.Len(t, entry.IPHash, 64)
+		assert.NotContains(t, entry.IPHash, "10.0.0.1")
+		assert.Equal(t, service.auditLogIPHash("10.0.0.1"), entry.IPHash)
+		assert.NotEqual(t, service.auditLogIPHash("10.0.0.2"), entry.IPHash)
+	})
+
+	t.Run("finds the public dashboard of a slug", func(t *testing.T) {
+		service, store := setup(t, true)
+		store.On("FindByAccessToken", mock.Anything, "status-page").Return(nil, nil)
+		store.On("FindBySlug", mock.Anything, "status-page").Return(pubdash, nil)
+		store.On("InsertAuditLogEntry", mock.Anything, mock.MatchedBy(func(entry *AuditLogEntry) bool {
+			return entry.PublicDashboardUid == "uid1" && entry.AccessTokenHash == accessTokenHash("status-page")
+		})).Return(nil)
+
+		service.RecordAuditLogEntry(context.Background(), "status-page", event)
+
+		store.AssertNumberOfCalls(t, "InsertAuditLogEntry", 1)
+	})
+
+	t.Run("truncates long user agents", func(t *testing.T) {
+		service, store := setup(t, true)
+		store.On("FindByAccessToken", mock.Anything, accessToken).Return(pubdash, nil)
+		store.On("InsertAuditLogEntry", mock.Anything, mock.Anything).Return(nil)
+
+		long := event
+		long.Client.UserAgent = strings.Repeat("a", 254) + "é"
+		service.RecordAuditLogEntry(context.Background(), accessToken, long)
+
+		entry := store.Calls[1].Arguments.Get(1).(*AuditLogEntry)
+		assert.Equal(t, strings.Repeat("a", 254), entry.UserAgent)
+	})
+
+	t.Run("does nothing when the audit log is disabled", func(t *testing.T) {
+		service, store := setup(t, false)
+
+		service.RecordAuditLogEntry(context.Background(), accessToken, event)
+
+		store.AssertNotCalled(t, "FindByAccessToken", mock.Anything, mock.Anything)
+		store.AssertNotCalled(t, "InsertAuditLogEntry", mock.Anything, mock.Anything)
+	})
+}
+
+func TestGetAuditLog(t *testing.T) {
+	pubdash := &PublicDashboard{Uid: "uid1", OrgId: 1, DashboardUid: "dash1"}
+
+	setup := func(t *testing.T) (*PublicDashboardServiceImpl, *FakePublicDashboardStore) {
+		store := &FakePublicDashboardStore{}
+		store.Test(t)
+		store.On("Find", mock.Anything, "uid1").Return(pubdash, nil)
+		return &PublicDashboardServiceImpl{log: log.NewNopLogger(), cfg: setting.NewCfg(), store: store}, store
+	}
+
+	t.Run("returns the requested page", func(t *testing.T) {
+		service, store := setup(t)
+		expected := &AuditLogResponseWithPagination{Entries: []*AuditLogEntry{}, Page: 3, PerPage: 20}
+		store.On("FindAuditLog", mock.Anything, &AuditLogQuery{OrgId: 1, PublicDashboardUid: "uid1", Page: 3, Limit: 20, Offset: 40}).
+			Return(expected, nil)
+
+		resp, err := service.GetAuditLog(context.Background(), 1, "dash1", "uid1", &AuditLogQuery{Page: 3, Limit: 20})
+		require.NoError(t, err)
+		assert.Equal(t, expected, resp)
+	})
+
+	t.Run("bounds the page size", func(t *testing.T) {
+		service, store := setup(t)
+		store.On("FindAuditLog", mock.Anything, mock.Anything).Return(&AuditLogResponseWithPagination{}, nil)
+
+		_, err := service.GetAuditLog(context.Background(), 1, "dash1", "uid1", &AuditLogQuery{})
+		require.NoError(t, err)
+		query := store.Calls[1].Arguments.Get(1).(*AuditLogQuery)
+		assert.Equal(t, defaultAuditLogPageSize, query.Limit)
+		assert.Equal(t, 1, query.Page)
+
+		_, err = service.GetAuditLog(context.Background(), 1, "dash1", "uid1", &AuditLogQuery{Limit: 5000})
+		require.NoError(t, err)
+		query = store.Calls[3].Arguments.Get(1).(*AuditLogQuery)
+		assert.Equal(t, maxAuditLogPageSize, query.Limit)
+	})
+
+	t.Run("public dashboards of other orgs aren't found", func(t *testing.T) {
+		service, _ := setup(t)
+
+		_, err := service.GetAuditLog(context.Background(), 2, "dash1", "uid1", &AuditLogQuery{})
+		require.ErrorIs(t, err, ErrPublicDashboardNotFound)
+	})
+
+	t.Run("the public dashboard has to belong to the dashboard", func(t *testing.T) {
+		service, _ := setup(t)
+
+		_, err := service.GetAuditLog(context.Background(), 1, "dash2", "uid1", &AuditLogQuery{})
+		require.ErrorIs(t, err, ErrInvalidUid)
+	})
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/metric"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
+)
+
+// batchQueryConcurrency is the number of panels of a batch query queried at the same time when it isn't configured.
+// Queries are still bounded by the query limiter of the service
+const batchQueryConcurrency = 10
+
+// GetQueryDataResponses queries the selected panels of a public dashboard concurrently with the same time range and
+// variables, so viewers load a dashboard in a single request. A panel failing or timing out doesn't fail the others,
+// its error is returned with its result
+func (pd *PublicDashboardServiceImpl) GetQueryDataResponses(ctx context.Context, skipDSCache bool, reqDTO models.PublicDashboardBatchQueryDTO, accessToken string) (*models.PublicDashboardBatchQueryResponse, error) {
+	ctx, span := tracer.Start(ctx, "publicdashboards.GetQueryDataResponses")
+	defer span.End()
+
+	if err := validation.ValidateBatchQueryPanels(reqDTO.PanelIds); err != nil {
+		return nil, err
+	}
+
+	panelIds := reqDTO.PanelIds.Ids
+	if reqDTO.PanelIds.All {
+		pubdash, dashboard, err := pd.FindEnabledPublicDashboardAndDashboardByAccessToken(ctx, accessToken)
+		if err != nil {
+			return nil, err
+		}
+		panelIds = sharedPanelIds(pubdash, dashboard, queriedPanelIds(dashboard.Data))
+	}
+
+	results := pd.queryPanels(ctx, panelIds, func(ctx context.Context, panelId int64) (*backend.QueryDataResponse, error) {
+		// every panel gets its own copy of the request, as variables are rewritten while querying
+		return pd.GetQueryDataResponse(ctx, skipDSCache, clonePanelQueryDTO(reqDTO.PublicDashboardQueryDTO), panelId, accessToken)
+	})
+
+	return &models.PublicDashboardBatchQueryResponse{Panels: results}, nil
+}
+
+// queryPanels queries the panels with a pool of workers limited by [public_dashboards] batch_query_concurrency, and by
+// the pool shared by every batch query. Each panel has batch_query_panel_timeout to get a slot and be queried
+func (pd *PublicDashboardServiceImpl) queryPanels(ctx context.Context, panelIds []int64, queryPanel func(ctx context.Context, panelId int64) (*backend.QueryDataResponse, error)) map[int64]models.PanelQueryResult {
+	concurrency := batchQueryConcurrency
+	var timeout time.Duration
+	if pd.cfg != nil {
+		if pd.cfg.PublicDashboardsBatchQueryConcurrency > 0 {
+			concurrency = pd.cfg.PublicDashboardsBatchQueryConcurrency
+		}
+		timeout = pd.cfg.PublicDashboardsBatchQueryPanelTimeout
+	}
+
+	var mu sync.Mutex
+	results := make(map[int64]models.PanelQueryResult, len(panelIds))
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for _, panelId := range panelIds {
+		g.Go(func() error {
+			panelCtx := gctx
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				panelCtx, cancel = context.WithTimeout(gctx, timeout)
+				defer cancel()
+			}
+
+			resp, err := pd.queryBatchPanel(panelCtx, panelId, queryPanel)
+			if err != nil && errors.Is(panelCtx.Err(), context.DeadlineExceeded) {
+				metric.BatchQueryPanelTimeoutsTotal.Inc()
+				err = models.ErrPanelQueryTimeout.Errorf("queryPanels: panel %d timed out after %s: %w", panelId, timeout, err)
+			}
+
+			result := models.PanelQueryResult{Response: resp}
+			if err != nil {
+				result = models.PanelQueryResult{Error: panelQueryError(err)}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[panelId] = result
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	return results
+}
+
+func (pd *PublicDashboardServiceImpl) queryBatchPanel(ctx context.Context, panelId int64, queryPanel func(ctx context.Context, panelId int64) (*backend.QueryDataResponse, error)) (*backend.QueryDataResponse, error) {
+	release, err := pd.batchQueryPool.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return queryPanel(ctx, panelId)
+}
+
+// batchQueryPool bounds the number of panels of batch queries queried at the same time across requests, so a few
+// dashboards with many panels can't take every query slot. A nil pool doesn't limit anything
+type batchQueryPool struct {
+	slots chan struct{}
+}
+
+func newBatchQueryPool(maxConcurrency int) *batchQueryPool {
+	if maxConcurrency <= 0 {
+		return nil
+	}
+	return &batchQueryPool{slots: make(chan struct{}, maxConcurrency)}
+}
+
+// acquire waits for a slot until the context is done. The returned func releases the slot
+func (p *batchQueryPool) acquire(ctx context.Context) (func(), error) {
+	if p == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	metric.BatchQueryPanelsInFlight.Inc()
+	return func() {
+		metric.BatchQueryPanelsInFlight.Dec()
+		<-p.slots
+	}, nil
+}
+
+// queriedPanelIds returns the ids of the panels of the dashboard with queries, in ascending order. Hidden panels are
+// left to sharedPanelIds
+func queriedPanelIds(dashboard *simplejson.Json) []int64 {
+	queriesByPanel := groupQueriesByPanelId(dashboard, nil)
+	if dashboard.Get("elements").Interface() != nil {
+		queriesByPanel = groupQueriesByPanelIdV2(dashboard, nil)
+	}
+
+	panelIds := make([]int64, 0, len(queriesByPanel))
+	for panelId, queries := range queriesByPanel {
+		if len(queries) > 0 {
+			panelIds = append(panelIds, panelId)
+		}
+	}
+	slices.Sort(panelIds)
+	return panelIds
+}
+
+// clonePanelQueryDTO copies the maps of the query DTO, so panels queried concurrently don't share them
+func clonePanelQueryDTO(reqDTO models.PublicDashboardQueryDTO) models.PublicDashboardQueryDTO {
+	if reqDTO.Variables != nil {
+		variables := make(map[string]interface{}, len(reqDTO.Variables))
+		for name, value := range reqDTO.Variables {
+			variables[name] = value
+		}
+		reqDTO.Variables = variables
+	}
+
+	if reqDTO.AdhocFilters != nil {
+		filters := make(map[string][]models.AdhocFilterDTO, len(reqDTO.AdhocFilters))
+		for name, value := range reqDTO.AdhocFilters {
+			filters[name] = slices.Clone(value)
+		}
+		reqDTO.AdhocFilters = filters
+	}
+
+	return reqDTO
+}
+
+// panelQueryError returns the public part of the error a panel query failed with. Errors without a public message
+// are reported as internal errors
+func panelQueryError(err error) *errutil.PublicError {
+	var gfErr errutil.Error
+	if !errors.As(err, &gfErr) {
+		gfErr = models.ErrInternalServerError.Errorf("GetQueryDataResponses: panel query failed: %w", err)
+	}
+	public := gfErr.Public()
+	return &public
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestGetQueryDataResponses(t *testing.T) {
+	dashboard := &dashboards.Dashboard{UID: "dash1", OrgID: 1, Data: simplejson.New()}
+	pubdash := &PublicDashboard{Uid: "uid1", IsEnabled: false, OrgId: 1, DashboardUid: dashboard.UID}
+
+	fakeStore := &FakePublicDashboardStore{}
+	fakeStore.On("FindByAccessToken", mock.Anything, mock.AnythingOfType("string")).Return(pubdash, nil)
+	fakeDashboardService := &dashboards.FakeDashboardService{}
+	fakeDashboardService.On("GetDashboard", mock.Anything, mock.Anything, mock.Anything).Return(dashboard, nil)
+
+	service := &PublicDashboardServiceImpl{
+		log:              log.NewNopLogger(),
+		cfg:              setting.NewCfg(),
+		store:            fakeStore,
+		dashboardService: fakeDashboardService,
+	}
+
+	t.Run("reports the error of each panel with its result", func(t *testing.T) {
+		reqDTO := PublicDashboardBatchQueryDTO{PanelIds: BatchQueryPanels{Ids: []int64{1, 2}}}
+		resp, err := service.GetQueryDataResponses(context.Background(), false, reqDTO, "abc123")
+		require.NoError(t, err)
+
+		require.Len(t, resp.Panels, 2)
+		for _, result := range resp.Panels {
+			assert.Nil(t, result.Response)
+			require.NotNil(t, result.Error)
+			assert.Equal(t, http.StatusForbidden, result.Error.StatusCode)
+		}
+	})
+
+	t.Run("returns the error of the public dashboard when querying every panel", func(t *testing.T) {
+		reqDTO := PublicDashboardBatchQueryDTO{PanelIds: BatchQueryPanels{All: true}}
+		_, err := service.GetQueryDataResponses(context.Background(), false, reqDTO, "abc123")
+		require.ErrorIs(t, err, ErrPublicDashboardNotEnabled)
+	})
+
+	t.Run("returns an error without panels", func(t *testing.T) {
+		_, err := service.GetQueryDataResponses(context.Background(), false, PublicDashboardBatchQueryDTO{}, "abc123")
+		require.ErrorIs(t, err, ErrInvalidBatchQueryPanels)
+	})
+}
+
+func TestQueryPanels(t *testing.T) {
+	newService := func(cfg *setting.Cfg) *PublicDashboardServiceImpl {
+		return &PublicDashboardServiceImpl{
+			log:            log.NewNopLogger(),
+			cfg:            cfg,
+			batchQueryPool: newBatchQueryPool(cfg.PublicDashboardsBatchQueryMaxConcurrency),
+		}
+	}
+
+	t.Run("returns the results of the panels that didn't time out", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.PublicDashboardsBatchQueryPanelTimeout = 50 * time.Millisecond
+		service := newService(cfg)
+
+		results := service.queryPanels(context.Background(), []int64{1, 2}, func(ctx context.Context, panelId int64) (*backend.QueryDataResponse, error) {
+			if panelId == 2 {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return &backend.QueryDataResponse{}, nil
+		})
+
+		require.Len(t, results, 2)
+		assert.NotNil(t, results[1].Response)
+		assert.Nil(t, results[1].Error)
+		require.NotNil(t, results[2].Error)
+		assert.Equal(t, http.StatusGatewayTimeout, results[2].Error.StatusCode)
+		assert.Equal(t, "publicdashboards.panelQueryTimeout", results[2].Error.MessageID)
+	})
+
+	t.Run("limits the panels queried at the same time for each request", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.PublicDashboardsBatchQueryConcurrency = 2
+		service := newService(cfg)
+
+		var running, maxRunning atomic.Int32
+		results := service.queryPanels(context.Background(), []int64{1, 2, 3, 4, 5, 6}, func(ctx context.Context, panelId int64) (*backend.QueryDataResponse, error) {
+			current := running.Add(1)
+			defer running.Add(-1)
+			for {
+				seen := maxRunning.Load()
+				if current <= seen || maxRunning.CompareAndSwap(seen, current) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			return &backend.QueryDataResponse{}, nil
+		})
+
+		assert.Len(t, results, 6)
+		assert.LessOrEqual(t, maxRunning.Load(), int32(2))
+	})
+
+	t.Run("panels wait for a slot shared by every request until they time out", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.PublicDashboardsBatchQueryMaxConcurrency = 1
+		cfg.PublicDashboardsBatchQueryPanelTimeout = 50 * time.Millisecond
+		service := newService(cfg)
+
+		// another request holds the only slot
+		release, err := service.batchQueryPool.acquire(context.Background())
+		require.NoError(t, err)
+
+		queried := false
+		results := service.queryPanels(context.Background(), []int64{1}, func(ctx context.Context, panelId int64) (*backend.QueryDataResponse, error) {
+			queried = true
+			return &backend.QueryDataResponse{}, nil
+		})
+		require.NotNil(t, results[1].Error)
+		assert.Equal(t, http.StatusGatewayTimeout, results[1].Error.StatusCode)
+		assert.False(t, queried)
+
+		release()
+		results = service.queryPanels(context.Background(), []int64{1}, func(ctx context.Context, panelId int64) (*backend.QueryDataResponse, error) {
+			return &backend.QueryDataResponse{}, nil
+		})
+		assert.Nil(t, results[1].Error)
+	})
+}
+
+func TestQueriedPanelIds(t *testing.T) {
+	dashboard, err := simplejson.NewJson([]byte(`{
+		"panels": [
+			{"id": 3, "targets": [{"refId": "A"}]},
+			{"id": 1, "targets": [{"refId": "A"}]},
+			{"id": 2, "type": "text"}
+		]
+	}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []int64{1, 3}, queriedPanelIds(dashboard))
+}
+
+func TestPanelQueryError(t *testing.T) {
+	assert.Equal(t, http.StatusNotFound, panelQueryError(ErrPanelNotFound.Errorf("panel not found")).StatusCode)
+	assert.Equal(t, http.StatusInternalServerError, panelQueryError(fmt.Errorf("boom")).StatusCode)
+}
@@ -8,9 +8,11 @@ import (
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/actest"
 	"github.com/grafana/grafana/pkg/services/annotations"
 	"github.com/grafana/grafana/pkg/services/annotations/annotationsimpl"
 	"github.com/grafana/grafana/pkg/services/dashboards"
+	fakeDatasources "github.com/grafana/grafana/pkg/services/datasources/fakes"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/licensing/licensingtest"
 	"github.com/grafana/grafana/pkg/services/publicdashboards"
@@ -50,9 +52,12 @@ func newPublicDashboardServiceImpl(
 
 	return &PublicDashboardServiceImpl{
 		AnnotationsRepo:    annotationsRepo,
+		ac:                 actest.FakeAccessControl{ExpectedEvaluate: true},
 		log:                log.New("test.logger"),
+		cfg:                cfg,
 		intervalCalculator: intervalv2.NewCalculator(),
 		dashboardService:   dashboardService,
+		datasourceService:  &fakeDatasources.FakeDataSourceService{},
 		store:              publicDashboardStore,
 		serviceWrapper:     serviceWrapper,
 		license:            license,
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+// loosenedConstraintActions returns the actions required to change the existing public dashboard into the updated one,
+// on top of the write action. Only changes exposing more of the dashboard need them, a nil existing public dashboard
+// is a new one with the default config
+func loosenedConstraintActions(existing *PublicDashboard, updated *PublicDashboard) []string {
+	if existing == nil {
+		existing = &PublicDashboard{}
+	}
+
+	var actions []string
+	if (updated.IsEnabled && !existing.IsEnabled) || widensShare(existing, updated) {
+		actions = append(actions, dashboards.ActionDashboardsPublicShare)
+	}
+	if updated.TimeSelectionEnabled && !existing.TimeSelectionEnabled {
+		actions = append(actions, dashboards.ActionDashboardsPublicTimeSelectionWrite)
+	}
+	if updated.AnnotationsEnabled && !existing.AnnotationsEnabled {
+		actions = append(actions, dashboards.ActionDashboardsPublicAnnotationsWrite)
+	}
+	if (updated.AllowsVariables() && !existing.AllowsVariables()) || widensAllowList(existing.VariableOverridesAllowed, updated.VariableOverridesAllowed) ||
+		unpinsVariables(existing.PinnedVariables, updated.PinnedVariables) {
+		actions = append(actions, dashboards.ActionDashboardsPublicVariableOverridesWrite)
+	}
+	// removing the expiration time or moving it later keeps the public dashboard viewable for longer
+	if !existing.ExpiresAt.IsZero() && (updated.ExpiresAt.IsZero() || updated.ExpiresAt.After(existing.ExpiresAt)) {
+		actions = append(actions, dashboards.ActionDashboardsPublicExpirationWrite)
+	}
+	return actions
+}
+
+// widensShare reports whether the public dashboard shares panels it didn't share before, or can be viewed by viewers
+// who couldn't view it before
+func widensShare(existing *PublicDashboard, updated *PublicDashboard) bool {
+	// sharing the whole dashboard or another panel instead of the shared panel
+	if existing.PanelId != 0 && updated.PanelId != existing.PanelId {
+		return true
+	}
+	// showing a hidden panel again
+	if addsElements(updated.HiddenPanelIds, existing.HiddenPanelIds) {
+		return true
+	}
+	// raising or removing the limit of concurrent viewers
+	if existing.MaxConcurrentViewers != 0 && (updated.MaxConcurrentViewers == 0 || updated.MaxConcurrentViewers > existing.MaxConcurrentViewers) {
+		return true
+	}
+	return widensAllowList(existing.AllowedDomains, updated.AllowedDomains) || widensGeoRestriction(existing.GeoRestriction, updated.GeoRestriction)
+}
+
+// widensAllowList reports whether the updated list allows a value the existing one didn't, nil allows every value
+func widensAllowList(existing []string, updated []string) bool {
+	if existing == nil {
+		return false
+	}
+	if updated == nil {
+		return true
+	}
+	return addsElements(existing, updated)
+}
+
+// widensGeoRestriction reports whether the updated geo restriction allows a country the existing one didn't, nil
+// allows every country
+func widensGeoRestriction(existing *GeoRestriction, updated *GeoRestriction) bool {
+	if existing == nil {
+		return false
+	}
+	if updated == nil || updated.Mode != existing.Mode {
+		return true
+	}
+	if existing.Mode == GeoRestrictionModeDeny {
+		// unblocking a country
+		return addsElements(updated.Countries, existing.Countries)
+	}
+	return addsElements(existing.Countries, updated.Countries)
+}
+
+// unpinsVariables reports whether a pinned variable isn't pinned anymore, so viewers may change it
+func unpinsVariables(existing PinnedVariables, updated PinnedVariables) bool {
+	for name := range existing {
+		if _, ok := updated[name]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// addsElements reports whether the updated list has an element the existing one hasn't
+func addsElements[T comparable](existing []T, updated []T) bool {
+	for _, element := range updated {
+		if !slices.Contains(existing, element) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkLoosenedConstraints returns an error when the user lacks an action required to loosen the constraints of the
+// public dashboard of the dashboard
+func (pd *PublicDashboardServiceImpl) checkLoosenedConstraints(ctx context.Context, u *user.SignedInUser, dashboardUid string, existing *PublicDashboard, updated *PublicDashboard) error {
+	actions := loosenedConstraintActions(existing, updated)
+	if len(actions) == 0 {
+		return nil
+	}
+
+	scope := dashboards.ScopeDashboardsProvider.GetResourceScopeUID(dashboardUid)
+	evaluators := make([]accesscontrol.Evaluator, 0, len(actions))
+	for _, action := range actions {
+		evaluators = append(evaluators, accesscontrol.EvalPermission(action, scope))
+	}
+
+	allowed, err := pd.ac.Evaluate(ctx, u, accesscontrol.EvalAll(evaluators...))
+	if err != nil {
+		return ErrInternalServerError.Errorf("checkLoosenedConstraints: failed to evaluate permissions: %w", err)
+	}
+	if !allowed {
+		return ErrLoosenConstraintsForbidden.Errorf("checkLoosenedConstraints: missing one of the actions %s", strings.Join(actions, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func TestLoosenedConstraintActions(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name     string
+		existing *PublicDashboard
+		updated  *PublicDashboard
+		expected []string
+	}{
+		{
+			name:     "creating a disabled public dashboard with the default config",
+			existing: nil,
+			updated:  &PublicDashboard{},
+			expected: nil,
+		},
+		{
+			name:     "creating an enabled public dashboard with time selection",
+			existing: nil,
+			updated:  &PublicDashboard{IsEnabled: true, TimeSelectionEnabled: true},
+			expected: []string{dashboards.ActionDashboardsPublicShare, dashboards.ActionDashboardsPublicTimeSelectionWrite},
+		},
+		{
+			name:     "disabling and turning features off",
+			existing: &PublicDashboard{IsEnabled: true, TimeSelectionEnabled: true, AnnotationsEnabled: true},
+			updated:  &PublicDashboard{},
+			expected: nil,
+		},
+		{
+			name:     "enabling annotations",
+			existing: &PublicDashboard{IsEnabled: true},
+			updated:  &PublicDashboard{IsEnabled: true, AnnotationsEnabled: true},
+			expected: []string{dashboards.ActionDashboardsPublicAnnotationsWrite},
+		},
+		{
+			name:     "narrowing the variable overrides",
+			existing: &PublicDashboard{VariableOverridesAllowed: []string{"env", "region"}},
+			updated:  &PublicDashboard{VariableOverridesAllowed: []string{"env"}},
+			expected: nil,
+		},
+		{
+			name:     "enabling variables",
+			existing: &PublicDashboard{VariablesEnabled: util.Pointer(false)},
+			updated:  &PublicDashboard{VariablesEnabled: util.Pointer(true)},
+			expected: []string{dashboards.ActionDashboardsPublicVariableOverridesWrite},
+		},
+		{
+			name:     "disabling variables",
+			existing: &PublicDashboard{},
+			updated:  &PublicDashboard{VariablesEnabled: util.Pointer(false)},
+			expected: nil,
+		},
+		{
+			name:     "allowing another variable override",
+			existing: &PublicDashboard{VariableOverridesAllowed: []string{"env"}},
+			updated:  &PublicDashboard{VariableOverridesAllowed: []string{"env", "region"}},
+			expected: []string{dashboards.ActionDashboardsPublicVariableOverridesWrite},
+		},
+		{
+			name:     "allowing every variable override",
+			existing: &PublicDashboard{VariableOverridesAllowed: []string{}},
+			updated:  &PublicDashboard{},
+			expected: []string{dashboards.ActionDashboardsPublicVariableOverridesWrite},
+		},
+		{
+			name:     "setting an expiration time",
+			existing: &PublicDashboard{},
+			updated:  &PublicDashboard{ExpiresAt: now},
+			expected: nil,
+		},
+		{
+			name:     "bringing the expiration time forward",
+			existing: &PublicDashboard{ExpiresAt: now},
+			updated:  &PublicDashboard{ExpiresAt: now.Add(-time.Hour)},
+			expected: nil,
+		},
+		{
+			name:     "extending the expiration time",
+			existing: &PublicDashboard{ExpiresAt: now},
+			updated:  &PublicDashboard{ExpiresAt: now.Add(time.Hour)},
+			expected: []string{dashboards.ActionDashboardsPublicExpirationWrite},
+		},
+		{
+			name:     "removing the expiration time",
+			existing: &PublicDashboard{ExpiresAt: now},
+			updated:  &PublicDashboard{},
+			expected: []string{dashboards.ActionDashboardsPublicExpirationWrite},
+		},
+		{
+			name:     "unpinning a variable",
+			existing: &PublicDashboard{PinnedVariables: PinnedVariables{"env": "prod", "region": "eu"}},
+			updated:  &PublicDashboard{PinnedVariables: PinnedVariables{"env": "prod"}},
+			expected: []string{dashboards.ActionDashboardsPublicVariableOverridesWrite},
+		},
+		{
+			name:     "removing the pinned variables",
+			existing: &PublicDashboard{PinnedVariables: PinnedVariables{"env": "prod"}},
+			updated:  &PublicDashboard{},
+			expected: []string{dashboards.ActionDashboardsPublicVariableOverridesWrite},
+		},
+		{
+			name:     "pinning a variable",
+			existing: &PublicDashboard{PinnedVariables: PinnedVariables{"env": "prod"}},
+			updated:  &PublicDashboard{PinnedVariables: PinnedVariables{"env": "dev", "region": "eu"}},
+			expected: nil,
+		},
+		{
+			name:     "sharing a single panel",
+			existing: &PublicDashboard{},
+			updated:  &PublicDashboard{PanelId: 2},
+			expected: nil,
+		},
+		{
+			name:     "sharing the whole dashboard instead of a panel",
+			existing: &PublicDashboard{PanelId: 2},
+			updated:  &PublicDashboard{},
+			expected: []string{dashboards.ActionDashboardsPublicShare},
+		},
+		{
+			name:     "sharing another panel",
+			existing: &PublicDashboard{PanelId: 2},
+			updated:  &PublicDashboard{PanelId: 3},
+			expected: []string{dashboards.ActionDashboardsPublicShare},
+		},
+		{
+			name:     "hiding another panel",
+			existing: &PublicDashboard{HiddenPanelIds: []int64{2}},
+			updated:  &PublicDashboard{HiddenPanelIds: []int64{2, 3}},
+			expected: nil,
+		},
+		{
+			name:     "clearing the hidden panels",
+			existing: &PublicDashboard{HiddenPanelIds: []int64{2, 3}},
+			updated:  &PublicDashboard{},
+			expected: []string{dashboards.ActionDashboardsPublicShare},
+		},
+		{
+			name:     "narrowing the allowed domains",
+			existing: &PublicDashboard{AllowedDomains: []string{"portal.example.com", "*.example.org"}},
+			updated:  &PublicDashboard{AllowedDomains: []string{"portal.example.com"}},
+			expected: nil,
+		},
+		{
+			name:     "allowing another domain",
+			existing: &PublicDashboard{AllowedDomains: []string{"portal.example.com"}},
+			updated:  &PublicDashboard{AllowedDomains: []string{"portal.example.com", "*.example.org"}},
+			expected: []string{dashboards.ActionDashboardsPublicShare},
+		},
+		{
+			name:     "clearing the allowed domains",
+			existing: &PublicDashboard{AllowedDomains: []string{"portal.example.com"}},
+			updated:  &PublicDashboard{},
+			expected: []string{dashboards.ActionDashboardsPublicShare},
+		},
+		{
+			name:     "adding a geo restriction",
+			existing: &PublicDashboard{},
+			updated:  &PublicDashboard{GeoRestriction: &GeoRestriction{Mode: GeoRestrictionModeAllow, Countries: []string{"DE"}}},
+			expected: nil,
+		},
+		{
+			name:     "clearing the geo restriction",
+			existing: &PublicDashboard{GeoRestriction: &GeoRestriction{Mode: GeoRestrictionModeAllow, Countries: []string{"DE"}}},
+			updated:  &PublicDashboard{},
+			expected: []string{dashboards.ActionDashboardsPublicShare},
+		},
+		{
+			name:     "allowing another country",
+			existing: &PublicDashboard{GeoRestriction: &GeoRestriction{Mode: GeoRestrictionModeAllow, Countries: []string{"DE"}}},
+			updated:  &PublicDashboard{GeoRestriction: &GeoRestriction{Mode: GeoRestrictionModeAllow, Countries: []string{"DE", "FR"}}},
+			expected: []string{dashboards.ActionDashboardsPublicShare},
+		},
+		{
+			name:     "blocking another country",
+			existing: &PublicDashboard{GeoRestriction: &GeoRestriction{Mode: GeoRestrictionModeDeny, Countries: []string{"DE"}}},
+			updated:  &PublicDashboard{GeoRestriction: &GeoRestriction{Mode: GeoRestrictionModeDeny, Countries: []string{"DE", "FR"}}},
+			expected: nil,
+		},
+		{
+			name:     "unblocking a country",
+			existing: &PublicDashboard{GeoRestriction: &GeoRestriction{Mode: GeoRestrictionModeDeny, Countries: []string{"DE", "FR"}}},
+			updated:  &PublicDashboard{GeoRestriction: &GeoRestriction{Mode: GeoRestrictionModeDeny, Countries: []string{"DE"}}},
+			expected: []string{dashboards.ActionDashboardsPublicShare},
+		},
+		{
+			name:     "switching the geo restriction mode",
+			existing: &PublicDashboard{GeoRestriction: &GeoRestriction{Mode: GeoRestrictionModeAllow, Countries: []string{"DE"}}},
+			updated:  &PublicDashboard{GeoRestriction: &GeoRestriction{Mode: GeoRestrictionModeDeny, Countries: []string{"DE"}}},
+			expected: []string{dashboards.ActionDashboardsPublicShare},
+		},
+		{
+			name:     "lowering the limit of concurrent viewers",
+			existing: &PublicDashboard{MaxConcurrentViewers: 10},
+			updated:  &PublicDashboard{MaxConcurrentViewers: 5},
+			expected: nil,
+		},
+		{
+			name:     "raising the limit of concurrent viewers",
+			existing: &PublicDashboard{MaxConcurrentViewers: 10},
+			updated:  &PublicDashboard{MaxConcurrentViewers: 20},
+			expected: []string{dashboards.ActionDashboardsPublicShare},
+		},
+		{
+			name:     "removing the limit of concurrent viewers",
+			existing: &PublicDashboard{MaxConcurrentViewers: 10},
+			updated:  &PublicDashboard{},
+			expected: []string{dashboards.ActionDashboardsPublicShare},
+		},
+		{
+			name:     "enabling and clearing the hidden panels",
+			existing: &PublicDashboard{HiddenPanelIds: []int64{2}},
+			updated:  &PublicDashboard{IsEnabled: true},
+			expected: []string{dashboards.ActionDashboardsPublicShare},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, loosenedConstraintActions(tc.existing, tc.updated))
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/tsdb/grafanads"
+)
+
+const (
+	opaqueDatasourceUidPrefix = "pds-"
+	opaqueDatasourceUidLength = 16
+	datasourceVariableType    = "datasource"
+)
+
+// reservedDatasourceUids are understood by the frontend and don't identify any infrastructure, so they are never masked
+var reservedDatasourceUids = map[string]bool{
+	grafanads.DatasourceUID:  true,
+	grafanads.DatasourceName: true,
+	"-- Mixed --":            true,
+	"-- Dashboard --":        true,
+}
+
+// datasourceUidMasker replaces the datasource uids of a dashboard with opaque identifiers before it's sent to anonymous
+// viewers, and translates them back in the variables they send. Identifiers are derived from the uid with a keyed hash
+// scoped to the public dashboard, so the same datasource gets a different identifier on every public dashboard and
+// uids can't be guessed from them
+type datasourceUidMasker struct {
+	key        []byte
+	scope      string
+	toInternal map[string]string
+}
+
+func newDatasourceUidMasker(secretKey string, pubdash *models.PublicDashboard) *datasourceUidMasker {
+	return &datasourceUidMasker{
+		key:        []byte(secretKey),
+		scope:      pubdash.Uid,
+		toInternal: map[string]string{},
+	}
+}
+
+// mask returns the opaque identifier of a datasource uid. Reserved uids and references to variables are kept
+func (m *datasourceUidMasker) mask(uid string) string {
+	if uid == "" || reservedDatasourceUids[uid] || expr.IsDataSource(uid) || strings.HasPrefix(uid, "$") {
+		return uid
+	}
+
+	h := hmac.New(sha256.New, m.key)
+	h.Write([]byte(m.scope))
+	h.Write([]byte{0})
+	h.Write([]byte(uid))
+	opaque := opaqueDatasourceUidPrefix + hex.EncodeToString(h.Sum(nil))[:opaqueDatasourceUidLength]
+
+	m.toInternal[opaque] = uid
+	return opaque
+}
+
+// maskDashboard replaces the datasource uids referenced by panels, queries, annotations and datasource variables of the
+// dashboard
+func (m *datasourceUidMasker) maskDashboard(data *simplejson.Json) {
+	walkDatasourceRefs(data.Interface(), m.mask)
+
+	for _, v := range dashboardVariableList(data) {
+		variable := simplejson.NewFromAny(v)
+		if variable.Get("type").MustString() != datasourceVariableType {
+			continue
+		}
+
+		// the text of a datasource variable is the datasource name
+		current := variable.Get("current")
+		if value, ok := current.CheckGet("value"); ok {
+			masked := m.maskValue(value.Interface())
+			current.Set("value", masked)
+			current.Set("text", masked)
+		}
+		for _, o := range variable.Get("options").MustArray() {
+			option := simplejson.NewFromAny(o)
+			masked := m.maskValue(option.Get("value").Interface())
+			option.Set("value", masked)
+			option.Set("text", masked)
+		}
+	}
+}
+
+// learn records the identifiers of the datasources referenced by the dashboard, so they can be translated back
+func (m *datasourceUidMasker) learn(data *simplejson.Json) {
+	copied, err := data.Encode()
+	if err != nil {
+		return
+	}
+	learned, err := simplejson.NewJson(copied)
+	if err != nil {
+		return
+	}
+	m.maskDashboard(learned)
+}
+
+// unmaskVariables returns the variables with opaque datasource identifiers replaced by the datasource uids
+func (m *datasourceUidMasker) unmaskVariables(variables map[string]any) map[string]any {
+	if len(variables) == 0 {
+		return variables
+	}
+
+	unmasked := make(map[string]any, len(variables))
+	for name, value := range variables {
+		switch v := value.(type) {
+		case string:
+			unmasked[name] = m.unmask(v)
+		case []any:
+			values := make([]any, len(v))
+			for i, item := range v {
+				if s, ok := item.(string); ok {
+					values[i] = m.unmask(s)
+				} else {
+					values[i] = item
+				}
+			}
+			unmasked[name] = values
+		default:
+			unmasked[name] = value
+		}
+	}
+	return unmasked
+}
+
+// maskOptions replaces the datasource uids of the options of a datasource variable
+func (m *datasourceUidMasker) maskOptions(options []models.MetricFindValue) []models.MetricFindValue {
+	masked := make([]models.MetricFindValue, len(options))
+	for i, o := range options {
+		value := m.mask(o.Value)
+		masked[i] = models.MetricFindValue{Text: value, Value: value}
+	}
+	return masked
+}
+
+func (m *datasourceUidMasker) unmask(value string) string {
+	if uid, ok := m.toInternal[value]; ok {
+		return uid
+	}
+	return value
+}
+
+func (m *datasourceUidMasker) maskValue(value any) any {
+	switch v := value.(type) {
+	case string:
+		return m.mask(v)
+	case []any:
+		values := make([]any, len(v))
+		for i, item := range v {
+			values[i] = m.maskValue(item)
+		}
+		return values
+	default:
+		return value
+	}
+}
+
+// walkDatasourceRefs calls replace on every datasource reference of the decoded dashboard JSON and stores the result.
+// References are either objects with a uid, or with a name for the v2 schema, or legacy datasource names
+func walkDatasourceRefs(node any, replace func(string) string) {
+	switch v := node.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if key == "datasource" {
+				switch ref := child.(type) {
+				case string:
+					v[key] = replace(ref)
+					continue
+				case map[string]any:
+					for _, field := range []string{"uid", "name"} {
+						if uid, ok := ref[field].(string); ok {
+							ref[field] = replace(uid)
+						}
+					}
+					continue
+				}
+			}
+			walkDatasourceRefs(child, replace)
+		}
+	case []any:
+		for _, child := range v {
+			walkDatasourceRefs(child, replace)
+		}
+	}
+}
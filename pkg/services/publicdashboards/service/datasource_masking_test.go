@@ -0,0 +1,95 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+func TestDatasourceUidMasker(t *testing.T) {
+	newDashboard := func(t *testing.T) *simplejson.Json {
+		data, err := simplejson.NewJson([]byte(`{
+			"panels": [
+				{
+					"id": 1,
+					"datasource": {"uid": "prod-postgres-eu", "type": "postgres"},
+					"targets": [
+						{"refId": "A", "datasource": {"uid": "prod-postgres-eu", "type": "postgres"}},
+						{"refId": "B", "datasource": {"uid": "__expr__", "type": "__expr__"}}
+					]
+				},
+				{
+					"id": 2,
+					"datasource": "Legacy datasource name",
+					"targets": [{"refId": "A", "datasource": {"uid": "${ds}", "type": "prometheus"}}]
+				}
+			],
+			"annotations": {"list": [{"datasource": {"uid": "grafana", "type": "grafana"}}]},
+			"templating": {
+				"list": [
+					{"name": "ds", "type": "datasource", "query": "prometheus", "current": {"text": "Prometheus EU", "value": "prom-eu"}},
+					{"name": "region", "type": "custom", "current": {"text": "eu", "value": "eu"}}
+				]
+			}
+		}`))
+		require.NoError(t, err)
+		return data
+	}
+
+	pubdash := &models.PublicDashboard{Uid: "pubdash1"}
+
+	t.Run("replaces datasource uids with opaque identifiers", func(t *testing.T) {
+		data := newDashboard(t)
+		newDatasourceUidMasker("secret", pubdash).maskDashboard(data)
+
+		encoded, err := data.Encode()
+		require.NoError(t, err)
+		for _, internal := range []string{"prod-postgres-eu", "Legacy datasource name", "prom-eu", "Prometheus EU"} {
+			assert.NotContains(t, string(encoded), internal)
+		}
+
+		panel := data.Get("panels").GetIndex(0)
+		masked := panel.GetPath("datasource", "uid").MustString()
+		assert.True(t, strings.HasPrefix(masked, opaqueDatasourceUidPrefix))
+		assert.Equal(t, "postgres", panel.GetPath("datasource", "type").MustString())
+		assert.Equal(t, masked, panel.Get("targets").GetIndex(0).GetPath("datasource", "uid").MustString())
+		assert.Equal(t, "__expr__", panel.Get("targets").GetIndex(1).GetPath("datasource", "uid").MustString())
+		assert.Equal(t, "${ds}", data.Get("panels").GetIndex(1).Get("targets").GetIndex(0).GetPath("datasource", "uid").MustString())
+		assert.Equal(t, "grafana", data.GetPath("annotations", "list").GetIndex(0).GetPath("datasource", "uid").MustString())
+		assert.Equal(t, "eu", data.GetPath("templating", "list").GetIndex(1).GetPath("current", "value").MustString())
+	})
+
+	t.Run("identifiers are scoped to the public dashboard", func(t *testing.T) {
+		masker := newDatasourceUidMasker("secret", pubdash)
+		other := newDatasourceUidMasker("secret", &models.PublicDashboard{Uid: "pubdash2"})
+
+		assert.Equal(t, masker.mask("prod-postgres-eu"), masker.mask("prod-postgres-eu"))
+		assert.NotEqual(t, masker.mask("prod-postgres-eu"), other.mask("prod-postgres-eu"))
+	})
+
+	t.Run("translates identifiers sent back in variables", func(t *testing.T) {
+		viewed := newDashboard(t)
+		newDatasourceUidMasker("secret", pubdash).maskDashboard(viewed)
+		maskedDs := viewed.GetPath("templating", "list").GetIndex(0).GetPath("current", "value").MustString()
+
+		masker := newDatasourceUidMasker("secret", pubdash)
+		masker.learn(newDashboard(t))
+		variables := masker.unmaskVariables(map[string]any{"ds": maskedDs, "region": []any{"eu", "us"}, "unknown": "pds-0000000000000000"})
+
+		assert.Equal(t, map[string]any{"ds": "prom-eu", "region": []any{"eu", "us"}, "unknown": "pds-0000000000000000"}, variables)
+	})
+
+	t.Run("masks the options of datasource variables", func(t *testing.T) {
+		masker := newDatasourceUidMasker("secret", pubdash)
+		options := masker.maskOptions([]models.MetricFindValue{{Text: "Prometheus EU", Value: "prom-eu"}})
+
+		require.Len(t, options, 1)
+		assert.Equal(t, masker.mask("prom-eu"), options[0].Value)
+		assert.Equal(t, options[0].Value, options[0].Text)
+	})
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	expirationCheckInterval = time.Minute
+	expirationLockName      = "disable expired public dashboards"
+)
+
+// ExpirationService disables public dashboards once their expiration time has passed. Expired public dashboards
+// already can't be viewed, disabling them shows their owners they were paused
+type ExpirationService struct {
+	log        log.Logger
+	cfg        *setting.Cfg
+	store      publicdashboards.Store
+	service    publicdashboards.Service
+	serverLock serverLocker
+}
+
+func ProvideExpirationService(cfg *setting.Cfg, store publicdashboards.Store, service publicdashboards.Service, serverLock *serverlock.ServerLockService) *ExpirationService {
+	return &ExpirationService{
+		log:        log.New("publicdashboards.expiration"),
+		cfg:        cfg,
+		store:      store,
+		service:    service,
+		serverLock: serverLock,
+	}
+}
+
+// IsDisabled returns true when public dashboards are disabled
+func (s *ExpirationService) IsDisabled() bool {
+	return !s.cfg.PublicDashboardsEnabled
+}
+
+func (s *ExpirationService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(expirationCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.serverLock.LockAndExecute(ctx, expirationLockName, expirationCheckInterval/2, s.disableExpired); err != nil {
+			s.log.Error("Failed to disable expired public dashboards", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *ExpirationService) disableExpired(ctx context.Context) {
+	now := time.Now()
+	pubdashes, err := s.store.FindEnabledExpiredBefore(ctx, now)
+	if err != nil {
+		s.log.Error("Failed to find expired public dashboards", "error", err)
+		return
+	}
+
+	for _, pubdash := range pubdashes {
+		// only is_enabled is written, so concurrent updates of the config aren't overwritten
+		cmd := PatchPublicDashboardCommand{
+			Columns: []string{"is_enabled"},
+			PublicDashboard: PublicDashboard{
+				Uid:       pubdash.Uid,
+				IsEnabled: false,
+				UpdatedBy: pubdash.UpdatedBy,
+				UpdatedAt: now,
+			},
+		}
+		if _, err := s.store.Patch(ctx, cmd); err != nil {
+			s.log.Error("Failed to disable expired public dashboard", "publicDashboardUid", pubdash.Uid, "error", err)
+			continue
+		}
+		s.service.PublicDashboardChanged(pubdash.Uid)
+
+		s.log.Info("Disabled expired public dashboard", "publicDashboardUid", pubdash.Uid, "dashboardUid", pubdash.DashboardUid, "orgId", pubdash.OrgId, "expiresAt", pubdash.ExpiresAt)
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestExpirationServiceDisableExpired(t *testing.T) {
+	setup := func(t *testing.T, pubdashes []*PublicDashboard) (*ExpirationService, *FakePublicDashboardStore, *FakePublicDashboardService) {
+		store := &FakePublicDashboardStore{}
+		store.On("FindEnabledExpiredBefore", mock.Anything, mock.Anything).Return(pubdashes, nil)
+
+		pdService := NewFakePublicDashboardService(t)
+
+		return &ExpirationService{
+			log:     log.NewNopLogger(),
+			cfg:     setting.NewCfg(),
+			store:   store,
+			service: pdService,
+		}, store, pdService
+	}
+
+	t.Run("disables expired public dashboards", func(t *testing.T) {
+		expired := &PublicDashboard{Uid: "expired", OrgId: 1, IsEnabled: true, UpdatedBy: 7, ExpiresAt: time.Now().Add(-time.Minute)}
+		service, store, pdService := setup(t, []*PublicDashboard{expired})
+		store.On("Patch", mock.Anything, mock.Anything).Return(int64(1), nil)
+		pdService.On("PublicDashboardChanged", "expired").Return()
+
+		service.disableExpired(context.Background())
+
+		store.AssertNumberOfCalls(t, "Patch", 1)
+		cmd := store.Calls[1].Arguments.Get(1).(PatchPublicDashboardCommand)
+		assert.Equal(t, []string{"is_enabled"}, cmd.Columns)
+		assert.Equal(t, "expired", cmd.PublicDashboard.Uid)
+		assert.False(t, cmd.PublicDashboard.IsEnabled)
+		assert.Equal(t, int64(7), cmd.PublicDashboard.UpdatedBy)
+
+		before := store.Calls[0].Arguments.Get(1).(time.Time)
+		assert.WithinDuration(t, time.Now(), before, time.Minute)
+	})
+
+	t.Run("keeps disabling after a failure", func(t *testing.T) {
+		first := &PublicDashboard{Uid: "first", IsEnabled: true, ExpiresAt: time.Now().Add(-time.Hour)}
+		second := &PublicDashboard{Uid: "second", IsEnabled: true, ExpiresAt: time.Now().Add(-time.Minute)}
+		service, store, pdService := setup(t, []*PublicDashboard{first, second})
+		store.On("Patch", mock.Anything, mock.MatchedBy(func(cmd PatchPublicDashboardCommand) bool {
+			return cmd.PublicDashboard.Uid == "first"
+		})).Return(int64(0), errors.New("db error"))
+		store.On("Patch", mock.Anything, mock.Anything).Return(int64(1), nil)
+		pdService.On("PublicDashboardChanged", "second").Return()
+
+		service.disableExpired(context.Background())
+
+		store.AssertNumberOfCalls(t, "Patch", 2)
+		pdService.AssertNotCalled(t, "PublicDashboardChanged", "first")
+	})
+
+	t.Run("is disabled with public dashboards", func(t *testing.T) {
+		service, _, _ := setup(t, nil)
+		service.cfg.PublicDashboardsEnabled = false
+		require.True(t, service.IsDisabled())
+	})
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+// panelExport holds the query results of a panel exported by a viewer
+type panelExport struct {
+	publicDashboard *models.PublicDashboard
+	dashboard       *dashboards.Dashboard
+	// timezone is the timezone of the time range, times are exported in it
+	timezone *time.Location
+	results  []queryResult
+}
+
+// queryResult holds the frames returned for a query of a panel
+type queryResult struct {
+	refID  string
+	frames data.Frames
+}
+
+// queryPanelExport queries the panel with the time range and variables of the viewer for an export. The export fails
+// when one of the queries of the panel fails, so viewers never download partial data
+func (pd *PublicDashboardServiceImpl) queryPanelExport(ctx context.Context, skipDSCache bool, reqDTO models.PublicDashboardQueryDTO, panelId int64, accessToken string) (*panelExport, error) {
+	pubdash, dashboard, err := pd.FindEnabledPublicDashboardAndDashboardByAccessToken(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	_, _, timezone := panelTimeRange(dashboard, reqDTO, pubdash, panelId)
+
+	resp, err := pd.GetQueryDataResponse(ctx, skipDSCache, reqDTO, panelId, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := queryResults(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &panelExport{publicDashboard: pubdash, dashboard: dashboard, timezone: timezone, results: results}, nil
+}
+
+// filename returns the name of the exported file, made of the slug of the dashboard and the panel id
+func (e *panelExport) filename(panelId int64, extension string) string {
+	return fmt.Sprintf("%s-panel-%d.%s", e.dashboard.Slug, panelId, extension)
+}
+
+// queryResults returns the frames of the query response by query, ordered by refId, and an error when a query failed
+func queryResults(resp *backend.QueryDataResponse) ([]queryResult, error) {
+	refIDs := make([]string, 0, len(resp.Responses))
+	for refID, res := range resp.Responses {
+		if res.Error != nil {
+			return nil, models.ErrPanelQueryFailed.Errorf("queryResults: query %s failed: %w", refID, res.Error)
+		}
+		refIDs = append(refIDs, refID)
+	}
+	sort.Strings(refIDs)
+
+	results := make([]queryResult, 0, len(refIDs))
+	for _, refID := range refIDs {
+		results = append(results, queryResult{refID: refID, frames: resp.Responses[refID].Frames})
+	}
+	return results, nil
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+// ExportPanelCSV queries a panel of a public dashboard and returns its frames as CSV, formatted with the requested
+// locale or the default locale of the public dashboard. Times are written in the timezone of the time range, and the
+// export ends with the watermark footnote when one is configured
+func (pd *PublicDashboardServiceImpl) ExportPanelCSV(ctx context.Context, skipDSCache bool, reqDTO models.PublicDashboardQueryDTO, panelId int64, accessToken string, locale string) (*models.PanelExport, error) {
+	ctx, span := tracer.Start(ctx, "publicdashboards.ExportPanelCSV")
+	defer span.End()
+
+	export, err := pd.queryPanelExport(ctx, skipDSCache, reqDTO, panelId, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	exportLocale, err := resolveExportLocale(locale, export.publicDashboard.ExportLocale)
+	if err != nil {
+		return nil, err
+	}
+
+	footnote, err := pd.exportWatermark(ctx, export.publicDashboard, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	var frames data.Frames
+	for _, result := range export.results {
+		frames = append(frames, result.frames...)
+	}
+
+	return &models.PanelExport{
+		Filename:    export.filename(panelId, "csv"),
+		ContentType: "text/csv; charset=utf-8",
+		WriteTo: func(w io.Writer) error {
+			return writeFramesCSV(w, frames, exportLocale, export.timezone, footnote)
+		},
+	}, nil
+}
+
+// writeFramesCSV writes every frame as a header row with the names of its fields followed by its rows. Frames are
+// separated by an empty line, and so is the footnote when there is one
+func writeFramesCSV(w io.Writer, frames data.Frames, locale exportLocale, timezone *time.Location, footnote string) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = locale.csvDelimiter
+
+	for i, frame := range frames {
+		if i > 0 {
+			if err := writer.Write(nil); err != nil {
+				return err
+			}
+		}
+
+		header := make([]string, len(frame.Fields))
+		for j, field := range frame.Fields {
+			header[j] = escapeCSVFormula(fieldDisplayName(field))
+		}
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+
+		rows, err := frame.RowLen()
+		if err != nil {
+			return err
+		}
+		record := make([]string, len(frame.Fields))
+		for row := 0; row < rows; row++ {
+			for j, field := range frame.Fields {
+				record[j] = formatCSVValue(field, row, locale, timezone)
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+
+		// frames are flushed one by one so large exports are streamed
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+	}
+
+	if footnote != "" {
+		if len(frames) > 0 {
+			if err := writer.Write(nil); err != nil {
+				return err
+			}
+		}
+		if err := writer.Write([]string{escapeCSVFormula(footnote)}); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// fieldDisplayName returns the name of the field as shown in panels: its display name when set, otherwise its name
+// followed by its labels
+func fieldDisplayName(field *data.Field) string {
+	if field.Config != nil {
+		if field.Config.DisplayName != "" {
+			return field.Config.DisplayName
+		}
+		if field.Config.DisplayNameFromDS != "" {
+			return field.Config.DisplayNameFromDS
+		}
+	}
+
+	name := field.Name
+	if name == "" {
+		name = "Value"
+	}
+	if len(field.Labels) == 0 {
+		return name
+	}
+
+	keys := make([]string, 0, len(field.Labels))
+	for key := range field.Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	labels := make([]string, len(keys))
+	for i, key := range keys {
+		labels[i] = key + "=" + strconv.Quote(field.Labels[key])
+	}
+	return name + " {" + strings.Join(labels, ", ") + "}"
+}
+
+// formatCSVValue writes the value of the field at the row with the conventions of the locale. Null values are empty
+func formatCSVValue(field *data.Field, row int, locale exportLocale, timezone *time.Location) string {
+	value, ok := field.ConcreteAt(row)
+	if !ok {
+		return ""
+	}
+
+	switch v := value.(type) {
+	case time.Time:
+		return locale.formatTime(v.In(timezone))
+	case float64:
+		return locale.formatNumber(v)
+	case float32:
+		return locale.formatNumber(float64(v))
+	case int8:
+		return locale.formatDecimal(strconv.FormatInt(int64(v), 10))
+	case int16:
+		return locale.formatDecimal(strconv.FormatInt(int64(v), 10))
+	case int32:
+		return locale.formatDecimal(strconv.FormatInt(int64(v), 10))
+	case int64:
+		return locale.formatDecimal(strconv.FormatInt(v, 10))
+	case uint8:
+		return locale.formatDecimal(strconv.FormatUint(uint64(v), 10))
+	case uint16:
+		return locale.formatDecimal(strconv.FormatUint(uint64(v), 10))
+	case uint32:
+		return locale.formatDecimal(strconv.FormatUint(uint64(v), 10))
+	case uint64:
+		return locale.formatDecimal(strconv.FormatUint(v, 10))
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return escapeCSVFormula(v)
+	default:
+		return escapeCSVFormula(fmt.Sprintf("%v", v))
+	}
+}
+
+// escapeCSVFormula prefixes text starting like a formula with a quote, so spreadsheets don't evaluate values that
+// come from datasources. Quoting of delimiters and line breaks is left to the CSV writer
+func escapeCSVFormula(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
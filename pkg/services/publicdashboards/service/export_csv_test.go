@@ -0,0 +1,72 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFramesCSV(t *testing.T) {
+	ts := time.Date(2024, 3, 5, 12, 30, 0, 0, time.UTC)
+	value := 1234.5
+	frames := data.Frames{
+		data.NewFrame("A",
+			data.NewField("Time", nil, []time.Time{ts, ts.Add(time.Minute)}),
+			data.NewField("Value", data.Labels{"host": "web-1"}, []*float64{&value, nil}),
+		),
+		data.NewFrame("B",
+			data.NewField("Name", nil, []string{"=HYPERLINK(\"x\")", "a;b"}),
+			data.NewField("Count", nil, []int64{1234567, -3}),
+		),
+	}
+
+	t.Run("writes frames with the conventions of the locale", func(t *testing.T) {
+		locale, err := resolveExportLocale("de-DE", "")
+		require.NoError(t, err)
+		berlin, err := time.LoadLocation("Europe/Berlin")
+		require.NoError(t, err)
+
+		var out strings.Builder
+		require.NoError(t, writeFramesCSV(&out, frames, locale, berlin, ""))
+		assert.Equal(t, strings.Join([]string{
+			`Time;"Value {host=""web-1""}"`,
+			"05.03.2024 13:30:00;1.234,5",
+			"05.03.2024 13:31:00;",
+			"",
+			"Name;Count",
+			`"'=HYPERLINK(""x"")";1.234.567`,
+			`"a;b";-3`,
+			"",
+		}, "\n"), out.String())
+	})
+
+	t.Run("writes machine readable values without locale", func(t *testing.T) {
+		var out strings.Builder
+		require.NoError(t, writeFramesCSV(&out, frames[:1], defaultExportLocale, time.UTC, ""))
+		assert.Equal(t, "Time,\"Value {host=\"\"web-1\"\"}\"\n2024-03-05 12:30:00,1234.5\n2024-03-05 12:31:00,\n", out.String())
+	})
+
+	t.Run("ends with the footnote", func(t *testing.T) {
+		var out strings.Builder
+		require.NoError(t, writeFramesCSV(&out, frames[1:], defaultExportLocale, time.UTC, "=Exported from Main Org."))
+		assert.Equal(t, strings.Join([]string{
+			"Name,Count",
+			`"'=HYPERLINK(""x"")",1234567`,
+			"a;b,-3",
+			"",
+			"'=Exported from Main Org.",
+			"",
+		}, "\n"), out.String())
+	})
+}
+
+func TestEscapeCSVFormula(t *testing.T) {
+	assert.Equal(t, "'=1+1", escapeCSVFormula("=1+1"))
+	assert.Equal(t, "'@SUM(A1)", escapeCSVFormula("@SUM(A1)"))
+	assert.Equal(t, "web-1", escapeCSVFormula("web-1"))
+	assert.Equal(t, "", escapeCSVFormula(""))
+}
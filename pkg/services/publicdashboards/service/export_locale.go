@@ -0,0 +1,158 @@
+package service
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+const (
+	isoDateLayout      = "2006-01-02 15:04:05"
+	usDateLayout       = "01/02/2006 15:04:05"
+	dayFirstSlashed    = "02/01/2006 15:04:05"
+	dayFirstDotted     = "02.01.2006 15:04:05"
+	dayFirstHyphenated = "02-01-2006 15:04:05"
+)
+
+// exportLocale describes how numbers and dates are written when exporting panel data
+type exportLocale struct {
+	tag          string
+	decimal      string
+	group        string
+	dateLayout   string
+	csvDelimiter rune
+}
+
+// defaultExportLocale is used when neither the request nor the public dashboard specify a locale. It produces output
+// that can be read back by machines: no grouping, a dot as decimal separator and ISO dates
+var defaultExportLocale = exportLocale{tag: "", decimal: ".", group: "", dateLayout: isoDateLayout, csvDelimiter: ','}
+
+type localeFormat struct {
+	decimal    string
+	group      string
+	dateLayout string
+}
+
+// localeFormatsByRegion takes precedence over localeFormatsByLanguage for languages whose conventions differ by region
+var localeFormatsByRegion = map[string]localeFormat{
+	"en-US": {decimal: ".", group: ",", dateLayout: usDateLayout},
+	"de-CH": {decimal: ".", group: "'", dateLayout: dayFirstDotted},
+	"fr-CH": {decimal: ".", group: "'", dateLayout: dayFirstDotted},
+	"pt-BR": {decimal: ",", group: ".", dateLayout: dayFirstSlashed},
+	"es-MX": {decimal: ".", group: ",", dateLayout: dayFirstSlashed},
+}
+
+// Locales grouping thousands with spaces use non-breaking spaces, so spreadsheets don't split the value
+var localeFormatsByLanguage = map[string]localeFormat{
+	"en": {decimal: ".", group: ",", dateLayout: dayFirstSlashed},
+	"de": {decimal: ",", group: ".", dateLayout: dayFirstDotted},
+	"fr": {decimal: ",", group: "\u202f", dateLayout: dayFirstSlashed},
+	"es": {decimal: ",", group: ".", dateLayout: dayFirstSlashed},
+	"it": {decimal: ",", group: ".", dateLayout: dayFirstSlashed},
+	"pt": {decimal: ",", group: "\u00a0", dateLayout: dayFirstSlashed},
+	"nl": {decimal: ",", group: ".", dateLayout: dayFirstHyphenated},
+	"ru": {decimal: ",", group: "\u00a0", dateLayout: dayFirstDotted},
+	"pl": {decimal: ",", group: "\u00a0", dateLayout: dayFirstDotted},
+	"cs": {decimal: ",", group: "\u00a0", dateLayout: dayFirstDotted},
+	"fi": {decimal: ",", group: "\u00a0", dateLayout: dayFirstDotted},
+	"nb": {decimal: ",", group: "\u00a0", dateLayout: dayFirstDotted},
+	"da": {decimal: ",", group: ".", dateLayout: dayFirstDotted},
+	"sv": {decimal: ",", group: "\u00a0", dateLayout: isoDateLayout},
+	"lt": {decimal: ",", group: "\u00a0", dateLayout: isoDateLayout},
+	"ja": {decimal: ".", group: ",", dateLayout: isoDateLayout},
+	"zh": {decimal: ".", group: ",", dateLayout: isoDateLayout},
+	"ko": {decimal: ".", group: ",", dateLayout: isoDateLayout},
+}
+
+// resolveExportLocale picks the locale requested by the viewer, falling back to the default configured for the public
+// dashboard. Languages without known conventions use the language neutral default formatting
+func resolveExportLocale(requested string, dashboardDefault string) (exportLocale, error) {
+	locale := requested
+	if locale == "" {
+		locale = dashboardDefault
+	}
+	if locale == "" {
+		return defaultExportLocale, nil
+	}
+
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return exportLocale{}, models.ErrInvalidExportLocale.Errorf("resolveExportLocale: %w", err)
+	}
+
+	base, _ := tag.Base()
+	region, _ := tag.Region()
+
+	format, ok := localeFormatsByRegion[base.String()+"-"+region.String()]
+	if !ok {
+		format, ok = localeFormatsByLanguage[base.String()]
+	}
+	if !ok {
+		l := defaultExportLocale
+		l.tag = tag.String()
+		return l, nil
+	}
+
+	delimiter := ','
+	if format.decimal == "," {
+		delimiter = ';'
+	}
+
+	return exportLocale{
+		tag:          tag.String(),
+		decimal:      format.decimal,
+		group:        format.group,
+		dateLayout:   format.dateLayout,
+		csvDelimiter: delimiter,
+	}, nil
+}
+
+// formatNumber writes the value with the decimal separator and thousands grouping of the locale
+func (l exportLocale) formatNumber(value float64) string {
+	formatted := strconv.FormatFloat(value, 'f', -1, 64)
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return formatted
+	}
+	return l.formatDecimal(formatted)
+}
+
+// formatDecimal localizes a number written in decimal notation with a dot as decimal separator, like integers that
+// can't be converted to float64 without losing precision
+func (l exportLocale) formatDecimal(formatted string) string {
+	sign := ""
+	if strings.HasPrefix(formatted, "-") {
+		sign = "-"
+		formatted = formatted[1:]
+	}
+
+	integer, fraction, hasFraction := strings.Cut(formatted, ".")
+	if l.group != "" && len(integer) > 3 {
+		var b strings.Builder
+		head := len(integer) % 3
+		if head > 0 {
+			b.WriteString(integer[:head])
+		}
+		for i := head; i < len(integer); i += 3 {
+			if b.Len() > 0 {
+				b.WriteString(l.group)
+			}
+			b.WriteString(integer[i : i+3])
+		}
+		integer = b.String()
+	}
+
+	if !hasFraction {
+		return sign + integer
+	}
+	return sign + integer + l.decimal + fraction
+}
+
+// formatTime writes the time in the date format of the locale
+func (l exportLocale) formatTime(t time.Time) string {
+	return t.Format(l.dateLayout)
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+func TestResolveExportLocale(t *testing.T) {
+	t.Run("uses the requested locale over the dashboard default", func(t *testing.T) {
+		locale, err := resolveExportLocale("de-DE", "en-US")
+		require.NoError(t, err)
+		assert.Equal(t, "de-DE", locale.tag)
+		assert.Equal(t, ';', locale.csvDelimiter)
+	})
+
+	t.Run("falls back to the dashboard default", func(t *testing.T) {
+		locale, err := resolveExportLocale("", "en-US")
+		require.NoError(t, err)
+		assert.Equal(t, "en-US", locale.tag)
+		assert.Equal(t, ',', locale.csvDelimiter)
+	})
+
+	t.Run("uses neutral formatting without any locale", func(t *testing.T) {
+		locale, err := resolveExportLocale("", "")
+		require.NoError(t, err)
+		assert.Equal(t, defaultExportLocale, locale)
+	})
+
+	t.Run("uses neutral formatting for unknown languages", func(t *testing.T) {
+		locale, err := resolveExportLocale("tlh", "")
+		require.NoError(t, err)
+		assert.Equal(t, "1234.5", locale.formatNumber(1234.5))
+	})
+
+	t.Run("returns an error for invalid locales", func(t *testing.T) {
+		_, err := resolveExportLocale("not a locale", "")
+		require.ErrorIs(t, err, ErrInvalidExportLocale)
+	})
+}
+
+func TestExportLocaleFormatNumber(t *testing.T) {
+	testCases := []struct {
+		locale   string
+		value    float64
+		expected string
+	}{
+		{locale: "", value: 1234567.891, expected: "1234567.891"},
+		{locale: "en-US", value: 1234567.891, expected: "1,234,567.891"},
+		{locale: "de-DE", value: 1234567.891, expected: "1.234.567,891"},
+		{locale: "fr-FR", value: -1234.5, expected: "-1\u202f234,5"},
+		{locale: "de-CH", value: 1234.5, expected: "1'234.5"},
+		{locale: "de-DE", value: 123, expected: "123"},
+		{locale: "en-US", value: 100000, expected: "100,000"},
+		{locale: "de-DE", value: math.NaN(), expected: "NaN"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.locale+" "+tc.expected, func(t *testing.T) {
+			locale, err := resolveExportLocale(tc.locale, "")
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, locale.formatNumber(tc.value))
+		})
+	}
+}
+
+func TestExportLocaleFormatTime(t *testing.T) {
+	ts := time.Date(2024, time.March, 5, 14, 30, 0, 0, time.UTC)
+
+	testCases := map[string]string{
+		"":      "2024-03-05 14:30:00",
+		"en-US": "03/05/2024 14:30:00",
+		"en-GB": "05/03/2024 14:30:00",
+		"de":    "05.03.2024 14:30:00",
+		"nl-NL": "05-03-2024 14:30:00",
+		"ja-JP": "2024-03-05 14:30:00",
+	}
+
+	for tag, expected := range testCases {
+		locale, err := resolveExportLocale(tag, "")
+		require.NoError(t, err)
+		assert.Equal(t, expected, locale.formatTime(ts), tag)
+	}
+}
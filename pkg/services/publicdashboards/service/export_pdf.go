@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/rendering"
+)
+
+// ExportDashboardPDF renders the public dashboard as a PDF with the image renderer, with the time range and variables
+// of the viewer. Exports are rate limited by public dashboard, their size is capped and the watermark footnote is set
+// as their subject when one is configured
+func (pd *PublicDashboardServiceImpl) ExportDashboardPDF(ctx context.Context, reqDTO PublicDashboardRenderDTO, accessToken string) (*PanelExport, error) {
+	ctx, span := tracer.Start(ctx, "publicdashboards.ExportDashboardPDF")
+	defer span.End()
+
+	pubdash, dashboard, err := pd.FindEnabledPublicDashboardAndDashboardByAccessToken(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// public dashboards limited to a panel are exported with their panel alone
+	var params url.Values
+	if pubdash.IsPanelShare() {
+		params = url.Values{"viewPanel": {fmt.Sprintf("panel-%d", pubdash.PanelId)}}
+	}
+
+	filePath, err := pd.renderPage(ctx, rendering.RenderPDF, pubdash, dashboard, reqDTO, renderLimits{
+		limiter:   pd.pdfExportLimiter,
+		maxWidth:  pd.cfg.PublicDashboardsPDFExportMaxWidth,
+		maxHeight: pd.cfg.PublicDashboardsPDFExportMaxHeight,
+		timeout:   pd.cfg.PublicDashboardsPDFExportTimeout,
+	}, params)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, ErrRenderFailed.Errorf("ExportDashboardPDF: failed to read rendered file: %w", err)
+	}
+	if maxSize := pd.cfg.PublicDashboardsPDFExportMaxSizeBytes; maxSize > 0 && info.Size() > maxSize {
+		return nil, ErrPDFExportTooLarge.Errorf("ExportDashboardPDF: rendered file of %d bytes is larger than %d bytes", info.Size(), maxSize)
+	}
+
+	footnote, err := pd.exportWatermark(ctx, pubdash, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	writeTo := renderedFileWriter(filePath)
+	if footnote != "" {
+		if writeTo, err = pdfWatermarkWriter(filePath, footnote); err != nil {
+			return nil, ErrRenderFailed.Errorf("ExportDashboardPDF: failed to watermark rendered file: %w", err)
+		}
+	}
+
+	return &PanelExport{
+		Filename:    fmt.Sprintf("%s.pdf", dashboard.Slug),
+		ContentType: "application/pdf",
+		WriteTo:     writeTo,
+	}, nil
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	grafanamodels "github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/licensing/licensingtest"
+	"github.com/grafana/grafana/pkg/services/org"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestExportDashboardPDF(t *testing.T) {
+	data, err := simplejson.NewJson([]byte(`{
+		"templating": {
+			"list": [
+				{"name": "env", "type": "custom", "current": {"text": "prod", "value": "prod"}, "options": [{"text": "prod", "value": "prod"}, {"text": "dev", "value": "dev"}]}
+			]
+		}
+	}`))
+	require.NoError(t, err)
+	dashboard := &dashboards.Dashboard{UID: "dash1", OrgID: 1, Slug: "my-dashboard", Data: data}
+	pubdash := &PublicDashboard{Uid: "uid1", IsEnabled: true, OrgId: 1, DashboardUid: dashboard.UID, AccessToken: "abc123", TimeSelectionEnabled: true}
+
+	renderedFile := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "dashboard.pdf")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	setup := func(t *testing.T, renderService rendering.Service) *PublicDashboardServiceImpl {
+		fakeStore := &FakePublicDashboardStore{}
+		fakeStore.On("FindByAccessToken", mock.Anything, mock.AnythingOfType("string")).Return(pubdash, nil).Maybe()
+		fakeDashboardService := &dashboards.FakeDashboardService{}
+		fakeDashboardService.On("GetDashboard", mock.Anything, mock.Anything, mock.Anything).Return(dashboard, nil).Maybe()
+
+		license := licensingtest.NewFakeLicensing()
+		license.On("FeatureEnabled", FeaturePublicDashboardsEmailSharing).Return(false).Maybe()
+
+		cfg := setting.NewCfg()
+		cfg.RendererDefaultImageWidth = 1000
+		cfg.RendererDefaultImageHeight = 500
+		cfg.PublicDashboardsPDFExportMaxWidth = 3000
+		cfg.PublicDashboardsPDFExportMaxHeight = 3000
+		cfg.PublicDashboardsPDFExportMaxSizeBytes = 16
+
+		return &PublicDashboardServiceImpl{
+			log:              log.NewNopLogger(),
+			cfg:              cfg,
+			store:            fakeStore,
+			dashboardService: fakeDashboardService,
+			license:          license,
+			renderService:    renderService,
+			pdfExportLimiter: newExportRateLimiter(1),
+		}
+	}
+
+	t.Run("Renders the public dashboard page with the time range and variables of the viewer", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		renderService := rendering.NewMockService(ctrl)
+		renderService.EXPECT().IsAvailable(gomock.Any()).Return(true)
+
+		var opts rendering.Opts
+		filePath := renderedFile(t, "%PDF")
+		renderService.EXPECT().Render(gomock.Any(), rendering.RenderPDF, gomock.Any(), nil).
+			DoAndReturn(func(_ context.Context, _ rendering.RenderType, o rendering.Opts, _ rendering.Session) (*rendering.RenderResult, error) {
+				opts = o
+				return &rendering.RenderResult{FilePath: filePath}, nil
+			})
+
+		service := setup(t, renderService)
+		export, err := service.ExportDashboardPDF(context.Background(), PublicDashboardRenderDTO{
+			TimeRange: TimeRangeDTO{From: "now-6h", To: "now"},
+			Variables: map[string]interface{}{"env": "dev"},
+		}, "abc123")
+		require.NoError(t, err)
+
+		assert.Equal(t, "public-dashboards/abc123?from=now-6h&kiosk=&to=now&var-env=dev", opts.Path)
+		assert.Equal(t, int64(1), opts.OrgID)
+		assert.Equal(t, org.RoleViewer, opts.OrgRole)
+		assert.Equal(t, 1000, opts.Width)
+		assert.Equal(t, 500, opts.Height)
+		assert.Equal(t, grafanamodels.ThemeLight, opts.Theme)
+
+		assert.Equal(t, "my-dashboard.pdf", export.Filename)
+		assert.Equal(t, "application/pdf", export.ContentType)
+		var buf bytes.Buffer
+		require.NoError(t, export.WriteTo(&buf))
+		assert.Equal(t, "%PDF", buf.String())
+	})
+
+	t.Run("Rejects values of variables the dashboard doesn't allow", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		renderService := rendering.NewMockService(ctrl)
+		renderService.EXPECT().IsAvailable(gomock.Any()).Return(true)
+
+		service := setup(t, renderService)
+		_, err := service.ExportDashboardPDF(context.Background(), PublicDashboardRenderDTO{
+			Variables: map[string]interface{}{"env": "staging"},
+		}, "abc123")
+		assert.ErrorIs(t, err, ErrInvalidVariableValue)
+	})
+
+	t.Run("Rejects page sizes above the maximum", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		renderService := rendering.NewMockService(ctrl)
+		renderService.EXPECT().IsAvailable(gomock.Any()).Return(true)
+
+		service := setup(t, renderService)
+		_, err := service.ExportDashboardPDF(context.Background(), PublicDashboardRenderDTO{Width: 5000}, "abc123")
+		assert.ErrorIs(t, err, ErrInvalidRenderOptions)
+	})
+
+	t.Run("Rejects exports above the rate limit of the public dashboard", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		renderService := rendering.NewMockService(ctrl)
+		renderService.EXPECT().IsAvailable(gomock.Any()).Return(true).Times(2)
+		renderService.EXPECT().Render(gomock.Any(), rendering.RenderPDF, gomock.Any(), nil).
+			Return(&rendering.RenderResult{FilePath: renderedFile(t, "%PDF")}, nil)
+
+		service := setup(t, renderService)
+		_, err := service.ExportDashboardPDF(context.Background(), PublicDashboardRenderDTO{}, "abc123")
+		require.NoError(t, err)
+
+		_, err = service.ExportDashboardPDF(context.Background(), PublicDashboardRenderDTO{}, "abc123")
+		assert.ErrorIs(t, err, ErrRenderRateLimited)
+	})
+
+	t.Run("Rejects rendered files above the maximum size", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		renderService := rendering.NewMockService(ctrl)
+		renderService.EXPECT().IsAvailable(gomock.Any()).Return(true)
+		renderService.EXPECT().Render(gomock.Any(), rendering.RenderPDF, gomock.Any(), nil).
+			Return(&rendering.RenderResult{FilePath: renderedFile(t, "%PDF with too many pages")}, nil)
+
+		service := setup(t, renderService)
+		_, err := service.ExportDashboardPDF(context.Background(), PublicDashboardRenderDTO{}, "abc123")
+		assert.ErrorIs(t, err, ErrPDFExportTooLarge)
+	})
+
+	t.Run("Fails when the image renderer isn't available", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		renderService := rendering.NewMockService(ctrl)
+		renderService.EXPECT().IsAvailable(gomock.Any()).Return(false)
+
+		service := setup(t, renderService)
+		_, err := service.ExportDashboardPDF(context.Background(), PublicDashboardRenderDTO{}, "abc123")
+		assert.ErrorIs(t, err, ErrRenderUnavailable)
+	})
+}
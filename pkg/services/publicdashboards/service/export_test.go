@@ -0,0 +1,30 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+func TestQueryResults(t *testing.T) {
+	frameA := data.NewFrame("A")
+	frameB := data.NewFrame("B")
+
+	results, err := queryResults(&backend.QueryDataResponse{Responses: backend.Responses{
+		"B": {Frames: data.Frames{frameB}},
+		"A": {Frames: data.Frames{frameA}},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []queryResult{{refID: "A", frames: data.Frames{frameA}}, {refID: "B", frames: data.Frames{frameB}}}, results)
+
+	_, err = queryResults(&backend.QueryDataResponse{Responses: backend.Responses{
+		"A": {Error: errors.New("timeout")},
+	}})
+	require.ErrorIs(t, err, ErrPanelQueryFailed)
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/grafana/grafana/pkg/services/org"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+// accessTokenHintLength is the number of characters of the access token shown in watermarks, enough to tell which
+// link leaked without giving the link away
+const accessTokenHintLength = 6
+
+var (
+	pdfTrailerSizeRegexp = regexp.MustCompile(`/Size\s+(\d+)`)
+	pdfTrailerRootRegexp = regexp.MustCompile(`/Root\s+(\d+\s+\d+\s+R)`)
+	pdfTrailerIDRegexp   = regexp.MustCompile(`/ID\s*\[[^\]]*\]`)
+)
+
+// exportWatermark returns the footnote of the exports of the public dashboard, from the export_watermark template of
+// [public_dashboards]. Empty when no template is configured or the org of the public dashboard isn't watermarked
+func (pd *PublicDashboardServiceImpl) exportWatermark(ctx context.Context, pubdash *PublicDashboard, now time.Time) (string, error) {
+	template := pd.cfg.PublicDashboardsExportWatermark
+	if template == "" {
+		return "", nil
+	}
+	if orgs := pd.cfg.PublicDashboardsExportWatermarkOrgs; len(orgs) > 0 && !slices.Contains(orgs, pubdash.OrgId) {
+		return "", nil
+	}
+
+	orgName := ""
+	if strings.Contains(template, "{org}") {
+		o, err := pd.orgService.GetByID(ctx, &org.GetOrgByIDQuery{ID: pubdash.OrgId})
+		if err != nil {
+			return "", ErrInternalServerError.Errorf("exportWatermark: failed to get org %d: %w", pubdash.OrgId, err)
+		}
+		orgName = o.Name
+	}
+
+	return strings.NewReplacer(
+		"{org}", orgName,
+		"{access_token_hint}", accessTokenHint(pubdash.AccessToken),
+		"{timestamp}", now.UTC().Format(time.RFC3339),
+	).Replace(template), nil
+}
+
+// accessTokenHint returns the last characters of the access token
+func accessTokenHint(accessToken string) string {
+	if len(accessToken) <= accessTokenHintLength {
+		return accessToken
+	}
+	return "…" + accessToken[len(accessToken)-accessTokenHintLength:]
+}
+
+// pdfWatermarkWriter streams the rendered PDF followed by an incremental update setting the footnote as the subject
+// of the document. The image renderer writes classic cross-reference tables, the update adds a new info dictionary
+// and a trailer pointing to the previous one
+func pdfWatermarkWriter(filePath string, footnote string) (func(w io.Writer) error, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	tail := make([]byte, min(size, 4096))
+	if _, err := f.ReadAt(tail, size-int64(len(tail))); err != nil {
+		return nil, err
+	}
+
+	startxref := bytes.LastIndex(tail, []byte("startxref"))
+	if startxref < 0 {
+		return nil, errors.New("pdf has no startxref")
+	}
+	fields := strings.Fields(string(tail[startxref+len("startxref"):]))
+	if len(fields) == 0 {
+		return nil, errors.New("pdf has no cross-reference offset")
+	}
+	prev, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("pdf has an invalid cross-reference offset: %w", err)
+	}
+
+	trailerStart := bytes.LastIndex(tail[:startxref], []byte("trailer"))
+	if trailerStart < 0 {
+		return nil, errors.New("pdf has no trailer, cross-reference streams aren't supported")
+	}
+	trailer := tail[trailerStart:startxref]
+	if bytes.Contains(trailer, []byte("/Encrypt")) {
+		return nil, errors.New("encrypted pdfs aren't supported")
+	}
+	sizeMatch := pdfTrailerSizeRegexp.FindSubmatch(trailer)
+	rootMatch := pdfTrailerRootRegexp.FindSubmatch(trailer)
+	if sizeMatch == nil || rootMatch == nil {
+		return nil, errors.New("pdf trailer has no size or root")
+	}
+	objNum, err := strconv.ParseInt(string(sizeMatch[1]), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("pdf trailer has an invalid size: %w", err)
+	}
+	id := pdfTrailerIDRegexp.Find(trailer)
+
+	var update bytes.Buffer
+	if !bytes.HasSuffix(tail, []byte("\n")) {
+		update.WriteByte('\n')
+	}
+	objOffset := size + int64(update.Len())
+	fmt.Fprintf(&update, "%d 0 obj\n<< /Subject %s >>\nendobj\n", objNum, pdfTextString(footnote))
+	xrefOffset := size + int64(update.Len())
+	// cross-reference entries are exactly 20 bytes long
+	fmt.Fprintf(&update, "xref\n%d 1\n%010d 00000 n \n", objNum, objOffset)
+	fmt.Fprintf(&update, "trailer\n<< /Size %d /Root %s /Info %d 0 R /Prev %d", objNum+1, rootMatch[1], objNum, prev)
+	if id != nil {
+		update.WriteString(" ")
+		update.Write(id)
+	}
+	fmt.Fprintf(&update, " >>\nstartxref\n%d\n%%%%EOF\n", xrefOffset)
+
+	return func(w io.Writer) error {
+		if err := renderedFileWriter(filePath)(w); err != nil {
+			return err
+		}
+		_, err := w.Write(update.Bytes())
+		return err
+	}, nil
+}
+
+// pdfTextString encodes the text as a PDF text string, in UTF-16 so org names in any script are kept
+func pdfTextString(text string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, unit := range utf16.Encode([]rune(text)) {
+		fmt.Fprintf(&b, "%04X", unit)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngHeaderLength is the length of the signature and the IHDR chunk, which comes first in PNG files
+const pngHeaderLength = 8 + 4 + 4 + 13 + 4
+
+// pngWatermarkWriter streams the rendered PNG with the footnote as its comment, in an iTXt chunk right after the
+// header
+func pngWatermarkWriter(filePath string, footnote string) (func(w io.Writer) error, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	header := make([]byte, pngHeaderLength)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return nil, fmt.Errorf("png is too short: %w", err)
+	}
+	if !bytes.Equal(header[:8], pngSignature) || string(header[12:16]) != "IHDR" {
+		return nil, errors.New("png has no signature or header")
+	}
+
+	// iTXt chunks hold UTF-8 text: keyword, compression flag and method, language tag and translated keyword
+	chunk := pngChunk("iTXt", append([]byte("Comment\x00\x00\x00\x00\x00"), footnote...))
+
+	return func(w io.Writer) error {
+		f, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+
+		if _, err := io.CopyN(w, f, pngHeaderLength); err != nil {
+			return err
+		}
+		if _, err := w.Write(chunk); err != nil {
+			return err
+		}
+		_, err = io.Copy(w, f)
+		return err
+	}, nil
+}
+
+func pngChunk(chunkType string, data []byte) []byte {
+	chunk := make([]byte, 0, 12+len(data))
+	chunk = binary.BigEndian.AppendUint32(chunk, uint32(len(data)))
+	chunk = append(chunk, chunkType...)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/org/orgtest"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestExportWatermark(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 30, 0, 0, time.UTC)
+	pubdash := &PublicDashboard{Uid: "uid1", OrgId: 2, AccessToken: "e71950f4b5fc4a9d8a34a2d1b2d7e5e0"}
+
+	testCases := []struct {
+		name     string
+		template string
+		orgs     []int64
+		expected string
+	}{
+		{name: "without template", expected: ""},
+		{name: "replaces the placeholders", template: "Exported from {org} ({access_token_hint}) at {timestamp}", expected: "Exported from Sales (…d7e5e0) at 2026-10-16T12:30:00Z"},
+		{name: "org watermarking its exports", template: "Confidential", orgs: []int64{1, 2}, expected: "Confidential"},
+		{name: "org not watermarking its exports", template: "Confidential", orgs: []int64{1}, expected: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := setting.NewCfg()
+			cfg.PublicDashboardsExportWatermark = tc.template
+			cfg.PublicDashboardsExportWatermarkOrgs = tc.orgs
+			orgService := orgtest.NewOrgServiceFake()
+			orgService.ExpectedOrg = &org.Org{ID: 2, Name: "Sales"}
+			service := &PublicDashboardServiceImpl{cfg: cfg, orgService: orgService}
+
+			footnote, err := service.exportWatermark(context.Background(), pubdash, now)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, footnote)
+		})
+	}
+}
+
+func TestPDFWatermarkWriter(t *testing.T) {
+	original := "%PDF-1.4\n" +
+		"1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n" +
+		"2 0 obj\n<< /Type /Pages /Kids [] /Count 0 >>\nendobj\n" +
+		"xref\n0 3\n0000000000 65535 f \n0000000009 00000 n \n0000000058 00000 n \n" +
+		"trailer\n<< /Size 3 /Root 1 0 R /ID [<abc> <abc>] >>\nstartxref\n108\n%%EOF\n"
+	path := filepath.Join(t.TempDir(), "dashboard.pdf")
+	require.NoError(t, os.WriteFile(path, []byte(original), 0o600))
+
+	writeTo, err := pdfWatermarkWriter(path, "Sales")
+	require.NoError(t, err)
+	var out bytes.Buffer
+	require.NoError(t, writeTo(&out))
+	watermarked := out.String()
+
+	require.True(t, strings.HasPrefix(watermarked, original), "the update is appended")
+	update := watermarked[len(original):]
+	assert.Contains(t, update, "3 0 obj\n<< /Subject <FEFF00530061006C00650073> >>\nendobj\n")
+	assert.Contains(t, update, "/Size 4 /Root 1 0 R /Info 3 0 R /Prev 108 /ID [<abc> <abc>]")
+	assert.Contains(t, update, "xref\n3 1\n"+leftPad(strconv.Itoa(len(original)), 10)+" 00000 n \n")
+	assert.Contains(t, update, "startxref\n"+strconv.Itoa(strings.LastIndex(watermarked, "xref\n3 1"))+"\n%%EOF\n")
+
+	t.Run("fails on pdfs without trailer", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "dashboard.pdf")
+		require.NoError(t, os.WriteFile(path, []byte("%PDF-1.5\nstartxref\n9\n%%EOF\n"), 0o600))
+		_, err := pdfWatermarkWriter(path, "Sales")
+		require.Error(t, err)
+	})
+}
+
+func TestPNGWatermarkWriter(t *testing.T) {
+	var original bytes.Buffer
+	require.NoError(t, png.Encode(&original, image.NewRGBA(image.Rect(0, 0, 4, 4))))
+	path := filepath.Join(t.TempDir(), "panel.png")
+	require.NoError(t, os.WriteFile(path, original.Bytes(), 0o600))
+
+	writeTo, err := pngWatermarkWriter(path, "Exported from Sales")
+	require.NoError(t, err)
+	var out bytes.Buffer
+	require.NoError(t, writeTo(&out))
+
+	assert.Contains(t, out.String(), "iTXtComment\x00\x00\x00\x00\x00Exported from Sales")
+	_, err = png.Decode(bytes.NewReader(out.Bytes()))
+	require.NoError(t, err, "the image is still valid")
+
+	t.Run("fails on files that aren't pngs", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "panel.png")
+		require.NoError(t, os.WriteFile(path, bytes.Repeat([]byte("x"), 64), 0o600))
+		_, err := pngWatermarkWriter(path, "Sales")
+		require.Error(t, err)
+	})
+}
+
+func leftPad(text string, length int) string {
+	return strings.Repeat("0", length-len(text)) + text
+}
@@ -0,0 +1,438 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+const (
+	xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+	xlsxMainNamespace          = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+	xlsxRelationshipsNamespace = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+
+	// xlsxMaxSheetNameLength is the longest sheet name spreadsheets accept
+	xlsxMaxSheetNameLength = 31
+	// xlsxDateTimeFormat is the built-in "m/d/yyyy h:mm" number format, shown in the date format of the viewer
+	xlsxDateTimeFormat = 22
+	// xlsxFirstCustomFormat is the id of the first number format that isn't built-in
+	xlsxFirstCustomFormat = 164
+)
+
+// xlsxEpoch is the day 0 of the dates of spreadsheets, dates are written as days since it
+var xlsxEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// xlsxUnitFormats are the number formats of the units of Grafana that spreadsheets can show. Numbers of fields with
+// other units are written as is, with the unit in the header
+var xlsxUnitFormats = map[string]string{
+	"percent":     `"%"`,
+	"percentunit": `%`,
+	"ns":          `" ns"`,
+	"µs":          `" µs"`,
+	"ms":          `" ms"`,
+	"s":           `" s"`,
+	"m":           `" min"`,
+	"h":           `" hour"`,
+	"d":           `" day"`,
+	"bytes":       `" B"`,
+	"decbytes":    `" B"`,
+	"bits":        `" b"`,
+	"decbits":     `" b"`,
+	"hertz":       `" Hz"`,
+	"celsius":     `" °C"`,
+	"fahrenheit":  `" °F"`,
+	"reqps":       `" req/s"`,
+	"ops":         `" ops/s"`,
+}
+
+// ExportPanelXLSX queries a panel of a public dashboard and returns its frames as an Excel workbook with one sheet per
+// query. Headers use the display names of the fields and numbers keep the unit of their field, dates and numbers are
+// shown in the locale of the spreadsheet. Times are written in the timezone of the time range
+func (pd *PublicDashboardServiceImpl) ExportPanelXLSX(ctx context.Context, skipDSCache bool, reqDTO models.PublicDashboardQueryDTO, panelId int64, accessToken string) (*models.PanelExport, error) {
+	ctx, span := tracer.Start(ctx, "publicdashboards.ExportPanelXLSX")
+	defer span.End()
+
+	export, err := pd.queryPanelExport(ctx, skipDSCache, reqDTO, panelId, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	footnote, err := pd.exportWatermark(ctx, export.publicDashboard, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PanelExport{
+		Filename:    export.filename(panelId, "xlsx"),
+		ContentType: xlsxContentType,
+		WriteTo: func(w io.Writer) error {
+			return writeXLSX(w, export.results, export.timezone, footnote)
+		},
+	}, nil
+}
+
+// xlsxWriter writes a workbook as it goes. Sheets are written first, the styles they use are collected and written
+// afterwards along with the workbook
+type xlsxWriter struct {
+	zip      *zip.Writer
+	timezone *time.Location
+	// footnote is written at the bottom of every sheet, after an empty row
+	footnote string
+	sheets   []string
+	// numFmts are the custom number formats, a cell style is added for each of them after the default and date styles
+	numFmts []string
+	styles  map[string]int
+}
+
+// writeXLSX writes the frames of each query in its own sheet, named after the refId of the query. Frames of the same
+// query are separated by an empty row
+func writeXLSX(w io.Writer, results []queryResult, timezone *time.Location, footnote string) error {
+	x := &xlsxWriter{zip: zip.NewWriter(w), timezone: timezone, footnote: footnote, styles: map[string]int{}}
+
+	used := map[string]bool{}
+	for _, result := range results {
+		name := xlsxSheetName(result.refID, used)
+		if err := x.writeSheet(name, result.frames); err != nil {
+			return err
+		}
+	}
+	if len(x.sheets) == 0 {
+		// workbooks need at least one sheet
+		if err := x.writeSheet("Sheet1", nil); err != nil {
+			return err
+		}
+	}
+
+	if err := x.writeParts(); err != nil {
+		return err
+	}
+	return x.zip.Close()
+}
+
+func (x *xlsxWriter) writeSheet(name string, frames data.Frames) error {
+	x.sheets = append(x.sheets, name)
+	part, err := x.zip.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(x.sheets)))
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="` + xlsxMainNamespace + `"><sheetData>`)
+
+	row := 1
+	for i, frame := range frames {
+		if i > 0 {
+			row++
+		}
+
+		b.WriteString(`<row r="` + strconv.Itoa(row) + `">`)
+		for col, field := range frame.Fields {
+			header := fieldDisplayName(field)
+			if unit := fieldUnit(field); unit != "" {
+				if _, _, ok := xlsxUnitFormat(unit); !ok {
+					header += " (" + unit + ")"
+				}
+			}
+			writeXLSXString(&b, xlsxCellRef(col, row), header)
+		}
+		b.WriteString(`</row>`)
+		row++
+
+		rows, err := frame.RowLen()
+		if err != nil {
+			return err
+		}
+		styles := make([]int, len(frame.Fields))
+		for col, field := range frame.Fields {
+			styles[col] = x.numberStyle(field)
+		}
+		for r := 0; r < rows; r++ {
+			b.WriteString(`<row r="` + strconv.Itoa(row) + `">`)
+			for col, field := range frame.Fields {
+				x.writeCell(&b, xlsxCellRef(col, row), field, r, styles[col])
+			}
+			b.WriteString(`</row>`)
+			row++
+
+			// rows are written in chunks so large exports are streamed
+			if b.Len() > 64*1024 {
+				if _, err := io.WriteString(part, b.String()); err != nil {
+					return err
+				}
+				b.Reset()
+			}
+		}
+	}
+
+	if x.footnote != "" {
+		if len(frames) > 0 {
+			row++
+		}
+		b.WriteString(`<row r="` + strconv.Itoa(row) + `">`)
+		writeXLSXString(&b, xlsxCellRef(0, row), x.footnote)
+		b.WriteString(`</row>`)
+	}
+
+	b.WriteString(`</sheetData></worksheet>`)
+	_, err = io.WriteString(part, b.String())
+	return err
+}
+
+// writeCell writes the value of the field at the row. Null values are left out, as empty cells
+func (x *xlsxWriter) writeCell(b *strings.Builder, ref string, field *data.Field, row int, style int) {
+	value, ok := field.ConcreteAt(row)
+	if !ok {
+		return
+	}
+
+	switch v := value.(type) {
+	case time.Time:
+		local := v.In(x.timezone)
+		wall := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), local.Nanosecond(), time.UTC)
+		days := float64(wall.Sub(xlsxEpoch)) / float64(24*time.Hour)
+		writeXLSXNumber(b, ref, strconv.FormatFloat(days, 'f', -1, 64), 1)
+	case float64:
+		writeXLSXFloat(b, ref, v, style)
+	case float32:
+		writeXLSXFloat(b, ref, float64(v), style)
+	case int8, int16, int32, int64, uint8, uint16, uint32, uint64:
+		writeXLSXNumber(b, ref, fmt.Sprintf("%d", v), style)
+	case bool:
+		value := "0"
+		if v {
+			value = "1"
+		}
+		b.WriteString(`<c r="` + ref + `" t="b"><v>` + value + `</v></c>`)
+	case string:
+		writeXLSXString(b, ref, v)
+	default:
+		writeXLSXString(b, ref, fmt.Sprintf("%v", v))
+	}
+}
+
+// numberStyle returns the cell style of the numbers of the field, with the unit and decimals of its config
+func (x *xlsxWriter) numberStyle(field *data.Field) int {
+	prefix, suffix, hasUnit := xlsxUnitFormat(fieldUnit(field))
+
+	var decimals *uint16
+	if field.Config != nil {
+		decimals = field.Config.Decimals
+	}
+	if !hasUnit && decimals == nil {
+		return 0
+	}
+
+	number := "General"
+	if suffix == "%" {
+		// spreadsheets multiply by 100 numbers formatted as percents
+		number = "0.00"
+	}
+	if decimals != nil {
+		number = "0"
+		if *decimals > 0 {
+			number += "." + strings.Repeat("0", int(*decimals))
+		}
+	}
+	format := prefix + number + suffix
+
+	if style, ok := x.styles[format]; ok {
+		return style
+	}
+	x.numFmts = append(x.numFmts, format)
+	// styles 0 and 1 are the default and date styles
+	style := len(x.numFmts) + 1
+	x.styles[format] = style
+	return style
+}
+
+// writeParts writes the styles, the workbook and the parts describing the package
+func (x *xlsxWriter) writeParts() error {
+	var contentTypes strings.Builder
+	contentTypes.WriteString(xml.Header)
+	contentTypes.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	contentTypes.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	contentTypes.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	contentTypes.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	contentTypes.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+
+	var workbook, workbookRels strings.Builder
+	workbook.WriteString(xml.Header)
+	workbook.WriteString(`<workbook xmlns="` + xlsxMainNamespace + `" xmlns:r="` + xlsxRelationshipsNamespace + `"><sheets>`)
+	workbookRels.WriteString(xml.Header)
+	workbookRels.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+
+	for i, name := range x.sheets {
+		id := strconv.Itoa(i + 1)
+		contentTypes.WriteString(`<Override PartName="/xl/worksheets/sheet` + id + `.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`)
+		workbook.WriteString(`<sheet name="` + xmlEscape(name) + `" sheetId="` + id + `" r:id="rId` + id + `"/>`)
+		workbookRels.WriteString(`<Relationship Id="rId` + id + `" Type="` + xlsxRelationshipsNamespace + `/worksheet" Target="worksheets/sheet` + id + `.xml"/>`)
+	}
+
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets></workbook>`)
+	workbookRels.WriteString(`<Relationship Id="rId` + strconv.Itoa(len(x.sheets)+1) + `" Type="` + xlsxRelationshipsNamespace + `/styles" Target="styles.xml"/>`)
+	workbookRels.WriteString(`</Relationships>`)
+
+	rels := xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="` + xlsxRelationshipsNamespace + `/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"xl/styles.xml", x.stylesXML()},
+		{"xl/workbook.xml", workbook.String()},
+		{"xl/_rels/workbook.xml.rels", workbookRels.String()},
+		{"_rels/.rels", rels},
+		{"[Content_Types].xml", contentTypes.String()},
+	}
+	for _, p := range parts {
+		part, err := x.zip.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(part, p.content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (x *xlsxWriter) stylesXML() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<styleSheet xmlns="` + xlsxMainNamespace + `">`)
+	if len(x.numFmts) > 0 {
+		b.WriteString(`<numFmts count="` + strconv.Itoa(len(x.numFmts)) + `">`)
+		for i, format := range x.numFmts {
+			b.WriteString(`<numFmt numFmtId="` + strconv.Itoa(xlsxFirstCustomFormat+i) + `" formatCode="` + xmlEscape(format) + `"/>`)
+		}
+		b.WriteString(`</numFmts>`)
+	}
+	b.WriteString(`<fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>`)
+	b.WriteString(`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>`)
+	b.WriteString(`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>`)
+	b.WriteString(`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>`)
+	b.WriteString(`<cellXfs count="` + strconv.Itoa(len(x.numFmts)+2) + `">`)
+	b.WriteString(`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>`)
+	b.WriteString(`<xf numFmtId="` + strconv.Itoa(xlsxDateTimeFormat) + `" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>`)
+	for i := range x.numFmts {
+		b.WriteString(`<xf numFmtId="` + strconv.Itoa(xlsxFirstCustomFormat+i) + `" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>`)
+	}
+	b.WriteString(`</cellXfs>`)
+	b.WriteString(`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>`)
+	b.WriteString(`</styleSheet>`)
+	return b.String()
+}
+
+// fieldUnit returns the unit of the field config
+func fieldUnit(field *data.Field) string {
+	if field.Config == nil {
+		return ""
+	}
+	return field.Config.Unit
+}
+
+// xlsxUnitFormat returns the literals written before and after numbers to show the unit, and false for units
+// spreadsheets can't show. Custom units follow the prefix: and suffix: syntax of Grafana
+func xlsxUnitFormat(unit string) (string, string, bool) {
+	if suffix, ok := xlsxUnitFormats[unit]; ok {
+		return "", suffix, true
+	}
+	if suffix, ok := strings.CutPrefix(unit, "suffix:"); ok && suffix != "" {
+		return "", xlsxFormatLiteral(suffix), true
+	}
+	if prefix, ok := strings.CutPrefix(unit, "prefix:"); ok && prefix != "" {
+		return xlsxFormatLiteral(prefix), "", true
+	}
+	return "", "", false
+}
+
+// xlsxFormatLiteral quotes the text for a number format, quotes can't be escaped so they are dropped
+func xlsxFormatLiteral(text string) string {
+	return `"` + strings.ReplaceAll(text, `"`, "") + `"`
+}
+
+// xlsxSheetName returns a valid and unused sheet name for the refId. Characters spreadsheets don't accept are
+// replaced and names are truncated to the longest accepted name
+func xlsxSheetName(refID string, used map[string]bool) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, refID)
+	name = strings.Trim(name, "'")
+	if name == "" {
+		name = "Query"
+	}
+	name = truncateRunes(name, xlsxMaxSheetNameLength)
+
+	unique := name
+	for i := 2; used[strings.ToLower(unique)]; i++ {
+		suffix := fmt.Sprintf(" (%d)", i)
+		unique = truncateRunes(name, xlsxMaxSheetNameLength-len(suffix)) + suffix
+	}
+	used[strings.ToLower(unique)] = true
+	return unique
+}
+
+func truncateRunes(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+	return string(runes[:length])
+}
+
+// xlsxCellRef returns the A1 reference of the cell at the zero based column and one based row
+func xlsxCellRef(col int, row int) string {
+	name := ""
+	for col >= 0 {
+		name = string(rune('A'+col%26)) + name
+		col = col/26 - 1
+	}
+	return name + strconv.Itoa(row)
+}
+
+func writeXLSXString(b *strings.Builder, ref string, value string) {
+	b.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">` + xmlEscape(value) + `</t></is></c>`)
+}
+
+// writeXLSXFloat writes the number, not a number and infinite values are written as text as spreadsheets can't
+// store them
+func writeXLSXFloat(b *strings.Builder, ref string, value float64, style int) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		writeXLSXString(b, ref, strconv.FormatFloat(value, 'f', -1, 64))
+		return
+	}
+	writeXLSXNumber(b, ref, strconv.FormatFloat(value, 'g', -1, 64), style)
+}
+
+func writeXLSXNumber(b *strings.Builder, ref string, value string, style int) {
+	b.WriteString(`<c r="` + ref + `"`)
+	if style > 0 {
+		b.WriteString(` s="` + strconv.Itoa(style) + `"`)
+	}
+	b.WriteString(`><v>` + value + `</v></c>`)
+}
+
+// xmlEscape escapes the text for XML content and attributes, characters XML can't hold are replaced
+func xmlEscape(text string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
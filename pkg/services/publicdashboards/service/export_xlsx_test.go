@@ -0,0 +1,120 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteXLSX(t *testing.T) {
+	ts := time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)
+	decimals := uint16(1)
+	latency := data.NewField("latency", nil, []float64{12.5})
+	latency.Config = &data.FieldConfig{DisplayName: "Latency", Unit: "ms", Decimals: &decimals}
+	temperature := data.NewField("temperature", nil, []float64{21})
+	temperature.Config = &data.FieldConfig{Unit: "kelvin"}
+
+	results := []queryResult{
+		{refID: "A", frames: data.Frames{data.NewFrame("A",
+			data.NewField("Time", nil, []time.Time{ts}),
+			latency,
+			temperature,
+		)}},
+		{refID: "B/C", frames: data.Frames{
+			data.NewFrame("B", data.NewField("Name", nil, []string{"<web & api>"})),
+			data.NewFrame("B", data.NewField("Up", nil, []bool{true})),
+		}},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, writeXLSX(&out, results, time.UTC, ""))
+
+	reader, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	require.NoError(t, err)
+	parts := map[string]string{}
+	for _, f := range reader.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+		parts[f.Name] = string(content)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml"} {
+		assert.Contains(t, parts, name)
+	}
+
+	assert.Contains(t, parts["xl/workbook.xml"], `<sheet name="A" sheetId="1" r:id="rId1"/>`)
+	assert.Contains(t, parts["xl/workbook.xml"], `<sheet name="B_C" sheetId="2" r:id="rId2"/>`)
+	assert.Contains(t, parts["xl/styles.xml"], `<numFmt numFmtId="164" formatCode="0.0&#34; ms&#34;"/>`)
+
+	// display names are used, units that can't be formatted are added to the header
+	sheet1 := parts["xl/worksheets/sheet1.xml"]
+	assert.Contains(t, sheet1, `<c r="B1" t="inlineStr"><is><t xml:space="preserve">Latency</t></is></c>`)
+	assert.Contains(t, sheet1, `<c r="C1" t="inlineStr"><is><t xml:space="preserve">temperature (kelvin)</t></is></c>`)
+	assert.Contains(t, sheet1, `<c r="A2" s="1"><v>45356.5</v></c>`)
+	assert.Contains(t, sheet1, `<c r="B2" s="2"><v>12.5</v></c>`)
+	assert.Contains(t, sheet1, `<c r="C2"><v>21</v></c>`)
+
+	// frames of the same query are separated by an empty row
+	sheet2 := parts["xl/worksheets/sheet2.xml"]
+	assert.Contains(t, sheet2, `<c r="A2" t="inlineStr"><is><t xml:space="preserve">&lt;web &amp; api&gt;</t></is></c>`)
+	assert.Contains(t, sheet2, `<row r="4"><c r="A4" t="inlineStr"><is><t xml:space="preserve">Up</t></is></c></row>`)
+	assert.Contains(t, sheet2, `<c r="A5" t="b"><v>1</v></c>`)
+}
+
+func TestWriteXLSXFootnote(t *testing.T) {
+	results := []queryResult{{refID: "A", frames: data.Frames{data.NewFrame("A", data.NewField("Name", nil, []string{"web"}))}}}
+
+	var out bytes.Buffer
+	require.NoError(t, writeXLSX(&out, results, time.UTC, "Exported from Main Org."))
+
+	reader, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	require.NoError(t, err)
+	rc, err := reader.Open("xl/worksheets/sheet1.xml")
+	require.NoError(t, err)
+	sheet, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	require.NoError(t, rc.Close())
+
+	assert.Contains(t, string(sheet), `<row r="4"><c r="A4" t="inlineStr"><is><t xml:space="preserve">Exported from Main Org.</t></is></c></row></sheetData>`)
+}
+
+func TestXLSXCellRef(t *testing.T) {
+	assert.Equal(t, "A1", xlsxCellRef(0, 1))
+	assert.Equal(t, "Z2", xlsxCellRef(25, 2))
+	assert.Equal(t, "AA3", xlsxCellRef(26, 3))
+	assert.Equal(t, "AZ1", xlsxCellRef(51, 1))
+	assert.Equal(t, "BA1", xlsxCellRef(52, 1))
+}
+
+func TestXLSXSheetName(t *testing.T) {
+	used := map[string]bool{}
+	assert.Equal(t, "A", xlsxSheetName("A", used))
+	assert.Equal(t, "a (2)", xlsxSheetName("a", used))
+	assert.Equal(t, "Query", xlsxSheetName("''", used))
+	assert.Equal(t, "x_y_z", xlsxSheetName("x[y]z", used))
+	assert.Equal(t, "0123456789012345678901234567890", xlsxSheetName("0123456789012345678901234567890123", used))
+	assert.Equal(t, "012345678901234567890123456 (2)", xlsxSheetName("0123456789012345678901234567890123", used))
+}
+
+func TestXLSXUnitFormat(t *testing.T) {
+	prefix, suffix, ok := xlsxUnitFormat("suffix:req")
+	assert.True(t, ok)
+	assert.Equal(t, "", prefix)
+	assert.Equal(t, `"req"`, suffix)
+
+	prefix, _, ok = xlsxUnitFormat("prefix:$")
+	assert.True(t, ok)
+	assert.Equal(t, `"$"`, prefix)
+
+	_, _, ok = xlsxUnitFormat("kelvin")
+	assert.False(t, ok)
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/folder"
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	folderRestrictionsCheckInterval = 10 * time.Minute
+	folderRestrictionsLockName      = "disable public dashboards in forbidden folders"
+)
+
+// checkFolderShareable returns an error when the dashboard is in a folder the folder restrictions of
+// [public_dashboards] don't allow to share publicly
+func (pd *PublicDashboardServiceImpl) checkFolderShareable(ctx context.Context, dashboard *dashboards.Dashboard) error {
+	shareable, err := isFolderShareable(ctx, pd.cfg, pd.folderService, dashboard.OrgID, dashboard.FolderUID)
+	if err != nil {
+		return ErrInternalServerError.Errorf("failed to check the folder restrictions of dashboard %s: %w", dashboard.UID, err)
+	}
+	if !shareable {
+		return ErrFolderNotShareable.Errorf("dashboard %s is in folder %q which can't be shared publicly", dashboard.UID, dashboard.FolderUID)
+	}
+	return nil
+}
+
+// hasFolderRestrictions returns true when an allowlist or a denylist of folders is configured
+func hasFolderRestrictions(cfg *setting.Cfg) bool {
+	return cfg != nil && (len(cfg.PublicDashboardsAllowedFolderUIDs) > 0 || len(cfg.PublicDashboardsDeniedFolderUIDs) > 0)
+}
+
+// isFolderShareable returns true when dashboards of the folder can be shared publicly. The restrictions of a folder
+// apply to its subfolders, the denylist wins over the allowlist, and dashboards at the root are in the general folder
+func isFolderShareable(ctx context.Context, cfg *setting.Cfg, folderService folder.Service, orgID int64, folderUID string) (bool, error) {
+	if !hasFolderRestrictions(cfg) {
+		return true, nil
+	}
+
+	folderUIDs := []string{folder.GeneralFolderUID}
+	if folderUID != "" && folderUID != folder.GeneralFolderUID {
+		folderUIDs = []string{folderUID}
+		if folderService != nil {
+			// the folders are checked on behalf of the admins who configured the restrictions, not the user
+			parents, err := identity.WithServiceIdentityFn(ctx, orgID, func(ctx context.Context) ([]*folder.Folder, error) {
+				return folderService.GetParents(ctx, folder.GetParentsQuery{UID: folderUID, OrgID: orgID})
+			})
+			if err != nil {
+				return false, err
+			}
+			for _, parent := range parents {
+				folderUIDs = append(folderUIDs, parent.UID)
+			}
+		}
+	}
+
+	for _, uid := range folderUIDs {
+		if slices.Contains(cfg.PublicDashboardsDeniedFolderUIDs, uid) {
+			return false, nil
+		}
+	}
+	if len(cfg.PublicDashboardsAllowedFolderUIDs) == 0 {
+		return true, nil
+	}
+	for _, uid := range folderUIDs {
+		if slices.Contains(cfg.PublicDashboardsAllowedFolderUIDs, uid) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// FolderRestrictionService disables public dashboards whose dashboard was moved to a folder the folder restrictions
+// of [public_dashboards] don't allow to share publicly
+type FolderRestrictionService struct {
+	log              log.Logger
+	cfg              *setting.Cfg
+	store            publicdashboards.Store
+	service          publicdashboards.Service
+	dashboardService dashboards.DashboardService
+	folderService    folder.Service
+	serverLock       serverLocker
+}
+
+func ProvideFolderRestrictionService(
+	cfg *setting.Cfg,
+	store publicdashboards.Store,
+	service publicdashboards.Service,
+	dashboardService dashboards.DashboardService,
+	folderService folder.Service,
+	serverLock *serverlock.ServerLockService,
+) *FolderRestrictionService {
+	return &FolderRestrictionService{
+		log:              log.New("publicdashboards.folderrestrictions"),
+		cfg:              cfg,
+		store:            store,
+		service:          service,
+		dashboardService: dashboardService,
+		folderService:    folderService,
+		serverLock:       serverLock,
+	}
+}
+
+// IsDisabled returns true when no folder restrictions are configured
+func (s *FolderRestrictionService) IsDisabled() bool {
+	return !s.cfg.PublicDashboardsEnabled || !hasFolderRestrictions(s.cfg)
+}
+
+func (s *FolderRestrictionService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(folderRestrictionsCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.serverLock.LockAndExecute(ctx, folderRestrictionsLockName, folderRestrictionsCheckInterval/2, s.disableInForbiddenFolders); err != nil {
+			s.log.Error("Failed to disable public dashboards in forbidden folders", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *FolderRestrictionService) disableInForbiddenFolders(ctx context.Context) {
+	if !hasFolderRestrictions(s.cfg) {
+		return
+	}
+
+	pubdashes, err := s.store.FindEnabled(ctx)
+	if err != nil {
+		s.log.Error("Failed to find enabled public dashboards", "error", err)
+		return
+	}
+
+	type orgFolder struct {
+		orgID     int64
+		folderUID string
+	}
+	// most public dashboards share a few folders, their restrictions are only checked once
+	shareableFolders := map[orgFolder]bool{}
+
+	now := time.Now()
+	for _, pubdash := range pubdashes {
+		dash, err := identity.WithServiceIdentityFn(ctx, pubdash.OrgId, func(ctx context.Context) (*dashboards.Dashboard, error) {
+			return s.dashboardService.GetDashboard(ctx, &dashboards.GetDashboardQuery{UID: pubdash.DashboardUid, OrgID: pubdash.OrgId})
+		})
+		if err != nil {
+			s.log.Warn("Can't find the dashboard of a public dashboard", "publicDashboardUid", pubdash.Uid, "dashboardUid", pubdash.DashboardUid, "error", err)
+			continue
+		}
+
+		key := orgFolder{orgID: pubdash.OrgId, folderUID: dash.FolderUID}
+		shareable, ok := shareableFolders[key]
+		if !ok {
+			shareable, err = isFolderShareable(ctx, s.cfg, s.folderService, pubdash.OrgId, dash.FolderUID)
+			if err != nil {
+				s.log.Warn("Failed to check the folder restrictions of a public dashboard", "publicDashboardUid", pubdash.Uid, "folderUid", dash.FolderUID, "error", err)
+				continue
+			}
+			shareableFolders[key] = shareable
+		}
+		if shareable {
+			continue
+		}
+
+		// only is_enabled is written, so concurrent updates of the config aren't overwritten
+		cmd := PatchPublicDashboardCommand{
+			Columns: []string{"is_enabled"},
+			PublicDashboard: PublicDashboard{
+				Uid:       pubdash.Uid,
+				IsEnabled: false,
+				UpdatedBy: pubdash.UpdatedBy,
+				UpdatedAt: now,
+			},
+		}
+		if _, err := s.store.Patch(ctx, cmd); err != nil {
+			s.log.Error("Failed to disable public dashboard in forbidden folder", "publicDashboardUid", pubdash.Uid, "error", err)
+			continue
+		}
+		s.service.PublicDashboardChanged(pubdash.Uid)
+
+		s.log.Info("Disabled public dashboard in forbidden folder", "publicDashboardUid", pubdash.Uid, "dashboardUid", pubdash.DashboardUid, "orgId", pubdash.OrgId, "folderUid", dash.FolderUID)
+	}
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/folder"
+	"github.com/grafana/grafana/pkg/services/folder/foldertest"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestIsFolderShareable(t *testing.T) {
+	testCases := []struct {
+		name      string
+		allowed   []string
+		denied    []string
+		folderUID string
+		parents   []string
+		expected  bool
+	}{
+		{name: "without restrictions", folderUID: "private", expected: true},
+		{name: "folder in the allowlist", allowed: []string{"public"}, folderUID: "public", expected: true},
+		{name: "folder not in the allowlist", allowed: []string{"public"}, folderUID: "private", expected: false},
+		{name: "subfolder of a folder in the allowlist", allowed: []string{"public"}, folderUID: "sales", parents: []string{"public"}, expected: true},
+		{name: "root with the general folder in the allowlist", allowed: []string{"general"}, folderUID: "", expected: true},
+		{name: "root not in the allowlist", allowed: []string{"public"}, folderUID: "", expected: false},
+		{name: "folder in the denylist", denied: []string{"private"}, folderUID: "private", expected: false},
+		{name: "folder not in the denylist", denied: []string{"private"}, folderUID: "public", expected: true},
+		{name: "subfolder of a folder in the denylist", denied: []string{"private"}, folderUID: "hr", parents: []string{"private"}, expected: false},
+		{name: "denylist takes precedence", allowed: []string{"public"}, denied: []string{"drafts"}, folderUID: "drafts", parents: []string{"public"}, expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := setting.NewCfg()
+			cfg.PublicDashboardsAllowedFolderUIDs = tc.allowed
+			cfg.PublicDashboardsDeniedFolderUIDs = tc.denied
+
+			folderService := foldertest.NewFakeService()
+			for _, uid := range tc.parents {
+				folderService.ExpectedFolders = append(folderService.ExpectedFolders, &folder.Folder{UID: uid})
+			}
+
+			shareable, err := isFolderShareable(context.Background(), cfg, folderService, 1, tc.folderUID)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, shareable)
+		})
+	}
+}
+
+func TestCreateInForbiddenFolder(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.PublicDashboardsDeniedFolderUIDs = []string{"private"}
+
+	dashboardService := &dashboards.FakeDashboardService{}
+	dashboardService.On("GetDashboard", mock.Anything, mock.Anything).Return(&dashboards.Dashboard{UID: "dash1", OrgID: 1, FolderUID: "private"}, nil)
+	wrapper := NewFakePublicDashboardServiceWrapper(t)
+	wrapper.On("FindByDashboardUid", mock.Anything, int64(1), "dash1").Return(nil, nil)
+	store := &FakePublicDashboardStore{}
+
+	service := &PublicDashboardServiceImpl{
+		log:              log.NewNopLogger(),
+		cfg:              cfg,
+		store:            store,
+		serviceWrapper:   wrapper,
+		dashboardService: dashboardService,
+		folderService:    foldertest.NewFakeService(),
+	}
+
+	isEnabled := true
+	_, err := service.Create(context.Background(), &user.SignedInUser{OrgID: 1, UserID: 1}, &SavePublicDashboardDTO{
+		DashboardUid:    "dash1",
+		OrgID:           1,
+		PublicDashboard: &PublicDashboardDTO{IsEnabled: &isEnabled},
+	})
+	require.ErrorIs(t, err, ErrFolderNotShareable)
+	store.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestFolderRestrictionServiceDisableInForbiddenFolders(t *testing.T) {
+	setup := func(t *testing.T, cfg *setting.Cfg, pubdashes []*PublicDashboard) (*FolderRestrictionService, *FakePublicDashboardStore, *FakePublicDashboardService) {
+		store := &FakePublicDashboardStore{}
+		store.On("FindEnabled", mock.Anything).Return(pubdashes, nil)
+
+		dashboardService := &dashboards.FakeDashboardService{}
+		dashboardService.On("GetDashboard", mock.Anything, mock.Anything).Return(func(ctx context.Context, query *dashboards.GetDashboardQuery) (*dashboards.Dashboard, error) {
+			folders := map[string]string{"moved": "private", "kept": "public"}
+			return &dashboards.Dashboard{UID: query.UID, OrgID: query.OrgID, FolderUID: folders[query.UID]}, nil
+		}).Maybe()
+
+		pdService := NewFakePublicDashboardService(t)
+
+		return &FolderRestrictionService{
+			log:              log.NewNopLogger(),
+			cfg:              cfg,
+			store:            store,
+			service:          pdService,
+			dashboardService: dashboardService,
+			folderService:    foldertest.NewFakeService(),
+		}, store, pdService
+	}
+
+	t.Run("disables public dashboards whose dashboard moved to a forbidden folder", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.PublicDashboardsAllowedFolderUIDs = []string{"public"}
+
+		moved := &PublicDashboard{Uid: "pd1", OrgId: 1, DashboardUid: "moved", IsEnabled: true, UpdatedBy: 7}
+		kept := &PublicDashboard{Uid: "pd2", OrgId: 1, DashboardUid: "kept", IsEnabled: true}
+		service, store, pdService := setup(t, cfg, []*PublicDashboard{moved, kept})
+		store.On("Patch", mock.Anything, mock.Anything).Return(int64(1), nil)
+		pdService.On("PublicDashboardChanged", "pd1").Return()
+
+		service.disableInForbiddenFolders(context.Background())
+
+		store.AssertNumberOfCalls(t, "Patch", 1)
+		cmd := store.Calls[1].Arguments.Get(1).(PatchPublicDashboardCommand)
+		assert.Equal(t, []string{"is_enabled"}, cmd.Columns)
+		assert.Equal(t, "pd1", cmd.PublicDashboard.Uid)
+		assert.False(t, cmd.PublicDashboard.IsEnabled)
+		assert.Equal(t, int64(7), cmd.PublicDashboard.UpdatedBy)
+	})
+
+	t.Run("does nothing without restrictions", func(t *testing.T) {
+		service, store, _ := setup(t, setting.NewCfg(), nil)
+
+		service.disableInForbiddenFolders(context.Background())
+
+		store.AssertNotCalled(t, "FindEnabled", mock.Anything)
+		require.True(t, service.IsDisabled())
+	})
+}
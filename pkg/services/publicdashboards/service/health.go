@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+// healthCheckConcurrency is the number of datasources of a public dashboard checked at the same time
+const healthCheckConcurrency = 5
+
+// CheckHealth runs the health check of every datasource queried by the panels of a public dashboard, with the service
+// identity the queries of viewers run with and with the public datasources they are swapped for. Panels with queries
+// of datasources that aren't healthy will fail publicly
+func (pd *PublicDashboardServiceImpl) CheckHealth(ctx context.Context, u *user.SignedInUser, uid string, dashboardUid string) (*models.PublicDashboardHealth, error) {
+	ctx, span := tracer.Start(ctx, "publicdashboards.CheckHealth")
+	defer span.End()
+
+	pubdash, err := pd.store.Find(ctx, uid)
+	if err != nil {
+		return nil, models.ErrInternalServerError.Errorf("CheckHealth: failed to find public dashboard by uid: %s: %w", uid, err)
+	}
+	if pubdash == nil || pubdash.OrgId != u.OrgID {
+		return nil, models.ErrPublicDashboardNotFound.Errorf("CheckHealth: public dashboard not found by uid: %s", uid)
+	}
+
+	// validate the public dashboard belongs to the dashboard
+	if pubdash.DashboardUid != dashboardUid {
+		return nil, models.ErrInvalidUid.Errorf("CheckHealth: the public dashboard does not belong to the dashboard")
+	}
+
+	dashboard, err := pd.FindDashboard(ctx, pubdash.OrgId, pubdash.DashboardUid)
+	if err != nil {
+		return nil, err
+	}
+	if dashboard == nil {
+		return nil, models.ErrPublicDashboardNotFound.Errorf("CheckHealth: dashboard not found by uid: %s", dashboardUid)
+	}
+
+	queriesByPanel := groupQueriesByPanelId(dashboard.Data, pubdash.HiddenPanelIds)
+	if dashboard.Data.Get("elements").Interface() != nil {
+		queriesByPanel = groupQueriesByPanelIdV2(dashboard.Data, pubdash.HiddenPanelIds)
+	}
+
+	// the panels querying each datasource
+	panelsByDatasource := map[string][]int64{}
+	for panelId, queries := range queriesByPanel {
+		for _, query := range queries {
+			dsUid := getDataSourceUidFromJson(query)
+			// expressions and the built in datasources don't have a health check
+			if dsUid == "" || expr.IsDataSource(dsUid) || reservedDatasourceUids[dsUid] || slices.Contains(panelsByDatasource[dsUid], panelId) {
+				continue
+			}
+			panelsByDatasource[dsUid] = append(panelsByDatasource[dsUid], panelId)
+		}
+	}
+
+	var mu sync.Mutex
+	results := make([]models.DatasourceHealth, 0, len(panelsByDatasource))
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(healthCheckConcurrency)
+	for dsUid, panelIds := range panelsByDatasource {
+		g.Go(func() error {
+			result := pd.checkDatasourceHealth(gctx, pubdash.OrgId, dsUid)
+			slices.Sort(panelIds)
+			result.PanelIds = panelIds
+
+			mu.Lock()
+			defer mu.Unlock()
+			results = append(results, result)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	slices.SortFunc(results, func(a, b models.DatasourceHealth) int {
+		return strings.Compare(a.Uid, b.Uid)
+	})
+
+	health := &models.PublicDashboardHealth{Healthy: true, Datasources: results, FailingPanelIds: []int64{}}
+	for _, result := range results {
+		if result.Status == backend.HealthStatusOk.String() {
+			continue
+		}
+		health.Healthy = false
+		for _, panelId := range result.PanelIds {
+			if !slices.Contains(health.FailingPanelIds, panelId) {
+				health.FailingPanelIds = append(health.FailingPanelIds, panelId)
+			}
+		}
+	}
+	slices.Sort(health.FailingPanelIds)
+
+	return health, nil
+}
+
+// checkDatasourceHealth runs the health check of the datasource, or of its public datasource when it has one
+func (pd *PublicDashboardServiceImpl) checkDatasourceHealth(ctx context.Context, orgID int64, uid string) models.DatasourceHealth {
+	result := models.DatasourceHealth{Uid: uid, Status: backend.HealthStatusUnknown.String()}
+
+	// datasources set by a variable depend on the values picked by viewers
+	if strings.Contains(uid, "$") {
+		result.Message = "The datasource is set by a variable"
+		return result
+	}
+
+	ds, err := pd.datasourceService.GetDataSource(ctx, &datasources.GetDataSourceQuery{UID: uid, OrgID: orgID})
+	if err != nil {
+		result.Status = backend.HealthStatusError.String()
+		result.Message = "Failed to get the datasource"
+		if errors.Is(err, datasources.ErrDataSourceNotFound) {
+			result.Message = "The datasource was not found"
+		}
+		return result
+	}
+
+	public, err := pd.findPublicDatasource(ctx, orgID, uid)
+	if err != nil {
+		result.Status = backend.HealthStatusError.String()
+		result.Message = err.Error()
+		return result
+	}
+	if public != nil {
+		ds = public
+	}
+	result.Name = ds.Name
+	result.Type = ds.Type
+
+	if pd.healthChecker == nil || pd.pluginContextProvider == nil {
+		return result
+	}
+
+	// We don't have a signed in user for public dashboards. We are using Grafana's Identity to check the datasource.
+	svcCtx, svcIdent := identity.WithServiceIdentity(ctx, orgID)
+	pCtx, err := pd.pluginContextProvider.GetWithDataSource(svcCtx, ds.Type, svcIdent, ds)
+	if err != nil {
+		result.Status = backend.HealthStatusError.String()
+		result.Message = "Failed to get the plugin of the datasource"
+		return result
+	}
+
+	res, err := pd.healthChecker.CheckHealth(svcCtx, &backend.CheckHealthRequest{PluginContext: pCtx})
+	if err != nil {
+		result.Status = backend.HealthStatusError.String()
+		result.Message = err.Error()
+		return result
+	}
+
+	result.Status = res.Status.String()
+	result.Message = res.Message
+	return result
+}
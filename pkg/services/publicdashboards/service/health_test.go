@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	fakeDatasources "github.com/grafana/grafana/pkg/services/datasources/fakes"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+func TestCheckHealth(t *testing.T) {
+	dashboard := &dashboards.Dashboard{UID: "dash1", OrgID: 1, Data: simplejson.NewFromAny(map[string]any{
+		"panels": []any{
+			map[string]any{
+				"id":         1,
+				"datasource": map[string]any{"uid": "ds1"},
+				"targets":    []any{map[string]any{"refId": "A", "datasource": map[string]any{"uid": "ds1"}}},
+			},
+			map[string]any{
+				"id":         2,
+				"datasource": map[string]any{"uid": "ds2"},
+				"targets": []any{
+					map[string]any{"refId": "A", "datasource": map[string]any{"uid": "ds2"}},
+					map[string]any{"refId": "B", "datasource": map[string]any{"uid": "__expr__", "type": "__expr__"}},
+				},
+			},
+			map[string]any{
+				"id":         3,
+				"datasource": map[string]any{"uid": "${ds}"},
+				"targets":    []any{map[string]any{"refId": "A", "datasource": map[string]any{"uid": "${ds}"}}},
+			},
+		},
+	})}
+	pubdash := &PublicDashboard{Uid: "pubdash1", DashboardUid: dashboard.UID, OrgId: dashboard.OrgID}
+
+	var checked []string
+	newService := func(t *testing.T) *PublicDashboardServiceImpl {
+		store := NewFakePublicDashboardStore(t)
+		store.On("Find", mock.Anything, pubdash.Uid).Return(pubdash, nil)
+		dashboardService := &dashboards.FakeDashboardService{}
+		dashboardService.On("GetDashboard", mock.Anything, mock.Anything).Return(dashboard, nil).Maybe()
+
+		return &PublicDashboardServiceImpl{
+			store:            store,
+			dashboardService: dashboardService,
+			datasourceService: &fakeDatasources.FakeDataSourceService{DataSources: []*datasources.DataSource{
+				{UID: "ds1", Name: "Prometheus", Type: "prometheus", OrgID: 1},
+				{UID: "ds2", Name: "Postgres", Type: "grafana-postgresql-datasource", OrgID: 1},
+			}},
+			pluginContextProvider: fakePluginContextProvider{},
+			healthChecker: backend.CheckHealthHandlerFunc(func(_ context.Context, req *backend.CheckHealthRequest) (*backend.CheckHealthResult, error) {
+				checked = append(checked, req.PluginContext.PluginID)
+				if req.PluginContext.PluginID == "grafana-postgresql-datasource" {
+					return nil, errors.New("connection refused")
+				}
+				return &backend.CheckHealthResult{Status: backend.HealthStatusOk, Message: "Data source is working"}, nil
+			}),
+		}
+	}
+
+	t.Run("Reports the panels of the datasources that aren't healthy", func(t *testing.T) {
+		service := newService(t)
+		health, err := service.CheckHealth(context.Background(), &user.SignedInUser{OrgID: 1}, pubdash.Uid, dashboard.UID)
+		require.NoError(t, err)
+
+		assert.False(t, health.Healthy)
+		assert.Equal(t, []int64{2, 3}, health.FailingPanelIds)
+		assert.Equal(t, []DatasourceHealth{
+			{Uid: "${ds}", Status: "UNKNOWN", Message: "The datasource is set by a variable", PanelIds: []int64{3}},
+			{Uid: "ds1", Name: "Prometheus", Type: "prometheus", Status: "OK", Message: "Data source is working", PanelIds: []int64{1}},
+			{Uid: "ds2", Name: "Postgres", Type: "grafana-postgresql-datasource", Status: "ERROR", Message: "connection refused", PanelIds: []int64{2}},
+		}, health.Datasources)
+		assert.ElementsMatch(t, []string{"prometheus", "grafana-postgresql-datasource"}, checked)
+	})
+
+	t.Run("Fails for public dashboards of other orgs", func(t *testing.T) {
+		service := newService(t)
+		_, err := service.CheckHealth(context.Background(), &user.SignedInUser{OrgID: 2}, pubdash.Uid, dashboard.UID)
+		assert.ErrorIs(t, err, ErrPublicDashboardNotFound)
+	})
+
+	t.Run("Fails when the public dashboard belongs to another dashboard", func(t *testing.T) {
+		service := newService(t)
+		_, err := service.CheckHealth(context.Background(), &user.SignedInUser{OrgID: 1}, pubdash.Uid, "otherdash")
+		assert.ErrorIs(t, err, ErrInvalidUid)
+	})
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// hiddenVariable is the hide value of variables the dashboard doesn't show. Anonymous viewers can neither query nor
+// change them, and they are removed from the dashboard sent to viewers
+const hiddenVariable = 2
+
+// v2HiddenVariable is the hide value of hidden variables of v2 dashboards
+const v2HiddenVariable = "hideVariable"
+
+// hiddenVariables returns the names of the hidden variables of the dashboard
+func hiddenVariables(dashboard *simplejson.Json) map[string]bool {
+	hidden := map[string]bool{}
+	for _, v := range dashboardVariableList(dashboard) {
+		variable := simplejson.NewFromAny(v)
+		if variable.Get("hide").MustInt() == hiddenVariable {
+			hidden[variable.Get("name").MustString()] = true
+		}
+	}
+	return hidden
+}
+
+// visibleValues drops the values sent for hidden variables, so these variables keep their saved value
+func visibleValues[T any](dashboard *simplejson.Json, values map[string]T) map[string]T {
+	hidden := hiddenVariables(dashboard)
+	if len(hidden) == 0 {
+		return values
+	}
+
+	visible := make(map[string]T, len(values))
+	for name, value := range values {
+		if !hidden[name] {
+			visible[name] = value
+		}
+	}
+	return visible
+}
+
+// stripHiddenVariables removes the hidden variables from the dashboard sent to viewers
+func stripHiddenVariables(dashboard *simplejson.Json) {
+	path := []string{"templating", "list"}
+	isHidden := func(v *simplejson.Json) bool { return v.Get("hide").MustInt() == hiddenVariable }
+	if dashboard.Get("elements").Interface() != nil {
+		path = []string{"variables"}
+		isHidden = func(v *simplejson.Json) bool { return v.GetPath("spec", "hide").MustString() == v2HiddenVariable }
+	}
+
+	variables := dashboard.GetPath(path...)
+	if variables.Interface() == nil {
+		return
+	}
+	visible := []interface{}{}
+	for _, v := range variables.MustArray() {
+		if !isHidden(simplejson.NewFromAny(v)) {
+			visible = append(visible, v)
+		}
+	}
+	dashboard.SetPath(path, visible)
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+func TestVisibleValues(t *testing.T) {
+	dashboard, err := simplejson.NewJson([]byte(`{
+		"templating": {
+			"list": [
+				{"name": "env", "type": "custom", "hide": 0},
+				{"name": "label", "type": "custom", "hide": 1},
+				{"name": "tenant", "type": "constant", "hide": 2}
+			]
+		}
+	}`))
+	require.NoError(t, err)
+
+	variables := map[string]interface{}{"env": "stage", "label": "a", "tenant": "other"}
+	assert.Equal(t, map[string]interface{}{"env": "stage", "label": "a"}, visibleValues(dashboard, variables))
+	assert.Equal(t, map[string]bool{"tenant": true}, hiddenVariables(dashboard))
+}
+
+func TestStripHiddenVariables(t *testing.T) {
+	t.Run("removes hidden variables of v1 dashboards", func(t *testing.T) {
+		dashboard, err := simplejson.NewJson([]byte(`{
+			"templating": {
+				"list": [
+					{"name": "env", "type": "custom"},
+					{"name": "tenant", "type": "constant", "hide": 2}
+				]
+			}
+		}`))
+		require.NoError(t, err)
+
+		stripHiddenVariables(dashboard)
+		assert.Equal(t, []string{"env"}, dashboardVariableNames(dashboard))
+	})
+
+	t.Run("removes hidden variables of v2 dashboards", func(t *testing.T) {
+		dashboard, err := simplejson.NewJson([]byte(`{
+			"elements": {},
+			"variables": [
+				{"kind": "CustomVariable", "spec": {"name": "env", "hide": "dontHide"}},
+				{"kind": "ConstantVariable", "spec": {"name": "tenant", "hide": "hideVariable"}}
+			]
+		}`))
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]bool{"tenant": true}, hiddenVariables(dashboard))
+
+		stripHiddenVariables(dashboard)
+		assert.Equal(t, []string{"env"}, dashboardVariableNames(dashboard))
+	})
+
+	t.Run("keeps dashboards without variables", func(t *testing.T) {
+		dashboard := simplejson.New()
+		stripHiddenVariables(dashboard)
+		assert.Nil(t, dashboard.Get("templating").Interface())
+	})
+}
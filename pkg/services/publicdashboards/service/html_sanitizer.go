@@ -0,0 +1,191 @@
+package service
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// allowedHTMLTags maps the tags a public viewer may receive to the attributes kept on them
+var allowedHTMLTags = map[atom.Atom][]string{
+	atom.A:          {"href", "title"},
+	atom.B:          nil,
+	atom.Blockquote: nil,
+	atom.Br:         nil,
+	atom.Code:       nil,
+	atom.Del:        nil,
+	atom.Div:        nil,
+	atom.Em:         nil,
+	atom.H1:         nil,
+	atom.H2:         nil,
+	atom.H3:         nil,
+	atom.H4:         nil,
+	atom.H5:         nil,
+	atom.H6:         nil,
+	atom.Hr:         nil,
+	atom.I:          nil,
+	atom.Img:        {"src", "alt", "title", "width", "height"},
+	atom.Li:         nil,
+	atom.Ol:         nil,
+	atom.P:          nil,
+	atom.Pre:        nil,
+	atom.S:          nil,
+	atom.Span:       nil,
+	atom.Strong:     nil,
+	atom.Sub:        nil,
+	atom.Sup:        nil,
+	atom.Table:      {"align"},
+	atom.Tbody:      nil,
+	atom.Td:         {"align", "colspan", "rowspan"},
+	atom.Th:         {"align", "colspan", "rowspan"},
+	atom.Thead:      nil,
+	atom.Tr:         nil,
+	atom.U:          nil,
+	atom.Ul:         nil,
+}
+
+// droppedHTMLTags are removed together with everything they contain
+var droppedHTMLTags = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Iframe:   true,
+	atom.Object:   true,
+	atom.Embed:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Form:     true,
+	atom.Textarea: true,
+	atom.Select:   true,
+}
+
+// sanitizeHTML reduces the given html to an allowlist of formatting tags and attributes.
+// Links are only kept when they point to an absolute external url. Relative links and links to internalHost
+// are unwrapped to their text so the public view does not leak urls of the Grafana instance.
+func sanitizeHTML(input string, internalHost string) string {
+	var sb strings.Builder
+	tokenizer := html.NewTokenizer(strings.NewReader(input))
+
+	// keeps track of whether each open anchor was written out, so closing tags can be matched
+	var anchors []bool
+	skipDepth := 0
+
+	for {
+		tt := tokenizer.Next()
+		if tt == html.ErrorToken {
+			// io.EOF or a malformed document, either way everything sanitized so far is returned
+			return sb.String()
+		}
+
+		token := tokenizer.Token()
+
+		if skipDepth > 0 {
+			switch {
+			case tt == html.StartTagToken && droppedHTMLTags[token.DataAtom]:
+				skipDepth++
+			case tt == html.EndTagToken && droppedHTMLTags[token.DataAtom]:
+				skipDepth--
+			}
+			continue
+		}
+
+		switch tt {
+		case html.TextToken:
+			sb.WriteString(html.EscapeString(token.Data))
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if droppedHTMLTags[token.DataAtom] {
+				if tt == html.StartTagToken {
+					skipDepth++
+				}
+				continue
+			}
+
+			allowedAttrs, ok := allowedHTMLTags[token.DataAtom]
+			if !ok {
+				continue
+			}
+
+			attrs := sanitizeAttributes(token, allowedAttrs, internalHost)
+			if token.DataAtom == atom.A {
+				if !hasAttribute(attrs, "href") {
+					anchors = append(anchors, false)
+					continue
+				}
+				anchors = append(anchors, true)
+				attrs = append(attrs, html.Attribute{Key: "target", Val: "_blank"}, html.Attribute{Key: "rel", Val: "noopener noreferrer"})
+			}
+			if token.DataAtom == atom.Img && !hasAttribute(attrs, "src") {
+				continue
+			}
+
+			token.Attr = attrs
+			sb.WriteString(token.String())
+		case html.EndTagToken:
+			if _, ok := allowedHTMLTags[token.DataAtom]; !ok {
+				continue
+			}
+
+			if token.DataAtom == atom.A {
+				if len(anchors) == 0 {
+					continue
+				}
+				written := anchors[len(anchors)-1]
+				anchors = anchors[:len(anchors)-1]
+				if !written {
+					continue
+				}
+			}
+
+			sb.WriteString(token.String())
+		}
+	}
+}
+
+// sanitizeAttributes keeps the allowed attributes of a token, dropping urls that are not safe to expose
+func sanitizeAttributes(token html.Token, allowed []string, internalHost string) []html.Attribute {
+	attrs := make([]html.Attribute, 0, len(token.Attr))
+	for _, attr := range token.Attr {
+		if attr.Namespace != "" || !slices.Contains(allowed, attr.Key) {
+			continue
+		}
+
+		switch attr.Key {
+		case "href":
+			if !isExternalURL(attr.Val, internalHost, "http", "https", "mailto") {
+				continue
+			}
+		case "src":
+			if !isExternalURL(attr.Val, internalHost, "http", "https") {
+				continue
+			}
+		}
+
+		attrs = append(attrs, attr)
+	}
+	return attrs
+}
+
+// isExternalURL reports whether the value is an absolute url outside internalHost using one of the given schemes
+func isExternalURL(value string, internalHost string, schemes ...string) bool {
+	u, err := url.Parse(strings.TrimSpace(value))
+	if err != nil || !u.IsAbs() {
+		return false
+	}
+
+	if u.Scheme != "mailto" && (u.Host == "" || strings.EqualFold(u.Host, internalHost)) {
+		return false
+	}
+
+	return slices.Contains(schemes, strings.ToLower(u.Scheme))
+}
+
+func hasAttribute(attrs []html.Attribute, key string) bool {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
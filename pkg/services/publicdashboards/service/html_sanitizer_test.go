@@ -0,0 +1,87 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeHTML(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "keeps formatting tags",
+			input:    "<h1>Title</h1><p><strong>bold</strong> and <em>italic</em></p>",
+			expected: "<h1>Title</h1><p><strong>bold</strong> and <em>italic</em></p>",
+		},
+		{
+			name:     "drops script tags and their content",
+			input:    "<p>before</p><script>alert('x')</script><p>after</p>",
+			expected: "<p>before</p><p>after</p>",
+		},
+		{
+			name:     "drops nested dropped tags",
+			input:    "<object><object>a</object>b</object><p>text</p>",
+			expected: "<p>text</p>",
+		},
+		{
+			name:     "unwraps unknown tags but keeps their text",
+			input:    "<custom-element>text</custom-element>",
+			expected: "text",
+		},
+		{
+			name:     "removes event handlers and styles",
+			input:    `<p onclick="alert(1)" style="color:red" class="x">text</p>`,
+			expected: "<p>text</p>",
+		},
+		{
+			name:     "keeps external links and opens them in a new tab",
+			input:    `<a href="https://grafana.com/docs" title="docs">docs</a>`,
+			expected: `<a href="https://grafana.com/docs" title="docs" target="_blank" rel="noopener noreferrer">docs</a>`,
+		},
+		{
+			name:     "unwraps relative links",
+			input:    `<p><a href="/d/internal-dashboard">internal</a></p>`,
+			expected: "<p>internal</p>",
+		},
+		{
+			name:     "unwraps links to the grafana instance",
+			input:    `<a href="https://grafana.internal:3000/d/abc">internal</a>`,
+			expected: "internal",
+		},
+		{
+			name:     "unwraps javascript links",
+			input:    `<a href="javascript:alert(1)">click</a>`,
+			expected: "click",
+		},
+		{
+			name:     "keeps mailto links",
+			input:    `<a href="mailto:ops@example.com">mail</a>`,
+			expected: `<a href="mailto:ops@example.com" target="_blank" rel="noopener noreferrer">mail</a>`,
+		},
+		{
+			name:     "unwraps nested anchors independently",
+			input:    `<a href="/internal">a<a href="https://example.com">b</a>c</a>`,
+			expected: `a<a href="https://example.com" target="_blank" rel="noopener noreferrer">b</a>c`,
+		},
+		{
+			name:     "drops images without an external source",
+			input:    `<img src="data:image/png;base64,AAAA"><img src="https://example.com/a.png" alt="a" onerror="x">`,
+			expected: `<img src="https://example.com/a.png" alt="a">`,
+		},
+		{
+			name:     "escapes text",
+			input:    "a &lt;b&gt; c",
+			expected: "a &lt;b&gt; c",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, sanitizeHTML(tc.input, "grafana.internal:3000"))
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	// lastAccessedAtResolution is how stale the last access of a public dashboard can get before it's updated again
+	lastAccessedAtResolution = time.Hour
+	inactivityCheckInterval  = time.Hour
+	inactivityLockName       = "disable inactive public dashboards"
+	inactivityEmailTemplate  = "public_dashboard_disabled"
+)
+
+type serverLocker interface {
+	LockAndExecute(ctx context.Context, actionName string, maxInterval time.Duration, fn func(ctx context.Context)) error
+}
+
+// InactivityService disables public dashboards that haven't been accessed for the number of days configured in
+// [public_dashboards] and notifies their creator by email
+type InactivityService struct {
+	log              log.Logger
+	cfg              *setting.Cfg
+	store            publicdashboards.Store
+	service          publicdashboards.Service
+	dashboardService dashboards.DashboardService
+	userService      user.Service
+	emailSender      notifications.EmailSender
+	serverLock       serverLocker
+}
+
+func ProvideInactivityService(
+	cfg *setting.Cfg,
+	store publicdashboards.Store,
+	service publicdashboards.Service,
+	dashboardService dashboards.DashboardService,
+	userService user.Service,
+	emailSender notifications.EmailSender,
+	serverLock *serverlock.ServerLockService,
+) *InactivityService {
+	return &InactivityService{
+		log:              log.New("publicdashboards.inactivity"),
+		cfg:              cfg,
+		store:            store,
+		service:          service,
+		dashboardService: dashboardService,
+		userService:      userService,
+		emailSender:      emailSender,
+		serverLock:       serverLock,
+	}
+}
+
+// IsDisabled returns true when neither a global nor an org policy disables inactive public dashboards
+func (s *InactivityService) IsDisabled() bool {
+	if !s.cfg.PublicDashboardsEnabled {
+		return true
+	}
+	_, enabled := s.shortestInactivityPeriod()
+	return !enabled
+}
+
+func (s *InactivityService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(inactivityCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.serverLock.LockAndExecute(ctx, inactivityLockName, inactivityCheckInterval/2, s.disableInactive); err != nil {
+			s.log.Error("Failed to disable inactive public dashboards", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *InactivityService) disableInactive(ctx context.Context) {
+	shortest, enabled := s.shortestInactivityPeriod()
+	if !enabled {
+		return
+	}
+
+	now := time.Now()
+	pubdashes, err := s.store.FindEnabledInactiveSince(ctx, now.Add(-shortest))
+	if err != nil {
+		s.log.Error("Failed to find inactive public dashboards", "error", err)
+		return
+	}
+
+	for _, pubdash := range pubdashes {
+		days := s.inactiveAfterDays(pubdash.OrgId)
+		if days <= 0 || lastActivity(pubdash).After(now.AddDate(0, 0, -days)) {
+			continue
+		}
+
+		// only is_enabled is written, so concurrent updates of the config aren't overwritten
+		cmd := PatchPublicDashboardCommand{
+			Columns: []string{"is_enabled"},
+			PublicDashboard: PublicDashboard{
+				Uid:       pubdash.Uid,
+				IsEnabled: false,
+				UpdatedBy: pubdash.UpdatedBy,
+				UpdatedAt: now,
+			},
+		}
+		if _, err := s.store.Patch(ctx, cmd); err != nil {
+			s.log.Error("Failed to disable inactive public dashboard", "publicDashboardUid", pubdash.Uid, "error", err)
+			continue
+		}
+		s.service.PublicDashboardChanged(pubdash.Uid)
+
+		s.log.Info("Disabled inactive public dashboard", "publicDashboardUid", pubdash.Uid, "dashboardUid", pubdash.DashboardUid, "orgId", pubdash.OrgId, "inactiveDays", days)
+		s.notifyOwner(ctx, pubdash, days)
+	}
+}
+
+// notifyOwner sends an email to the creator of the public dashboard. Failures are only logged, the public dashboard
+// stays disabled
+func (s *InactivityService) notifyOwner(ctx context.Context, pubdash *PublicDashboard, days int) {
+	owner, err := s.userService.GetByID(ctx, &user.GetUserByIDQuery{ID: pubdash.CreatedBy})
+	if err != nil || owner.Email == "" {
+		s.log.Warn("Can't notify the creator of a disabled public dashboard", "publicDashboardUid", pubdash.Uid, "userId", pubdash.CreatedBy, "error", err)
+		return
+	}
+
+	dash, err := identity.WithServiceIdentityFn(ctx, pubdash.OrgId, func(ctx context.Context) (*dashboards.Dashboard, error) {
+		return s.dashboardService.GetDashboard(ctx, &dashboards.GetDashboardQuery{UID: pubdash.DashboardUid, OrgID: pubdash.OrgId})
+	})
+	if err != nil {
+		s.log.Warn("Can't find the dashboard of a disabled public dashboard", "publicDashboardUid", pubdash.Uid, "error", err)
+		return
+	}
+
+	err = s.emailSender.SendEmailCommandHandler(ctx, &notifications.SendEmailCommand{
+		To:       []string{owner.Email},
+		Template: inactivityEmailTemplate,
+		Data: map[string]any{
+			"Name":           owner.NameOrFallback(),
+			"DashboardTitle": dash.Title,
+			"DashboardUrl":   dashboards.GetFullDashboardURL(dash.UID, dash.Slug),
+			"InactiveDays":   days,
+		},
+	})
+	if err != nil {
+		s.log.Warn("Failed to notify the creator of a disabled public dashboard", "publicDashboardUid", pubdash.Uid, "error", err)
+	}
+}
+
+// inactiveAfterDays returns the number of days after which public dashboards of the org are disabled, 0 if never
+func (s *InactivityService) inactiveAfterDays(orgID int64) int {
+	if days, ok := s.cfg.PublicDashboardsDisableInactiveAfterDaysByOrg[orgID]; ok {
+		return days
+	}
+	return s.cfg.PublicDashboardsDisableInactiveAfterDays
+}
+
+func (s *InactivityService) shortestInactivityPeriod() (time.Duration, bool) {
+	shortest := s.cfg.PublicDashboardsDisableInactiveAfterDays
+	for _, days := range s.cfg.PublicDashboardsDisableInactiveAfterDaysByOrg {
+		if days > 0 && (shortest <= 0 || days < shortest) {
+			shortest = days
+		}
+	}
+
+	if shortest <= 0 {
+		return 0, false
+	}
+	return time.Duration(shortest) * 24 * time.Hour, true
+}
+
+// lastActivity is the latest time the public dashboard was created, updated or accessed
+func lastActivity(pubdash *PublicDashboard) time.Time {
+	last := pubdash.CreatedAt
+	if pubdash.UpdatedAt.After(last) {
+		last = pubdash.UpdatedAt
+	}
+	if pubdash.LastAccessedAt.After(last) {
+		last = pubdash.LastAccessedAt
+	}
+	return last
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/notifications"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/user/usertest"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestInactivityServiceDisableInactive(t *testing.T) {
+	now := time.Now()
+	daysAgo := func(days int) time.Time { return now.AddDate(0, 0, -days) }
+
+	setup := func(t *testing.T, cfg *setting.Cfg, pubdashes []*PublicDashboard) (*InactivityService, *FakePublicDashboardStore, *FakePublicDashboardService, *notifications.NotificationServiceMock) {
+		store := &FakePublicDashboardStore{}
+		store.On("FindEnabledInactiveSince", mock.Anything, mock.Anything).Return(pubdashes, nil)
+
+		dashboardService := &dashboards.FakeDashboardService{}
+		dashboardService.On("GetDashboard", mock.Anything, mock.Anything).Return(&dashboards.Dashboard{UID: "dash", Slug: "sales", Title: "Sales"}, nil).Maybe()
+
+		userService := usertest.NewUserServiceFake()
+		userService.ExpectedUser = &user.User{ID: 7, Email: "owner@example.com", Name: "Owner"}
+
+		emailSender := notifications.MockNotificationService()
+
+		pdService := NewFakePublicDashboardService(t)
+
+		return &InactivityService{
+			log:              log.NewNopLogger(),
+			cfg:              cfg,
+			store:            store,
+			service:          pdService,
+			dashboardService: dashboardService,
+			userService:      userService,
+			emailSender:      emailSender,
+		}, store, pdService, emailSender
+	}
+
+	t.Run("disables public dashboards inactive for longer than the global policy and notifies the creator", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.PublicDashboardsDisableInactiveAfterDays = 30
+
+		inactive := &PublicDashboard{Uid: "inactive", OrgId: 1, DashboardUid: "dash", IsEnabled: true, CreatedBy: 7,
+			CreatedAt: daysAgo(90), UpdatedAt: daysAgo(60), LastAccessedAt: daysAgo(31)}
+		recent := &PublicDashboard{Uid: "recent", OrgId: 1, DashboardUid: "dash", IsEnabled: true, CreatedBy: 7,
+			CreatedAt: daysAgo(90), UpdatedAt: daysAgo(10)}
+
+		service, store, pdService, emailSender := setup(t, cfg, []*PublicDashboard{inactive, recent})
+		store.On("Patch", mock.Anything, mock.Anything).Return(int64(1), nil)
+		pdService.On("PublicDashboardChanged", "inactive").Return()
+
+		service.disableInactive(context.Background())
+
+		store.AssertNumberOfCalls(t, "Patch", 1)
+		cmd := store.Calls[1].Arguments.Get(1).(PatchPublicDashboardCommand)
+		assert.Equal(t, []string{"is_enabled"}, cmd.Columns)
+		assert.Equal(t, "inactive", cmd.PublicDashboard.Uid)
+		assert.False(t, cmd.PublicDashboard.IsEnabled)
+
+		assert.Equal(t, []string{"owner@example.com"}, emailSender.Email.To)
+		assert.Equal(t, "public_dashboard_disabled", emailSender.Email.Template)
+		assert.Equal(t, "Sales", emailSender.Email.Data["DashboardTitle"])
+		assert.Equal(t, 30, emailSender.Email.Data["InactiveDays"])
+	})
+
+	t.Run("applies org overrides", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.PublicDashboardsDisableInactiveAfterDays = 30
+		cfg.PublicDashboardsDisableInactiveAfterDaysByOrg = map[int64]int{2: 0, 3: 7}
+
+		exempt := &PublicDashboard{Uid: "exempt", OrgId: 2, IsEnabled: true, CreatedAt: daysAgo(90), UpdatedAt: daysAgo(90)}
+		strict := &PublicDashboard{Uid: "strict", OrgId: 3, IsEnabled: true, CreatedAt: daysAgo(10), UpdatedAt: daysAgo(10)}
+
+		service, store, pdService, _ := setup(t, cfg, []*PublicDashboard{exempt, strict})
+		store.On("Patch", mock.Anything, mock.Anything).Return(int64(1), nil)
+		pdService.On("PublicDashboardChanged", "strict").Return()
+
+		service.disableInactive(context.Background())
+
+		store.AssertNumberOfCalls(t, "Patch", 1)
+		cmd := store.Calls[1].Arguments.Get(1).(PatchPublicDashboardCommand)
+		assert.Equal(t, "strict", cmd.PublicDashboard.Uid)
+
+		since := store.Calls[0].Arguments.Get(1).(time.Time)
+		assert.WithinDuration(t, daysAgo(7), since, time.Minute)
+	})
+
+	t.Run("does nothing without a policy", func(t *testing.T) {
+		service, store, _, _ := setup(t, setting.NewCfg(), nil)
+
+		service.disableInactive(context.Background())
+
+		store.AssertNotCalled(t, "FindEnabledInactiveSince", mock.Anything, mock.Anything)
+		require.True(t, service.IsDisabled())
+	})
+}
+
+func TestRecordAccess(t *testing.T) {
+	t.Run("updates stale last access", func(t *testing.T) {
+		store := &FakePublicDashboardStore{}
+		store.On("UpdateLastAccessedAt", mock.Anything, "uid", mock.Anything).Return(nil)
+		service := &PublicDashboardServiceImpl{log: log.NewNopLogger(), store: store}
+
+		service.recordAccess(context.Background(), &PublicDashboard{Uid: "uid", LastAccessedAt: time.Now().Add(-2 * time.Hour)})
+
+		store.AssertNumberOfCalls(t, "UpdateLastAccessedAt", 1)
+	})
+
+	t.Run("keeps the public dashboard cached with its new last access", func(t *testing.T) {
+		store := &FakePublicDashboardStore{}
+		store.On("UpdateLastAccessedAt", mock.Anything, "uid", mock.Anything).Return(nil)
+		service := &PublicDashboardServiceImpl{log: log.NewNopLogger(), store: store, accessTokens: newAccessTokenCache(time.Minute)}
+		pubdash := &PublicDashboard{Uid: "uid", AccessToken: "token", LastAccessedAt: time.Now().Add(-2 * time.Hour)}
+		dashboard := &dashboards.Dashboard{UID: "dash", Data: simplejson.New()}
+		service.accessTokens.set("token", pubdash, dashboard, service.accessTokens.currentGeneration(), time.Now())
+
+		service.recordAccess(context.Background(), pubdash)
+
+		cached, _, ok := service.accessTokens.get("token", time.Now())
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now(), cached.LastAccessedAt, time.Minute)
+	})
+
+	t.Run("skips recent last access", func(t *testing.T) {
+		store := &FakePublicDashboardStore{}
+		service := &PublicDashboardServiceImpl{log: log.NewNopLogger(), store: store}
+
+		service.recordAccess(context.Background(), &PublicDashboard{Uid: "uid", LastAccessedAt: time.Now().Add(-time.Minute)})
+
+		store.AssertNotCalled(t, "UpdateLastAccessedAt", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+// InspectPanelQuery runs the queries of a panel of a public dashboard exactly like its viewers do, with the same
+// variables, sanitization and safe interval, but returns the queries sent to the datasources and the full metadata of
+// the frames. It lets owners debug panels that only fail publicly, so it also works while the public dashboard is
+// disabled
+func (pd *PublicDashboardServiceImpl) InspectPanelQuery(ctx context.Context, u *user.SignedInUser, uid string, dashboardUid string, panelId int64, skipDSCache bool, reqDTO models.PublicDashboardQueryDTO) (*models.PublicDashboardQueryInspection, error) {
+	ctx, span := tracer.Start(ctx, "publicdashboards.InspectPanelQuery")
+	defer span.End()
+
+	pubdash, err := pd.store.Find(ctx, uid)
+	if err != nil {
+		return nil, models.ErrInternalServerError.Errorf("InspectPanelQuery: failed to find public dashboard by uid: %s: %w", uid, err)
+	}
+	if pubdash == nil || pubdash.OrgId != u.OrgID {
+		return nil, models.ErrPublicDashboardNotFound.Errorf("InspectPanelQuery: public dashboard not found by uid: %s", uid)
+	}
+
+	// validate the public dashboard belongs to the dashboard
+	if pubdash.DashboardUid != dashboardUid {
+		return nil, models.ErrInvalidUid.Errorf("InspectPanelQuery: the public dashboard does not belong to the dashboard")
+	}
+
+	dashboard, err := pd.FindDashboard(ctx, pubdash.OrgId, pubdash.DashboardUid)
+	if err != nil {
+		return nil, err
+	}
+	if dashboard == nil {
+		return nil, models.ErrPublicDashboardNotFound.Errorf("InspectPanelQuery: dashboard not found by uid: %s", dashboardUid)
+	}
+
+	res, metricReq, err := pd.queryPanel(ctx, pubdash, dashboard, skipDSCache, &reqDTO, panelId)
+	if err != nil {
+		return nil, err
+	}
+
+	pd.log.Info("Inspected public dashboard panel queries", "publicDashboardUid", pubdash.Uid, "panelId", panelId, "user", u.Login)
+
+	return &models.PublicDashboardQueryInspection{
+		Queries:  metricReq.Queries,
+		From:     metricReq.From,
+		To:       metricReq.To,
+		Response: res,
+	}, nil
+}
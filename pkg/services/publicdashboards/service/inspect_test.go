@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	dashboardsDB "github.com/grafana/grafana/pkg/services/dashboards/database"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/tag/tagimpl"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/util/testutil"
+)
+
+func TestIntegrationInspectPanelQuery(t *testing.T) {
+	testutil.SkipIntegrationTestInShortMode(t)
+
+	fakeDashboardService := &dashboards.FakeDashboardService{}
+	service, sqlStore, _ := newPublicDashboardServiceImpl(t, nil, nil, nil, fakeDashboardService, nil)
+	fakeQueryService := &query.FakeQueryService{}
+	fakeQueryService.On("QueryData", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+		func(context.Context, identity.Requester, bool, dtos.MetricRequest) (*backend.QueryDataResponse, error) {
+			frame := data.NewFrame("A").SetMeta(&data.FrameMeta{ExecutedQueryString: "SELECT 1"})
+			return &backend.QueryDataResponse{Responses: backend.Responses{"A": {Frames: data.Frames{frame}}}}, nil
+		}, nil)
+	service.QueryDataService = fakeQueryService
+
+	dashboardStore, err := dashboardsDB.ProvideDashboardStore(sqlStore, service.cfg, featuremgmt.WithFeatures(), tagimpl.ProvideService(sqlStore))
+	require.NoError(t, err)
+
+	panels := []interface{}{
+		map[string]interface{}{
+			"id":         1,
+			"datasource": map[string]interface{}{"uid": "ds1"},
+			"targets": []interface{}{
+				map[string]interface{}{"datasource": map[string]interface{}{"uid": "ds1"}, "refId": "A", "rawSql": "SELECT 1"},
+			},
+		},
+	}
+	dashboard := insertTestDashboard(t, dashboardStore, "testDashInspect", 1, 0, "", true, []map[string]interface{}{}, panels)
+	fakeDashboardService.On("GetDashboard", mock.Anything, mock.Anything, mock.Anything).Return(dashboard, nil)
+
+	admin := &user.SignedInUser{UserID: 1234, OrgID: dashboard.OrgID, Login: "admin"}
+	isEnabled := false
+	pubdash, err := service.Create(context.Background(), admin, &SavePublicDashboardDTO{
+		DashboardUid:    dashboard.UID,
+		UserId:          admin.UserID,
+		OrgID:           dashboard.OrgID,
+		PublicDashboard: &PublicDashboardDTO{IsEnabled: &isEnabled},
+	})
+	require.NoError(t, err)
+
+	reqDTO := PublicDashboardQueryDTO{IntervalMs: 1, MaxDataPoints: 1}
+
+	t.Run("Keeps the executed query strings removed for viewers", func(t *testing.T) {
+		inspection, err := service.InspectPanelQuery(context.Background(), admin, pubdash.Uid, dashboard.UID, 1, false, reqDTO)
+		require.NoError(t, err)
+
+		require.Len(t, inspection.Queries, 1)
+		assert.Equal(t, "A", inspection.Queries[0].Get("refId").MustString())
+		assert.Equal(t, "SELECT 1", inspection.Response.Responses["A"].Frames[0].Meta.ExecutedQueryString)
+	})
+
+	t.Run("Fails for public dashboards of other orgs", func(t *testing.T) {
+		otherOrgAdmin := &user.SignedInUser{UserID: 1234, OrgID: dashboard.OrgID + 1, Login: "admin"}
+		_, err := service.InspectPanelQuery(context.Background(), otherOrgAdmin, pubdash.Uid, dashboard.UID, 1, false, reqDTO)
+		assert.ErrorIs(t, err, ErrPublicDashboardNotFound)
+	})
+
+	t.Run("Fails when the public dashboard belongs to another dashboard", func(t *testing.T) {
+		_, err := service.InspectPanelQuery(context.Background(), admin, pubdash.Uid, "otherdash", 1, false, reqDTO)
+		assert.ErrorIs(t, err, ErrInvalidUid)
+	})
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+)
+
+const (
+	intervalVariableType = "interval"
+
+	// autoIntervalValue is the value of the auto option of interval variables, older dashboards use
+	// $__auto_interval_<name> instead
+	autoIntervalValue = "$__auto"
+	autoIntervalText  = "auto"
+
+	// defaultAutoCount and defaultAutoMin are used by the dashboard when the variable doesn't set auto_count or auto_min
+	defaultAutoCount = 30
+	defaultAutoMin   = "10s"
+)
+
+// isAutoIntervalValue returns whether the value is the auto option of the interval variable
+func isAutoIntervalValue(name string, value interface{}) bool {
+	values := variableValueStrings(value)
+	return len(values) == 1 && (values[0] == autoIntervalValue || values[0] == "$__auto_interval_"+name)
+}
+
+// autoInterval computes the interval of the auto option like the dashboard does: the time range divided by the auto
+// count and rounded, and never less than the auto min
+func autoInterval(from, to time.Time, autoCount int, autoMin string) string {
+	if autoCount <= 0 {
+		autoCount = defaultAutoCount
+	}
+	if autoMin == "" {
+		autoMin = defaultAutoMin
+	}
+
+	interval := gtime.RoundInterval(to.Sub(from) / time.Duration(autoCount))
+	if minInterval, err := gtime.ParseIntervalStringToTimeDuration(autoMin); err == nil && minInterval > interval {
+		interval = minInterval
+	}
+	return gtime.FormatInterval(interval)
+}
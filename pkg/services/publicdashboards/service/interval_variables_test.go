@@ -0,0 +1,86 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+func TestAutoInterval(t *testing.T) {
+	from := time.UnixMilli(1700000000000)
+
+	testCases := []struct {
+		name      string
+		rangeSize time.Duration
+		autoCount int
+		autoMin   string
+		expected  string
+	}{
+		{name: "divides the time range by the auto count", rangeSize: time.Hour, autoCount: 30, expected: "2m"},
+		{name: "rounds the interval", rangeSize: 6 * time.Hour, autoCount: 30, expected: "10m"},
+		{name: "uses 30 steps by default", rangeSize: 24 * time.Hour, expected: "1h"},
+		{name: "is at least the auto min", rangeSize: 15 * time.Minute, autoCount: 30, autoMin: "1m", expected: "1m"},
+		{name: "is at least 10s by default", rangeSize: time.Minute, autoCount: 30, expected: "10s"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, autoInterval(from, from.Add(tc.rangeSize), tc.autoCount, tc.autoMin))
+		})
+	}
+}
+
+func TestIntervalVariableAutoOption(t *testing.T) {
+	dashboard, err := simplejson.NewJson([]byte(`{
+		"templating": {
+			"list": [
+				{
+					"name": "interval",
+					"type": "interval",
+					"auto": true,
+					"auto_count": 30,
+					"auto_min": "10s",
+					"query": "1m,10m,1h",
+					"current": {"text": "auto", "value": "$__auto_interval_interval"}
+				}
+			]
+		}
+	}`))
+	require.NoError(t, err)
+
+	t.Run("interpolates auto with the interval of the time range", func(t *testing.T) {
+		variables := withTimeRangeVariables(nil, TimeSettings{From: "1700000000000", To: "1700021600000"})
+		interpolator := newTemplateInterpolator(dashboard, variables)
+		assert.Equal(t, "rate(up[10m])", interpolator.interpolate("rate(up[$interval])", nil, ""))
+
+		interpolator = newTemplateInterpolator(dashboard, withTimeRangeVariables(map[string]interface{}{"interval": "$__auto"}, TimeSettings{From: "1700000000000", To: "1700003600000"}))
+		assert.Equal(t, "rate(up[2m])", interpolator.interpolate("rate(up[$interval])", nil, ""))
+	})
+
+	t.Run("keeps other values", func(t *testing.T) {
+		variables := withTimeRangeVariables(map[string]interface{}{"interval": "1h"}, TimeSettings{From: "1700000000000", To: "1700021600000"})
+		interpolator := newTemplateInterpolator(dashboard, variables)
+		assert.Equal(t, "rate(up[1h])", interpolator.interpolate("rate(up[$interval])", nil, ""))
+	})
+
+	t.Run("adds the auto option", func(t *testing.T) {
+		service := &PublicDashboardServiceImpl{log: log.NewNopLogger()}
+
+		options, err := service.getIntervalVariableOptions(&variableDefinition{Name: "interval", Type: "interval", Auto: true})
+		require.NoError(t, err)
+		assert.Equal(t, MetricFindValue{Text: "auto", Value: "$__auto"}, options[0])
+
+		options, err = service.getIntervalVariableOptions(&variableDefinition{Name: "interval", Type: "interval", Auto: true, Options: []variableOption{
+			{Text: "auto", Value: "$__auto_interval_interval"},
+			{Text: "1m", Value: "1m"},
+		}})
+		require.NoError(t, err)
+		assert.Equal(t, []MetricFindValue{{Text: "auto", Value: "$__auto_interval_interval"}, {Text: "1m", Value: "1m"}}, options)
+	})
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+const (
+	dashboardLinkTypeTags = "dashboards"
+	linksOverrideProperty = "links"
+)
+
+// publicLinkResolver rewrites the dashboard links, panel links and data links of a public dashboard. Links to
+// dashboards that are shared publicly point to their public dashboard, links to other dashboards of the instance are
+// removed since anonymous viewers can't open them, and links to other sites are kept. Variables in the URLs are
+// replaced with their percent-encoded value, and links running scripts are removed
+type publicLinkResolver struct {
+	pd           *PublicDashboardServiceImpl
+	orgID        int64
+	targets      map[string]string
+	interpolator *templateInterpolator
+}
+
+func (pd *PublicDashboardServiceImpl) resolvePublicLinks(ctx context.Context, publicDashboard *models.PublicDashboard, data *simplejson.Json) {
+	r := &publicLinkResolver{
+		pd:           pd,
+		orgID:        publicDashboard.OrgId,
+		targets:      map[string]string{},
+		interpolator: newTemplateInterpolator(data, withPinnedValues(publicDashboard, data, nil)),
+	}
+	r.walk(ctx, data.Interface(), nil)
+}
+
+// walk visits every links list of the decoded dashboard JSON, including field config overrides. Links of repeated
+// panels are interpolated with the variables of the panel
+func (r *publicLinkResolver) walk(ctx context.Context, node any, scopedVars map[string]scopedVariable) {
+	switch v := node.(type) {
+	case map[string]any:
+		if _, ok := v["scopedVars"]; ok {
+			scopedVars = scopedVariables(simplejson.NewFromAny(v))
+		}
+		for key, child := range v {
+			if links, ok := child.([]any); ok && key == "links" {
+				v[key] = r.resolve(ctx, links, scopedVars)
+				continue
+			}
+			if links, ok := child.([]any); ok && key == "value" && v["id"] == linksOverrideProperty {
+				v[key] = r.resolve(ctx, links, scopedVars)
+				continue
+			}
+			r.walk(ctx, child, scopedVars)
+		}
+	case []any:
+		for _, child := range v {
+			r.walk(ctx, child, scopedVars)
+		}
+	}
+}
+
+func (r *publicLinkResolver) resolve(ctx context.Context, links []any, scopedVars map[string]scopedVariable) []any {
+	resolved := make([]any, 0, len(links))
+	for _, l := range links {
+		link, ok := l.(map[string]any)
+		if !ok {
+			resolved = append(resolved, l)
+			continue
+		}
+
+		// links listing dashboards by tag can't be restricted to public dashboards
+		if link["type"] == dashboardLinkTypeTags {
+			continue
+		}
+
+		rawURL, _ := link["url"].(string)
+		rawURL = r.interpolator.interpolate(rawURL, scopedVars, percentEncodeVariableFormat)
+		if hasUnsafeScheme(rawURL) {
+			continue
+		}
+		publicURL, keep := r.publicURL(ctx, rawURL)
+		if !keep {
+			continue
+		}
+		link["url"] = publicURL
+		resolved = append(resolved, link)
+	}
+	return resolved
+}
+
+// publicURL returns the URL viewers of the public dashboard should use for the link, and false when the link has to be
+// removed
+func (r *publicLinkResolver) publicURL(ctx context.Context, rawURL string) (string, bool) {
+	dashboardUid, u, ok := r.dashboardUidFromURL(rawURL)
+	if !ok {
+		return rawURL, true
+	}
+
+	// the target depends on variables and can't be checked
+	if strings.Contains(dashboardUid, "$") {
+		return "", false
+	}
+
+	accessToken, ok := r.targets[dashboardUid]
+	if !ok {
+		accessToken = r.findAccessToken(ctx, dashboardUid)
+		r.targets[dashboardUid] = accessToken
+	}
+	if accessToken == "" {
+		return "", false
+	}
+
+	u.Scheme = ""
+	u.Host = ""
+	u.Path = r.pd.cfg.AppSubURL + "/public-dashboards/" + accessToken
+	u.RawPath = ""
+	return u.String(), true
+}
+
+// hasUnsafeScheme reports whether the URL runs code when opened. Browsers ignore whitespace and control characters in
+// the scheme, so they are dropped before checking it
+func hasUnsafeScheme(rawURL string) bool {
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, rawURL)
+
+	scheme, _, ok := strings.Cut(cleaned, ":")
+	if !ok {
+		return false
+	}
+	switch strings.ToLower(scheme) {
+	case "javascript", "vbscript", "data":
+		return true
+	}
+	return false
+}
+
+// dashboardUidFromURL returns the uid of the dashboard of the instance the URL points to
+func (r *publicLinkResolver) dashboardUidFromURL(rawURL string) (string, *url.URL, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || rawURL == "" {
+		return "", nil, false
+	}
+
+	if u.Host != "" {
+		appURL, err := url.Parse(r.pd.cfg.AppURL)
+		if err != nil || !strings.EqualFold(u.Host, appURL.Host) {
+			return "", nil, false
+		}
+	}
+
+	path := strings.TrimPrefix(u.Path, r.pd.cfg.AppSubURL)
+	path = strings.TrimPrefix(path, "/")
+	for _, prefix := range []string{"d/", "d-solo/"} {
+		if rest, ok := strings.CutPrefix(path, prefix); ok {
+			uid, _, _ := strings.Cut(rest, "/")
+			if uid != "" {
+				return uid, u, true
+			}
+		}
+	}
+
+	return "", nil, false
+}
+
+// findAccessToken returns the access token of the enabled public dashboard shared with anyone of the dashboard, or an
+// empty string if there is none
+func (r *publicLinkResolver) findAccessToken(ctx context.Context, dashboardUid string) string {
+	pubdash, err := r.pd.store.FindByDashboardUid(ctx, r.orgID, dashboardUid)
+	if err != nil {
+		r.pd.log.Warn("Failed to find the public dashboard of a linked dashboard", "dashboardUid", dashboardUid, "error", err)
+		return ""
+	}
+	if pubdash == nil || !pubdash.IsEnabled || pubdash.IsExpired(time.Now()) || pubdash.Share != models.PublicShareType {
+		return ""
+	}
+	return pubdash.AccessToken
+}
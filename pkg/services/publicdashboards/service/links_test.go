@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestResolvePublicLinks(t *testing.T) {
+	store := &FakePublicDashboardStore{}
+	store.On("FindByDashboardUid", mock.Anything, int64(1), "public").
+		Return(&PublicDashboard{AccessToken: "a1b2c3", IsEnabled: true, Share: PublicShareType}, nil).Once()
+	store.On("FindByDashboardUid", mock.Anything, int64(1), "paused").
+		Return(&PublicDashboard{AccessToken: "d4e5f6", IsEnabled: false, Share: PublicShareType}, nil)
+	store.On("FindByDashboardUid", mock.Anything, int64(1), "private").Return(nil, nil)
+
+	cfg := setting.NewCfg()
+	cfg.AppURL = "https://grafana.example.com/grafana/"
+	cfg.AppSubURL = "/grafana"
+	service := &PublicDashboardServiceImpl{log: log.NewNopLogger(), cfg: cfg, store: store}
+
+	data, err := simplejson.NewJson([]byte(`{
+		"links": [
+			{"type": "link", "title": "Public", "url": "/grafana/d/public/sales?orgId=1&var-region=eu"},
+			{"type": "link", "title": "Private", "url": "https://grafana.example.com/grafana/d/private/costs"},
+			{"type": "link", "title": "Docs", "url": "https://docs.example.com"},
+			{"type": "dashboards", "tags": ["sales"]}
+		],
+		"panels": [
+			{
+				"id": 1,
+				"links": [{"title": "Paused", "url": "/grafana/d/paused"}],
+				"fieldConfig": {
+					"defaults": {"links": [{"title": "Drilldown", "url": "/grafana/d/public/sales?var-host=${__value.text}"}]},
+					"overrides": [
+						{"properties": [{"id": "links", "value": [{"title": "Templated", "url": "/grafana/d/${target}"}]}]}
+					]
+				}
+			}
+		]
+	}`))
+	require.NoError(t, err)
+
+	service.resolvePublicLinks(context.Background(), &PublicDashboard{OrgId: 1}, data)
+
+	links := data.Get("links").MustArray()
+	require.Len(t, links, 2)
+	assert.Equal(t, "/grafana/public-dashboards/a1b2c3?orgId=1&var-region=eu", data.Get("links").GetIndex(0).Get("url").MustString())
+	assert.Equal(t, "https://docs.example.com", data.Get("links").GetIndex(1).Get("url").MustString())
+
+	panel := data.Get("panels").GetIndex(0)
+	assert.Empty(t, panel.Get("links").MustArray())
+	assert.Equal(t, "/grafana/public-dashboards/a1b2c3?var-host=${__value.text}",
+		panel.GetPath("fieldConfig", "defaults", "links").GetIndex(0).Get("url").MustString())
+	assert.Empty(t, panel.GetPath("fieldConfig", "overrides").GetIndex(0).Get("properties").GetIndex(0).Get("value").MustArray())
+}
+
+func TestResolvePublicLinksVariables(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.AppURL = "https://grafana.example.com/"
+	service := &PublicDashboardServiceImpl{log: log.NewNopLogger(), cfg: cfg, store: &FakePublicDashboardStore{}}
+
+	data, err := simplejson.NewJson([]byte(`{
+		"templating": {
+			"list": [
+				{"name": "host", "type": "custom", "current": {"text": "web 1&2", "value": "web 1&2"}},
+				{"name": "region", "type": "custom", "current": {"text": "eu", "value": "eu"}}
+			]
+		},
+		"links": [
+			{"title": "Search", "url": "https://search.example.com/?q=${host}&raw=${host:raw}"},
+			{"title": "Script", "url": "java\tscript:alert(1)"},
+			{"title": "Injected", "url": "${region:raw}"}
+		],
+		"panels": [
+			{
+				"id": 1,
+				"scopedVars": {"host": {"text": "db", "value": "db"}},
+				"fieldConfig": {
+					"defaults": {"links": [{"title": "Logs", "url": "https://logs.example.com/${region}/${host}?series=${__series.name}"}]}
+				}
+			}
+		]
+	}`))
+	require.NoError(t, err)
+
+	service.resolvePublicLinks(context.Background(), &PublicDashboard{OrgId: 1, PinnedVariables: PinnedVariables{"region": "javascript:alert(1)"}}, data)
+
+	links := data.Get("links")
+	require.Len(t, links.MustArray(), 1)
+	assert.Equal(t, "https://search.example.com/?q=web%201%262&raw=web 1&2", links.GetIndex(0).Get("url").MustString())
+
+	// panel variables are used by repeated panels, and references unknown to the server are left to the browser
+	assert.Equal(t, "https://logs.example.com/javascript%3Aalert%281%29/db?series=${__series.name}",
+		data.Get("panels").GetIndex(0).GetPath("fieldConfig", "defaults", "links").GetIndex(0).Get("url").MustString())
+}
+
+func TestHasUnsafeScheme(t *testing.T) {
+	for _, u := range []string{"javascript:alert(1)", " JavaScript:alert(1)", "java\nscript:alert(1)", "vbscript:msgbox", "data:text/html;base64,PHNjcmlwdD4="} {
+		assert.True(t, hasUnsafeScheme(u), u)
+	}
+	for _, u := range []string{"https://example.com", "/d/abc?var-x=javascript:alert(1)", "mailto:team@example.com", ""} {
+		assert.False(t, hasUnsafeScheme(u), u)
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+// ListVariables returns the variables of a public dashboard with their current value, as the dashboard is sent to
+// viewers: hidden variables are left out, variables start from their default value, pinned variables have their pinned
+// value and datasource uids are masked
+func (pd *PublicDashboardServiceImpl) ListVariables(ctx context.Context, accessToken string) ([]PublicDashboardVariable, error) {
+	ctx, span := tracer.Start(ctx, "publicdashboards.ListVariables")
+	defer span.End()
+
+	pubdash, dash, err := pd.FindEnabledPublicDashboardAndDashboardByAccessToken(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return pd.listVariables(pubdash, dash), nil
+}
+
+// listVariables returns the variables of the dashboard as viewers get them, only the variables of the shared panel
+// when a single panel is shared. The data of the dashboard is modified
+func (pd *PublicDashboardServiceImpl) listVariables(pubdash *PublicDashboard, dash *dashboards.Dashboard) []PublicDashboardVariable {
+	shared := pd.sharedVariables(pubdash, dash)
+	applyVariableSnapshot(pubdash, dash.Data)
+	applyVariableDefaults(pubdash, dash.Data)
+	stripHiddenVariables(dash.Data)
+	masker := newDatasourceUidMasker(pd.cfg.SecretKey, pubdash)
+	masker.maskDashboard(dash.Data)
+
+	variables := make([]PublicDashboardVariable, 0)
+	for _, v := range dashboardVariableList(dash.Data) {
+		variable := simplejson.NewFromAny(v)
+		name := variable.Get("name").MustString()
+		if name == "" || (shared != nil && !shared[name]) {
+			continue
+		}
+
+		current := VariableSnapshotValue{
+			Text:  variable.GetPath("current", "text").Interface(),
+			Value: variable.GetPath("current", "value").Interface(),
+		}
+		if value, ok := pubdash.PinnedVariables[name]; ok {
+			if variable.Get("type").MustString() == datasourceVariableType {
+				value = masker.maskValue(value)
+			}
+			current = VariableSnapshotValue{Text: value, Value: value}
+		}
+
+		variables = append(variables, PublicDashboardVariable{
+			Name:       name,
+			Label:      variable.Get("label").MustString(),
+			Type:       variable.Get("type").MustString(),
+			Multi:      variable.Get("multi").MustBool(),
+			IncludeAll: variable.Get("includeAll").MustBool(),
+			Hide:       variable.Get("hide").MustInt(),
+			ReadOnly:   !pubdash.AllowsVariableOverride(name),
+			Current:    current,
+		})
+	}
+
+	return variables
+}
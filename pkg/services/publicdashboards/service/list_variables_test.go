@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/licensing/licensingtest"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestListVariables(t *testing.T) {
+	data, err := simplejson.NewJson([]byte(`{
+		"templating": {
+			"list": [
+				{"name": "env", "label": "Environment", "type": "custom", "multi": true, "includeAll": true, "current": {"text": ["prod"], "value": ["prod"]}},
+				{"name": "region", "type": "custom", "hide": 1, "current": {"text": "eu", "value": "eu"}},
+				{"name": "tenant", "type": "constant", "hide": 2, "current": {"text": "acme", "value": "acme"}}
+			]
+		}
+	}`))
+	require.NoError(t, err)
+	dashboard := &dashboards.Dashboard{UID: "dash1", OrgID: 1, Data: data}
+
+	pubdash := &PublicDashboard{Uid: "uid1", IsEnabled: true, OrgId: 1, DashboardUid: dashboard.UID, PinnedVariables: PinnedVariables{"region": "us"}}
+	fakeStore := &FakePublicDashboardStore{}
+	fakeStore.On("FindByAccessToken", mock.Anything, mock.AnythingOfType("string")).Return(pubdash, nil)
+	fakeDashboardService := &dashboards.FakeDashboardService{}
+	fakeDashboardService.On("GetDashboard", mock.Anything, mock.Anything, mock.Anything).Return(dashboard, nil)
+
+	license := licensingtest.NewFakeLicensing()
+	license.On("FeatureEnabled", FeaturePublicDashboardsEmailSharing).Return(false)
+
+	service := &PublicDashboardServiceImpl{
+		log:              log.NewNopLogger(),
+		cfg:              setting.NewCfg(),
+		store:            fakeStore,
+		dashboardService: fakeDashboardService,
+		license:          license,
+	}
+
+	variables, err := service.ListVariables(context.Background(), "abc123")
+	require.NoError(t, err)
+
+	// hidden variables aren't listed and pinned variables are read only
+	assert.Equal(t, []PublicDashboardVariable{
+		{
+			Name:       "env",
+			Label:      "Environment",
+			Type:       "custom",
+			Multi:      true,
+			IncludeAll: true,
+			Current:    VariableSnapshotValue{Text: []interface{}{"prod"}, Value: []interface{}{"prod"}},
+		},
+		{
+			Name:     "region",
+			Type:     "custom",
+			Hide:     1,
+			ReadOnly: true,
+			Current:  VariableSnapshotValue{Text: "us", Value: "us"},
+		},
+	}, variables)
+}
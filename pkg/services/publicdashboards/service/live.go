@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/live"
+	"github.com/grafana/grafana/pkg/services/live/model"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// liveChannelHandler handles the grafana/public-dashboard/<accessToken> Live channels. Anyone in the org of an enabled
+// public dashboard can subscribe to its channel and to the datasource streams bridged below it, events are only
+// published by the server
+type liveChannelHandler struct {
+	pd *PublicDashboardServiceImpl
+}
+
+func (h *liveChannelHandler) GetHandlerForPath(_ string) (model.ChannelHandler, error) {
+	return h, nil // all public dashboards share the same handler
+}
+
+func (h *liveChannelHandler) OnSubscribe(ctx context.Context, user identity.Requester, e model.SubscribeEvent) (model.SubscribeReply, backend.SubscribeStreamStatus, error) {
+	accessToken, streamPath, bridged := strings.Cut(e.Path, "/")
+	if !validation.IsValidAccessToken(accessToken) {
+		return model.SubscribeReply{}, backend.SubscribeStreamStatusNotFound, nil
+	}
+
+	pubdash, dashboard, err := h.pd.FindEnabledPublicDashboardAndDashboardByAccessToken(ctx, accessToken)
+	if err != nil || pubdash.OrgId != user.GetOrgID() {
+		return model.SubscribeReply{}, backend.SubscribeStreamStatusNotFound, nil
+	}
+
+	if bridged {
+		return h.pd.onBridgedSubscribe(ctx, pubdash, e, streamPath)
+	}
+
+	// viewers start from the options of the variable snapshot
+	h.pd.liveSubscriptions.add(pubdash, h.pd.liveVariableOptions(dashboard, pubdash, pubdash.VariableSnapshot))
+	return model.SubscribeReply{Presence: true}, backend.SubscribeStreamStatusOK, nil
+}
+
+func (h *liveChannelHandler) OnPublish(_ context.Context, _ identity.Requester, _ model.PublishEvent) (model.PublishReply, backend.PublishStreamStatus, error) {
+	return model.PublishReply{}, backend.PublishStreamStatusPermissionDenied, nil
+}
+
+// liveSubscriptions keeps the public dashboards with Live subscribers on this instance, with the variable options
+// last sent to them. A nil tracker doesn't track anything
+type liveSubscriptions struct {
+	mu         sync.Mutex
+	dashboards map[string]*liveSubscription
+}
+
+type liveSubscription struct {
+	orgID     int64
+	variables map[string][]MetricFindValue
+}
+
+func newLiveSubscriptions() *liveSubscriptions {
+	return &liveSubscriptions{dashboards: map[string]*liveSubscription{}}
+}
+
+// add tracks the public dashboard with the variable options its viewers have
+func (s *liveSubscriptions) add(pubdash *PublicDashboard, variables map[string][]MetricFindValue) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.dashboards[pubdash.AccessToken]; ok {
+		return
+	}
+	s.dashboards[pubdash.AccessToken] = &liveSubscription{orgID: pubdash.OrgId, variables: variables}
+}
+
+// list returns a copy of the tracked subscriptions, keyed by access token
+func (s *liveSubscriptions) list() map[string]liveSubscription {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscriptions := make(map[string]liveSubscription, len(s.dashboards))
+	for accessToken, sub := range s.dashboards {
+		subscriptions[accessToken] = *sub
+	}
+	return subscriptions
+}
+
+// update sets the variable options sent to the subscribers of the public dashboard
+func (s *liveSubscriptions) update(accessToken string, variables map[string][]MetricFindValue) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if sub, ok := s.dashboards[accessToken]; ok {
+		sub.variables = variables
+	}
+}
+
+// forget stops tracking the public dashboard
+func (s *liveSubscriptions) forget(accessToken string) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.dashboards, accessToken)
+}
+
+// forgetMatching stops tracking the public dashboards whose access token matches
+func (s *liveSubscriptions) forgetMatching(match func(accessToken string) bool) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for accessToken := range s.dashboards {
+		if match(accessToken) {
+			delete(s.dashboards, accessToken)
+		}
+	}
+}
+
+// publishLiveEvent pushes the event to the viewers of the public dashboard subscribed to its Live channel
+func (pd *PublicDashboardServiceImpl) publishLiveEvent(pubdash *PublicDashboard, event PublicDashboardLiveEvent) {
+	if pd.livePublisher == nil {
+		return
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		pd.log.Warn("Failed to encode public dashboard live event", "publicDashboardUid", pubdash.Uid, "error", err)
+		return
+	}
+	if err := pd.livePublisher(pubdash.OrgId, live.PublicDashboardChannel(pubdash.AccessToken), data); err != nil {
+		pd.log.Warn("Failed to publish public dashboard live event", "publicDashboardUid", pubdash.Uid, "action", event.Action, "error", err)
+	}
+}
+
+// invalidateLiveViewers tells the Live subscribers of the public dashboard to load it again and stops refreshing
+// their variables
+func (pd *PublicDashboardServiceImpl) invalidateLiveViewers(pubdash *PublicDashboard) {
+	pd.publishLiveEvent(pubdash, PublicDashboardLiveEvent{Action: LiveActionInvalidated})
+	pd.liveSubscriptions.forget(pubdash.AccessToken)
+}
+
+// refreshLiveVariables resolves the options of the variables of the public dashboards with Live subscribers again,
+// and pushes them when they changed. Public dashboards without subscribers left are no longer tracked
+func (pd *PublicDashboardServiceImpl) refreshLiveVariables(ctx context.Context) {
+	for accessToken, sub := range pd.liveSubscriptions.list() {
+		if pd.liveClientCount != nil {
+			count, err := pd.liveClientCount(sub.orgID, live.PublicDashboardChannel(accessToken))
+			if err == nil && count == 0 {
+				pd.liveSubscriptions.forget(accessToken)
+				continue
+			}
+		}
+
+		// viewers of public dashboards that were disabled or removed load them again to get their current state
+		pubdash, dashboard, err := pd.FindEnabledPublicDashboardAndDashboardByAccessToken(ctx, accessToken)
+		if err != nil {
+			pd.invalidateLiveViewers(&PublicDashboard{OrgId: sub.orgID, AccessToken: accessToken})
+			continue
+		}
+
+		variables := pd.liveVariableOptions(dashboard, pubdash, pd.snapshotVariables(ctx, dashboard, pubdash))
+		if reflect.DeepEqual(variables, sub.variables) {
+			continue
+		}
+		pd.publishLiveEvent(pubdash, PublicDashboardLiveEvent{Action: LiveActionVariablesRefreshed, Variables: variables})
+		pd.liveSubscriptions.update(accessToken, variables)
+	}
+}
+
+// liveVariableOptions returns the options of the variables of the snapshot as viewers get them, with the All option
+// and with masked datasource uids
+func (pd *PublicDashboardServiceImpl) liveVariableOptions(dashboard *dashboards.Dashboard, pubdash *PublicDashboard, snapshot VariableSnapshot) map[string][]MetricFindValue {
+	masker := newDatasourceUidMasker(pd.cfg.SecretKey, pubdash)
+	masker.learn(dashboard.Data)
+
+	variables := map[string][]MetricFindValue{}
+	for name, entry := range snapshot {
+		variable, err := pd.findVariableInDashboard(dashboard, name)
+		if err != nil {
+			continue
+		}
+
+		options := entry.Options
+		if _, pinned := pubdash.PinnedVariables[name]; !pinned {
+			options = withAllOption(variable, options)
+		}
+		if variable.Type == datasourceVariableType {
+			options = masker.maskOptions(options)
+		}
+		variables[name] = options
+	}
+	return variables
+}
+
+// LiveVariablesService periodically refreshes the options of the variables of public dashboards watched over
+// Grafana Live, so kiosk displays get new options without polling
+type LiveVariablesService struct {
+	cfg *setting.Cfg
+	pd  *PublicDashboardServiceImpl
+}
+
+func ProvideLiveVariablesService(cfg *setting.Cfg, pd *PublicDashboardServiceImpl) *LiveVariablesService {
+	return &LiveVariablesService{cfg: cfg, pd: pd}
+}
+
+// IsDisabled returns true when public dashboards are disabled or variables aren't refreshed
+func (s *LiveVariablesService) IsDisabled() bool {
+	return !s.cfg.PublicDashboardsEnabled || s.cfg.PublicDashboardsLiveVariablesRefreshInterval <= 0
+}
+
+func (s *LiveVariablesService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.cfg.PublicDashboardsLiveVariablesRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			s.pd.refreshLiveVariables(ctx)
+		}
+	}
+}
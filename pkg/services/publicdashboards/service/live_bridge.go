@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	sdklive "github.com/grafana/grafana-plugin-sdk-go/live"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/live"
+	"github.com/grafana/grafana/pkg/services/live/model"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+// liveStreamPathPrefix prefixes the paths of the bridged channels of a public dashboard, after its access token:
+// grafana/public-dashboard/<accessToken>/stream/<maskedDatasourceUid>/<signature>/<path>
+const liveStreamPathPrefix = "stream/"
+
+// liveChannelHandlerGetter returns the handler of a Live channel, like GrafanaLive.GetChannelHandler
+type liveChannelHandlerGetter func(ctx context.Context, user identity.Requester, channel string) (model.ChannelHandler, sdklive.Channel, error)
+
+// bridgeLiveChannels replaces the datasource channels of the streaming frames of the response with channels of the
+// public dashboard, which viewers can subscribe to with the Live connection of the public dashboard. The bridged
+// channels mask the datasource uid and are signed for the access token, so viewers can't open other streams
+func (pd *PublicDashboardServiceImpl) bridgeLiveChannels(pubdash *PublicDashboard, res *backend.QueryDataResponse) {
+	if pd.liveChannelGetter == nil || res == nil {
+		return
+	}
+
+	masker := newDatasourceUidMasker(pd.cfg.SecretKey, pubdash)
+	for _, dr := range res.Responses {
+		for _, frame := range dr.Frames {
+			if frame.Meta == nil || frame.Meta.Channel == "" {
+				continue
+			}
+
+			addr, err := sdklive.ParseChannel(frame.Meta.Channel)
+			if err != nil || addr.Scope != sdklive.ScopeDatasource {
+				continue
+			}
+			frame.Meta.Channel = live.PublicDashboardChannel(pubdash.AccessToken) + "/" + liveStreamPathPrefix +
+				masker.mask(addr.Namespace) + "/" + pd.liveChannelSignature(pubdash, addr.String()) + "/" + addr.Path
+		}
+	}
+}
+
+// onBridgedSubscribe subscribes a viewer to a bridged channel of the public dashboard. The stream of the datasource
+// runs with the service identity and publishes to the bridged channel directly, so viewers never join the channel
+// of the datasource
+func (pd *PublicDashboardServiceImpl) onBridgedSubscribe(ctx context.Context, pubdash *PublicDashboard, e model.SubscribeEvent, streamPath string) (model.SubscribeReply, backend.SubscribeStreamStatus, error) {
+	if pd.liveChannelGetter == nil {
+		return model.SubscribeReply{}, backend.SubscribeStreamStatusNotFound, nil
+	}
+
+	channel, ok := pd.resolveBridgedChannel(ctx, pubdash, streamPath)
+	if !ok {
+		return model.SubscribeReply{}, backend.SubscribeStreamStatusNotFound, nil
+	}
+
+	if maxConnections := pd.cfg.PublicDashboardsLiveMaxConnections; maxConnections > 0 && pd.liveBridges.connections(pubdash.AccessToken, pd.liveClientCount) >= maxConnections {
+		pd.log.Warn("Live connection limit of public dashboard reached", "publicDashboardUid", pubdash.Uid, "limit", maxConnections)
+		return model.SubscribeReply{}, backend.SubscribeStreamStatusPermissionDenied, nil
+	}
+
+	svcCtx, svcIdent := identity.WithServiceIdentity(ctx, pubdash.OrgId)
+	handler, addr, err := pd.liveChannelGetter(svcCtx, svcIdent, channel)
+	if err != nil {
+		pd.log.Warn("Failed to get handler of bridged live channel", "publicDashboardUid", pubdash.Uid, "error", err)
+		return model.SubscribeReply{}, backend.SubscribeStreamStatusNotFound, nil
+	}
+
+	reply, status, err := handler.OnSubscribe(svcCtx, svcIdent, model.SubscribeEvent{
+		Channel: e.Channel,
+		Path:    addr.Path,
+		Data:    e.Data,
+	})
+	if err != nil || status != backend.SubscribeStreamStatusOK {
+		return reply, status, err
+	}
+
+	pd.liveBridges.add(pubdash.AccessToken, pubdash.OrgId, e.Channel)
+	return reply, status, nil
+}
+
+// resolveBridgedChannel returns the datasource channel of the stream path of a bridged channel, when its signature
+// matches. Masked datasource uids are resolved against the datasources of the org of the public dashboard
+func (pd *PublicDashboardServiceImpl) resolveBridgedChannel(ctx context.Context, pubdash *PublicDashboard, streamPath string) (string, bool) {
+	rest, ok := strings.CutPrefix(streamPath, liveStreamPathPrefix)
+	if !ok {
+		return "", false
+	}
+	parts := strings.SplitN(rest, "/", 3)
+	if len(parts) != 3 || parts[2] == "" {
+		return "", false
+	}
+	maskedUid, signature, path := parts[0], parts[1], parts[2]
+
+	dataSources, err := pd.datasourceService.GetDataSources(ctx, &datasources.GetDataSourcesQuery{OrgID: pubdash.OrgId})
+	if err != nil {
+		pd.log.Warn("Failed to list datasources for bridged live channel", "publicDashboardUid", pubdash.Uid, "error", err)
+		return "", false
+	}
+
+	masker := newDatasourceUidMasker(pd.cfg.SecretKey, pubdash)
+	for _, ds := range dataSources {
+		if masker.mask(ds.UID) != maskedUid {
+			continue
+		}
+
+		channel := sdklive.Channel{Scope: sdklive.ScopeDatasource, Namespace: ds.UID, Path: path}.String()
+		if !hmac.Equal([]byte(signature), []byte(pd.liveChannelSignature(pubdash, channel))) {
+			return "", false
+		}
+		return channel, true
+	}
+	return "", false
+}
+
+// liveChannelSignature signs a datasource channel for the public dashboard
+func (pd *PublicDashboardServiceImpl) liveChannelSignature(pubdash *PublicDashboard, channel string) string {
+	h := hmac.New(sha256.New, []byte(pd.cfg.SecretKey))
+	h.Write([]byte(pubdash.AccessToken))
+	h.Write([]byte{0})
+	h.Write([]byte(channel))
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// liveBridges keeps the bridged channels of each public dashboard subscribed to on this instance, to count the Live
+// connections of their viewers. A nil tracker doesn't track anything
+type liveBridges struct {
+	mu       sync.Mutex
+	channels map[string]map[string]int64
+}
+
+func newLiveBridges() *liveBridges {
+	return &liveBridges{channels: map[string]map[string]int64{}}
+}
+
+// add tracks the bridged channel of the public dashboard
+func (b *liveBridges) add(accessToken string, orgID int64, channel string) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.channels[accessToken]; !ok {
+		b.channels[accessToken] = map[string]int64{}
+	}
+	b.channels[accessToken][channel] = orgID
+}
+
+// connections returns the number of viewers subscribed to the bridged channels of the public dashboard. Channels
+// without subscribers left are no longer tracked
+func (b *liveBridges) connections(accessToken string, clientCount model.ChannelClientCount) int {
+	if b == nil || clientCount == nil {
+		return 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	total := 0
+	for channel, orgID := range b.channels[accessToken] {
+		count, err := clientCount(orgID, channel)
+		if err != nil {
+			continue
+		}
+		if count == 0 {
+			delete(b.channels[accessToken], channel)
+			continue
+		}
+		total += count
+	}
+	if len(b.channels[accessToken]) == 0 {
+		delete(b.channels, accessToken)
+	}
+	return total
+}
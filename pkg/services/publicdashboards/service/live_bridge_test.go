@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	sdklive "github.com/grafana/grafana-plugin-sdk-go/live"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	fakeDatasources "github.com/grafana/grafana/pkg/services/datasources/fakes"
+	"github.com/grafana/grafana/pkg/services/live/model"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+type fakeBridgedChannelHandler struct {
+	ctx   context.Context
+	user  identity.Requester
+	event model.SubscribeEvent
+}
+
+func (h *fakeBridgedChannelHandler) OnSubscribe(ctx context.Context, user identity.Requester, e model.SubscribeEvent) (model.SubscribeReply, backend.SubscribeStreamStatus, error) {
+	h.ctx = ctx
+	h.user = user
+	h.event = e
+	return model.SubscribeReply{Presence: true}, backend.SubscribeStreamStatusOK, nil
+}
+
+func (h *fakeBridgedChannelHandler) OnPublish(_ context.Context, _ identity.Requester, _ model.PublishEvent) (model.PublishReply, backend.PublishStreamStatus, error) {
+	return model.PublishReply{}, backend.PublishStreamStatusPermissionDenied, nil
+}
+
+func newLiveBridgeTestService(t *testing.T, pubdash *PublicDashboard, clients int) (*PublicDashboardServiceImpl, *fakeBridgedChannelHandler, *[]string) {
+	t.Helper()
+
+	service, _ := newLiveTestService(t, pubdash, clients)
+	service.cfg.PublicDashboardsLiveMaxConnections = 2
+	service.datasourceService = &fakeDatasources.FakeDataSourceService{DataSources: []*datasources.DataSource{
+		{UID: "testdata", OrgID: 1},
+		{UID: "loki", OrgID: 1},
+	}}
+	service.liveBridges = newLiveBridges()
+
+	handler := &fakeBridgedChannelHandler{}
+	requested := &[]string{}
+	service.liveChannelGetter = func(_ context.Context, _ identity.Requester, channel string) (model.ChannelHandler, sdklive.Channel, error) {
+		*requested = append(*requested, channel)
+		addr, err := sdklive.ParseChannel(channel)
+		return handler, addr, err
+	}
+	return service, handler, requested
+}
+
+func streamingResponse(channel string) *backend.QueryDataResponse {
+	frame := data.NewFrame("stream")
+	frame.Meta = &data.FrameMeta{Channel: channel}
+	return &backend.QueryDataResponse{Responses: backend.Responses{"A": backend.DataResponse{Frames: data.Frames{frame}}}}
+}
+
+func TestBridgeLiveChannels(t *testing.T) {
+	pubdash := &PublicDashboard{Uid: "uid1", AccessToken: "e71950f3e0e5452a9cf0c2b4a9f5bcf4", IsEnabled: true, OrgId: 1, DashboardUid: "dash1"}
+	service, _, _ := newLiveBridgeTestService(t, pubdash, 1)
+
+	t.Run("replaces datasource channels with signed channels of the public dashboard", func(t *testing.T) {
+		res := streamingResponse("ds/loki/tail/abc")
+		service.bridgeLiveChannels(pubdash, res)
+
+		channel := res.Responses["A"].Frames[0].Meta.Channel
+		streamPath, ok := strings.CutPrefix(channel, "grafana/public-dashboard/"+pubdash.AccessToken+"/")
+		require.True(t, ok)
+		assert.NotContains(t, channel, "loki")
+		assert.True(t, strings.HasSuffix(channel, "/tail/abc"))
+
+		resolved, ok := service.resolveBridgedChannel(context.Background(), pubdash, streamPath)
+		require.True(t, ok)
+		assert.Equal(t, "ds/loki/tail/abc", resolved)
+	})
+
+	t.Run("keeps channels of other scopes", func(t *testing.T) {
+		res := streamingResponse("plugin/testdata/random-2s-stream")
+		service.bridgeLiveChannels(pubdash, res)
+		assert.Equal(t, "plugin/testdata/random-2s-stream", res.Responses["A"].Frames[0].Meta.Channel)
+	})
+
+	t.Run("rejects channels with another path than the signed one", func(t *testing.T) {
+		res := streamingResponse("ds/loki/tail/abc")
+		service.bridgeLiveChannels(pubdash, res)
+
+		streamPath := strings.TrimPrefix(res.Responses["A"].Frames[0].Meta.Channel, "grafana/public-dashboard/"+pubdash.AccessToken+"/")
+		_, ok := service.resolveBridgedChannel(context.Background(), pubdash, strings.Replace(streamPath, "/tail/abc", "/tail/other", 1))
+		assert.False(t, ok)
+	})
+
+	t.Run("rejects channels signed for another public dashboard", func(t *testing.T) {
+		res := streamingResponse("ds/loki/tail/abc")
+		service.bridgeLiveChannels(pubdash, res)
+
+		other := &PublicDashboard{Uid: "uid1", AccessToken: "a71950f3e0e5452a9cf0c2b4a9f5bcf4", OrgId: 1}
+		streamPath := strings.TrimPrefix(res.Responses["A"].Frames[0].Meta.Channel, "grafana/public-dashboard/"+pubdash.AccessToken+"/")
+		_, ok := service.resolveBridgedChannel(context.Background(), other, streamPath)
+		assert.False(t, ok)
+	})
+}
+
+func TestLiveChannelHandlerBridgedSubscribe(t *testing.T) {
+	pubdash := &PublicDashboard{Uid: "uid1", AccessToken: "e71950f3e0e5452a9cf0c2b4a9f5bcf4", IsEnabled: true, OrgId: 1, DashboardUid: "dash1"}
+
+	bridgedChannel := func(service *PublicDashboardServiceImpl) string {
+		res := streamingResponse("ds/testdata/random-walk")
+		service.bridgeLiveChannels(pubdash, res)
+		return res.Responses["A"].Frames[0].Meta.Channel
+	}
+
+	t.Run("subscribes to the datasource stream with the service identity", func(t *testing.T) {
+		service, fake, requested := newLiveBridgeTestService(t, pubdash, 1)
+		channel := bridgedChannel(service)
+
+		_, status, err := (&liveChannelHandler{pd: service}).OnSubscribe(context.Background(), &user.SignedInUser{OrgID: 1}, model.SubscribeEvent{
+			Channel: channel,
+			Path:    strings.TrimPrefix(channel, "grafana/public-dashboard/"),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, backend.SubscribeStreamStatusOK, status)
+
+		assert.Equal(t, []string{"ds/testdata/random-walk"}, *requested)
+		assert.Equal(t, model.SubscribeEvent{Channel: channel, Path: "random-walk"}, fake.event)
+		assert.True(t, identity.IsServiceIdentity(fake.ctx))
+		assert.Equal(t, int64(1), fake.user.GetOrgID())
+	})
+
+	t.Run("rejects tampered channels", func(t *testing.T) {
+		service, _, requested := newLiveBridgeTestService(t, pubdash, 1)
+		channel := strings.Replace(bridgedChannel(service), "random-walk", "other-stream", 1)
+
+		_, status, err := (&liveChannelHandler{pd: service}).OnSubscribe(context.Background(), &user.SignedInUser{OrgID: 1}, model.SubscribeEvent{
+			Channel: channel,
+			Path:    strings.TrimPrefix(channel, "grafana/public-dashboard/"),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, backend.SubscribeStreamStatusNotFound, status)
+		assert.Empty(t, *requested)
+	})
+
+	t.Run("rejects subscriptions above the connection limit", func(t *testing.T) {
+		service, _, requested := newLiveBridgeTestService(t, pubdash, 2)
+		channel := bridgedChannel(service)
+		service.liveBridges.add(pubdash.AccessToken, 1, channel)
+
+		_, status, err := (&liveChannelHandler{pd: service}).OnSubscribe(context.Background(), &user.SignedInUser{OrgID: 1}, model.SubscribeEvent{
+			Channel: channel,
+			Path:    strings.TrimPrefix(channel, "grafana/public-dashboard/"),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, backend.SubscribeStreamStatusPermissionDenied, status)
+		assert.Empty(t, *requested)
+	})
+}
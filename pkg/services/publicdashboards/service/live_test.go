@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/licensing/licensingtest"
+	"github.com/grafana/grafana/pkg/services/live/model"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type publishedLiveEvent struct {
+	orgID   int64
+	channel string
+	event   PublicDashboardLiveEvent
+}
+
+func newLiveTestService(t *testing.T, pubdash *PublicDashboard, clients int) (*PublicDashboardServiceImpl, *[]publishedLiveEvent) {
+	t.Helper()
+
+	data, err := simplejson.NewJson([]byte(`{
+		"templating": {"list": [{"name": "env", "type": "custom", "query": "prod,stage", "current": {"text": "prod", "value": "prod"}}]}
+	}`))
+	require.NoError(t, err)
+	dashboard := &dashboards.Dashboard{UID: "dash1", OrgID: 1, Data: data}
+
+	fakeStore := &FakePublicDashboardStore{}
+	fakeStore.On("FindByAccessToken", mock.Anything, mock.AnythingOfType("string")).Return(pubdash, nil)
+	fakeDashboardService := &dashboards.FakeDashboardService{}
+	fakeDashboardService.On("GetDashboard", mock.Anything, mock.Anything, mock.Anything).Return(dashboard, nil)
+	license := licensingtest.NewFakeLicensing()
+	license.On("FeatureEnabled", FeaturePublicDashboardsEmailSharing).Return(false)
+
+	published := &[]publishedLiveEvent{}
+	service := &PublicDashboardServiceImpl{
+		log:              log.NewNopLogger(),
+		cfg:              setting.NewCfg(),
+		store:            fakeStore,
+		dashboardService: fakeDashboardService,
+		license:          license,
+		livePublisher: func(orgID int64, channel string, data []byte) error {
+			var event PublicDashboardLiveEvent
+			require.NoError(t, json.Unmarshal(data, &event))
+			*published = append(*published, publishedLiveEvent{orgID: orgID, channel: channel, event: event})
+			return nil
+		},
+		liveClientCount: func(_ int64, _ string) (int, error) {
+			return clients, nil
+		},
+		liveSubscriptions: newLiveSubscriptions(),
+	}
+	return service, published
+}
+
+func TestLiveChannelHandler(t *testing.T) {
+	pubdash := &PublicDashboard{Uid: "uid1", AccessToken: "e71950f3e0e5452a9cf0c2b4a9f5bcf4", IsEnabled: true, OrgId: 1, DashboardUid: "dash1"}
+	service, _ := newLiveTestService(t, pubdash, 1)
+	handler := &liveChannelHandler{pd: service}
+
+	t.Run("allows viewers of the org of the public dashboard to subscribe", func(t *testing.T) {
+		_, status, err := handler.OnSubscribe(context.Background(), &user.SignedInUser{OrgID: 1}, model.SubscribeEvent{Path: pubdash.AccessToken})
+		require.NoError(t, err)
+		assert.Equal(t, backend.SubscribeStreamStatusOK, status)
+		assert.Contains(t, service.liveSubscriptions.list(), pubdash.AccessToken)
+	})
+
+	t.Run("hides the public dashboard from other orgs", func(t *testing.T) {
+		_, status, err := handler.OnSubscribe(context.Background(), &user.SignedInUser{OrgID: 2}, model.SubscribeEvent{Path: pubdash.AccessToken})
+		require.NoError(t, err)
+		assert.Equal(t, backend.SubscribeStreamStatusNotFound, status)
+	})
+
+	t.Run("rejects invalid access tokens", func(t *testing.T) {
+		_, status, err := handler.OnSubscribe(context.Background(), &user.SignedInUser{OrgID: 1}, model.SubscribeEvent{Path: "../other"})
+		require.NoError(t, err)
+		assert.Equal(t, backend.SubscribeStreamStatusNotFound, status)
+	})
+
+	t.Run("doesn't let viewers publish", func(t *testing.T) {
+		_, status, err := handler.OnPublish(context.Background(), &user.SignedInUser{OrgID: 1}, model.PublishEvent{Path: pubdash.AccessToken})
+		require.NoError(t, err)
+		assert.Equal(t, backend.PublishStreamStatusPermissionDenied, status)
+	})
+}
+
+func TestRefreshLiveVariables(t *testing.T) {
+	pubdash := &PublicDashboard{Uid: "uid1", AccessToken: "e71950f3e0e5452a9cf0c2b4a9f5bcf4", IsEnabled: true, OrgId: 1, DashboardUid: "dash1"}
+
+	t.Run("pushes the options once they changed", func(t *testing.T) {
+		service, published := newLiveTestService(t, pubdash, 1)
+		service.liveSubscriptions.add(pubdash, map[string][]MetricFindValue{"env": {{Text: "prod", Value: "prod"}}})
+
+		service.refreshLiveVariables(context.Background())
+		require.Len(t, *published, 1)
+		assert.Equal(t, publishedLiveEvent{
+			orgID:   1,
+			channel: "grafana/public-dashboard/" + pubdash.AccessToken,
+			event: PublicDashboardLiveEvent{
+				Action:    LiveActionVariablesRefreshed,
+				Variables: map[string][]MetricFindValue{"env": {{Text: "prod", Value: "prod"}, {Text: "stage", Value: "stage"}}},
+			},
+		}, (*published)[0])
+
+		// unchanged options aren't pushed again
+		service.refreshLiveVariables(context.Background())
+		assert.Len(t, *published, 1)
+	})
+
+	t.Run("stops refreshing without subscribers", func(t *testing.T) {
+		service, published := newLiveTestService(t, pubdash, 0)
+		service.liveSubscriptions.add(pubdash, nil)
+
+		service.refreshLiveVariables(context.Background())
+		assert.Empty(t, *published)
+		assert.Empty(t, service.liveSubscriptions.list())
+	})
+
+	t.Run("tells viewers to reload public dashboards that are no longer enabled", func(t *testing.T) {
+		paused := *pubdash
+		paused.IsEnabled = false
+		service, published := newLiveTestService(t, &paused, 1)
+		service.liveSubscriptions.add(&paused, nil)
+
+		service.refreshLiveVariables(context.Background())
+		require.Len(t, *published, 1)
+		assert.Equal(t, LiveActionInvalidated, (*published)[0].event.Action)
+		assert.Empty(t, service.liveSubscriptions.list())
+	})
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+// GetMetadata returns the title, panels, time settings, enabled features and variables of a public dashboard, so
+// embedders can build their own UI without downloading the full dashboard JSON
+func (pd *PublicDashboardServiceImpl) GetMetadata(ctx context.Context, accessToken string) (*PublicDashboardMetadata, error) {
+	ctx, span := tracer.Start(ctx, "publicdashboards.GetMetadata")
+	defer span.End()
+
+	pubdash, dash, err := pd.FindEnabledPublicDashboardAndDashboardByAccessToken(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// public dashboards limited to a panel only expose their panel, hidden panels are never exposed
+	panels := slices.DeleteFunc(dashboardPanels(dash), func(panel PublicDashboardPanel) bool {
+		return !sharesPanel(pubdash, dash, panel.Id)
+	})
+
+	metadata := &PublicDashboardMetadata{
+		Title:                dash.Title,
+		Panels:               panels,
+		Time:                 dashboardTime(dash),
+		AnnotationsEnabled:   pubdash.AnnotationsEnabled,
+		TimeSelectionEnabled: pubdash.TimeSelectionEnabled,
+	}
+	// variables come last, listing them modifies the data of the dashboard
+	metadata.Variables = pd.listVariables(pubdash, dash)
+
+	return metadata, nil
+}
+
+// dashboardPanels returns the panels of the dashboard in either schema version. Panels of v1 dashboards are in the
+// order of the dashboard, panels of v2 dashboards are ordered by id
+func dashboardPanels(dashboard *dashboards.Dashboard) []PublicDashboardPanel {
+	panels := make([]PublicDashboardPanel, 0)
+	if dashboard.Data.Get("elements").Interface() == nil {
+		return appendDashboardPanels(panels, dashboard.Data.Get("panels").MustArray())
+	}
+
+	for _, elementObj := range dashboard.Data.Get("elements").MustMap() {
+		spec := simplejson.NewFromAny(elementObj).Get("spec")
+		vizConfig := spec.Get("vizConfig")
+		panels = append(panels, PublicDashboardPanel{
+			Id:    spec.Get("id").MustInt64(),
+			Title: spec.Get("title").MustString(),
+			// v2beta1 stores the plugin id in group, earlier versions in kind
+			Type: vizConfig.Get("group").MustString(vizConfig.Get("kind").MustString()),
+		})
+	}
+	sort.Slice(panels, func(i, j int) bool { return panels[i].Id < panels[j].Id })
+	return panels
+}
+
+func appendDashboardPanels(panels []PublicDashboardPanel, panelObjs []any) []PublicDashboardPanel {
+	for _, panelObj := range panelObjs {
+		panel := simplejson.NewFromAny(panelObj)
+
+		// collapsed rows keep their panels nested
+		if panel.Get("type").MustString() == "row" {
+			panels = appendDashboardPanels(panels, panel.Get("panels").MustArray())
+			continue
+		}
+
+		panels = append(panels, PublicDashboardPanel{
+			Id:    panel.Get("id").MustInt64(),
+			Title: panel.Get("title").MustString(),
+			Type:  panel.Get("type").MustString(),
+		})
+	}
+	return panels
+}
+
+// dashboardTime returns the default time range, timezone and refresh interval of the dashboard in either schema version
+func dashboardTime(dashboard *dashboards.Dashboard) PublicDashboardTime {
+	if dashboard.Data.Get("elements").Interface() != nil {
+		timeSettings := dashboard.Data.Get("timeSettings")
+		return PublicDashboardTime{
+			From:     timeSettings.Get("from").MustString(),
+			To:       timeSettings.Get("to").MustString(),
+			Timezone: timeSettings.Get("timezone").MustString(),
+			Refresh:  timeSettings.Get("autoRefresh").MustString(),
+		}
+	}
+
+	return PublicDashboardTime{
+		From:     dashboard.Data.GetPath("time", "from").MustString(),
+		To:       dashboard.Data.GetPath("time", "to").MustString(),
+		Timezone: dashboard.Data.Get("timezone").MustString(),
+		Refresh:  dashboard.Data.Get("refresh").MustString(),
+	}
+}
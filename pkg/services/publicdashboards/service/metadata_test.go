@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/licensing/licensingtest"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestGetMetadata(t *testing.T) {
+	data, err := simplejson.NewJson([]byte(`{
+		"time": {"from": "now-6h", "to": "now"},
+		"timezone": "utc",
+		"refresh": "1m",
+		"panels": [
+			{"id": 1, "type": "timeseries", "title": "Requests"},
+			{"id": 2, "type": "row", "title": "Details", "collapsed": true, "panels": [
+				{"id": 3, "type": "table", "title": "Errors"}
+			]}
+		],
+		"templating": {
+			"list": [
+				{"name": "env", "type": "custom", "current": {"text": "prod", "value": "prod"}},
+				{"name": "tenant", "type": "constant", "hide": 2, "current": {"text": "acme", "value": "acme"}}
+			]
+		}
+	}`))
+	require.NoError(t, err)
+	dashboard := &dashboards.Dashboard{UID: "dash1", OrgID: 1, Title: "Service health", Data: data}
+
+	pubdash := &PublicDashboard{Uid: "uid1", IsEnabled: true, OrgId: 1, DashboardUid: dashboard.UID, AnnotationsEnabled: true}
+	fakeStore := &FakePublicDashboardStore{}
+	fakeStore.On("FindByAccessToken", mock.Anything, mock.AnythingOfType("string")).Return(pubdash, nil)
+	fakeDashboardService := &dashboards.FakeDashboardService{}
+	fakeDashboardService.On("GetDashboard", mock.Anything, mock.Anything, mock.Anything).Return(dashboard, nil)
+
+	license := licensingtest.NewFakeLicensing()
+	license.On("FeatureEnabled", FeaturePublicDashboardsEmailSharing).Return(false)
+
+	service := &PublicDashboardServiceImpl{
+		log:              log.NewNopLogger(),
+		cfg:              setting.NewCfg(),
+		store:            fakeStore,
+		dashboardService: fakeDashboardService,
+		license:          license,
+	}
+
+	metadata, err := service.GetMetadata(context.Background(), "abc123")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Service health", metadata.Title)
+	// rows aren't listed, the panels of collapsed rows are
+	assert.Equal(t, []PublicDashboardPanel{
+		{Id: 1, Title: "Requests", Type: "timeseries"},
+		{Id: 3, Title: "Errors", Type: "table"},
+	}, metadata.Panels)
+	assert.Equal(t, PublicDashboardTime{From: "now-6h", To: "now", Timezone: "utc", Refresh: "1m"}, metadata.Time)
+	assert.True(t, metadata.AnnotationsEnabled)
+	assert.False(t, metadata.TimeSelectionEnabled)
+	// hidden variables aren't listed
+	require.Len(t, metadata.Variables, 1)
+	assert.Equal(t, "env", metadata.Variables[0].Name)
+}
+
+func TestDashboardPanelsV2(t *testing.T) {
+	data, err := simplejson.NewJson([]byte(`{
+		"elements": {
+			"panel-2": {"kind": "Panel", "spec": {"id": 2, "title": "Errors", "vizConfig": {"group": "table"}}},
+			"panel-1": {"kind": "Panel", "spec": {"id": 1, "title": "Requests", "vizConfig": {"kind": "timeseries"}}}
+		},
+		"timeSettings": {"from": "now-1h", "to": "now", "timezone": "browser", "autoRefresh": "30s"}
+	}`))
+	require.NoError(t, err)
+	dashboard := &dashboards.Dashboard{Data: data}
+
+	assert.Equal(t, []PublicDashboardPanel{
+		{Id: 1, Title: "Requests", Type: "timeseries"},
+		{Id: 2, Title: "Errors", Type: "table"},
+	}, dashboardPanels(dashboard))
+	assert.Equal(t, PublicDashboardTime{From: "now-1h", To: "now", Timezone: "browser", Refresh: "30s"}, dashboardTime(dashboard))
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/metric"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore/searchstore"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	orphanedCleanupLockName = "delete orphaned public dashboards"
+	// orphanedLookupBatchSize is how many dashboards are looked up at once to find the deleted ones
+	orphanedLookupBatchSize = 100
+)
+
+// CleanupOrphaned deletes the public dashboards of every org whose dashboards were deleted. A dry run only reports
+// them. Nothing is deleted when the dashboards can't be looked up, so a failing lookup isn't mistaken for deleted
+// dashboards
+func (pd *PublicDashboardServiceImpl) CleanupOrphaned(ctx context.Context, dryRun bool) (*OrphanedCleanupResult, error) {
+	ctx, span := tracer.Start(ctx, "publicdashboards.CleanupOrphaned")
+	defer span.End()
+
+	pubdashes, err := pd.store.FindAllAcrossOrgs(ctx)
+	if err != nil {
+		return nil, ErrInternalServerError.Errorf("CleanupOrphaned: failed to find public dashboards: %w", err)
+	}
+
+	byOrg := make(map[int64][]*PublicDashboard)
+	for _, pubdash := range pubdashes {
+		byOrg[pubdash.OrgId] = append(byOrg[pubdash.OrgId], pubdash)
+	}
+	orgIDs := make([]int64, 0, len(byOrg))
+	for orgID := range byOrg {
+		orgIDs = append(orgIDs, orgID)
+	}
+	sort.Slice(orgIDs, func(i, j int) bool { return orgIDs[i] < orgIDs[j] })
+
+	result := &OrphanedCleanupResult{DryRun: dryRun, Orphaned: []OrphanedPublicDashboard{}}
+	for _, orgID := range orgIDs {
+		orphaned, err := pd.findOrphaned(ctx, orgID, byOrg[orgID])
+		if err != nil {
+			return nil, ErrInternalServerError.Errorf("CleanupOrphaned: failed to find the dashboards of org %d: %w", orgID, err)
+		}
+		for _, pubdash := range orphaned {
+			result.Orphaned = append(result.Orphaned, OrphanedPublicDashboard{Uid: pubdash.Uid, OrgId: pubdash.OrgId, DashboardUid: pubdash.DashboardUid})
+		}
+	}
+	metric.OrphanedFound.Set(float64(len(result.Orphaned)))
+
+	if dryRun {
+		return result, nil
+	}
+
+	for _, orphan := range result.Orphaned {
+		if err := pd.Delete(ctx, orphan.Uid, orphan.DashboardUid); err != nil {
+			pd.log.Error("Failed to delete orphaned public dashboard", "publicDashboardUid", orphan.Uid, "dashboardUid", orphan.DashboardUid, "orgId", orphan.OrgId, "error", err)
+			continue
+		}
+		pd.log.Info("Deleted orphaned public dashboard", "publicDashboardUid", orphan.Uid, "dashboardUid", orphan.DashboardUid, "orgId", orphan.OrgId)
+		metric.OrphanedDeletedTotal.Inc()
+		result.Deleted++
+	}
+
+	return result, nil
+}
+
+// findOrphaned returns the public dashboards of the org whose dashboards don't exist anymore
+func (pd *PublicDashboardServiceImpl) findOrphaned(ctx context.Context, orgID int64, pubdashes []*PublicDashboard) ([]*PublicDashboard, error) {
+	svcCtx, svcIdent := identity.WithServiceIdentity(ctx, orgID)
+
+	existing := make(map[string]bool, len(pubdashes))
+	for start := 0; start < len(pubdashes); start += orphanedLookupBatchSize {
+		end := min(start+orphanedLookupBatchSize, len(pubdashes))
+		dashUIDs := make([]string, 0, end-start)
+		for _, pubdash := range pubdashes[start:end] {
+			dashUIDs = append(dashUIDs, pubdash.DashboardUid)
+		}
+
+		found, err := pd.dashboardService.FindDashboards(svcCtx, &dashboards.FindPersistedDashboardsQuery{
+			OrgId:         orgID,
+			DashboardUIDs: dashUIDs,
+			SignedInUser:  svcIdent,
+			Limit:         int64(len(dashUIDs)),
+			Type:          searchstore.TypeDashboard,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, dash := range found {
+			existing[dash.UID] = true
+		}
+	}
+
+	orphaned := make([]*PublicDashboard, 0)
+	for _, pubdash := range pubdashes {
+		if !existing[pubdash.DashboardUid] {
+			orphaned = append(orphaned, pubdash)
+		}
+	}
+	return orphaned, nil
+}
+
+// OrphanedCleanupService deletes the public dashboards whose dashboards were deleted on the interval configured in
+// [public_dashboards]
+type OrphanedCleanupService struct {
+	log        log.Logger
+	cfg        *setting.Cfg
+	service    *PublicDashboardServiceImpl
+	serverLock serverLocker
+}
+
+func ProvideOrphanedCleanupService(cfg *setting.Cfg, service *PublicDashboardServiceImpl, serverLock *serverlock.ServerLockService) *OrphanedCleanupService {
+	return &OrphanedCleanupService{
+		log:        log.New("publicdashboards.orphaned"),
+		cfg:        cfg,
+		service:    service,
+		serverLock: serverLock,
+	}
+}
+
+// IsDisabled returns true when public dashboards are disabled or the cleanup interval is 0
+func (s *OrphanedCleanupService) IsDisabled() bool {
+	return !s.cfg.PublicDashboardsEnabled || s.cfg.PublicDashboardsOrphanedCleanupInterval <= 0
+}
+
+func (s *OrphanedCleanupService) Run(ctx context.Context) error {
+	interval := s.cfg.PublicDashboardsOrphanedCleanupInterval
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.serverLock.LockAndExecute(ctx, orphanedCleanupLockName, interval/2, s.cleanup); err != nil {
+			s.log.Error("Failed to delete orphaned public dashboards", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *OrphanedCleanupService) cleanup(ctx context.Context) {
+	dryRun := s.cfg.PublicDashboardsOrphanedCleanupDryRun
+	result, err := s.service.CleanupOrphaned(ctx, dryRun)
+	if err != nil {
+		s.log.Error("Failed to delete orphaned public dashboards", "error", err)
+		return
+	}
+
+	if len(result.Orphaned) > 0 {
+		s.log.Info("Found orphaned public dashboards", "count", len(result.Orphaned), "deleted", result.Deleted, "dryRun", dryRun)
+	}
+}
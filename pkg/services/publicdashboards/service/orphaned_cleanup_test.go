@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestCleanupOrphaned(t *testing.T) {
+	pubdashes := []*PublicDashboard{
+		{Uid: "pubdash1", OrgId: 1, DashboardUid: "dash1", AccessToken: "e71950f4b5fc4a9d8a34a2d1b2d7e5e0"},
+		{Uid: "pubdash2", OrgId: 1, DashboardUid: "deleted1", AccessToken: "a3b1e6f5c7d84b2e9f0a1c2d3e4f5a6b"},
+		{Uid: "pubdash3", OrgId: 2, DashboardUid: "deleted2", AccessToken: "0b3a1c4e5d6f47a8b9c0d1e2f3a4b5c6"},
+	}
+
+	setup := func(t *testing.T) (*PublicDashboardServiceImpl, *FakePublicDashboardStore, *dashboards.FakeDashboardService, *FakePublicDashboardServiceWrapper) {
+		store := &FakePublicDashboardStore{}
+		store.Test(t)
+		store.On("FindAllAcrossOrgs", mock.Anything).Return(pubdashes, nil)
+		dashboardService := &dashboards.FakeDashboardService{}
+		dashboardService.Test(t)
+		wrapper := NewFakePublicDashboardServiceWrapper(t)
+		service := &PublicDashboardServiceImpl{
+			log:              log.NewNopLogger(),
+			cfg:              setting.NewCfg(),
+			store:            store,
+			dashboardService: dashboardService,
+			serviceWrapper:   wrapper,
+			presence:         newPresenceTracker(),
+			variableUsage:    newVariableUsageTracker(),
+			variableOptions:  newVariableOptionsCache(time.Minute, nil),
+			viewerQueries:    newViewerQueryTracker(),
+		}
+		return service, store, dashboardService, wrapper
+	}
+
+	findDashboards := func(dashboardService *dashboards.FakeDashboardService, orgID int64, found ...string) {
+		projections := make([]dashboards.DashboardSearchProjection, 0, len(found))
+		for _, uid := range found {
+			projections = append(projections, dashboards.DashboardSearchProjection{UID: uid})
+		}
+		dashboardService.On("FindDashboards", mock.Anything, mock.MatchedBy(func(query *dashboards.FindPersistedDashboardsQuery) bool {
+			return query.OrgId == orgID
+		})).Return(projections, nil)
+	}
+
+	expectedOrphaned := []OrphanedPublicDashboard{
+		{Uid: "pubdash2", OrgId: 1, DashboardUid: "deleted1"},
+		{Uid: "pubdash3", OrgId: 2, DashboardUid: "deleted2"},
+	}
+
+	t.Run("a dry run only reports the public dashboards of deleted dashboards", func(t *testing.T) {
+		service, _, dashboardService, wrapper := setup(t)
+		findDashboards(dashboardService, 1, "dash1")
+		findDashboards(dashboardService, 2)
+
+		result, err := service.CleanupOrphaned(context.Background(), true)
+		require.NoError(t, err)
+		assert.Equal(t, &OrphanedCleanupResult{DryRun: true, Orphaned: expectedOrphaned}, result)
+		wrapper.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
+	t.Run("deletes the public dashboards of deleted dashboards", func(t *testing.T) {
+		service, store, dashboardService, wrapper := setup(t)
+		findDashboards(dashboardService, 1, "dash1")
+		findDashboards(dashboardService, 2)
+		store.On("Find", mock.Anything, "pubdash2").Return(pubdashes[1], nil)
+		store.On("Find", mock.Anything, "pubdash3").Return(pubdashes[2], nil)
+		wrapper.On("Delete", mock.Anything, "pubdash2").Return(nil)
+		wrapper.On("Delete", mock.Anything, "pubdash3").Return(errors.New("database is locked"))
+
+		result, err := service.CleanupOrphaned(context.Background(), false)
+		require.NoError(t, err)
+		assert.Equal(t, &OrphanedCleanupResult{Orphaned: expectedOrphaned, Deleted: 1}, result)
+	})
+
+	t.Run("doesn't delete anything when the dashboards can't be looked up", func(t *testing.T) {
+		service, _, dashboardService, wrapper := setup(t)
+		findDashboards(dashboardService, 1, "dash1")
+		dashboardService.On("FindDashboards", mock.Anything, mock.MatchedBy(func(query *dashboards.FindPersistedDashboardsQuery) bool {
+			return query.OrgId == 2
+		})).Return(nil, errors.New("search unavailable"))
+
+		_, err := service.CleanupOrphaned(context.Background(), false)
+		require.ErrorIs(t, err, ErrInternalServerError)
+		wrapper.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+}
+
+func TestOrphanedCleanupServiceIsDisabled(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.PublicDashboardsEnabled = true
+	cfg.PublicDashboardsOrphanedCleanupInterval = time.Hour
+	s := &OrphanedCleanupService{cfg: cfg}
+	assert.False(t, s.IsDisabled())
+
+	cfg.PublicDashboardsOrphanedCleanupInterval = 0
+	assert.True(t, s.IsDisabled())
+
+	cfg.PublicDashboardsOrphanedCleanupInterval = time.Hour
+	cfg.PublicDashboardsEnabled = false
+	assert.True(t, s.IsDisabled())
+}
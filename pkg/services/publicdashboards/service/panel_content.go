@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"html"
+	"net/url"
+
+	"github.com/russross/blackfriday/v2"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+const (
+	textPanelType = "text"
+
+	textModeMarkdown = "markdown"
+	textModeHTML     = "html"
+	textModeCode     = "code"
+)
+
+// GetPanelContent returns the variable interpolated and sanitized rich text of a panel, the description of any
+// panel and the content of text panels, so public viewers never receive the raw markdown of the dashboard
+func (pd *PublicDashboardServiceImpl) GetPanelContent(ctx context.Context, accessToken string, panelId int64, reqDTO models.PublicDashboardPanelContentDTO) (*models.PanelContent, error) {
+	ctx, span := tracer.Start(ctx, "publicdashboards.GetPanelContent")
+	defer span.End()
+
+	publicDashboard, dashboard, err := pd.FindEnabledPublicDashboardAndDashboardByAccessToken(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	panel := findPanelContent(dashboard, panelId)
+	if panel == nil || !sharesPanel(publicDashboard, dashboard, panelId) {
+		return nil, models.ErrPanelNotFound.Errorf("GetPanelContent: panel %d not found", panelId)
+	}
+
+	internalHost := pd.internalHost()
+	interpolator := newTemplateInterpolator(dashboard.Data, withPinnedValues(publicDashboard, dashboard.Data, overridableValues(publicDashboard, visibleValues(dashboard.Data, reqDTO.Variables))))
+
+	// titles use the text of the variables and text panels escape values like the panel does in the browser
+	content := &models.PanelContent{
+		PanelId: panelId,
+		Title:   html.EscapeString(interpolator.interpolate(panel.title, nil, textVariableFormat)),
+	}
+
+	if panel.description != "" {
+		content.Description = sanitizeHTML(renderMarkdown(interpolator.interpolate(panel.description, nil, "")), internalHost)
+	}
+
+	if panel.pluginId == textPanelType && panel.content != "" {
+		content.Content = sanitizeHTML(renderTextPanelContent(interpolator.interpolate(panel.content, nil, "html"), panel.mode), internalHost)
+	}
+
+	return content, nil
+}
+
+// internalHost returns the host Grafana is served from, links pointing there are not exposed to public viewers
+func (pd *PublicDashboardServiceImpl) internalHost() string {
+	if pd.cfg == nil || pd.cfg.AppURL == "" {
+		return ""
+	}
+
+	u, err := url.Parse(pd.cfg.AppURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// panelContent holds the rich text fields of a panel regardless of the dashboard schema version
+type panelContent struct {
+	pluginId    string
+	title       string
+	description string
+	content     string
+	mode        string
+}
+
+// findPanelContent looks up the panel in either schema version and returns its rich text fields
+func findPanelContent(dashboard *dashboards.Dashboard, panelId int64) *panelContent {
+	if dashboard.Data.Get("elements").Interface() != nil {
+		return findPanelContentV2(dashboard.Data, panelId)
+	}
+	return findPanelContentInPanels(dashboard.Data.Get("panels").MustArray(), panelId)
+}
+
+func findPanelContentInPanels(panels []any, panelId int64) *panelContent {
+	for _, panelObj := range panels {
+		panel := simplejson.NewFromAny(panelObj)
+
+		// collapsed rows keep their panels nested
+		if panel.Get("type").MustString() == "row" {
+			if found := findPanelContentInPanels(panel.Get("panels").MustArray(), panelId); found != nil {
+				return found
+			}
+			continue
+		}
+
+		if panel.Get("id").MustInt64() != panelId {
+			continue
+		}
+
+		// text panels before 7.1 kept their content at the panel level
+		options := panel.Get("options")
+		return &panelContent{
+			pluginId:    panel.Get("type").MustString(),
+			title:       panel.Get("title").MustString(),
+			description: panel.Get("description").MustString(),
+			content:     options.Get("content").MustString(panel.Get("content").MustString()),
+			mode:        options.Get("mode").MustString(panel.Get("mode").MustString(textModeMarkdown)),
+		}
+	}
+
+	return nil
+}
+
+func findPanelContentV2(dashboard *simplejson.Json, panelId int64) *panelContent {
+	for _, elementObj := range dashboard.Get("elements").MustMap() {
+		element := simplejson.NewFromAny(elementObj)
+		spec := element.Get("spec")
+		if spec.Get("id").MustInt64() != panelId {
+			continue
+		}
+
+		vizConfig := spec.Get("vizConfig")
+		// v2beta1 stores the plugin id in group, earlier versions in kind
+		pluginId := vizConfig.Get("group").MustString(vizConfig.Get("kind").MustString())
+		options := vizConfig.Get("spec").Get("options")
+
+		return &panelContent{
+			pluginId:    pluginId,
+			title:       spec.Get("title").MustString(),
+			description: spec.Get("description").MustString(),
+			content:     options.Get("content").MustString(),
+			mode:        options.Get("mode").MustString(textModeMarkdown),
+		}
+	}
+
+	return nil
+}
+
+// renderTextPanelContent renders the content of a text panel according to its mode, the result still has to be sanitized
+func renderTextPanelContent(content string, mode string) string {
+	switch mode {
+	case textModeHTML:
+		return content
+	case textModeCode:
+		return "<pre><code>" + html.EscapeString(content) + "</code></pre>"
+	default:
+		return renderMarkdown(content)
+	}
+}
+
+func renderMarkdown(text string) string {
+	return string(blackfriday.Run([]byte(text)))
+}
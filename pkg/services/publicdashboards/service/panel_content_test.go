@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/licensing/licensingtest"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestGetPanelContent(t *testing.T) {
+	dashboardJSON := `{
+		"panels": [
+			{
+				"id": 1,
+				"type": "text",
+				"title": "Notes for $env",
+				"description": "See [runbook](/d/runbook) for **$env**",
+				"options": {
+					"mode": "markdown",
+					"content": "# Status of $env\n\n<script>alert(1)</script>\n\n[docs](https://grafana.com/docs)"
+				}
+			},
+			{
+				"id": 2,
+				"type": "timeseries",
+				"title": "CPU",
+				"description": "<img src=x onerror=alert(1)>usage",
+				"options": {"content": "ignored"}
+			},
+			{
+				"id": 3,
+				"type": "row",
+				"panels": [
+					{
+						"id": 4,
+						"type": "text",
+						"options": {"mode": "code", "content": "<b>raw</b>"}
+					}
+				]
+			}
+		]
+	}`
+
+	setup := func(t *testing.T) *PublicDashboardServiceImpl {
+		data, err := simplejson.NewJson([]byte(dashboardJSON))
+		require.NoError(t, err)
+		dashboard := &dashboards.Dashboard{UID: "dash1", OrgID: 1, Data: data}
+
+		pubdash := &PublicDashboard{Uid: "uid1", IsEnabled: true, OrgId: 1, DashboardUid: dashboard.UID}
+		fakeStore := &FakePublicDashboardStore{}
+		fakeStore.On("FindByAccessToken", mock.Anything, mock.AnythingOfType("string")).Return(pubdash, nil)
+		fakeDashboardService := &dashboards.FakeDashboardService{}
+		fakeDashboardService.On("GetDashboard", mock.Anything, mock.Anything, mock.Anything).Return(dashboard, nil)
+
+		license := licensingtest.NewFakeLicensing()
+		license.On("FeatureEnabled", FeaturePublicDashboardsEmailSharing).Return(false)
+
+		cfg := setting.NewCfg()
+		cfg.AppURL = "https://grafana.internal/"
+
+		return &PublicDashboardServiceImpl{
+			log:              log.NewNopLogger(),
+			cfg:              cfg,
+			store:            fakeStore,
+			dashboardService: fakeDashboardService,
+			license:          license,
+		}
+	}
+
+	t.Run("renders and sanitizes the content of a text panel", func(t *testing.T) {
+		service := setup(t)
+
+		content, err := service.GetPanelContent(context.Background(), "abc123", 1, PublicDashboardPanelContentDTO{
+			Variables: map[string]interface{}{"env": "prod"},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(1), content.PanelId)
+		assert.Equal(t, "Notes for prod", content.Title)
+		assert.Equal(t, "<p>See runbook for <strong>prod</strong></p>\n", content.Description)
+		assert.Contains(t, content.Content, "<h1>Status of prod</h1>")
+		assert.Contains(t, content.Content, `<a href="https://grafana.com/docs" target="_blank" rel="noopener noreferrer">docs</a>`)
+		assert.NotContains(t, content.Content, "script")
+		assert.NotContains(t, content.Content, "alert")
+	})
+
+	t.Run("only returns the description for other panel types", func(t *testing.T) {
+		service := setup(t)
+
+		content, err := service.GetPanelContent(context.Background(), "abc123", 2, PublicDashboardPanelContentDTO{})
+		require.NoError(t, err)
+
+		assert.Equal(t, "<p>usage</p>\n", content.Description)
+		assert.Empty(t, content.Content)
+	})
+
+	t.Run("finds panels nested in rows and escapes code", func(t *testing.T) {
+		service := setup(t)
+
+		content, err := service.GetPanelContent(context.Background(), "abc123", 4, PublicDashboardPanelContentDTO{})
+		require.NoError(t, err)
+
+		assert.Equal(t, "<pre><code>&lt;b&gt;raw&lt;/b&gt;</code></pre>", content.Content)
+	})
+
+	t.Run("returns not found for unknown panels", func(t *testing.T) {
+		service := setup(t)
+
+		_, err := service.GetPanelContent(context.Background(), "abc123", 42, PublicDashboardPanelContentDTO{})
+		require.ErrorIs(t, err, ErrPanelNotFound)
+	})
+}
+
+func TestFindPanelContentV2(t *testing.T) {
+	data, err := simplejson.NewJson([]byte(`{
+		"elements": {
+			"panel-1": {
+				"kind": "Panel",
+				"spec": {
+					"id": 1,
+					"title": "Notes",
+					"description": "desc",
+					"vizConfig": {
+						"group": "text",
+						"spec": {"options": {"mode": "html", "content": "<p>hi</p>"}}
+					}
+				}
+			}
+		}
+	}`))
+	require.NoError(t, err)
+
+	panel := findPanelContent(&dashboards.Dashboard{Data: data}, 1)
+	require.NotNil(t, panel)
+	assert.Equal(t, "text", panel.pluginId)
+	assert.Equal(t, "Notes", panel.title)
+	assert.Equal(t, "desc", panel.description)
+	assert.Equal(t, "<p>hi</p>", panel.content)
+	assert.Equal(t, textModeHTML, panel.mode)
+
+	assert.Nil(t, findPanelContent(&dashboards.Dashboard{Data: data}, 2))
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"encoding/json"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+// validateSharedPanel checks the panel a public dashboard is limited to exists in the dashboard. Nil and zero share
+// the whole dashboard
+func validateSharedPanel(dashboard *dashboards.Dashboard, panelId *int64) error {
+	if panelId == nil || *panelId == 0 {
+		return nil
+	}
+
+	if findPanelContent(dashboard, *panelId) == nil {
+		return ErrInvalidPanelId.Errorf("validateSharedPanel: panel %d not found in dashboard %s", *panelId, dashboard.UID)
+	}
+	return nil
+}
+
+// validateHiddenPanels checks the panels hidden from the viewers of a public dashboard exist in the dashboard
+func validateHiddenPanels(dashboard *dashboards.Dashboard, panelIds []int64) error {
+	for _, panelId := range panelIds {
+		if findPanelContent(dashboard, panelId) == nil {
+			return ErrInvalidHiddenPanelIds.Errorf("validateHiddenPanels: panel %d not found in dashboard %s", panelId, dashboard.UID)
+		}
+	}
+	return nil
+}
+
+// sharesPanel reports whether viewers of the public dashboard can access the panel. Every panel is shared when the
+// whole dashboard is, otherwise only the shared panel and the instances of the shared panel when it's repeated.
+// Hidden panels and their instances are never shared
+func sharesPanel(pubdash *PublicDashboard, dashboard *dashboards.Dashboard, panelId int64) bool {
+	if hidesPanel(pubdash, dashboard, panelId) {
+		return false
+	}
+	if !pubdash.IsPanelShare() || panelId == pubdash.PanelId {
+		return true
+	}
+
+	// ids of the other panels of the dashboard are never ids of instances
+	if findPanelContent(dashboard, panelId) != nil {
+		return false
+	}
+	return isRepeatedPanelInstance(panelId, pubdash.PanelId)
+}
+
+// hidesPanel reports whether the panel is hidden from the viewers of the public dashboard, or is an instance of a
+// hidden repeated panel
+func hidesPanel(pubdash *PublicDashboard, dashboard *dashboards.Dashboard, panelId int64) bool {
+	if len(pubdash.HiddenPanelIds) == 0 {
+		return false
+	}
+	if pubdash.HidesPanel(panelId) {
+		return true
+	}
+
+	// ids of the other panels of the dashboard are never ids of instances
+	if findPanelContent(dashboard, panelId) != nil {
+		return false
+	}
+	repeatedPanelId := panelId / repeatedPanelIdFactor
+	return isRepeatedPanelInstance(panelId, repeatedPanelId) && pubdash.HidesPanel(repeatedPanelId)
+}
+
+// isRepeatedPanelInstance reports whether the id is the synthetic id of an instance of the repeated panel
+func isRepeatedPanelInstance(panelId int64, repeatedPanelId int64) bool {
+	return panelId/repeatedPanelIdFactor == repeatedPanelId && panelId%repeatedPanelIdFactor != 0
+}
+
+// sharedPanelIds returns the ids of the panels viewers of the public dashboard can access among the given ids
+func sharedPanelIds(pubdash *PublicDashboard, dashboard *dashboards.Dashboard, panelIds []int64) []int64 {
+	if !pubdash.IsPanelShare() && len(pubdash.HiddenPanelIds) == 0 {
+		return panelIds
+	}
+
+	shared := make([]int64, 0, len(panelIds))
+	for _, panelId := range panelIds {
+		if sharesPanel(pubdash, dashboard, panelId) {
+			shared = append(shared, panelId)
+		}
+	}
+	return shared
+}
+
+// stripHiddenPanels removes the hidden panels from the dashboard served to viewers, including the panels of collapsed
+// rows. Elements of v2 dashboards are kept as their layout references them, their queries are still refused
+func stripHiddenPanels(pubdash *PublicDashboard, dashboard *simplejson.Json) {
+	if len(pubdash.HiddenPanelIds) == 0 || dashboard.Get("elements").Interface() != nil {
+		return
+	}
+	dashboard.Set("panels", withoutHiddenPanels(pubdash, dashboard.Get("panels").MustArray()))
+}
+
+func withoutHiddenPanels(pubdash *PublicDashboard, panels []any) []any {
+	visible := make([]any, 0, len(panels))
+	for _, panelObj := range panels {
+		panel, ok := panelObj.(map[string]any)
+		if !ok {
+			visible = append(visible, panelObj)
+			continue
+		}
+		if pubdash.HidesPanel(simplejson.NewFromAny(panel).Get("id").MustInt64()) {
+			continue
+		}
+		if nested, ok := panel["panels"].([]any); ok {
+			panel["panels"] = withoutHiddenPanels(pubdash, nested)
+		}
+		visible = append(visible, panel)
+	}
+	return visible
+}
+
+// sharedVariables returns the names of the variables viewers of a public dashboard limited to a panel can access: the
+// variables the panel references and the variables these depend on. Nil when the whole dashboard is shared
+func (pd *PublicDashboardServiceImpl) sharedVariables(pubdash *PublicDashboard, dashboard *dashboards.Dashboard) map[string]bool {
+	if !pubdash.IsPanelShare() {
+		return nil
+	}
+
+	variables := map[string]*variableDefinition{}
+	for _, v := range dashboardVariableList(dashboard.Data) {
+		name := simplejson.NewFromAny(v).Get("name").MustString()
+		if name == "" {
+			continue
+		}
+		// variables that can't be read can't be queried either
+		if variable, err := pd.findVariableInDashboard(dashboard, name); err == nil {
+			variables[name] = variable
+		}
+	}
+
+	dependencies := variableDependencies(variables)
+	shared := map[string]bool{}
+	for _, name := range panelVariableReferences(dashboard.Data, pubdash.PanelId) {
+		if _, ok := variables[name]; !ok {
+			continue
+		}
+		shared[name] = true
+		for _, upstream := range upstreamVariables(dependencies, name) {
+			shared[upstream] = true
+		}
+	}
+	return shared
+}
+
+// panelVariableReferences returns the names of the variables referenced by the panel, including the variables the
+// panel and its row are repeated by
+func panelVariableReferences(dashboard *simplejson.Json, panelId int64) []string {
+	var panel any
+	var refs []string
+	if dashboard.Get("elements").Interface() != nil {
+		for _, elementObj := range dashboard.Get("elements").MustMap() {
+			if simplejson.NewFromAny(elementObj).GetPath("spec", "id").MustInt64() == panelId {
+				panel = elementObj
+				break
+			}
+		}
+	} else {
+		panels := dashboard.Get("panels").MustArray()
+		if found := findPanelById(panels, panelId); found != nil {
+			panel = found
+			if repeat, ok := found["repeat"].(string); ok && repeat != "" {
+				refs = append(refs, repeat)
+			}
+			if row := findPanelRow(panels, panelId); row != nil {
+				if repeat, ok := row["repeat"].(string); ok && repeat != "" {
+					refs = append(refs, repeat)
+				}
+			}
+		}
+	}
+	if panel == nil {
+		return refs
+	}
+
+	encoded, err := json.Marshal(panel)
+	if err != nil {
+		return refs
+	}
+	for _, groups := range variableRegex.FindAllStringSubmatch(string(encoded), -1) {
+		for _, ref := range []string{groups[1], groups[2], groups[4]} {
+			if ref != "" {
+				refs = append(refs, ref)
+			}
+		}
+	}
+	return refs
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/licensing/licensingtest"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func panelScopeDashboard(t *testing.T) *dashboards.Dashboard {
+	t.Helper()
+	data, err := simplejson.NewJson([]byte(`{
+		"panels": [
+			{"id": 1, "type": "timeseries", "title": "Requests", "targets": [{"refId": "A", "expr": "rate(requests{region=\"$region\"}[5m])"}]},
+			{"id": 2, "type": "row", "title": "Details", "collapsed": true, "repeat": "cluster", "panels": [
+				{"id": 4, "type": "table", "title": "Errors", "repeat": "host", "targets": [{"refId": "A", "expr": "errors"}]}
+			]},
+			{"id": 4001, "type": "stat", "title": "Tenants", "targets": [{"refId": "A", "expr": "tenants{tenant=\"${tenant}\"}"}]}
+		],
+		"templating": {
+			"list": [
+				{"name": "env", "type": "custom", "query": "prod,dev", "current": {"text": "prod", "value": "prod"}},
+				{"name": "region", "type": "query", "query": "label_values(up{env=\"$env\"}, region)", "current": {"text": "eu", "value": "eu"}},
+				{"name": "tenant", "type": "custom", "query": "acme", "current": {"text": "acme", "value": "acme"}},
+				{"name": "cluster", "type": "custom", "query": "a,b", "current": {"text": "a", "value": "a"}},
+				{"name": "host", "type": "custom", "query": "h1,h2", "current": {"text": "h1", "value": "h1"}}
+			]
+		}
+	}`))
+	require.NoError(t, err)
+	return &dashboards.Dashboard{UID: "dash1", OrgID: 1, Title: "Service health", Data: data}
+}
+
+func TestValidateSharedPanel(t *testing.T) {
+	dashboard := panelScopeDashboard(t)
+	id := func(panelId int64) *int64 { return &panelId }
+
+	require.NoError(t, validateSharedPanel(dashboard, nil))
+	require.NoError(t, validateSharedPanel(dashboard, id(0)))
+	require.NoError(t, validateSharedPanel(dashboard, id(4)))
+	require.ErrorIs(t, validateSharedPanel(dashboard, id(99)), ErrInvalidPanelId)
+}
+
+func TestValidateHiddenPanels(t *testing.T) {
+	dashboard := panelScopeDashboard(t)
+
+	require.NoError(t, validateHiddenPanels(dashboard, nil))
+	require.NoError(t, validateHiddenPanels(dashboard, []int64{1, 4}))
+	require.ErrorIs(t, validateHiddenPanels(dashboard, []int64{1, 99}), ErrInvalidHiddenPanelIds)
+	// rows aren't panels
+	require.ErrorIs(t, validateHiddenPanels(dashboard, []int64{2}), ErrInvalidHiddenPanelIds)
+}
+
+func TestSharesPanel(t *testing.T) {
+	dashboard := panelScopeDashboard(t)
+
+	t.Run("shares every panel of public dashboards sharing the whole dashboard", func(t *testing.T) {
+		pubdash := &PublicDashboard{}
+		assert.True(t, sharesPanel(pubdash, dashboard, 1))
+		assert.True(t, sharesPanel(pubdash, dashboard, 4))
+	})
+
+	t.Run("only shares the panel and its instances", func(t *testing.T) {
+		pubdash := &PublicDashboard{PanelId: 4}
+		assert.True(t, sharesPanel(pubdash, dashboard, 4))
+		assert.True(t, sharesPanel(pubdash, dashboard, 4002))
+		assert.False(t, sharesPanel(pubdash, dashboard, 1))
+		assert.False(t, sharesPanel(pubdash, dashboard, 4000))
+		// 4001 is the id of another panel rather than an instance
+		assert.False(t, sharesPanel(pubdash, dashboard, 4001))
+	})
+
+	t.Run("never shares hidden panels and their instances", func(t *testing.T) {
+		pubdash := &PublicDashboard{HiddenPanelIds: []int64{4}}
+		assert.False(t, sharesPanel(pubdash, dashboard, 4))
+		assert.False(t, sharesPanel(pubdash, dashboard, 4002))
+		assert.True(t, sharesPanel(pubdash, dashboard, 1))
+		// 4001 is the id of another panel rather than an instance
+		assert.True(t, sharesPanel(pubdash, dashboard, 4001))
+
+		assert.False(t, sharesPanel(&PublicDashboard{PanelId: 4, HiddenPanelIds: []int64{4}}, dashboard, 4))
+	})
+
+	t.Run("filters the shared panel ids", func(t *testing.T) {
+		assert.Equal(t, []int64{1, 4, 4001}, sharedPanelIds(&PublicDashboard{}, dashboard, []int64{1, 4, 4001}))
+		assert.Equal(t, []int64{1}, sharedPanelIds(&PublicDashboard{PanelId: 1}, dashboard, []int64{1, 4, 4001}))
+		assert.Equal(t, []int64{1, 4001}, sharedPanelIds(&PublicDashboard{HiddenPanelIds: []int64{4}}, dashboard, []int64{1, 4, 4001}))
+	})
+}
+
+func TestStripHiddenPanels(t *testing.T) {
+	dashboard := panelScopeDashboard(t)
+	stripHiddenPanels(&PublicDashboard{HiddenPanelIds: []int64{4, 4001}}, dashboard.Data)
+
+	panels := dashboard.Data.Get("panels")
+	require.Len(t, panels.MustArray(), 2)
+	assert.Equal(t, int64(1), panels.GetIndex(0).Get("id").MustInt64())
+	assert.Equal(t, int64(2), panels.GetIndex(1).Get("id").MustInt64())
+	assert.Empty(t, panels.GetIndex(1).Get("panels").MustArray(), "panels of collapsed rows are removed too")
+}
+
+func TestSharedVariables(t *testing.T) {
+	dashboard := panelScopeDashboard(t)
+	service := &PublicDashboardServiceImpl{log: log.NewNopLogger(), cfg: setting.NewCfg()}
+
+	assert.Nil(t, service.sharedVariables(&PublicDashboard{}, dashboard))
+	// region is referenced by the panel and depends on env
+	assert.Equal(t, map[string]bool{"region": true, "env": true}, service.sharedVariables(&PublicDashboard{PanelId: 1}, dashboard))
+	// the panel and its row are repeated by host and cluster
+	assert.Equal(t, map[string]bool{"host": true, "cluster": true}, service.sharedVariables(&PublicDashboard{PanelId: 4}, dashboard))
+}
+
+func TestPanelShare(t *testing.T) {
+	setup := func(t *testing.T) *PublicDashboardServiceImpl {
+		dashboard := panelScopeDashboard(t)
+		pubdash := &PublicDashboard{Uid: "uid1", IsEnabled: true, OrgId: 1, DashboardUid: dashboard.UID, AnnotationsEnabled: true, PanelId: 1}
+		fakeStore := &FakePublicDashboardStore{}
+		fakeStore.On("FindByAccessToken", mock.Anything, mock.AnythingOfType("string")).Return(pubdash, nil)
+		fakeDashboardService := &dashboards.FakeDashboardService{}
+		fakeDashboardService.On("GetDashboard", mock.Anything, mock.Anything, mock.Anything).Return(dashboard, nil)
+
+		license := licensingtest.NewFakeLicensing()
+		license.On("FeatureEnabled", FeaturePublicDashboardsEmailSharing).Return(false)
+
+		return &PublicDashboardServiceImpl{
+			log:              log.NewNopLogger(),
+			cfg:              setting.NewCfg(),
+			store:            fakeStore,
+			dashboardService: fakeDashboardService,
+			license:          license,
+		}
+	}
+
+	t.Run("the metadata only lists the shared panel and its variables", func(t *testing.T) {
+		metadata, err := setup(t).GetMetadata(context.Background(), "abc123")
+		require.NoError(t, err)
+
+		assert.Equal(t, []PublicDashboardPanel{{Id: 1, Title: "Requests", Type: "timeseries"}}, metadata.Panels)
+		names := make([]string, 0, len(metadata.Variables))
+		for _, variable := range metadata.Variables {
+			names = append(names, variable.Name)
+		}
+		assert.Equal(t, []string{"env", "region"}, names)
+	})
+
+	t.Run("other panels can't be queried", func(t *testing.T) {
+		_, err := setup(t).GetQueryDataResponse(context.Background(), false, PublicDashboardQueryDTO{}, 4001, "abc123")
+		require.ErrorIs(t, err, ErrPanelNotFound)
+	})
+
+	t.Run("variables of other panels can't be queried", func(t *testing.T) {
+		_, err := setup(t).GetVariableQueryResponse(context.Background(), "abc123", "tenant", PublicDashboardVariableQueryDTO{})
+		require.ErrorIs(t, err, ErrVariableNotFound)
+	})
+
+	t.Run("annotations of other panels can't be queried", func(t *testing.T) {
+		_, err := setup(t).FindAnnotations(context.Background(), AnnotationsQueryDTO{PanelId: 4001}, "abc123")
+		require.ErrorIs(t, err, ErrPanelNotFound)
+	})
+}
+
+func TestHiddenPanels(t *testing.T) {
+	setup := func(t *testing.T) *PublicDashboardServiceImpl {
+		dashboard := panelScopeDashboard(t)
+		pubdash := &PublicDashboard{Uid: "uid1", IsEnabled: true, OrgId: 1, DashboardUid: dashboard.UID, AnnotationsEnabled: true, HiddenPanelIds: []int64{4}}
+		fakeStore := &FakePublicDashboardStore{}
+		fakeStore.On("FindByAccessToken", mock.Anything, mock.AnythingOfType("string")).Return(pubdash, nil)
+		fakeDashboardService := &dashboards.FakeDashboardService{}
+		fakeDashboardService.On("GetDashboard", mock.Anything, mock.Anything, mock.Anything).Return(dashboard, nil)
+
+		license := licensingtest.NewFakeLicensing()
+		license.On("FeatureEnabled", FeaturePublicDashboardsEmailSharing).Return(false)
+
+		return &PublicDashboardServiceImpl{
+			log:              log.NewNopLogger(),
+			cfg:              setting.NewCfg(),
+			store:            fakeStore,
+			dashboardService: fakeDashboardService,
+			license:          license,
+		}
+	}
+
+	t.Run("the metadata doesn't list hidden panels", func(t *testing.T) {
+		metadata, err := setup(t).GetMetadata(context.Background(), "abc123")
+		require.NoError(t, err)
+
+		assert.Equal(t, []PublicDashboardPanel{
+			{Id: 1, Title: "Requests", Type: "timeseries"},
+			{Id: 4001, Title: "Tenants", Type: "stat"},
+		}, metadata.Panels)
+	})
+
+	t.Run("hidden panels and their instances can't be queried", func(t *testing.T) {
+		for _, panelId := range []int64{4, 4002} {
+			_, err := setup(t).GetQueryDataResponse(context.Background(), false, PublicDashboardQueryDTO{}, panelId, "abc123")
+			require.ErrorIs(t, err, ErrPanelNotFound)
+		}
+	})
+
+	t.Run("annotations of hidden panels can't be queried", func(t *testing.T) {
+		_, err := setup(t).FindAnnotations(context.Background(), AnnotationsQueryDTO{PanelId: 4}, "abc123")
+		require.ErrorIs(t, err, ErrPanelNotFound)
+	})
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// patchColumns are the columns of the fields of the config of a public dashboard that can be patched
+var patchColumns = map[string]string{
+	"isEnabled":                "is_enabled",
+	"annotationsEnabled":       "annotations_enabled",
+	"timeSelectionEnabled":     "time_selection_enabled",
+	"share":                    "share",
+	"queryCachingMode":         "query_caching_mode",
+	"exportLocale":             "export_locale",
+	"geoRestriction":           "geo_restriction",
+	"annotationSettings":       "annotation_settings",
+	"allowedDomains":           "allowed_domains",
+	"variableConstraints":      "variable_constraints",
+	"variablesEnabled":         "variables_enabled",
+	"variableOverridesAllowed": "variable_overrides_allowed",
+	"pinnedVariables":          "pinned_variables",
+	"variableDefaults":         "variable_defaults",
+	"slug":                     "slug",
+	"expiresAt":                "expires_at",
+	"panelId":                  "panel_id",
+	"maxConcurrentViewers":     "max_concurrent_viewers",
+	"hiddenPanelIds":           "hidden_panel_ids",
+}
+
+// Patch updates the fields of the patch of an existing public dashboard. Unlike Update, the other fields aren't
+// written, so concurrent patches of different fields don't overwrite each other. The variable snapshot isn't refreshed
+func (pd *PublicDashboardServiceImpl) Patch(ctx context.Context, u *user.SignedInUser, uid string, dashboardUid string, patch PublicDashboardPatch) (*PublicDashboard, error) {
+	ctx, span := tracer.Start(ctx, "publicdashboards.Patch")
+	defer span.End()
+
+	if len(patch.Fields) == 0 || patch.PublicDashboard == nil {
+		return nil, ErrInvalidPatch.Errorf("Patch: no fields to patch")
+	}
+
+	columns := make([]string, 0, len(patch.Fields))
+	for _, field := range patch.Fields {
+		column, ok := patchColumns[field]
+		if !ok {
+			return nil, ErrInvalidPatch.Errorf("Patch: field %s can't be patched", field)
+		}
+		columns = append(columns, column)
+	}
+
+	err := validation.ValidatePublicDashboard(&SavePublicDashboardDTO{PublicDashboard: patch.PublicDashboard})
+	if err != nil {
+		return nil, err
+	}
+
+	existingPubdash, err := pd.store.Find(ctx, uid)
+	if err != nil {
+		return nil, ErrInternalServerError.Errorf("Patch: failed to find public dashboard by uid: %s: %w", uid, err)
+	} else if existingPubdash == nil {
+		return nil, ErrPublicDashboardNotFound.Errorf("Patch: public dashboard not found by uid: %s", uid)
+	}
+
+	// validate the public dashboard belongs to the dashboard
+	if existingPubdash.DashboardUid != dashboardUid {
+		return nil, ErrInvalidUid.Errorf("Patch: the public dashboard does not belong to the dashboard")
+	}
+
+	// an expired public dashboard can only be enabled again with a new expiration time
+	enables := slices.Contains(patch.Fields, "isEnabled") && returnValueOrDefault(patch.PublicDashboard.IsEnabled, false)
+	if enables && !existingPubdash.IsEnabled && !slices.Contains(patch.Fields, "expiresAt") && existingPubdash.IsExpired(time.Now()) {
+		return nil, ErrInvalidExpiresAt.Errorf("Patch: the public dashboard expired, its expiration time must be changed to enable it")
+	}
+
+	patchesPanels := slices.Contains(patch.Fields, "panelId") || slices.Contains(patch.Fields, "hiddenPanelIds") ||
+		slices.Contains(patch.Fields, "annotationSettings")
+	if patchesPanels || (enables && hasFolderRestrictions(pd.cfg)) {
+		dashboard, err := pd.FindDashboard(ctx, existingPubdash.OrgId, dashboardUid)
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(patch.Fields, "panelId") {
+			if err := validateSharedPanel(dashboard, patch.PublicDashboard.PanelId); err != nil {
+				return nil, err
+			}
+		}
+		if slices.Contains(patch.Fields, "hiddenPanelIds") {
+			if err := validateHiddenPanels(dashboard, patch.PublicDashboard.HiddenPanelIds); err != nil {
+				return nil, err
+			}
+		}
+		if slices.Contains(patch.Fields, "annotationSettings") {
+			if err := validateAnnotationSettings(dashboard, patch.PublicDashboard.AnnotationSettings); err != nil {
+				return nil, err
+			}
+		}
+		// the dashboard may have been moved to a forbidden folder since the public dashboard was created
+		if enables {
+			if err := pd.checkFolderShareable(ctx, dashboard); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	patched := newPatchPublicDashboard(uid, u.UserID, patch.PublicDashboard)
+	if err := pd.checkLoosenedConstraints(ctx, u, dashboardUid, existingPubdash, applyPatch(existingPubdash, &patched, patch.Fields)); err != nil {
+		return nil, err
+	}
+
+	cmd := PatchPublicDashboardCommand{
+		Columns:         columns,
+		PublicDashboard: patched,
+	}
+
+	affectedRows, err := pd.store.Patch(ctx, cmd)
+	if errors.Is(err, ErrPublicDashboardSlugExists) {
+		return nil, err
+	} else if err != nil {
+		return nil, ErrInternalServerError.Errorf("Patch: failed to patch public dashboard: %w", err)
+	}
+
+	// 404 if deleted in the meantime
+	if affectedRows == 0 {
+		return nil, ErrPublicDashboardNotFound.Errorf("Patch: failed to patch public dashboard not found by uid: %s", uid)
+	}
+	pd.PublicDashboardChanged(uid)
+
+	newPubdash, err := pd.store.Find(ctx, uid)
+	if err != nil {
+		return nil, ErrInternalServerError.Errorf("Patch: failed to find public dashboard by uid: %s: %w", uid, err)
+	}
+
+	pd.logIsEnabledChanged(existingPubdash, newPubdash, u)
+	pd.invalidateLiveViewers(newPubdash)
+
+	return newPubdash, nil
+}
+
+// newPatchPublicDashboard returns the public dashboard with the values of the patch. Fields left out or set to null
+// get their default value, only the fields of the patch are written though
+func newPatchPublicDashboard(uid string, userId int64, dto *PublicDashboardDTO) PublicDashboard {
+	share := dto.Share
+	if share == "" {
+		share = PublicShareType
+	}
+
+	queryCachingMode := dto.QueryCachingMode
+	if queryCachingMode == "" {
+		queryCachingMode = QueryCachingModeNormal
+	}
+
+	var slug string
+	if dto.Slug != nil {
+		slug = *dto.Slug
+	}
+
+	var expiresAt time.Time
+	if dto.ExpiresAt != nil {
+		expiresAt = *dto.ExpiresAt
+	}
+
+	var panelId int64
+	if dto.PanelId != nil {
+		panelId = *dto.PanelId
+	}
+
+	var maxConcurrentViewers int64
+	if dto.MaxConcurrentViewers != nil {
+		maxConcurrentViewers = *dto.MaxConcurrentViewers
+	}
+
+	return PublicDashboard{
+		Uid:                      uid,
+		IsEnabled:                returnValueOrDefault(dto.IsEnabled, false),
+		AnnotationsEnabled:       returnValueOrDefault(dto.AnnotationsEnabled, false),
+		TimeSelectionEnabled:     returnValueOrDefault(dto.TimeSelectionEnabled, false),
+		Share:                    share,
+		QueryCachingMode:         queryCachingMode,
+		ExportLocale:             dto.ExportLocale,
+		GeoRestriction:           normalizeGeoRestriction(dto.GeoRestriction),
+		AnnotationSettings:       normalizeAnnotationSettings(dto.AnnotationSettings),
+		AllowedDomains:           normalizeAllowedDomains(dto.AllowedDomains),
+		VariableConstraints:      dto.VariableConstraints,
+		VariablesEnabled:         util.Pointer(returnValueOrDefault(dto.VariablesEnabled, true)),
+		VariableOverridesAllowed: dto.VariableOverridesAllowed,
+		PinnedVariables:          dto.PinnedVariables,
+		VariableDefaults:         dto.VariableDefaults,
+		Slug:                     slug,
+		ExpiresAt:                expiresAt,
+		PanelId:                  panelId,
+		MaxConcurrentViewers:     maxConcurrentViewers,
+		HiddenPanelIds:           normalizeHiddenPanelIds(dto.HiddenPanelIds),
+		UpdatedBy:                userId,
+		UpdatedAt:                time.Now(),
+	}
+}
+
+// applyPatch returns the existing public dashboard with the constraint fields of the patch, as it is once patched
+func applyPatch(existing *PublicDashboard, patched *PublicDashboard, fields []string) *PublicDashboard {
+	result := *existing
+	for _, field := range fields {
+		switch field {
+		case "isEnabled":
+			result.IsEnabled = patched.IsEnabled
+		case "timeSelectionEnabled":
+			result.TimeSelectionEnabled = patched.TimeSelectionEnabled
+		case "annotationsEnabled":
+			result.AnnotationsEnabled = patched.AnnotationsEnabled
+		case "variablesEnabled":
+			result.VariablesEnabled = patched.VariablesEnabled
+		case "variableOverridesAllowed":
+			result.VariableOverridesAllowed = patched.VariableOverridesAllowed
+		case "expiresAt":
+			result.ExpiresAt = patched.ExpiresAt
+		}
+	}
+	return &result
+}
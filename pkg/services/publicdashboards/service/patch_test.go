@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/actest"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestPatch(t *testing.T) {
+	u := &user.SignedInUser{UserID: 1, Login: "admin"}
+	pubdash := &PublicDashboard{Uid: "uid1", DashboardUid: "dash1", OrgId: 1, IsEnabled: true, AccessToken: "abc123", Slug: "sales"}
+
+	setup := func(t *testing.T) (*PublicDashboardServiceImpl, *FakePublicDashboardStore) {
+		fakeStore := &FakePublicDashboardStore{}
+		return &PublicDashboardServiceImpl{
+			log:   log.NewNopLogger(),
+			cfg:   setting.NewCfg(),
+			store: fakeStore,
+			ac:    actest.FakeAccessControl{ExpectedEvaluate: true},
+		}, fakeStore
+	}
+
+	t.Run("Writes only the columns of the fields of the patch", func(t *testing.T) {
+		service, fakeStore := setup(t)
+		disabled := *pubdash
+		disabled.IsEnabled = false
+		fakeStore.On("Find", mock.Anything, "uid1").Return(pubdash, nil).Once()
+		fakeStore.On("Find", mock.Anything, "uid1").Return(&disabled, nil).Once()
+
+		var cmd PatchPublicDashboardCommand
+		fakeStore.On("Patch", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) { cmd = args.Get(1).(PatchPublicDashboardCommand) }).
+			Return(int64(1), nil)
+
+		isEnabled := false
+		result, err := service.Patch(context.Background(), u, "uid1", "dash1", PublicDashboardPatch{
+			Fields:          []string{"isEnabled"},
+			PublicDashboard: &PublicDashboardDTO{IsEnabled: &isEnabled},
+		})
+		require.NoError(t, err)
+
+		assert.False(t, result.IsEnabled)
+		assert.Equal(t, []string{"is_enabled"}, cmd.Columns)
+		assert.False(t, cmd.PublicDashboard.IsEnabled)
+		assert.Equal(t, "uid1", cmd.PublicDashboard.Uid)
+		assert.Equal(t, int64(1), cmd.PublicDashboard.UpdatedBy)
+	})
+
+	t.Run("Resets fields set to null to their default", func(t *testing.T) {
+		service, fakeStore := setup(t)
+		fakeStore.On("Find", mock.Anything, "uid1").Return(pubdash, nil)
+
+		var cmd PatchPublicDashboardCommand
+		fakeStore.On("Patch", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) { cmd = args.Get(1).(PatchPublicDashboardCommand) }).
+			Return(int64(1), nil)
+
+		_, err := service.Patch(context.Background(), u, "uid1", "dash1", PublicDashboardPatch{
+			Fields:          []string{"share", "slug"},
+			PublicDashboard: &PublicDashboardDTO{},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"share", "slug"}, cmd.Columns)
+		assert.Equal(t, PublicShareType, cmd.PublicDashboard.Share)
+		assert.Empty(t, cmd.PublicDashboard.Slug)
+	})
+
+	t.Run("Rejects fields that can't be patched", func(t *testing.T) {
+		service, fakeStore := setup(t)
+
+		_, err := service.Patch(context.Background(), u, "uid1", "dash1", PublicDashboardPatch{
+			Fields:          []string{"accessToken"},
+			PublicDashboard: &PublicDashboardDTO{},
+		})
+		assert.ErrorIs(t, err, ErrInvalidPatch)
+		fakeStore.AssertNotCalled(t, "Patch", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Rejects empty patches", func(t *testing.T) {
+		service, fakeStore := setup(t)
+
+		_, err := service.Patch(context.Background(), u, "uid1", "dash1", PublicDashboardPatch{PublicDashboard: &PublicDashboardDTO{}})
+		assert.ErrorIs(t, err, ErrInvalidPatch)
+		fakeStore.AssertNotCalled(t, "Patch", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Fails when the public dashboard belongs to another dashboard", func(t *testing.T) {
+		service, fakeStore := setup(t)
+		fakeStore.On("Find", mock.Anything, "uid1").Return(pubdash, nil)
+
+		isEnabled := false
+		_, err := service.Patch(context.Background(), u, "uid1", "dash2", PublicDashboardPatch{
+			Fields:          []string{"isEnabled"},
+			PublicDashboard: &PublicDashboardDTO{IsEnabled: &isEnabled},
+		})
+		assert.ErrorIs(t, err, ErrInvalidUid)
+		fakeStore.AssertNotCalled(t, "Patch", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Fails when the public dashboard was deleted in the meantime", func(t *testing.T) {
+		service, fakeStore := setup(t)
+		fakeStore.On("Find", mock.Anything, "uid1").Return(pubdash, nil)
+		fakeStore.On("Patch", mock.Anything, mock.Anything).Return(int64(0), nil)
+
+		isEnabled := false
+		_, err := service.Patch(context.Background(), u, "uid1", "dash1", PublicDashboardPatch{
+			Fields:          []string{"isEnabled"},
+			PublicDashboard: &PublicDashboardDTO{IsEnabled: &isEnabled},
+		})
+		assert.ErrorIs(t, err, ErrPublicDashboardNotFound)
+	})
+	t.Run("Requires the action of a loosened constraint", func(t *testing.T) {
+		service, fakeStore := setup(t)
+		service.ac = actest.FakeAccessControl{ExpectedEvaluate: false}
+		fakeStore.On("Find", mock.Anything, "uid1").Return(pubdash, nil)
+
+		timeSelectionEnabled := true
+		_, err := service.Patch(context.Background(), u, "uid1", "dash1", PublicDashboardPatch{
+			Fields:          []string{"timeSelectionEnabled"},
+			PublicDashboard: &PublicDashboardDTO{TimeSelectionEnabled: &timeSelectionEnabled},
+		})
+		assert.ErrorIs(t, err, ErrLoosenConstraintsForbidden)
+		fakeStore.AssertNotCalled(t, "Patch", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+// withPinnedValues returns the variable values with the values pinned by the owner of the public dashboard, whatever
+// the viewer sent. Pinned variables that are no longer defined in the dashboard are ignored
+func withPinnedValues(publicDashboard *models.PublicDashboard, dashboard *simplejson.Json, values map[string]interface{}) map[string]interface{} {
+	if len(publicDashboard.PinnedVariables) == 0 {
+		return values
+	}
+
+	pinned := make(map[string]interface{}, len(values)+len(publicDashboard.PinnedVariables))
+	for name, value := range values {
+		pinned[name] = value
+	}
+	for _, name := range dashboardVariableNames(dashboard) {
+		if value, ok := publicDashboard.PinnedVariables[name]; ok {
+			pinned[name] = value
+		}
+	}
+	return pinned
+}
+
+// pinnedOptions returns the value pinned by the owner as the only options of the variable
+func pinnedOptions(value interface{}) []models.MetricFindValue {
+	options := []models.MetricFindValue{}
+	for _, v := range variableValueStrings(value) {
+		options = append(options, models.MetricFindValue{Text: v, Value: v})
+	}
+	return options
+}
+
+// readOnlyVariables returns the variables viewers can't change in the order of the dashboard, either because they
+// are pinned or because they aren't in the allowlist of the public dashboard
+func readOnlyVariables(publicDashboard *models.PublicDashboard, dashboard *simplejson.Json) []string {
+	var readOnly []string
+	for _, name := range dashboardVariableNames(dashboard) {
+		if !publicDashboard.AllowsVariableOverride(name) {
+			readOnly = append(readOnly, name)
+		}
+	}
+	return readOnly
+}
+
+// pinDashboardVariables sets the pinned values as the current value and only option of their variable, so the
+// dashboard shows viewers the values its queries use
+func pinDashboardVariables(publicDashboard *models.PublicDashboard, dashboard *simplejson.Json) {
+	for _, v := range dashboard.GetPath("templating", "list").MustArray() {
+		variable, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := variable["name"].(string)
+		value, ok := publicDashboard.PinnedVariables[name]
+		if !ok {
+			continue
+		}
+
+		variable["current"] = map[string]interface{}{"text": value, "value": value, "selected": true}
+		options := make([]interface{}, 0)
+		for _, o := range pinnedOptions(value) {
+			options = append(options, map[string]interface{}{"text": o.Text, "value": o.Value, "selected": true})
+		}
+		variable["options"] = options
+	}
+}
+
+// dashboardVariableNames returns the names of the variables of the dashboard in the order of the dashboard
+func dashboardVariableNames(dashboard *simplejson.Json) []string {
+	var names []string
+	for _, v := range dashboardVariableList(dashboard) {
+		definition, _ := v.(map[string]interface{})
+		if name, _ := definition["name"].(string); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
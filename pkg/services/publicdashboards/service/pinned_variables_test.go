@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+const pinnedVariablesDashboard = `{
+	"templating": {
+		"list": [
+			{
+				"name": "tenant",
+				"type": "custom",
+				"query": "acme,globex",
+				"current": {"text": "globex", "value": "globex"}
+			},
+			{
+				"name": "env",
+				"type": "custom",
+				"query": "prod,stage",
+				"current": {"text": "prod", "value": "prod"}
+			},
+			{
+				"name": "server",
+				"type": "custom",
+				"query": "web-1,web-2",
+				"current": {"text": "web-1", "value": "web-1"}
+			}
+		]
+	}
+}`
+
+func TestWithPinnedValues(t *testing.T) {
+	dashboard, err := simplejson.NewJson([]byte(pinnedVariablesDashboard))
+	require.NoError(t, err)
+	pubdash := &PublicDashboard{PinnedVariables: PinnedVariables{"tenant": "acme", "deleted": "value"}}
+
+	t.Run("forces the pinned values", func(t *testing.T) {
+		values := withPinnedValues(pubdash, dashboard, map[string]interface{}{"tenant": "globex", "env": "stage"})
+		assert.Equal(t, map[string]interface{}{"tenant": "acme", "env": "stage"}, values)
+	})
+
+	t.Run("keeps the values without pinned variables", func(t *testing.T) {
+		values := map[string]interface{}{"env": "stage"}
+		assert.Equal(t, values, withPinnedValues(&PublicDashboard{}, dashboard, values))
+	})
+}
+
+func TestReadOnlyVariables(t *testing.T) {
+	dashboard, err := simplejson.NewJson([]byte(pinnedVariablesDashboard))
+	require.NoError(t, err)
+
+	assert.Empty(t, readOnlyVariables(&PublicDashboard{}, dashboard))
+	assert.Equal(t, []string{"tenant"}, readOnlyVariables(&PublicDashboard{PinnedVariables: PinnedVariables{"tenant": "acme"}}, dashboard))
+	assert.Equal(t, []string{"tenant", "server"}, readOnlyVariables(&PublicDashboard{
+		PinnedVariables:          PinnedVariables{"tenant": "acme"},
+		VariableOverridesAllowed: []string{"env"},
+	}, dashboard))
+}
+
+func TestPinDashboardVariables(t *testing.T) {
+	dashboard, err := simplejson.NewJson([]byte(pinnedVariablesDashboard))
+	require.NoError(t, err)
+
+	pinDashboardVariables(&PublicDashboard{PinnedVariables: PinnedVariables{"tenant": "acme", "server": []interface{}{"web-1", "web-2"}}}, dashboard)
+
+	tenant := dashboard.GetPath("templating", "list").GetIndex(0)
+	assert.Equal(t, "acme", tenant.GetPath("current", "value").MustString())
+	assert.Equal(t, []interface{}{map[string]interface{}{"text": "acme", "value": "acme", "selected": true}}, tenant.Get("options").MustArray())
+
+	server := dashboard.GetPath("templating", "list").GetIndex(2)
+	assert.Equal(t, []interface{}{"web-1", "web-2"}, server.GetPath("current", "value").MustArray())
+	assert.Len(t, server.Get("options").MustArray(), 2)
+
+	// other variables are untouched
+	assert.Equal(t, "prod", dashboard.GetPath("templating", "list").GetIndex(1).GetPath("current", "value").MustString())
+}
+
+func TestValidateVariablesWithPinnedValues(t *testing.T) {
+	dashboardData, err := simplejson.NewJson([]byte(pinnedVariablesDashboard))
+	require.NoError(t, err)
+	dashboard := &dashboards.Dashboard{UID: "dash1", OrgID: 1, Data: dashboardData}
+	service := &PublicDashboardServiceImpl{log: log.NewNopLogger()}
+
+	// the owner can pin values that aren't options of the variable
+	pubdash := &PublicDashboard{PinnedVariables: PinnedVariables{"tenant": "initech"}}
+	variables := withPinnedValues(pubdash, dashboardData, map[string]interface{}{"tenant": "globex", "env": "stage"})
+
+	assert.NoError(t, service.validateVariables(context.Background(), dashboard, pubdash, variables))
+	assert.ErrorIs(t, service.validateVariables(context.Background(), dashboard, pubdash, map[string]interface{}{"env": "dev"}), ErrInvalidVariableValue)
+}
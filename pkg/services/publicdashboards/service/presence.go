@@ -0,0 +1,120 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+const (
+	// viewerSessionTimeout is how long a viewer is considered present after its last heartbeat. Viewers send a
+	// heartbeat every 30 seconds, so a couple of missed heartbeats don't drop them
+	viewerSessionTimeout = 90 * time.Second
+	// maxViewerSessionsPerDashboard bounds the memory a single public dashboard can use. Heartbeats of new sessions
+	// above the limit are ignored
+	maxViewerSessionsPerDashboard = 10000
+)
+
+// presenceTracker keeps track of the anonymous viewers currently looking at public dashboards, keyed by access token.
+// State is kept in memory, so counts are per Grafana instance and reset on restart. A nil tracker doesn't track anything
+type presenceTracker struct {
+	mu         sync.Mutex
+	dashboards map[string]*dashboardPresence
+}
+
+type dashboardPresence struct {
+	sessions map[string]time.Time
+	peak     int
+	peakAt   time.Time
+}
+
+func newPresenceTracker() *presenceTracker {
+	return &presenceTracker{dashboards: map[string]*dashboardPresence{}}
+}
+
+// heartbeat marks the viewer session as present and updates the peak of concurrent viewers
+func (t *presenceTracker) heartbeat(accessToken string, sessionID string, now time.Time) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p, ok := t.dashboards[accessToken]
+	if !ok {
+		p = &dashboardPresence{sessions: map[string]time.Time{}}
+		t.dashboards[accessToken] = p
+	}
+	p.expire(now)
+
+	if _, ok := p.sessions[sessionID]; !ok && len(p.sessions) >= maxViewerSessionsPerDashboard {
+		return
+	}
+	p.sessions[sessionID] = now
+
+	if len(p.sessions) > p.peak {
+		p.peak = len(p.sessions)
+		p.peakAt = now
+	}
+}
+
+// stats returns the current and peak number of concurrent viewers of the public dashboard
+func (t *presenceTracker) stats(accessToken string, now time.Time) *models.ViewerStats {
+	if t == nil {
+		return &models.ViewerStats{}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p, ok := t.dashboards[accessToken]
+	if !ok {
+		return &models.ViewerStats{}
+	}
+	p.expire(now)
+
+	stats := &models.ViewerStats{CurrentViewers: len(p.sessions), PeakViewers: p.peak}
+	if !p.peakAt.IsZero() {
+		peakAt := p.peakAt
+		stats.PeakAt = &peakAt
+	}
+	return stats
+}
+
+// forget drops the presence of a public dashboard, used when it's deleted
+func (t *presenceTracker) forget(accessToken string) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.dashboards, accessToken)
+}
+
+// forgetMatching drops the presence of the public dashboards whose access token matches
+func (t *presenceTracker) forgetMatching(match func(accessToken string) bool) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for accessToken := range t.dashboards {
+		if match(accessToken) {
+			delete(t.dashboards, accessToken)
+		}
+	}
+}
+
+func (p *dashboardPresence) expire(now time.Time) {
+	for id, lastSeen := range p.sessions {
+		if now.Sub(lastSeen) > viewerSessionTimeout {
+			delete(p.sessions, id)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPresenceTracker(t *testing.T) {
+	now := time.Now()
+
+	t.Run("counts current and peak viewers", func(t *testing.T) {
+		tracker := newPresenceTracker()
+		tracker.heartbeat("token", "a", now)
+		tracker.heartbeat("token", "b", now)
+		tracker.heartbeat("token", "a", now.Add(30*time.Second))
+		tracker.heartbeat("other", "c", now)
+
+		stats := tracker.stats("token", now.Add(30*time.Second))
+		assert.Equal(t, 2, stats.CurrentViewers)
+		assert.Equal(t, 2, stats.PeakViewers)
+		require.NotNil(t, stats.PeakAt)
+		assert.Equal(t, now, *stats.PeakAt)
+	})
+
+	t.Run("expires sessions without heartbeats and keeps the peak", func(t *testing.T) {
+		tracker := newPresenceTracker()
+		tracker.heartbeat("token", "a", now)
+		tracker.heartbeat("token", "b", now)
+		tracker.heartbeat("token", "a", now.Add(time.Minute))
+
+		stats := tracker.stats("token", now.Add(2*time.Minute))
+		assert.Equal(t, 1, stats.CurrentViewers)
+		assert.Equal(t, 2, stats.PeakViewers)
+	})
+
+	t.Run("forgets deleted public dashboards", func(t *testing.T) {
+		tracker := newPresenceTracker()
+		tracker.heartbeat("token", "a", now)
+		tracker.forget("token")
+
+		stats := tracker.stats("token", now)
+		assert.Equal(t, 0, stats.CurrentViewers)
+		assert.Equal(t, 0, stats.PeakViewers)
+		assert.Nil(t, stats.PeakAt)
+	})
+
+	t.Run("nil tracker doesn't track anything", func(t *testing.T) {
+		var tracker *presenceTracker
+		tracker.heartbeat("token", "a", now)
+		assert.Equal(t, 0, tracker.stats("token", now).CurrentViewers)
+	})
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+// PublicDatasourceJSONDataKey is the jsonData field of a datasource holding the uid of the datasource used instead of
+// it by public dashboards. It allows queries of public dashboards to go to a read replica or to use a restricted
+// role, while the dashboard keeps using the main datasource for signed in users
+const PublicDatasourceJSONDataKey = "publicDashboardsDatasourceUid"
+
+// usePublicDatasources points the queries to the datasources configured for public traffic. Datasources without a
+// public datasource are left untouched. A misconfigured public datasource fails the query instead of falling back to
+// the main credentials
+func (pd *PublicDashboardServiceImpl) usePublicDatasources(ctx context.Context, orgID int64, queries []*simplejson.Json) error {
+	resolved := map[string]*datasources.DataSource{}
+
+	for _, query := range queries {
+		uid := getDataSourceUidFromJson(query)
+		if uid == "" || expr.IsDataSource(uid) {
+			continue
+		}
+
+		public, ok := resolved[uid]
+		if !ok {
+			var err error
+			public, err = pd.findPublicDatasource(ctx, orgID, uid)
+			if err != nil {
+				return err
+			}
+			resolved[uid] = public
+		}
+		if public == nil {
+			continue
+		}
+
+		if query.Get("datasource").Get("uid").MustString() != "" {
+			query.Get("datasource").Set("uid", public.UID)
+		} else {
+			query.Set("datasource", map[string]any{"uid": public.UID, "type": public.Type})
+		}
+	}
+
+	return nil
+}
+
+// findPublicDatasource returns the datasource configured for public traffic of the datasource with the given uid, or
+// nil when there is none
+func (pd *PublicDashboardServiceImpl) findPublicDatasource(ctx context.Context, orgID int64, uid string) (*datasources.DataSource, error) {
+	ds, err := pd.datasourceService.GetDataSource(ctx, &datasources.GetDataSourceQuery{UID: uid, OrgID: orgID})
+	if err != nil {
+		if errors.Is(err, datasources.ErrDataSourceNotFound) {
+			// let the query service report missing datasources like for any other query
+			return nil, nil
+		}
+		return nil, models.ErrInternalServerError.Errorf("findPublicDatasource: failed to get datasource %s: %w", uid, err)
+	}
+
+	publicUID := ""
+	if ds.JsonData != nil {
+		publicUID = ds.JsonData.Get(PublicDatasourceJSONDataKey).MustString()
+	}
+	if publicUID == "" || publicUID == ds.UID {
+		return nil, nil
+	}
+
+	public, err := pd.datasourceService.GetDataSource(ctx, &datasources.GetDataSourceQuery{UID: publicUID, OrgID: orgID})
+	if err != nil {
+		return nil, models.ErrPublicDatasourceMisconfigured.Errorf("findPublicDatasource: failed to get public datasource %s of datasource %s: %w", publicUID, uid, err)
+	}
+	if public.Type != ds.Type {
+		return nil, models.ErrPublicDatasourceMisconfigured.Errorf("findPublicDatasource: public datasource %s of datasource %s has type %s instead of %s", publicUID, uid, public.Type, ds.Type)
+	}
+
+	return public, nil
+}
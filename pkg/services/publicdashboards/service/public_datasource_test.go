@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	fakeDatasources "github.com/grafana/grafana/pkg/services/datasources/fakes"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+func TestUsePublicDatasources(t *testing.T) {
+	primary := &datasources.DataSource{UID: "primary", OrgID: 1, Type: "postgres",
+		JsonData: simplejson.NewFromAny(map[string]any{PublicDatasourceJSONDataKey: "replica"})}
+	replica := &datasources.DataSource{UID: "replica", OrgID: 1, Type: "postgres", JsonData: simplejson.New()}
+	other := &datasources.DataSource{UID: "other", OrgID: 1, Type: "prometheus", JsonData: simplejson.New()}
+	wrongType := &datasources.DataSource{UID: "wrong-type", OrgID: 1, Type: "mysql",
+		JsonData: simplejson.NewFromAny(map[string]any{PublicDatasourceJSONDataKey: "replica"})}
+	dangling := &datasources.DataSource{UID: "dangling", OrgID: 1, Type: "postgres",
+		JsonData: simplejson.NewFromAny(map[string]any{PublicDatasourceJSONDataKey: "deleted"})}
+
+	service := &PublicDashboardServiceImpl{
+		log:               log.NewNopLogger(),
+		datasourceService: &fakeDatasources.FakeDataSourceService{DataSources: []*datasources.DataSource{primary, replica, other, wrongType, dangling}},
+	}
+
+	query := func(uid string) *simplejson.Json {
+		return simplejson.NewFromAny(map[string]any{"refId": "A", "datasource": map[string]any{"uid": uid, "type": "postgres"}})
+	}
+
+	t.Run("uses the public datasource of datasources that have one", func(t *testing.T) {
+		queries := []*simplejson.Json{query("primary"), query("other"), query("__expr__"), query("missing")}
+
+		err := service.usePublicDatasources(context.Background(), 1, queries)
+		require.NoError(t, err)
+
+		assert.Equal(t, "replica", getDataSourceUidFromJson(queries[0]))
+		assert.Equal(t, "other", getDataSourceUidFromJson(queries[1]))
+		assert.Equal(t, "__expr__", getDataSourceUidFromJson(queries[2]))
+		assert.Equal(t, "missing", getDataSourceUidFromJson(queries[3]))
+	})
+
+	t.Run("fails when the public datasource doesn't exist", func(t *testing.T) {
+		err := service.usePublicDatasources(context.Background(), 1, []*simplejson.Json{query("dangling")})
+		assert.ErrorIs(t, err, ErrPublicDatasourceMisconfigured)
+	})
+
+	t.Run("fails when the public datasource has another type", func(t *testing.T) {
+		err := service.usePublicDatasources(context.Background(), 1, []*simplejson.Json{query("wrong-type")})
+		assert.ErrorIs(t, err, ErrPublicDatasourceMisconfigured)
+	})
+}
@@ -2,8 +2,9 @@ package service
 
 import (
 	"context"
-	"fmt"
-	"regexp"
+	"errors"
+	"math"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -32,6 +33,17 @@ func (pd *PublicDashboardServiceImpl) FindAnnotations(ctx context.Context, reqDT
 		return []models.AnnotationEvent{}, nil
 	}
 
+	// public dashboards limited to a panel only share the annotations of their panel, hidden panels have none
+	if reqDTO.PanelId != 0 && !sharesPanel(pub, dash, reqDTO.PanelId) {
+		return nil, models.ErrPanelNotFound.Errorf("FindAnnotations: panel %d is not shared", reqDTO.PanelId)
+	}
+	if pub.IsPanelShare() && reqDTO.PanelId == 0 {
+		reqDTO.PanelId = pub.PanelId
+	}
+	if reqDTO.PanelId != 0 && pub.AnnotationSettings.HidesPanel(reqDTO.PanelId) {
+		return []models.AnnotationEvent{}, nil
+	}
+
 	annoDto, err := UnmarshalDashboardAnnotations(dash.Data)
 	if err != nil {
 		return nil, models.ErrInternalServerError.Errorf("FindAnnotations: failed to unmarshal dashboard annotations: %w", err)
@@ -45,45 +57,43 @@ func (pd *PublicDashboardServiceImpl) FindAnnotations(ctx context.Context, reqDT
 		if !anno.Enable || (*anno.Datasource.Uid != grafanads.DatasourceUID && *anno.Datasource.Uid != grafanads.DatasourceName) {
 			continue
 		}
-		annoQuery := &annotations.ItemQuery{
-			From:         reqDTO.From,
-			To:           reqDTO.To,
-			OrgID:        dash.OrgID,
-			DashboardID:  dash.ID,
-			DashboardUID: dash.UID,
-			SignedInUser: svcIdent,
-		}
-
-		if anno.Target != nil {
-			annoQuery.Limit = anno.Target.Limit
-			annoQuery.MatchAny = anno.Target.MatchAny
-			if anno.Target.Type == "tags" {
-				annoQuery.DashboardID = 0 // nolint: staticcheck
-				annoQuery.DashboardUID = ""
-				annoQuery.Tags = anno.Target.Tags
-			}
+		// skip annotations filtered out of the panel
+		if reqDTO.PanelId != 0 && !annotationShownOnPanel(anno, reqDTO.PanelId) {
+			continue
+		}
+		// skip annotation queries the owner doesn't share
+		if pub.AnnotationSettings.HidesQuery(anno.Name) {
+			continue
 		}
+		annoQuery := buildAnnotationQuery(reqDTO, dash, anno, svcIdent)
 
-		annotationItems, err := pd.AnnotationsRepo.Find(svcCtx, annoQuery)
+		annotationItems, err := pd.findAnnotationItems(svcCtx, annoQuery, dash)
 		if err != nil {
 			return nil, models.ErrInternalServerError.Errorf("FindAnnotations: failed to find annotations: %w", err)
 		}
 
 		for _, item := range annotationItems {
 			event := models.AnnotationEvent{
-				Id:          item.ID,
-				DashboardId: item.DashboardID, // nolint: staticcheck
-				Tags:        item.Tags,
-				IsRegion:    item.TimeEnd > 0 && item.Time != item.TimeEnd,
-				Text:        item.Text,
-				Color:       anno.IconColor,
-				Time:        item.Time,
-				TimeEnd:     item.TimeEnd,
-				Source:      anno,
+				Id:       item.ID,
+				Tags:     item.Tags,
+				IsRegion: item.TimeEnd > 0 && item.Time != item.TimeEnd,
+				Text:     item.Text,
+				Color:    anno.IconColor,
+				Time:     item.Time,
+				TimeEnd:  item.TimeEnd,
+				Source:   anno,
 			}
 
 			if item.DashboardUID != nil {
 				event.DashboardUID = *item.DashboardUID
+			} else if annoQuery.DashboardUID != "" {
+				// items found through the legacy id fallback are not backfilled with a dashboard uid yet
+				event.DashboardUID = dash.UID
+			}
+
+			// kept for clients that still read the deprecated id
+			if event.DashboardUID != "" && event.DashboardUID == dash.UID {
+				event.DashboardId = dash.ID // nolint: staticcheck
 			}
 
 			// We want dashboard annotations to reference the panel they're for. If no panelId is provided, they'll show up on all panels
@@ -92,6 +102,15 @@ func (pd *PublicDashboardServiceImpl) FindAnnotations(ctx context.Context, reqDT
 				event.PanelId = item.PanelID
 			}
 
+			// annotations of other panels aren't shown on the panel, the ones without panel are shown on every panel
+			if reqDTO.PanelId != 0 && event.PanelId != 0 && event.PanelId != reqDTO.PanelId {
+				continue
+			}
+			// annotations of hidden panels, or of panels whose annotations are hidden, aren't shared
+			if event.PanelId != 0 && (pub.HidesPanel(event.PanelId) || pub.AnnotationSettings.HidesPanel(event.PanelId)) {
+				continue
+			}
+
 			// We want events from tag queries to overwrite existing events
 			_, has := uniqueEvents[event.Id]
 			if !has || (has && anno.Target != nil && anno.Target.Type == "tags") {
@@ -108,6 +127,64 @@ func (pd *PublicDashboardServiceImpl) FindAnnotations(ctx context.Context, reqDT
 	return results, nil
 }
 
+// annotationShownOnPanel returns whether the panel filter of the annotation source includes the panel
+func annotationShownOnPanel(anno models.DashAnnotation, panelId int64) bool {
+	if anno.Filter == nil {
+		return true
+	}
+
+	listed := false
+	for _, id := range anno.Filter.Ids {
+		if int64(id) == panelId {
+			listed = true
+			break
+		}
+	}
+
+	if anno.Filter.Exclude != nil && *anno.Filter.Exclude {
+		return !listed
+	}
+	return listed
+}
+
+// buildAnnotationQuery builds the annotation query for an annotation source of the dashboard. Dashboard annotations
+// are scoped by dashboard UID, tag queries are not scoped to a dashboard.
+func buildAnnotationQuery(reqDTO models.AnnotationsQueryDTO, dash *dashboards.Dashboard, anno models.DashAnnotation, user identity.Requester) *annotations.ItemQuery {
+	annoQuery := &annotations.ItemQuery{
+		From:         reqDTO.From,
+		To:           reqDTO.To,
+		OrgID:        dash.OrgID,
+		DashboardUID: dash.UID,
+		SignedInUser: user,
+	}
+
+	if anno.Target != nil {
+		annoQuery.Limit = anno.Target.Limit
+		annoQuery.MatchAny = anno.Target.MatchAny
+		if anno.Target.Type == "tags" {
+			annoQuery.DashboardUID = ""
+			annoQuery.Tags = anno.Target.Tags
+		}
+	}
+
+	return annoQuery
+}
+
+// findAnnotationItems finds the annotations for the query. Annotations stored before dashboard_uid was backfilled
+// (the migration can be skipped on startup) only reference the dashboard by id, so dashboard scoped queries
+// without results are retried with the deprecated id.
+func (pd *PublicDashboardServiceImpl) findAnnotationItems(ctx context.Context, query *annotations.ItemQuery, dash *dashboards.Dashboard) ([]*annotations.ItemDTO, error) {
+	items, err := pd.findAnnotationsForViewer(ctx, query)
+	if err != nil || len(items) > 0 || query.DashboardUID == "" || dash.ID == 0 {
+		return items, err
+	}
+
+	legacyQuery := *query
+	legacyQuery.DashboardUID = ""
+	legacyQuery.DashboardID = dash.ID // nolint: staticcheck
+	return pd.findAnnotationsForViewer(ctx, &legacyQuery)
+}
+
 // GetMetricRequest returns a metric request for the given panel and query
 func (pd *PublicDashboardServiceImpl) GetMetricRequest(ctx context.Context, dashboard *dashboards.Dashboard, publicDashboard *models.PublicDashboard, panelId int64, queryDto models.PublicDashboardQueryDTO) (dtos.MetricRequest, error) {
 	err := validation.ValidateQueryPublicDashboardRequest(queryDto, publicDashboard)
@@ -138,252 +215,258 @@ func (pd *PublicDashboardServiceImpl) GetQueryDataResponse(ctx context.Context,
 	// Temp: Log received variables at Info level for debugging
 	pd.log.Info("GetQueryDataResponse: received variables", "variables", queryDto.Variables, "panelId", panelId)
 
-	// Apply template variable interpolation to dashboard if variables are provided
-	if queryDto.Variables != nil && len(queryDto.Variables) > 0 {
-		dashboard = pd.applyTemplateVariables(dashboard, queryDto.Variables)
-	}
+	ctx, done := pd.viewerQueries.track(ctx, publicDashboard.Uid)
+	defer done()
 
-	metricReq, err := pd.GetMetricRequest(ctx, dashboard, publicDashboard, panelId, queryDto)
+	res, _, err := pd.queryPanel(ctx, publicDashboard, dashboard, skipDSCache, &queryDto, panelId)
 	if err != nil {
+		if errors.Is(context.Cause(ctx), errViewerQueriesRevoked) {
+			return nil, models.ErrPublicDashboardNotFound.Errorf("GetQueryDataResponse: public dashboard %s was revoked", publicDashboard.Uid)
+		}
 		return nil, err
 	}
 
-	if len(metricReq.Queries) == 0 {
-		return nil, models.ErrPanelQueriesNotFound.Errorf("GetQueryDataResponse: failed to extract queries from panel")
+	pd.sanitizationPolicy(publicDashboard.OrgId).sanitizeResponse(res)
+	pd.bridgeLiveChannels(publicDashboard, res)
+
+	pd.variableUsage.record(accessToken, dashboard.Data, queryDto.Variables)
+	pd.usage.recordQuery(publicDashboard, time.Now())
+
+	return res, nil
+}
+
+// queryPanel runs the queries of a panel of a public dashboard the way its viewers run them. The variables of the
+// request are replaced with the values actually used. The metadata of the frames is left as returned by the
+// datasources
+func (pd *PublicDashboardServiceImpl) queryPanel(ctx context.Context, publicDashboard *models.PublicDashboard, dashboard *dashboards.Dashboard, skipDSCache bool, queryDto *models.PublicDashboardQueryDTO, panelId int64) (*backend.QueryDataResponse, dtos.MetricRequest, error) {
+	if !sharesPanel(publicDashboard, dashboard, panelId) {
+		return nil, dtos.MetricRequest{}, models.ErrPanelNotFound.Errorf("queryPanel: panel %d is not shared", panelId)
 	}
 
-	// We don't have a signed in user for public dashboards. We are using Grafana's Identity to query the datasource.
-	svcCtx, svcIdent := identity.WithServiceIdentity(ctx, dashboard.OrgID)
-	res, err := pd.QueryDataService.QueryData(svcCtx, svcIdent, skipDSCache, metricReq)
+	queryDto.Variables = overridableValues(publicDashboard, visibleValues(dashboard.Data, queryDto.Variables))
+	queryDto.AdhocFilters = overridableValues(publicDashboard, visibleValues(dashboard.Data, queryDto.AdhocFilters))
 
-	reqDatasources := metricReq.GetUniqueDatasourceTypes()
-	if err != nil {
-		LogQueryFailure(reqDatasources, pd.log, err)
-		return nil, err
+	if len(queryDto.Variables) > 0 {
+		masker := newDatasourceUidMasker(pd.cfg.SecretKey, publicDashboard)
+		masker.learn(dashboard.Data)
+		queryDto.Variables = masker.unmaskVariables(queryDto.Variables)
 	}
-	LogQuerySuccess(reqDatasources, pd.log)
 
-	sanitizeMetadataFromQueryData(res)
+	queryDto.Variables = withPinnedValues(publicDashboard, dashboard.Data, queryDto.Variables)
+	if len(queryDto.Variables) > 0 {
+		if err := pd.validateVariables(ctx, dashboard, publicDashboard, queryDto.Variables); err != nil {
+			return nil, dtos.MetricRequest{}, err
+		}
+	}
 
-	return res, nil
-}
+	if len(queryDto.AdhocFilters) > 0 {
+		if err := validateAdhocFilters(dashboard.Data, queryDto.AdhocFilters); err != nil {
+			return nil, dtos.MetricRequest{}, err
+		}
+	}
 
-// applyTemplateVariables applies template variable interpolation to dashboard data
-func (pd *PublicDashboardServiceImpl) applyTemplateVariables(dashboard *dashboards.Dashboard, variables map[string]interface{}) *dashboards.Dashboard {
-	// Create a proper deep copy of the dashboard data to avoid modifying the original
-	dashboardJSON, err := dashboard.Data.Encode()
-	if err != nil {
-		// If encoding fails (e.g., due to circular references), return original dashboard
-		pd.log.Warn("Failed to encode dashboard JSON for variable interpolation, using original", "error", err)
-		return dashboard
+	// Instances of repeated panels are queried with their synthetic id
+	dashboard = pd.expandRepeatedPanel(ctx, dashboard, publicDashboard, panelId, queryDto.Variables)
+
+	if err := pd.sanitizationPolicy(publicDashboard.OrgId).checkQuery(dashboard.Data, panelId, queryDto); err != nil {
+		return nil, dtos.MetricRequest{}, err
 	}
 
-	copiedData, err := simplejson.NewJson(dashboardJSON)
+	metricReq, err := pd.GetMetricRequest(ctx, dashboard, publicDashboard, panelId, *queryDto)
 	if err != nil {
-		// If JSON parsing fails, return original dashboard
-		pd.log.Warn("Failed to create deep copy of dashboard JSON, using original", "error", err)
-		return dashboard
+		return nil, dtos.MetricRequest{}, err
 	}
 
-	dashboardCopy := &dashboards.Dashboard{
-		ID:      dashboard.ID,
-		UID:     dashboard.UID,
-		Title:   dashboard.Title,
-		Data:    copiedData,
-		OrgID:   dashboard.OrgID,
-		Created: dashboard.Created,
-		Updated: dashboard.Updated,
+	if len(metricReq.Queries) == 0 {
+		return nil, dtos.MetricRequest{}, models.ErrPanelQueriesNotFound.Errorf("queryPanel: failed to extract queries from panel")
 	}
 
-	// Apply targeted variable substitution to preserve dashboard structure
-	pd.interpolateVariablesInDashboard(dashboardCopy.Data, variables)
+	// Variables are sent along with the queries as scopedVars, variables that are not provided use their saved value
+	ts := buildPanelTimeSettings(dashboard, *queryDto, publicDashboard, panelId)
+	metricReq.Queries = pd.applyTemplateVariables(ctx, dashboard, publicDashboard, panelId, metricReq.Queries, withTimeRangeVariables(queryDto.Variables, ts))
 
-	return dashboardCopy
-}
+	// Ad hoc filters are applied to the queries of their datasource, before these are swapped for public datasources
+	applyAdhocFilters(dashboard.Data, metricReq.Queries, newTemplateInterpolator(dashboard.Data, queryDto.Variables), queryDto.AdhocFilters)
 
-// interpolateVariablesInDashboard performs targeted template variable substitution
-// It only replaces variables in safe locations (queries, titles, etc.) while preserving panel structure
-func (pd *PublicDashboardServiceImpl) interpolateVariablesInDashboard(dashboard *simplejson.Json, variables map[string]interface{}) {
-	// Interpolate in dashboard title if it exists
-	if title := dashboard.Get("title"); title.Interface() != nil {
-		dashboard.Set("title", pd.interpolateVariables(title.MustString(), variables))
+	if err := pd.usePublicDatasources(ctx, dashboard.OrgID, metricReq.Queries); err != nil {
+		return nil, dtos.MetricRequest{}, err
 	}
 
-	// Interpolate in panels (preserving panel IDs and structure)
-	if panels := dashboard.Get("panels"); panels.Interface() != nil {
-		panelsArray := panels.MustArray()
-		for i, panelInterface := range panelsArray {
-			panel := simplejson.NewFromAny(panelInterface)
-			pd.interpolateVariablesInPanel(panel, variables)
-			panels.SetIndex(i, panel.Interface())
-		}
-	}
+	skipDSCache = resolveSkipDSCache(publicDashboard.QueryCachingMode, skipDSCache)
 
-	// For v2 schema (elements), interpolate in elements
-	if elements := dashboard.Get("elements"); elements.Interface() != nil {
-		elementsMap := elements.MustMap()
-		for elementId, elementInterface := range elementsMap {
-			element := simplejson.NewFromAny(elementInterface)
-			pd.interpolateVariablesInElementV2(element, variables)
-			elements.Set(elementId, element.Interface())
-		}
+	release, err := pd.queryLimiter.acquire(ctx)
+	if err != nil {
+		return nil, dtos.MetricRequest{}, err
 	}
-}
+	defer release()
 
-// interpolateVariablesInPanel interpolates variables within a single panel
-func (pd *PublicDashboardServiceImpl) interpolateVariablesInPanel(panel *simplejson.Json, variables map[string]interface{}) {
-	// Interpolate panel title (safe)
-	if title := panel.Get("title"); title.Interface() != nil {
-		panel.Set("title", pd.interpolateVariables(title.MustString(), variables))
-	}
+	// We don't have a signed in user for public dashboards. We are using Grafana's Identity to query the datasource.
+	svcCtx, svcIdent := identity.WithServiceIdentity(ctx, dashboard.OrgID)
+	res, err := pd.QueryDataService.QueryData(svcCtx, svcIdent, skipDSCache, metricReq)
 
-	// Interpolate panel description (safe)
-	if description := panel.Get("description"); description.Interface() != nil {
-		panel.Set("description", pd.interpolateVariables(description.MustString(), variables))
+	reqDatasources := metricReq.GetUniqueDatasourceTypes()
+	if err != nil {
+		LogQueryFailure(reqDatasources, pd.log, err)
+		return nil, dtos.MetricRequest{}, err
 	}
+	LogQuerySuccess(reqDatasources, pd.log)
 
-	// Interpolate panel-level datasource UID if present
-	// This is important because queries may inherit datasource from panel
-	if datasource := panel.Get("datasource"); datasource.Interface() != nil {
-		pd.log.Info("interpolateVariablesInPanel: found panel datasource", "datasource", datasource.Interface())
-		if uid := datasource.Get("uid"); uid.Interface() != nil {
-			if str, ok := uid.Interface().(string); ok {
-				interpolated := pd.interpolateVariables(str, variables)
-				pd.log.Info("interpolateVariablesInPanel: interpolating datasource UID", "original", str, "interpolated", interpolated, "variables", variables)
-				datasource.Set("uid", interpolated)
-			}
-		}
+	return res, metricReq, nil
+}
+
+// resolveSkipDSCache decides whether the query cache should be skipped based on the caching mode configured
+// for the public dashboard. In normal mode the decision sent along with the request is kept.
+func resolveSkipDSCache(mode models.QueryCachingMode, requested bool) bool {
+	switch mode {
+	case models.QueryCachingModeForce:
+		return false
+	case models.QueryCachingModeBypass:
+		return true
+	default:
+		return requested
 	}
+}
 
-	// Interpolate in targets/queries (safe)
-	if targets := panel.Get("targets"); targets.Interface() != nil {
-		targetsArray := targets.MustArray()
-		for i, targetInterface := range targetsArray {
-			target := simplejson.NewFromAny(targetInterface)
-			pd.interpolateVariablesInTarget(target, variables)
-			targets.SetIndex(i, target.Interface())
+// applyTemplateVariables returns copies of the queries of the panel with the resolved variables they reference set as
+// scopedVars the way the dashboard sends them, so datasources interpolate the queries themselves. Only the datasource
+// of the queries is resolved, as queries are routed by datasource uid, the queries and the dashboard are never rewritten.
+// Values of repeated panels take precedence over the dashboard variables
+func (pd *PublicDashboardServiceImpl) applyTemplateVariables(ctx context.Context, dashboard *dashboards.Dashboard, publicDashboard *models.PublicDashboard, panelId int64, queries []*simplejson.Json, variables map[string]interface{}) []*simplejson.Json {
+	result := make([]*simplejson.Json, 0, len(queries))
+	copies := make([]*simplejson.Json, 0, len(queries))
+	used := map[string]bool{}
+	for _, query := range queries {
+		encoded, err := query.Encode()
+		if err != nil {
+			pd.log.Warn("Failed to encode query for variable interpolation, using original", "refId", query.Get("refId").MustString(), "error", err)
+			result = append(result, query)
+			continue
+		}
+		queryCopy, err := simplejson.NewJson(encoded)
+		if err != nil {
+			pd.log.Warn("Failed to copy query for variable interpolation, using original", "refId", query.Get("refId").MustString(), "error", err)
+			result = append(result, query)
+			continue
 		}
-	}
 
-	// Interpolate in nested panels (for rows)
-	if panels := panel.Get("panels"); panels.Interface() != nil {
-		panelsArray := panels.MustArray()
-		for i, nestedPanelInterface := range panelsArray {
-			nestedPanel := simplejson.NewFromAny(nestedPanelInterface)
-			pd.interpolateVariablesInPanel(nestedPanel, variables)
-			panels.SetIndex(i, nestedPanel.Interface())
+		for _, match := range variableRegex.FindAllString(string(encoded), -1) {
+			used[parseVariableReference(match).name] = true
 		}
+		result = append(result, queryCopy)
+		copies = append(copies, queryCopy)
+	}
+	if len(copies) == 0 {
+		return result
 	}
-}
 
-// interpolateVariablesInElementV2 interpolates variables within a v2 schema element
-func (pd *PublicDashboardServiceImpl) interpolateVariablesInElementV2(element *simplejson.Json, variables map[string]interface{}) {
-	spec := element.Get("spec")
-	if spec.Interface() == nil {
-		return
+	var panelVars map[string]scopedVariable
+	if panel := findPanelById(dashboard.Data.Get("panels").MustArray(), panelId); panel != nil {
+		panelVars = scopedVariables(simplejson.NewFromAny(panel))
 	}
+	interpolator := pd.newVariableInterpolator(ctx, dashboard, publicDashboard, variables, "")
 
-	// Interpolate element-level datasource UID if present (for V2 schema)
-	// This handles cases where queries inherit datasource from the element
-	if datasource := spec.Get("datasource"); datasource.Interface() != nil {
-		if uid := datasource.Get("uid"); uid.Interface() != nil {
-			if str, ok := uid.Interface().(string); ok {
-				datasource.Set("uid", pd.interpolateVariables(str, variables))
-			}
-		}
-		// Also check for "name" field which is used in V2 schema
-		if name := datasource.Get("name"); name.Interface() != nil {
-			if str, ok := name.Interface().(string); ok {
-				datasource.Set("name", pd.interpolateVariables(str, variables))
-			}
+	// variables the panel doesn't use, like hidden variables, aren't sent to datasources
+	scopedVars := interpolator.scopedVars(panelVars)
+	for name := range scopedVars {
+		if !used[name] {
+			delete(scopedVars, name)
 		}
 	}
 
-	// Interpolate in data spec queries
-	data := spec.Get("data")
-	if data.Interface() != nil {
-		dataSpec := data.Get("spec")
-		if dataSpec.Interface() != nil {
-			if queries := dataSpec.Get("queries"); queries.Interface() != nil {
-				queriesArray := queries.MustArray()
-				for i, queryInterface := range queriesArray {
-					query := simplejson.NewFromAny(queryInterface)
-					pd.interpolateVariablesInTarget(query, variables)
-					queries.SetIndex(i, query.Interface())
-				}
-			}
+	for _, query := range copies {
+		if uid, ok := query.Get("datasource").Get("uid").Interface().(string); ok {
+			query.Get("datasource").Set("uid", interpolator.interpolate(uid, panelVars, ""))
 		}
+		query.Set("scopedVars", scopedVars)
 	}
+
+	return result
 }
 
+// nestedQueryFields are the query fields holding nested structures with variable references, like Elasticsearch
+// aggregations and metrics, Tempo filters and CloudWatch dimensions
+var nestedQueryFields = []string{"bucketAggs", "metrics", "filters", "dimensions"}
+
+// maxNestedQueryDepth bounds how deep nested query structures are walked
+const maxNestedQueryDepth = 5
+
 // interpolateVariablesInTarget interpolates variables within a query target
-func (pd *PublicDashboardServiceImpl) interpolateVariablesInTarget(target *simplejson.Json, variables map[string]interface{}) {
+func (pd *PublicDashboardServiceImpl) interpolateVariablesInTarget(target *simplejson.Json, interpolator *templateInterpolator, scopedVars map[string]scopedVariable, panelDatasourceType string) {
+	// Values are formatted the way the datasource of the query formats them in the browser, queries inheriting the
+	// datasource of their panel are formatted like the panel datasource
+	datasourceType := target.Get("datasource").Get("type").MustString()
+	if datasourceType == "" || datasourceType == inheritedDatasourceType {
+		datasourceType = panelDatasourceType
+	}
+
 	// Interpolate common query fields only to avoid infinite recursion
 	// Note: measurement is used by InfluxDB, metric by some other datasources
-	queryFields := []string{"expr", "query", "rawQuery", "select", "from", "where", "group", "alias", "legendFormat", "format", "interval", "step", "measurement", "metric", "table", "database"}
+	queryFields := []string{"expr", "query", "rawQuery", "rawSql", "select", "from", "where", "group", "alias", "legendFormat", "format", "interval", "step", "measurement", "metric", "table", "database"}
 
 	for _, field := range queryFields {
 		if value := target.Get(field); value.Interface() != nil {
 			if str, ok := value.Interface().(string); ok {
-				target.Set(field, pd.interpolateVariables(str, variables))
+				target.Set(field, interpolator.interpolateQuery(str, scopedVars, datasourceType))
 			}
 		}
 	}
 
-	// Handle specific nested structures without recursive calls to avoid infinite loops
-	if datasource := target.Get("datasource"); datasource.Interface() != nil {
-		if uid := datasource.Get("uid"); uid.Interface() != nil {
-			if str, ok := uid.Interface().(string); ok {
-				datasource.Set("uid", pd.interpolateVariables(str, variables))
+	// Any other field whose whole value is a single variable reference gets the variable value with its own type,
+	// so plugins expecting numbers, booleans or lists in their query model (e.g. a limit) don't receive strings
+	for field, value := range target.MustMap() {
+		if field == "refId" || field == "datasource" || slices.Contains(queryFields, field) {
+			continue
+		}
+		if str, ok := value.(string); ok {
+			if typedValue, ok := interpolator.typedValue(str, scopedVars); ok {
+				target.Set(field, typedValue)
 			}
 		}
 	}
-}
-
-// interpolateVariables performs basic template variable substitution on a string
-func (pd *PublicDashboardServiceImpl) interpolateVariables(text string, variables map[string]interface{}) string {
-	result := text
 
-	// Replace variables in ${variable} format
-	for varName, varValue := range variables {
-		if varValue == nil {
-			continue
+	// Nested query shapes are walked down to maxNestedQueryDepth
+	for _, field := range nestedQueryFields {
+		if value := target.Get(field).Interface(); value != nil {
+			target.Set(field, interpolateNestedQueryValue(value, 0, func(str string) string {
+				return interpolator.interpolateQuery(str, scopedVars, datasourceType)
+			}))
 		}
-
-		// Convert value to string
-		valueStr := pd.variableValueToString(varValue)
-
-		// Replace variable references
-		variablePattern := regexp.MustCompile(`\$\{` + regexp.QuoteMeta(varName) + `\}`)
-		result = variablePattern.ReplaceAllString(result, valueStr)
-
-		// Also handle $variable format (without braces)
-		simplePattern := regexp.MustCompile(`\$` + regexp.QuoteMeta(varName) + `\b`)
-		result = simplePattern.ReplaceAllString(result, valueStr)
 	}
 
-	return result
+	// Handle specific nested structures without recursive calls to avoid infinite loops
+	if datasource := target.Get("datasource"); datasource.Interface() != nil {
+		if uid := datasource.Get("uid"); uid.Interface() != nil {
+			if str, ok := uid.Interface().(string); ok {
+				datasource.Set("uid", interpolator.interpolate(str, scopedVars, ""))
+			}
+		}
+	}
 }
 
-// variableValueToString converts a variable value to its string representation
-func (pd *PublicDashboardServiceImpl) variableValueToString(varValue interface{}) string {
-	switch v := varValue.(type) {
+// interpolateNestedQueryValue interpolates the string values of a nested query structure. Structures deeper than
+// maxNestedQueryDepth are left as they are
+func interpolateNestedQueryValue(value interface{}, depth int, interpolate func(string) string) interface{} {
+	switch v := value.(type) {
 	case string:
+		return interpolate(v)
+	case map[string]interface{}:
+		if depth >= maxNestedQueryDepth {
+			return v
+		}
+		for key, child := range v {
+			v[key] = interpolateNestedQueryValue(child, depth+1, interpolate)
+		}
 		return v
 	case []interface{}:
-		// Handle multi-value variables
-		var values []string
-		for _, val := range v {
-			if str, ok := val.(string); ok {
-				values = append(values, str)
-			}
+		if depth >= maxNestedQueryDepth {
+			return v
 		}
-		if len(values) > 0 {
-			return strings.Join(values, ",")
+		for i, child := range v {
+			v[i] = interpolateNestedQueryValue(child, depth+1, interpolate)
 		}
-		return ""
+		return v
 	default:
-		return fmt.Sprintf("%v", v)
+		return v
 	}
 }
 
@@ -396,7 +479,7 @@ func (pd *PublicDashboardServiceImpl) buildMetricRequest(dashboard *dashboards.D
 	}
 
 	// group queries by panel
-	queriesByPanel := groupQueriesByPanelId(dashboard.Data)
+	queriesByPanel := groupQueriesByPanelId(dashboard.Data, publicDashboard.HiddenPanelIds)
 	queries, ok := queriesByPanel[panelID]
 	if !ok {
 		return dtos.MetricRequest{}, models.ErrPanelNotFound.Errorf("buildMetricRequest: public dashboard panel not found")
@@ -411,6 +494,7 @@ func (pd *PublicDashboardServiceImpl) buildMetricRequest(dashboard *dashboards.D
 		queries[i].Set("maxDataPoints", safeResolution)
 		queries[i].Set("queryCachingTTL", reqDTO.QueryCachingTTL)
 	}
+	pd.interpolateTimeMacros(queries, ts, safeInterval)
 
 	return dtos.MetricRequest{
 		From:    ts.From,
@@ -421,7 +505,7 @@ func (pd *PublicDashboardServiceImpl) buildMetricRequest(dashboard *dashboards.D
 
 func (pd *PublicDashboardServiceImpl) buildMetricRequestV2(dashboard *dashboards.Dashboard, publicDashboard *models.PublicDashboard, panelID int64, reqDTO models.PublicDashboardQueryDTO) (dtos.MetricRequest, error) {
 	// group queries by panel for V2
-	queriesByPanel := groupQueriesByPanelIdV2(dashboard.Data)
+	queriesByPanel := groupQueriesByPanelIdV2(dashboard.Data, publicDashboard.HiddenPanelIds)
 	queries, ok := queriesByPanel[panelID]
 	if !ok {
 		return dtos.MetricRequest{}, models.ErrPanelNotFound.Errorf("buildMetricRequestV2: public dashboard panel not found")
@@ -436,6 +520,7 @@ func (pd *PublicDashboardServiceImpl) buildMetricRequestV2(dashboard *dashboards
 		queries[i].Set("maxDataPoints", safeResolution)
 		queries[i].Set("queryCachingTTL", reqDTO.QueryCachingTTL)
 	}
+	pd.interpolateTimeMacros(queries, ts, safeInterval)
 
 	return dtos.MetricRequest{
 		From:    ts.From,
@@ -444,15 +529,74 @@ func (pd *PublicDashboardServiceImpl) buildMetricRequestV2(dashboard *dashboards
 	}, nil
 }
 
-func groupQueriesByPanelId(dashboard *simplejson.Json) map[int64][]*simplejson.Json {
+// interpolateTimeMacros replaces the built-in time range and interval variables in the queries, with the interval the
+// queries are sent with
+func (pd *PublicDashboardServiceImpl) interpolateTimeMacros(queries []*simplejson.Json, ts models.TimeSettings, intervalMs int64) {
+	interpolator := newTemplateInterpolator(nil, timeMacroVariables(ts, intervalMs))
+	for _, query := range queries {
+		pd.interpolateVariablesInTarget(query, interpolator, nil, "")
+	}
+}
+
+// timeMacroVariables returns the built-in variables the dashboard sets for every query from its time range and
+// interval. $__rate_interval is computed like the Prometheus datasource does with its default scrape interval
+func timeMacroVariables(ts models.TimeSettings, intervalMs int64) map[string]interface{} {
+	from, _ := strconv.ParseInt(ts.From, 10, 64)
+	to, _ := strconv.ParseInt(ts.To, 10, 64)
+	rangeMs := to - from
+	rangeS := int64(math.Round(float64(rangeMs) / 1000))
+
+	interval := time.Duration(intervalMs) * time.Millisecond
+	rateInterval := max(interval+defaultScrapeInterval, 4*defaultScrapeInterval)
+
+	return map[string]interface{}{
+		fromVariable:         ts.From,
+		toVariable:           ts.To,
+		"__interval":         formatMacroInterval(interval),
+		"__interval_ms":      intervalMs,
+		"__rate_interval":    formatMacroInterval(rateInterval),
+		"__rate_interval_ms": rateInterval.Milliseconds(),
+		"__range":            strconv.FormatInt(rangeS, 10) + "s",
+		"__range_s":          rangeS,
+		"__range_ms":         rangeMs,
+	}
+}
+
+// formatMacroInterval formats an interval the way query languages accept it, like 1m30s, without rounding it
+func formatMacroInterval(d time.Duration) string {
+	if d%time.Second != 0 {
+		return strconv.FormatInt(d.Milliseconds(), 10) + "ms"
+	}
+
+	var formatted string
+	for _, unit := range []struct {
+		suffix string
+		size   time.Duration
+	}{{"h", time.Hour}, {"m", time.Minute}, {"s", time.Second}} {
+		if n := d / unit.size; n > 0 {
+			formatted += strconv.FormatInt(int64(n), 10) + unit.suffix
+			d -= n * unit.size
+		}
+	}
+	if formatted == "" {
+		return "0s"
+	}
+	return formatted
+}
+
+// groupQueriesByPanelId returns the queries of the panels of the dashboard by panel id, without the hidden panels
+func groupQueriesByPanelId(dashboard *simplejson.Json, hiddenPanelIds []int64) map[int64][]*simplejson.Json {
 	result := make(map[int64][]*simplejson.Json)
 
 	extractQueriesFromPanels(dashboard.Get("panels").MustArray(), result)
+	deleteHiddenPanels(result, hiddenPanelIds)
 
 	return result
 }
 
-func groupQueriesByPanelIdV2(dashboard *simplejson.Json) map[int64][]*simplejson.Json {
+// groupQueriesByPanelIdV2 returns the queries of the elements of the v2 dashboard by panel id, without the hidden
+// panels
+func groupQueriesByPanelIdV2(dashboard *simplejson.Json, hiddenPanelIds []int64) map[int64][]*simplejson.Json {
 	result := make(map[int64][]*simplejson.Json)
 
 	elementsMap := dashboard.Get("elements").MustMap()
@@ -531,10 +675,20 @@ func groupQueriesByPanelIdV2(dashboard *simplejson.Json) map[int64][]*simplejson
 
 		result[element.Get("spec").Get("id").MustInt64()] = panelQueries
 	}
+	deleteHiddenPanels(result, hiddenPanelIds)
 
 	return result
 }
 
+func deleteHiddenPanels(queriesByPanel map[int64][]*simplejson.Json, hiddenPanelIds []int64) {
+	for _, panelId := range hiddenPanelIds {
+		delete(queriesByPanel, panelId)
+	}
+}
+
+// inheritedDatasourceType is the type set on queries without a datasource, which use the datasource of their panel
+const inheritedDatasourceType = "public-ds"
+
 func extractQueriesFromPanels(panels []any, result map[int64][]*simplejson.Json) {
 	for _, panelObj := range panels {
 		panel := simplejson.NewFromAny(panelObj)
@@ -565,7 +719,7 @@ func extractQueriesFromPanels(panels []any, result map[int64][]*simplejson.Json)
 			// if query target has no datasource, set it to have the datasource on the panel
 			if _, ok := query.CheckGet("datasource"); !ok {
 				uid := getDataSourceUidFromJson(panel)
-				datasource := map[string]any{"type": "public-ds", "uid": uid}
+				datasource := map[string]any{"type": inheritedDatasourceType, "uid": uid}
 				query.Set("datasource", datasource)
 			}
 			panelQueries = append(panelQueries, query)
@@ -657,37 +811,8 @@ func getDataSourceUidFromJsonSchemaV2(query *simplejson.Json) string {
 	return uid
 }
 
-func sanitizeMetadataFromQueryData(res *backend.QueryDataResponse) {
-	for k := range res.Responses {
-		frames := res.Responses[k].Frames
-		for i := range frames {
-			if frames[i].Meta != nil {
-				frames[i].Meta.ExecutedQueryString = ""
-			}
-		}
-	}
-}
-
-// sanitizeData removes the query expressions from the dashboard data
-func sanitizeData(data *simplejson.Json) {
-	for _, panelObj := range data.Get("panels").MustArray() {
-		panel := simplejson.NewFromAny(panelObj)
-
-		// if the panel is a row and it is collapsed, get the queries from the panels inside the row
-		if panel.Get("type").MustString() == "row" && panel.Get("collapsed").MustBool() {
-			// recursive call to get queries from panels inside a row
-			sanitizeData(panel)
-			continue
-		}
-
-		for _, targetObj := range panel.Get("targets").MustArray() {
-			target := simplejson.NewFromAny(targetObj)
-			target.Del("expr")
-			target.Del("query")
-			target.Del("rawSql")
-		}
-	}
-}
+// defaultScrapeInterval is the scrape interval Prometheus datasources use when none is configured
+const defaultScrapeInterval = 15 * time.Second
 
 // NewTimeRange declared to be able to stub this function in tests
 var NewTimeRange = gtime.NewTimeRange
@@ -714,6 +839,23 @@ func buildTimeSettings(d *dashboards.Dashboard, reqDTO models.PublicDashboardQue
 	}
 }
 
+// buildPanelTimeSettings builds the time settings of a panel for either schema version
+func buildPanelTimeSettings(d *dashboards.Dashboard, reqDTO models.PublicDashboardQueryDTO, pd *models.PublicDashboard, panelID int64) models.TimeSettings {
+	if d.Data.Get("elements").Interface() != nil {
+		return buildTimeSettingsV2(d, reqDTO, pd, panelID)
+	}
+	return buildTimeSettings(d, reqDTO, pd, panelID)
+}
+
+// panelTimeRange returns the time range and timezone of a panel as set in the dashboard or by the viewer, before
+// relative times are resolved
+func panelTimeRange(d *dashboards.Dashboard, reqDTO models.PublicDashboardQueryDTO, pd *models.PublicDashboard, panelID int64) (string, string, *time.Location) {
+	if d.Data.Get("elements").Interface() != nil {
+		return getTimeRangeValuesOrDefaultV2(d, reqDTO, pd.TimeSelectionEnabled, panelID)
+	}
+	return getTimeRangeValuesOrDefault(reqDTO, d, pd.TimeSelectionEnabled, panelID)
+}
+
 // buildTimeSettingsV2 builds time settings for V2 dashboards
 func buildTimeSettingsV2(d *dashboards.Dashboard, reqDTO models.PublicDashboardQueryDTO, pd *models.PublicDashboard, panelID int64) models.TimeSettings {
 	from, to, timezone := getTimeRangeValuesOrDefaultV2(d, reqDTO, pd.TimeSelectionEnabled, panelID)
@@ -742,7 +884,7 @@ func getTimeRangeValuesOrDefault(reqDTO models.PublicDashboardQueryDTO, d *dashb
 	to := d.Data.GetPath("time", "to").MustString()
 	dashboardTimezone := d.Data.GetPath("timezone").MustString()
 
-	panelRelativeTime := getPanelRelativeTimeRange(d.Data, panelID)
+	panelRelativeTime := getPanelRelativeTimeRange(d.Data, panelID, reqDTO.Variables)
 	if panelRelativeTime != "" {
 		from = panelRelativeTime
 	}
@@ -779,7 +921,7 @@ func getTimeRangeValuesOrDefaultV2(d *dashboards.Dashboard, reqDTO models.Public
 	dashboardTimezone := timeSettings.Get("timezone").MustString()
 
 	// Check for panel-specific time override in V2 structure
-	panelRelativeTime := getPanelRelativeTimeRangeV2(d.Data, panelID)
+	panelRelativeTime := getPanelRelativeTimeRangeV2(d.Data, panelID, reqDTO.Variables)
 	if panelRelativeTime != "" {
 		from = panelRelativeTime
 	}
@@ -807,19 +949,37 @@ func getTimeRangeValuesOrDefaultV2(d *dashboards.Dashboard, reqDTO models.Public
 	return from, to, timezone
 }
 
-func getPanelRelativeTimeRange(dashboard *simplejson.Json, panelID int64) string {
+// getPanelRelativeTimeRange returns the relative time of the panel, with its variables interpolated with the values
+// of the viewer. Variables must have been validated beforehand
+func getPanelRelativeTimeRange(dashboard *simplejson.Json, panelID int64, variables map[string]interface{}) string {
 	for _, panelObj := range dashboard.Get("panels").MustArray() {
 		panel := simplejson.NewFromAny(panelObj)
 
 		if panel.Get("id").MustInt64() == panelID {
-			return panel.Get("timeFrom").MustString()
+			return interpolateRelativeTime(dashboard, panel.Get("timeFrom").MustString(), scopedVariables(panel), variables)
 		}
 	}
 
 	return ""
 }
 
-func getPanelRelativeTimeRangeV2(dashboard *simplejson.Json, panelID int64) string {
+// interpolateRelativeTime replaces the variable references of a relative time like "$range". Values that still
+// reference variables once interpolated are ignored, so the dashboard time range is used instead
+func interpolateRelativeTime(dashboard *simplejson.Json, relativeTime string, scopedVars map[string]scopedVariable, variables map[string]interface{}) string {
+	if !strings.Contains(relativeTime, "$") {
+		return relativeTime
+	}
+
+	interpolated := strings.TrimSpace(newTemplateInterpolator(dashboard, variables).interpolate(relativeTime, scopedVars, rawVariableFormat))
+	if strings.Contains(interpolated, "$") {
+		return ""
+	}
+	return interpolated
+}
+
+// getPanelRelativeTimeRangeV2 returns the relative time of the panel of a V2 dashboard, with its variables
+// interpolated with the values of the viewer. Variables must have been validated beforehand
+func getPanelRelativeTimeRangeV2(dashboard *simplejson.Json, panelID int64, variables map[string]interface{}) string {
 	// In V2, check elements for panel-specific time settings
 	elements := dashboard.Get("elements")
 	if elements.Interface() == nil {
@@ -858,7 +1018,7 @@ func getPanelRelativeTimeRangeV2(dashboard *simplejson.Json, panelID int64) stri
 
 		timeFrom := queryOptions.Get("timeFrom")
 		if timeFrom.Interface() != nil {
-			return timeFrom.MustString()
+			return interpolateRelativeTime(dashboard, timeFrom.MustString(), nil, variables)
 		}
 
 		return ""
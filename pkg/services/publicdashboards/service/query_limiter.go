@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/publicdashboards/metric"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+const (
+	shedReasonQueueFull = "queue_full"
+	shedReasonTimeout   = "timeout"
+	shedReasonCanceled  = "canceled"
+)
+
+// queryLimiter bounds the number of public dashboard queries executed at the same time. Queries above the limit wait
+// in a bounded queue and are shed once the queue is full or they waited for too long. Queries of signed in users don't
+// go through the limiter, so when datasources or Grafana are saturated anonymous public traffic is delayed or dropped first.
+// A nil limiter doesn't limit anything.
+type queryLimiter struct {
+	slots   chan struct{}
+	queue   chan struct{}
+	timeout time.Duration
+}
+
+func newQueryLimiter(maxConcurrency int, maxQueueSize int, timeout time.Duration) *queryLimiter {
+	if maxConcurrency <= 0 {
+		return nil
+	}
+
+	if maxQueueSize < 0 {
+		maxQueueSize = 0
+	}
+
+	return &queryLimiter{
+		slots:   make(chan struct{}, maxConcurrency),
+		queue:   make(chan struct{}, maxQueueSize),
+		timeout: timeout,
+	}
+}
+
+// acquire reserves an execution slot for a query. The returned func releases the slot and has to be called once the
+// query finished
+func (l *queryLimiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return l.started(), nil
+	default:
+	}
+
+	select {
+	case l.queue <- struct{}{}:
+	default:
+		metric.QueriesShedTotal.WithLabelValues(shedReasonQueueFull).Inc()
+		return nil, models.ErrQueryShed.Errorf("acquire: query queue is full")
+	}
+
+	metric.QueryQueueDepth.Inc()
+	defer func() {
+		<-l.queue
+		metric.QueryQueueDepth.Dec()
+	}()
+
+	var timeout <-chan time.Time
+	if l.timeout > 0 {
+		timer := time.NewTimer(l.timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return l.started(), nil
+	case <-timeout:
+		metric.QueriesShedTotal.WithLabelValues(shedReasonTimeout).Inc()
+		return nil, models.ErrQueryShed.Errorf("acquire: timed out after %s waiting for a query slot", l.timeout)
+	case <-ctx.Done():
+		metric.QueriesShedTotal.WithLabelValues(shedReasonCanceled).Inc()
+		return nil, ctx.Err()
+	}
+}
+
+func (l *queryLimiter) started() func() {
+	metric.QueriesInFlight.Inc()
+	return func() {
+		metric.QueriesInFlight.Dec()
+		<-l.slots
+	}
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/publicdashboards/metric"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+func TestQueryLimiter(t *testing.T) {
+	t.Run("nil limiter does not limit queries", func(t *testing.T) {
+		limiter := newQueryLimiter(0, 10, time.Second)
+		require.Nil(t, limiter)
+
+		release, err := limiter.acquire(context.Background())
+		require.NoError(t, err)
+		release()
+	})
+
+	t.Run("queued query runs once a slot is released", func(t *testing.T) {
+		limiter := newQueryLimiter(1, 1, time.Second)
+
+		release, err := limiter.acquire(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, float64(1), testutil.ToFloat64(metric.QueriesInFlight))
+
+		acquired := make(chan error)
+		go func() {
+			release, err := limiter.acquire(context.Background())
+			if err == nil {
+				release()
+			}
+			acquired <- err
+		}()
+
+		require.Eventually(t, func() bool {
+			return testutil.ToFloat64(metric.QueryQueueDepth) == 1
+		}, time.Second, 10*time.Millisecond)
+
+		release()
+		require.NoError(t, <-acquired)
+		assert.Equal(t, float64(0), testutil.ToFloat64(metric.QueryQueueDepth))
+		assert.Equal(t, float64(0), testutil.ToFloat64(metric.QueriesInFlight))
+	})
+
+	t.Run("sheds queries when the queue is full", func(t *testing.T) {
+		limiter := newQueryLimiter(1, 0, time.Second)
+		shed := testutil.ToFloat64(metric.QueriesShedTotal.WithLabelValues(shedReasonQueueFull))
+
+		release, err := limiter.acquire(context.Background())
+		require.NoError(t, err)
+		defer release()
+
+		_, err = limiter.acquire(context.Background())
+		require.ErrorIs(t, err, ErrQueryShed)
+		assert.Equal(t, shed+1, testutil.ToFloat64(metric.QueriesShedTotal.WithLabelValues(shedReasonQueueFull)))
+	})
+
+	t.Run("sheds queries waiting longer than the timeout", func(t *testing.T) {
+		limiter := newQueryLimiter(1, 1, 10*time.Millisecond)
+		shed := testutil.ToFloat64(metric.QueriesShedTotal.WithLabelValues(shedReasonTimeout))
+
+		release, err := limiter.acquire(context.Background())
+		require.NoError(t, err)
+		defer release()
+
+		_, err = limiter.acquire(context.Background())
+		require.ErrorIs(t, err, ErrQueryShed)
+		assert.Equal(t, shed+1, testutil.ToFloat64(metric.QueriesShedTotal.WithLabelValues(shedReasonTimeout)))
+	})
+
+	t.Run("stops waiting when the request is canceled", func(t *testing.T) {
+		limiter := newQueryLimiter(1, 1, time.Minute)
+
+		release, err := limiter.acquire(context.Background())
+		require.NoError(t, err)
+		defer release()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err = limiter.acquire(ctx)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
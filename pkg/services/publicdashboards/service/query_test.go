@@ -449,7 +449,7 @@ func TestIntegrationFindAnnotations(t *testing.T) {
 
 		expected := AnnotationEvent{
 			Id:          1,
-			DashboardId: 1,
+			DashboardId: 0,
 			PanelId:     0,
 			Tags:        []string{"tag1"},
 			IsRegion:    false,
@@ -506,7 +506,7 @@ func TestIntegrationFindAnnotations(t *testing.T) {
 
 		expected := AnnotationEvent{
 			Id:          1,
-			DashboardId: 1,
+			DashboardId: 0,
 			PanelId:     0,
 			Tags:        []string{},
 			IsRegion:    true,
@@ -523,6 +523,8 @@ func TestIntegrationFindAnnotations(t *testing.T) {
 
 	t.Run("Test can get grafana annotations and will skip annotation queries and disabled annotations", func(t *testing.T) {
 		dash := dashboards.NewDashboard("test")
+		dash.ID = 1
+		dash.UID = "dash-uid"
 		disabledGrafanaAnnotation := DashAnnotation{
 			Datasource: CreateDatasource("grafana", "grafana"),
 			Enable:     false,
@@ -574,16 +576,17 @@ func TestIntegrationFindAnnotations(t *testing.T) {
 		items, err := service.FindAnnotations(context.Background(), AnnotationsQueryDTO{}, "abc123")
 
 		expected := AnnotationEvent{
-			Id:          1,
-			DashboardId: 1,
-			PanelId:     1,
-			Tags:        []string{},
-			IsRegion:    true,
-			Text:        "text",
-			Color:       color,
-			Time:        2,
-			TimeEnd:     1,
-			Source:      grafanaAnnotation,
+			Id:           1,
+			DashboardId:  1,
+			DashboardUID: "dash-uid",
+			PanelId:      1,
+			Tags:         []string{},
+			IsRegion:     true,
+			Text:         "text",
+			Color:        color,
+			Time:         2,
+			TimeEnd:      1,
+			Source:       grafanaAnnotation,
 		}
 		require.NoError(t, err)
 		assert.Len(t, items, 1)
@@ -669,6 +672,8 @@ func TestIntegrationFindAnnotations(t *testing.T) {
 
 	t.Run("Test find annotations does not panics when Target in datasource is nil", func(t *testing.T) {
 		dash := dashboards.NewDashboard("test")
+		dash.ID = 1
+		dash.UID = "dash-uid"
 		grafanaAnnotation := DashAnnotation{
 			Datasource: CreateDatasource("grafana", "grafana"),
 			Enable:     true,
@@ -705,21 +710,215 @@ func TestIntegrationFindAnnotations(t *testing.T) {
 		items, err := service.FindAnnotations(context.Background(), AnnotationsQueryDTO{}, "abc123")
 
 		expected := AnnotationEvent{
-			Id:          1,
-			DashboardId: 1,
-			PanelId:     1,
-			Tags:        []string{"tag1"},
-			IsRegion:    false,
-			Text:        "this is an annotation",
-			Color:       color,
-			Time:        2,
-			TimeEnd:     2,
-			Source:      grafanaAnnotation,
+			Id:           1,
+			DashboardId:  1,
+			DashboardUID: "dash-uid",
+			PanelId:      1,
+			Tags:         []string{"tag1"},
+			IsRegion:     false,
+			Text:         "this is an annotation",
+			Color:        color,
+			Time:         2,
+			TimeEnd:      2,
+			Source:       grafanaAnnotation,
 		}
 		require.NoError(t, err)
 		assert.Len(t, items, 1)
 		assert.Equal(t, expected, items[0])
 	})
+
+	t.Run("dashboard annotations are queried by uid and fall back to the dashboard id", func(t *testing.T) {
+		dash := dashboards.NewDashboard("test")
+		dash.ID = 1
+		dash.UID = "dash-uid"
+		grafanaAnnotation := DashAnnotation{
+			Datasource: CreateDatasource("grafana", "grafana"),
+			Enable:     true,
+			Name:       name,
+			IconColor:  color,
+			Type:       util.Pointer("dashboard"),
+		}
+		dashboard := AddAnnotationsToDashboard(t, dash, []DashAnnotation{grafanaAnnotation})
+		pubdash := &PublicDashboard{Uid: "uid1", IsEnabled: true, OrgId: 1, DashboardUid: dashboard.UID, AnnotationsEnabled: true}
+
+		fakeStore := &FakePublicDashboardStore{}
+		fakeStore.On("FindByAccessToken", mock.Anything, mock.AnythingOfType("string")).Return(pubdash, nil)
+		fakeDashboardService := &dashboards.FakeDashboardService{}
+		fakeDashboardService.On("GetDashboard", mock.Anything, mock.Anything, mock.Anything).Return(dashboard, nil)
+		annotationsRepo := &annotations.FakeAnnotationsRepo{}
+		annotationsRepo.On("Find", mock.Anything, mock.MatchedBy(func(query *annotations.ItemQuery) bool {
+			return query.DashboardUID == "dash-uid" && query.DashboardID == 0 // nolint: staticcheck
+		})).Return([]*annotations.ItemDTO{}, nil).Once()
+		annotationsRepo.On("Find", mock.Anything, mock.MatchedBy(func(query *annotations.ItemQuery) bool {
+			return query.DashboardUID == "" && query.DashboardID == 1 // nolint: staticcheck
+		})).Return([]*annotations.ItemDTO{{ID: 1, PanelID: 2, Time: 2, TimeEnd: 2, Text: "legacy"}}, nil).Once()
+
+		service, _, _ := newPublicDashboardServiceImpl(t, nil, nil, fakeStore, fakeDashboardService, annotationsRepo)
+
+		items, err := service.FindAnnotations(context.Background(), AnnotationsQueryDTO{}, "abc123")
+
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, "dash-uid", items[0].DashboardUID)
+		assert.Equal(t, int64(1), items[0].DashboardId)
+		assert.Equal(t, int64(2), items[0].PanelId)
+		annotationsRepo.AssertExpectations(t)
+	})
+
+	t.Run("only returns the annotations shown on the panel", func(t *testing.T) {
+		dash := dashboards.NewDashboard("test")
+		dash.ID = 1
+		dash.UID = "dash-uid"
+		dashboardAnnotation := DashAnnotation{
+			Datasource: CreateDatasource("grafana", "grafana"),
+			Enable:     true,
+			Name:       name,
+			IconColor:  color,
+			Type:       util.Pointer("dashboard"),
+		}
+		excludedAnnotation := DashAnnotation{
+			Datasource: CreateDatasource("grafana", "grafana"),
+			Enable:     true,
+			Name:       "excluded",
+			IconColor:  color,
+			Target:     &dashboard2.AnnotationTarget{Limit: 100, Tags: []string{"tag1"}, Type: "tags"},
+			Filter:     &dashboard2.AnnotationPanelFilter{Exclude: util.Pointer(true), Ids: []uint8{2}},
+		}
+		dashboard := AddAnnotationsToDashboard(t, dash, []DashAnnotation{dashboardAnnotation, excludedAnnotation})
+		pubdash := &PublicDashboard{Uid: "uid1", IsEnabled: true, OrgId: 1, DashboardUid: dashboard.UID, AnnotationsEnabled: true}
+
+		fakeStore := &FakePublicDashboardStore{}
+		fakeStore.On("FindByAccessToken", mock.Anything, mock.AnythingOfType("string")).Return(pubdash, nil)
+		fakeDashboardService := &dashboards.FakeDashboardService{}
+		fakeDashboardService.On("GetDashboard", mock.Anything, mock.Anything, mock.Anything).Return(dashboard, nil)
+		annotationsRepo := &annotations.FakeAnnotationsRepo{}
+		annotationsRepo.On("Find", mock.Anything, mock.MatchedBy(func(query *annotations.ItemQuery) bool {
+			return query.DashboardUID == "dash-uid"
+		})).Return([]*annotations.ItemDTO{
+			{ID: 1, DashboardUID: util.Pointer("dash-uid"), PanelID: 2, Time: 2, Text: "panel 2"},
+			{ID: 2, DashboardUID: util.Pointer("dash-uid"), PanelID: 3, Time: 2, Text: "panel 3"},
+			{ID: 3, DashboardUID: util.Pointer("dash-uid"), Time: 2, Text: "dashboard"},
+		}, nil).Once()
+
+		service, _, _ := newPublicDashboardServiceImpl(t, nil, nil, fakeStore, fakeDashboardService, annotationsRepo)
+
+		items, err := service.FindAnnotations(context.Background(), AnnotationsQueryDTO{PanelId: 2}, "abc123")
+
+		require.NoError(t, err)
+		texts := []string{}
+		for _, item := range items {
+			texts = append(texts, item.Text)
+		}
+		assert.ElementsMatch(t, []string{"panel 2", "dashboard"}, texts)
+		// the tag annotations are excluded from the panel, so they aren't queried
+		annotationsRepo.AssertExpectations(t)
+	})
+
+	t.Run("leaves out the annotations of hidden panels", func(t *testing.T) {
+		dash := dashboards.NewDashboard("test")
+		dash.ID = 1
+		dash.UID = "dash-uid"
+		dashboardAnnotation := DashAnnotation{
+			Datasource: CreateDatasource("grafana", "grafana"),
+			Enable:     true,
+			Name:       name,
+			IconColor:  color,
+			Type:       util.Pointer("dashboard"),
+		}
+		dashboard := AddAnnotationsToDashboard(t, dash, []DashAnnotation{dashboardAnnotation})
+		pubdash := &PublicDashboard{Uid: "uid1", IsEnabled: true, OrgId: 1, DashboardUid: dashboard.UID, AnnotationsEnabled: true, HiddenPanelIds: []int64{3}}
+
+		fakeStore := &FakePublicDashboardStore{}
+		fakeStore.On("FindByAccessToken", mock.Anything, mock.AnythingOfType("string")).Return(pubdash, nil)
+		fakeDashboardService := &dashboards.FakeDashboardService{}
+		fakeDashboardService.On("GetDashboard", mock.Anything, mock.Anything, mock.Anything).Return(dashboard, nil)
+		annotationsRepo := &annotations.FakeAnnotationsRepo{}
+		annotationsRepo.On("Find", mock.Anything, mock.Anything).Return([]*annotations.ItemDTO{
+			{ID: 1, DashboardUID: util.Pointer("dash-uid"), PanelID: 2, Time: 2, Text: "panel 2"},
+			{ID: 2, DashboardUID: util.Pointer("dash-uid"), PanelID: 3, Time: 2, Text: "panel 3"},
+			{ID: 3, DashboardUID: util.Pointer("dash-uid"), Time: 2, Text: "dashboard"},
+		}, nil).Once()
+
+		service, _, _ := newPublicDashboardServiceImpl(t, nil, nil, fakeStore, fakeDashboardService, annotationsRepo)
+
+		items, err := service.FindAnnotations(context.Background(), AnnotationsQueryDTO{}, "abc123")
+
+		require.NoError(t, err)
+		texts := []string{}
+		for _, item := range items {
+			texts = append(texts, item.Text)
+		}
+		assert.ElementsMatch(t, []string{"panel 2", "dashboard"}, texts)
+	})
+
+	t.Run("leaves out the hidden annotation queries and the annotations of panels whose annotations are hidden", func(t *testing.T) {
+		dash := dashboards.NewDashboard("test")
+		dash.ID = 1
+		dash.UID = "dash-uid"
+		deployments := DashAnnotation{
+			Datasource: CreateDatasource("grafana", "grafana"),
+			Enable:     true,
+			Name:       "Deployments",
+			IconColor:  color,
+			Type:       util.Pointer("dashboard"),
+		}
+		incidents := DashAnnotation{
+			Datasource: CreateDatasource("grafana", "grafana"),
+			Enable:     true,
+			Name:       "Incidents",
+			IconColor:  color,
+			Target:     &dashboard2.AnnotationTarget{Limit: 100, Tags: []string{"incident"}, Type: "tags"},
+		}
+		dashboard := AddAnnotationsToDashboard(t, dash, []DashAnnotation{deployments, incidents})
+		pubdash := &PublicDashboard{Uid: "uid1", IsEnabled: true, OrgId: 1, DashboardUid: dashboard.UID, AnnotationsEnabled: true,
+			AnnotationSettings: &AnnotationSettings{HiddenQueries: []string{"Incidents"}, HiddenPanelIds: []int64{3}}}
+
+		fakeStore := &FakePublicDashboardStore{}
+		fakeStore.On("FindByAccessToken", mock.Anything, mock.AnythingOfType("string")).Return(pubdash, nil)
+		fakeDashboardService := &dashboards.FakeDashboardService{}
+		fakeDashboardService.On("GetDashboard", mock.Anything, mock.Anything, mock.Anything).Return(dashboard, nil)
+		annotationsRepo := &annotations.FakeAnnotationsRepo{}
+		annotationsRepo.On("Find", mock.Anything, mock.MatchedBy(func(query *annotations.ItemQuery) bool {
+			return query.DashboardUID == "dash-uid"
+		})).Return([]*annotations.ItemDTO{
+			{ID: 1, DashboardUID: util.Pointer("dash-uid"), PanelID: 2, Time: 2, Text: "panel 2"},
+			{ID: 2, DashboardUID: util.Pointer("dash-uid"), PanelID: 3, Time: 2, Text: "panel 3"},
+		}, nil)
+
+		service, _, _ := newPublicDashboardServiceImpl(t, nil, nil, fakeStore, fakeDashboardService, annotationsRepo)
+
+		items, err := service.FindAnnotations(context.Background(), AnnotationsQueryDTO{}, "abc123")
+		require.NoError(t, err)
+		texts := []string{}
+		for _, item := range items {
+			texts = append(texts, item.Text)
+		}
+		assert.ElementsMatch(t, []string{"panel 2"}, texts)
+		// the hidden tag annotations aren't queried
+		annotationsRepo.AssertNumberOfCalls(t, "Find", 1)
+
+		items, err = service.FindAnnotations(context.Background(), AnnotationsQueryDTO{PanelId: 3}, "abc123")
+		require.NoError(t, err)
+		assert.Empty(t, items)
+	})
+}
+
+func TestAnnotationShownOnPanel(t *testing.T) {
+	t.Run("Shows annotations without filter on every panel", func(t *testing.T) {
+		assert.True(t, annotationShownOnPanel(DashAnnotation{}, 2))
+	})
+
+	t.Run("Shows annotations on the included panels", func(t *testing.T) {
+		anno := DashAnnotation{Filter: &dashboard2.AnnotationPanelFilter{Ids: []uint8{2, 4}}}
+		assert.True(t, annotationShownOnPanel(anno, 4))
+		assert.False(t, annotationShownOnPanel(anno, 3))
+	})
+
+	t.Run("Hides annotations on the excluded panels", func(t *testing.T) {
+		anno := DashAnnotation{Filter: &dashboard2.AnnotationPanelFilter{Exclude: util.Pointer(true), Ids: []uint8{2}}}
+		assert.False(t, annotationShownOnPanel(anno, 2))
+		assert.True(t, annotationShownOnPanel(anno, 3))
+	})
 }
 
 func TestIntegrationGetMetricRequest(t *testing.T) {
@@ -922,10 +1121,18 @@ func TestIntegrationBuildMetricRequest(t *testing.T) {
 }
 
 func TestGroupQueriesByPanelId(t *testing.T) {
+	t.Run("leaves out hidden panels", func(t *testing.T) {
+		json, err := simplejson.NewJson([]byte(oldStyleDashboard))
+		require.NoError(t, err)
+		require.Contains(t, groupQueriesByPanelId(json, nil), int64(2))
+
+		queries := groupQueriesByPanelId(json, []int64{2})
+		require.NotContains(t, queries, int64(2))
+	})
 	t.Run("can extract queries from dashboard with panel datasource string that has no datasource on panel targets", func(t *testing.T) {
 		json, err := simplejson.NewJson([]byte(oldStyleDashboard))
 		require.NoError(t, err)
-		queries := groupQueriesByPanelId(json)
+		queries := groupQueriesByPanelId(json, nil)
 
 		panelId := int64(2)
 		queriesByDatasource := groupQueriesByDataSource(t, queries[panelId])
@@ -934,7 +1141,7 @@ func TestGroupQueriesByPanelId(t *testing.T) {
 	t.Run("will delete exemplar property from target if exists", func(t *testing.T) {
 		json, err := simplejson.NewJson([]byte(dashboardWithQueriesExemplarEnabled))
 		require.NoError(t, err)
-		queries := groupQueriesByPanelId(json)
+		queries := groupQueriesByPanelId(json, nil)
 
 		panelId := int64(2)
 		queriesByDatasource := groupQueriesByDataSource(t, queries[panelId])
@@ -946,7 +1153,7 @@ func TestGroupQueriesByPanelId(t *testing.T) {
 	t.Run("can extract queries from dashboard with panel json datasource that has no datasource on panel targets", func(t *testing.T) {
 		json, err := simplejson.NewJson([]byte(dashboardWithTargetsWithNoDatasources))
 		require.NoError(t, err)
-		queries := groupQueriesByPanelId(json)
+		queries := groupQueriesByPanelId(json, nil)
 
 		panelId := int64(2)
 		queriesByDatasource := groupQueriesByDataSource(t, queries[panelId])
@@ -956,7 +1163,7 @@ func TestGroupQueriesByPanelId(t *testing.T) {
 		json, err := simplejson.NewJson([]byte(`{"panels": {}}`))
 		require.NoError(t, err)
 
-		queries := groupQueriesByPanelId(json)
+		queries := groupQueriesByPanelId(json, nil)
 		require.Len(t, queries, 0)
 	})
 
@@ -964,7 +1171,7 @@ func TestGroupQueriesByPanelId(t *testing.T) {
 		json, err := simplejson.NewJson([]byte(dashboardWithNoQueries))
 		require.NoError(t, err)
 
-		queries := groupQueriesByPanelId(json)
+		queries := groupQueriesByPanelId(json, nil)
 		require.Len(t, queries, 1)
 		require.Contains(t, queries, int64(2))
 		require.Len(t, queries[2], 0)
@@ -974,7 +1181,7 @@ func TestGroupQueriesByPanelId(t *testing.T) {
 		json, err := simplejson.NewJson([]byte(dashboardWithQueriesExemplarEnabled))
 		require.NoError(t, err)
 
-		queries := groupQueriesByPanelId(json)
+		queries := groupQueriesByPanelId(json, nil)
 		require.Len(t, queries, 1)
 		require.Contains(t, queries, int64(2))
 		require.Len(t, queries[2], 2)
@@ -1008,7 +1215,7 @@ func TestGroupQueriesByPanelId(t *testing.T) {
 		json, err := simplejson.NewJson([]byte(oldStyleDashboard))
 		require.NoError(t, err)
 
-		queries := groupQueriesByPanelId(json)
+		queries := groupQueriesByPanelId(json, nil)
 		require.Len(t, queries, 1)
 		require.Contains(t, queries, int64(2))
 		require.Len(t, queries[2], 1)
@@ -1029,7 +1236,7 @@ func TestGroupQueriesByPanelId(t *testing.T) {
 	t.Run("hidden queries in a panel with an expression not filtered", func(t *testing.T) {
 		json, err := simplejson.NewJson([]byte(dashboardWithOneHiddenQuery))
 		require.NoError(t, err)
-		queries := groupQueriesByPanelId(json)[2]
+		queries := groupQueriesByPanelId(json, nil)[2]
 
 		require.Len(t, queries, 3)
 	})
@@ -1037,7 +1244,7 @@ func TestGroupQueriesByPanelId(t *testing.T) {
 	t.Run("all hidden queries in a panel with an expression not filtered", func(t *testing.T) {
 		json, err := simplejson.NewJson([]byte(dashboardWithAllHiddenQueries))
 		require.NoError(t, err)
-		queries := groupQueriesByPanelId(json)[2]
+		queries := groupQueriesByPanelId(json, nil)[2]
 
 		require.Len(t, queries, 3)
 	})
@@ -1046,7 +1253,7 @@ func TestGroupQueriesByPanelId(t *testing.T) {
 		json, err := simplejson.NewJson([]byte(dashboardWithRowsAndOneHiddenQuery))
 		require.NoError(t, err)
 
-		queries := groupQueriesByPanelId(json)
+		queries := groupQueriesByPanelId(json, nil)
 		for idx := range queries {
 			assert.NotNil(t, queries[idx])
 		}
@@ -1058,7 +1265,7 @@ func TestGroupQueriesByPanelId(t *testing.T) {
 		json, err := simplejson.NewJson([]byte(dashboardWithRowsAndOneHiddenQuery))
 		require.NoError(t, err)
 
-		queries := groupQueriesByPanelId(json)
+		queries := groupQueriesByPanelId(json, nil)
 		var totalQueries int
 		for idx := range queries {
 			totalQueries += len(queries[idx])
@@ -1161,7 +1368,7 @@ func TestSanitizeMetadataFromQueryData(t *testing.T) {
 				},
 			},
 		}
-		sanitizeMetadataFromQueryData(fakeResponse)
+		stripExecutedQueryStringRule{}.sanitizeResponse(fakeResponse)
 		assert.Equal(t, fakeResponse.Responses["A"].Frames[0].Meta.ExecutedQueryString, "")
 		assert.Equal(t, fakeResponse.Responses["A"].Frames[0].Meta.Custom, map[string]string{"test1": "test1"})
 		assert.Equal(t, fakeResponse.Responses["A"].Frames[1].Meta.ExecutedQueryString, "")
@@ -1171,6 +1378,51 @@ func TestSanitizeMetadataFromQueryData(t *testing.T) {
 	})
 }
 
+func TestResolveSkipDSCache(t *testing.T) {
+	testCases := []struct {
+		name      string
+		mode      QueryCachingMode
+		requested bool
+		expected  bool
+	}{
+		{name: "normal mode keeps requested skip", mode: QueryCachingModeNormal, requested: true, expected: true},
+		{name: "normal mode keeps requested use", mode: QueryCachingModeNormal, requested: false, expected: false},
+		{name: "empty mode behaves like normal", mode: "", requested: true, expected: true},
+		{name: "force mode ignores requested skip", mode: QueryCachingModeForce, requested: true, expected: false},
+		{name: "bypass mode always skips", mode: QueryCachingModeBypass, requested: false, expected: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, resolveSkipDSCache(tc.mode, tc.requested))
+		})
+	}
+}
+
+func TestInterpolateTimeMacros(t *testing.T) {
+	service := &PublicDashboardServiceImpl{}
+	ts := TimeSettings{From: "1700000000000", To: "1700003600000"}
+
+	query := simplejson.NewFromAny(map[string]interface{}{
+		"refId":    "A",
+		"expr":     "rate(http_requests_total[$__rate_interval]) * $__range_s / ${__interval_ms}",
+		"interval": "$__interval",
+		"range":    "$__range",
+		"limit":    "$__range_ms",
+		"from":     "${__from:date:seconds}",
+	})
+	service.interpolateTimeMacros([]*simplejson.Json{query}, ts, 2000)
+
+	assert.Equal(t, "rate(http_requests_total[1m]) * 3600 / 2000", query.Get("expr").MustString())
+	assert.Equal(t, "2s", query.Get("interval").MustString())
+	assert.Equal(t, "3600s", query.Get("range").MustString())
+	assert.Equal(t, int64(3600000), query.Get("limit").Interface())
+	assert.Equal(t, "1700000000", query.Get("from").MustString())
+
+	// the rate interval covers at least one interval and scrape interval
+	assert.Equal(t, "5m15s", timeMacroVariables(ts, 300000)["__rate_interval"])
+}
+
 func TestBuildTimeSettings(t *testing.T) {
 	var defaultDashboardData = simplejson.NewFromAny(map[string]interface{}{
 		"time": map[string]interface{}{
@@ -1367,6 +1619,33 @@ func TestBuildTimeSettings(t *testing.T) {
 	}
 }
 
+func TestGetPanelRelativeTimeRange(t *testing.T) {
+	dashboard, err := simplejson.NewJson([]byte(`{
+		"templating": {"list": [{"name": "range", "type": "custom", "current": {"text": "now-6h", "value": "now-6h"}}]},
+		"panels": [
+			{"id": 1, "timeFrom": "$range"},
+			{"id": 2, "timeFrom": "now-1d/d"},
+			{"id": 3, "timeFrom": "$unknown"},
+			{"id": 4, "timeFrom": "$range", "scopedVars": {"range": {"text": "now-2d", "value": "now-2d"}}}
+		]
+	}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "now-6h", getPanelRelativeTimeRange(dashboard, 1, nil))
+	assert.Equal(t, "now-12h", getPanelRelativeTimeRange(dashboard, 1, map[string]interface{}{"range": "now-12h"}))
+	assert.Equal(t, "now-1d/d", getPanelRelativeTimeRange(dashboard, 2, map[string]interface{}{"range": "now-12h"}))
+	assert.Equal(t, "", getPanelRelativeTimeRange(dashboard, 3, nil))
+	assert.Equal(t, "now-2d", getPanelRelativeTimeRange(dashboard, 4, map[string]interface{}{"range": "now-12h"}))
+
+	dashboardV2, err := simplejson.NewJson([]byte(`{
+		"variables": [{"kind": "CustomVariable", "spec": {"name": "range", "current": {"text": "now-6h", "value": "now-6h"}}}],
+		"elements": {"panel-1": {"kind": "Panel", "spec": {"id": 1, "data": {"spec": {"queryOptions": {"timeFrom": "$range"}}}}}}
+	}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "now-12h", getPanelRelativeTimeRangeV2(dashboardV2, 1, map[string]interface{}{"range": "now-12h"}))
+}
+
 func groupQueriesByDataSource(t *testing.T, queries []*simplejson.Json) (result [][]*simplejson.Json) {
 	t.Helper()
 	byDataSource := make(map[string][]*simplejson.Json)
@@ -1400,3 +1679,39 @@ func buildJsonDataWithTimeRange(from, to, timezone string) *simplejson.Json {
 		"timezone": timezone,
 	})
 }
+
+func TestInterpolateVariablesInTargetNestedFields(t *testing.T) {
+	service := &PublicDashboardServiceImpl{}
+	interpolator := newTemplateInterpolator(nil, map[string]interface{}{"field": "host.name", "service": "api", "instance": "i-123"})
+
+	query := simplejson.NewFromAny(map[string]interface{}{
+		"refId":      "A",
+		"bucketAggs": []interface{}{map[string]interface{}{"type": "terms", "field": "$field", "settings": map[string]interface{}{"size": "10"}}},
+		"filters":    []interface{}{map[string]interface{}{"tag": "service.name", "operator": "=", "value": []interface{}{"${service}"}}},
+		"dimensions": map[string]interface{}{"InstanceId": "$instance"},
+		"deep":       map[string]interface{}{"a": "$service"},
+	})
+	service.interpolateVariablesInTarget(query, interpolator, nil, "")
+
+	assert.Equal(t, "host.name", query.Get("bucketAggs").GetIndex(0).Get("field").MustString())
+	assert.Equal(t, "10", query.Get("bucketAggs").GetIndex(0).GetPath("settings", "size").MustString())
+	assert.Equal(t, "api", query.Get("filters").GetIndex(0).Get("value").GetIndex(0).MustString())
+	assert.Equal(t, "i-123", query.GetPath("dimensions", "InstanceId").MustString())
+	// fields outside of the known shapes are left as they are
+	assert.Equal(t, "$service", query.GetPath("deep", "a").MustString())
+
+	t.Run("stops at the maximum depth", func(t *testing.T) {
+		var nested interface{} = "$service"
+		for i := 0; i <= maxNestedQueryDepth; i++ {
+			nested = []interface{}{nested}
+		}
+		query := simplejson.NewFromAny(map[string]interface{}{"refId": "A", "filters": nested})
+		service.interpolateVariablesInTarget(query, interpolator, nil, "")
+
+		leaf := query.Get("filters")
+		for i := 0; i < maxNestedQueryDepth; i++ {
+			leaf = leaf.GetIndex(0)
+		}
+		assert.Equal(t, []interface{}{"$service"}, leaf.MustArray())
+	})
+}
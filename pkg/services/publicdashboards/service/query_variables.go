@@ -3,9 +3,13 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
@@ -14,13 +18,19 @@ import (
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
 )
 
-// GetVariableQueryResponse returns the options for a template variable in a public dashboard
-func (pd *PublicDashboardServiceImpl) GetVariableQueryResponse(ctx context.Context, accessToken string, variableName string, reqDTO models.PublicDashboardVariableQueryDTO) ([]models.MetricFindValue, error) {
+// GetVariableQueryResponse returns the options for a template variable in a public dashboard, and when they were
+// queried for query variables
+func (pd *PublicDashboardServiceImpl) GetVariableQueryResponse(ctx context.Context, accessToken string, variableName string, reqDTO models.PublicDashboardVariableQueryDTO) (*models.PublicDashboardVariableQueryResponse, error) {
 	ctx, span := tracer.Start(ctx, "publicdashboards.GetVariableQueryResponse")
 	defer span.End()
 
+	if err := validation.ValidateVariableQueryRequest(reqDTO); err != nil {
+		return nil, err
+	}
+
 	// Find the public dashboard and dashboard by access token
 	publicDashboard, dashboard, err := pd.FindEnabledPublicDashboardAndDashboardByAccessToken(ctx, accessToken)
 	if err != nil {
@@ -32,19 +42,64 @@ func (pd *PublicDashboardServiceImpl) GetVariableQueryResponse(ctx context.Conte
 	if err != nil {
 		return nil, err
 	}
+	if variable.Hide == hiddenVariable {
+		return nil, models.ErrVariableNotFound.Errorf("GetVariableQueryResponse: variable '%s' not found", variableName)
+	}
+	if shared := pd.sharedVariables(publicDashboard, dashboard); shared != nil && !shared[variableName] {
+		return nil, models.ErrVariableNotFound.Errorf("GetVariableQueryResponse: variable '%s' not used by the shared panel", variableName)
+	}
+
+	// Datasource uids are sent to viewers as opaque identifiers
+	masker := newDatasourceUidMasker(pd.cfg.SecretKey, publicDashboard)
+	masker.learn(dashboard.Data)
+	reqDTO.Variables = masker.unmaskVariables(overridableValues(publicDashboard, visibleValues(dashboard.Data, reqDTO.Variables)))
+	reqDTO.Variables = withPinnedValues(publicDashboard, dashboard.Data, reqDTO.Variables)
+
+	// The options of the variable are resolved with the values of the other variables
+	others := make(map[string]interface{}, len(reqDTO.Variables))
+	for name, value := range reqDTO.Variables {
+		if name != variableName {
+			others[name] = value
+		}
+	}
+	if err := pd.validateVariables(ctx, dashboard, publicDashboard, others); err != nil {
+		return nil, err
+	}
 
-	// Get variable options based on variable type
-	options, err := pd.getVariableOptions(ctx, dashboard, publicDashboard, variable, reqDTO)
+	// Upstream variables the viewer didn't send are refreshed, so dependent variables get the options the dashboard
+	// would show
+	reqDTO.Variables, err = pd.resolveUpstreamVariables(ctx, dashboard, publicDashboard, variableName, others)
 	if err != nil {
 		return nil, err
 	}
 
+	// Get variable options based on variable type, pinned variables only have their pinned value
+	var options []models.MetricFindValue
+	var refreshedAt time.Time
+	if value, ok := publicDashboard.PinnedVariables[variableName]; ok {
+		options = pinnedOptions(value)
+	} else {
+		options, refreshedAt, err = pd.getVariableOptions(ctx, dashboard, publicDashboard, variable, reqDTO)
+		if err != nil {
+			return nil, err
+		}
+		options = withAllOption(variable, options)
+	}
+
+	if variable.Type == datasourceVariableType {
+		options = masker.maskOptions(options)
+	}
+
 	// Apply search filter if provided
 	if reqDTO.SearchFilter != "" {
 		options = filterVariableOptions(options, reqDTO.SearchFilter)
 	}
 
-	return options, nil
+	resp := &models.PublicDashboardVariableQueryResponse{Options: paginateVariableOptions(options, reqDTO.Limit, reqDTO.Page)}
+	if !refreshedAt.IsZero() {
+		resp.RefreshedAt = &refreshedAt
+	}
+	return resp, nil
 }
 
 // variableDefinition represents a template variable from the dashboard JSON
@@ -56,11 +111,19 @@ type variableDefinition struct {
 	Options    []variableOption       `json:"options"`
 	Current    variableCurrent        `json:"current"`
 	Multi      bool                   `json:"multi"`
+	IncludeAll bool                   `json:"includeAll"`
+	AllValue   string                 `json:"allValue"`
 	Refresh    int                    `json:"refresh"`
 	Regex      string                 `json:"regex"`
 	Sort       int                    `json:"sort"`
+	Auto       bool                   `json:"auto"`
+	Hide       int                    `json:"hide"`
 }
 
+// refreshOnTimeRangeChange is the refresh of variables whose options depend on the time range of the viewer, other
+// variables are queried over the time range the dashboard was shared with
+const refreshOnTimeRangeChange = 2
+
 type variableOption struct {
 	Text     interface{} `json:"text"`
 	Value    interface{} `json:"value"`
@@ -72,19 +135,22 @@ type variableCurrent struct {
 	Value interface{} `json:"value"`
 }
 
-// findVariableInDashboard finds a variable definition in the dashboard's templating.list
+// findVariableInDashboard finds a variable definition in the dashboard's templating.list, or in the variables of v2
+// dashboards
 func (pd *PublicDashboardServiceImpl) findVariableInDashboard(dashboard *dashboards.Dashboard, variableName string) (*variableDefinition, error) {
-	templating := dashboard.Data.Get("templating")
-	if templating.Interface() == nil {
-		return nil, models.ErrVariableNotFound.Errorf("findVariableInDashboard: no templating section found in dashboard")
-	}
+	if dashboard.Data.Get("elements").Interface() == nil {
+		templating := dashboard.Data.Get("templating")
+		if templating.Interface() == nil {
+			return nil, models.ErrVariableNotFound.Errorf("findVariableInDashboard: no templating section found in dashboard")
+		}
 
-	list := templating.Get("list")
-	if list.Interface() == nil {
-		return nil, models.ErrVariableNotFound.Errorf("findVariableInDashboard: no templating.list found in dashboard")
+		list := templating.Get("list")
+		if list.Interface() == nil {
+			return nil, models.ErrVariableNotFound.Errorf("findVariableInDashboard: no templating.list found in dashboard")
+		}
 	}
 
-	for _, varInterface := range list.MustArray() {
+	for _, varInterface := range dashboardVariableList(dashboard.Data) {
 		varJSON := simplejson.NewFromAny(varInterface)
 		name := varJSON.Get("name").MustString()
 		if name == variableName {
@@ -106,27 +172,30 @@ func (pd *PublicDashboardServiceImpl) findVariableInDashboard(dashboard *dashboa
 	return nil, models.ErrVariableNotFound.Errorf("findVariableInDashboard: variable '%s' not found", variableName)
 }
 
-// getVariableOptions returns options based on the variable type
-func (pd *PublicDashboardServiceImpl) getVariableOptions(ctx context.Context, dashboard *dashboards.Dashboard, publicDashboard *models.PublicDashboard, variable *variableDefinition, reqDTO models.PublicDashboardVariableQueryDTO) ([]models.MetricFindValue, error) {
+// getVariableOptions returns options based on the variable type, and when they were queried for query variables
+func (pd *PublicDashboardServiceImpl) getVariableOptions(ctx context.Context, dashboard *dashboards.Dashboard, publicDashboard *models.PublicDashboard, variable *variableDefinition, reqDTO models.PublicDashboardVariableQueryDTO) ([]models.MetricFindValue, time.Time, error) {
 	var options []models.MetricFindValue
+	var refreshedAt time.Time
 	var err error
 
 	switch variable.Type {
 	case "query":
-		options, err = pd.getQueryVariableOptions(ctx, dashboard, publicDashboard, variable, reqDTO)
+		options, refreshedAt, err = pd.getQueryVariableOptions(ctx, dashboard, publicDashboard, variable, reqDTO)
 	case "custom":
 		options, err = pd.getCustomVariableOptions(variable)
 	case "constant":
 		options, err = pd.getConstantVariableOptions(variable)
-	case "interval":
+	case intervalVariableType:
 		options, err = pd.getIntervalVariableOptions(variable)
+	case textboxVariableType:
+		options, err = pd.getTextboxVariableOptions(variable)
 	default:
 		// For unsupported types, return existing options if available
 		options, err = pd.getStaticVariableOptions(variable)
 	}
 
 	if err != nil {
-		return []models.MetricFindValue{}, err
+		return []models.MetricFindValue{}, time.Time{}, err
 	}
 
 	// If no options found, try to return the current value as a fallback
@@ -139,7 +208,19 @@ func (pd *PublicDashboardServiceImpl) getVariableOptions(ctx context.Context, da
 		options = []models.MetricFindValue{}
 	}
 
-	return options, nil
+	return options, refreshedAt, nil
+}
+
+// allOptionText is the text of the option selecting all the values of a variable
+const allOptionText = "All"
+
+// withAllOption adds the "All" option to the options of variables including it. Its value is always $__all, which is
+// interpolated as the allValue of the variable when it defines one, and as all the options otherwise
+func withAllOption(variable *variableDefinition, options []models.MetricFindValue) []models.MetricFindValue {
+	if !variable.IncludeAll || slices.ContainsFunc(options, func(o models.MetricFindValue) bool { return o.Value == allVariableValue }) {
+		return options
+	}
+	return append([]models.MetricFindValue{{Text: allOptionText, Value: allVariableValue}}, options...)
 }
 
 // getCurrentValueAsOption returns the variable's current value as a single option
@@ -183,8 +264,88 @@ func (pd *PublicDashboardServiceImpl) getCurrentValueAsOption(variable *variable
 	return options
 }
 
-// getQueryVariableOptions executes a datasource query to get variable options
-func (pd *PublicDashboardServiceImpl) getQueryVariableOptions(ctx context.Context, dashboard *dashboards.Dashboard, publicDashboard *models.PublicDashboard, variable *variableDefinition, reqDTO models.PublicDashboardVariableQueryDTO) ([]models.MetricFindValue, error) {
+// newVariableInterpolator returns the interpolator of the variable values where "All" expands to the full option set
+// of the variables, like in the dashboard after refreshing them. Saved dashboards only keep the options of query
+// variables from the last time they were refreshed, so these are queried again. Only the variables defined before the
+// given variable are expanded, or every variable when it's empty
+func (pd *PublicDashboardServiceImpl) newVariableInterpolator(ctx context.Context, dashboard *dashboards.Dashboard, publicDashboard *models.PublicDashboard, variables map[string]interface{}, before string) *templateInterpolator {
+	interpolator := newTemplateInterpolator(dashboard.Data, variables)
+
+	preceding := make(map[string]interface{}, len(variables))
+	for _, v := range dashboardVariableList(dashboard.Data) {
+		definition, _ := v.(map[string]interface{})
+		name, _ := definition["name"].(string)
+		if name == "" {
+			continue
+		}
+		if name == before {
+			break
+		}
+
+		variable, value, _ := interpolator.lookup(name)
+		if variable.IncludeAll && variable.AllValue == "" && isAllVariableValue(value) {
+			if values := pd.allVariableValues(ctx, dashboard, publicDashboard, name, preceding); len(values) > 0 {
+				interpolator.allOptions[name] = values
+			}
+		}
+
+		if value, ok := variables[name]; ok {
+			preceding[name] = value
+		}
+	}
+
+	return interpolator
+}
+
+// allVariableValues returns the values "All" expands to for query and custom variables, resolved with the values of
+// the variables defined before them. Other variables keep the options saved in the dashboard
+func (pd *PublicDashboardServiceImpl) allVariableValues(ctx context.Context, dashboard *dashboards.Dashboard, publicDashboard *models.PublicDashboard, name string, preceding map[string]interface{}) []interface{} {
+	variable, err := pd.findVariableInDashboard(dashboard, name)
+	if err != nil || variable.AllValue != "" {
+		// a custom all value is interpolated as is
+		return nil
+	}
+
+	var values []string
+	switch variable.Type {
+	case "query":
+		values, err = pd.queryVariableValues(ctx, dashboard, publicDashboard, variable, preceding)
+		if err != nil {
+			pd.log.Warn("allVariableValues: failed to query the options of the variable, using the saved options", "variable", name, "error", err)
+			return nil
+		}
+	case "custom":
+		options, _ := pd.getCustomVariableOptions(variable)
+		for _, o := range options {
+			values = append(values, o.Value)
+		}
+	}
+
+	allValues := make([]interface{}, len(values))
+	for i, v := range values {
+		allValues[i] = v
+	}
+	return allValues
+}
+
+// sqlDatasourceTypes are the datasources expanding SQL macros like $__timeFilter and $__timeGroup themselves
+var sqlDatasourceTypes = map[string]bool{
+	"mysql":                         true,
+	"mssql":                         true,
+	"postgres":                      true,
+	"grafana-postgresql-datasource": true,
+}
+
+// getQueryVariableOptions executes a datasource query to get variable options, and returns when the options were
+// queried. Variables refreshed on load are served from the snapshot of the public dashboard, as long as the variables
+// they reference have the value of the snapshot
+func (pd *PublicDashboardServiceImpl) getQueryVariableOptions(ctx context.Context, dashboard *dashboards.Dashboard, publicDashboard *models.PublicDashboard, variable *variableDefinition, reqDTO models.PublicDashboardVariableQueryDTO) ([]models.MetricFindValue, time.Time, error) {
+	if variable.Refresh != refreshOnTimeRangeChange {
+		if options, refreshedAt, ok := snapshotQueryOptions(publicDashboard, variable, reqDTO.Variables); ok {
+			return options, refreshedAt, nil
+		}
+	}
+
 	// Get the datasource UID from the variable definition
 	dsUID := ""
 	dsType := ""
@@ -202,7 +363,7 @@ func (pd *PublicDashboardServiceImpl) getQueryVariableOptions(ctx context.Contex
 	if dsUID == "" {
 		// If no datasource specified, return empty options
 		pd.log.Warn("getQueryVariableOptions: variable has no datasource", "variable", variable.Name)
-		return []models.MetricFindValue{}, nil
+		return []models.MetricFindValue{}, time.Time{}, nil
 	}
 
 	// Get the query from the variable - preserve the full query object for datasources that need it
@@ -224,9 +385,60 @@ func (pd *PublicDashboardServiceImpl) getQueryVariableOptions(ctx context.Contex
 
 	pd.log.Info("getQueryVariableOptions: extracted query", "variable", variable.Name, "queryStr", queryStr)
 
-	// Apply variable interpolation to the query if other variables are provided
-	if reqDTO.Variables != nil && queryStr != "" {
-		queryStr = pd.interpolateVariables(queryStr, reqDTO.Variables)
+	// Variable queries run over the time range of the dashboard, so macros like $__timeFilter are expanded by the
+	// datasource like for panel queries. Only variables refreshed when the time range changes follow the time range of
+	// the viewer, the others are refreshed on load and keep the options of the time range of the shared dashboard
+	timeRangeDTO := models.PublicDashboardQueryDTO{}
+	if variable.Refresh == refreshOnTimeRangeChange {
+		timeRangeDTO.TimeRange = reqDTO.TimeRange
+	}
+	ts := buildPanelTimeSettings(dashboard, timeRangeDTO, publicDashboard, 0)
+	from, to, timezone := panelTimeRange(dashboard, timeRangeDTO, publicDashboard, 0)
+
+	// Options are cached by the query interpolated with the time range as set in the dashboard, so relative time ranges
+	// reuse them until they expire
+	interpolator := pd.newVariableInterpolator(ctx, dashboard, publicDashboard, withTimeRangeVariables(reqDTO.Variables, models.TimeSettings{From: from, To: to}), variable.Name)
+	cacheQuery, err := json.Marshal([]interface{}{variable.Query, variable.Datasource})
+	if err != nil {
+		return nil, time.Time{}, models.ErrInternalServerError.Errorf("getQueryVariableOptions: failed to encode query of variable %s: %w", variable.Name, err)
+	}
+	cacheKey := strings.Join([]string{publicDashboard.AccessToken, variable.Name, from, to, timezone.String(), interpolator.interpolate(string(cacheQuery), nil, "")}, "/")
+
+	options, refreshedAt, ok := pd.variableOptions.get(cacheKey, dashboard.OrgID, time.Now())
+	if !ok {
+		interpolator.values = withTimeRangeVariables(reqDTO.Variables, ts)
+		refreshedAt = time.Now()
+		options, err = pd.runQueryVariable(ctx, dashboard, variable, interpolator, queryObj, queryStr, dsType, ts)
+		if errors.Is(err, errVariableQueryFailed) {
+			// failed queries aren't cached, the viewer gets the options of the snapshot until the query succeeds
+			return snapshotOptions(publicDashboard, variable.Name), publicDashboard.VariableSnapshot[variable.Name].RefreshedAt, nil
+		}
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		pd.variableOptions.set(cacheKey, dashboard.OrgID, options, refreshedAt)
+	}
+
+	if variable.Regex == "" {
+		return options, refreshedAt, nil
+	}
+
+	// The regex filters the results like in the dashboard, variables in the regex are escaped
+	regex, err := parseVariableRegex(interpolator.interpolate(variable.Regex, nil, "regex"))
+	if err != nil {
+		pd.log.Warn("getQueryVariableOptions: invalid regex", "variable", variable.Name, "error", err)
+		return []models.MetricFindValue{}, refreshedAt, nil
+	}
+	return regex.apply(options), refreshedAt, nil
+}
+
+// errVariableQueryFailed is returned by runQueryVariable when the datasource fails to run the query
+var errVariableQueryFailed = errors.New("variable query failed")
+
+// runQueryVariable runs the query of a query variable over the time range and extracts its options
+func (pd *PublicDashboardServiceImpl) runQueryVariable(ctx context.Context, dashboard *dashboards.Dashboard, variable *variableDefinition, interpolator *templateInterpolator, queryObj map[string]interface{}, queryStr string, dsType string, ts models.TimeSettings) ([]models.MetricFindValue, error) {
+	if queryStr != "" {
+		queryStr = interpolator.interpolate(queryStr, nil, "")
 		queryObj["query"] = queryStr
 	}
 
@@ -255,23 +467,45 @@ func (pd *PublicDashboardServiceImpl) getQueryVariableOptions(ctx context.Contex
 		queryData["rawSql"] = queryStr
 	}
 
+	// SQL datasources return the options as a table
+	if sqlDatasourceTypes[dsType] {
+		if _, ok := queryData["format"]; !ok {
+			queryData["format"] = "table"
+		}
+	}
+
 	// Build a metric request for the variable query
 	metricReq := dtos.MetricRequest{
-		From:    "now-1h",
-		To:      "now",
+		From:    ts.From,
+		To:      ts.To,
 		Queries: []*simplejson.Json{simplejson.NewFromAny(queryData)},
 	}
 
+	if err := pd.usePublicDatasources(ctx, dashboard.OrgID, metricReq.Queries); err != nil {
+		return nil, err
+	}
+
 	pd.log.Info("getQueryVariableOptions: executing query", "variable", variable.Name, "queryData", queryData)
 
+	release, err := pd.queryLimiter.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	// Use service identity to execute the query
 	svcCtx, svcIdent := identity.WithServiceIdentity(ctx, dashboard.OrgID)
 
+	// Datasources exposing variable support through their resource API answer the query there
+	if options, ok, err := pd.callVariableResource(svcCtx, svcIdent, dashboard.OrgID, dsType, metricReq.Queries[0], ts); ok {
+		return options, err
+	}
+
 	// Execute the query
 	res, err := pd.QueryDataService.QueryData(svcCtx, svcIdent, false, metricReq)
 	if err != nil {
 		pd.log.Error("getQueryVariableOptions: query failed", "error", err, "variable", variable.Name)
-		return []models.MetricFindValue{}, nil
+		return nil, errVariableQueryFailed
 	}
 
 	pd.log.Info("getQueryVariableOptions: query succeeded", "variable", variable.Name)
@@ -280,9 +514,12 @@ func (pd *PublicDashboardServiceImpl) getQueryVariableOptions(ctx context.Contex
 	return pd.extractOptionsFromQueryResponse(res)
 }
 
-// extractOptionsFromQueryResponse extracts MetricFindValue options from a query response
+// extractOptionsFromQueryResponse extracts MetricFindValue options from a query response. Like the variables of the
+// dashboard, __text and __value fields take precedence over other text and value fields, a missing text shows the
+// value, and options are deduplicated by value keeping the first text
 func (pd *PublicDashboardServiceImpl) extractOptionsFromQueryResponse(res *backend.QueryDataResponse) ([]models.MetricFindValue, error) {
 	options := make([]models.MetricFindValue, 0)
+	seen := map[string]bool{}
 
 	pd.log.Info("extractOptionsFromQueryResponse: processing response", "numResponses", len(res.Responses))
 
@@ -299,31 +536,7 @@ func (pd *PublicDashboardServiceImpl) extractOptionsFromQueryResponse(res *backe
 				continue
 			}
 
-			// Try to extract text/value from the frame fields
-			var textField, valueField *data.Field
-			for i, field := range frame.Fields {
-				if field == nil {
-					continue
-				}
-				name := strings.ToLower(field.Name)
-				if name == "text" || name == "__text" || name == "name" || name == "label" {
-					textField = frame.Fields[i]
-				}
-				if name == "value" || name == "__value" || name == "id" {
-					valueField = frame.Fields[i]
-				}
-			}
-
-			// If we couldn't find specific text/value fields, use the first field for both
-			if len(frame.Fields) > 0 && frame.Fields[0] != nil {
-				if textField == nil {
-					textField = frame.Fields[0]
-				}
-				if valueField == nil {
-					valueField = textField
-				}
-			}
-
+			textField, valueField := optionFields(frame)
 			if textField == nil {
 				continue
 			}
@@ -335,12 +548,17 @@ func (pd *PublicDashboardServiceImpl) extractOptionsFromQueryResponse(res *backe
 				if valueField != nil && i < valueField.Len() {
 					value = fieldValueToString(valueField.At(i))
 				}
-				if text != "" {
-					options = append(options, models.MetricFindValue{
-						Text:  text,
-						Value: value,
-					})
+				if text == "" {
+					text = value
 				}
+				if text == "" || seen[value] {
+					continue
+				}
+				seen[value] = true
+				options = append(options, models.MetricFindValue{
+					Text:  text,
+					Value: value,
+				})
 			}
 		}
 	}
@@ -348,6 +566,44 @@ func (pd *PublicDashboardServiceImpl) extractOptionsFromQueryResponse(res *backe
 	return options, nil
 }
 
+// optionFields returns the fields holding the text and the value of the options. __text and __value are preferred
+// over the other names, a frame with only one of them uses it for both, and a frame without any uses its first field
+func optionFields(frame *data.Frame) (*data.Field, *data.Field) {
+	var textField, valueField *data.Field
+	textRank, valueRank := 0, 0
+	for _, field := range frame.Fields {
+		if field == nil {
+			continue
+		}
+		switch strings.ToLower(field.Name) {
+		case "__text":
+			textField, textRank = field, 2
+		case "text", "name", "label":
+			if textRank < 2 {
+				textField, textRank = field, 1
+			}
+		case "__value":
+			valueField, valueRank = field, 2
+		case "value", "id":
+			if valueRank < 2 {
+				valueField, valueRank = field, 1
+			}
+		}
+	}
+
+	if textField == nil {
+		textField = valueField
+	}
+	// If we couldn't find specific text/value fields, use the first field for both
+	if textField == nil && len(frame.Fields) > 0 {
+		textField = frame.Fields[0]
+	}
+	if valueField == nil {
+		valueField = textField
+	}
+	return textField, valueField
+}
+
 // convertInterfaceToString converts a variable value (string or []interface{}) to string.
 // For arrays, returns the first element; for strings, returns as-is.
 func convertInterfaceToString(v interface{}) string {
@@ -383,6 +639,14 @@ func fieldValueToString(v interface{}) string {
 		v = rv.Elem().Interface()
 	}
 
+	// Numbers are formatted without exponent, so large ids keep their digits
+	switch n := v.(type) {
+	case float64:
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(n), 'f', -1, 32)
+	}
+
 	return fmt.Sprintf("%v", v)
 }
 
@@ -425,22 +689,45 @@ func (pd *PublicDashboardServiceImpl) getConstantVariableOptions(variable *varia
 	return pd.getCurrentValueAsOption(variable), nil
 }
 
+// getTextboxVariableOptions returns the saved value of a text box variable, or its default value stored in the query
+// field. Viewers type any other value, which is validated against the constraint of the variable
+func (pd *PublicDashboardServiceImpl) getTextboxVariableOptions(variable *variableDefinition) ([]models.MetricFindValue, error) {
+	if options := pd.getCurrentValueAsOption(variable); len(options) > 0 {
+		return options, nil
+	}
+
+	defaultValue := convertInterfaceToString(variable.Query)
+	if defaultValue == "" {
+		return []models.MetricFindValue{}, nil
+	}
+	return []models.MetricFindValue{{Text: defaultValue, Value: defaultValue}}, nil
+}
+
 // getIntervalVariableOptions returns pre-defined interval options
 func (pd *PublicDashboardServiceImpl) getIntervalVariableOptions(variable *variableDefinition) ([]models.MetricFindValue, error) {
+	var options []models.MetricFindValue
+
 	// Return the options from the variable definition if available
 	if len(variable.Options) > 0 {
-		return pd.getStaticVariableOptions(variable)
+		staticOptions, err := pd.getStaticVariableOptions(variable)
+		if err != nil {
+			return nil, err
+		}
+		options = staticOptions
+	} else {
+		// Default interval options
+		intervals := []string{"1m", "5m", "10m", "30m", "1h", "6h", "12h", "1d", "7d", "14d", "30d"}
+		for _, interval := range intervals {
+			options = append(options, models.MetricFindValue{
+				Text:  interval,
+				Value: interval,
+			})
+		}
 	}
 
-	// Default interval options
-	intervals := []string{"1m", "5m", "10m", "30m", "1h", "6h", "12h", "1d", "7d", "14d", "30d"}
-	var options []models.MetricFindValue
-
-	for _, interval := range intervals {
-		options = append(options, models.MetricFindValue{
-			Text:  interval,
-			Value: interval,
-		})
+	// The auto option is resolved to an interval computed from the time range when the variable is interpolated
+	if variable.Auto && !slices.ContainsFunc(options, func(o models.MetricFindValue) bool { return isAutoIntervalValue(variable.Name, o.Value) }) {
+		options = append([]models.MetricFindValue{{Text: autoIntervalText, Value: autoIntervalValue}}, options...)
 	}
 
 	return options, nil
@@ -471,6 +758,26 @@ func (pd *PublicDashboardServiceImpl) getStaticVariableOptions(variable *variabl
 	return options, nil
 }
 
+// paginateVariableOptions returns a page of options, pages start at 1 and a limit of 0 returns every option
+func paginateVariableOptions(options []models.MetricFindValue, limit int, page int) []models.MetricFindValue {
+	if options == nil {
+		options = []models.MetricFindValue{}
+	}
+	if limit <= 0 {
+		return options
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	start := (page - 1) * limit
+	if start >= len(options) {
+		return []models.MetricFindValue{}
+	}
+	end := min(start+limit, len(options))
+	return options[start:end]
+}
+
 // filterVariableOptions filters options by a search filter
 func filterVariableOptions(options []models.MetricFindValue, filter string) []models.MetricFindValue {
 	filter = strings.ToLower(filter)
@@ -1,12 +1,23 @@
 package service
 
 import (
+	"context"
 	"testing"
+	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	fakeDatasources "github.com/grafana/grafana/pkg/services/datasources/fakes"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/util"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
@@ -16,117 +27,95 @@ func TestApplyTemplateVariables(t *testing.T) {
 	}
 
 	testCases := []struct {
-		name           string
-		dashboardJSON  string
-		variables      map[string]interface{}
-		expectedResult string
+		name               string
+		dashboardJSON      string
+		variables          map[string]interface{}
+		expectedScopedVars map[string]interface{}
 	}{
 		{
-			name: "should replace simple variable in panel query",
+			name: "sends the variables referenced by the queries",
 			dashboardJSON: `{
 				"panels": [
 					{
 						"id": 1,
-						"targets": [
-							{
-								"expr": "up{instance=\"${server}\"}",
-								"refId": "A"
-							}
-						]
+						"targets": [{"expr": "rate($metric[${interval}])", "refId": "A"}]
 					}
-				]
-			}`,
-			variables: map[string]interface{}{
-				"server": "localhost:9090",
-			},
-			expectedResult: `up{instance="localhost:9090"}`,
-		},
-		{
-			name: "should replace multiple variable formats",
-			dashboardJSON: `{
-				"panels": [
-					{
-						"id": 1,
-						"targets": [
-							{
-								"expr": "rate($metric[${interval}])",
-								"refId": "A"
-							}
-						]
-					}
-				]
+				],
+				"templating": {
+					"list": [
+						{"name": "metric", "type": "custom", "current": {"text": "cpu_usage", "value": "cpu_usage"}},
+						{"name": "interval", "type": "custom", "current": {"text": "1m", "value": "1m"}}
+					]
+				}
 			}`,
 			variables: map[string]interface{}{
-				"metric":   "cpu_usage",
 				"interval": "5m",
 			},
-			expectedResult: `rate(cpu_usage[5m])`,
+			expectedScopedVars: map[string]interface{}{
+				"metric":   map[string]interface{}{"text": "cpu_usage", "value": "cpu_usage"},
+				"interval": map[string]interface{}{"text": "5m", "value": "5m"},
+			},
 		},
 		{
-			name: "should handle multi-value variables",
+			name: "sends multi-value variables as lists",
 			dashboardJSON: `{
 				"panels": [
 					{
 						"id": 1,
-						"targets": [
-							{
-								"expr": "up{instance=~\"${servers}\"}",
-								"refId": "A"
-							}
-						]
+						"datasource": {"type": "prometheus", "uid": "prom"},
+						"targets": [{"expr": "up{instance=~\"${servers}\"}", "refId": "A"}]
 					}
-				]
+				],
+				"templating": {
+					"list": [{"name": "servers", "type": "query", "multi": true}]
+				}
 			}`,
 			variables: map[string]interface{}{
-				"servers": []interface{}{"server1", "server2", "server3"},
+				"servers": []interface{}{"server1", "server2"},
+			},
+			expectedScopedVars: map[string]interface{}{
+				"servers": map[string]interface{}{"text": "server1 + server2", "value": []interface{}{"server1", "server2"}},
 			},
-			expectedResult: `up{instance=~"server1,server2,server3"}`,
 		},
 		{
-			name: "should not replace undefined variables",
+			name: "doesn't send the variables the panel doesn't use",
 			dashboardJSON: `{
 				"panels": [
 					{
 						"id": 1,
-						"targets": [
-							{
-								"expr": "up{instance=\"${undefined_var}\"}",
-								"refId": "A"
-							}
-						]
+						"targets": [{"expr": "up{instance=\"${server}\"}", "refId": "A"}]
 					}
-				]
+				],
+				"templating": {
+					"list": [
+						{"name": "server", "type": "custom", "current": {"text": "web-1", "value": "web-1"}},
+						{"name": "tenant", "type": "constant", "hide": 2, "query": "secret", "current": {"text": "secret", "value": "secret"}}
+					]
+				}
 			}`,
-			variables: map[string]interface{}{
-				"defined_var": "value",
+			expectedScopedVars: map[string]interface{}{
+				"server": map[string]interface{}{"text": "web-1", "value": "web-1"},
 			},
-			expectedResult: `up{instance="${undefined_var}"}`,
 		},
 		{
-			name: "should handle null and empty variables",
+			name: "doesn't send undefined variables",
 			dashboardJSON: `{
 				"panels": [
 					{
 						"id": 1,
-						"targets": [
-							{
-								"expr": "up{instance=\"${null_var}\"}",
-								"refId": "A"
-							}
-						]
+						"targets": [{"expr": "up{instance=\"${undefined_var}\"}", "refId": "A"}]
 					}
 				]
 			}`,
 			variables: map[string]interface{}{
-				"null_var": nil,
+				"undefined_var": "value",
 			},
-			expectedResult: `up{instance="${null_var}"}`,
+			expectedScopedVars: map[string]interface{}{},
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			// Parse the dashboard JSON
 			dashboardData, err := simplejson.NewJson([]byte(tc.dashboardJSON))
 			require.NoError(t, err)
 
@@ -135,30 +124,20 @@ func TestApplyTemplateVariables(t *testing.T) {
 				Data: dashboardData,
 			}
 
-			// Apply template variables
-			result := service.applyTemplateVariables(dashboard, tc.variables)
-
-			// Extract the expr from the first panel's first target
-			panels := result.Data.Get("panels").MustArray()
-			require.Len(t, panels, 1)
-
-			panel := panels[0].(map[string]interface{})
-			targets := panel["targets"].([]interface{})
-			require.Len(t, targets, 1)
-
-			target := targets[0].(map[string]interface{})
-			actualExpr := target["expr"].(string)
+			// Apply template variables to the queries of the panel
+			queries := groupQueriesByPanelId(dashboard.Data, nil)[1]
+			expr := queries[0].Get("expr").MustString()
+			result := service.applyTemplateVariables(context.Background(), dashboard, &models.PublicDashboard{}, 1, queries, tc.variables)
+			require.Len(t, result, 1)
 
-			assert.Equal(t, tc.expectedResult, actualExpr)
+			// datasources interpolate the queries with the scoped vars
+			assert.Equal(t, expr, result[0].Get("expr").MustString())
+			assert.Equal(t, tc.expectedScopedVars, result[0].Get("scopedVars").Interface())
 		})
 	}
 }
 
 func TestInterpolateVariables(t *testing.T) {
-	service := &PublicDashboardServiceImpl{
-		log: log.NewNopLogger(),
-	}
-
 	testCases := []struct {
 		name      string
 		text      string
@@ -192,12 +171,20 @@ func TestInterpolateVariables(t *testing.T) {
 			expected: "SELECT name FROM users WHERE id = 123",
 		},
 		{
-			name: "should handle multi-value variables",
+			name: "should format multi-value variables with glob by default",
 			text: "SELECT * FROM table WHERE col IN (${values})",
 			variables: map[string]interface{}{
 				"values": []interface{}{"a", "b", "c"},
 			},
-			expected: "SELECT * FROM table WHERE col IN (a,b,c)",
+			expected: "SELECT * FROM table WHERE col IN ({a,b,c})",
+		},
+		{
+			name: "should apply the requested format to multi-value variables",
+			text: "SELECT * FROM table WHERE col IN (${values:sqlstring})",
+			variables: map[string]interface{}{
+				"values": []interface{}{"a", "b", "c"},
+			},
+			expected: "SELECT * FROM table WHERE col IN ('a','b','c')",
 		},
 		{
 			name: "should handle number variables",
@@ -222,18 +209,59 @@ func TestInterpolateVariables(t *testing.T) {
 			expected:  "SELECT * FROM table WHERE col = ${myVar}",
 		},
 		{
-			name: "should handle special regex characters in variable names",
+			name: "should read dots in braced references as a field path",
 			text: "SELECT * FROM table WHERE col = ${my.var-name}",
 			variables: map[string]interface{}{
 				"my.var-name": "test-value",
 			},
-			expected: "SELECT * FROM table WHERE col = test-value",
+			expected: "SELECT * FROM table WHERE col = ${my.var-name}",
+		},
+		{
+			name: "should emit $$ as a literal dollar sign",
+			text: "label_values(up{job=~\"$$job\"}, instance) and $$",
+			variables: map[string]interface{}{
+				"job": "node",
+			},
+			expected: "label_values(up{job=~\"$job\"}, instance) and $",
+		},
+		{
+			name: "should keep dollar anchors that are not variable references",
+			text: "up{instance=~\"${server}.*:9090$\"}",
+			variables: map[string]interface{}{
+				"server": "web",
+			},
+			expected: "up{instance=~\"web.*:9090$\"}",
+		},
+		{
+			name: "should pass the value through with the raw format",
+			text: "up{instance=~\"${server:raw}\"}",
+			variables: map[string]interface{}{
+				"server": "web-[0-9]+",
+			},
+			expected: "up{instance=~\"web-[0-9]+\"}",
+		},
+		{
+			name: "should fall back to glob for unknown formats",
+			text: "up{instance=~\"${server:unknown}\"}",
+			variables: map[string]interface{}{
+				"server": []interface{}{"web", "db"},
+			},
+			expected: "up{instance=~\"{web,db}\"}",
+		},
+		{
+			name: "should not interpolate variable references contained in values",
+			text: "SELECT * FROM table WHERE col = '${first}'",
+			variables: map[string]interface{}{
+				"first":  "$second",
+				"second": "value",
+			},
+			expected: "SELECT * FROM table WHERE col = '$second'",
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			result := service.interpolateVariables(tc.text, tc.variables)
+			result := newTemplateInterpolator(nil, tc.variables).interpolate(tc.text, nil, "")
 			assert.Equal(t, tc.expected, result)
 		})
 	}
@@ -250,10 +278,13 @@ func TestApplyTemplateVariablesWithComplexDashboard(t *testing.T) {
 			{
 				"id": 1,
 				"title": "Panel for ${service}",
+				"datasource": {"uid": "${ds}", "type": "prometheus"},
 				"targets": [
 					{
 						"expr": "rate(${metric}[${interval}])",
 						"legendFormat": "${service} - {{instance}}",
+						"limit": "${limit}",
+						"datasource": {"uid": "${ds}", "type": "prometheus"},
 						"refId": "A"
 					},
 					{
@@ -272,6 +303,11 @@ func TestApplyTemplateVariablesWithComplexDashboard(t *testing.T) {
 				{
 					"name": "service",
 					"current": {"value": "api"}
+				},
+				{
+					"name": "ds",
+					"type": "datasource",
+					"current": {"value": "prom"}
 				}
 			]
 		}
@@ -282,6 +318,7 @@ func TestApplyTemplateVariablesWithComplexDashboard(t *testing.T) {
 		"service":  "api-service",
 		"metric":   "http_requests_total",
 		"interval": "5m",
+		"limit":    float64(100),
 		"services": []interface{}{"api", "web", "worker"},
 	}
 
@@ -293,26 +330,27 @@ func TestApplyTemplateVariablesWithComplexDashboard(t *testing.T) {
 		Data: dashboardData,
 	}
 
-	result := service.applyTemplateVariables(dashboard, variables)
-
-	// Check title interpolation
-	title := result.Data.Get("title").MustString()
-	assert.Equal(t, "Dashboard with production environment", title)
+	result := service.applyTemplateVariables(context.Background(), dashboard, &models.PublicDashboard{}, 1, groupQueriesByPanelId(dashboard.Data, nil)[1], variables)
+	require.Len(t, result, 2)
 
-	// Check panel title interpolation
-	panels := result.Data.Get("panels").MustArray()
-	panel := panels[0].(map[string]interface{})
-	panelTitle := panel["title"].(string)
-	assert.Equal(t, "Panel for api-service", panelTitle)
+	// The targets are left to the datasource, only their datasource is resolved
+	assert.Equal(t, "rate(${metric}[${interval}])", result[0].Get("expr").MustString())
+	assert.Equal(t, "${service} - {{instance}}", result[0].Get("legendFormat").MustString())
+	assert.Equal(t, "${limit}", result[0].Get("limit").MustString())
+	assert.Equal(t, "prom", result[0].Get("datasource").Get("uid").MustString())
+	assert.Equal(t, "up{service=~\"${services}\"}", result[1].Get("expr").MustString())
 
-	// Check target interpolation
-	targets := panel["targets"].([]interface{})
-	target1 := targets[0].(map[string]interface{})
-	assert.Equal(t, "rate(http_requests_total[5m])", target1["expr"])
-	assert.Equal(t, "api-service - {{instance}}", target1["legendFormat"])
+	// The dashboard variables used by the queries are sent along with each query
+	for _, query := range result {
+		assert.Equal(t, map[string]interface{}{
+			"service": map[string]interface{}{"text": "api-service", "value": "api-service"},
+			"ds":      map[string]interface{}{"text": "prom", "value": "prom"},
+		}, query.Get("scopedVars").Interface())
+	}
 
-	target2 := targets[1].(map[string]interface{})
-	assert.Equal(t, "up{service=~\"api,web,worker\"}", target2["expr"])
+	// The dashboard itself isn't rewritten
+	assert.Equal(t, "Panel for ${service}", dashboard.Data.Get("panels").GetIndex(0).Get("title").MustString())
+	assert.Equal(t, "${ds}", dashboard.Data.Get("panels").GetIndex(0).Get("targets").GetIndex(0).Get("datasource").Get("uid").MustString())
 }
 
 func TestApplyTemplateVariablesInvalidJSON(t *testing.T) {
@@ -344,13 +382,336 @@ func TestApplyTemplateVariablesInvalidJSON(t *testing.T) {
 		"server":   "localhost",
 	}
 
-	// This should successfully process variables and return a new dashboard
-	result := service.applyTemplateVariables(dashboard, variables)
+	// This should successfully process variables and return copies of the queries
+	queries := groupQueriesByPanelId(dashboard.Data, nil)[1]
+	result := service.applyTemplateVariables(context.Background(), dashboard, &models.PublicDashboard{}, 1, queries, variables)
+
+	// Should have set the scoped vars on a copy of the query
+	require.Len(t, result, 1)
+	assert.NotSame(t, queries[0], result[0])
+	assert.NotNil(t, result[0].Get("scopedVars").Interface())
+	assert.Nil(t, queries[0].Get("scopedVars").Interface())
+	assert.Equal(t, "up{instance=\"${server}\"}", result[0].Get("expr").MustString())
+}
+
+func TestApplyTemplateVariablesWithAll(t *testing.T) {
+	dashboardData, err := simplejson.NewJson([]byte(`{
+		"templating": {
+			"list": [
+				{
+					"name": "server",
+					"type": "query",
+					"multi": true,
+					"includeAll": true,
+					"datasource": {"uid": "prom", "type": "prometheus"},
+					"query": "label_values(up, instance)",
+					"current": {"text": ["All"], "value": ["$__all"]},
+					"options": [{"text": "All", "value": "$__all"}, {"text": "web-1", "value": "web-1"}]
+				},
+				{
+					"name": "env",
+					"type": "custom",
+					"includeAll": true,
+					"query": "prod,stage",
+					"current": {"text": "All", "value": "$__all"},
+					"options": []
+				},
+				{
+					"name": "job",
+					"type": "query",
+					"includeAll": true,
+					"allValue": ".*",
+					"datasource": {"uid": "prom", "type": "prometheus"},
+					"query": "label_values(up{instance=~\"$server\"}, job)",
+					"current": {"text": "All", "value": "$__all"}
+				}
+			]
+		},
+		"panels": [
+			{
+				"id": 1,
+				"datasource": {"uid": "prom", "type": "prometheus"},
+				"targets": [{"refId": "A", "expr": "up{instance=~\"$server\", env=~\"$env\", job=~\"$job\"}"}]
+			}
+		]
+	}`))
+	require.NoError(t, err)
+	dashboard := &dashboards.Dashboard{UID: "dash1", OrgID: 1, Data: dashboardData}
+	pubdash := &models.PublicDashboard{Uid: "pubdash1", AccessToken: "abc123", OrgId: 1}
+
+	setup := func() (*PublicDashboardServiceImpl, *query.FakeQueryService) {
+		fakeQueryService := &query.FakeQueryService{}
+		fakeQueryService.On("QueryData", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&backend.QueryDataResponse{
+			Responses: map[string]backend.DataResponse{
+				"A": {Frames: data.Frames{data.NewFrame("", data.NewField("text", nil, []string{"web-1", "web-2"}))}},
+			},
+		}, nil)
+
+		return &PublicDashboardServiceImpl{
+			log:              log.NewNopLogger(),
+			QueryDataService: fakeQueryService,
+			datasourceService: &fakeDatasources.FakeDataSourceService{DataSources: []*datasources.DataSource{
+				{UID: "prom", Name: "Prometheus", OrgID: 1, Type: "prometheus", JsonData: simplejson.New()},
+			}},
+			variableOptions: newVariableOptionsCache(time.Minute, nil),
+		}, fakeQueryService
+	}
+
+	t.Run("expands all to the options of the variables", func(t *testing.T) {
+		service, fakeQueryService := setup()
+
+		result := service.applyTemplateVariables(context.Background(), dashboard, pubdash, 1, groupQueriesByPanelId(dashboard.Data, nil)[1], map[string]interface{}{"job": "$__all"})
+
+		// the saved options of query variables are refreshed, while custom all values are kept
+		scopedVars := result[0].Get("scopedVars")
+		assert.Equal(t, []interface{}{"web-1", "web-2"}, scopedVars.Get("server").Get("value").Interface())
+		assert.Equal(t, []interface{}{"prod", "stage"}, scopedVars.Get("env").Get("value").Interface())
+		assert.Equal(t, ".*", scopedVars.Get("job").Get("value").Interface())
+		assert.Equal(t, allVariableText, scopedVars.Get("server").Get("text").Interface())
+		fakeQueryService.AssertNumberOfCalls(t, "QueryData", 1)
+	})
+
+	t.Run("keeps the selected values", func(t *testing.T) {
+		service, fakeQueryService := setup()
+
+		result := service.applyTemplateVariables(context.Background(), dashboard, pubdash, 1, groupQueriesByPanelId(dashboard.Data, nil)[1], map[string]interface{}{"server": []interface{}{"web-1"}, "env": "prod"})
+
+		scopedVars := result[0].Get("scopedVars")
+		assert.Equal(t, []interface{}{"web-1"}, scopedVars.Get("server").Get("value").Interface())
+		assert.Equal(t, "prod", scopedVars.Get("env").Get("value").Interface())
+		assert.Equal(t, ".*", scopedVars.Get("job").Get("value").Interface())
+		fakeQueryService.AssertNotCalled(t, "QueryData", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("expands all in the queries of chained variables", func(t *testing.T) {
+		service, fakeQueryService := setup()
+
+		job, err := service.findVariableInDashboard(dashboard, "job")
+		require.NoError(t, err)
+
+		_, _, err = service.getQueryVariableOptions(context.Background(), dashboard, pubdash, job, models.PublicDashboardVariableQueryDTO{})
+		require.NoError(t, err)
+
+		fakeQueryService.AssertNumberOfCalls(t, "QueryData", 2)
+		metricReq := fakeQueryService.Calls[1].Arguments.Get(3).(dtos.MetricRequest)
+		assert.Equal(t, `label_values(up{instance=~"{web-1,web-2}"}, job)`, metricReq.Queries[0].Get("expr").MustString())
+	})
+}
+
+func TestGetQueryVariableOptionsWithTimeRange(t *testing.T) {
+	dashboardData, err := simplejson.NewJson([]byte(`{
+		"time": {"from": "1700000000000", "to": "1700003600000"},
+		"templating": {
+			"list": [
+				{
+					"name": "host",
+					"type": "query",
+					"datasource": {"uid": "mysql", "type": "mysql"},
+					"query": "SELECT host FROM hosts WHERE $__timeFilter(time) AND created < ${__to:date:seconds}",
+					"current": {"text": "web-1", "value": "web-1"}
+				}
+			]
+		}
+	}`))
+	require.NoError(t, err)
+	dashboard := &dashboards.Dashboard{UID: "dash1", OrgID: 1, Data: dashboardData}
+
+	testCases := []struct {
+		name         string
+		pubdash      *models.PublicDashboard
+		refresh      int
+		timeRange    models.TimeRangeDTO
+		expectedFrom string
+		expectedTo   string
+		expectedSQL  string
+	}{
+		{
+			name:         "uses the time range of the dashboard",
+			pubdash:      &models.PublicDashboard{Uid: "pubdash1", AccessToken: "abc123", OrgId: 1},
+			timeRange:    models.TimeRangeDTO{From: "1600000000000", To: "1600003600000"},
+			expectedFrom: "1700000000000",
+			expectedTo:   "1700003600000",
+			expectedSQL:  "SELECT host FROM hosts WHERE $__timeFilter(time) AND created < 1700003600",
+		},
+		{
+			name:         "uses the time range of the viewer when the time selection is enabled",
+			pubdash:      &models.PublicDashboard{Uid: "pubdash1", AccessToken: "abc123", OrgId: 1, TimeSelectionEnabled: true},
+			refresh:      refreshOnTimeRangeChange,
+			timeRange:    models.TimeRangeDTO{From: "1600000000000", To: "1600003600000"},
+			expectedFrom: "1600000000000",
+			expectedTo:   "1600003600000",
+			expectedSQL:  "SELECT host FROM hosts WHERE $__timeFilter(time) AND created < 1600003600",
+		},
+		{
+			name:         "uses the time range of the dashboard for variables refreshed on load",
+			pubdash:      &models.PublicDashboard{Uid: "pubdash1", AccessToken: "abc123", OrgId: 1, TimeSelectionEnabled: true},
+			refresh:      1,
+			timeRange:    models.TimeRangeDTO{From: "1600000000000", To: "1600003600000"},
+			expectedFrom: "1700000000000",
+			expectedTo:   "1700003600000",
+			expectedSQL:  "SELECT host FROM hosts WHERE $__timeFilter(time) AND created < 1700003600",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeQueryService := &query.FakeQueryService{}
+			fakeQueryService.On("QueryData", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&backend.QueryDataResponse{}, nil)
+			service := &PublicDashboardServiceImpl{
+				log:              log.NewNopLogger(),
+				QueryDataService: fakeQueryService,
+				datasourceService: &fakeDatasources.FakeDataSourceService{DataSources: []*datasources.DataSource{
+					{UID: "mysql", Name: "MySQL", OrgID: 1, Type: "mysql", JsonData: simplejson.New()},
+				}},
+			}
+
+			host, err := service.findVariableInDashboard(dashboard, "host")
+			require.NoError(t, err)
+			host.Refresh = tc.refresh
+
+			_, _, err = service.getQueryVariableOptions(context.Background(), dashboard, tc.pubdash, host, models.PublicDashboardVariableQueryDTO{TimeRange: tc.timeRange})
+			require.NoError(t, err)
+
+			// SQL macros are expanded by the datasource with the time range of the request
+			metricReq := fakeQueryService.Calls[0].Arguments.Get(3).(dtos.MetricRequest)
+			assert.Equal(t, tc.expectedFrom, metricReq.From)
+			assert.Equal(t, tc.expectedTo, metricReq.To)
+			assert.Equal(t, tc.expectedSQL, metricReq.Queries[0].Get("rawSql").MustString())
+			assert.Equal(t, "table", metricReq.Queries[0].Get("format").MustString())
+		})
+	}
+}
+
+func TestExtractOptionsFromQueryResponse(t *testing.T) {
+	service := &PublicDashboardServiceImpl{log: log.NewNopLogger()}
+
+	testCases := []struct {
+		name     string
+		frame    *data.Frame
+		expected []models.MetricFindValue
+	}{
+		{
+			name: "uses __text and __value over other fields",
+			frame: data.NewFrame("",
+				data.NewField("name", nil, []string{"ignored"}),
+				data.NewField("__text", nil, []string{"Production"}),
+				data.NewField("id", nil, []string{"ignored"}),
+				data.NewField("__value", nil, []string{"prod"}),
+			),
+			expected: []models.MetricFindValue{{Text: "Production", Value: "prod"}},
+		},
+		{
+			name: "formats numeric values",
+			frame: data.NewFrame("",
+				data.NewField("__text", nil, []string{"Team A", "Team B"}),
+				data.NewField("__value", nil, []*float64{util.Pointer(1234567.0), util.Pointer(2.5)}),
+			),
+			expected: []models.MetricFindValue{{Text: "Team A", Value: "1234567"}, {Text: "Team B", Value: "2.5"}},
+		},
+		{
+			name: "uses the value as text without text field",
+			frame: data.NewFrame("",
+				data.NewField("count", nil, []int64{3}),
+				data.NewField("__value", nil, []string{"web-1"}),
+			),
+			expected: []models.MetricFindValue{{Text: "web-1", Value: "web-1"}},
+		},
+		{
+			name: "deduplicates values keeping the first text",
+			frame: data.NewFrame("",
+				data.NewField("__text", nil, []string{"web 1", "web one", "web 2"}),
+				data.NewField("__value", nil, []string{"web-1", "web-1", "web-2"}),
+			),
+			expected: []models.MetricFindValue{{Text: "web 1", Value: "web-1"}, {Text: "web 2", Value: "web-2"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := &backend.QueryDataResponse{Responses: backend.Responses{"A": {Frames: data.Frames{tc.frame}}}}
+			options, err := service.extractOptionsFromQueryResponse(res)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, options)
+		})
+	}
+}
+
+func TestWithAllOption(t *testing.T) {
+	options := []models.MetricFindValue{{Text: "prod", Value: "prod"}}
+
+	t.Run("adds the all option to variables including it", func(t *testing.T) {
+		variable := &variableDefinition{Name: "env", IncludeAll: true, AllValue: ".*"}
+		assert.Equal(t, []models.MetricFindValue{{Text: "All", Value: "$__all"}, {Text: "prod", Value: "prod"}}, withAllOption(variable, options))
+	})
 
-	// Should return a different dashboard instance (not the same reference)
-	assert.NotEqual(t, dashboard, result)
-	// Should have interpolated the variables
-	panels := result.Data.Get("panels").MustArray()
-	panel := panels[0].(map[string]interface{})
-	assert.Equal(t, "Panel with test-var", panel["title"])
+	t.Run("keeps the saved all option", func(t *testing.T) {
+		saved := []models.MetricFindValue{{Text: "All", Value: "$__all"}, {Text: "prod", Value: "prod"}}
+		assert.Equal(t, saved, withAllOption(&variableDefinition{Name: "env", IncludeAll: true}, saved))
+	})
+
+	t.Run("keeps the options of variables without all", func(t *testing.T) {
+		assert.Equal(t, options, withAllOption(&variableDefinition{Name: "env"}, options))
+	})
+}
+
+func TestGetTextboxVariableOptions(t *testing.T) {
+	service := &PublicDashboardServiceImpl{log: log.NewNopLogger()}
+
+	testCases := []struct {
+		name     string
+		variable *variableDefinition
+		expected []models.MetricFindValue
+	}{
+		{
+			name:     "returns the saved value",
+			variable: &variableDefinition{Name: "search", Type: textboxVariableType, Query: "default", Current: variableCurrent{Text: "web", Value: "web"}},
+			expected: []models.MetricFindValue{{Text: "web", Value: "web"}},
+		},
+		{
+			name:     "returns the default value without saved value",
+			variable: &variableDefinition{Name: "search", Type: textboxVariableType, Query: "default", Current: variableCurrent{Text: "", Value: ""}},
+			expected: []models.MetricFindValue{{Text: "default", Value: "default"}},
+		},
+		{
+			name:     "returns no option without value",
+			variable: &variableDefinition{Name: "search", Type: textboxVariableType},
+			expected: []models.MetricFindValue{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			options, _, err := service.getVariableOptions(context.Background(), &dashboards.Dashboard{}, &models.PublicDashboard{}, tc.variable, models.PublicDashboardVariableQueryDTO{})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, options)
+		})
+	}
+}
+
+func TestPaginateVariableOptions(t *testing.T) {
+	options := []models.MetricFindValue{
+		{Text: "a", Value: "a"},
+		{Text: "b", Value: "b"},
+		{Text: "c", Value: "c"},
+	}
+
+	testCases := []struct {
+		name     string
+		options  []models.MetricFindValue
+		limit    int
+		page     int
+		expected []models.MetricFindValue
+	}{
+		{name: "returns every option without limit", options: options, expected: options},
+		{name: "returns the first page without page", options: options, limit: 2, expected: options[:2]},
+		{name: "returns the requested page", options: options, limit: 2, page: 2, expected: options[2:]},
+		{name: "returns no option after the last page", options: options, limit: 2, page: 3, expected: []models.MetricFindValue{}},
+		{name: "never returns nil", options: nil, limit: 2, expected: []models.MetricFindValue{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, paginateVariableOptions(tc.options, tc.limit, tc.page))
+		})
+	}
 }
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/quota"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// Count reports the number of public dashboards to the quota service
+func (pd *PublicDashboardServiceImpl) Count(ctx context.Context, scopeParams *quota.ScopeParameters) (*quota.Map, error) {
+	return pd.store.Count(ctx, scopeParams)
+}
+
+// checkQuota returns ErrPublicDashboardQuotaReached when the org or the user can't create more public dashboards
+func (pd *PublicDashboardServiceImpl) checkQuota(ctx context.Context, u *user.SignedInUser) error {
+	if pd.quotaService == nil {
+		return nil
+	}
+
+	reached, err := pd.quotaService.CheckQuotaReached(ctx, QuotaTargetSrv, &quota.ScopeParameters{OrgID: u.OrgID, UserID: u.UserID})
+	if err != nil {
+		return ErrInternalServerError.Errorf("checkQuota: failed to check the public dashboards quota: %w", err)
+	}
+	if reached {
+		return ErrPublicDashboardQuotaReached.Errorf("checkQuota: public dashboards quota reached for org %d and user %d", u.OrgID, u.UserID)
+	}
+	return nil
+}
+
+// findQuota returns the usage and limit of the public dashboards quota of the org and of the user. Returns nil when
+// quotas are disabled
+func (pd *PublicDashboardServiceImpl) findQuota(ctx context.Context, orgID int64, userID int64) (*PublicDashboardQuota, error) {
+	if pd.quotaService == nil {
+		return nil, nil
+	}
+
+	org, err := pd.findQuotaUsage(ctx, quota.OrgScope, orgID)
+	if errors.Is(err, quota.ErrDisabled) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	// users without id, like API keys, have no user quota
+	usr := QuotaUsage{Limit: -1}
+	if userID != 0 {
+		if usr, err = pd.findQuotaUsage(ctx, quota.UserScope, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	return &PublicDashboardQuota{Org: org, User: usr}, nil
+}
+
+func (pd *PublicDashboardServiceImpl) findQuotaUsage(ctx context.Context, scope quota.Scope, id int64) (QuotaUsage, error) {
+	quotas, err := pd.quotaService.GetQuotasByScope(ctx, scope, id)
+	if err != nil {
+		return QuotaUsage{}, err
+	}
+
+	for _, q := range quotas {
+		if q.Target == string(QuotaTarget) {
+			return QuotaUsage{Used: q.Used, Limit: q.Limit}, nil
+		}
+	}
+	return QuotaUsage{Limit: -1}, nil
+}
+
+func readQuotaConfig(cfg *setting.Cfg) (*quota.Map, error) {
+	limits := &quota.Map{}
+
+	if cfg == nil {
+		return limits, nil
+	}
+
+	globalQuotaTag, err := quota.NewTag(QuotaTargetSrv, QuotaTarget, quota.GlobalScope)
+	if err != nil {
+		return limits, err
+	}
+	orgQuotaTag, err := quota.NewTag(QuotaTargetSrv, QuotaTarget, quota.OrgScope)
+	if err != nil {
+		return limits, err
+	}
+	userQuotaTag, err := quota.NewTag(QuotaTargetSrv, QuotaTarget, quota.UserScope)
+	if err != nil {
+		return limits, err
+	}
+
+	limits.Set(globalQuotaTag, cfg.Quota.Global.PublicDashboard)
+	limits.Set(orgQuotaTag, cfg.Quota.Org.PublicDashboard)
+	limits.Set(userQuotaTag, cfg.Quota.User.PublicDashboard)
+	return limits, nil
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/quota"
+	"github.com/grafana/grafana/pkg/services/quota/quotatest"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestCheckQuota(t *testing.T) {
+	u := &user.SignedInUser{OrgID: 1, UserID: 1}
+
+	t.Run("returns an error when the quota is reached", func(t *testing.T) {
+		service := &PublicDashboardServiceImpl{log: log.NewNopLogger(), quotaService: quotatest.New(true, nil)}
+		err := service.checkQuota(context.Background(), u)
+		assert.ErrorIs(t, err, ErrPublicDashboardQuotaReached)
+	})
+
+	t.Run("returns an internal error when the quota can't be checked", func(t *testing.T) {
+		service := &PublicDashboardServiceImpl{log: log.NewNopLogger(), quotaService: quotatest.New(false, errors.New("db error"))}
+		err := service.checkQuota(context.Background(), u)
+		assert.ErrorIs(t, err, ErrInternalServerError)
+	})
+
+	t.Run("returns nil when the quota isn't reached or there's no quota service", func(t *testing.T) {
+		service := &PublicDashboardServiceImpl{log: log.NewNopLogger(), quotaService: quotatest.New(false, nil)}
+		require.NoError(t, service.checkQuota(context.Background(), u))
+
+		service = &PublicDashboardServiceImpl{log: log.NewNopLogger()}
+		require.NoError(t, service.checkQuota(context.Background(), u))
+	})
+}
+
+func TestFindQuota(t *testing.T) {
+	t.Run("returns no limit when the quota isn't reported", func(t *testing.T) {
+		service := &PublicDashboardServiceImpl{log: log.NewNopLogger(), quotaService: quotatest.New(false, nil)}
+		q, err := service.findQuota(context.Background(), 1, 1)
+		require.NoError(t, err)
+		assert.Equal(t, &PublicDashboardQuota{Org: QuotaUsage{Limit: -1}, User: QuotaUsage{Limit: -1}}, q)
+	})
+
+	t.Run("returns nil when there's no quota service", func(t *testing.T) {
+		service := &PublicDashboardServiceImpl{log: log.NewNopLogger()}
+		q, err := service.findQuota(context.Background(), 1, 1)
+		require.NoError(t, err)
+		assert.Nil(t, q)
+	})
+}
+
+func TestReadQuotaConfig(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.Quota.Global.PublicDashboard = 100
+	cfg.Quota.Org.PublicDashboard = 10
+	cfg.Quota.User.PublicDashboard = 5
+
+	limits, err := readQuotaConfig(cfg)
+	require.NoError(t, err)
+
+	for scope, expected := range map[quota.Scope]int64{quota.GlobalScope: 100, quota.OrgScope: 10, quota.UserScope: 5} {
+		tag, err := quota.NewTag(QuotaTargetSrv, QuotaTarget, scope)
+		require.NoError(t, err)
+		limit, ok := limits.Get(tag)
+		require.True(t, ok)
+		assert.Equal(t, expected, limit)
+	}
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	grafanamodels "github.com/grafana/grafana/pkg/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/org"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/rendering"
+)
+
+// variableParamPrefix prefixes the variables in the query string of dashboard pages
+const variableParamPrefix = "var-"
+
+// RenderPanelPNG renders a panel of the public dashboard as a PNG image with the image renderer, with the time range
+// and variables of the viewer, so panels can be embedded as static images without exposing the render URLs of Grafana.
+// The watermark footnote is set as the comment of the image when one is configured
+func (pd *PublicDashboardServiceImpl) RenderPanelPNG(ctx context.Context, reqDTO PublicDashboardRenderDTO, panelId int64, accessToken string) (*PanelExport, error) {
+	ctx, span := tracer.Start(ctx, "publicdashboards.RenderPanelPNG")
+	defer span.End()
+
+	pubdash, dashboard, err := pd.FindEnabledPublicDashboardAndDashboardByAccessToken(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if findPanelContent(dashboard, panelId) == nil || !sharesPanel(pubdash, dashboard, panelId) {
+		return nil, ErrPanelNotFound.Errorf("RenderPanelPNG: panel %d not found in dashboard %s", panelId, dashboard.UID)
+	}
+
+	filePath, err := pd.renderPage(ctx, rendering.RenderPNG, pubdash, dashboard, reqDTO, renderLimits{
+		limiter:   pd.renderLimiter,
+		maxWidth:  pd.cfg.PublicDashboardsRenderMaxWidth,
+		maxHeight: pd.cfg.PublicDashboardsRenderMaxHeight,
+		timeout:   pd.cfg.PublicDashboardsRenderTimeout,
+	}, url.Values{"viewPanel": {fmt.Sprintf("panel-%d", panelId)}})
+	if err != nil {
+		return nil, err
+	}
+
+	footnote, err := pd.exportWatermark(ctx, pubdash, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	writeTo := renderedFileWriter(filePath)
+	if footnote != "" {
+		if writeTo, err = pngWatermarkWriter(filePath, footnote); err != nil {
+			return nil, ErrRenderFailed.Errorf("RenderPanelPNG: failed to watermark rendered file: %w", err)
+		}
+	}
+
+	return &PanelExport{
+		Filename:    fmt.Sprintf("%s-panel-%d.png", dashboard.Slug, panelId),
+		ContentType: "image/png",
+		WriteTo:     writeTo,
+	}, nil
+}
+
+// renderLimits bound the renders of a public dashboard, rendering is far more expensive than querying
+type renderLimits struct {
+	limiter   *exportRateLimiter
+	maxWidth  int
+	maxHeight int
+	timeout   time.Duration
+}
+
+// renderPage renders the public dashboard page with the image renderer and returns the path of the rendered file.
+// The renderer signs in with a viewer identity of the org of the public dashboard, since it only loads its public page
+func (pd *PublicDashboardServiceImpl) renderPage(ctx context.Context, renderType rendering.RenderType, pubdash *PublicDashboard, dashboard *dashboards.Dashboard, reqDTO PublicDashboardRenderDTO, limits renderLimits, params url.Values) (string, error) {
+	if pd.renderService == nil || !pd.renderService.IsAvailable(ctx) {
+		return "", ErrRenderUnavailable.Errorf("renderPage: image renderer is not available")
+	}
+
+	width, height, err := pd.renderSize(reqDTO, limits.maxWidth, limits.maxHeight)
+	if err != nil {
+		return "", err
+	}
+
+	theme := grafanamodels.ThemeLight
+	if reqDTO.Theme != "" {
+		if theme, err = grafanamodels.ParseTheme(reqDTO.Theme); err != nil {
+			return "", ErrInvalidRenderOptions.Errorf("renderPage: %w", err)
+		}
+	}
+
+	// variables are validated like the ones of queries, but are passed on as the viewer sent them: the public
+	// dashboard page expects masked datasource uids and applies pinned variables by itself
+	variables := overridableValues(pubdash, visibleValues(dashboard.Data, reqDTO.Variables))
+	if len(variables) > 0 {
+		masker := newDatasourceUidMasker(pd.cfg.SecretKey, pubdash)
+		masker.learn(dashboard.Data)
+		if err := pd.validateVariables(ctx, dashboard, pubdash, withPinnedValues(pubdash, dashboard.Data, masker.unmaskVariables(variables))); err != nil {
+			return "", err
+		}
+	}
+
+	if !limits.limiter.allow(pubdash.AccessToken) {
+		return "", ErrRenderRateLimited.Errorf("renderPage: rate limit of public dashboard %s reached", pubdash.Uid)
+	}
+
+	result, err := pd.renderService.Render(ctx, renderType, rendering.Opts{
+		CommonOpts: rendering.CommonOpts{
+			TimeoutOpts: rendering.TimeoutOpts{
+				Timeout: limits.timeout,
+			},
+			AuthOpts: rendering.AuthOpts{
+				OrgID:   pubdash.OrgId,
+				OrgRole: org.RoleViewer,
+			},
+			Path:            renderPagePath(pubdash, reqDTO, variables, params),
+			Timezone:        reqDTO.TimeRange.Timezone,
+			ConcurrentLimit: pd.cfg.RendererConcurrentRequestLimit,
+		},
+		ErrorOpts: rendering.ErrorOpts{
+			ErrorConcurrentLimitReached: true,
+			ErrorRenderUnavailable:      true,
+		},
+		Width:             width,
+		Height:            height,
+		DeviceScaleFactor: 1,
+		Theme:             theme,
+	}, nil)
+	if err != nil {
+		switch {
+		case errors.Is(err, rendering.ErrRenderUnavailable):
+			return "", ErrRenderUnavailable.Errorf("renderPage: %w", err)
+		case errors.Is(err, rendering.ErrConcurrentLimitReached), errors.Is(err, rendering.ErrTooManyRequests):
+			return "", ErrRenderRateLimited.Errorf("renderPage: %w", err)
+		default:
+			return "", ErrRenderFailed.Errorf("renderPage: failed to render public dashboard %s: %w", pubdash.Uid, err)
+		}
+	}
+
+	return result.FilePath, nil
+}
+
+// renderSize returns the size of a render, the default size of the image renderer when the viewer didn't pick one.
+// Sizes above the maximum are rejected
+func (pd *PublicDashboardServiceImpl) renderSize(reqDTO PublicDashboardRenderDTO, maxWidth int, maxHeight int) (int, int, error) {
+	width, height := reqDTO.Width, reqDTO.Height
+	if width == 0 {
+		width = pd.cfg.RendererDefaultImageWidth
+	}
+	if height == 0 {
+		height = pd.cfg.RendererDefaultImageHeight
+	}
+
+	if width < 0 || height < 0 || width > maxWidth || height > maxHeight {
+		return 0, 0, ErrInvalidRenderOptions.Errorf("renderSize: size %dx%d is not between 0x0 and %dx%d", width, height, maxWidth, maxHeight)
+	}
+	return width, height, nil
+}
+
+// renderPagePath returns the path of the public dashboard page the image renderer loads, in kiosk mode with the time
+// range and variables of the viewer and the given params. The time range is only passed on when viewers can change it
+func renderPagePath(pubdash *PublicDashboard, reqDTO PublicDashboardRenderDTO, variables map[string]interface{}, params url.Values) string {
+	query := url.Values{}
+	for key, values := range params {
+		query[key] = values
+	}
+	query.Set("kiosk", "")
+	if pubdash.TimeSelectionEnabled {
+		if reqDTO.TimeRange.From != "" && reqDTO.TimeRange.To != "" {
+			query.Set("from", reqDTO.TimeRange.From)
+			query.Set("to", reqDTO.TimeRange.To)
+		}
+		if reqDTO.TimeRange.Timezone != "" {
+			query.Set("timezone", reqDTO.TimeRange.Timezone)
+		}
+	}
+
+	for name, value := range variables {
+		key := variableParamPrefix + name
+		switch value := value.(type) {
+		case []interface{}:
+			for _, v := range value {
+				query.Add(key, fmt.Sprint(v))
+			}
+		case []string:
+			for _, v := range value {
+				query.Add(key, v)
+			}
+		default:
+			query.Add(key, fmt.Sprint(value))
+		}
+	}
+
+	return fmt.Sprintf("public-dashboards/%s?%s", pubdash.AccessToken, query.Encode())
+}
+
+// renderedFileWriter streams a file rendered by the image renderer
+func renderedFileWriter(filePath string) func(w io.Writer) error {
+	return func(w io.Writer) error {
+		f, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+
+		_, err = io.Copy(w, f)
+		return err
+	}
+}
+
+// exportRateLimiter limits the number of exports of each public dashboard per minute. A nil limiter doesn't limit
+// anything
+type exportRateLimiter struct {
+	mu       sync.Mutex
+	perMin   int
+	limiters map[string]*rate.Limiter
+}
+
+func newExportRateLimiter(perMin int) *exportRateLimiter {
+	if perMin <= 0 {
+		return nil
+	}
+	return &exportRateLimiter{perMin: perMin, limiters: map[string]*rate.Limiter{}}
+}
+
+// allow returns true when the public dashboard can be exported once more
+func (l *exportRateLimiter) allow(accessToken string) bool {
+	if l == nil {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limiter, ok := l.limiters[accessToken]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Every(time.Minute/time.Duration(l.perMin)), l.perMin)
+		l.limiters[accessToken] = limiter
+	}
+	return limiter.Allow()
+}
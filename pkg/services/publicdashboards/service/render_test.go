@@ -0,0 +1,138 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/licensing/licensingtest"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/rendering"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestRenderPanelPNG(t *testing.T) {
+	data, err := simplejson.NewJson([]byte(`{"panels": [{"id": 2, "type": "timeseries", "title": "Requests"}]}`))
+	require.NoError(t, err)
+	dashboard := &dashboards.Dashboard{UID: "dash1", OrgID: 1, Slug: "my-dashboard", Data: data}
+	pubdash := &PublicDashboard{Uid: "uid1", IsEnabled: true, OrgId: 1, DashboardUid: dashboard.UID, AccessToken: "abc123"}
+
+	setup := func(t *testing.T, renderService rendering.Service) *PublicDashboardServiceImpl {
+		fakeStore := &FakePublicDashboardStore{}
+		fakeStore.On("FindByAccessToken", mock.Anything, mock.AnythingOfType("string")).Return(pubdash, nil)
+		fakeDashboardService := &dashboards.FakeDashboardService{}
+		fakeDashboardService.On("GetDashboard", mock.Anything, mock.Anything, mock.Anything).Return(dashboard, nil)
+
+		license := licensingtest.NewFakeLicensing()
+		license.On("FeatureEnabled", FeaturePublicDashboardsEmailSharing).Return(false).Maybe()
+
+		cfg := setting.NewCfg()
+		cfg.RendererDefaultImageWidth = 1000
+		cfg.RendererDefaultImageHeight = 500
+		cfg.PublicDashboardsRenderMaxWidth = 2000
+		cfg.PublicDashboardsRenderMaxHeight = 2000
+
+		return &PublicDashboardServiceImpl{
+			log:              log.NewNopLogger(),
+			cfg:              cfg,
+			store:            fakeStore,
+			dashboardService: fakeDashboardService,
+			license:          license,
+			renderService:    renderService,
+			renderLimiter:    newExportRateLimiter(1),
+		}
+	}
+
+	t.Run("Renders the panel alone on the public dashboard page", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		renderService := rendering.NewMockService(ctrl)
+		renderService.EXPECT().IsAvailable(gomock.Any()).Return(true)
+
+		filePath := filepath.Join(t.TempDir(), "panel.png")
+		require.NoError(t, os.WriteFile(filePath, []byte("PNG"), 0o600))
+
+		var opts rendering.Opts
+		renderService.EXPECT().Render(gomock.Any(), rendering.RenderPNG, gomock.Any(), nil).
+			DoAndReturn(func(_ context.Context, _ rendering.RenderType, o rendering.Opts, _ rendering.Session) (*rendering.RenderResult, error) {
+				opts = o
+				return &rendering.RenderResult{FilePath: filePath}, nil
+			})
+
+		service := setup(t, renderService)
+		image, err := service.RenderPanelPNG(context.Background(), PublicDashboardRenderDTO{Width: 800, Height: 400}, 2, "abc123")
+		require.NoError(t, err)
+
+		assert.Equal(t, "public-dashboards/abc123?kiosk=&viewPanel=panel-2", opts.Path)
+		assert.Equal(t, 800, opts.Width)
+		assert.Equal(t, 400, opts.Height)
+
+		assert.Equal(t, "my-dashboard-panel-2.png", image.Filename)
+		assert.Equal(t, "image/png", image.ContentType)
+		var buf bytes.Buffer
+		require.NoError(t, image.WriteTo(&buf))
+		assert.Equal(t, "PNG", buf.String())
+	})
+
+	t.Run("Fails when the panel isn't in the dashboard", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		service := setup(t, rendering.NewMockService(ctrl))
+
+		_, err := service.RenderPanelPNG(context.Background(), PublicDashboardRenderDTO{}, 3, "abc123")
+		assert.ErrorIs(t, err, ErrPanelNotFound)
+	})
+
+	t.Run("Rejects image sizes above the maximum", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		renderService := rendering.NewMockService(ctrl)
+		renderService.EXPECT().IsAvailable(gomock.Any()).Return(true)
+
+		service := setup(t, renderService)
+		_, err := service.RenderPanelPNG(context.Background(), PublicDashboardRenderDTO{Height: 2500}, 2, "abc123")
+		assert.ErrorIs(t, err, ErrInvalidRenderOptions)
+	})
+
+	t.Run("Returns a rate limit error when the image renderer is saturated", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		renderService := rendering.NewMockService(ctrl)
+		renderService.EXPECT().IsAvailable(gomock.Any()).Return(true)
+		renderService.EXPECT().Render(gomock.Any(), rendering.RenderPNG, gomock.Any(), nil).
+			Return(nil, rendering.ErrConcurrentLimitReached)
+
+		service := setup(t, renderService)
+		_, err := service.RenderPanelPNG(context.Background(), PublicDashboardRenderDTO{}, 2, "abc123")
+		assert.ErrorIs(t, err, ErrRenderRateLimited)
+	})
+}
+
+func TestRenderPagePath(t *testing.T) {
+	reqDTO := PublicDashboardRenderDTO{TimeRange: TimeRangeDTO{From: "now-1h", To: "now", Timezone: "utc"}}
+	variables := map[string]interface{}{"region": []interface{}{"eu", "us"}, "env": "prod"}
+
+	t.Run("Passes the time range when viewers can change it", func(t *testing.T) {
+		pubdash := &PublicDashboard{AccessToken: "abc123", TimeSelectionEnabled: true}
+		assert.Equal(t, "public-dashboards/abc123?from=now-1h&kiosk=&timezone=utc&to=now&var-env=prod&var-region=eu&var-region=us",
+			renderPagePath(pubdash, reqDTO, variables, nil))
+	})
+
+	t.Run("Leaves the time range out when viewers can't change it", func(t *testing.T) {
+		pubdash := &PublicDashboard{AccessToken: "abc123"}
+		assert.Equal(t, "public-dashboards/abc123?kiosk=&var-env=prod&var-region=eu&var-region=us", renderPagePath(pubdash, reqDTO, variables, nil))
+	})
+
+	t.Run("Adds the params of the page", func(t *testing.T) {
+		pubdash := &PublicDashboard{AccessToken: "abc123"}
+		assert.Equal(t, "public-dashboards/abc123?kiosk=&viewPanel=panel-2", renderPagePath(pubdash, reqDTO, nil, url.Values{"viewPanel": {"panel-2"}}))
+	})
+}
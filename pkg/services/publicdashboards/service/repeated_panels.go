@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+// repeatedPanelIdFactor builds the synthetic ids of the instances of repeated panels: the id of the repeated panel
+// times the factor, plus the position of the instance starting at 1. Panel 4 repeated by a variable with three values
+// has the instances 4001, 4002 and 4003
+const repeatedPanelIdFactor = 1000
+
+func repeatedPanelId(panelId int64, index int) int64 {
+	return panelId*repeatedPanelIdFactor + int64(index) + 1
+}
+
+// expandRepeatedPanel returns a copy of the dashboard with the instance of a repeated panel requested by its synthetic
+// id, a clone of the repeated panel with the value of its instance set as scoped variable. Panels of repeated rows are
+// instances of the panel for each value of the row variable, with the same synthetic ids. Instances are built from the
+// values selected by the viewer like the dashboard does in the browser. The dashboard is returned unchanged when the id
+// is the id of a panel, or isn't the id of an instance. Repeated panels of v2 dashboards aren't supported
+func (pd *PublicDashboardServiceImpl) expandRepeatedPanel(ctx context.Context, dashboard *dashboards.Dashboard, publicDashboard *models.PublicDashboard, panelId int64, variables map[string]interface{}) *dashboards.Dashboard {
+	if dashboard.Data.Get("elements").Interface() != nil {
+		return dashboard
+	}
+
+	panels := dashboard.Data.Get("panels").MustArray()
+	if findPanelById(panels, panelId) != nil {
+		return dashboard
+	}
+
+	sourceId, index := panelId/repeatedPanelIdFactor, int(panelId%repeatedPanelIdFactor)-1
+	if sourceId <= 0 || index < 0 {
+		return dashboard
+	}
+	source := findPanelById(panels, sourceId)
+	if source == nil {
+		return dashboard
+	}
+	repeat, _ := source["repeat"].(string)
+	if repeat == "" {
+		if row := findPanelRow(panels, sourceId); row != nil {
+			repeat, _ = row["repeat"].(string)
+		}
+	}
+	if repeat == "" {
+		return dashboard
+	}
+
+	interpolator := pd.newVariableInterpolator(ctx, dashboard, publicDashboard, variables, "")
+	variable, value, ok := interpolator.lookup(repeat)
+	if !ok {
+		return dashboard
+	}
+	var values []interface{}
+	if isAllVariableValue(value) {
+		values = interpolator.allValues(variable)
+	} else {
+		for _, v := range variableValueStrings(value) {
+			values = append(values, v)
+		}
+	}
+	if index >= len(values) {
+		return dashboard
+	}
+
+	// the dashboard is copied so the instance isn't added to the dashboard of other requests
+	encoded, err := simplejson.NewFromAny(source).Encode()
+	if err != nil {
+		pd.log.Warn("Failed to encode repeated panel", "panelId", sourceId, "error", err)
+		return dashboard
+	}
+	instance, err := simplejson.NewJson(encoded)
+	if err != nil {
+		pd.log.Warn("Failed to copy repeated panel", "panelId", sourceId, "error", err)
+		return dashboard
+	}
+
+	scopedVars := instance.Get("scopedVars").MustMap()
+	if scopedVars == nil {
+		scopedVars = map[string]interface{}{}
+	}
+	scopedVars[repeat] = map[string]interface{}{
+		"text":     interpolator.text(variable, values[index]),
+		"value":    values[index],
+		"selected": true,
+	}
+	instance.Set("scopedVars", scopedVars)
+	instance.Set("id", panelId)
+	instance.Del("repeat")
+
+	data, err := dashboard.Data.Encode()
+	if err != nil {
+		pd.log.Warn("Failed to encode dashboard with repeated panel", "panelId", sourceId, "error", err)
+		return dashboard
+	}
+	dataCopy, err := simplejson.NewJson(data)
+	if err != nil {
+		pd.log.Warn("Failed to copy dashboard with repeated panel", "panelId", sourceId, "error", err)
+		return dashboard
+	}
+	dataCopy.Set("panels", append(dataCopy.Get("panels").MustArray(), instance.Interface()))
+
+	dashboardCopy := *dashboard
+	dashboardCopy.Data = dataCopy
+	return &dashboardCopy
+}
+
+// findPanelById returns the panel with the id among the panels and the panels of their rows
+func findPanelById(panels []interface{}, panelId int64) map[string]interface{} {
+	for _, p := range panels {
+		panel, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if simplejson.NewFromAny(panel).Get("id").MustInt64() == panelId {
+			return panel
+		}
+		if nested, ok := panel["panels"].([]interface{}); ok {
+			if found := findPanelById(nested, panelId); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+// findPanelRow returns the row of a panel, the collapsed row holding it or the last row before it in the dashboard
+func findPanelRow(panels []interface{}, panelId int64) map[string]interface{} {
+	var row map[string]interface{}
+	for _, p := range panels {
+		panel, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if panel["type"] == "row" {
+			row = panel
+			if nested, ok := panel["panels"].([]interface{}); ok && findPanelById(nested, panelId) != nil {
+				return panel
+			}
+			continue
+		}
+		if simplejson.NewFromAny(panel).Get("id").MustInt64() == panelId {
+			return row
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+func TestExpandRepeatedPanel(t *testing.T) {
+	dashboardData, err := simplejson.NewJson([]byte(`{
+		"templating": {
+			"list": [
+				{
+					"name": "server",
+					"type": "custom",
+					"multi": true,
+					"includeAll": true,
+					"query": "web-1,web-2,web-3",
+					"current": {"text": ["web-1", "web-2"], "value": ["web-1", "web-2"]}
+				}
+			]
+		},
+		"panels": [
+			{
+				"id": 4,
+				"repeat": "server",
+				"datasource": {"uid": "prom", "type": "prometheus"},
+				"targets": [{"refId": "A", "expr": "up{instance=\"$server\"}"}]
+			},
+			{
+				"id": 5,
+				"datasource": {"uid": "prom", "type": "prometheus"},
+				"targets": [{"refId": "A", "expr": "up"}]
+			}
+		]
+	}`))
+	require.NoError(t, err)
+	dashboard := &dashboards.Dashboard{UID: "dash1", OrgID: 1, Data: dashboardData}
+	pubdash := &PublicDashboard{Uid: "pubdash1", AccessToken: "abc123", OrgId: 1}
+	service := &PublicDashboardServiceImpl{log: log.NewNopLogger()}
+
+	valueOf := func(t *testing.T, variables map[string]interface{}, panelId int64) interface{} {
+		expanded := service.expandRepeatedPanel(context.Background(), dashboard, pubdash, panelId, variables)
+		queries, ok := groupQueriesByPanelId(expanded.Data, nil)[panelId]
+		require.True(t, ok)
+		return service.applyTemplateVariables(context.Background(), expanded, pubdash, panelId, queries, variables)[0].Get("scopedVars").Get("server").Get("value").Interface()
+	}
+
+	t.Run("queries each instance with its value", func(t *testing.T) {
+		assert.Equal(t, "web-1", valueOf(t, nil, repeatedPanelId(4, 0)))
+		assert.Equal(t, "web-2", valueOf(t, nil, repeatedPanelId(4, 1)))
+	})
+
+	t.Run("uses the values selected by the viewer", func(t *testing.T) {
+		assert.Equal(t, "web-3", valueOf(t, map[string]interface{}{"server": []interface{}{"web-3"}}, 4001))
+	})
+
+	t.Run("repeats over every option for all", func(t *testing.T) {
+		assert.Equal(t, "web-3", valueOf(t, map[string]interface{}{"server": []interface{}{"$__all"}}, 4003))
+	})
+
+	t.Run("keeps the dashboard for other ids", func(t *testing.T) {
+		for _, panelId := range []int64{4, 5, 4003, 5001, 7001} {
+			assert.Same(t, dashboard, service.expandRepeatedPanel(context.Background(), dashboard, pubdash, panelId, nil))
+		}
+	})
+
+	t.Run("doesn't change the dashboard", func(t *testing.T) {
+		service.expandRepeatedPanel(context.Background(), dashboard, pubdash, 4001, nil)
+		assert.Len(t, dashboard.Data.Get("panels").MustArray(), 2)
+	})
+}
+
+func TestExpandRepeatedRow(t *testing.T) {
+	dashboardData, err := simplejson.NewJson([]byte(`{
+		"templating": {
+			"list": [
+				{
+					"name": "server",
+					"type": "custom",
+					"multi": true,
+					"query": "web-1,web-2",
+					"current": {"text": ["web-1", "web-2"], "value": ["web-1", "web-2"]}
+				}
+			]
+		},
+		"panels": [
+			{"id": 1, "type": "row", "repeat": "server", "collapsed": false, "panels": []},
+			{
+				"id": 2,
+				"datasource": {"uid": "prom", "type": "prometheus"},
+				"targets": [{"refId": "A", "expr": "up{instance=\"$server\"}"}]
+			},
+			{"id": 3, "type": "row", "collapsed": true, "repeat": "server", "panels": [
+				{
+					"id": 4,
+					"datasource": {"uid": "prom", "type": "prometheus"},
+					"targets": [{"refId": "A", "expr": "load{instance=\"$server\"}"}]
+				}
+			]},
+			{"id": 5, "type": "row", "collapsed": false, "panels": []},
+			{
+				"id": 6,
+				"datasource": {"uid": "prom", "type": "prometheus"},
+				"targets": [{"refId": "A", "expr": "up"}]
+			}
+		]
+	}`))
+	require.NoError(t, err)
+	dashboard := &dashboards.Dashboard{UID: "dash1", OrgID: 1, Data: dashboardData}
+	pubdash := &PublicDashboard{Uid: "pubdash1", AccessToken: "abc123", OrgId: 1}
+	service := &PublicDashboardServiceImpl{log: log.NewNopLogger()}
+
+	valueOf := func(t *testing.T, panelId int64) interface{} {
+		expanded := service.expandRepeatedPanel(context.Background(), dashboard, pubdash, panelId, nil)
+		queries, ok := groupQueriesByPanelId(expanded.Data, nil)[panelId]
+		require.True(t, ok)
+		return service.applyTemplateVariables(context.Background(), expanded, pubdash, panelId, queries, nil)[0].Get("scopedVars").Get("server").Get("value").Interface()
+	}
+
+	t.Run("queries the panels of each row instance with its value", func(t *testing.T) {
+		assert.Equal(t, "web-1", valueOf(t, repeatedPanelId(2, 0)))
+		assert.Equal(t, "web-2", valueOf(t, repeatedPanelId(2, 1)))
+	})
+
+	t.Run("queries the panels of collapsed rows", func(t *testing.T) {
+		assert.Equal(t, "web-2", valueOf(t, repeatedPanelId(4, 1)))
+	})
+
+	t.Run("keeps the dashboard for panels of rows that don't repeat", func(t *testing.T) {
+		assert.Same(t, dashboard, service.expandRepeatedPanel(context.Background(), dashboard, pubdash, repeatedPanelId(6, 0), nil))
+	})
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/bus"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/annotations/annotationsimpl"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	alertingStore "github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/database"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/metric"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/tag"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// ReadReplica serves the reads of public dashboard viewers from the database read replica configured with
+// [public_dashboards] read_replica_url, so anonymous traffic doesn't load the primary database. Reads fall back to the
+// primary database when the replica fails or doesn't have the data yet because of replication lag
+type ReadReplica struct {
+	store       publicdashboards.Store
+	dashboards  dashboardGetter
+	annotations annotations.Repository
+}
+
+// dashboardGetter gets a dashboard, it's implemented by dashboards.DashboardService too
+type dashboardGetter interface {
+	GetDashboard(ctx context.Context, query *dashboards.GetDashboardQuery) (*dashboards.Dashboard, error)
+}
+
+// ProvideReadReplica connects to the read replica of public dashboards. Returns nil when none is configured, or when
+// the replica can't be connected to so viewers read from the primary database
+func ProvideReadReplica(
+	cfg *setting.Cfg,
+	features featuremgmt.FeatureToggles,
+	bus bus.Bus,
+	tracer tracing.Tracer,
+	tagService tag.Service,
+	ruleStore *alertingStore.DBstore,
+	dashboardService dashboards.DashboardService,
+) *ReadReplica {
+	if cfg.PublicDashboardsReadReplicaURL == "" {
+		return nil
+	}
+
+	replica, err := sqlstore.NewReadReplica(cfg, features, bus, tracer, cfg.PublicDashboardsReadReplicaURL)
+	if err != nil {
+		log.New(LogPrefix).Error("Failed to connect to the read replica of public dashboards, reading from the primary database", "error", err)
+		return nil
+	}
+
+	return &ReadReplica{
+		store:      database.ProvideStore(replica, cfg, features),
+		dashboards: &replicaDashboardStore{db: replica},
+		// the metrics of the annotations are registered by the repository of the primary database
+		annotations: annotationsimpl.ProvideService(replica, cfg, features, tagService, tracer, ruleStore, dashboardService, nil),
+	}
+}
+
+// replicaDashboardStore gets dashboards from the read replica the way the dashboard store gets them from the primary
+// database. The dashboard store itself isn't used since it migrates dashboard tags when it's created
+type replicaDashboardStore struct {
+	db db.DB
+}
+
+// GetDashboard returns nil when the dashboard isn't found, it may not be replicated yet
+func (s *replicaDashboardStore) GetDashboard(ctx context.Context, query *dashboards.GetDashboardQuery) (*dashboards.Dashboard, error) {
+	var found bool
+	dashboard := &dashboards.Dashboard{OrgID: query.OrgID, UID: query.UID}
+	err := s.db.WithDbSession(ctx, func(sess *db.Session) error {
+		var err error
+		found, err = sess.Where("deleted IS NULL").Get(dashboard)
+		return err
+	})
+	if err != nil || !found {
+		return nil, err
+	}
+
+	dashboard.SetID(dashboard.ID)
+	dashboard.SetUID(dashboard.UID)
+	return dashboard, nil
+}
+
+// findByAccessTokenOnReadReplica finds the public dashboard by access token on the read replica. Returns nil when
+// there's no replica, or it failed or doesn't have the public dashboard yet
+func (pd *PublicDashboardServiceImpl) findByAccessTokenOnReadReplica(ctx context.Context, accessToken string) *PublicDashboard {
+	if pd.readReplica == nil {
+		return nil
+	}
+
+	pubdash, err := pd.readReplica.store.FindByAccessToken(ctx, accessToken)
+	if err != nil || pubdash == nil {
+		pd.readReplicaFallback("access_token", err)
+		return nil
+	}
+	return pubdash
+}
+
+// findDashboardForViewer finds the dashboard of a public dashboard for a viewer on the read replica
+func (pd *PublicDashboardServiceImpl) findDashboardForViewer(ctx context.Context, orgId int64, dashboardUid string) (*dashboards.Dashboard, error) {
+	if pd.readReplica != nil {
+		dash, err := pd.readReplica.dashboards.GetDashboard(ctx, &dashboards.GetDashboardQuery{UID: dashboardUid, OrgID: orgId})
+		if err == nil && dash != nil {
+			return dash, nil
+		}
+		pd.readReplicaFallback("dashboard", err)
+	}
+
+	return pd.FindDashboard(ctx, orgId, dashboardUid)
+}
+
+// findAnnotationsForViewer finds the annotations of a public dashboard for a viewer on the read replica. Annotations
+// are only looked up again on the primary database when the replica fails, no annotations is a common result
+func (pd *PublicDashboardServiceImpl) findAnnotationsForViewer(ctx context.Context, query *annotations.ItemQuery) ([]*annotations.ItemDTO, error) {
+	if pd.readReplica != nil {
+		items, err := pd.readReplica.annotations.Find(ctx, query)
+		if err == nil {
+			return items, nil
+		}
+		pd.readReplicaFallback("annotations", err)
+	}
+
+	return pd.AnnotationsRepo.Find(ctx, query)
+}
+
+func (pd *PublicDashboardServiceImpl) readReplicaFallback(read string, err error) {
+	metric.ReadReplicaFallbacksTotal.WithLabelValues(read).Inc()
+	if err != nil {
+		pd.log.Warn("Failed to read from the read replica, reading from the primary database", "read", read, "error", err)
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestReadReplica(t *testing.T) {
+	pubdash := &PublicDashboard{Uid: "pubdash1", OrgId: 1, DashboardUid: "dash1", AccessToken: "token1", IsEnabled: true}
+	dashboard := &dashboards.Dashboard{UID: "dash1", OrgID: 1, Data: simplejson.New()}
+
+	newService := func(primaryStore *FakePublicDashboardStore, primaryDashboards *dashboards.FakeDashboardService, replica *ReadReplica) *PublicDashboardServiceImpl {
+		return &PublicDashboardServiceImpl{
+			log:              log.NewNopLogger(),
+			cfg:              setting.NewCfg(),
+			store:            primaryStore,
+			dashboardService: primaryDashboards,
+			readReplica:      replica,
+		}
+	}
+
+	t.Run("reads public dashboards and dashboards from the replica", func(t *testing.T) {
+		primaryStore := &FakePublicDashboardStore{}
+		primaryDashboards := &dashboards.FakeDashboardService{}
+		replicaStore := &FakePublicDashboardStore{}
+		replicaStore.On("FindByAccessToken", mock.Anything, "token1").Return(pubdash, nil)
+		replicaDashboards := &dashboards.FakeDashboardService{}
+		replicaDashboards.On("GetDashboard", mock.Anything, mock.Anything).Return(dashboard, nil)
+
+		service := newService(primaryStore, primaryDashboards, &ReadReplica{store: replicaStore, dashboards: replicaDashboards})
+		p, d, err := service.FindPublicDashboardAndDashboardByAccessToken(context.Background(), "token1")
+		require.NoError(t, err)
+		assert.Equal(t, pubdash.Uid, p.Uid)
+		assert.Equal(t, dashboard.UID, d.UID)
+		primaryStore.AssertNotCalled(t, "FindByAccessToken", mock.Anything, mock.Anything)
+		primaryDashboards.AssertNotCalled(t, "GetDashboard", mock.Anything, mock.Anything)
+	})
+
+	t.Run("falls back to the primary database when the replica fails or isn't up to date", func(t *testing.T) {
+		primaryStore := &FakePublicDashboardStore{}
+		primaryStore.On("FindByAccessToken", mock.Anything, "token1").Return(pubdash, nil)
+		primaryDashboards := &dashboards.FakeDashboardService{}
+		primaryDashboards.On("GetDashboard", mock.Anything, mock.Anything).Return(dashboard, nil)
+		replicaStore := &FakePublicDashboardStore{}
+		replicaStore.On("FindByAccessToken", mock.Anything, "token1").Return(nil, errors.New("connection refused"))
+		replicaDashboards := &dashboards.FakeDashboardService{}
+		replicaDashboards.On("GetDashboard", mock.Anything, mock.Anything).Return(nil, nil)
+
+		service := newService(primaryStore, primaryDashboards, &ReadReplica{store: replicaStore, dashboards: replicaDashboards})
+		p, d, err := service.FindPublicDashboardAndDashboardByAccessToken(context.Background(), "token1")
+		require.NoError(t, err)
+		assert.Equal(t, pubdash.Uid, p.Uid)
+		assert.Equal(t, dashboard.UID, d.UID)
+		primaryStore.AssertNumberOfCalls(t, "FindByAccessToken", 1)
+		primaryDashboards.AssertNumberOfCalls(t, "GetDashboard", 1)
+	})
+
+	t.Run("reads annotations from the replica and falls back when it fails", func(t *testing.T) {
+		query := &annotations.ItemQuery{OrgID: 1, DashboardUID: "dash1"}
+		items := []*annotations.ItemDTO{{ID: 1}}
+
+		primaryAnnotations := &annotations.FakeAnnotationsRepo{}
+		primaryAnnotations.On("Find", mock.Anything, query).Return(items, nil)
+		replicaAnnotations := &annotations.FakeAnnotationsRepo{}
+		replicaAnnotations.On("Find", mock.Anything, query).Return(items, nil).Once()
+		replicaAnnotations.On("Find", mock.Anything, query).Return(nil, errors.New("connection refused")).Once()
+
+		service := newService(&FakePublicDashboardStore{}, &dashboards.FakeDashboardService{}, &ReadReplica{annotations: replicaAnnotations})
+		service.AnnotationsRepo = primaryAnnotations
+
+		found, err := service.findAnnotationsForViewer(context.Background(), query)
+		require.NoError(t, err)
+		assert.Equal(t, items, found)
+		primaryAnnotations.AssertNotCalled(t, "Find", mock.Anything, mock.Anything)
+
+		found, err = service.findAnnotationsForViewer(context.Background(), query)
+		require.NoError(t, err)
+		assert.Equal(t, items, found)
+		primaryAnnotations.AssertNumberOfCalls(t, "Find", 1)
+	})
+}
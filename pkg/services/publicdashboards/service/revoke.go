@@ -0,0 +1,213 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+// errViewerQueriesRevoked is the cause of the cancellation of the queries of viewers of a revoked public dashboard
+var errViewerQueriesRevoked = errors.New("public dashboard revoked")
+
+// cdnPurgeClient posts the surrogate keys of revoked access tokens to the CDN purge webhook
+var cdnPurgeClient = &http.Client{Timeout: 10 * time.Second}
+
+// Revoke deletes the public dashboard and invalidates everything its access tokens are still serving from: the
+// caches and the running queries of viewers of every instance, the Live connections of viewers and, when a purge
+// webhook is configured, the responses cached by the CDN. Only deleting the public dashboard is required to succeed,
+// the invalidations are reported in the result. Revocations are recorded in the audit log
+func (pd *PublicDashboardServiceImpl) Revoke(ctx context.Context, u *user.SignedInUser, uid string, dashboardUid string) (*PublicDashboardRevocation, error) {
+	ctx, span := tracer.Start(ctx, "publicdashboards.Revoke")
+	defer span.End()
+
+	existingPubdash, err := pd.store.Find(ctx, uid)
+	if err != nil {
+		return nil, ErrInternalServerError.Errorf("Revoke: failed to find public dashboard by uid: %s: %w", uid, err)
+	}
+	if existingPubdash == nil || existingPubdash.OrgId != u.OrgID {
+		return nil, ErrPublicDashboardNotFound.Errorf("Revoke: public dashboard not found by uid: %s", uid)
+	}
+
+	// validate the public dashboard belongs to the dashboard
+	if existingPubdash.DashboardUid != dashboardUid {
+		return nil, ErrInvalidUid.Errorf("Revoke: the public dashboard does not belong to the dashboard")
+	}
+
+	if err := pd.serviceWrapper.Delete(ctx, uid); err != nil {
+		return nil, err
+	}
+
+	// the previous access token of a rotation may still be in its grace period
+	accessTokens := []string{existingPubdash.AccessToken}
+	if existingPubdash.PreviousAccessToken != "" {
+		accessTokens = append(accessTokens, existingPubdash.PreviousAccessToken)
+	}
+
+	// the other instances are only sent hashes, so access tokens don't go through the Live broker
+	accessTokenHashes := make([]string, 0, len(accessTokens))
+	for _, accessToken := range accessTokens {
+		accessTokenHashes = append(accessTokenHashes, accessTokenHash(accessToken))
+	}
+
+	revocation := &PublicDashboardRevocation{
+		Uid:                       uid,
+		CancelledQueries:          pd.forgetRevoked(uid, accessTokenHashes),
+		LiveViewersDisconnected:   true,
+		OtherInstancesInvalidated: true,
+	}
+	if pd.liveBroadcastRevoked != nil {
+		if err := pd.liveBroadcastRevoked(uid, accessTokenHashes); err != nil {
+			pd.log.Warn("Failed to invalidate revoked public dashboard on other instances", "publicDashboardUid", uid, "error", err)
+			revocation.OtherInstancesInvalidated = false
+		}
+	}
+	for _, accessToken := range accessTokens {
+		pd.invalidateLiveViewers(&PublicDashboard{Uid: uid, OrgId: existingPubdash.OrgId, AccessToken: accessToken})
+
+		if pd.liveDisconnect != nil {
+			if err := pd.liveDisconnect(accessToken); err != nil {
+				pd.log.Warn("Failed to disconnect Live viewers of revoked public dashboard", "publicDashboardUid", uid, "error", err)
+				revocation.LiveViewersDisconnected = false
+			}
+		}
+	}
+	revocation.CDNPurged = pd.purgeCDN(ctx, uid, accessTokens)
+
+	log.New("publicdashboards.audit").Info("Revoked public dashboard",
+		"publicDashboardUid", uid,
+		"dashboardUid", dashboardUid,
+		"orgId", existingPubdash.OrgId,
+		"user", u.Login,
+		"userId", u.UserID,
+		"cancelledQueries", revocation.CancelledQueries,
+		"otherInstancesInvalidated", revocation.OtherInstancesInvalidated,
+		"cdnPurged", revocation.CDNPurged)
+
+	return revocation, nil
+}
+
+// forgetRevoked clears the state this instance keeps for a revoked public dashboard and the access tokens with the
+// hashes, and returns how many running queries of viewers were cancelled. It's called for the revocations of every
+// instance
+func (pd *PublicDashboardServiceImpl) forgetRevoked(uid string, accessTokenHashes []string) int {
+	cancelled := pd.viewerQueries.cancel(uid)
+	pd.viewerSessions.forget(uid)
+	pd.accessTokens.forgetPublicDashboard(uid)
+
+	revoked := func(accessToken string) bool {
+		return slices.Contains(accessTokenHashes, accessTokenHash(accessToken))
+	}
+	pd.presence.forgetMatching(revoked)
+	pd.variableUsage.forgetMatching(revoked)
+	pd.variableOptions.forgetMatching(revoked)
+	pd.liveSubscriptions.forgetMatching(revoked)
+	return cancelled
+}
+
+// purgeCDN posts the surrogate keys of the access tokens to the CDN purge webhook, it returns false without webhook
+// or when the webhook failed
+func (pd *PublicDashboardServiceImpl) purgeCDN(ctx context.Context, uid string, accessTokens []string) bool {
+	if pd.cfg == nil || pd.cfg.PublicDashboardsCDNPurgeURL == "" {
+		return false
+	}
+
+	body := struct {
+		SurrogateKeys []string `json:"surrogateKeys"`
+	}{}
+	for _, accessToken := range accessTokens {
+		body.SurrogateKeys = append(body.SurrogateKeys, SurrogateKey(accessToken))
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		pd.log.Warn("Failed to encode CDN purge request", "publicDashboardUid", uid, "error", err)
+		return false
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pd.cfg.PublicDashboardsCDNPurgeURL, bytes.NewReader(data))
+	if err != nil {
+		pd.log.Warn("Failed to create CDN purge request", "publicDashboardUid", uid, "error", err)
+		return false
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := cdnPurgeClient.Do(req)
+	if err != nil {
+		pd.log.Warn("Failed to purge CDN of revoked public dashboard", "publicDashboardUid", uid, "error", err)
+		return false
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		pd.log.Warn("CDN purge webhook rejected the purge of revoked public dashboard", "publicDashboardUid", uid, "status", resp.StatusCode)
+		return false
+	}
+	return true
+}
+
+// viewerQueryTracker keeps the cancel functions of the running queries of viewers on this instance, keyed by public
+// dashboard uid, so revoking a public dashboard stops them. A nil tracker doesn't track anything
+type viewerQueryTracker struct {
+	mu      sync.Mutex
+	nextID  uint64
+	queries map[string]map[uint64]context.CancelCauseFunc
+}
+
+func newViewerQueryTracker() *viewerQueryTracker {
+	return &viewerQueryTracker{queries: map[string]map[uint64]context.CancelCauseFunc{}}
+}
+
+// track returns a context cancelled when the public dashboard is revoked, and the function to call once the query is
+// done
+func (t *viewerQueryTracker) track(ctx context.Context, uid string) (context.Context, func()) {
+	if t == nil {
+		return ctx, func() {}
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.nextID++
+	id := t.nextID
+	if t.queries[uid] == nil {
+		t.queries[uid] = map[uint64]context.CancelCauseFunc{}
+	}
+	t.queries[uid][id] = cancel
+
+	return ctx, func() {
+		t.mu.Lock()
+		delete(t.queries[uid], id)
+		if len(t.queries[uid]) == 0 {
+			delete(t.queries, uid)
+		}
+		t.mu.Unlock()
+		cancel(nil)
+	}
+}
+
+// cancel cancels the running queries of the public dashboard and returns how many were running
+func (t *viewerQueryTracker) cancel(uid string) int {
+	if t == nil {
+		return 0
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	queries := t.queries[uid]
+	for _, cancel := range queries {
+		cancel(errViewerQueriesRevoked)
+	}
+	delete(t.queries, uid)
+	return len(queries)
+}
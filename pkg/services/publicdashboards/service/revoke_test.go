@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestRevoke(t *testing.T) {
+	accessToken := "e71950f4b5fc4a9d8a34a2d1b2d7e5e0"
+	previousAccessToken := "0b3a1c4e5d6f47a8b9c0d1e2f3a4b5c6"
+	pubdash := &PublicDashboard{Uid: "uid1", OrgId: 1, DashboardUid: "dash1", AccessToken: accessToken, PreviousAccessToken: previousAccessToken}
+	u := &user.SignedInUser{OrgID: 1, UserID: 2, Login: "admin"}
+
+	setup := func(t *testing.T, cfg *setting.Cfg) (*PublicDashboardServiceImpl, *FakePublicDashboardStore, *FakePublicDashboardServiceWrapper) {
+		store := &FakePublicDashboardStore{}
+		store.Test(t)
+		wrapper := NewFakePublicDashboardServiceWrapper(t)
+		service := &PublicDashboardServiceImpl{
+			log:             log.NewNopLogger(),
+			cfg:             cfg,
+			store:           store,
+			serviceWrapper:  wrapper,
+			presence:        newPresenceTracker(),
+			variableUsage:   newVariableUsageTracker(),
+			variableOptions: newVariableOptionsCache(time.Minute, nil),
+			viewerQueries:   newViewerQueryTracker(),
+		}
+		return service, store, wrapper
+	}
+
+	t.Run("deletes the public dashboard and invalidates both access tokens", func(t *testing.T) {
+		var purged []string
+		cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body struct {
+				SurrogateKeys []string `json:"surrogateKeys"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			purged = body.SurrogateKeys
+		}))
+		defer cdn.Close()
+
+		cfg := setting.NewCfg()
+		cfg.PublicDashboardsCDNPurgeURL = cdn.URL
+		service, store, wrapper := setup(t, cfg)
+		store.On("Find", mock.Anything, "uid1").Return(pubdash, nil)
+		wrapper.On("Delete", mock.Anything, "uid1").Return(nil)
+
+		var disconnected, broadcast []string
+		service.liveDisconnect = func(accessToken string) error {
+			disconnected = append(disconnected, accessToken)
+			return nil
+		}
+		service.liveBroadcastRevoked = func(uid string, accessTokenHashes []string) error {
+			broadcast = accessTokenHashes
+			return nil
+		}
+		service.presence.heartbeat(accessToken, "session1", time.Now())
+		queryCtx, done := service.viewerQueries.track(context.Background(), "uid1")
+		defer done()
+
+		revocation, err := service.Revoke(context.Background(), u, "uid1", "dash1")
+		require.NoError(t, err)
+
+		assert.Equal(t, &PublicDashboardRevocation{Uid: "uid1", CancelledQueries: 1, LiveViewersDisconnected: true, OtherInstancesInvalidated: true, CDNPurged: true}, revocation)
+		assert.ErrorIs(t, context.Cause(queryCtx), errViewerQueriesRevoked)
+		assert.Equal(t, []string{accessToken, previousAccessToken}, disconnected)
+		// other instances only get hashes of the access tokens
+		assert.Equal(t, []string{accessTokenHash(accessToken), accessTokenHash(previousAccessToken)}, broadcast)
+		assert.Equal(t, []string{SurrogateKey(accessToken), SurrogateKey(previousAccessToken)}, purged)
+		assert.Zero(t, service.presence.stats(accessToken, time.Now()).CurrentViewers)
+	})
+
+	t.Run("reports the invalidations that failed", func(t *testing.T) {
+		cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer cdn.Close()
+
+		cfg := setting.NewCfg()
+		cfg.PublicDashboardsCDNPurgeURL = cdn.URL
+		service, store, wrapper := setup(t, cfg)
+		store.On("Find", mock.Anything, "uid1").Return(pubdash, nil)
+		wrapper.On("Delete", mock.Anything, "uid1").Return(nil)
+		service.liveDisconnect = func(string) error { return errors.New("node unavailable") }
+		service.liveBroadcastRevoked = func(string, []string) error { return errors.New("survey timeout") }
+
+		revocation, err := service.Revoke(context.Background(), u, "uid1", "dash1")
+		require.NoError(t, err)
+		assert.Equal(t, &PublicDashboardRevocation{Uid: "uid1"}, revocation)
+	})
+
+	t.Run("doesn't purge the CDN without webhook", func(t *testing.T) {
+		service, store, wrapper := setup(t, setting.NewCfg())
+		store.On("Find", mock.Anything, "uid1").Return(pubdash, nil)
+		wrapper.On("Delete", mock.Anything, "uid1").Return(nil)
+
+		revocation, err := service.Revoke(context.Background(), u, "uid1", "dash1")
+		require.NoError(t, err)
+		assert.Equal(t, &PublicDashboardRevocation{Uid: "uid1", LiveViewersDisconnected: true, OtherInstancesInvalidated: true}, revocation)
+	})
+
+	t.Run("returns not found for public dashboards of other orgs", func(t *testing.T) {
+		service, store, _ := setup(t, setting.NewCfg())
+		store.On("Find", mock.Anything, "uid1").Return(pubdash, nil)
+
+		_, err := service.Revoke(context.Background(), &user.SignedInUser{OrgID: 2}, "uid1", "dash1")
+		require.ErrorIs(t, err, ErrPublicDashboardNotFound)
+	})
+
+	t.Run("returns an error when the public dashboard belongs to another dashboard", func(t *testing.T) {
+		service, store, _ := setup(t, setting.NewCfg())
+		store.On("Find", mock.Anything, "uid1").Return(pubdash, nil)
+
+		_, err := service.Revoke(context.Background(), u, "uid1", "dash2")
+		require.ErrorIs(t, err, ErrInvalidUid)
+	})
+}
+
+func TestForgetRevoked(t *testing.T) {
+	service := &PublicDashboardServiceImpl{
+		presence:        newPresenceTracker(),
+		variableOptions: newVariableOptionsCache(time.Minute, nil),
+		viewerQueries:   newViewerQueryTracker(),
+	}
+	service.presence.heartbeat("token1", "session1", time.Now())
+	service.presence.heartbeat("token2", "session1", time.Now())
+	service.variableOptions.set("token1/env", 1, []MetricFindValue{{Text: "prod", Value: "prod"}}, time.Now())
+	service.variableOptions.set("token2/env", 1, []MetricFindValue{{Text: "prod", Value: "prod"}}, time.Now())
+	queryCtx, done := service.viewerQueries.track(context.Background(), "uid1")
+	defer done()
+
+	// revocations broadcast by other instances clear the state of this instance
+	assert.Equal(t, 1, service.forgetRevoked("uid1", []string{accessTokenHash("token1")}))
+	assert.ErrorIs(t, context.Cause(queryCtx), errViewerQueriesRevoked)
+	assert.Zero(t, service.presence.stats("token1", time.Now()).CurrentViewers)
+	_, _, ok := service.variableOptions.get("token1/env", 1, time.Now())
+	assert.False(t, ok)
+
+	// the state of other access tokens is kept
+	assert.Equal(t, 1, service.presence.stats("token2", time.Now()).CurrentViewers)
+	_, _, ok = service.variableOptions.get("token2/env", 1, time.Now())
+	assert.True(t, ok)
+}
+
+func TestViewerQueryTracker(t *testing.T) {
+	tracker := newViewerQueryTracker()
+
+	ctx1, done1 := tracker.track(context.Background(), "uid1")
+	ctx2, done2 := tracker.track(context.Background(), "uid2")
+	defer done2()
+
+	done1()
+	assert.ErrorIs(t, ctx1.Err(), context.Canceled)
+	assert.Equal(t, 0, tracker.cancel("uid1"))
+
+	assert.Equal(t, 1, tracker.cancel("uid2"))
+	assert.ErrorIs(t, context.Cause(ctx2), errViewerQueriesRevoked)
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+// maxAccessTokenGracePeriod bounds how long the previous access token keeps working after a rotation
+const maxAccessTokenGracePeriod = 7 * 24 * time.Hour
+
+// RotateAccessToken replaces the access token of the public dashboard with a new one. The previous access token keeps
+// working during the grace period of the request, so embeds can be updated before it stops working. Rotations are
+// recorded in the audit log
+func (pd *PublicDashboardServiceImpl) RotateAccessToken(ctx context.Context, u *user.SignedInUser, uid string, dashboardUid string, dto RotateAccessTokenDTO) (*PublicDashboard, error) {
+	ctx, span := tracer.Start(ctx, "publicdashboards.RotateAccessToken")
+	defer span.End()
+
+	gracePeriod := time.Duration(dto.GracePeriodSeconds) * time.Second
+	if gracePeriod < 0 || gracePeriod > maxAccessTokenGracePeriod {
+		return nil, ErrInvalidGracePeriod.Errorf("RotateAccessToken: grace period of %s is not between 0 and %s", gracePeriod, maxAccessTokenGracePeriod)
+	}
+
+	existingPubdash, err := pd.store.Find(ctx, uid)
+	if err != nil {
+		return nil, ErrInternalServerError.Errorf("RotateAccessToken: failed to find public dashboard by uid: %s: %w", uid, err)
+	}
+	if existingPubdash == nil {
+		return nil, ErrPublicDashboardNotFound.Errorf("RotateAccessToken: public dashboard not found by uid: %s", uid)
+	}
+
+	// validate the public dashboard belongs to the dashboard
+	if existingPubdash.DashboardUid != dashboardUid {
+		return nil, ErrInvalidUid.Errorf("RotateAccessToken: the public dashboard does not belong to the dashboard")
+	}
+
+	accessToken, err := pd.NewPublicDashboardAccessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	cmd := RotateAccessTokenCommand{
+		Uid:            uid,
+		AccessToken:    existingPubdash.AccessToken,
+		NewAccessToken: accessToken,
+		UpdatedBy:      u.UserID,
+		UpdatedAt:      now,
+	}
+	if gracePeriod > 0 {
+		cmd.PreviousAccessTokenExpiresAt = now.Add(gracePeriod)
+	}
+
+	affectedRows, err := pd.store.RotateAccessToken(ctx, cmd)
+	if err != nil {
+		return nil, ErrInternalServerError.Errorf("RotateAccessToken: failed to rotate access token: %w", err)
+	}
+
+	// the access token was rotated by someone else, or the public dashboard deleted, since it was read
+	if affectedRows == 0 {
+		return nil, ErrAccessTokenRotationConflict.Errorf("RotateAccessToken: access token of public dashboard %s changed concurrently", uid)
+	}
+	pd.PublicDashboardChanged(uid)
+
+	newPubdash, err := pd.store.Find(ctx, uid)
+	if err != nil {
+		return nil, ErrInternalServerError.Errorf("RotateAccessToken: failed to find public dashboard by uid: %s: %w", uid, err)
+	}
+	if newPubdash == nil {
+		return nil, ErrPublicDashboardNotFound.Errorf("RotateAccessToken: public dashboard not found by uid: %s", uid)
+	}
+
+	// viewers following the previous access token over Live load the dashboard again, and get the new state once the
+	// previous access token stopped working
+	if gracePeriod == 0 {
+		pd.presence.forget(existingPubdash.AccessToken)
+		pd.variableUsage.forget(existingPubdash.AccessToken)
+	}
+	pd.invalidateLiveViewers(existingPubdash)
+
+	log.New("publicdashboards.audit").Info("Rotated public dashboard access token",
+		"publicDashboardUid", uid,
+		"dashboardUid", dashboardUid,
+		"orgId", existingPubdash.OrgId,
+		"user", u.Login,
+		"userId", u.UserID,
+		"gracePeriod", gracePeriod)
+
+	return newPubdash, nil
+}
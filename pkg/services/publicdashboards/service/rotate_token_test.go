@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestRotateAccessToken(t *testing.T) {
+	u := &user.SignedInUser{UserID: 1, Login: "admin"}
+	pubdash := &PublicDashboard{Uid: "uid1", DashboardUid: "dash1", OrgId: 1, IsEnabled: true, AccessToken: "abc123"}
+
+	setup := func(t *testing.T) (*PublicDashboardServiceImpl, *FakePublicDashboardStore) {
+		fakeStore := &FakePublicDashboardStore{}
+		fakeStore.On("FindByAccessToken", mock.Anything, mock.AnythingOfType("string")).Return(nil, nil).Maybe()
+		return &PublicDashboardServiceImpl{
+			log:   log.NewNopLogger(),
+			cfg:   setting.NewCfg(),
+			store: fakeStore,
+		}, fakeStore
+	}
+
+	t.Run("Replaces the access token right away without a grace period", func(t *testing.T) {
+		service, fakeStore := setup(t)
+		rotated := *pubdash
+		rotated.AccessToken = "def456"
+		fakeStore.On("Find", mock.Anything, "uid1").Return(pubdash, nil).Once()
+		fakeStore.On("Find", mock.Anything, "uid1").Return(&rotated, nil).Once()
+
+		var cmd RotateAccessTokenCommand
+		fakeStore.On("RotateAccessToken", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) { cmd = args.Get(1).(RotateAccessTokenCommand) }).
+			Return(int64(1), nil)
+
+		result, err := service.RotateAccessToken(context.Background(), u, "uid1", "dash1", RotateAccessTokenDTO{})
+		require.NoError(t, err)
+
+		assert.Equal(t, "def456", result.AccessToken)
+		assert.Equal(t, "abc123", cmd.AccessToken)
+		assert.NotEqual(t, "abc123", cmd.NewAccessToken)
+		assert.True(t, cmd.PreviousAccessTokenExpiresAt.IsZero())
+		assert.Equal(t, int64(1), cmd.UpdatedBy)
+	})
+
+	t.Run("Keeps the previous access token during the grace period", func(t *testing.T) {
+		service, fakeStore := setup(t)
+		fakeStore.On("Find", mock.Anything, "uid1").Return(pubdash, nil)
+
+		var cmd RotateAccessTokenCommand
+		fakeStore.On("RotateAccessToken", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) { cmd = args.Get(1).(RotateAccessTokenCommand) }).
+			Return(int64(1), nil)
+
+		_, err := service.RotateAccessToken(context.Background(), u, "uid1", "dash1", RotateAccessTokenDTO{GracePeriodSeconds: 3600})
+		require.NoError(t, err)
+
+		assert.WithinDuration(t, time.Now().Add(time.Hour), cmd.PreviousAccessTokenExpiresAt, time.Minute)
+	})
+
+	t.Run("Rejects grace periods above the maximum", func(t *testing.T) {
+		service, fakeStore := setup(t)
+
+		_, err := service.RotateAccessToken(context.Background(), u, "uid1", "dash1", RotateAccessTokenDTO{GracePeriodSeconds: int64(maxAccessTokenGracePeriod/time.Second) + 1})
+		assert.ErrorIs(t, err, ErrInvalidGracePeriod)
+		fakeStore.AssertNotCalled(t, "RotateAccessToken", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Fails when the public dashboard belongs to another dashboard", func(t *testing.T) {
+		service, fakeStore := setup(t)
+		fakeStore.On("Find", mock.Anything, "uid1").Return(pubdash, nil)
+
+		_, err := service.RotateAccessToken(context.Background(), u, "uid1", "dash2", RotateAccessTokenDTO{})
+		assert.ErrorIs(t, err, ErrInvalidUid)
+		fakeStore.AssertNotCalled(t, "RotateAccessToken", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Fails when the access token was rotated concurrently", func(t *testing.T) {
+		service, fakeStore := setup(t)
+		fakeStore.On("Find", mock.Anything, "uid1").Return(pubdash, nil)
+		fakeStore.On("RotateAccessToken", mock.Anything, mock.Anything).Return(int64(0), nil)
+
+		_, err := service.RotateAccessToken(context.Background(), u, "uid1", "dash1", RotateAccessTokenDTO{})
+		assert.ErrorIs(t, err, ErrAccessTokenRotationConflict)
+	})
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// redactedLabelValue replaces the values of the redacted labels
+const redactedLabelValue = "[redacted]"
+
+// defaultStrippedTargetFields are the fields of the targets holding the query expressions
+var defaultStrippedTargetFields = []string{"expr", "query", "rawSql"}
+
+// sanitizationRule is a rule of the sanitization policy of public dashboards. Rules sanitize what viewers get and
+// check what they send, the hooks a rule isn't concerned with are left to noopSanitizationRule
+type sanitizationRule interface {
+	// sanitizeDashboard removes what viewers don't see from the dashboard data
+	sanitizeDashboard(data *simplejson.Json)
+	// checkQuery rejects or adjusts the query of a viewer for the panel before it runs
+	checkQuery(data *simplejson.Json, panelId int64, queryDto *models.PublicDashboardQueryDTO) error
+	// sanitizeResponse removes what viewers don't see from the response of their query
+	sanitizeResponse(res *backend.QueryDataResponse)
+}
+
+// sanitizationPolicy applies its rules in order
+type sanitizationPolicy []sanitizationRule
+
+// newSanitizationPolicy returns the policy with the rules of the settings, the zero settings are the default secure
+// policy
+func newSanitizationPolicy(settings setting.PublicDashboardsSanitizationPolicy) sanitizationPolicy {
+	fields := settings.StripTargetFields
+	if fields == nil {
+		fields = defaultStrippedTargetFields
+	}
+
+	policy := sanitizationPolicy{stripTargetFieldsRule{fields: fields}}
+	if !settings.KeepExecutedQueryString {
+		policy = append(policy, stripExecutedQueryStringRule{})
+	}
+	if !settings.AllowQueryOverrides {
+		policy = append(policy, rejectQueryOverridesRule{})
+	}
+	if len(settings.RedactLabels) > 0 {
+		policy = append(policy, redactLabelsRule{labels: settings.RedactLabels})
+	}
+	return policy
+}
+
+// sanitizationPolicy returns the sanitization policy of the org, the default secure policy without settings
+func (pd *PublicDashboardServiceImpl) sanitizationPolicy(orgId int64) sanitizationPolicy {
+	if pd.cfg == nil {
+		return newSanitizationPolicy(setting.PublicDashboardsSanitizationPolicy{})
+	}
+	if settings, ok := pd.cfg.PublicDashboardsSanitizationPolicyByOrg[orgId]; ok {
+		return newSanitizationPolicy(settings)
+	}
+	return newSanitizationPolicy(pd.cfg.PublicDashboardsSanitizationPolicy)
+}
+
+func (p sanitizationPolicy) sanitizeDashboard(data *simplejson.Json) {
+	for _, rule := range p {
+		rule.sanitizeDashboard(data)
+	}
+}
+
+func (p sanitizationPolicy) checkQuery(data *simplejson.Json, panelId int64, queryDto *models.PublicDashboardQueryDTO) error {
+	for _, rule := range p {
+		if err := rule.checkQuery(data, panelId, queryDto); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p sanitizationPolicy) sanitizeResponse(res *backend.QueryDataResponse) {
+	for _, rule := range p {
+		rule.sanitizeResponse(res)
+	}
+}
+
+// noopSanitizationRule leaves everything as is, rules embed it for the hooks they aren't concerned with
+type noopSanitizationRule struct{}
+
+func (noopSanitizationRule) sanitizeDashboard(*simplejson.Json) {}
+
+func (noopSanitizationRule) checkQuery(*simplejson.Json, int64, *models.PublicDashboardQueryDTO) error {
+	return nil
+}
+
+func (noopSanitizationRule) sanitizeResponse(*backend.QueryDataResponse) {}
+
+// stripTargetFieldsRule removes the fields of the targets of the panels, like the query expressions
+type stripTargetFieldsRule struct {
+	noopSanitizationRule
+	fields []string
+}
+
+func (r stripTargetFieldsRule) sanitizeDashboard(data *simplejson.Json) {
+	for _, panelObj := range data.Get("panels").MustArray() {
+		panel := simplejson.NewFromAny(panelObj)
+
+		// collapsed rows keep their panels nested
+		if panel.Get("type").MustString() == "row" && panel.Get("collapsed").MustBool() {
+			r.sanitizeDashboard(panel)
+			continue
+		}
+
+		for _, targetObj := range panel.Get("targets").MustArray() {
+			target := simplejson.NewFromAny(targetObj)
+			for _, field := range r.fields {
+				target.Del(field)
+			}
+		}
+	}
+}
+
+// stripExecutedQueryStringRule removes the query strings the datasources report they executed
+type stripExecutedQueryStringRule struct {
+	noopSanitizationRule
+}
+
+func (stripExecutedQueryStringRule) sanitizeResponse(res *backend.QueryDataResponse) {
+	for k := range res.Responses {
+		for _, frame := range res.Responses[k].Frames {
+			if frame.Meta != nil {
+				frame.Meta.ExecutedQueryString = ""
+			}
+		}
+	}
+}
+
+// rejectQueryOverridesRule rejects queries overriding the query caching TTL of the panel, and runs the others with
+// the query caching TTL of the panel
+type rejectQueryOverridesRule struct {
+	noopSanitizationRule
+}
+
+func (rejectQueryOverridesRule) checkQuery(data *simplejson.Json, panelId int64, queryDto *models.PublicDashboardQueryDTO) error {
+	ttl := panelQueryCachingTTL(data, panelId)
+	if queryDto.QueryCachingTTL != 0 && queryDto.QueryCachingTTL != ttl {
+		return models.ErrQueryOverrideNotAllowed.Errorf("checkQuery: query caching TTL %d differs from the one of panel %d", queryDto.QueryCachingTTL, panelId)
+	}
+	queryDto.QueryCachingTTL = ttl
+	return nil
+}
+
+// panelQueryCachingTTL returns the query caching TTL of the panel in either schema version, 0 when it has none
+func panelQueryCachingTTL(data *simplejson.Json, panelId int64) int64 {
+	if data.Get("elements").Interface() != nil {
+		for _, elementObj := range data.Get("elements").MustMap() {
+			spec := simplejson.NewFromAny(elementObj).Get("spec")
+			if spec.Get("id").MustInt64() == panelId {
+				return spec.Get("data").Get("spec").Get("queryOptions").Get("queryCachingTTL").MustInt64()
+			}
+		}
+		return 0
+	}
+
+	panel := findPanelById(data.Get("panels").MustArray(), panelId)
+	if panel == nil {
+		return 0
+	}
+	return simplejson.NewFromAny(panel).Get("queryCachingTTL").MustInt64()
+}
+
+// redactLabelsRule replaces the values of the labels of the fields of the frames
+type redactLabelsRule struct {
+	noopSanitizationRule
+	labels []string
+}
+
+func (r redactLabelsRule) sanitizeResponse(res *backend.QueryDataResponse) {
+	for k := range res.Responses {
+		for _, frame := range res.Responses[k].Frames {
+			for _, field := range frame.Fields {
+				for _, label := range r.labels {
+					if _, ok := field.Labels[label]; ok {
+						field.Labels[label] = redactedLabelValue
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestSanitizationPolicy(t *testing.T) {
+	newDashboard := func(t *testing.T) *simplejson.Json {
+		t.Helper()
+		dashboard, err := simplejson.NewJson([]byte(`{
+			"panels": [
+				{"id": 1, "queryCachingTTL": 60000, "targets": [{"refId": "A", "expr": "up", "sql": "SELECT 1"}]},
+				{"id": 2, "type": "row", "collapsed": true, "panels": [
+					{"id": 3, "targets": [{"refId": "A", "rawSql": "SELECT 1"}]}
+				]}
+			]
+		}`))
+		require.NoError(t, err)
+		return dashboard
+	}
+	newResponse := func() *backend.QueryDataResponse {
+		field := data.NewField("value", data.Labels{"instance": "10.0.0.1:9100", "job": "node"}, []float64{1})
+		frame := data.NewFrame("A", field).SetMeta(&data.FrameMeta{ExecutedQueryString: "up"})
+		return &backend.QueryDataResponse{Responses: backend.Responses{"A": {Frames: data.Frames{frame}}}}
+	}
+
+	t.Run("the default policy strips the query expressions and executed queries", func(t *testing.T) {
+		policy := newSanitizationPolicy(setting.PublicDashboardsSanitizationPolicy{})
+
+		dashboard := newDashboard(t)
+		policy.sanitizeDashboard(dashboard)
+		target := dashboard.Get("panels").GetIndex(0).Get("targets").GetIndex(0)
+		assert.Nil(t, target.Get("expr").Interface())
+		assert.Equal(t, "SELECT 1", target.Get("sql").MustString())
+		assert.Nil(t, dashboard.Get("panels").GetIndex(1).Get("panels").GetIndex(0).Get("targets").GetIndex(0).Get("rawSql").Interface())
+
+		res := newResponse()
+		policy.sanitizeResponse(res)
+		assert.Empty(t, res.Responses["A"].Frames[0].Meta.ExecutedQueryString)
+		assert.Equal(t, "10.0.0.1:9100", res.Responses["A"].Frames[0].Fields[0].Labels["instance"])
+	})
+
+	t.Run("the default policy rejects query overrides", func(t *testing.T) {
+		policy := newSanitizationPolicy(setting.PublicDashboardsSanitizationPolicy{})
+
+		queryDto := &PublicDashboardQueryDTO{}
+		require.NoError(t, policy.checkQuery(newDashboard(t), 1, queryDto))
+		assert.Equal(t, int64(60000), queryDto.QueryCachingTTL)
+
+		queryDto = &PublicDashboardQueryDTO{QueryCachingTTL: 60000}
+		require.NoError(t, policy.checkQuery(newDashboard(t), 1, queryDto))
+
+		err := policy.checkQuery(newDashboard(t), 1, &PublicDashboardQueryDTO{QueryCachingTTL: 1})
+		require.ErrorIs(t, err, ErrQueryOverrideNotAllowed)
+		err = policy.checkQuery(newDashboard(t), 3, &PublicDashboardQueryDTO{QueryCachingTTL: 60000})
+		require.ErrorIs(t, err, ErrQueryOverrideNotAllowed)
+	})
+
+	t.Run("the rules follow the settings", func(t *testing.T) {
+		policy := newSanitizationPolicy(setting.PublicDashboardsSanitizationPolicy{
+			StripTargetFields:       []string{"sql"},
+			KeepExecutedQueryString: true,
+			AllowQueryOverrides:     true,
+			RedactLabels:            []string{"instance"},
+		})
+
+		dashboard := newDashboard(t)
+		policy.sanitizeDashboard(dashboard)
+		target := dashboard.Get("panels").GetIndex(0).Get("targets").GetIndex(0)
+		assert.Equal(t, "up", target.Get("expr").MustString())
+		assert.Nil(t, target.Get("sql").Interface())
+
+		queryDto := &PublicDashboardQueryDTO{QueryCachingTTL: 1}
+		require.NoError(t, policy.checkQuery(dashboard, 1, queryDto))
+		assert.Equal(t, int64(1), queryDto.QueryCachingTTL)
+
+		res := newResponse()
+		policy.sanitizeResponse(res)
+		assert.Equal(t, "up", res.Responses["A"].Frames[0].Meta.ExecutedQueryString)
+		assert.Equal(t, data.Labels{"instance": redactedLabelValue, "job": "node"}, res.Responses["A"].Frames[0].Fields[0].Labels)
+	})
+
+	t.Run("orgs get their own policy", func(t *testing.T) {
+		cfg := setting.NewCfg()
+		cfg.PublicDashboardsSanitizationPolicyByOrg = map[int64]setting.PublicDashboardsSanitizationPolicy{
+			2: {KeepExecutedQueryString: true},
+		}
+		service := &PublicDashboardServiceImpl{cfg: cfg}
+
+		res := newResponse()
+		service.sanitizationPolicy(1).sanitizeResponse(res)
+		assert.Empty(t, res.Responses["A"].Frames[0].Meta.ExecutedQueryString)
+
+		res = newResponse()
+		service.sanitizationPolicy(2).sanitizeResponse(res)
+		assert.Equal(t, "up", res.Responses["A"].Frames[0].Meta.ExecutedQueryString)
+	})
+}
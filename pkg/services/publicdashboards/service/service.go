@@ -1,10 +1,13 @@
 package service
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,17 +20,26 @@ import (
 	queryV0 "github.com/grafana/grafana/pkg/apis/query/v0alpha1"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/metrics"
+	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/annotations"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/dashboards/dashboardaccess"
+	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/folder"
 	"github.com/grafana/grafana/pkg/services/licensing"
+	"github.com/grafana/grafana/pkg/services/live"
+	"github.com/grafana/grafana/pkg/services/live/model"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/pluginsintegration/plugincontext"
 	"github.com/grafana/grafana/pkg/services/publicdashboards"
 	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
 	"github.com/grafana/grafana/pkg/services/publicdashboards/service/intervalv2"
 	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
 	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/quota"
+	"github.com/grafana/grafana/pkg/services/rendering"
 	"github.com/grafana/grafana/pkg/services/sqlstore/searchstore"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
@@ -47,7 +59,52 @@ type PublicDashboardServiceImpl struct {
 	ac                 accesscontrol.AccessControl
 	serviceWrapper     publicdashboards.ServiceWrapper
 	dashboardService   dashboards.DashboardService
+	folderService      folder.Service
+	orgService         org.Service
+	datasourceService  datasources.DataSourceService
 	license            licensing.Licensing
+	queryLimiter       *queryLimiter
+	batchQueryPool     *batchQueryPool
+	presence           *presenceTracker
+	viewerSessions     *viewerSessionLimiter
+	variableUsage      *variableUsageTracker
+	variableOptions    *variableOptionsCache
+	accessTokens       *accessTokenCache
+	// usage counts the views and queries of public dashboards until UsageService writes them to the store
+	usage *usageRecorder
+	// viewerQueries cancels the running queries of viewers of revoked public dashboards
+	viewerQueries *viewerQueryTracker
+	// pluginClient and pluginContextProvider call the resource API of datasources for variable queries
+	pluginClient          backend.CallResourceHandler
+	pluginContextProvider pluginContextProvider
+	// healthChecker runs the health checks of the datasources of public dashboards
+	healthChecker backend.CheckHealthHandler
+	// livePublisher and liveClientCount push events to the viewers subscribed to the Live channel of public dashboards
+	livePublisher     model.ChannelPublisher
+	liveClientCount   model.ChannelClientCount
+	liveSubscriptions *liveSubscriptions
+	// liveDisconnect closes the Live connections of the viewers of an access token
+	liveDisconnect func(accessToken string) error
+	// liveBroadcastRevoked invalidates the state of a revoked public dashboard and the access tokens with the hashes
+	// on every instance
+	liveBroadcastRevoked func(uid string, accessTokenHashes []string) error
+	// liveBroadcastChanged removes a changed public dashboard from the cache of every instance
+	liveBroadcastChanged func(uid string) error
+	// liveChannelGetter and liveBridges bridge the streaming channels of datasources to the viewers of public dashboards
+	liveChannelGetter liveChannelHandlerGetter
+	liveBridges       *liveBridges
+	// renderService renders PDF exports and panel images of public dashboards, at most the limiters allow
+	renderService    rendering.Service
+	pdfExportLimiter *exportRateLimiter
+	renderLimiter    *exportRateLimiter
+	// rejectUnsafeVariableValues rejects variable values with characters that could end a quoted string of a query
+	rejectUnsafeVariableValues bool
+	// viewerChallenger challenges viewers before they get viewer tokens, nil doesn't challenge anyone
+	viewerChallenger publicdashboards.ViewerChallenger
+	// readReplica serves the reads of viewers, nil reads from the primary database
+	readReplica *ReadReplica
+	// quotaService limits the number of public dashboards of orgs and users, nil doesn't limit them
+	quotaService quota.Service
 }
 
 var LogPrefix = "publicdashboards.service"
@@ -68,9 +125,19 @@ func ProvideService(
 	ac accesscontrol.AccessControl,
 	serviceWrapper publicdashboards.ServiceWrapper,
 	dashboardService dashboards.DashboardService,
+	folderService folder.Service,
+	orgService org.Service,
 	license licensing.Licensing,
-) *PublicDashboardServiceImpl {
-	return &PublicDashboardServiceImpl{
+	datasourceService datasources.DataSourceService,
+	pluginClient plugins.Client,
+	pCtxProvider *plugincontext.Provider,
+	liveService *live.GrafanaLive,
+	renderService rendering.Service,
+	viewerChallenger publicdashboards.ViewerChallenger,
+	readReplica *ReadReplica,
+	quotaService quota.Service,
+) (*PublicDashboardServiceImpl, error) {
+	pd := &PublicDashboardServiceImpl{
 		log:                log.New(LogPrefix),
 		cfg:                cfg,
 		features:           features,
@@ -81,8 +148,63 @@ func ProvideService(
 		ac:                 ac,
 		serviceWrapper:     serviceWrapper,
 		dashboardService:   dashboardService,
+		folderService:      folderService,
+		orgService:         orgService,
+		datasourceService:  datasourceService,
 		license:            license,
+		queryLimiter:       newQueryLimiter(cfg.PublicDashboardsQueryMaxConcurrency, cfg.PublicDashboardsQueryMaxQueueSize, cfg.PublicDashboardsQueryQueueTimeout),
+		batchQueryPool:     newBatchQueryPool(cfg.PublicDashboardsBatchQueryMaxConcurrency),
+		presence:           newPresenceTracker(),
+		viewerSessions:     newViewerSessionLimiter(),
+		variableUsage:      newVariableUsageTracker(),
+		usage:              newUsageRecorder(),
+		variableOptions:    newVariableOptionsCache(cfg.PublicDashboardsVariableOptionsCacheTTL, cfg.PublicDashboardsVariableOptionsCacheTTLByOrg),
+		accessTokens:       newAccessTokenCache(cfg.PublicDashboardsAccessTokenCacheTTL),
+		viewerQueries:      newViewerQueryTracker(),
+
+		pluginClient:          pluginClient,
+		pluginContextProvider: pCtxProvider,
+		healthChecker:         pluginClient,
+
+		renderService:    renderService,
+		pdfExportLimiter: newExportRateLimiter(cfg.PublicDashboardsPDFExportRateLimit),
+		renderLimiter:    newExportRateLimiter(cfg.PublicDashboardsRenderRateLimit),
+
+		rejectUnsafeVariableValues: cfg.PublicDashboardsRejectUnsafeVariableValues,
+		viewerChallenger:           viewerChallenger,
+		readReplica:                readReplica,
+		quotaService:               quotaService,
 	}
+
+	if liveService != nil {
+		pd.livePublisher = liveService.Publish
+		pd.liveClientCount = liveService.ClientCount
+		pd.liveSubscriptions = newLiveSubscriptions()
+		pd.liveDisconnect = liveService.DisconnectPublicDashboardViewers
+		pd.liveBroadcastRevoked = liveService.BroadcastPublicDashboardRevoked
+		liveService.OnPublicDashboardRevoked(func(uid string, accessTokenHashes []string) {
+			pd.forgetRevoked(uid, accessTokenHashes)
+		})
+		pd.liveBroadcastChanged = liveService.BroadcastPublicDashboardChanged
+		liveService.OnPublicDashboardChanged(pd.accessTokens.forgetPublicDashboard)
+		pd.liveChannelGetter = liveService.GetChannelHandler
+		pd.liveBridges = newLiveBridges()
+		liveService.GrafanaScope.Features[live.PublicDashboardNamespace] = &liveChannelHandler{pd: pd}
+	}
+
+	defaultLimits, err := readQuotaConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := quotaService.RegisterQuotaReporter(&quota.NewUsageReporter{
+		TargetSrv:     QuotaTargetSrv,
+		DefaultLimits: defaultLimits,
+		Reporter:      pd.Count,
+	}); err != nil {
+		return nil, err
+	}
+
+	return pd, nil
 }
 
 func (pd *PublicDashboardServiceImpl) GetPublicDashboardForView(ctx context.Context, accessToken string) (*dtos.DashboardFullWithMeta, error) {
@@ -111,13 +233,95 @@ func (pd *PublicDashboardServiceImpl) GetPublicDashboardForView(ctx context.Cont
 		FolderUid:              dash.FolderUID,
 		PublicDashboardEnabled: pubdash.IsEnabled,
 	}
+	applyVariableSnapshot(pubdash, dash.Data)
+	applyVariableDefaults(pubdash, dash.Data)
+	stripHiddenVariables(dash.Data)
+	stripHiddenPanels(pubdash, dash.Data)
+	meta.PublicDashboardReadOnlyVariables = readOnlyVariables(pubdash, dash.Data)
+	if accessToken != pubdash.AccessToken {
+		meta.PublicDashboardAccessToken = pubdash.AccessToken
+	}
 	dash.Data.Get("timepicker").Set("hidden", !pubdash.TimeSelectionEnabled)
 
-	sanitizeData(dash.Data)
+	pd.sanitizationPolicy(pubdash.OrgId).sanitizeDashboard(dash.Data)
+	pd.resolvePublicLinks(ctx, pubdash, dash.Data)
+	pinDashboardVariables(pubdash, dash.Data)
+	newDatasourceUidMasker(pd.cfg.SecretKey, pubdash).maskDashboard(dash.Data)
+
+	pd.recordAccess(ctx, pubdash)
+	pd.usage.recordView(pubdash, time.Now())
 
 	return &dtos.DashboardFullWithMeta{Meta: meta, Dashboard: dash.Data}, nil
 }
 
+// recordAccess updates when the public dashboard was last accessed. To avoid a write on every view, the timestamp is
+// only updated once it's older than lastAccessedAtResolution
+func (pd *PublicDashboardServiceImpl) recordAccess(ctx context.Context, pubdash *PublicDashboard) {
+	now := time.Now()
+	if now.Sub(pubdash.LastAccessedAt) < lastAccessedAtResolution {
+		return
+	}
+
+	if err := pd.store.UpdateLastAccessedAt(ctx, pubdash.Uid, now); err != nil {
+		pd.log.Warn("Failed to record public dashboard access", "publicDashboardUid", pubdash.Uid, "error", err)
+		return
+	}
+	// the cached public dashboard would update the timestamp again on every view until it expires, it's updated in
+	// place as dropping it would make the next viewers look up the public dashboard and its dashboard again
+	pd.accessTokens.touchPublicDashboard(pubdash.Uid, now)
+}
+
+// RecordViewerHeartbeat marks the anonymous viewer session as currently looking at the public dashboard
+func (pd *PublicDashboardServiceImpl) RecordViewerHeartbeat(ctx context.Context, accessToken string, sessionId string) error {
+	ctx, span := tracer.Start(ctx, "publicdashboards.RecordViewerHeartbeat")
+	defer span.End()
+
+	pubdash, _, err := pd.FindEnabledPublicDashboardAndDashboardByAccessToken(ctx, accessToken)
+	if err != nil {
+		return err
+	}
+
+	// viewers of slugs and previous access tokens count towards the current access token
+	pd.presence.heartbeat(pubdash.AccessToken, sessionId, time.Now())
+	return nil
+}
+
+// GetStats returns the current and peak number of concurrent anonymous viewers of a public dashboard and the topN
+// most requested values of each of its variables
+func (pd *PublicDashboardServiceImpl) GetStats(ctx context.Context, orgId int64, dashboardUid string, uid string, topN int) (*PublicDashboardStats, error) {
+	ctx, span := tracer.Start(ctx, "publicdashboards.GetStats")
+	defer span.End()
+
+	pubdash, err := pd.store.Find(ctx, uid)
+	if err != nil {
+		return nil, ErrInternalServerError.Errorf("GetStats: failed to find public dashboard by uid: %s: %w", uid, err)
+	}
+	if pubdash == nil || pubdash.OrgId != orgId {
+		return nil, ErrPublicDashboardNotFound.Errorf("GetStats: public dashboard not found by uid: %s", uid)
+	}
+	if pubdash.DashboardUid != dashboardUid {
+		return nil, ErrInvalidUid.Errorf("GetStats: the public dashboard does not belong to the dashboard")
+	}
+
+	if topN <= 0 {
+		topN = defaultTopVariableValues
+	}
+	if topN > maxTopVariableValues {
+		topN = maxTopVariableValues
+	}
+
+	usage, err := pd.getUsage(ctx, uid)
+	if err != nil {
+		return nil, ErrInternalServerError.Errorf("GetStats: failed to find usage of public dashboard by uid: %s: %w", uid, err)
+	}
+
+	return &PublicDashboardStats{
+		ViewerStats:       *pd.presence.stats(pubdash.AccessToken, time.Now()),
+		Usage:             usage,
+		TopVariableValues: pd.variableUsage.top(pubdash.AccessToken, topN),
+	}, nil
+}
+
 // FindByDashboardUid this method would be replaced by another implementation for Enterprise version
 func (pd *PublicDashboardServiceImpl) FindByDashboardUid(ctx context.Context, orgId int64, dashboardUid string) (*PublicDashboard, error) {
 	ctx, span := tracer.Start(ctx, "publicdashboards.FindByDashboardUid")
@@ -161,6 +365,11 @@ func (pd *PublicDashboardServiceImpl) FindDashboard(ctx context.Context, orgId i
 func (pd *PublicDashboardServiceImpl) FindByAccessToken(ctx context.Context, accessToken string) (*PublicDashboard, error) {
 	ctx, span := tracer.Start(ctx, "publicdashboards.FindByAccessToken")
 	defer span.End()
+	// viewers look up public dashboards by access token, so they are read from the read replica when there's one
+	if pubdash := pd.findByAccessTokenOnReadReplica(ctx, accessToken); pubdash != nil {
+		return pubdash, nil
+	}
+
 	pubdash, err := pd.store.FindByAccessToken(ctx, accessToken)
 	if err != nil {
 		return nil, ErrInternalServerError.Errorf("FindByAccessToken: failed to find a public dashboard: %w", err)
@@ -177,20 +386,58 @@ func (pd *PublicDashboardServiceImpl) FindByAccessToken(ctx context.Context, acc
 func (pd *PublicDashboardServiceImpl) FindEnabledPublicDashboardAndDashboardByAccessToken(ctx context.Context, accessToken string) (*PublicDashboard, *dashboards.Dashboard, error) {
 	ctx, span := tracer.Start(ctx, "publicdashboards.FindEnabledPublicDashboardAndDashboardByAccessToken")
 	defer span.End()
-	pubdash, dash, err := pd.FindPublicDashboardAndDashboardByAccessToken(ctx, accessToken)
-	if err != nil {
-		return pubdash, dash, err
+	pubdash, dash, ok := pd.accessTokens.get(accessToken, time.Now())
+	if !ok {
+		generation := pd.accessTokens.currentGeneration()
+		var err error
+		pubdash, dash, err = pd.FindPublicDashboardAndDashboardByAccessToken(ctx, accessToken)
+		if errors.Is(err, ErrPublicDashboardNotFound) && validation.IsValidSlug(accessToken) {
+			pubdash, dash, err = pd.findPublicDashboardAndDashboardBySlug(ctx, accessToken)
+		}
+		if err != nil {
+			return pubdash, dash, err
+		}
+		// disabled and expired public dashboards are cached too, they're checked on every lookup
+		pd.accessTokens.set(accessToken, pubdash, dash, generation, time.Now())
 	}
 
 	if !pubdash.IsEnabled {
 		return nil, nil, ErrPublicDashboardNotEnabled.Errorf("FindEnabledPublicDashboardAndDashboardByAccessToken: Public dashboard is not enabled accessToken: %s", accessToken)
 	}
 
+	// expired public dashboards are disabled in the background, until then they are already not viewable
+	if pubdash.IsExpired(time.Now()) {
+		return nil, nil, ErrPublicDashboardExpired.Errorf("FindEnabledPublicDashboardAndDashboardByAccessToken: Public dashboard expired at %s accessToken: %s", pubdash.ExpiresAt.UTC(), accessToken)
+	}
+
 	if !pd.license.FeatureEnabled(FeaturePublicDashboardsEmailSharing) && pubdash.Share == EmailShareType {
 		return nil, nil, ErrPublicDashboardNotFound.Errorf("FindEnabledPublicDashboardAndDashboardByAccessToken: Dashboard not found accessToken: %s", accessToken)
 	}
 
-	return pubdash, dash, err
+	return pubdash, dash, nil
+}
+
+// findPublicDashboardAndDashboardBySlug Gets public dashboard and a dashboard by slug
+func (pd *PublicDashboardServiceImpl) findPublicDashboardAndDashboardBySlug(ctx context.Context, slug string) (*PublicDashboard, *dashboards.Dashboard, error) {
+	pubdash, err := pd.store.FindBySlug(ctx, slug)
+	if err != nil {
+		return nil, nil, ErrInternalServerError.Errorf("findPublicDashboardAndDashboardBySlug: failed to find public dashboard by slug: %w", err)
+	}
+
+	if pubdash == nil {
+		return nil, nil, ErrPublicDashboardNotFound.Errorf("findPublicDashboardAndDashboardBySlug: Public dashboard not found slug: %s", slug)
+	}
+
+	dash, err := pd.findDashboardForViewer(ctx, pubdash.OrgId, pubdash.DashboardUid)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if dash == nil {
+		return nil, nil, ErrPublicDashboardNotFound.Errorf("findPublicDashboardAndDashboardBySlug: Dashboard not found slug: %s", slug)
+	}
+
+	return pubdash, dash, nil
 }
 
 // FindPublicDashboardAndDashboardByAccessToken Gets public dashboard and a dashboard by access token
@@ -202,7 +449,7 @@ func (pd *PublicDashboardServiceImpl) FindPublicDashboardAndDashboardByAccessTok
 		return nil, nil, err
 	}
 
-	dash, err := pd.FindDashboard(ctx, pubdash.OrgId, pubdash.DashboardUid)
+	dash, err := pd.findDashboardForViewer(ctx, pubdash.OrgId, pubdash.DashboardUid)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -225,7 +472,7 @@ func (pd *PublicDashboardServiceImpl) Create(ctx context.Context, u *user.Signed
 	}
 
 	// ensure dashboard exists
-	_, err = pd.FindDashboard(ctx, u.OrgID, dto.DashboardUid)
+	dashboard, err := pd.FindDashboard(ctx, u.OrgID, dto.DashboardUid)
 	if err != nil {
 		return nil, err
 	}
@@ -246,17 +493,44 @@ func (pd *PublicDashboardServiceImpl) Create(ctx context.Context, u *user.Signed
 		return nil, ErrDashboardIsPublic.Errorf("Create: public dashboard for dashboard %s already exists", dto.DashboardUid)
 	}
 
+	if err := pd.checkFolderShareable(ctx, dashboard); err != nil {
+		return nil, err
+	}
+
+	if err := pd.checkQuota(ctx, u); err != nil {
+		return nil, err
+	}
+
+	if err := validateSharedPanel(dashboard, dto.PublicDashboard.PanelId); err != nil {
+		return nil, err
+	}
+
+	if err := validateHiddenPanels(dashboard, dto.PublicDashboard.HiddenPanelIds); err != nil {
+		return nil, err
+	}
+
+	if err := validateAnnotationSettings(dashboard, dto.PublicDashboard.AnnotationSettings); err != nil {
+		return nil, err
+	}
+
 	publicDashboard, err := pd.newCreatePublicDashboard(ctx, dto)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := pd.checkLoosenedConstraints(ctx, u, dto.DashboardUid, nil, publicDashboard); err != nil {
+		return nil, err
+	}
+	publicDashboard.VariableSnapshot = pd.snapshotVariables(ctx, dashboard, publicDashboard)
+
 	cmd := SavePublicDashboardCommand{
 		PublicDashboard: *publicDashboard,
 	}
 
 	affectedRows, err := pd.store.Create(ctx, cmd)
-	if err != nil {
+	if errors.Is(err, ErrPublicDashboardSlugExists) {
+		return nil, err
+	} else if err != nil {
 		return nil, ErrInternalServerError.Errorf("Create: failed to create the public dashboard with Uid %s: %w", publicDashboard.Uid, err)
 	} else if affectedRows == 0 {
 		return nil, ErrInternalServerError.Errorf("Create: failed to create a database entry for public dashboard with Uid %s. 0 rows changed, no error reported.", publicDashboard.Uid)
@@ -284,7 +558,7 @@ func (pd *PublicDashboardServiceImpl) Update(ctx context.Context, u *user.Signed
 	}
 
 	// validate dashboard exists
-	_, err = pd.FindDashboard(ctx, u.OrgID, dto.DashboardUid)
+	dashboard, err := pd.FindDashboard(ctx, u.OrgID, dto.DashboardUid)
 	if err != nil {
 		return nil, err
 	}
@@ -302,8 +576,38 @@ func (pd *PublicDashboardServiceImpl) Update(ctx context.Context, u *user.Signed
 		return nil, ErrInvalidUid.Errorf("Update: the public dashboard does not belong to the dashboard")
 	}
 
+	if err := validateSharedPanel(dashboard, dto.PublicDashboard.PanelId); err != nil {
+		return nil, err
+	}
+
+	if err := validateHiddenPanels(dashboard, dto.PublicDashboard.HiddenPanelIds); err != nil {
+		return nil, err
+	}
+
+	if err := validateAnnotationSettings(dashboard, dto.PublicDashboard.AnnotationSettings); err != nil {
+		return nil, err
+	}
+
 	publicDashboard := newUpdatePublicDashboard(dto, existingPubdash)
 
+	if err := pd.checkLoosenedConstraints(ctx, u, dto.DashboardUid, existingPubdash, publicDashboard); err != nil {
+		return nil, err
+	}
+
+	// an expired public dashboard can only be enabled again with a new expiration time
+	if publicDashboard.IsEnabled && !existingPubdash.IsEnabled && publicDashboard.IsExpired(time.Now()) {
+		return nil, ErrInvalidExpiresAt.Errorf("Update: the public dashboard expired, its expiration time must be changed to enable it")
+	}
+
+	// the dashboard may have been moved to a forbidden folder since the public dashboard was created
+	if publicDashboard.IsEnabled {
+		if err := pd.checkFolderShareable(ctx, dashboard); err != nil {
+			return nil, err
+		}
+	}
+
+	publicDashboard.VariableSnapshot = pd.snapshotVariables(ctx, dashboard, existingPubdash)
+
 	// set values to update
 	cmd := SavePublicDashboardCommand{
 		PublicDashboard: *publicDashboard,
@@ -311,7 +615,9 @@ func (pd *PublicDashboardServiceImpl) Update(ctx context.Context, u *user.Signed
 
 	// persist
 	affectedRows, err := pd.store.Update(ctx, cmd)
-	if err != nil {
+	if errors.Is(err, ErrPublicDashboardSlugExists) {
+		return nil, err
+	} else if err != nil {
 		return nil, ErrInternalServerError.Errorf("Update: failed to update public dashboard: %w", err)
 	}
 
@@ -319,6 +625,7 @@ func (pd *PublicDashboardServiceImpl) Update(ctx context.Context, u *user.Signed
 	if affectedRows == 0 {
 		return nil, ErrPublicDashboardNotFound.Errorf("Update: failed to update public dashboard not found by uid: %s", dto.Uid)
 	}
+	pd.PublicDashboardChanged(existingPubdash.Uid)
 
 	// get latest public dashboard to return
 	newPubdash, err := pd.store.Find(ctx, existingPubdash.Uid)
@@ -327,6 +634,7 @@ func (pd *PublicDashboardServiceImpl) Update(ctx context.Context, u *user.Signed
 	}
 
 	pd.logIsEnabledChanged(existingPubdash, newPubdash, u)
+	pd.invalidateLiveViewers(newPubdash)
 
 	return newPubdash, nil
 }
@@ -354,7 +662,7 @@ func (pd *PublicDashboardServiceImpl) NewPublicDashboardAccessToken(ctx context.
 	var accessToken string
 	for i := 0; i < 3; i++ {
 		var err error
-		accessToken, err = GenerateAccessToken()
+		accessToken, err = pd.generateAccessToken()
 		if err != nil {
 			continue
 		}
@@ -383,13 +691,20 @@ func (pd *PublicDashboardServiceImpl) FindAllWithPagination(ctx context.Context,
 		dashUIDs[i] = pubdash.DashboardUid
 	}
 
-	dashboardsFound, err := pd.dashboardService.FindDashboards(ctx, &dashboards.FindPersistedDashboardsQuery{
+	dashboardsQuery := &dashboards.FindPersistedDashboardsQuery{
 		OrgId:         query.OrgID,
 		DashboardUIDs: dashUIDs,
 		SignedInUser:  query.User,
 		Limit:         int64(len(dashUIDs)),
 		Type:          searchstore.TypeDashboard,
-	})
+		// dashboards not matching the title or folder are left out like the ones the user can't access
+		Title: query.Query,
+	}
+	if query.FolderUID != "" {
+		dashboardsQuery.FolderUIDs = []string{query.FolderUID}
+	}
+
+	dashboardsFound, err := pd.dashboardService.FindDashboards(ctx, dashboardsQuery)
 	if err != nil {
 		return nil, ErrInternalServerError.Errorf("FindAllWithPagination: GetDashboards: %w", err)
 	}
@@ -400,7 +715,8 @@ func (pd *PublicDashboardServiceImpl) FindAllWithPagination(ctx context.Context,
 	}
 
 	// add dashboard title & slug to response, and
-	// remove any public dashboards that don't have a corresponding active dashboard that the user has access to
+	// remove any public dashboards that don't have a corresponding active dashboard that the user has access to, or
+	// that doesn't match the filters
 	idx := 0
 	for _, pubdash := range resp.PublicDashboards {
 		if dash, exists := dashMap[pubdash.DashboardUid]; exists {
@@ -408,16 +724,13 @@ func (pd *PublicDashboardServiceImpl) FindAllWithPagination(ctx context.Context,
 			pubdash.Slug = dash.Slug
 			resp.PublicDashboards[idx] = pubdash
 			idx++
-		} else {
-			resp.TotalCount--
 		}
 	}
 	resp.PublicDashboards = resp.PublicDashboards[:idx]
+	// the total only counts the public dashboards the user can see
+	resp.TotalCount = int64(len(resp.PublicDashboards))
 
-	//  sort by title
-	sort.Slice(resp.PublicDashboards, func(i, j int) bool {
-		return resp.PublicDashboards[i].Title < resp.PublicDashboards[j].Title
-	})
+	sortPublicDashboardList(resp.PublicDashboards, query.Sort, query.Direction)
 
 	// and now paginate
 	start := query.Offset
@@ -433,25 +746,102 @@ func (pd *PublicDashboardServiceImpl) FindAllWithPagination(ctx context.Context,
 	resp.Page = query.Page
 	resp.PerPage = query.Limit
 
+	var userID int64
+	if query.User != nil {
+		userID = query.User.UserID
+	}
+	resp.Quota, err = pd.findQuota(ctx, query.OrgID, userID)
+	if err != nil {
+		return nil, ErrInternalServerError.Errorf("FindAllWithPagination: failed to find the public dashboards quota: %w", err)
+	}
+
 	return resp, nil
 }
 
+// sortPublicDashboardList sorts the list of public dashboards by the field and direction, by title and ascending by
+// default. Ties are sorted by title then uid, so pages are stable
+func sortPublicDashboardList(list []*PublicDashboardListResponse, listSort PublicDashboardListSort, direction SortDirection) {
+	compareTitles := func(a, b *PublicDashboardListResponse) int {
+		if c := strings.Compare(a.Title, b.Title); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Uid, b.Uid)
+	}
+
+	compare := compareTitles
+	switch listSort {
+	case PublicDashboardListSortCreated:
+		compare = func(a, b *PublicDashboardListResponse) int {
+			if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+				return c
+			}
+			return compareTitles(a, b)
+		}
+	case PublicDashboardListSortUpdated:
+		compare = func(a, b *PublicDashboardListResponse) int {
+			if c := a.UpdatedAt.Compare(b.UpdatedAt); c != 0 {
+				return c
+			}
+			return compareTitles(a, b)
+		}
+	case PublicDashboardListSortLastViewed:
+		compare = func(a, b *PublicDashboardListResponse) int {
+			if c := a.LastAccessedAt.Compare(b.LastAccessedAt); c != 0 {
+				return c
+			}
+			return compareTitles(a, b)
+		}
+	case PublicDashboardListSortViews:
+		compare = func(a, b *PublicDashboardListResponse) int {
+			if c := cmp.Compare(a.Views, b.Views); c != 0 {
+				return c
+			}
+			return compareTitles(a, b)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if direction == SortDirectionDesc {
+			return compare(list[i], list[j]) > 0
+		}
+		return compare(list[i], list[j]) < 0
+	})
+}
+
 func (pd *PublicDashboardServiceImpl) ExistsEnabledByDashboardUid(ctx context.Context, dashboardUid string) (bool, error) {
 	ctx, span := tracer.Start(ctx, "publicdashboards.ExistsEnabledByDashboardUid")
 	defer span.End()
 	return pd.store.ExistsEnabledByDashboardUid(ctx, dashboardUid)
 }
 
+// ExistsEnabledByAccessToken Responds true if the access token, previous access token or slug belongs to a viewable
+// public dashboard. Expired public dashboards return ErrPublicDashboardExpired
 func (pd *PublicDashboardServiceImpl) ExistsEnabledByAccessToken(ctx context.Context, accessToken string) (bool, error) {
 	ctx, span := tracer.Start(ctx, "publicdashboards.ExistsEnabledByAccessToken")
 	defer span.End()
-	return pd.store.ExistsEnabledByAccessToken(ctx, accessToken)
+	_, _, err := pd.FindEnabledPublicDashboardAndDashboardByAccessToken(ctx, accessToken)
+	if errors.Is(err, ErrPublicDashboardNotFound) || errors.Is(err, ErrPublicDashboardNotEnabled) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
+// GetOrgIdByAccessToken Returns the OrgId of the public dashboard of the access token, previous access token or slug,
+// 0 when there is none
 func (pd *PublicDashboardServiceImpl) GetOrgIdByAccessToken(ctx context.Context, accessToken string) (int64, error) {
 	ctx, span := tracer.Start(ctx, "publicdashboards.GetOrgIdByAccessToken")
 	defer span.End()
-	return pd.store.GetOrgIdByAccessToken(ctx, accessToken)
+	pubdash, err := pd.findByAccessTokenOrSlug(ctx, accessToken)
+	if errors.Is(err, ErrPublicDashboardNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return pubdash.OrgId, nil
 }
 
 func (pd *PublicDashboardServiceImpl) Delete(ctx context.Context, uid string, dashboardUid string) error {
@@ -470,7 +860,17 @@ func (pd *PublicDashboardServiceImpl) Delete(ctx context.Context, uid string, da
 	if existingPubdash.DashboardUid != dashboardUid {
 		return ErrInvalidUid.Errorf("Delete: the public dashboard does not belong to the dashboard")
 	}
-	return pd.serviceWrapper.Delete(ctx, uid)
+	if err := pd.serviceWrapper.Delete(ctx, uid); err != nil {
+		return err
+	}
+
+	pd.presence.forget(existingPubdash.AccessToken)
+	pd.variableUsage.forget(existingPubdash.AccessToken)
+	pd.viewerSessions.forget(uid)
+	pd.usage.forget(uid)
+	pd.PublicDashboardChanged(uid)
+	pd.invalidateLiveViewers(existingPubdash)
+	return nil
 }
 
 // intervalMS and maxQueryData values are being calculated on the frontend for regular dashboards
@@ -535,6 +935,28 @@ func GenerateAccessToken() (string, error) {
 	return fmt.Sprintf("%x", token[:]), nil
 }
 
+// generateAccessToken generates an access token with the length and alphabet of the settings, the default settings
+// keep generating uuids
+func (pd *PublicDashboardServiceImpl) generateAccessToken() (string, error) {
+	if pd.cfg == nil || (pd.cfg.PublicDashboardsAccessTokenLength <= 32 && pd.cfg.PublicDashboardsAccessTokenAlphabet != "base62") {
+		return GenerateAccessToken()
+	}
+
+	length := max(pd.cfg.PublicDashboardsAccessTokenLength, 32)
+	if pd.cfg.PublicDashboardsAccessTokenAlphabet == "base62" {
+		// base62 access tokens need an uppercase letter to be told apart from slugs, the odds of generating one
+		// without any are below one in ten million
+		for {
+			// GetRandomString uses the base62 alphabet by default
+			token, err := util.GetRandomString(length)
+			if err != nil || strings.ToLower(token) != token {
+				return token, err
+			}
+		}
+	}
+	return util.GetRandomString(length, []byte("0123456789abcdef")...)
+}
+
 func (pd *PublicDashboardServiceImpl) newCreatePublicDashboard(ctx context.Context, dto *SavePublicDashboardDTO) (*PublicDashboard, error) {
 	ctx, span := tracer.Start(ctx, "publicdashboards.newCreatePublicDashboard")
 	defer span.End()
@@ -577,22 +999,63 @@ func (pd *PublicDashboardServiceImpl) newCreatePublicDashboard(ctx context.Conte
 		share = PublicShareType
 	}
 
+	queryCachingMode := dto.PublicDashboard.QueryCachingMode
+	if queryCachingMode == "" {
+		queryCachingMode = QueryCachingModeNormal
+	}
+
+	var slug string
+	if dto.PublicDashboard.Slug != nil {
+		slug = *dto.PublicDashboard.Slug
+	}
+
+	var expiresAt time.Time
+	if dto.PublicDashboard.ExpiresAt != nil {
+		expiresAt = *dto.PublicDashboard.ExpiresAt
+	}
+
+	var panelId int64
+	if dto.PublicDashboard.PanelId != nil {
+		panelId = *dto.PublicDashboard.PanelId
+	}
+
+	var maxConcurrentViewers int64
+	if dto.PublicDashboard.MaxConcurrentViewers != nil {
+		maxConcurrentViewers = *dto.PublicDashboard.MaxConcurrentViewers
+	}
+
 	now := time.Now()
 
 	return &PublicDashboard{
-		Uid:                  uid,
-		DashboardUid:         dto.DashboardUid,
-		OrgId:                dto.OrgID,
-		IsEnabled:            isEnabled,
-		AnnotationsEnabled:   annotationsEnabled,
-		TimeSelectionEnabled: timeSelectionEnabled,
-		TimeSettings:         &TimeSettings{},
-		Share:                share,
-		CreatedBy:            dto.UserId,
-		CreatedAt:            now,
-		UpdatedBy:            dto.UserId,
-		UpdatedAt:            now,
-		AccessToken:          accessToken,
+		Uid:                      uid,
+		DashboardUid:             dto.DashboardUid,
+		OrgId:                    dto.OrgID,
+		IsEnabled:                isEnabled,
+		AnnotationsEnabled:       annotationsEnabled,
+		TimeSelectionEnabled:     timeSelectionEnabled,
+		TimeSettings:             &TimeSettings{},
+		Share:                    share,
+		QueryCachingMode:         queryCachingMode,
+		ExportLocale:             dto.PublicDashboard.ExportLocale,
+		GeoRestriction:           normalizeGeoRestriction(dto.PublicDashboard.GeoRestriction),
+		AnnotationSettings:       normalizeAnnotationSettings(dto.PublicDashboard.AnnotationSettings),
+		AllowedDomains:           normalizeAllowedDomains(dto.PublicDashboard.AllowedDomains),
+		VariableConstraints:      dto.PublicDashboard.VariableConstraints,
+		VariablesEnabled:         util.Pointer(returnValueOrDefault(dto.PublicDashboard.VariablesEnabled, true)),
+		VariableOverridesAllowed: dto.PublicDashboard.VariableOverridesAllowed,
+		PinnedVariables:          dto.PublicDashboard.PinnedVariables,
+		VariableDefaults:         dto.PublicDashboard.VariableDefaults,
+		CreatedBy:                dto.UserId,
+		CreatedAt:                now,
+		UpdatedBy:                dto.UserId,
+		UpdatedAt:                now,
+		AccessToken:              accessToken,
+		AccessTokenCreatedAt:     now,
+		Slug:                     slug,
+		ExpiresAt:                expiresAt,
+		PanelId:                  panelId,
+		MaxConcurrentViewers:     maxConcurrentViewers,
+		HiddenPanelIds:           normalizeHiddenPanelIds(dto.PublicDashboard.HiddenPanelIds),
 	}, nil
 }
 
@@ -601,22 +1064,146 @@ func newUpdatePublicDashboard(dto *SavePublicDashboardDTO, pd *PublicDashboard)
 	timeSelectionEnabled := returnValueOrDefault(pubdashDTO.TimeSelectionEnabled, pd.TimeSelectionEnabled)
 	isEnabled := returnValueOrDefault(pubdashDTO.IsEnabled, pd.IsEnabled)
 	annotationsEnabled := returnValueOrDefault(pubdashDTO.AnnotationsEnabled, pd.AnnotationsEnabled)
+	variablesEnabled := util.Pointer(returnValueOrDefault(pubdashDTO.VariablesEnabled, pd.AllowsVariables()))
 
 	share := pubdashDTO.Share
 	if pubdashDTO.Share == "" {
 		share = pd.Share
 	}
 
+	queryCachingMode := pubdashDTO.QueryCachingMode
+	if queryCachingMode == "" {
+		queryCachingMode = pd.QueryCachingMode
+	}
+
+	exportLocale := pubdashDTO.ExportLocale
+	if exportLocale == "" {
+		exportLocale = pd.ExportLocale
+	}
+
+	geoRestriction := pd.GeoRestriction
+	if pubdashDTO.GeoRestriction != nil {
+		geoRestriction = normalizeGeoRestriction(pubdashDTO.GeoRestriction)
+	}
+
+	annotationSettings := pd.AnnotationSettings
+	if pubdashDTO.AnnotationSettings != nil {
+		annotationSettings = normalizeAnnotationSettings(pubdashDTO.AnnotationSettings)
+	}
+
+	allowedDomains := pd.AllowedDomains
+	if pubdashDTO.AllowedDomains != nil {
+		allowedDomains = normalizeAllowedDomains(pubdashDTO.AllowedDomains)
+	}
+
+	variableConstraints := pd.VariableConstraints
+	if pubdashDTO.VariableConstraints != nil {
+		variableConstraints = pubdashDTO.VariableConstraints
+	}
+
+	variableOverridesAllowed := pd.VariableOverridesAllowed
+	if pubdashDTO.VariableOverridesAllowed != nil {
+		variableOverridesAllowed = pubdashDTO.VariableOverridesAllowed
+	}
+
+	pinnedVariables := pd.PinnedVariables
+	if pubdashDTO.PinnedVariables != nil {
+		pinnedVariables = pubdashDTO.PinnedVariables
+	}
+
+	variableDefaults := pd.VariableDefaults
+	if pubdashDTO.VariableDefaults != nil {
+		variableDefaults = pubdashDTO.VariableDefaults
+	}
+
+	slug := pd.Slug
+	if pubdashDTO.Slug != nil {
+		slug = *pubdashDTO.Slug
+	}
+
+	expiresAt := pd.ExpiresAt
+	if pubdashDTO.ExpiresAt != nil {
+		expiresAt = *pubdashDTO.ExpiresAt
+	}
+
+	panelId := pd.PanelId
+	if pubdashDTO.PanelId != nil {
+		panelId = *pubdashDTO.PanelId
+	}
+
+	maxConcurrentViewers := pd.MaxConcurrentViewers
+	if pubdashDTO.MaxConcurrentViewers != nil {
+		maxConcurrentViewers = *pubdashDTO.MaxConcurrentViewers
+	}
+
+	hiddenPanelIds := pd.HiddenPanelIds
+	if pubdashDTO.HiddenPanelIds != nil {
+		hiddenPanelIds = normalizeHiddenPanelIds(pubdashDTO.HiddenPanelIds)
+	}
+
 	return &PublicDashboard{
-		Uid:                  pd.Uid,
-		IsEnabled:            isEnabled,
-		AnnotationsEnabled:   annotationsEnabled,
-		TimeSelectionEnabled: timeSelectionEnabled,
-		TimeSettings:         pd.TimeSettings,
-		Share:                share,
-		UpdatedBy:            dto.UserId,
-		UpdatedAt:            time.Now(),
+		Uid:                      pd.Uid,
+		IsEnabled:                isEnabled,
+		AnnotationsEnabled:       annotationsEnabled,
+		TimeSelectionEnabled:     timeSelectionEnabled,
+		TimeSettings:             pd.TimeSettings,
+		Share:                    share,
+		QueryCachingMode:         queryCachingMode,
+		ExportLocale:             exportLocale,
+		GeoRestriction:           geoRestriction,
+		AnnotationSettings:       annotationSettings,
+		AllowedDomains:           allowedDomains,
+		VariableConstraints:      variableConstraints,
+		VariablesEnabled:         variablesEnabled,
+		VariableOverridesAllowed: variableOverridesAllowed,
+		PinnedVariables:          pinnedVariables,
+		VariableDefaults:         variableDefaults,
+		Slug:                     slug,
+		ExpiresAt:                expiresAt,
+		PanelId:                  panelId,
+		MaxConcurrentViewers:     maxConcurrentViewers,
+		HiddenPanelIds:           hiddenPanelIds,
+		UpdatedBy:                dto.UserId,
+		UpdatedAt:                time.Now(),
+	}
+}
+
+// normalizeGeoRestriction upper cases country codes and drops restrictions without a mode
+func normalizeGeoRestriction(gr *GeoRestriction) *GeoRestriction {
+	if gr == nil || gr.Mode == "" {
+		return nil
 	}
+
+	countries := make([]string, 0, len(gr.Countries))
+	for _, country := range gr.Countries {
+		countries = append(countries, strings.ToUpper(country))
+	}
+
+	return &GeoRestriction{Mode: gr.Mode, Countries: countries}
+}
+
+// normalizeAllowedDomains lower cases the allowed domains, an empty list removes the restriction
+func normalizeAllowedDomains(domains []string) []string {
+	if len(domains) == 0 {
+		return nil
+	}
+
+	normalized := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		normalized = append(normalized, strings.ToLower(domain))
+	}
+	return normalized
+}
+
+// normalizeHiddenPanelIds sorts the hidden panels and drops duplicates, an empty list shows every panel
+func normalizeHiddenPanelIds(panelIds []int64) []int64 {
+	if len(panelIds) == 0 {
+		return nil
+	}
+
+	normalized := slices.Clone(panelIds)
+	slices.Sort(normalized)
+	return slices.Compact(normalized)
 }
 
 func returnValueOrDefault(value *bool, defaultValue bool) bool {
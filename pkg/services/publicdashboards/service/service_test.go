@@ -17,15 +17,18 @@ import (
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/apimachinery/errutil"
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	dashboardsDB "github.com/grafana/grafana/pkg/services/dashboards/database"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/licensing/licensingtest"
 	. "github.com/grafana/grafana/pkg/services/publicdashboards"
 	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
 	"github.com/grafana/grafana/pkg/services/publicdashboards/service/intervalv2"
 	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
 	"github.com/grafana/grafana/pkg/services/tag/tagimpl"
 	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/tests/testsuite"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/util/testutil"
@@ -385,6 +388,7 @@ func TestIntegrationGetPublicDashboardForView(t *testing.T) {
 		t.Run(test.Name, func(t *testing.T) {
 			fakeStore := &FakePublicDashboardStore{}
 			fakeStore.On("FindByAccessToken", mock.Anything, mock.Anything).Return(test.StoreResp.pd, test.StoreResp.err)
+			fakeStore.On("UpdateLastAccessedAt", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
 			fakeDashboardService := &dashboards.FakeDashboardService{}
 			fakeDashboardService.On("GetDashboard", mock.Anything, mock.Anything, mock.Anything).Return(test.StoreResp.d, test.StoreResp.err)
 			service, _, _ := newPublicDashboardServiceImpl(t, nil, nil, fakeStore, fakeDashboardService, nil)
@@ -422,7 +426,7 @@ func TestIntegrationGetPublicDashboardForView(t *testing.T) {
 					// if the panel is a row and it is collapsed, get the queries from the panels inside the row
 					if panel.Get("type").MustString() == "row" && panel.Get("collapsed").MustBool() {
 						// recursive call to get queries from panels inside a row
-						sanitizeData(panel)
+						newSanitizationPolicy(setting.PublicDashboardsSanitizationPolicy{}).sanitizeDashboard(panel)
 						continue
 					}
 
@@ -582,6 +586,211 @@ func TestIntegrationGetEnabledPublicDashboard(t *testing.T) {
 	}
 }
 
+func TestFindEnabledPublicDashboardAndDashboardBySlug(t *testing.T) {
+	dashboard := &dashboards.Dashboard{UID: "mydashboard", OrgID: 1, Data: dashboardData}
+
+	setup := func(t *testing.T, bySlug *PublicDashboard) *PublicDashboardServiceImpl {
+		fakeStore := &FakePublicDashboardStore{}
+		fakeStore.On("FindByAccessToken", mock.Anything, mock.Anything).Return(nil, nil)
+		fakeStore.On("FindBySlug", mock.Anything, "status-page").Return(bySlug, nil)
+		fakeDashboardService := &dashboards.FakeDashboardService{}
+		fakeDashboardService.On("GetDashboard", mock.Anything, mock.Anything, mock.Anything).Return(dashboard, nil).Maybe()
+
+		license := licensingtest.NewFakeLicensing()
+		license.On("FeatureEnabled", FeaturePublicDashboardsEmailSharing).Return(false).Maybe()
+
+		return &PublicDashboardServiceImpl{
+			log:              log.NewNopLogger(),
+			cfg:              setting.NewCfg(),
+			store:            fakeStore,
+			dashboardService: fakeDashboardService,
+			license:          license,
+		}
+	}
+
+	t.Run("Finds the public dashboard by slug when no access token matches", func(t *testing.T) {
+		pubdash := &PublicDashboard{Uid: "uid1", OrgId: 1, DashboardUid: dashboard.UID, AccessToken: "abc123", Slug: "status-page", IsEnabled: true}
+		service := setup(t, pubdash)
+
+		pdc, dash, err := service.FindEnabledPublicDashboardAndDashboardByAccessToken(context.Background(), "status-page")
+		require.NoError(t, err)
+		assert.Equal(t, pubdash, pdc)
+		assert.Equal(t, dashboard, dash)
+	})
+
+	t.Run("Doesn't find unknown slugs", func(t *testing.T) {
+		service := setup(t, nil)
+
+		_, _, err := service.FindEnabledPublicDashboardAndDashboardByAccessToken(context.Background(), "status-page")
+		assert.ErrorIs(t, err, ErrPublicDashboardNotFound)
+	})
+
+	t.Run("Doesn't find paused public dashboards by slug", func(t *testing.T) {
+		service := setup(t, &PublicDashboard{Uid: "uid1", OrgId: 1, DashboardUid: dashboard.UID, Slug: "status-page"})
+
+		_, _, err := service.FindEnabledPublicDashboardAndDashboardByAccessToken(context.Background(), "status-page")
+		assert.ErrorIs(t, err, ErrPublicDashboardNotEnabled)
+	})
+
+	t.Run("Doesn't find expired public dashboards", func(t *testing.T) {
+		service := setup(t, &PublicDashboard{Uid: "uid1", OrgId: 1, DashboardUid: dashboard.UID, Slug: "status-page", IsEnabled: true, ExpiresAt: time.Now().Add(-time.Minute)})
+
+		_, _, err := service.FindEnabledPublicDashboardAndDashboardByAccessToken(context.Background(), "status-page")
+		assert.ErrorIs(t, err, ErrPublicDashboardExpired)
+	})
+}
+
+func TestLookupsByAccessTokenOrSlug(t *testing.T) {
+	dashboard := &dashboards.Dashboard{UID: "mydashboard", OrgID: 3, Data: dashboardData}
+
+	setup := func(t *testing.T, bySlug *PublicDashboard) *PublicDashboardServiceImpl {
+		fakeStore := &FakePublicDashboardStore{}
+		fakeStore.On("FindByAccessToken", mock.Anything, mock.Anything).Return(nil, nil)
+		fakeStore.On("FindBySlug", mock.Anything, "status-page").Return(bySlug, nil)
+		fakeDashboardService := &dashboards.FakeDashboardService{}
+		fakeDashboardService.On("GetDashboard", mock.Anything, mock.Anything, mock.Anything).Return(dashboard, nil).Maybe()
+
+		license := licensingtest.NewFakeLicensing()
+		license.On("FeatureEnabled", FeaturePublicDashboardsEmailSharing).Return(false).Maybe()
+
+		return &PublicDashboardServiceImpl{
+			log:              log.NewNopLogger(),
+			cfg:              setting.NewCfg(),
+			store:            fakeStore,
+			dashboardService: fakeDashboardService,
+			license:          license,
+			presence:         newPresenceTracker(),
+		}
+	}
+
+	t.Run("finds enabled public dashboards by slug", func(t *testing.T) {
+		service := setup(t, &PublicDashboard{Uid: "uid1", OrgId: 3, DashboardUid: dashboard.UID, AccessToken: "abc123", Slug: "status-page", IsEnabled: true})
+
+		exists, err := service.ExistsEnabledByAccessToken(context.Background(), "status-page")
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("doesn't find unknown or paused public dashboards", func(t *testing.T) {
+		exists, err := setup(t, nil).ExistsEnabledByAccessToken(context.Background(), "status-page")
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		paused := setup(t, &PublicDashboard{Uid: "uid1", OrgId: 3, DashboardUid: dashboard.UID, Slug: "status-page"})
+		exists, err = paused.ExistsEnabledByAccessToken(context.Background(), "status-page")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("tells expired public dashboards apart", func(t *testing.T) {
+		service := setup(t, &PublicDashboard{Uid: "uid1", OrgId: 3, DashboardUid: dashboard.UID, Slug: "status-page", IsEnabled: true, ExpiresAt: time.Now().Add(-time.Minute)})
+
+		exists, err := service.ExistsEnabledByAccessToken(context.Background(), "status-page")
+		assert.ErrorIs(t, err, ErrPublicDashboardExpired)
+		assert.False(t, exists)
+	})
+
+	t.Run("gets the org of slugs, 0 for unknown ones", func(t *testing.T) {
+		service := setup(t, &PublicDashboard{Uid: "uid1", OrgId: 3, DashboardUid: dashboard.UID, Slug: "status-page"})
+		orgID, err := service.GetOrgIdByAccessToken(context.Background(), "status-page")
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), orgID)
+
+		orgID, err = setup(t, nil).GetOrgIdByAccessToken(context.Background(), "status-page")
+		require.NoError(t, err)
+		assert.Zero(t, orgID)
+	})
+
+	t.Run("counts heartbeats of slugs towards the access token", func(t *testing.T) {
+		service := setup(t, &PublicDashboard{Uid: "uid1", OrgId: 3, DashboardUid: dashboard.UID, AccessToken: "abc123", Slug: "status-page", IsEnabled: true})
+
+		require.NoError(t, service.RecordViewerHeartbeat(context.Background(), "status-page", "session1"))
+		assert.Equal(t, 1, service.presence.stats("abc123", time.Now()).CurrentViewers)
+	})
+}
+
+func TestFindAllWithPagination(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+
+	setup := func(t *testing.T) *PublicDashboardServiceImpl {
+		fakeStore := &FakePublicDashboardStore{}
+		fakeStore.On("FindAll", mock.Anything, mock.Anything).Return(&PublicDashboardListResponseWithPagination{
+			PublicDashboards: []*PublicDashboardListResponse{
+				{Uid: "pd-b", DashboardUid: "dash-b", CreatedAt: day(1), UpdatedAt: day(5), LastAccessedAt: day(9)},
+				{Uid: "pd-a", DashboardUid: "dash-a", CreatedAt: day(2), UpdatedAt: day(4)},
+				{Uid: "pd-c", DashboardUid: "dash-c", CreatedAt: day(3), UpdatedAt: day(6), LastAccessedAt: day(7)},
+				{Uid: "pd-hidden", DashboardUid: "dash-hidden", CreatedAt: day(4), UpdatedAt: day(4)},
+			},
+			TotalCount: 4,
+		}, nil)
+
+		fakeDashboardService := &dashboards.FakeDashboardService{}
+		fakeDashboardService.On("FindDashboards", mock.Anything, mock.Anything).Return([]dashboards.DashboardSearchProjection{
+			{UID: "dash-a", Title: "A"},
+			{UID: "dash-b", Title: "B"},
+			{UID: "dash-c", Title: "C"},
+		}, nil)
+
+		return &PublicDashboardServiceImpl{
+			log:              log.NewNopLogger(),
+			store:            fakeStore,
+			dashboardService: fakeDashboardService,
+		}
+	}
+
+	uids := func(resp *PublicDashboardListResponseWithPagination) []string {
+		result := make([]string, 0, len(resp.PublicDashboards))
+		for _, pubdash := range resp.PublicDashboards {
+			result = append(result, pubdash.Uid)
+		}
+		return result
+	}
+
+	testCases := []struct {
+		name      string
+		sort      PublicDashboardListSort
+		direction SortDirection
+		expected  []string
+	}{
+		{name: "sorts by title by default", expected: []string{"pd-a", "pd-b", "pd-c"}},
+		{name: "sorts by title descending", sort: PublicDashboardListSortTitle, direction: SortDirectionDesc, expected: []string{"pd-c", "pd-b", "pd-a"}},
+		{name: "sorts by created", sort: PublicDashboardListSortCreated, expected: []string{"pd-b", "pd-a", "pd-c"}},
+		{name: "sorts by updated descending", sort: PublicDashboardListSortUpdated, direction: SortDirectionDesc, expected: []string{"pd-c", "pd-b", "pd-a"}},
+		{name: "sorts by last viewed descending", sort: PublicDashboardListSortLastViewed, direction: SortDirectionDesc, expected: []string{"pd-b", "pd-c", "pd-a"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := setup(t).FindAllWithPagination(context.Background(), &PublicDashboardListQuery{
+				OrgID: 1, Page: 1, Limit: 10, Sort: tc.sort, Direction: tc.direction,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, uids(resp))
+		})
+	}
+
+	t.Run("filters dashboards by title and folder", func(t *testing.T) {
+		service := setup(t)
+		_, err := service.FindAllWithPagination(context.Background(), &PublicDashboardListQuery{OrgID: 1, Page: 1, Limit: 10, Query: "sales", FolderUID: "folder1"})
+		require.NoError(t, err)
+
+		fakeDashboardService := service.dashboardService.(*dashboards.FakeDashboardService)
+		fakeDashboardService.AssertCalled(t, "FindDashboards", mock.Anything, mock.MatchedBy(func(query *dashboards.FindPersistedDashboardsQuery) bool {
+			return query.Title == "sales" && len(query.FolderUIDs) == 1 && query.FolderUIDs[0] == "folder1"
+		}))
+	})
+
+	t.Run("counts the public dashboards the user can see in the total", func(t *testing.T) {
+		resp, err := setup(t).FindAllWithPagination(context.Background(), &PublicDashboardListQuery{OrgID: 1, Page: 2, Limit: 2})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"pd-c"}, uids(resp))
+		assert.Equal(t, int64(3), resp.TotalCount)
+		assert.Equal(t, 2, resp.Page)
+		assert.Equal(t, 2, resp.PerPage)
+	})
+}
+
 // We're using sqlite here because testing all of the behaviors with mocks in
 // the correct order is convoluted.
 func TestIntegrationCreatePublicDashboard(t *testing.T) {
@@ -627,6 +836,9 @@ func TestIntegrationCreatePublicDashboard(t *testing.T) {
 		// CreatedAt set to non-zero time
 		assert.NotEqual(t, &time.Time{}, pubdash.CreatedAt)
 		assert.Equal(t, dto.PublicDashboard.Share, pubdash.Share)
+		// viewers can change variables unless disabled
+		require.NotNil(t, pubdash.VariablesEnabled)
+		assert.True(t, *pubdash.VariablesEnabled)
 		// accessToken is valid uuid
 		_, err = uuid.Parse(pubdash.AccessToken)
 		require.NoError(t, err, "expected a valid UUID, got %s", pubdash.AccessToken)
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/validation"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+// ExportConfig returns the share config of the public dashboard with its access token, to import it against another
+// dashboard or in another org or instance
+func (pd *PublicDashboardServiceImpl) ExportConfig(ctx context.Context, u *user.SignedInUser, uid string, dashboardUid string) (*PublicDashboardConfigExport, error) {
+	ctx, span := tracer.Start(ctx, "publicdashboards.ExportConfig")
+	defer span.End()
+
+	pubdash, err := pd.store.Find(ctx, uid)
+	if err != nil {
+		return nil, ErrInternalServerError.Errorf("ExportConfig: failed to find public dashboard by uid: %s: %w", uid, err)
+	}
+	if pubdash == nil || pubdash.OrgId != u.OrgID {
+		return nil, ErrPublicDashboardNotFound.Errorf("ExportConfig: public dashboard not found by uid: %s", uid)
+	}
+
+	// validate the public dashboard belongs to the dashboard
+	if pubdash.DashboardUid != dashboardUid {
+		return nil, ErrInvalidUid.Errorf("ExportConfig: the public dashboard does not belong to the dashboard")
+	}
+
+	return &PublicDashboardConfigExport{
+		Version:      PublicDashboardConfigExportVersion,
+		Uid:          pubdash.Uid,
+		DashboardUid: pubdash.DashboardUid,
+		ExportedAt:   time.Now().UTC(),
+		Config:       exportedConfig(pubdash),
+	}, nil
+}
+
+// ImportConfig creates a public dashboard of the dashboard with an exported share config. The config is validated
+// like the configs of new public dashboards, so the shared and hidden panels have to exist in the dashboard
+func (pd *PublicDashboardServiceImpl) ImportConfig(ctx context.Context, u *user.SignedInUser, dashboardUid string, dto *PublicDashboardConfigImportDTO) (*PublicDashboard, error) {
+	ctx, span := tracer.Start(ctx, "publicdashboards.ImportConfig")
+	defer span.End()
+
+	if dto.Export.Version != PublicDashboardConfigExportVersion {
+		return nil, ErrBadRequest.Errorf("ImportConfig: unsupported export version %d", dto.Export.Version)
+	}
+
+	config := dto.Export.Config
+	// the imported public dashboard is a new one, the uid of the exported one may be taken
+	config.Uid = ""
+	if !dto.PreserveAccessToken {
+		config.AccessToken = ""
+	} else if !validation.IsValidAccessToken(config.AccessToken) {
+		return nil, ErrInvalidAccessToken.Errorf("ImportConfig: invalid Access Token %s", config.AccessToken)
+	} else if !pd.ownsAccessToken(ctx, u.OrgID, dto.Export.Uid, config.AccessToken) {
+		// access tokens this instance didn't generate for the public dashboard could have been picked by the caller,
+		// they're replaced by one following the entropy settings
+		config.AccessToken = ""
+	}
+
+	return pd.Create(ctx, u, &SavePublicDashboardDTO{
+		DashboardUid:    dashboardUid,
+		OrgID:           u.OrgID,
+		UserId:          u.UserID,
+		PublicDashboard: &config,
+	})
+}
+
+// ownsAccessToken returns true when the access token belongs to the public dashboard of the org in this instance
+func (pd *PublicDashboardServiceImpl) ownsAccessToken(ctx context.Context, orgId int64, uid string, accessToken string) bool {
+	if uid == "" {
+		return false
+	}
+	pubdash, err := pd.store.FindByAccessToken(ctx, accessToken)
+	if err != nil || pubdash == nil {
+		return false
+	}
+	return pubdash.Uid == uid && pubdash.OrgId == orgId
+}
+
+// exportedConfig returns every setting of the public dashboard, as they're set when creating one
+func exportedConfig(pubdash *PublicDashboard) PublicDashboardDTO {
+	config := PublicDashboardDTO{
+		AccessToken:              pubdash.AccessToken,
+		TimeSelectionEnabled:     &pubdash.TimeSelectionEnabled,
+		IsEnabled:                &pubdash.IsEnabled,
+		AnnotationsEnabled:       &pubdash.AnnotationsEnabled,
+		Share:                    pubdash.Share,
+		QueryCachingMode:         pubdash.QueryCachingMode,
+		ExportLocale:             pubdash.ExportLocale,
+		GeoRestriction:           pubdash.GeoRestriction,
+		AnnotationSettings:       pubdash.AnnotationSettings,
+		AllowedDomains:           pubdash.AllowedDomains,
+		VariableConstraints:      pubdash.VariableConstraints,
+		VariablesEnabled:         pubdash.VariablesEnabled,
+		VariableOverridesAllowed: pubdash.VariableOverridesAllowed,
+		PinnedVariables:          pubdash.PinnedVariables,
+		VariableDefaults:         pubdash.VariableDefaults,
+		PanelId:                  &pubdash.PanelId,
+		MaxConcurrentViewers:     &pubdash.MaxConcurrentViewers,
+		HiddenPanelIds:           pubdash.HiddenPanelIds,
+	}
+	if pubdash.Slug != "" {
+		config.Slug = &pubdash.Slug
+	}
+	if !pubdash.ExpiresAt.IsZero() {
+		config.ExpiresAt = &pubdash.ExpiresAt
+	}
+	return config
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	dashboardsDB "github.com/grafana/grafana/pkg/services/dashboards/database"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/tag/tagimpl"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util/testutil"
+)
+
+func TestExportConfig(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	pubdash := &PublicDashboard{
+		Uid:                      "uid1",
+		OrgId:                    1,
+		DashboardUid:             "dash1",
+		AccessToken:              "e71950f4b5fc4a9d8a34a2d1b2d7e5e0",
+		IsEnabled:                true,
+		Share:                    PublicShareType,
+		Slug:                     "status-page",
+		ExpiresAt:                expiresAt,
+		VariableOverridesAllowed: []string{"env"},
+		HiddenPanelIds:           []int64{2},
+	}
+
+	setup := func() *PublicDashboardServiceImpl {
+		store := &FakePublicDashboardStore{}
+		store.On("Find", mock.Anything, "uid1").Return(pubdash, nil)
+		return &PublicDashboardServiceImpl{
+			log:   log.NewNopLogger(),
+			cfg:   setting.NewCfg(),
+			store: store,
+		}
+	}
+
+	t.Run("exports every setting with the access token", func(t *testing.T) {
+		export, err := setup().ExportConfig(context.Background(), &user.SignedInUser{OrgID: 1}, "uid1", "dash1")
+		require.NoError(t, err)
+
+		assert.Equal(t, PublicDashboardConfigExportVersion, export.Version)
+		assert.Equal(t, "uid1", export.Uid)
+		assert.Equal(t, "dash1", export.DashboardUid)
+		assert.Empty(t, export.Config.Uid)
+		assert.Equal(t, pubdash.AccessToken, export.Config.AccessToken)
+		assert.True(t, *export.Config.IsEnabled)
+		assert.Equal(t, "status-page", *export.Config.Slug)
+		assert.Equal(t, expiresAt, *export.Config.ExpiresAt)
+		assert.Equal(t, []string{"env"}, export.Config.VariableOverridesAllowed)
+		assert.Equal(t, []int64{2}, export.Config.HiddenPanelIds)
+	})
+
+	t.Run("doesn't export public dashboards of other orgs or dashboards", func(t *testing.T) {
+		_, err := setup().ExportConfig(context.Background(), &user.SignedInUser{OrgID: 2}, "uid1", "dash1")
+		assert.ErrorIs(t, err, ErrPublicDashboardNotFound)
+
+		_, err = setup().ExportConfig(context.Background(), &user.SignedInUser{OrgID: 1}, "uid1", "dash2")
+		assert.ErrorIs(t, err, ErrInvalidUid)
+	})
+}
+
+func TestImportConfigValidation(t *testing.T) {
+	service := &PublicDashboardServiceImpl{log: log.NewNopLogger(), cfg: setting.NewCfg()}
+	u := &user.SignedInUser{OrgID: 1}
+
+	t.Run("rejects configs of other versions", func(t *testing.T) {
+		_, err := service.ImportConfig(context.Background(), u, "dash1", &PublicDashboardConfigImportDTO{Export: PublicDashboardConfigExport{Version: 2}})
+		assert.ErrorIs(t, err, ErrBadRequest)
+	})
+
+	t.Run("rejects invalid access tokens to preserve", func(t *testing.T) {
+		_, err := service.ImportConfig(context.Background(), u, "dash1", &PublicDashboardConfigImportDTO{
+			Export:              PublicDashboardConfigExport{Version: PublicDashboardConfigExportVersion, Config: PublicDashboardDTO{AccessToken: "not a token"}},
+			PreserveAccessToken: true,
+		})
+		assert.ErrorIs(t, err, ErrInvalidAccessToken)
+	})
+}
+
+func TestIntegrationImportConfig(t *testing.T) {
+	testutil.SkipIntegrationTestInShortMode(t)
+
+	fakeDashboardService := &dashboards.FakeDashboardService{}
+	service, sqlStore, cfg := newPublicDashboardServiceImpl(t, nil, nil, nil, fakeDashboardService, nil)
+	dashboardStore, err := dashboardsDB.ProvideDashboardStore(sqlStore, cfg, featuremgmt.WithFeatures(), tagimpl.ProvideService(sqlStore))
+	require.NoError(t, err)
+
+	source := insertTestDashboard(t, dashboardStore, "source", 1, 0, "", true, []map[string]any{}, nil)
+	target := insertTestDashboard(t, dashboardStore, "target", 1, 0, "", true, []map[string]any{}, nil)
+	other := insertTestDashboard(t, dashboardStore, "other", 1, 0, "", true, []map[string]any{}, nil)
+	fakeDashboardService.On("GetDashboard", mock.Anything, mock.Anything).Return(
+		func(_ context.Context, query *dashboards.GetDashboardQuery) (*dashboards.Dashboard, error) {
+			switch query.UID {
+			case target.UID:
+				return target, nil
+			case other.UID:
+				return other, nil
+			}
+			return source, nil
+		})
+
+	isEnabled, timeSelectionEnabled := true, true
+	exported, err := service.Create(context.Background(), SignedInUser, &SavePublicDashboardDTO{
+		DashboardUid: source.UID,
+		OrgID:        source.OrgID,
+		UserId:       SignedInUser.UserID,
+		PublicDashboard: &PublicDashboardDTO{
+			IsEnabled:            &isEnabled,
+			TimeSelectionEnabled: &timeSelectionEnabled,
+			AllowedDomains:       []string{"example.com"},
+		},
+	})
+	require.NoError(t, err)
+
+	export, err := service.ExportConfig(context.Background(), SignedInUser, exported.Uid, source.UID)
+	require.NoError(t, err)
+
+	t.Run("the access token can't be preserved while it's used", func(t *testing.T) {
+		_, err := service.ImportConfig(context.Background(), SignedInUser, target.UID, &PublicDashboardConfigImportDTO{Export: *export, PreserveAccessToken: true})
+		assert.ErrorIs(t, err, ErrPublicDashboardAccessTokenExists)
+	})
+
+	t.Run("generates a new access token when the exported public dashboard doesn't own it", func(t *testing.T) {
+		forged := *export
+		forged.Uid = "other-uid"
+
+		imported, err := service.ImportConfig(context.Background(), SignedInUser, other.UID, &PublicDashboardConfigImportDTO{Export: forged, PreserveAccessToken: true})
+		require.NoError(t, err)
+
+		assert.NotEqual(t, exported.AccessToken, imported.AccessToken)
+	})
+
+	t.Run("imports the config against another dashboard with a new access token", func(t *testing.T) {
+		imported, err := service.ImportConfig(context.Background(), SignedInUser, target.UID, &PublicDashboardConfigImportDTO{Export: *export})
+		require.NoError(t, err)
+
+		assert.Equal(t, target.UID, imported.DashboardUid)
+		assert.NotEqual(t, exported.Uid, imported.Uid)
+		assert.NotEqual(t, exported.AccessToken, imported.AccessToken)
+		assert.True(t, imported.IsEnabled)
+		assert.True(t, imported.TimeSelectionEnabled)
+		assert.Equal(t, []string{"example.com"}, imported.AllowedDomains)
+	})
+}
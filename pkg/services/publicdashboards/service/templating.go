@@ -0,0 +1,416 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+// variableRegex matches variable references like the dashboard does in the browser: $var, [[var]], [[var:format]] and
+// ${var}, ${var.fieldPath} or ${var:format}. An escaped dollar sign ($$) is matched first so it's never read as a
+// reference
+var variableRegex = regexp.MustCompile(`\$\$|\$(\w+)|\[\[(\w+?)(?::(\w+))?\]\]|\$\{(\w+)(?:\.([^:^}]+))?(?::([^}]+))?\}`)
+
+const (
+	allVariableValue = "$__all"
+	allVariableText  = "All"
+
+	adhocVariableType = "adhoc"
+
+	// fromVariable and toVariable are the global variables holding the time range of the query in epoch milliseconds
+	fromVariable = "__from"
+	toVariable   = "__to"
+)
+
+// templateVariable holds the parts of a dashboard variable definition used to resolve its value
+type templateVariable struct {
+	Name       string           `json:"name"`
+	Type       string           `json:"type"`
+	Multi      bool             `json:"multi"`
+	IncludeAll bool             `json:"includeAll"`
+	AllValue   string           `json:"allValue"`
+	Current    variableCurrent  `json:"current"`
+	Options    []variableOption `json:"options"`
+	// Auto, AutoCount and AutoMin configure the auto option of interval variables
+	Auto      bool   `json:"auto"`
+	AutoCount int    `json:"auto_count"`
+	AutoMin   string `json:"auto_min"`
+}
+
+// scopedVariable is a variable value set for a single panel, like the value of a repeated panel
+type scopedVariable struct {
+	Text  interface{} `json:"text"`
+	Value interface{} `json:"value"`
+}
+
+// variableReference is a variable reference matched by variableRegex
+type variableReference struct {
+	name      string
+	fieldPath string
+	format    string
+}
+
+// templateInterpolator resolves variables the same way the dashboard does for signed in users: values sent by the
+// viewer take precedence over the current values saved in the dashboard, "All" is expanded to the custom all value or
+// to every option, ad hoc filters are never interpolated and values are formatted with the requested format, glob by
+// default
+type templateInterpolator struct {
+	variables map[string]templateVariable
+	values    map[string]interface{}
+	// allOptions holds the values "All" expands to for variables whose options were resolved again, used over the
+	// options saved in the dashboard
+	allOptions map[string][]interface{}
+}
+
+func newTemplateInterpolator(dashboard *simplejson.Json, values map[string]interface{}) *templateInterpolator {
+	t := &templateInterpolator{
+		variables:  map[string]templateVariable{},
+		values:     values,
+		allOptions: map[string][]interface{}{},
+	}
+
+	if dashboard == nil {
+		return t
+	}
+
+	for _, v := range dashboardVariableList(dashboard) {
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			continue
+		}
+		var variable templateVariable
+		if err := json.Unmarshal(encoded, &variable); err != nil || variable.Name == "" {
+			continue
+		}
+		t.variables[variable.Name] = variable
+	}
+
+	return t
+}
+
+// withTimeRangeVariables returns the variables along with the global __from and __to variables of the time range
+func withTimeRangeVariables(variables map[string]interface{}, ts models.TimeSettings) map[string]interface{} {
+	withTimeRange := make(map[string]interface{}, len(variables)+2)
+	for name, value := range variables {
+		withTimeRange[name] = value
+	}
+	withTimeRange[fromVariable] = ts.From
+	withTimeRange[toVariable] = ts.To
+	return withTimeRange
+}
+
+// scopedVariables returns the variables set on a panel, used by panels repeated by a variable
+func scopedVariables(panel *simplejson.Json) map[string]scopedVariable {
+	scoped := map[string]scopedVariable{}
+	for name, v := range panel.Get("scopedVars").MustMap() {
+		value, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		scoped[name] = scopedVariable{Text: value["text"], Value: value["value"]}
+	}
+	return scoped
+}
+
+// interpolate replaces the variable references of the text. References without a format use the given format, and
+// references to unknown variables are left untouched
+func (t *templateInterpolator) interpolate(text string, scopedVars map[string]scopedVariable, format string) string {
+	return t.replace(text, scopedVars, format, nil, 0)
+}
+
+// interpolateQuery replaces the variable references of a query field. References without a format are formatted
+// the way the datasource of the query does it in the browser
+func (t *templateInterpolator) interpolateQuery(text string, scopedVars map[string]scopedVariable, datasourceType string) string {
+	return t.replace(text, scopedVars, "", datasourceVariableFormatters[datasourceType], 0)
+}
+
+func (t *templateInterpolator) replace(text string, scopedVars map[string]scopedVariable, format string, datasourceFormatter variableFormatter, depth int) string {
+	return variableRegex.ReplaceAllStringFunc(text, func(match string) string {
+		if match == "$$" {
+			return "$"
+		}
+
+		ref := parseVariableReference(match)
+		if ref.format == "" {
+			ref.format = format
+		}
+
+		value, ok := t.evaluate(ref, scopedVars, datasourceFormatter, depth)
+		if !ok {
+			return match
+		}
+		return value
+	})
+}
+
+// typedValue returns the value of the referenced variable without converting it to a string when the text consists
+// of exactly one variable reference without a format, or with the raw format
+func (t *templateInterpolator) typedValue(text string, scopedVars map[string]scopedVariable) (interface{}, bool) {
+	loc := variableRegex.FindStringIndex(text)
+	if loc == nil || loc[0] != 0 || loc[1] != len(text) || text == "$$" {
+		return nil, false
+	}
+
+	ref := parseVariableReference(text)
+	if ref.fieldPath != "" || (ref.format != "" && ref.format != rawVariableFormat) {
+		return nil, false
+	}
+
+	if scoped, ok := scopedVars[ref.name]; ok && scoped.Value != nil {
+		return scoped.Value, true
+	}
+
+	variable, value, ok := t.lookup(ref.name)
+	if !ok || value == nil || variable.Type == adhocVariableType {
+		return nil, false
+	}
+
+	if isAllVariableValue(value) {
+		if variable.AllValue != "" {
+			return variable.AllValue, true
+		}
+		return t.allValues(variable), true
+	}
+
+	return value, true
+}
+
+// evaluate returns the formatted value of a variable reference, and false when the variable is unknown
+func (t *templateInterpolator) evaluate(ref variableReference, scopedVars map[string]scopedVariable, datasourceFormatter variableFormatter, depth int) (string, bool) {
+	if scoped, ok := scopedVars[ref.name]; ok {
+		value := scoped.Value
+		if ref.fieldPath != "" {
+			value = fieldPathValue(scoped.Value, ref.fieldPath)
+		}
+		if value != nil {
+			text := scoped.Text
+			if s, ok := value.(string); ok && !reflect.DeepEqual(value, scoped.Value) {
+				text = s
+			}
+			return formatVariableValue(t.variables[ref.name], ref.name, value, variableText(text), ref.format, datasourceFormatter), true
+		}
+	}
+
+	variable, value, ok := t.lookup(ref.name)
+	if !ok {
+		return "", false
+	}
+
+	// ad hoc filters are applied by the datasource and never interpolated
+	if variable.Type == adhocVariableType || value == nil {
+		return "", true
+	}
+
+	text := t.text(variable, value)
+
+	// query parameters keep "All" so the link selects all values like the current view
+	if formatName(ref.format) == queryParamVariableFormat {
+		return formatVariableValue(variable, ref.name, value, text, ref.format, datasourceFormatter), true
+	}
+
+	if isAllVariableValue(value) {
+		text = allVariableText
+		if variable.AllValue != "" {
+			// custom all values are interpolated as they are unless the text is requested
+			if ref.format != textVariableFormat && ref.format != percentEncodeVariableFormat {
+				if depth > 0 {
+					return variable.AllValue, true
+				}
+				return t.replace(variable.AllValue, nil, "", nil, depth+1), true
+			}
+			value = variable.AllValue
+		} else {
+			value = t.allValues(variable)
+		}
+	}
+
+	if ref.fieldPath != "" {
+		if fieldValue := fieldPathValue(value, ref.fieldPath); fieldValue != nil {
+			value = fieldValue
+		}
+	}
+
+	return formatVariableValue(variable, ref.name, value, text, ref.format, datasourceFormatter), true
+}
+
+// lookup returns the definition and value of a variable. Values sent by the viewer are used over the current value
+// saved in the dashboard, and the auto option of interval variables is resolved to its interval
+func (t *templateInterpolator) lookup(name string) (templateVariable, interface{}, bool) {
+	variable, defined := t.variables[name]
+	if value, ok := t.values[name]; ok && value != nil {
+		return variable, t.resolveAutoInterval(variable, value), true
+	}
+	if !defined {
+		return variable, nil, false
+	}
+	return variable, t.resolveAutoInterval(variable, variable.Current.Value), true
+}
+
+// resolveAutoInterval returns the interval the auto option of an interval variable stands for, computed from the
+// __from and __to variables. Other values, and the auto option without a time range, are returned as they are
+func (t *templateInterpolator) resolveAutoInterval(variable templateVariable, value interface{}) interface{} {
+	if variable.Type != intervalVariableType || !isAutoIntervalValue(variable.Name, value) {
+		return value
+	}
+
+	from, fromErr := strconv.ParseInt(convertInterfaceToString(t.values[fromVariable]), 10, 64)
+	to, toErr := strconv.ParseInt(convertInterfaceToString(t.values[toVariable]), 10, 64)
+	if fromErr != nil || toErr != nil {
+		return value
+	}
+	return autoInterval(time.UnixMilli(from), time.UnixMilli(to), variable.AutoCount, variable.AutoMin)
+}
+
+// allValues returns the values "All" expands to when the variable has no custom all value
+func (t *templateInterpolator) allValues(variable templateVariable) []interface{} {
+	if values, ok := t.allOptions[variable.Name]; ok {
+		return values
+	}
+	return allOptionValues(variable)
+}
+
+// text returns the display text of the variable value, taken from the current value or the options of the variable
+func (t *templateInterpolator) text(variable templateVariable, value interface{}) string {
+	if reflect.DeepEqual(value, variable.Current.Value) && variable.Current.Text != nil {
+		return variableText(variable.Current.Text)
+	}
+
+	optionText := func(v string) string {
+		for _, o := range variable.Options {
+			if convertInterfaceToString(o.Value) == v {
+				if text := convertInterfaceToString(o.Text); text != "" {
+					return text
+				}
+			}
+		}
+		return v
+	}
+
+	values := variableValueStrings(value)
+	texts := make([]string, len(values))
+	for i, v := range values {
+		texts[i] = optionText(v)
+	}
+	return strings.Join(texts, " + ")
+}
+
+// scopedVars returns the resolved value of each variable of the dashboard along with the variables set on the panel,
+// the way the dashboard sends them along with the queries of a panel. "All" is expanded like in the queries
+func (t *templateInterpolator) scopedVars(panelVars map[string]scopedVariable) map[string]interface{} {
+	vars := make(map[string]interface{}, len(t.variables)+len(panelVars))
+	for name := range t.variables {
+		variable, value, ok := t.lookup(name)
+		if !ok || value == nil || variable.Type == adhocVariableType {
+			continue
+		}
+
+		text := t.text(variable, value)
+		if isAllVariableValue(value) {
+			text = allVariableText
+			if variable.AllValue != "" {
+				value = variable.AllValue
+			} else {
+				value = t.allValues(variable)
+			}
+		}
+		vars[name] = map[string]interface{}{"text": text, "value": value}
+	}
+
+	for name, v := range panelVars {
+		vars[name] = map[string]interface{}{"text": v.Text, "value": v.Value}
+	}
+	return vars
+}
+
+func parseVariableReference(match string) variableReference {
+	groups := variableRegex.FindStringSubmatch(match)
+	var ref variableReference
+	switch {
+	case groups[1] != "":
+		ref.name = groups[1]
+	case groups[2] != "":
+		ref.name, ref.format = groups[2], groups[3]
+	default:
+		ref.name, ref.fieldPath, ref.format = groups[4], groups[5], groups[6]
+	}
+	return ref
+}
+
+func isAllVariableValue(value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return v == allVariableValue
+	case []interface{}:
+		return len(v) > 0 && v[0] == allVariableValue
+	case []string:
+		return len(v) > 0 && v[0] == allVariableValue
+	default:
+		return false
+	}
+}
+
+// allOptionValues returns the values of every option of the variable but the "All" option, which isn't always listed
+// first or at all
+func allOptionValues(variable templateVariable) []interface{} {
+	values := make([]interface{}, 0, len(variable.Options))
+	for _, o := range variable.Options {
+		if o.Value == allVariableValue {
+			continue
+		}
+		values = append(values, o.Value)
+	}
+	return values
+}
+
+// fieldPathValue returns the value at the dot separated path of an object value, or nil if there is none
+func fieldPathValue(value interface{}, path string) interface{} {
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			value = v[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil
+			}
+			value = v[i]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+// variableText converts the text of a variable, a list for multi-value variables, to a string
+func variableText(text interface{}) string {
+	return strings.Join(variableValueStrings(text), " + ")
+}
+
+// variableValueStrings returns the values of a variable as strings
+func variableValueStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		values := make([]string, len(v))
+		for i, item := range v {
+			if item != nil {
+				values[i] = fmt.Sprintf("%v", item)
+			}
+		}
+		return values
+	default:
+		return []string{fmt.Sprintf("%v", v)}
+	}
+}
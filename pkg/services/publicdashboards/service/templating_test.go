@@ -0,0 +1,254 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+func TestTemplateInterpolator(t *testing.T) {
+	dashboard, err := simplejson.NewJson([]byte(`{
+		"templating": {
+			"list": [
+				{
+					"name": "env",
+					"type": "custom",
+					"current": {"text": "Production", "value": "prod"},
+					"options": [
+						{"text": "Production", "value": "prod"},
+						{"text": "Staging", "value": "stage"}
+					]
+				},
+				{
+					"name": "server",
+					"type": "query",
+					"multi": true,
+					"includeAll": true,
+					"current": {"text": ["All"], "value": ["$__all"]},
+					"options": [
+						{"text": "All", "value": "$__all"},
+						{"text": "web-1", "value": "web-1"},
+						{"text": "web.2", "value": "web.2"}
+					]
+				},
+				{
+					"name": "job",
+					"type": "query",
+					"includeAll": true,
+					"allValue": ".*",
+					"current": {"text": "All", "value": "$__all"},
+					"options": [{"text": "All", "value": "$__all"}, {"text": "node", "value": "node"}]
+				},
+				{
+					"name": "filters",
+					"type": "adhoc",
+					"filters": [{"key": "job", "operator": "=", "value": "node"}]
+				}
+			]
+		}
+	}`))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		text      string
+		variables map[string]interface{}
+		format    string
+		expected  string
+	}{
+		{
+			name:     "uses the saved value of variables that are not sent",
+			text:     "env=$env",
+			expected: "env=prod",
+		},
+		{
+			name:      "uses the values sent over the saved values",
+			text:      "env=${env}",
+			variables: map[string]interface{}{"env": "stage"},
+			expected:  "env=stage",
+		},
+		{
+			name:      "reads the text of the value from the options",
+			text:      "${env:text}",
+			variables: map[string]interface{}{"env": "stage"},
+			expected:  "Staging",
+		},
+		{
+			name:     "expands all to every option",
+			text:     "${server:regex}",
+			expected: `(web-1|web\.2)`,
+		},
+		{
+			name:     "expands all to the custom all value without formatting it",
+			text:     "${job:regex} $job",
+			expected: ".* .*",
+		},
+		{
+			name:     "uses the all text with the text format",
+			text:     "${server:text}",
+			expected: "All",
+		},
+		{
+			name:     "keeps all in query parameters",
+			text:     "${server:queryparam}",
+			expected: "var-server=%24__all",
+		},
+		{
+			name:     "never interpolates ad hoc filters",
+			text:     "sum($filters)",
+			expected: "sum()",
+		},
+		{
+			name:      "supports the legacy bracket syntax",
+			text:      "[[env]] [[server:csv]]",
+			variables: map[string]interface{}{"server": []interface{}{"a", "b"}},
+			expected:  "prod a,b",
+		},
+		{
+			name:      "uses the default format for references without a format",
+			text:      "$server ${env:raw}",
+			variables: map[string]interface{}{"server": []interface{}{"a", "b"}},
+			format:    textVariableFormat,
+			expected:  "a + b prod",
+		},
+		{
+			name:     "leaves references to unknown variables and macros untouched",
+			text:     "$unknown ${__interval} [[__from]]",
+			expected: "$unknown ${__interval} [[__from]]",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			interpolator := newTemplateInterpolator(dashboard, tc.variables)
+			assert.Equal(t, tc.expected, interpolator.interpolate(tc.text, nil, tc.format))
+		})
+	}
+
+	t.Run("uses the values of repeated panels first", func(t *testing.T) {
+		panel, err := simplejson.NewJson([]byte(`{"scopedVars": {"env": {"text": "Staging", "value": "stage"}}}`))
+		require.NoError(t, err)
+
+		interpolator := newTemplateInterpolator(dashboard, map[string]interface{}{"env": "prod"})
+		assert.Equal(t, "stage Staging", interpolator.interpolate("$env ${env:text}", scopedVariables(panel), ""))
+	})
+
+	t.Run("formats values like the datasource of the query", func(t *testing.T) {
+		interpolator := newTemplateInterpolator(dashboard, map[string]interface{}{"server": []interface{}{"web-1", "web.2"}})
+		assert.Equal(t, `up{instance=~"(web-1|web\\.2)",env="prod"}`, interpolator.interpolateQuery(`up{instance=~"$server",env="$env"}`, nil, "prometheus"))
+		assert.Equal(t, `{instance=~"web-1|web\\.2"}`, interpolator.interpolateQuery(`{instance=~"$server"}`, nil, "loki"))
+		assert.Equal(t, `WHERE server IN ('web-1','web.2') AND env = 'prod'`, interpolator.interpolateQuery(`WHERE server IN ($server) AND env = '$env'`, nil, "mysql"))
+		assert.Equal(t, `{web-1,web.2}`, interpolator.interpolateQuery(`$server`, nil, "unknown"))
+		assert.Equal(t, `web-1|web.2`, interpolator.interpolateQuery(`${server:pipe}`, nil, "prometheus"))
+	})
+
+	t.Run("escapes values that would end the quoted strings of queries", func(t *testing.T) {
+		interpolator := newTemplateInterpolator(dashboard, map[string]interface{}{"env": `x"} or up{a="`, "server": []interface{}{`a"b`}})
+		assert.Equal(t, `up{env="x\"} or up{a=\""}`, interpolator.interpolateQuery(`up{env="$env"}`, nil, "prometheus"))
+		assert.Equal(t, `up{instance=~"a\"b"}`, interpolator.interpolateQuery(`up{instance=~"$server"}`, nil, "prometheus"))
+
+		interpolator = newTemplateInterpolator(dashboard, map[string]interface{}{"env": `\' OR 1=1 -- `})
+		assert.Equal(t, `WHERE env = '\\'' OR 1=1 -- '`, interpolator.interpolateQuery(`WHERE env = '$env'`, nil, "mysql"))
+		assert.Equal(t, `WHERE env = '\'' OR 1=1 -- '`, interpolator.interpolateQuery(`WHERE env = '$env'`, nil, "postgres"))
+	})
+
+	t.Run("returns the resolved variables as scoped vars", func(t *testing.T) {
+		interpolator := newTemplateInterpolator(dashboard, map[string]interface{}{"env": "stage"})
+		assert.Equal(t, map[string]interface{}{
+			"env":    map[string]interface{}{"text": "Staging", "value": "stage"},
+			"server": map[string]interface{}{"text": allVariableText, "value": []interface{}{"web-1", "web.2"}},
+			"job":    map[string]interface{}{"text": "web-1", "value": "web-1"},
+		}, interpolator.scopedVars(map[string]scopedVariable{"job": {Text: "web-1", Value: "web-1"}}))
+	})
+
+	t.Run("sets the time range variables", func(t *testing.T) {
+		variables := withTimeRangeVariables(map[string]interface{}{"env": "stage"}, TimeSettings{From: "1700000000000", To: "1700003600000"})
+		interpolator := newTemplateInterpolator(dashboard, variables)
+		assert.Equal(t, "stage 1700000000000 1700003600", interpolator.interpolate("$env $__from ${__to:date:seconds}", nil, ""))
+	})
+
+	t.Run("returns typed values of single references", func(t *testing.T) {
+		interpolator := newTemplateInterpolator(dashboard, map[string]interface{}{"limit": float64(10)})
+
+		value, ok := interpolator.typedValue("${limit}", nil)
+		assert.True(t, ok)
+		assert.Equal(t, float64(10), value)
+
+		value, ok = interpolator.typedValue("$server", nil)
+		assert.True(t, ok)
+		assert.Equal(t, []interface{}{"web-1", "web.2"}, value)
+
+		_, ok = interpolator.typedValue("${limit:csv}", nil)
+		assert.False(t, ok)
+		_, ok = interpolator.typedValue("$filters", nil)
+		assert.False(t, ok)
+	})
+}
+
+func TestAllOptionValues(t *testing.T) {
+	t.Run("skips the all option wherever it's listed", func(t *testing.T) {
+		variable := templateVariable{Options: []variableOption{
+			{Text: "web-1", Value: "web-1"},
+			{Text: "All", Value: allVariableValue},
+			{Text: "web-2", Value: "web-2"},
+		}}
+		assert.Equal(t, []interface{}{"web-1", "web-2"}, allOptionValues(variable))
+	})
+
+	t.Run("keeps every option when the all option isn't listed", func(t *testing.T) {
+		variable := templateVariable{Options: []variableOption{{Text: "web-1", Value: "web-1"}, {Text: "web-2", Value: "web-2"}}}
+		assert.Equal(t, []interface{}{"web-1", "web-2"}, allOptionValues(variable))
+	})
+}
+
+func TestFormatVariableValue(t *testing.T) {
+	multi := []interface{}{"a'b", "c.d"}
+
+	testCases := []struct {
+		format   string
+		value    interface{}
+		expected string
+	}{
+		{format: "", value: multi, expected: "{a'b,c.d}"},
+		{format: "glob", value: []interface{}{"a"}, expected: "a"},
+		{format: "unknown", value: multi, expected: "{a'b,c.d}"},
+		{format: "raw", value: multi, expected: "a'b,c.d"},
+		{format: "csv", value: multi, expected: "a'b,c.d"},
+		{format: "join: - ", value: multi, expected: "a'b - c.d"},
+		{format: "pipe", value: multi, expected: "a'b|c.d"},
+		{format: "distributed", value: multi, expected: "a'b,server=c.d"},
+		{format: "regex", value: "c.d", expected: `c\.d`},
+		{format: "regex", value: multi, expected: `(a'b|c\.d)`},
+		{format: "lucene", value: "a b", expected: `a\ b`},
+		{format: "lucene", value: multi, expected: `("a'b" OR "c.d")`},
+		{format: "lucene", value: []interface{}{}, expected: "__empty__"},
+		{format: "html", value: "<b>", expected: "&lt;b&gt;"},
+		{format: "json", value: multi, expected: `["a'b","c.d"]`},
+		{format: "json", value: float64(1), expected: `1`},
+		{format: "percentencode", value: "a b&c", expected: "a%20b%26c"},
+		{format: "percentencode", value: multi, expected: "%7Ba%27b%2Cc.d%7D"},
+		{format: "uriencode", value: "a b/c", expected: "a%20b/c"},
+		{format: "singlequote", value: multi, expected: `'a\'b','c.d'`},
+		{format: "doublequote", value: "x", expected: `"x"`},
+		{format: "sqlstring", value: multi, expected: `'a''b','c.d'`},
+		{format: "text", value: multi, expected: "A + C"},
+		{format: "queryparam", value: multi, expected: "var-server=a%27b&var-server=c.d"},
+		{format: "date", value: "1700000000123", expected: "2023-11-14T22:13:20.123Z"},
+		{format: "date:ms", value: "1700000000123", expected: "1700000000123"},
+		{format: "date:seconds", value: "1700000000623", expected: "1700000001"},
+		{format: "date:YYYY-MM-DD HH:mm:ss", value: "1700000000123", expected: "2023-11-14 22:13:20"},
+		{format: "date:[week of] ddd D MMM", value: "1700000000123", expected: "week of Tue 14 Nov"},
+		{format: "date", value: "now", expected: "now"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.format, func(t *testing.T) {
+			actual := formatVariableValue(templateVariable{}, "server", tc.value, "A + C", tc.format, nil)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+// Upsert creates the public dashboard of the dashboard, or updates it when the dashboard already has one, so the same
+// request can be repeated with the same outcome. The uid and access token of the request are only used to create the
+// public dashboard, they have to match the existing one otherwise. Reports whether the public dashboard was created
+func (pd *PublicDashboardServiceImpl) Upsert(ctx context.Context, u *user.SignedInUser, dto *SavePublicDashboardDTO) (*PublicDashboard, bool, error) {
+	ctx, span := tracer.Start(ctx, "publicdashboards.Upsert")
+	defer span.End()
+
+	existingPubdash, err := pd.FindByDashboardUid(ctx, u.OrgID, dto.DashboardUid)
+	if err != nil && !errors.Is(err, ErrPublicDashboardNotFound) {
+		return nil, false, err
+	}
+
+	if existingPubdash == nil {
+		pubdash, err := pd.Create(ctx, u, dto)
+		if !errors.Is(err, ErrDashboardIsPublic) {
+			return pubdash, err == nil, err
+		}
+
+		// another request created the public dashboard since it was looked up
+		existingPubdash, err = pd.FindByDashboardUid(ctx, u.OrgID, dto.DashboardUid)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	if dto.PublicDashboard.Uid != "" && dto.PublicDashboard.Uid != existingPubdash.Uid {
+		return nil, false, ErrInvalidUid.Errorf("Upsert: the dashboard is shared by the public dashboard %s", existingPubdash.Uid)
+	}
+	if dto.PublicDashboard.AccessToken != "" && dto.PublicDashboard.AccessToken != existingPubdash.AccessToken {
+		return nil, false, ErrInvalidAccessToken.Errorf("Upsert: the access token of a public dashboard can't be changed, rotate it instead")
+	}
+
+	dto.Uid = existingPubdash.Uid
+	pubdash, err := pd.Update(ctx, u, dto)
+	return pubdash, false, err
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	dashboardsDB "github.com/grafana/grafana/pkg/services/dashboards/database"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/tag/tagimpl"
+	"github.com/grafana/grafana/pkg/util/testutil"
+)
+
+func TestIntegrationUpsertPublicDashboard(t *testing.T) {
+	testutil.SkipIntegrationTestInShortMode(t)
+
+	fakeDashboardService := &dashboards.FakeDashboardService{}
+	service, sqlStore, cfg := newPublicDashboardServiceImpl(t, nil, nil, nil, fakeDashboardService, nil)
+	dashboardStore, err := dashboardsDB.ProvideDashboardStore(sqlStore, cfg, featuremgmt.WithFeatures(), tagimpl.ProvideService(sqlStore))
+	require.NoError(t, err)
+	dashboard := insertTestDashboard(t, dashboardStore, "testDashie", 1, 0, "", true, []map[string]any{}, nil)
+	fakeDashboardService.On("GetDashboard", mock.Anything, mock.Anything).Return(dashboard, nil)
+
+	upsert := func(uid string, accessToken string, isEnabled bool) (*PublicDashboard, bool, error) {
+		return service.Upsert(context.Background(), SignedInUser, &SavePublicDashboardDTO{
+			DashboardUid: dashboard.UID,
+			OrgID:        dashboard.OrgID,
+			UserId:       SignedInUser.UserID,
+			PublicDashboard: &PublicDashboardDTO{
+				Uid:         uid,
+				AccessToken: accessToken,
+				IsEnabled:   &isEnabled,
+			},
+		})
+	}
+
+	created, isNew, err := upsert("pubdash1", "e71950f4b5fc4a9d8a34a2d1b2d7e5e0", true)
+	require.NoError(t, err)
+	assert.True(t, isNew)
+	assert.Equal(t, "pubdash1", created.Uid)
+	assert.Equal(t, "e71950f4b5fc4a9d8a34a2d1b2d7e5e0", created.AccessToken)
+	assert.True(t, created.IsEnabled)
+
+	t.Run("repeating the request updates the public dashboard", func(t *testing.T) {
+		updated, isNew, err := upsert("pubdash1", "e71950f4b5fc4a9d8a34a2d1b2d7e5e0", false)
+		require.NoError(t, err)
+		assert.False(t, isNew)
+		assert.Equal(t, created.Uid, updated.Uid)
+		assert.False(t, updated.IsEnabled)
+	})
+
+	t.Run("the uid and access token are optional to update", func(t *testing.T) {
+		updated, isNew, err := upsert("", "", true)
+		require.NoError(t, err)
+		assert.False(t, isNew)
+		assert.Equal(t, created.AccessToken, updated.AccessToken)
+		assert.True(t, updated.IsEnabled)
+	})
+
+	t.Run("the uid and access token of the existing public dashboard can't be changed", func(t *testing.T) {
+		_, _, err := upsert("pubdash2", "", true)
+		assert.ErrorIs(t, err, ErrInvalidUid)
+
+		_, _, err = upsert("", "a3b1e6f5c7d84b2e9f0a1c2d3e4f5a6b", true)
+		assert.ErrorIs(t, err, ErrInvalidAccessToken)
+	})
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// usageFlushInterval is how often the views and queries counted in memory are written to the store
+const usageFlushInterval = time.Minute
+
+// usageRecorder counts the views and queries of public dashboards in memory, keyed by uid, until they're flushed to
+// the store. A nil recorder doesn't count anything
+type usageRecorder struct {
+	mu    sync.Mutex
+	usage map[string]*PublicDashboardUsage
+}
+
+func newUsageRecorder() *usageRecorder {
+	return &usageRecorder{usage: map[string]*PublicDashboardUsage{}}
+}
+
+func (r *usageRecorder) recordView(pubdash *PublicDashboard, now time.Time) {
+	r.add(pubdash, PublicDashboardUsage{Views: 1, LastViewedAt: now})
+}
+
+func (r *usageRecorder) recordQuery(pubdash *PublicDashboard, now time.Time) {
+	r.add(pubdash, PublicDashboardUsage{Queries: 1, LastQueriedAt: now})
+}
+
+func (r *usageRecorder) add(pubdash *PublicDashboard, usage PublicDashboardUsage) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.usage[pubdash.Uid]
+	if !ok {
+		u = &PublicDashboardUsage{OrgId: pubdash.OrgId, PublicDashboardUid: pubdash.Uid}
+		r.usage[pubdash.Uid] = u
+	}
+	u.Add(usage)
+}
+
+// pending returns the usage of a public dashboard that wasn't flushed yet
+func (r *usageRecorder) pending(uid string) PublicDashboardUsage {
+	if r == nil {
+		return PublicDashboardUsage{}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if u, ok := r.usage[uid]; ok {
+		return *u
+	}
+	return PublicDashboardUsage{}
+}
+
+// take returns the usage counted since the last flush and starts counting again
+func (r *usageRecorder) take() []PublicDashboardUsage {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	usage := make([]PublicDashboardUsage, 0, len(r.usage))
+	for _, u := range r.usage {
+		usage = append(usage, *u)
+	}
+	r.usage = map[string]*PublicDashboardUsage{}
+	return usage
+}
+
+// restore counts usage that couldn't be flushed again, so it's written with the next flush
+func (r *usageRecorder) restore(usage []PublicDashboardUsage) {
+	for _, u := range usage {
+		r.add(&PublicDashboard{Uid: u.PublicDashboardUid, OrgId: u.OrgId}, u)
+	}
+}
+
+// forget drops the usage of a public dashboard, used when it's deleted
+func (r *usageRecorder) forget(uid string) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.usage, uid)
+}
+
+// flushUsage writes the usage counted since the last flush to the store
+func (pd *PublicDashboardServiceImpl) flushUsage(ctx context.Context) error {
+	usage := pd.usage.take()
+	if len(usage) == 0 {
+		return nil
+	}
+
+	if err := pd.store.AddUsage(ctx, usage); err != nil {
+		pd.usage.restore(usage)
+		return err
+	}
+	return nil
+}
+
+// getUsage returns the usage of a public dashboard, including what wasn't flushed yet
+func (pd *PublicDashboardServiceImpl) getUsage(ctx context.Context, uid string) (PublicDashboardUsage, error) {
+	usage := PublicDashboardUsage{}
+	stored, err := pd.store.FindUsage(ctx, uid)
+	if err != nil {
+		return usage, err
+	}
+	if stored != nil {
+		usage = *stored
+	}
+	usage.Add(pd.usage.pending(uid))
+	return usage, nil
+}
+
+// UsageService writes the views and queries of public dashboards counted by the service to the store in batches
+type UsageService struct {
+	log     log.Logger
+	cfg     *setting.Cfg
+	service *PublicDashboardServiceImpl
+}
+
+func ProvideUsageService(cfg *setting.Cfg, service *PublicDashboardServiceImpl) *UsageService {
+	return &UsageService{
+		log:     log.New("publicdashboards.usage"),
+		cfg:     cfg,
+		service: service,
+	}
+}
+
+// IsDisabled returns true when public dashboards are disabled, nothing is counted then
+func (s *UsageService) IsDisabled() bool {
+	return !s.cfg.PublicDashboardsEnabled
+}
+
+func (s *UsageService) Run(ctx context.Context) error {
+	ticker := time.NewTicker(usageFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// the usage counted since the last flush would be lost on shutdown
+			if err := s.service.flushUsage(context.WithoutCancel(ctx)); err != nil {
+				s.log.Error("Failed to write public dashboard usage", "error", err)
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		if err := s.service.flushUsage(ctx); err != nil {
+			s.log.Error("Failed to write public dashboard usage", "error", err)
+		}
+	}
+}
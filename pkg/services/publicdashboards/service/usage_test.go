@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+func TestUsageRecorder(t *testing.T) {
+	now := time.Now()
+	pubdash := &PublicDashboard{Uid: "pubdash1", OrgId: 1}
+
+	t.Run("counts views and queries per public dashboard", func(t *testing.T) {
+		recorder := newUsageRecorder()
+		recorder.recordView(pubdash, now)
+		recorder.recordQuery(pubdash, now.Add(time.Second))
+		recorder.recordQuery(pubdash, now.Add(2*time.Second))
+		recorder.recordView(&PublicDashboard{Uid: "pubdash2", OrgId: 1}, now)
+
+		assert.Equal(t, PublicDashboardUsage{
+			OrgId:              1,
+			PublicDashboardUid: "pubdash1",
+			Views:              1,
+			Queries:            2,
+			LastViewedAt:       now,
+			LastQueriedAt:      now.Add(2 * time.Second),
+		}, recorder.pending("pubdash1"))
+		assert.Equal(t, int64(1), recorder.pending("pubdash2").Views)
+	})
+
+	t.Run("starts counting again after taking the usage", func(t *testing.T) {
+		recorder := newUsageRecorder()
+		recorder.recordView(pubdash, now)
+
+		assert.Len(t, recorder.take(), 1)
+		assert.Empty(t, recorder.take())
+		assert.Equal(t, PublicDashboardUsage{}, recorder.pending("pubdash1"))
+	})
+
+	t.Run("forgets deleted public dashboards", func(t *testing.T) {
+		recorder := newUsageRecorder()
+		recorder.recordView(pubdash, now)
+		recorder.forget("pubdash1")
+
+		assert.Empty(t, recorder.take())
+	})
+
+	t.Run("nil recorder doesn't count anything", func(t *testing.T) {
+		var recorder *usageRecorder
+		recorder.recordView(pubdash, now)
+		recorder.forget("pubdash1")
+
+		assert.Equal(t, PublicDashboardUsage{}, recorder.pending("pubdash1"))
+		assert.Nil(t, recorder.take())
+	})
+}
+
+func TestFlushUsage(t *testing.T) {
+	now := time.Now()
+	pubdash := &PublicDashboard{Uid: "pubdash1", OrgId: 1}
+
+	t.Run("writes the usage to the store", func(t *testing.T) {
+		store := &FakePublicDashboardStore{}
+		store.On("AddUsage", mock.Anything, mock.Anything).Return(nil)
+		service := &PublicDashboardServiceImpl{store: store, usage: newUsageRecorder()}
+		service.usage.recordView(pubdash, now)
+
+		require.NoError(t, service.flushUsage(context.Background()))
+
+		usage := store.Calls[0].Arguments.Get(1).([]PublicDashboardUsage)
+		require.Len(t, usage, 1)
+		assert.Equal(t, int64(1), usage[0].Views)
+		assert.Equal(t, PublicDashboardUsage{}, service.usage.pending("pubdash1"))
+	})
+
+	t.Run("doesn't write without usage", func(t *testing.T) {
+		store := &FakePublicDashboardStore{}
+		service := &PublicDashboardServiceImpl{store: store, usage: newUsageRecorder()}
+
+		require.NoError(t, service.flushUsage(context.Background()))
+		store.AssertNotCalled(t, "AddUsage", mock.Anything, mock.Anything)
+	})
+
+	t.Run("keeps the usage when writing fails", func(t *testing.T) {
+		store := &FakePublicDashboardStore{}
+		store.On("AddUsage", mock.Anything, mock.Anything).Return(errors.New("db error"))
+		service := &PublicDashboardServiceImpl{store: store, usage: newUsageRecorder()}
+		service.usage.recordView(pubdash, now)
+
+		require.Error(t, service.flushUsage(context.Background()))
+		assert.Equal(t, int64(1), service.usage.pending("pubdash1").Views)
+	})
+}
+
+func TestGetUsage(t *testing.T) {
+	now := time.Now()
+	store := &FakePublicDashboardStore{}
+	store.On("FindUsage", mock.Anything, "pubdash1").Return(&PublicDashboardUsage{PublicDashboardUid: "pubdash1", Views: 10, LastViewedAt: now.Add(-time.Hour)}, nil)
+	store.On("FindUsage", mock.Anything, mock.Anything).Return(nil, nil)
+	service := &PublicDashboardServiceImpl{store: store, usage: newUsageRecorder()}
+	service.usage.recordView(&PublicDashboard{Uid: "pubdash1", OrgId: 1}, now)
+
+	usage, err := service.getUsage(context.Background(), "pubdash1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), usage.Views, "includes the usage that wasn't written yet")
+	assert.Equal(t, now, usage.LastViewedAt)
+
+	usage, err = service.getUsage(context.Background(), "pubdash2")
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), usage.Views)
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"slices"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+// applyVariableDefaults sets the default values chosen by the owner of the public dashboard as the current value of
+// their variable, so viewers start from them instead of the values saved in the dashboard. Options matching the
+// default are selected, and defaults of variables that are no longer defined in the dashboard are ignored
+func applyVariableDefaults(publicDashboard *models.PublicDashboard, dashboard *simplejson.Json) {
+	if len(publicDashboard.VariableDefaults) == 0 {
+		return
+	}
+
+	for _, variable := range dashboardVariableMaps(dashboard) {
+		name, _ := variable["name"].(string)
+		value, ok := publicDashboard.VariableDefaults[name]
+		if !ok {
+			continue
+		}
+
+		variable["current"] = map[string]interface{}{"text": value, "value": value}
+		options, _ := variable["options"].([]interface{})
+		selected := variableValueStrings(value)
+		for _, o := range options {
+			if option, ok := o.(map[string]interface{}); ok {
+				optionValue, _ := option["value"].(string)
+				option["selected"] = slices.Contains(selected, optionValue)
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+func TestApplyVariableDefaults(t *testing.T) {
+	pubdash := &models.PublicDashboard{VariableDefaults: models.VariableDefaults{
+		"env":     "stage",
+		"regions": []interface{}{"eu", "us"},
+		"removed": "x",
+	}}
+
+	t.Run("sets the defaults as current value of v1 variables", func(t *testing.T) {
+		dashboard, err := simplejson.NewJson([]byte(`{
+			"templating": {"list": [
+				{"name": "env", "type": "custom", "current": {"text": "prod", "value": "prod"}, "options": [
+					{"text": "prod", "value": "prod", "selected": true},
+					{"text": "stage", "value": "stage", "selected": false}
+				]},
+				{"name": "regions", "type": "custom", "multi": true, "current": {"text": ["eu"], "value": ["eu"]}},
+				{"name": "job", "type": "custom", "current": {"text": "api", "value": "api"}}
+			]}
+		}`))
+		require.NoError(t, err)
+
+		applyVariableDefaults(pubdash, dashboard)
+		list := dashboard.GetPath("templating", "list")
+		assert.Equal(t, "stage", list.GetIndex(0).GetPath("current", "value").MustString())
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"text": "prod", "value": "prod", "selected": false},
+			map[string]interface{}{"text": "stage", "value": "stage", "selected": true},
+		}, list.GetIndex(0).Get("options").MustArray())
+		assert.Equal(t, []interface{}{"eu", "us"}, list.GetIndex(1).GetPath("current", "value").MustArray())
+		assert.Equal(t, "api", list.GetIndex(2).GetPath("current", "value").MustString())
+	})
+
+	t.Run("sets the defaults as current value of v2 variables", func(t *testing.T) {
+		dashboard, err := simplejson.NewJson([]byte(`{
+			"elements": {},
+			"variables": [{"kind": "CustomVariable", "spec": {"name": "env", "current": {"text": "prod", "value": "prod"}}}]
+		}`))
+		require.NoError(t, err)
+
+		applyVariableDefaults(pubdash, dashboard)
+		spec := dashboard.Get("variables").GetIndex(0).Get("spec")
+		assert.Equal(t, "stage", spec.GetPath("current", "value").MustString())
+	})
+
+	t.Run("keeps the dashboard without defaults", func(t *testing.T) {
+		dashboard, err := simplejson.NewJson([]byte(`{
+			"templating": {"list": [{"name": "env", "type": "custom", "current": {"text": "prod", "value": "prod"}}]}
+		}`))
+		require.NoError(t, err)
+
+		applyVariableDefaults(&models.PublicDashboard{}, dashboard)
+		assert.Equal(t, "prod", dashboard.GetPath("templating", "list").GetIndex(0).GetPath("current", "value").MustString())
+	})
+}
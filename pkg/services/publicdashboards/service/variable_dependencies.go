@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+// variableDependencies returns the names of the dashboard variables each variable references in its query or
+// datasource
+func variableDependencies(variables map[string]*variableDefinition) map[string][]string {
+	dependencies := make(map[string][]string, len(variables))
+	for name, variable := range variables {
+		encoded, err := json.Marshal([]interface{}{variable.Query, variable.Datasource})
+		if err != nil {
+			continue
+		}
+
+		seen := map[string]bool{}
+		for _, groups := range variableRegex.FindAllStringSubmatch(string(encoded), -1) {
+			for _, ref := range []string{groups[1], groups[2], groups[4]} {
+				if _, ok := variables[ref]; ok && ref != name && !seen[ref] {
+					seen[ref] = true
+					dependencies[name] = append(dependencies[name], ref)
+				}
+			}
+		}
+	}
+	return dependencies
+}
+
+// upstreamVariables returns the variables the given variable depends on, directly or through other variables, in
+// topological order so every variable comes after the variables it depends on. References forming a cycle are ignored
+func upstreamVariables(dependencies map[string][]string, name string) []string {
+	var order []string
+	visited := map[string]bool{}
+	visiting := map[string]bool{}
+
+	var visit func(string)
+	visit = func(n string) {
+		if visited[n] || visiting[n] {
+			return
+		}
+		visiting[n] = true
+		for _, dep := range dependencies[n] {
+			visit(dep)
+		}
+		visiting[n] = false
+		visited[n] = true
+		order = append(order, n)
+	}
+	visit(name)
+
+	// the variable itself is visited last
+	return order[:len(order)-1]
+}
+
+// resolveUpstreamVariables returns the values of the variables the given variable depends on. Values sent by the
+// viewer are kept, other query variables are refreshed in topological order like the dashboard does when a value
+// changes: the saved value is kept when it's still one of the options, otherwise "All" or the first option is selected
+func (pd *PublicDashboardServiceImpl) resolveUpstreamVariables(ctx context.Context, dashboard *dashboards.Dashboard, publicDashboard *models.PublicDashboard, variableName string, provided map[string]interface{}) (map[string]interface{}, error) {
+	variables := map[string]*variableDefinition{}
+	for _, v := range dashboardVariableList(dashboard.Data) {
+		definition, _ := v.(map[string]interface{})
+		name, _ := definition["name"].(string)
+		if name == "" {
+			continue
+		}
+		variable, err := pd.findVariableInDashboard(dashboard, name)
+		if err != nil {
+			return nil, err
+		}
+		variables[name] = variable
+	}
+
+	resolved := make(map[string]interface{}, len(provided))
+	for name, value := range provided {
+		resolved[name] = value
+	}
+
+	if _, ok := variables[variableName]; !ok {
+		return resolved, nil
+	}
+
+	for _, name := range upstreamVariables(variableDependencies(variables), variableName) {
+		variable := variables[name]
+		if _, ok := provided[name]; ok || variable.Type != "query" {
+			continue
+		}
+
+		options, err := pd.queryVariableValues(ctx, dashboard, publicDashboard, variable, resolved)
+		if err != nil {
+			return nil, err
+		}
+		if value, changed := selectVariableValue(variable, options); changed {
+			resolved[name] = value
+		}
+	}
+
+	return resolved, nil
+}
+
+// selectVariableValue returns the value of the variable for its refreshed options, and whether it differs from the
+// saved value. Saved values that are no longer options are dropped, and when none is left "All" is selected if the
+// variable includes it, the first option otherwise
+func selectVariableValue(variable *variableDefinition, options []string) (interface{}, bool) {
+	if len(options) == 0 || (variable.IncludeAll && isAllVariableValue(variable.Current.Value)) {
+		return nil, false
+	}
+
+	available := make(map[string]bool, len(options))
+	for _, o := range options {
+		available[o] = true
+	}
+
+	current := variableValueStrings(variable.Current.Value)
+	kept := make([]interface{}, 0, len(current))
+	for _, v := range current {
+		if available[v] {
+			kept = append(kept, v)
+		}
+	}
+
+	switch {
+	case len(kept) == len(current) && len(kept) > 0:
+		return nil, false
+	case len(kept) > 0 && variable.Multi:
+		return kept, true
+	}
+
+	selected := options[0]
+	if variable.IncludeAll {
+		selected = allVariableValue
+	}
+	if variable.Multi {
+		return []interface{}{selected}, true
+	}
+	return selected, true
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	fakeDatasources "github.com/grafana/grafana/pkg/services/datasources/fakes"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/query"
+)
+
+func TestUpstreamVariables(t *testing.T) {
+	variables := map[string]*variableDefinition{
+		"server": {Name: "server", Query: `label_values(up{env="$env", region=~"${region:regex}"}, instance)`},
+		"env":    {Name: "env", Query: map[string]interface{}{"query": `label_values(up{region="[[region]]"}, env)`}},
+		"region": {Name: "region", Query: "label_values(region)", Datasource: map[string]interface{}{"uid": "${ds}"}},
+		"ds":     {Name: "ds", Query: "prometheus"},
+		"a":      {Name: "a", Query: "$b"},
+		"b":      {Name: "b", Query: "$a $__interval"},
+	}
+	dependencies := variableDependencies(variables)
+
+	assert.Equal(t, []string{"ds", "region", "env"}, upstreamVariables(dependencies, "server"))
+	assert.Equal(t, []string{"ds"}, upstreamVariables(dependencies, "region"))
+	assert.Empty(t, upstreamVariables(dependencies, "ds"))
+	assert.Equal(t, []string{"b"}, upstreamVariables(dependencies, "a"))
+}
+
+func TestSelectVariableValue(t *testing.T) {
+	testCases := []struct {
+		name     string
+		variable variableDefinition
+		options  []string
+		expected interface{}
+		changed  bool
+	}{
+		{
+			name:     "keeps the saved value when it's an option",
+			variable: variableDefinition{Current: variableCurrent{Value: "prod"}},
+			options:  []string{"stage", "prod"},
+		},
+		{
+			name:     "keeps all",
+			variable: variableDefinition{IncludeAll: true, Current: variableCurrent{Value: "$__all"}},
+			options:  []string{"stage"},
+		},
+		{
+			name:     "keeps the saved value without options",
+			variable: variableDefinition{Current: variableCurrent{Value: "prod"}},
+		},
+		{
+			name:     "selects the first option",
+			variable: variableDefinition{Current: variableCurrent{Value: "prod"}},
+			options:  []string{"stage", "dev"},
+			expected: "stage",
+			changed:  true,
+		},
+		{
+			name:     "selects all when the variable includes it",
+			variable: variableDefinition{Multi: true, IncludeAll: true, Current: variableCurrent{Value: []interface{}{"prod"}}},
+			options:  []string{"stage", "dev"},
+			expected: []interface{}{"$__all"},
+			changed:  true,
+		},
+		{
+			name:     "drops the saved values that are no longer options",
+			variable: variableDefinition{Multi: true, Current: variableCurrent{Value: []interface{}{"prod", "dev"}}},
+			options:  []string{"stage", "dev"},
+			expected: []interface{}{"dev"},
+			changed:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			value, changed := selectVariableValue(&tc.variable, tc.options)
+			assert.Equal(t, tc.changed, changed)
+			assert.Equal(t, tc.expected, value)
+		})
+	}
+}
+
+func TestResolveUpstreamVariables(t *testing.T) {
+	// the list isn't in the order of the dependencies
+	dashboardData, err := simplejson.NewJson([]byte(`{
+		"templating": {
+			"list": [
+				{
+					"name": "server",
+					"type": "query",
+					"datasource": {"uid": "prom", "type": "prometheus"},
+					"query": "label_values(up{env=\"$env\"}, instance)",
+					"current": {"text": "web-1", "value": "web-1"}
+				},
+				{
+					"name": "env",
+					"type": "query",
+					"datasource": {"uid": "prom", "type": "prometheus"},
+					"query": "label_values(up{region=\"$region\"}, env)",
+					"current": {"text": "prod", "value": "prod"}
+				},
+				{
+					"name": "region",
+					"type": "query",
+					"datasource": {"uid": "prom", "type": "prometheus"},
+					"query": "label_values(region)",
+					"current": {"text": "eu", "value": "eu"}
+				},
+				{
+					"name": "job",
+					"type": "query",
+					"datasource": {"uid": "prom", "type": "prometheus"},
+					"query": "label_values(job)",
+					"current": {"text": "node", "value": "node"}
+				}
+			]
+		}
+	}`))
+	require.NoError(t, err)
+	dashboard := &dashboards.Dashboard{UID: "dash1", OrgID: 1, Data: dashboardData}
+	pubdash := &PublicDashboard{Uid: "pubdash1", AccessToken: "abc123", OrgId: 1}
+
+	setup := func() (*PublicDashboardServiceImpl, *query.FakeQueryService) {
+		fakeQueryService := &query.FakeQueryService{}
+		for expr, values := range map[string][]string{
+			`label_values(region)`:               {"us", "ap"},
+			`label_values(up{region="us"}, env)`: {"stage", "prod"},
+			`label_values(up{region="ap"}, env)`: {"dev"},
+		} {
+			expr := expr
+			fakeQueryService.On("QueryData", mock.Anything, mock.Anything, mock.Anything, mock.MatchedBy(func(req dtos.MetricRequest) bool {
+				return req.Queries[0].Get("expr").MustString() == expr
+			})).Return(&backend.QueryDataResponse{
+				Responses: map[string]backend.DataResponse{
+					"A": {Frames: data.Frames{data.NewFrame("", data.NewField("text", nil, values))}},
+				},
+			}, nil)
+		}
+
+		return &PublicDashboardServiceImpl{
+			log:              log.NewNopLogger(),
+			QueryDataService: fakeQueryService,
+			datasourceService: &fakeDatasources.FakeDataSourceService{DataSources: []*datasources.DataSource{
+				{UID: "prom", Name: "Prometheus", OrgID: 1, Type: "prometheus", JsonData: simplejson.New()},
+			}},
+			variableOptions: newVariableOptionsCache(time.Minute, nil),
+		}, fakeQueryService
+	}
+
+	t.Run("refreshes the upstream variables in the order of their dependencies", func(t *testing.T) {
+		service, fakeQueryService := setup()
+
+		resolved, err := service.resolveUpstreamVariables(context.Background(), dashboard, pubdash, "server", map[string]interface{}{})
+		require.NoError(t, err)
+
+		// eu is no longer a region, and prod is still an environment of the first region
+		assert.Equal(t, map[string]interface{}{"region": "us"}, resolved)
+		fakeQueryService.AssertNumberOfCalls(t, "QueryData", 2)
+	})
+
+	t.Run("keeps the values sent by the viewer", func(t *testing.T) {
+		service, fakeQueryService := setup()
+
+		resolved, err := service.resolveUpstreamVariables(context.Background(), dashboard, pubdash, "server", map[string]interface{}{"region": "ap", "job": "node"})
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]interface{}{"region": "ap", "env": "dev", "job": "node"}, resolved)
+		fakeQueryService.AssertNumberOfCalls(t, "QueryData", 1)
+	})
+
+	t.Run("doesn't query variables without dependents", func(t *testing.T) {
+		service, fakeQueryService := setup()
+
+		resolved, err := service.resolveUpstreamVariables(context.Background(), dashboard, pubdash, "job", map[string]interface{}{})
+		require.NoError(t, err)
+
+		assert.Empty(t, resolved)
+		fakeQueryService.AssertNotCalled(t, "QueryData", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,413 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Variable formats supported in references like ${var:format}, matching the formats of the dashboard in the browser
+const (
+	rawVariableFormat           = "raw"
+	globVariableFormat          = "glob"
+	textVariableFormat          = "text"
+	queryParamVariableFormat    = "queryparam"
+	percentEncodeVariableFormat = "percentencode"
+)
+
+// variableFormatter formats the value of a variable. Arguments of the format come after ":" like in ${var:join:-}
+type variableFormatter func(v formattedVariable, args []string) string
+
+// formattedVariable is a variable value being formatted
+type formattedVariable struct {
+	name string
+	// value is the value as sent by the viewer or saved in the dashboard, used by the json format
+	value  interface{}
+	values []string
+	// list is set when the value is a list of values
+	list bool
+	// multiSelect is set for variables allowing several values or "All"
+	multiSelect bool
+	text        string
+}
+
+// String converts the value like the browser does, joining multiple values with commas
+func (v formattedVariable) String() string {
+	return strings.Join(v.values, ",")
+}
+
+var variableFormats = map[string]variableFormatter{
+	rawVariableFormat: func(v formattedVariable, _ []string) string {
+		return v.String()
+	},
+	globVariableFormat: func(v formattedVariable, _ []string) string {
+		if v.list && len(v.values) > 1 {
+			return "{" + v.String() + "}"
+		}
+		return v.String()
+	},
+	"csv": func(v formattedVariable, _ []string) string {
+		return v.String()
+	},
+	"join": func(v formattedVariable, args []string) string {
+		separator := ","
+		if len(args) > 0 {
+			separator = args[0]
+		}
+		return strings.Join(v.values, separator)
+	},
+	"pipe": func(v formattedVariable, _ []string) string {
+		return strings.Join(v.values, "|")
+	},
+	"distributed": func(v formattedVariable, _ []string) string {
+		if !v.list {
+			return v.String()
+		}
+		values := make([]string, len(v.values))
+		for i, value := range v.values {
+			if i == 0 {
+				values[i] = value
+			} else {
+				values[i] = v.name + "=" + value
+			}
+		}
+		return strings.Join(values, ",")
+	},
+	"regex": func(v formattedVariable, _ []string) string {
+		if !v.list {
+			return regexEscape(v.String())
+		}
+		escaped := make([]string, len(v.values))
+		for i, value := range v.values {
+			escaped[i] = regexEscape(value)
+		}
+		if len(escaped) == 1 {
+			return escaped[0]
+		}
+		return "(" + strings.Join(escaped, "|") + ")"
+	},
+	"lucene": func(v formattedVariable, _ []string) string {
+		if !v.list {
+			if _, ok := v.value.(string); ok {
+				return luceneEscape(v.String())
+			}
+			return v.String()
+		}
+		if len(v.values) == 0 {
+			return "__empty__"
+		}
+		quoted := make([]string, len(v.values))
+		for i, value := range v.values {
+			quoted[i] = `"` + luceneEscape(value) + `"`
+		}
+		return "(" + strings.Join(quoted, " OR ") + ")"
+	},
+	"html": func(v formattedVariable, _ []string) string {
+		return htmlEscaper.Replace(strings.Join(v.values, ", "))
+	},
+	"json": func(v formattedVariable, _ []string) string {
+		encoded, err := json.Marshal(v.value)
+		if err != nil {
+			return v.String()
+		}
+		return string(encoded)
+	},
+	percentEncodeVariableFormat: func(v formattedVariable, _ []string) string {
+		if v.list {
+			return encodeURIComponentStrict("{" + v.String() + "}")
+		}
+		return encodeURIComponentStrict(v.String())
+	},
+	"uriencode": func(v formattedVariable, _ []string) string {
+		if v.list {
+			return encodeURI("{" + v.String() + "}")
+		}
+		return encodeURI(v.String())
+	},
+	"singlequote": func(v formattedVariable, _ []string) string {
+		return quoteVariableValues(v.values, `'`, `\'`)
+	},
+	"doublequote": func(v formattedVariable, _ []string) string {
+		return quoteVariableValues(v.values, `"`, `\"`)
+	},
+	"sqlstring": func(v formattedVariable, _ []string) string {
+		return quoteVariableValues(v.values, `'`, `''`)
+	},
+	textVariableFormat: func(v formattedVariable, _ []string) string {
+		return v.text
+	},
+	// date formats epoch milliseconds, like the __from and __to variables, as iso, ms, seconds or a moment.js format
+	"date": func(v formattedVariable, args []string) string {
+		ms, err := strconv.ParseInt(v.String(), 10, 64)
+		if err != nil {
+			return v.String()
+		}
+
+		format := "iso"
+		if len(args) > 0 {
+			format = strings.Join(args, ":")
+		}
+
+		switch format {
+		case "ms":
+			return v.String()
+		case "seconds":
+			return strconv.FormatInt(int64(math.Round(float64(ms)/1000)), 10)
+		case "iso":
+			return time.UnixMilli(ms).UTC().Format("2006-01-02T15:04:05.000Z")
+		default:
+			return formatMomentDate(time.UnixMilli(ms).UTC(), format)
+		}
+	},
+	queryParamVariableFormat: func(v formattedVariable, _ []string) string {
+		params := make([]string, len(v.values))
+		for i, value := range v.values {
+			params[i] = "var-" + encodeURIComponentStrict(v.name) + "=" + encodeURIComponentStrict(value)
+		}
+		return strings.Join(params, "&")
+	},
+}
+
+// datasourceVariableFormatters format references without a format in the queries of a datasource, like the
+// datasource does in the browser
+var datasourceVariableFormatters = map[string]variableFormatter{
+	"prometheus":                    prometheusVariableFormatter,
+	"loki":                          lokiVariableFormatter,
+	"mysql":                         newSQLVariableFormatter(mysqlStringEscaper),
+	"mssql":                         newSQLVariableFormatter(sqlStringEscaper),
+	"postgres":                      newSQLVariableFormatter(sqlStringEscaper),
+	"grafana-postgresql-datasource": newSQLVariableFormatter(sqlStringEscaper),
+}
+
+// formatVariableValue formats a variable value with the given format. References without a format use the
+// datasource formatter when there is one, and glob otherwise. Unknown formats fall back to glob
+func formatVariableValue(variable templateVariable, name string, value interface{}, text string, format string, datasourceFormatter variableFormatter) string {
+	if value == nil {
+		return ""
+	}
+
+	v := formattedVariable{
+		name:        name,
+		value:       value,
+		values:      variableValueStrings(value),
+		multiSelect: variable.Multi || variable.IncludeAll,
+		text:        text,
+	}
+	switch value.(type) {
+	case []interface{}, []string:
+		v.list = true
+	}
+
+	if format == "" && datasourceFormatter != nil {
+		return datasourceFormatter(v, nil)
+	}
+
+	args := strings.Split(format, ":")
+	formatter, ok := variableFormats[args[0]]
+	if !ok {
+		formatter = variableFormats[globVariableFormat]
+	}
+	return formatter(v, args[1:])
+}
+
+// formatName returns the name of a format without its arguments
+func formatName(format string) string {
+	name, _, _ := strings.Cut(format, ":")
+	return name
+}
+
+// prometheusVariableFormatter escapes values for label matchers, as regular expressions for variables allowing
+// several values
+func prometheusVariableFormatter(v formattedVariable, _ []string) string {
+	if v.list && !v.multiSelect {
+		return v.String()
+	}
+	if !v.multiSelect {
+		if _, ok := v.value.(string); ok {
+			return prometheusRegularEscaper.Replace(v.String())
+		}
+		return v.String()
+	}
+	if !v.list {
+		return prometheusRegexEscape(v.String())
+	}
+
+	escaped := make([]string, len(v.values))
+	for i, value := range v.values {
+		escaped[i] = prometheusRegexEscape(value)
+	}
+	if len(escaped) == 1 {
+		return escaped[0]
+	}
+	return "(" + strings.Join(escaped, "|") + ")"
+}
+
+// lokiVariableFormatter escapes values as regular expressions for variables allowing several values
+func lokiVariableFormatter(v formattedVariable, _ []string) string {
+	if !v.multiSelect {
+		return v.String()
+	}
+	if !v.list {
+		return prometheusRegexEscape(v.String())
+	}
+
+	escaped := make([]string, len(v.values))
+	for i, value := range v.values {
+		escaped[i] = prometheusRegexEscape(value)
+	}
+	return strings.Join(escaped, "|")
+}
+
+// newSQLVariableFormatter returns a formatter quoting values as string literals for variables allowing several values,
+// and escaping them with the string escaping of the database otherwise
+func newSQLVariableFormatter(escaper *strings.Replacer) variableFormatter {
+	return func(v formattedVariable, _ []string) string {
+		if v.list {
+			return quoteSQLVariableValues(v.values, escaper)
+		}
+		if _, ok := v.value.(string); !ok {
+			return v.String()
+		}
+		if v.multiSelect {
+			return quoteSQLVariableValues(v.values, escaper)
+		}
+		return escaper.Replace(v.String())
+	}
+}
+
+func quoteSQLVariableValues(values []string, escaper *strings.Replacer) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = `'` + escaper.Replace(value) + `'`
+	}
+	return strings.Join(quoted, ",")
+}
+
+func quoteVariableValues(values []string, quote, escapedQuote string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = quote + strings.ReplaceAll(value, quote, escapedQuote) + quote
+	}
+	return strings.Join(quoted, ",")
+}
+
+var (
+	regexSpecialChars  = regexp.MustCompile(`[\\^$*+?.()|[\]{}/]`)
+	luceneSpecialChars = regexp.MustCompile(`([!*+\-=<>\s&|()[\]{}^~?:\\/"])`)
+	htmlEscaper        = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&#39;")
+
+	prometheusRegexSpecialChars = regexp.MustCompile(`[$^*{}[\]+?.()|]`)
+	prometheusRegularEscaper    = strings.NewReplacer(`\`, `\\`, `'`, `\\'`, `"`, `\"`, "\n", `\n`)
+	// prometheusQuoteEscaper escapes the quotes and line breaks that would end the quoted string of a label matcher
+	prometheusQuoteEscaper = strings.NewReplacer(`"`, `\"`, "\n", `\n`)
+
+	// sqlStringEscaper doubles the quotes of a string literal, mysqlStringEscaper escapes backslashes as well since
+	// MySQL reads them as escape characters in string literals
+	sqlStringEscaper   = strings.NewReplacer(`'`, `''`)
+	mysqlStringEscaper = strings.NewReplacer(`\`, `\\`, `'`, `''`)
+)
+
+func regexEscape(value string) string {
+	return regexSpecialChars.ReplaceAllString(value, `\$0`)
+}
+
+func luceneEscape(value string) string {
+	return luceneSpecialChars.ReplaceAllString(value, `\$1`)
+}
+
+// prometheusRegexEscape escapes a value used in a regular expression label matcher, the backslashes are doubled
+// since the value is also in a quoted string
+func prometheusRegexEscape(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\\\`)
+	value = prometheusRegexSpecialChars.ReplaceAllString(value, `\\$0`)
+	return prometheusQuoteEscaper.Replace(value)
+}
+
+// encodeURIComponentStrict percent-encodes everything but unreserved characters, like encodeURIComponent in the
+// browser with !'()* encoded as well
+func encodeURIComponentStrict(value string) string {
+	return percentEncode(value, "-_.~")
+}
+
+// encodeURI percent-encodes the characters that can't appear in a URL, like encodeURI in the browser
+func encodeURI(value string) string {
+	return percentEncode(value, "-_.~!*'();/?:@&=+$,#")
+}
+
+func percentEncode(value string, allowed string) string {
+	var b strings.Builder
+	for _, c := range []byte(value) {
+		if ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || strings.IndexByte(allowed, c) >= 0 {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// momentTokens are the moment.js format tokens supported by the date format, longest tokens first
+var momentTokens = []struct {
+	token  string
+	format func(t time.Time) string
+}{
+	{"YYYY", func(t time.Time) string { return t.Format("2006") }},
+	{"YY", func(t time.Time) string { return t.Format("06") }},
+	{"MMMM", func(t time.Time) string { return t.Format("January") }},
+	{"MMM", func(t time.Time) string { return t.Format("Jan") }},
+	{"MM", func(t time.Time) string { return t.Format("01") }},
+	{"M", func(t time.Time) string { return t.Format("1") }},
+	{"DD", func(t time.Time) string { return t.Format("02") }},
+	{"D", func(t time.Time) string { return t.Format("2") }},
+	{"dddd", func(t time.Time) string { return t.Format("Monday") }},
+	{"ddd", func(t time.Time) string { return t.Format("Mon") }},
+	{"HH", func(t time.Time) string { return t.Format("15") }},
+	{"H", func(t time.Time) string { return strconv.Itoa(t.Hour()) }},
+	{"hh", func(t time.Time) string { return t.Format("03") }},
+	{"h", func(t time.Time) string { return t.Format("3") }},
+	{"mm", func(t time.Time) string { return t.Format("04") }},
+	{"m", func(t time.Time) string { return t.Format("4") }},
+	{"ss", func(t time.Time) string { return t.Format("05") }},
+	{"s", func(t time.Time) string { return t.Format("5") }},
+	{"SSS", func(t time.Time) string { return fmt.Sprintf("%03d", t.Nanosecond()/int(time.Millisecond)) }},
+	{"A", func(t time.Time) string { return t.Format("PM") }},
+	{"a", func(t time.Time) string { return t.Format("pm") }},
+	{"ZZ", func(t time.Time) string { return t.Format("-0700") }},
+	{"Z", func(t time.Time) string { return t.Format("-07:00") }},
+	{"X", func(t time.Time) string { return strconv.FormatInt(t.Unix(), 10) }},
+	{"x", func(t time.Time) string { return strconv.FormatInt(t.UnixMilli(), 10) }},
+}
+
+// formatMomentDate formats the time with a moment.js format. Text in square brackets is kept as it is. Dates are
+// formatted in UTC since the timezone of the viewer isn't known
+func formatMomentDate(t time.Time, layout string) string {
+	var b strings.Builder
+	for i := 0; i < len(layout); {
+		if layout[i] == '[' {
+			if end := strings.IndexByte(layout[i:], ']'); end > 0 {
+				b.WriteString(layout[i+1 : i+end])
+				i += end + 1
+				continue
+			}
+		}
+
+		matched := false
+		for _, tok := range momentTokens {
+			if strings.HasPrefix(layout[i:], tok.token) {
+				b.WriteString(tok.format(t))
+				i += len(tok.token)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			b.WriteByte(layout[i])
+			i++
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/publicdashboards/metric"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+const (
+	maxCachedVariableOptions = 10000
+
+	cacheResultHit  = "hit"
+	cacheResultMiss = "miss"
+)
+
+// variableOptionsCache keeps the options of query variables for a short time, keyed by access token, variable,
+// interpolated query and time range. Options are kept for the TTL of the org of the dashboard, a TTL of 0 or a nil
+// cache doesn't keep anything
+type variableOptionsCache struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	ttlByOrg map[int64]time.Duration
+	entries  map[string]cachedVariableOptions
+}
+
+type cachedVariableOptions struct {
+	options     []models.MetricFindValue
+	refreshedAt time.Time
+	expires     time.Time
+}
+
+func newVariableOptionsCache(ttl time.Duration, ttlByOrg map[int64]time.Duration) *variableOptionsCache {
+	return &variableOptionsCache{
+		ttl:      ttl,
+		ttlByOrg: ttlByOrg,
+		entries:  map[string]cachedVariableOptions{},
+	}
+}
+
+// orgTTL returns how long the options of the org are kept
+func (c *variableOptionsCache) orgTTL(orgID int64) time.Duration {
+	if ttl, ok := c.ttlByOrg[orgID]; ok {
+		return ttl
+	}
+	return c.ttl
+}
+
+// get returns the cached options of the key and when they were queried
+func (c *variableOptionsCache) get(key string, orgID int64, now time.Time) ([]models.MetricFindValue, time.Time, bool) {
+	if c == nil || c.orgTTL(orgID) <= 0 {
+		return nil, time.Time{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || now.After(entry.expires) {
+		metric.VariableOptionsCacheRequestsTotal.WithLabelValues(cacheResultMiss).Inc()
+		return nil, time.Time{}, false
+	}
+	metric.VariableOptionsCacheRequestsTotal.WithLabelValues(cacheResultHit).Inc()
+	return entry.options, entry.refreshedAt, true
+}
+
+func (c *variableOptionsCache) set(key string, orgID int64, options []models.MetricFindValue, now time.Time) {
+	if c == nil {
+		return
+	}
+	ttl := c.orgTTL(orgID)
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= maxCachedVariableOptions {
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= maxCachedVariableOptions {
+			c.entries = map[string]cachedVariableOptions{}
+		}
+	}
+	c.entries[key] = cachedVariableOptions{options: options, refreshedAt: now, expires: now.Add(ttl)}
+}
+
+// forget removes the cached options of the public dashboard with the access token, the keys start with it
+func (c *variableOptionsCache) forget(accessToken string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k := range c.entries {
+		if strings.HasPrefix(k, accessToken+"/") {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// forgetMatching removes the cached options of the public dashboards whose access token matches
+func (c *variableOptionsCache) forgetMatching(match func(accessToken string) bool) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k := range c.entries {
+		if accessToken, _, _ := strings.Cut(k, "/"); match(accessToken) {
+			delete(c.entries, k)
+		}
+	}
+}
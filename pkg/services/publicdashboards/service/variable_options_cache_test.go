@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	fakeDatasources "github.com/grafana/grafana/pkg/services/datasources/fakes"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/metric"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/query"
+)
+
+func TestVariableOptionsCache(t *testing.T) {
+	options := []MetricFindValue{{Text: "web-1", Value: "web-1"}}
+	now := time.Now()
+
+	t.Run("forgets the options of a public dashboard", func(t *testing.T) {
+		cache := newVariableOptionsCache(time.Minute, nil)
+		cache.set("token1/env", 1, options, now)
+		cache.set("token2/env", 1, options, now)
+
+		cache.forget("token1")
+
+		_, _, ok := cache.get("token1/env", 1, now)
+		assert.False(t, ok)
+		_, _, ok = cache.get("token2/env", 1, now)
+		assert.True(t, ok)
+	})
+
+	t.Run("keeps options for the ttl with the time they were queried", func(t *testing.T) {
+		cache := newVariableOptionsCache(time.Minute, nil)
+		cache.set("key", 1, options, now)
+
+		cached, refreshedAt, ok := cache.get("key", 1, now.Add(59*time.Second))
+		require.True(t, ok)
+		assert.Equal(t, options, cached)
+		assert.Equal(t, now, refreshedAt)
+
+		_, _, ok = cache.get("key", 1, now.Add(61*time.Second))
+		assert.False(t, ok)
+	})
+
+	t.Run("uses the ttl of the org", func(t *testing.T) {
+		cache := newVariableOptionsCache(time.Minute, map[int64]time.Duration{2: 5 * time.Minute, 3: 0})
+		cache.set("org2", 2, options, now)
+		cache.set("org3", 3, options, now)
+
+		_, _, ok := cache.get("org2", 2, now.Add(4*time.Minute))
+		assert.True(t, ok)
+		_, _, ok = cache.get("org3", 3, now)
+		assert.False(t, ok)
+	})
+
+	t.Run("counts hits and misses", func(t *testing.T) {
+		hits := testutil.ToFloat64(metric.VariableOptionsCacheRequestsTotal.WithLabelValues(cacheResultHit))
+		misses := testutil.ToFloat64(metric.VariableOptionsCacheRequestsTotal.WithLabelValues(cacheResultMiss))
+
+		cache := newVariableOptionsCache(time.Minute, nil)
+		_, _, _ = cache.get("key", 1, now)
+		cache.set("key", 1, options, now)
+		_, _, _ = cache.get("key", 1, now)
+
+		assert.Equal(t, hits+1, testutil.ToFloat64(metric.VariableOptionsCacheRequestsTotal.WithLabelValues(cacheResultHit)))
+		assert.Equal(t, misses+1, testutil.ToFloat64(metric.VariableOptionsCacheRequestsTotal.WithLabelValues(cacheResultMiss)))
+	})
+}
+
+func TestGetQueryVariableOptionsCache(t *testing.T) {
+	dashboardData, err := simplejson.NewJson([]byte(`{
+		"time": {"from": "now-6h", "to": "now"},
+		"templating": {
+			"list": [
+				{
+					"name": "env",
+					"type": "custom",
+					"query": "prod,stage",
+					"current": {"text": "prod", "value": "prod"}
+				},
+				{
+					"name": "server",
+					"type": "query",
+					"datasource": {"uid": "prom", "type": "prometheus"},
+					"query": "label_values(up{env=\"$env\"}, instance)",
+					"refresh": 2,
+					"current": {"text": "web-1", "value": "web-1"}
+				}
+			]
+		}
+	}`))
+	require.NoError(t, err)
+	dashboard := &dashboards.Dashboard{UID: "dash1", OrgID: 1, Data: dashboardData}
+	pubdash := &PublicDashboard{Uid: "pubdash1", AccessToken: "abc123", OrgId: 1, TimeSelectionEnabled: true}
+
+	fakeQueryService := &query.FakeQueryService{}
+	fakeQueryService.On("QueryData", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&backend.QueryDataResponse{
+		Responses: map[string]backend.DataResponse{
+			"A": {Frames: data.Frames{data.NewFrame("", data.NewField("text", nil, []string{"web-1", "web-2"}))}},
+		},
+	}, nil)
+	service := &PublicDashboardServiceImpl{
+		log:              log.NewNopLogger(),
+		QueryDataService: fakeQueryService,
+		datasourceService: &fakeDatasources.FakeDataSourceService{DataSources: []*datasources.DataSource{
+			{UID: "prom", Name: "Prometheus", OrgID: 1, Type: "prometheus", JsonData: simplejson.New()},
+		}},
+		variableOptions: newVariableOptionsCache(time.Minute, nil),
+	}
+	server, err := service.findVariableInDashboard(dashboard, "server")
+	require.NoError(t, err)
+
+	getOptions := func(reqDTO PublicDashboardVariableQueryDTO) {
+		options, _, err := service.getQueryVariableOptions(context.Background(), dashboard, pubdash, server, reqDTO)
+		require.NoError(t, err)
+		assert.Len(t, options, 2)
+	}
+
+	// relative time ranges reuse the options even though they resolve to another time
+	getOptions(PublicDashboardVariableQueryDTO{})
+	getOptions(PublicDashboardVariableQueryDTO{})
+	fakeQueryService.AssertNumberOfCalls(t, "QueryData", 1)
+
+	getOptions(PublicDashboardVariableQueryDTO{Variables: map[string]interface{}{"env": "stage"}})
+	fakeQueryService.AssertNumberOfCalls(t, "QueryData", 2)
+
+	getOptions(PublicDashboardVariableQueryDTO{TimeRange: TimeRangeDTO{From: "now-1h", To: "now"}})
+	fakeQueryService.AssertNumberOfCalls(t, "QueryData", 3)
+
+	// variables refreshed on load keep the options of the time range of the dashboard
+	server.Refresh = 1
+	getOptions(PublicDashboardVariableQueryDTO{TimeRange: TimeRangeDTO{From: "now-6h", To: "now"}})
+	fakeQueryService.AssertNumberOfCalls(t, "QueryData", 3)
+
+	// and are served from the snapshot of the public dashboard while env keeps the value of the snapshot
+	refreshedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	pubdash.VariableSnapshot = VariableSnapshot{
+		"env": {Current: VariableSnapshotValue{Text: "prod", Value: "prod"}},
+		"server": {
+			Options:     []MetricFindValue{{Text: "web-3", Value: "web-3"}},
+			RefreshedAt: refreshedAt,
+		},
+	}
+	options, at, err := service.getQueryVariableOptions(context.Background(), dashboard, pubdash, server, PublicDashboardVariableQueryDTO{Variables: map[string]interface{}{"env": "prod"}})
+	require.NoError(t, err)
+	assert.Equal(t, pubdash.VariableSnapshot["server"].Options, options)
+	assert.Equal(t, refreshedAt, at)
+	fakeQueryService.AssertNumberOfCalls(t, "QueryData", 3)
+
+	// other values of env get the queried options, cached earlier for stage
+	getOptions(PublicDashboardVariableQueryDTO{Variables: map[string]interface{}{"env": "stage"}})
+	fakeQueryService.AssertNumberOfCalls(t, "QueryData", 3)
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+// jsRegexLiteral matches a regex written like a javascript literal, /pattern/flags
+var jsRegexLiteral = regexp.MustCompile(`^/(.*)/(\w*)$`)
+
+// variableResultsRegex is the regex of a query variable, global regexes return every match of a value
+type variableResultsRegex struct {
+	re     *regexp.Regexp
+	global bool
+}
+
+// parseVariableRegex parses the regex of a query variable like the dashboard does in the browser: a regex literal
+// keeps its flags, anything else has to match the whole value
+func parseVariableRegex(regex string) (*variableResultsRegex, error) {
+	if !strings.HasPrefix(regex, "/") {
+		re, err := regexp.Compile("^" + regex + "$")
+		if err != nil {
+			return nil, err
+		}
+		return &variableResultsRegex{re: re}, nil
+	}
+
+	groups := jsRegexLiteral.FindStringSubmatch(regex)
+	if groups == nil {
+		return nil, fmt.Errorf("invalid regex %s", regex)
+	}
+
+	pattern, global := groups[1], false
+	var goFlags string
+	for _, flag := range groups[2] {
+		switch flag {
+		case 'g':
+			global = true
+		case 'i', 'm', 's':
+			goFlags += string(flag)
+		case 'u', 'y':
+			// the values are already unicode, and the regex is applied to each value from its start
+		default:
+			return nil, fmt.Errorf("invalid regex flag %c", flag)
+		}
+	}
+	if goFlags != "" {
+		pattern = "(?" + goFlags + ")" + pattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return &variableResultsRegex{re: re, global: global}, nil
+}
+
+// apply filters the options of a query variable by the regex. The named groups text and value set the text and value
+// of an option, otherwise the first capture group sets both. A global regex with several matches adds an option for
+// each match. Options matching the regex are kept as they are when it has no capture group
+func (r *variableResultsRegex) apply(options []models.MetricFindValue) []models.MetricFindValue {
+	textIndex, valueIndex := r.re.SubexpIndex("text"), r.re.SubexpIndex("value")
+
+	filtered := make([]models.MetricFindValue, 0, len(options))
+	for _, option := range options {
+		var matches [][]string
+		if r.global {
+			matches = r.re.FindAllStringSubmatch(option.Value, -1)
+		} else if match := r.re.FindStringSubmatch(option.Value); match != nil {
+			matches = [][]string{match}
+		}
+		if len(matches) == 0 {
+			continue
+		}
+
+		text, value := namedGroup(matches, textIndex), namedGroup(matches, valueIndex)
+		if text != "" || value != "" {
+			if value == "" {
+				value = text
+			}
+			if text == "" {
+				text = value
+			}
+			filtered = append(filtered, models.MetricFindValue{Text: text, Value: value})
+			continue
+		}
+
+		if len(matches[0]) < 2 {
+			filtered = append(filtered, option)
+			continue
+		}
+		if len(matches) > 1 {
+			for _, match := range matches {
+				filtered = append(filtered, models.MetricFindValue{Text: match[1], Value: match[1]})
+			}
+			continue
+		}
+		filtered = append(filtered, models.MetricFindValue{Text: matches[0][1], Value: matches[0][1]})
+	}
+
+	return uniqueVariableOptions(filtered)
+}
+
+// namedGroup returns the first non-empty capture of a named group in the matches
+func namedGroup(matches [][]string, index int) string {
+	if index < 0 {
+		return ""
+	}
+	for _, match := range matches {
+		if match[index] != "" {
+			return match[index]
+		}
+	}
+	return ""
+}
+
+// uniqueVariableOptions removes the options with the value of a previous option
+func uniqueVariableOptions(options []models.MetricFindValue) []models.MetricFindValue {
+	seen := make(map[string]bool, len(options))
+	unique := options[:0]
+	for _, option := range options {
+		if seen[option.Value] {
+			continue
+		}
+		seen[option.Value] = true
+		unique = append(unique, option)
+	}
+	return unique
+}
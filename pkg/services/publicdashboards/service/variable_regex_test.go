@@ -0,0 +1,88 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+func TestApplyVariableRegex(t *testing.T) {
+	options := func(values ...string) []models.MetricFindValue {
+		result := make([]models.MetricFindValue, 0, len(values))
+		for _, v := range values {
+			result = append(result, models.MetricFindValue{Text: v, Value: v})
+		}
+		return result
+	}
+
+	testCases := []struct {
+		name     string
+		regex    string
+		options  []models.MetricFindValue
+		expected []models.MetricFindValue
+	}{
+		{
+			name:     "keeps the values matching the regex",
+			regex:    "/^web/",
+			options:  options("web-1", "db-1", "web-2"),
+			expected: options("web-1", "web-2"),
+		},
+		{
+			name:     "matches the whole value without slashes",
+			regex:    "web-\\d",
+			options:  options("web-1", "web-12", "my-web-1"),
+			expected: options("web-1"),
+		},
+		{
+			name:     "uses the first capture group",
+			regex:    `/instance="([^"]+)"/`,
+			options:  options(`up{instance="web-1",job="node"}`, `up{instance="web-1",job="api"}`, `up{job="db"}`),
+			expected: options("web-1"),
+		},
+		{
+			name:    "uses the named groups for the text and value",
+			regex:   `/(?<value>[^:]+):(?<text>.+)/`,
+			options: options("1:web", "2:db"),
+			expected: []models.MetricFindValue{
+				{Text: "web", Value: "1"},
+				{Text: "db", Value: "2"},
+			},
+		},
+		{
+			name:     "uses the text group as the value without value group",
+			regex:    `/name=(?<text>\w+)/`,
+			options:  options("id=1 name=web"),
+			expected: options("web"),
+		},
+		{
+			name:     "adds every match of global regexes",
+			regex:    `/(\w+)-\d/g`,
+			options:  options("web-1 db-2", "cache-3"),
+			expected: options("web", "db", "cache"),
+		},
+		{
+			name:     "applies the flags",
+			regex:    "/^WEB/i",
+			options:  options("web-1", "db-1"),
+			expected: options("web-1"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			regex, err := parseVariableRegex(tc.regex)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, regex.apply(tc.options))
+		})
+	}
+
+	t.Run("rejects invalid regexes", func(t *testing.T) {
+		for _, regex := range []string{"/[a-z/", "/web/x", "[a-z"} {
+			_, err := parseVariableRegex(regex)
+			assert.Error(t, err, regex)
+		}
+	})
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+// pluginContextProvider returns the plugin context of a datasource, it is implemented by plugincontext.Provider
+type pluginContextProvider interface {
+	GetWithDataSource(ctx context.Context, pluginID string, user identity.Requester, ds *datasources.DataSource) (backend.PluginContext, error)
+}
+
+// variableResource is a resource request of a datasource answering a variable query, with the reader of its response
+type variableResource struct {
+	path   string
+	params url.Values
+	parse  func(body []byte) ([]models.MetricFindValue, error)
+}
+
+// variableResourceBuilders build the resource requests of the datasources exposing variable support through their
+// resource API instead of QueryData. A builder returns nil for the queries the datasource only answers with QueryData
+var variableResourceBuilders = map[string]func(query *simplejson.Json, ts models.TimeSettings) *variableResource{
+	"prometheus": prometheusVariableResource,
+	"loki":       lokiVariableResource,
+	"cloudwatch": cloudWatchVariableResource,
+}
+
+var (
+	labelNamesQuery  = regexp.MustCompile(`^label_names\(\s*(.*?)\s*\)\s*$`)
+	labelValuesQuery = regexp.MustCompile(`^label_values\(\s*(?:(.+?)\s*,\s*)?([a-zA-Z_$][a-zA-Z0-9_]*)\s*\)\s*$`)
+)
+
+// prometheusVariableResource calls the labels API of prometheus for label_names() and label_values() queries
+func prometheusVariableResource(query *simplejson.Json, ts models.TimeSettings) *variableResource {
+	params := resourceTimeRange(ts)
+	expr := strings.TrimSpace(query.Get("query").MustString())
+
+	if match := labelNamesQuery.FindStringSubmatch(expr); match != nil {
+		if match[1] != "" {
+			params.Set("match[]", match[1])
+		}
+		return &variableResource{path: "api/v1/labels", params: params, parse: parseLabelsResponse}
+	}
+	if match := labelValuesQuery.FindStringSubmatch(expr); match != nil {
+		if match[1] != "" {
+			params.Set("match[]", match[1])
+		}
+		return &variableResource{path: "api/v1/label/" + match[2] + "/values", params: params, parse: parseLabelsResponse}
+	}
+	return nil
+}
+
+// lokiVariableResource calls the labels API of loki for label_names() and label_values() queries
+func lokiVariableResource(query *simplejson.Json, ts models.TimeSettings) *variableResource {
+	params := resourceTimeRange(ts)
+	expr := strings.TrimSpace(query.Get("query").MustString())
+
+	if labelNamesQuery.MatchString(expr) {
+		return &variableResource{path: "labels", params: params, parse: parseLabelsResponse}
+	}
+	if match := labelValuesQuery.FindStringSubmatch(expr); match != nil {
+		if match[1] != "" {
+			params.Set("query", match[1])
+		}
+		return &variableResource{path: "label/" + match[2] + "/values", params: params, parse: parseLabelsResponse}
+	}
+	return nil
+}
+
+// cloudWatchVariableResource calls the dimension APIs of cloudwatch for dimension keys and values queries
+func cloudWatchVariableResource(query *simplejson.Json, _ models.TimeSettings) *variableResource {
+	params := url.Values{}
+	for _, name := range []string{"region", "namespace", "metricName"} {
+		if value := query.Get(name).MustString(); value != "" {
+			params.Set(name, value)
+		}
+	}
+	if filters := query.Get("dimensionFilters").Interface(); filters != nil {
+		encoded, err := json.Marshal(filters)
+		if err != nil {
+			return nil
+		}
+		params.Set("dimensionFilters", string(encoded))
+	}
+
+	switch query.Get("queryType").MustString() {
+	case "dimensionKeys":
+		return &variableResource{path: "dimension-keys", params: params, parse: parseCloudWatchResponse}
+	case "dimensionValues":
+		params.Set("dimensionKey", query.Get("dimensionKey").MustString())
+		return &variableResource{path: "dimension-values", params: params, parse: parseCloudWatchResponse}
+	}
+	return nil
+}
+
+// resourceTimeRange returns the time range of the variable query as start and end parameters
+func resourceTimeRange(ts models.TimeSettings) url.Values {
+	params := url.Values{}
+	if from, err := strconv.ParseInt(ts.From, 10, 64); err == nil {
+		params.Set("start", time.UnixMilli(from).UTC().Format(time.RFC3339Nano))
+	}
+	if to, err := strconv.ParseInt(ts.To, 10, 64); err == nil {
+		params.Set("end", time.UnixMilli(to).UTC().Format(time.RFC3339Nano))
+	}
+	return params
+}
+
+// parseLabelsResponse reads the label names or values returned by the labels API of prometheus and loki
+func parseLabelsResponse(body []byte) ([]models.MetricFindValue, error) {
+	var res struct {
+		Status string   `json:"status"`
+		Data   []string `json:"data"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, err
+	}
+	if res.Status != "" && res.Status != "success" {
+		return nil, fmt.Errorf("labels request returned status %s", res.Status)
+	}
+
+	options := make([]models.MetricFindValue, 0, len(res.Data))
+	for _, value := range res.Data {
+		options = append(options, models.MetricFindValue{Text: value, Value: value})
+	}
+	return options, nil
+}
+
+// parseCloudWatchResponse reads the values returned by the resource API of cloudwatch
+func parseCloudWatchResponse(body []byte) ([]models.MetricFindValue, error) {
+	var res []struct {
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, err
+	}
+
+	options := make([]models.MetricFindValue, 0, len(res))
+	for _, r := range res {
+		options = append(options, models.MetricFindValue{Text: r.Value, Value: r.Value})
+	}
+	return options, nil
+}
+
+// callVariableResource answers the variable query with the resource API of its datasource. It returns false when the
+// datasource or the query is not supported, and the query is then run with QueryData
+func (pd *PublicDashboardServiceImpl) callVariableResource(ctx context.Context, user identity.Requester, orgID int64, dsType string, query *simplejson.Json, ts models.TimeSettings) ([]models.MetricFindValue, bool, error) {
+	if pd.pluginClient == nil || pd.pluginContextProvider == nil {
+		return nil, false, nil
+	}
+
+	uid := query.Get("datasource").Get("uid").MustString()
+	if t := query.Get("datasource").Get("type").MustString(); t != "" {
+		dsType = t
+	}
+	build, ok := variableResourceBuilders[dsType]
+	if uid == "" || !ok {
+		return nil, false, nil
+	}
+	resource := build(query, ts)
+	if resource == nil {
+		return nil, false, nil
+	}
+
+	ds, err := pd.datasourceService.GetDataSource(ctx, &datasources.GetDataSourceQuery{UID: uid, OrgID: orgID})
+	if err != nil {
+		pd.log.Error("callVariableResource: failed to get datasource", "error", err, "datasource", uid)
+		return nil, true, errVariableQueryFailed
+	}
+	pCtx, err := pd.pluginContextProvider.GetWithDataSource(ctx, ds.Type, user, ds)
+	if err != nil {
+		pd.log.Error("callVariableResource: failed to get plugin context", "error", err, "datasource", uid)
+		return nil, true, errVariableQueryFailed
+	}
+
+	req := &backend.CallResourceRequest{
+		PluginContext: pCtx,
+		Path:          resource.path,
+		Method:        http.MethodGet,
+		URL:           resource.path + "?" + resource.params.Encode(),
+	}
+	status := http.StatusOK
+	var body bytes.Buffer
+	sender := backend.CallResourceResponseSenderFunc(func(res *backend.CallResourceResponse) error {
+		if res.Status != 0 {
+			status = res.Status
+		}
+		_, err := body.Write(res.Body)
+		return err
+	})
+	if err := pd.pluginClient.CallResource(ctx, req, sender); err != nil {
+		pd.log.Error("callVariableResource: resource request failed", "error", err, "datasource", uid, "path", resource.path)
+		return nil, true, errVariableQueryFailed
+	}
+	if status >= http.StatusBadRequest {
+		pd.log.Error("callVariableResource: resource request failed", "status", status, "datasource", uid, "path", resource.path)
+		return nil, true, errVariableQueryFailed
+	}
+
+	options, err := resource.parse(body.Bytes())
+	if err != nil {
+		pd.log.Error("callVariableResource: failed to read resource response", "error", err, "datasource", uid, "path", resource.path)
+		return nil, true, errVariableQueryFailed
+	}
+	return options, true, nil
+}
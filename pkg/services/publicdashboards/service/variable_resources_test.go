@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	fakeDatasources "github.com/grafana/grafana/pkg/services/datasources/fakes"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+type fakePluginContextProvider struct{}
+
+func (fakePluginContextProvider) GetWithDataSource(_ context.Context, pluginID string, _ identity.Requester, ds *datasources.DataSource) (backend.PluginContext, error) {
+	return backend.PluginContext{PluginID: pluginID, OrgID: ds.OrgID}, nil
+}
+
+func TestVariableResourceBuilders(t *testing.T) {
+	ts := TimeSettings{From: "1700000000000", To: "1700003600000"}
+
+	testCases := []struct {
+		name         string
+		dsType       string
+		query        string
+		expectedPath string
+		expectedURL  url.Values
+	}{
+		{
+			name:         "prometheus label values of a metric",
+			dsType:       "prometheus",
+			query:        `{"query": "label_values(up{job=\"api\"}, instance)"}`,
+			expectedPath: "api/v1/label/instance/values",
+			expectedURL:  url.Values{"match[]": {`up{job="api"}`}, "start": {"2023-11-14T22:13:20Z"}, "end": {"2023-11-14T23:13:20Z"}},
+		},
+		{
+			name:         "prometheus label names",
+			dsType:       "prometheus",
+			query:        `{"query": "label_names()"}`,
+			expectedPath: "api/v1/labels",
+			expectedURL:  url.Values{"start": {"2023-11-14T22:13:20Z"}, "end": {"2023-11-14T23:13:20Z"}},
+		},
+		{
+			name:         "loki label values of a stream",
+			dsType:       "loki",
+			query:        `{"query": "label_values({app=\"web\"}, pod)"}`,
+			expectedPath: "label/pod/values",
+			expectedURL:  url.Values{"query": {`{app="web"}`}, "start": {"2023-11-14T22:13:20Z"}, "end": {"2023-11-14T23:13:20Z"}},
+		},
+		{
+			name:         "cloudwatch dimension values",
+			dsType:       "cloudwatch",
+			query:        `{"queryType": "dimensionValues", "region": "us-east-1", "namespace": "AWS/EC2", "metricName": "CPUUtilization", "dimensionKey": "InstanceId"}`,
+			expectedPath: "dimension-values",
+			expectedURL:  url.Values{"region": {"us-east-1"}, "namespace": {"AWS/EC2"}, "metricName": {"CPUUtilization"}, "dimensionKey": {"InstanceId"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			query, err := simplejson.NewJson([]byte(tc.query))
+			require.NoError(t, err)
+
+			resource := variableResourceBuilders[tc.dsType](query, ts)
+			require.NotNil(t, resource)
+			assert.Equal(t, tc.expectedPath, resource.path)
+			assert.Equal(t, tc.expectedURL, resource.params)
+		})
+	}
+
+	t.Run("leaves other queries to QueryData", func(t *testing.T) {
+		assert.Nil(t, prometheusVariableResource(simplejson.NewFromAny(map[string]any{"query": "query_result(up)"}), ts))
+		assert.Nil(t, lokiVariableResource(simplejson.NewFromAny(map[string]any{"query": "{app=\"web\"}"}), ts))
+		assert.Nil(t, cloudWatchVariableResource(simplejson.NewFromAny(map[string]any{"queryType": "regions"}), ts))
+	})
+}
+
+func TestCallVariableResource(t *testing.T) {
+	ts := TimeSettings{From: "1700000000000", To: "1700003600000"}
+	var requests []*backend.CallResourceRequest
+
+	service := &PublicDashboardServiceImpl{
+		log: log.NewNopLogger(),
+		datasourceService: &fakeDatasources.FakeDataSourceService{DataSources: []*datasources.DataSource{
+			{UID: "prom", Type: "prometheus", OrgID: 1},
+		}},
+		pluginClient: backend.CallResourceHandlerFunc(func(_ context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+			requests = append(requests, req)
+			if req.Path == "api/v1/labels" {
+				return sender.Send(&backend.CallResourceResponse{Status: http.StatusBadGateway})
+			}
+			return sender.Send(&backend.CallResourceResponse{Status: http.StatusOK, Body: []byte(`{"status":"success","data":["web-1","web-2"]}`)})
+		}),
+		pluginContextProvider: fakePluginContextProvider{},
+	}
+
+	t.Run("returns the values of the resource", func(t *testing.T) {
+		query := simplejson.NewFromAny(map[string]any{"query": "label_values(up, instance)", "datasource": map[string]any{"uid": "prom"}})
+		options, ok, err := service.callVariableResource(context.Background(), nil, 1, "prometheus", query, ts)
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, []MetricFindValue{{Text: "web-1", Value: "web-1"}, {Text: "web-2", Value: "web-2"}}, options)
+
+		require.Len(t, requests, 1)
+		assert.Equal(t, "prometheus", requests[0].PluginContext.PluginID)
+		assert.Equal(t, "api/v1/label/instance/values", requests[0].Path)
+	})
+
+	t.Run("fails the query when the resource fails", func(t *testing.T) {
+		query := simplejson.NewFromAny(map[string]any{"query": "label_names()", "datasource": map[string]any{"uid": "prom", "type": "prometheus"}})
+		_, ok, err := service.callVariableResource(context.Background(), nil, 1, "", query, ts)
+		require.True(t, ok)
+		assert.ErrorIs(t, err, errVariableQueryFailed)
+	})
+
+	t.Run("falls back to QueryData for other queries", func(t *testing.T) {
+		query := simplejson.NewFromAny(map[string]any{"query": "up", "datasource": map[string]any{"uid": "prom", "type": "prometheus"}})
+		_, ok, err := service.callVariableResource(context.Background(), nil, 1, "prometheus", query, ts)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("falls back to QueryData without plugin client", func(t *testing.T) {
+		query := simplejson.NewFromAny(map[string]any{"query": "label_names()", "datasource": map[string]any{"uid": "prom", "type": "prometheus"}})
+		_, ok, err := (&PublicDashboardServiceImpl{log: log.NewNopLogger()}).callVariableResource(context.Background(), nil, 1, "prometheus", query, ts)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// v2VariableTypes maps the kinds of the variables of v2 dashboards to the types of templating.list
+var v2VariableTypes = map[string]string{
+	"QueryVariable":      "query",
+	"CustomVariable":     "custom",
+	"ConstantVariable":   "constant",
+	"DatasourceVariable": "datasource",
+	"IntervalVariable":   intervalVariableType,
+	"TextVariable":       textboxVariableType,
+	"AdhocVariable":      adhocVariableType,
+	"GroupByVariable":    "groupby",
+}
+
+// v2VariableRefresh, v2VariableSort and v2VariableHide map the refresh, sort and hide of v2 variables to the values of
+// templating.list
+var (
+	v2VariableRefresh = map[string]int{
+		"never":              0,
+		"onDashboardLoad":    1,
+		"onTimeRangeChanged": 2,
+	}
+	v2VariableSort = map[string]int{
+		"disabled":                        0,
+		"alphabeticalAsc":                 1,
+		"alphabeticalDesc":                2,
+		"numericalAsc":                    3,
+		"numericalDesc":                   4,
+		"alphabeticalCaseInsensitiveAsc":  5,
+		"alphabeticalCaseInsensitiveDesc": 6,
+		"naturalAsc":                      7,
+		"naturalDesc":                     8,
+	}
+	v2VariableHide = map[string]int{
+		"dontHide":       0,
+		"hideLabel":      1,
+		v2HiddenVariable: hiddenVariable,
+	}
+)
+
+// dashboardVariableList returns the variables of the dashboard in the format of templating.list. The variables of v2
+// dashboards are converted from their kind, so both schemas are read the same way
+func dashboardVariableList(dashboard *simplejson.Json) []interface{} {
+	if dashboard.Get("elements").Interface() == nil {
+		return dashboard.GetPath("templating", "list").MustArray()
+	}
+
+	var list []interface{}
+	for _, v := range dashboard.Get("variables").MustArray() {
+		if variable := variableFromV2(simplejson.NewFromAny(v)); variable != nil {
+			list = append(list, variable)
+		}
+	}
+	return list
+}
+
+// variableFromV2 converts a variable of a v2 dashboard to the format of templating.list, and returns nil for unknown
+// kinds
+func variableFromV2(kind *simplejson.Json) map[string]interface{} {
+	variableType, ok := v2VariableTypes[kind.Get("kind").MustString()]
+	if !ok {
+		return nil
+	}
+	spec := kind.Get("spec")
+	specMap := spec.MustMap()
+	if specMap == nil {
+		return nil
+	}
+
+	variable := make(map[string]interface{}, len(specMap)+1)
+	for key, value := range specMap {
+		variable[key] = value
+	}
+	variable["type"] = variableType
+	variable["refresh"] = v2VariableRefresh[spec.Get("refresh").MustString()]
+	variable["sort"] = v2VariableSort[spec.Get("sort").MustString()]
+	variable["hide"] = v2VariableHide[spec.Get("hide").MustString()]
+
+	switch variableType {
+	case "query":
+		query, datasource := variableQueryFromV2(spec)
+		variable["query"] = query
+		if datasource != nil {
+			variable["datasource"] = datasource
+		}
+	case "datasource":
+		variable["query"] = spec.Get("pluginId").MustString()
+	}
+
+	return variable
+}
+
+// variableQueryFromV2 returns the query of a v2 query variable and its datasource. The query is either a DataQuery
+// kind holding the datasource type in its group and the datasource uid in its datasource name, or a kind named after
+// the datasource type with the datasource set on the variable
+func variableQueryFromV2(spec *simplejson.Json) (interface{}, map[string]interface{}) {
+	query := spec.Get("query")
+	model := query.Get("spec").MustMap()
+	if model == nil {
+		return query.Interface(), spec.Get("datasource").MustMap()
+	}
+
+	if datasource := spec.Get("datasource").MustMap(); datasource != nil {
+		return model, datasource
+	}
+
+	datasourceType := query.Get("group").MustString()
+	if kind := query.Get("kind").MustString(); datasourceType == "" && kind != "DataQuery" {
+		datasourceType = kind
+	}
+	uid := query.Get("datasource").Get("name").MustString()
+	if datasourceType == "" && uid == "" {
+		return model, nil
+	}
+	return model, map[string]interface{}{"type": datasourceType, "uid": uid}
+}
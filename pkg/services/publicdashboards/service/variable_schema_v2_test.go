@@ -0,0 +1,103 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+)
+
+func TestDashboardVariableList(t *testing.T) {
+	t.Run("returns templating.list of v1 dashboards", func(t *testing.T) {
+		dashboard, err := simplejson.NewJson([]byte(`{"templating": {"list": [{"name": "env", "type": "custom"}]}}`))
+		require.NoError(t, err)
+
+		assert.Equal(t, []interface{}{map[string]interface{}{"name": "env", "type": "custom"}}, dashboardVariableList(dashboard))
+	})
+
+	t.Run("converts the variables of v2 dashboards", func(t *testing.T) {
+		dashboard, err := simplejson.NewJson([]byte(`{
+			"elements": {},
+			"variables": [
+				{
+					"kind": "QueryVariable",
+					"spec": {
+						"name": "server",
+						"multi": true,
+						"refresh": "onTimeRangeChanged",
+						"sort": "alphabeticalAsc",
+						"current": {"text": "web-1", "value": "web-1"},
+						"query": {
+							"kind": "DataQuery",
+							"group": "prometheus",
+							"version": "v0",
+							"datasource": {"name": "prom"},
+							"spec": {"query": "label_values(up, instance)"}
+						}
+					}
+				},
+				{
+					"kind": "QueryVariable",
+					"spec": {
+						"name": "host",
+						"datasource": {"type": "mysql", "uid": "mysql"},
+						"query": {"kind": "mysql", "spec": {"rawSql": "SELECT host FROM hosts"}}
+					}
+				},
+				{"kind": "CustomVariable", "spec": {"name": "env", "query": "prod,stage", "refresh": "never"}},
+				{"kind": "DatasourceVariable", "spec": {"name": "ds", "pluginId": "prometheus"}},
+				{"kind": "TextVariable", "spec": {"name": "search", "query": "error"}},
+				{"kind": "UnknownVariable", "spec": {"name": "unknown"}}
+			]
+		}`))
+		require.NoError(t, err)
+
+		variables := dashboardVariableList(dashboard)
+		require.Len(t, variables, 5)
+
+		server := simplejson.NewFromAny(variables[0])
+		assert.Equal(t, "query", server.Get("type").MustString())
+		assert.Equal(t, 2, server.Get("refresh").MustInt())
+		assert.Equal(t, 1, server.Get("sort").MustInt())
+		assert.True(t, server.Get("multi").MustBool())
+		assert.Equal(t, map[string]interface{}{"query": "label_values(up, instance)"}, server.Get("query").Interface())
+		assert.Equal(t, map[string]interface{}{"type": "prometheus", "uid": "prom"}, server.Get("datasource").Interface())
+
+		host := simplejson.NewFromAny(variables[1])
+		assert.Equal(t, map[string]interface{}{"rawSql": "SELECT host FROM hosts"}, host.Get("query").Interface())
+		assert.Equal(t, map[string]interface{}{"type": "mysql", "uid": "mysql"}, host.Get("datasource").Interface())
+
+		assert.Equal(t, "custom", simplejson.NewFromAny(variables[2]).Get("type").MustString())
+		assert.Equal(t, "prod,stage", simplejson.NewFromAny(variables[2]).Get("query").MustString())
+		assert.Equal(t, "prometheus", simplejson.NewFromAny(variables[3]).Get("query").MustString())
+		assert.Equal(t, textboxVariableType, simplejson.NewFromAny(variables[4]).Get("type").MustString())
+	})
+}
+
+func TestFindVariableInDashboardV2(t *testing.T) {
+	dashboardData, err := simplejson.NewJson([]byte(`{
+		"elements": {},
+		"variables": [
+			{"kind": "CustomVariable", "spec": {"name": "env", "query": "prod,stage", "current": {"text": "prod", "value": "prod"}}}
+		]
+	}`))
+	require.NoError(t, err)
+	dashboard := &dashboards.Dashboard{UID: "dash1", OrgID: 1, Data: dashboardData}
+	service := &PublicDashboardServiceImpl{log: log.NewNopLogger()}
+
+	variable, err := service.findVariableInDashboard(dashboard, "env")
+	require.NoError(t, err)
+	assert.Equal(t, "custom", variable.Type)
+	assert.Equal(t, "prod,stage", variable.Query)
+
+	options, err := service.getCustomVariableOptions(variable)
+	require.NoError(t, err)
+	assert.Len(t, options, 2)
+
+	_, err = service.findVariableInDashboard(dashboard, "unknown")
+	assert.Error(t, err)
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+// snapshotVariables captures the options and the saved value of the variables of the dashboard, as viewers get them
+// when they don't change any value. Hidden variables are left out, and nil is returned when there is no variable
+func (pd *PublicDashboardServiceImpl) snapshotVariables(ctx context.Context, dashboard *dashboards.Dashboard, publicDashboard *models.PublicDashboard) models.VariableSnapshot {
+	hidden := hiddenVariables(dashboard.Data)
+	// the options are queried again rather than served from the previous snapshot
+	fresh := *publicDashboard
+	fresh.VariableSnapshot = nil
+
+	var snapshot models.VariableSnapshot
+	for _, name := range dashboardVariableNames(dashboard.Data) {
+		if hidden[name] {
+			continue
+		}
+		variable, err := pd.findVariableInDashboard(dashboard, name)
+		if err != nil {
+			continue
+		}
+
+		options, refreshedAt, err := pd.getVariableOptions(ctx, dashboard, &fresh, variable, models.PublicDashboardVariableQueryDTO{})
+		if err != nil {
+			pd.log.Warn("snapshotVariables: failed to get the options of the variable", "variable", name, "error", err)
+			continue
+		}
+
+		if snapshot == nil {
+			snapshot = models.VariableSnapshot{}
+		}
+		snapshot[name] = models.VariableSnapshotEntry{
+			Options:     options,
+			Current:     models.VariableSnapshotValue{Text: variable.Current.Text, Value: variable.Current.Value},
+			RefreshedAt: refreshedAt,
+		}
+	}
+	return snapshot
+}
+
+// snapshotOptions returns the options of the variable captured in the snapshot of the public dashboard
+func snapshotOptions(publicDashboard *models.PublicDashboard, name string) []models.MetricFindValue {
+	entry, ok := publicDashboard.VariableSnapshot[name]
+	if !ok || entry.Options == nil {
+		return []models.MetricFindValue{}
+	}
+	return entry.Options
+}
+
+// snapshotQueryOptions returns the options of a query variable captured in the snapshot of the public dashboard and
+// when they were queried. The snapshot isn't used when a variable referenced by the query has another value than in
+// the snapshot, as the options depend on it
+func snapshotQueryOptions(publicDashboard *models.PublicDashboard, variable *variableDefinition, values map[string]interface{}) ([]models.MetricFindValue, time.Time, bool) {
+	entry, ok := publicDashboard.VariableSnapshot[variable.Name]
+	if !ok || entry.Options == nil {
+		return nil, time.Time{}, false
+	}
+
+	definition, err := json.Marshal([]interface{}{variable.Query, variable.Datasource})
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+	for _, match := range variableRegex.FindAllStringSubmatch(string(definition), -1) {
+		name := match[1] + match[2] + match[4]
+		if name == "" || name == variable.Name {
+			continue
+		}
+		snap, ok := publicDashboard.VariableSnapshot[name]
+		value, sent := values[name]
+		if !ok || !sent {
+			continue
+		}
+		if !slices.Equal(variableValueStrings(value), variableValueStrings(snap.Current.Value)) {
+			return nil, time.Time{}, false
+		}
+	}
+	return entry.Options, entry.RefreshedAt, true
+}
+
+// applyVariableSnapshot sets the current value and the options of the variables of the dashboard sent to viewers to
+// their snapshot, so viewers start from the state the dashboard was shared with
+func applyVariableSnapshot(publicDashboard *models.PublicDashboard, dashboard *simplejson.Json) {
+	if len(publicDashboard.VariableSnapshot) == 0 {
+		return
+	}
+
+	for _, variable := range dashboardVariableMaps(dashboard) {
+		name, _ := variable["name"].(string)
+		entry, ok := publicDashboard.VariableSnapshot[name]
+		if !ok {
+			continue
+		}
+
+		selected := variableValueStrings(entry.Current.Value)
+		options := make([]interface{}, 0, len(entry.Options))
+		for _, o := range entry.Options {
+			options = append(options, map[string]interface{}{"text": o.Text, "value": o.Value, "selected": slices.Contains(selected, o.Value)})
+		}
+		variable["current"] = map[string]interface{}{"text": entry.Current.Text, "value": entry.Current.Value}
+		variable["options"] = options
+	}
+}
+
+// dashboardVariableMaps returns the variables of the dashboard as maps that can be changed in place: the entries of
+// templating.list for v1 dashboards and the specs of the variables for v2 dashboards
+func dashboardVariableMaps(dashboard *simplejson.Json) []map[string]interface{} {
+	variables := dashboard.GetPath("templating", "list").MustArray()
+	isV2 := dashboard.Get("elements").Interface() != nil
+	if isV2 {
+		variables = dashboard.Get("variables").MustArray()
+	}
+
+	var maps []map[string]interface{}
+	for _, v := range variables {
+		variable, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if isV2 {
+			if variable, ok = variable["spec"].(map[string]interface{}); !ok {
+				continue
+			}
+		}
+		maps = append(maps, variable)
+	}
+	return maps
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+func TestSnapshotVariables(t *testing.T) {
+	service := &PublicDashboardServiceImpl{log: log.NewNopLogger()}
+
+	t.Run("captures the options and the value of visible variables", func(t *testing.T) {
+		data, err := simplejson.NewJson([]byte(`{
+			"templating": {
+				"list": [
+					{"name": "env", "type": "custom", "query": "prod,stage", "current": {"text": "stage", "value": "stage"}},
+					{"name": "tenant", "type": "constant", "hide": 2, "query": "acme", "current": {"text": "acme", "value": "acme"}}
+				]
+			}
+		}`))
+		require.NoError(t, err)
+		dashboard := &dashboards.Dashboard{OrgID: 1, Data: data}
+
+		snapshot := service.snapshotVariables(context.Background(), dashboard, &PublicDashboard{})
+		assert.Equal(t, VariableSnapshot{"env": {
+			Options: []MetricFindValue{{Text: "prod", Value: "prod"}, {Text: "stage", Value: "stage"}},
+			Current: VariableSnapshotValue{Text: "stage", Value: "stage"},
+		}}, snapshot)
+	})
+
+	t.Run("is nil without variables", func(t *testing.T) {
+		dashboard := &dashboards.Dashboard{OrgID: 1, Data: simplejson.New()}
+		assert.Nil(t, service.snapshotVariables(context.Background(), dashboard, &PublicDashboard{}))
+	})
+}
+
+func TestApplyVariableSnapshot(t *testing.T) {
+	pubdash := &PublicDashboard{VariableSnapshot: VariableSnapshot{"server": {
+		Options: []MetricFindValue{{Text: "web-1", Value: "web-1"}, {Text: "web-2", Value: "web-2"}},
+		Current: VariableSnapshotValue{Text: "web-2", Value: "web-2"},
+	}}}
+	expectedOptions := []interface{}{
+		map[string]interface{}{"text": "web-1", "value": "web-1", "selected": false},
+		map[string]interface{}{"text": "web-2", "value": "web-2", "selected": true},
+	}
+
+	t.Run("sets the state of v1 variables", func(t *testing.T) {
+		dashboard, err := simplejson.NewJson([]byte(`{
+			"templating": {"list": [{"name": "server", "type": "query", "current": {"text": "web-1", "value": "web-1"}, "options": []}]}
+		}`))
+		require.NoError(t, err)
+
+		applyVariableSnapshot(pubdash, dashboard)
+		variable := dashboard.GetPath("templating", "list").GetIndex(0)
+		assert.Equal(t, "web-2", variable.GetPath("current", "value").MustString())
+		assert.Equal(t, expectedOptions, variable.Get("options").MustArray())
+	})
+
+	t.Run("sets the state of v2 variables", func(t *testing.T) {
+		dashboard, err := simplejson.NewJson([]byte(`{
+			"elements": {},
+			"variables": [{"kind": "QueryVariable", "spec": {"name": "server", "current": {"text": "web-1", "value": "web-1"}, "options": []}}]
+		}`))
+		require.NoError(t, err)
+
+		applyVariableSnapshot(pubdash, dashboard)
+		spec := dashboard.Get("variables").GetIndex(0).Get("spec")
+		assert.Equal(t, "web-2", spec.GetPath("current", "value").MustString())
+		assert.Equal(t, expectedOptions, spec.Get("options").MustArray())
+	})
+}
+
+func TestSnapshotOptions(t *testing.T) {
+	pubdash := &PublicDashboard{VariableSnapshot: VariableSnapshot{"server": {
+		Options: []MetricFindValue{{Text: "web-1", Value: "web-1"}},
+	}}}
+
+	assert.Equal(t, []MetricFindValue{{Text: "web-1", Value: "web-1"}}, snapshotOptions(pubdash, "server"))
+	assert.Equal(t, []MetricFindValue{}, snapshotOptions(pubdash, "job"))
+	assert.Equal(t, []MetricFindValue{}, snapshotOptions(&PublicDashboard{}, "server"))
+}
+
+func TestSnapshotQueryOptions(t *testing.T) {
+	refreshedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	pubdash := &PublicDashboard{VariableSnapshot: VariableSnapshot{
+		"env": {Options: []MetricFindValue{{Text: "prod", Value: "prod"}}, Current: VariableSnapshotValue{Text: "prod", Value: "prod"}},
+		"server": {
+			Options:     []MetricFindValue{{Text: "web-1", Value: "web-1"}},
+			Current:     VariableSnapshotValue{Text: "web-1", Value: "web-1"},
+			RefreshedAt: refreshedAt,
+		},
+	}}
+	server := &variableDefinition{Name: "server", Type: "query", Query: `label_values(up{env="${env}"}, instance)`}
+
+	t.Run("returns the options of the snapshot when the referenced variables keep their value", func(t *testing.T) {
+		for _, values := range []map[string]interface{}{nil, {"env": "prod"}, {"env": []interface{}{"prod"}, "server": "web-2"}} {
+			options, at, ok := snapshotQueryOptions(pubdash, server, values)
+			require.True(t, ok)
+			assert.Equal(t, []MetricFindValue{{Text: "web-1", Value: "web-1"}}, options)
+			assert.Equal(t, refreshedAt, at)
+		}
+	})
+
+	t.Run("doesn't use the snapshot when a referenced variable has another value", func(t *testing.T) {
+		_, _, ok := snapshotQueryOptions(pubdash, server, map[string]interface{}{"env": "stage"})
+		assert.False(t, ok)
+	})
+
+	t.Run("doesn't use the snapshot when the variable isn't in it", func(t *testing.T) {
+		_, _, ok := snapshotQueryOptions(pubdash, &variableDefinition{Name: "job", Type: "query"}, nil)
+		assert.False(t, ok)
+		_, _, ok = snapshotQueryOptions(&PublicDashboard{}, server, nil)
+		assert.False(t, ok)
+	})
+}
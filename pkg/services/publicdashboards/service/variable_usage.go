@@ -0,0 +1,173 @@
+package service
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+const (
+	// minVariableValueRequests hides values requested fewer times, so rarely used values can't be tied to a
+	// single viewer
+	minVariableValueRequests = 5
+	// maxTrackedValuesPerVariable and maxVariableValueLength bound the memory used by a single variable
+	maxTrackedValuesPerVariable = 1000
+	maxVariableValueLength      = 256
+	defaultTopVariableValues    = 10
+	maxTopVariableValues        = 100
+)
+
+// untrackedVariableTypes hold values typed in by viewers, which can contain anything and are never recorded
+var untrackedVariableTypes = map[string]bool{
+	"textbox": true,
+	"adhoc":   true,
+}
+
+// variableUsageTracker counts how often each variable value is used by the queries of public dashboards, keyed by
+// access token. Only values of variables defined in the dashboard are counted, and only aggregated counts are kept.
+// State is kept in memory, so counts are per Grafana instance and reset on restart. A nil tracker doesn't track anything
+type variableUsageTracker struct {
+	mu         sync.Mutex
+	dashboards map[string]map[string]map[string]int64
+}
+
+func newVariableUsageTracker() *variableUsageTracker {
+	return &variableUsageTracker{dashboards: map[string]map[string]map[string]int64{}}
+}
+
+// record counts the values of the variables sent along with a query. Each value of a multi-value variable is
+// counted on its own
+func (t *variableUsageTracker) record(accessToken string, dashboard *simplejson.Json, variables map[string]any) {
+	if t == nil || len(variables) == 0 {
+		return
+	}
+
+	trackable := trackableVariables(dashboard)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for name, value := range variables {
+		if !trackable[name] {
+			continue
+		}
+
+		for _, v := range variableValues(value) {
+			if v == "" || len(v) > maxVariableValueLength {
+				continue
+			}
+
+			vars, ok := t.dashboards[accessToken]
+			if !ok {
+				vars = map[string]map[string]int64{}
+				t.dashboards[accessToken] = vars
+			}
+			counts, ok := vars[name]
+			if !ok {
+				counts = map[string]int64{}
+				vars[name] = counts
+			}
+			if _, ok := counts[v]; !ok && len(counts) >= maxTrackedValuesPerVariable {
+				continue
+			}
+			counts[v]++
+		}
+	}
+}
+
+// top returns the n most requested values of each variable, most requested first
+func (t *variableUsageTracker) top(accessToken string, n int) map[string][]models.VariableValueUsage {
+	result := map[string][]models.VariableValueUsage{}
+	if t == nil {
+		return result
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for name, counts := range t.dashboards[accessToken] {
+		usage := make([]models.VariableValueUsage, 0, len(counts))
+		for value, count := range counts {
+			if count >= minVariableValueRequests {
+				usage = append(usage, models.VariableValueUsage{Value: value, Requests: count})
+			}
+		}
+		if len(usage) == 0 {
+			continue
+		}
+
+		sort.Slice(usage, func(i, j int) bool {
+			if usage[i].Requests != usage[j].Requests {
+				return usage[i].Requests > usage[j].Requests
+			}
+			return usage[i].Value < usage[j].Value
+		})
+		if len(usage) > n {
+			usage = usage[:n]
+		}
+		result[name] = usage
+	}
+
+	return result
+}
+
+// forget drops the usage of a public dashboard, used when it's deleted
+func (t *variableUsageTracker) forget(accessToken string) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.dashboards, accessToken)
+}
+
+// forgetMatching drops the usage of the public dashboards whose access token matches
+func (t *variableUsageTracker) forgetMatching(match func(accessToken string) bool) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for accessToken := range t.dashboards {
+		if match(accessToken) {
+			delete(t.dashboards, accessToken)
+		}
+	}
+}
+
+// trackableVariables returns the names of the variables of the dashboard whose values can be recorded
+func trackableVariables(dashboard *simplejson.Json) map[string]bool {
+	names := map[string]bool{}
+	for _, v := range dashboardVariableList(dashboard) {
+		variable := simplejson.NewFromAny(v)
+		if name := variable.Get("name").MustString(); name != "" && !untrackedVariableTypes[variable.Get("type").MustString()] {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+func variableValues(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	case []string:
+		return v
+	default:
+		return nil
+	}
+}
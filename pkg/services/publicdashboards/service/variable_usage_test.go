@@ -0,0 +1,64 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+func TestVariableUsageTracker(t *testing.T) {
+	dashboard, err := simplejson.NewJson([]byte(`{
+		"templating": {
+			"list": [
+				{"name": "region", "type": "custom"},
+				{"name": "host", "type": "query"},
+				{"name": "search", "type": "textbox"}
+			]
+		}
+	}`))
+	require.NoError(t, err)
+
+	record := func(tracker *variableUsageTracker, times int, variables map[string]any) {
+		for i := 0; i < times; i++ {
+			tracker.record("token", dashboard, variables)
+		}
+	}
+
+	t.Run("returns the most requested values of each variable", func(t *testing.T) {
+		tracker := newVariableUsageTracker()
+		record(tracker, 7, map[string]any{"region": "eu"})
+		record(tracker, 9, map[string]any{"region": "us"})
+		record(tracker, 5, map[string]any{"region": "ap"})
+		record(tracker, 6, map[string]any{"host": []any{"a", "b"}})
+
+		top := tracker.top("token", 2)
+		assert.Equal(t, []models.VariableValueUsage{{Value: "us", Requests: 9}, {Value: "eu", Requests: 7}}, top["region"])
+		assert.Equal(t, []models.VariableValueUsage{{Value: "a", Requests: 6}, {Value: "b", Requests: 6}}, top["host"])
+	})
+
+	t.Run("hides rarely requested values", func(t *testing.T) {
+		tracker := newVariableUsageTracker()
+		record(tracker, minVariableValueRequests-1, map[string]any{"region": "eu"})
+
+		assert.Empty(t, tracker.top("token", 10))
+	})
+
+	t.Run("doesn't record free text and unknown variables", func(t *testing.T) {
+		tracker := newVariableUsageTracker()
+		record(tracker, 10, map[string]any{"search": "john.doe@example.com", "unknown": "value"})
+
+		assert.Empty(t, tracker.top("token", 10))
+	})
+
+	t.Run("forgets deleted public dashboards", func(t *testing.T) {
+		tracker := newVariableUsageTracker()
+		record(tracker, 10, map[string]any{"region": "eu"})
+		tracker.forget("token")
+
+		assert.Empty(t, tracker.top("token", 10))
+	})
+}
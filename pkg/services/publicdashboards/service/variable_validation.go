@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+const textboxVariableType = "textbox"
+
+// unsafeVariableValueChars are the characters rejected when unsafe variable values are rejected, along with control
+// characters: they could end a quoted string or a statement of a query
+const unsafeVariableValueChars = "'\"`\\;"
+
+// validateVariables checks the variable values sent by a viewer against the values the dashboard allows, so anonymous
+// viewers can't substitute arbitrary values into queries. Variables are validated in the order of the dashboard, and
+// queries of chained variables only receive values that were already validated. Text box variables accept values
+// matching their constraint and ad hoc filters are never interpolated
+func (pd *PublicDashboardServiceImpl) validateVariables(ctx context.Context, dashboard *dashboards.Dashboard, publicDashboard *models.PublicDashboard, variables map[string]interface{}) error {
+	order := make(map[string]bool, len(variables))
+	names := make([]string, 0, len(variables))
+	for _, v := range dashboardVariableList(dashboard.Data) {
+		definition, _ := v.(map[string]interface{})
+		name, _ := definition["name"].(string)
+		if _, ok := variables[name]; ok && !order[name] {
+			order[name] = true
+			names = append(names, name)
+		}
+	}
+	for name := range variables {
+		if !order[name] {
+			return models.ErrInvalidVariableValue.Errorf("validateVariables: variable %s is not defined in the dashboard", name)
+		}
+	}
+
+	validated := make(map[string]interface{}, len(variables))
+	for _, name := range names {
+		value := variables[name]
+		if value == nil {
+			continue
+		}
+
+		// values pinned by the owner are trusted
+		if _, ok := publicDashboard.PinnedVariables[name]; ok {
+			validated[name] = value
+			continue
+		}
+
+		if pd.rejectUnsafeVariableValues && isUnsafeVariableValue(value) {
+			return models.ErrInvalidVariableValue.Errorf("validateVariables: value of variable %s contains unsafe characters", name)
+		}
+
+		variable, err := pd.findVariableInDashboard(dashboard, name)
+		if err != nil {
+			return err
+		}
+
+		if err := pd.validateVariableValue(ctx, dashboard, publicDashboard, variable, value, validated); err != nil {
+			return err
+		}
+		validated[name] = value
+	}
+
+	return nil
+}
+
+// isUnsafeVariableValue returns whether a value has quotes, backslashes, semicolons or control characters
+func isUnsafeVariableValue(value interface{}) bool {
+	for _, v := range variableValueStrings(value) {
+		if strings.ContainsAny(v, unsafeVariableValueChars) || strings.IndexFunc(v, unicode.IsControl) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// overridableValues drops the values sent for variables the public dashboard doesn't allow viewers to change, so
+// these variables keep their saved value. Every value is dropped when variables are disabled
+func overridableValues[T any](publicDashboard *models.PublicDashboard, values map[string]T) map[string]T {
+	if publicDashboard.AllowsVariables() && publicDashboard.VariableOverridesAllowed == nil {
+		return values
+	}
+
+	allowed := make(map[string]T, len(values))
+	for name, value := range values {
+		if publicDashboard.AllowsVariableOverride(name) {
+			allowed[name] = value
+		}
+	}
+	return allowed
+}
+
+func (pd *PublicDashboardServiceImpl) validateVariableValue(ctx context.Context, dashboard *dashboards.Dashboard, publicDashboard *models.PublicDashboard, variable *variableDefinition, value interface{}, validated map[string]interface{}) error {
+	if variable.Type == adhocVariableType {
+		return nil
+	}
+	if variable.Type == textboxVariableType {
+		return validateTextboxValue(publicDashboard, variable, value)
+	}
+
+	values := variableValueStrings(value)
+	if len(values) > 1 && !variable.Multi {
+		return models.ErrInvalidVariableValue.Errorf("validateVariableValue: variable %s doesn't allow several values", variable.Name)
+	}
+
+	if isAllVariableValue(value) {
+		if !variable.IncludeAll {
+			return models.ErrInvalidVariableValue.Errorf("validateVariableValue: variable %s doesn't include all", variable.Name)
+		}
+		return nil
+	}
+
+	// the saved value and options are always allowed
+	allowed := map[string]bool{}
+	for _, v := range variableValueStrings(variable.Current.Value) {
+		allowed[v] = true
+	}
+	for _, o := range variable.Options {
+		allowed[convertInterfaceToString(o.Value)] = true
+	}
+	if allAllowed(allowed, values) {
+		return nil
+	}
+
+	switch variable.Type {
+	case "query":
+		options, err := pd.queryVariableValues(ctx, dashboard, publicDashboard, variable, validated)
+		if err != nil {
+			return err
+		}
+		for _, v := range options {
+			allowed[v] = true
+		}
+	case "custom":
+		options, _ := pd.getCustomVariableOptions(variable)
+		for _, o := range options {
+			allowed[o.Value] = true
+		}
+	case intervalVariableType:
+		options, _ := pd.getIntervalVariableOptions(variable)
+		for _, o := range options {
+			allowed[o.Value] = true
+		}
+	case datasourceVariableType:
+		for _, v := range values {
+			if pd.isDatasourceOfType(ctx, dashboard.OrgID, v, convertInterfaceToString(variable.Query)) {
+				allowed[v] = true
+			}
+		}
+	}
+
+	if !allAllowed(allowed, values) {
+		return models.ErrInvalidVariableValue.Errorf("validateVariableValue: value of variable %s is not one of its options", variable.Name)
+	}
+	return nil
+}
+
+// validateTextboxValue checks the value typed in a text box variable against the constraint of the variable in the
+// public dashboard config. Values are limited to MaxTextboxVariableLength when the variable has no max length
+func validateTextboxValue(publicDashboard *models.PublicDashboard, variable *variableDefinition, value interface{}) error {
+	values := variableValueStrings(value)
+	if len(values) > 1 {
+		return models.ErrInvalidVariableValue.Errorf("validateTextboxValue: variable %s doesn't allow several values", variable.Name)
+	}
+
+	constraint := publicDashboard.VariableConstraints[variable.Name]
+	maxLength := constraint.MaxLength
+	if maxLength == 0 {
+		maxLength = models.MaxTextboxVariableLength
+	}
+
+	var pattern *regexp.Regexp
+	if constraint.Pattern != "" {
+		var err error
+		// the pattern has to match the whole value
+		pattern, err = regexp.Compile("^(?:" + constraint.Pattern + ")$")
+		if err != nil {
+			return models.ErrInvalidVariableValue.Errorf("validateTextboxValue: invalid pattern for variable %s: %w", variable.Name, err)
+		}
+	}
+
+	for _, v := range values {
+		if utf8.RuneCountInString(v) > maxLength {
+			return models.ErrInvalidVariableValue.Errorf("validateTextboxValue: value of variable %s is longer than %d characters", variable.Name, maxLength)
+		}
+		if pattern != nil && !pattern.MatchString(v) {
+			return models.ErrInvalidVariableValue.Errorf("validateTextboxValue: value of variable %s doesn't match its pattern", variable.Name)
+		}
+	}
+	return nil
+}
+
+// queryVariableValues returns the values of a query variable in the order of the query, running its query with the
+// values of the variables it depends on. The options are cached, so the panels of a dashboard don't all run the
+// variable queries
+func (pd *PublicDashboardServiceImpl) queryVariableValues(ctx context.Context, dashboard *dashboards.Dashboard, publicDashboard *models.PublicDashboard, variable *variableDefinition, validated map[string]interface{}) ([]string, error) {
+	options, _, err := pd.getQueryVariableOptions(ctx, dashboard, publicDashboard, variable, models.PublicDashboardVariableQueryDTO{Variables: validated})
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]string, 0, len(options))
+	for _, o := range options {
+		values = append(values, o.Value)
+	}
+	return values, nil
+}
+
+// isDatasourceOfType reports whether the value of a datasource variable is a datasource of the plugin type of the
+// variable, by uid or by name for older dashboards
+func (pd *PublicDashboardServiceImpl) isDatasourceOfType(ctx context.Context, orgID int64, value string, pluginType string) bool {
+	ds, err := pd.datasourceService.GetDataSource(ctx, &datasources.GetDataSourceQuery{UID: value, OrgID: orgID})
+	if errors.Is(err, datasources.ErrDataSourceNotFound) {
+		ds, err = pd.datasourceService.GetDataSource(ctx, &datasources.GetDataSourceQuery{Name: value, OrgID: orgID})
+	}
+	if err != nil {
+		return false
+	}
+	return pluginType == "" || ds.Type == pluginType
+}
+
+func allAllowed(allowed map[string]bool, values []string) bool {
+	for _, v := range values {
+		if !allowed[v] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	fakeDatasources "github.com/grafana/grafana/pkg/services/datasources/fakes"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/services/query"
+)
+
+func TestValidateVariables(t *testing.T) {
+	dashboardData, err := simplejson.NewJson([]byte(`{
+		"templating": {
+			"list": [
+				{
+					"name": "env",
+					"type": "custom",
+					"query": "prod,stage",
+					"current": {"text": "prod", "value": "prod"}
+				},
+				{
+					"name": "server",
+					"type": "query",
+					"multi": true,
+					"includeAll": true,
+					"datasource": {"uid": "prom", "type": "prometheus"},
+					"query": "label_values(up{env=\"$env\"}, instance)",
+					"current": {"text": "web-1", "value": ["web-1"]}
+				},
+				{
+					"name": "region",
+					"type": "constant",
+					"query": "eu",
+					"current": {"text": "eu", "value": "eu"}
+				},
+				{
+					"name": "search",
+					"type": "textbox",
+					"current": {"text": "", "value": ""}
+				},
+				{
+					"name": "ds",
+					"type": "datasource",
+					"query": "prometheus",
+					"current": {"text": "prom", "value": "prom"}
+				}
+			]
+		}
+	}`))
+	require.NoError(t, err)
+	dashboard := &dashboards.Dashboard{UID: "dash1", OrgID: 1, Data: dashboardData}
+	pubdash := &PublicDashboard{Uid: "pubdash1", AccessToken: "abc123", OrgId: 1}
+
+	setup := func() (*PublicDashboardServiceImpl, *query.FakeQueryService) {
+		fakeQueryService := &query.FakeQueryService{}
+		fakeQueryService.On("QueryData", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&backend.QueryDataResponse{
+			Responses: map[string]backend.DataResponse{
+				"A": {Frames: data.Frames{data.NewFrame("", data.NewField("text", nil, []string{"web-1", "web-2"}))}},
+			},
+		}, nil)
+
+		return &PublicDashboardServiceImpl{
+			log:              log.NewNopLogger(),
+			QueryDataService: fakeQueryService,
+			datasourceService: &fakeDatasources.FakeDataSourceService{DataSources: []*datasources.DataSource{
+				{UID: "prom", Name: "Prometheus", OrgID: 1, Type: "prometheus", JsonData: simplejson.New()},
+				{UID: "prom-2", Name: "Prometheus 2", OrgID: 1, Type: "prometheus", JsonData: simplejson.New()},
+				{UID: "loki", Name: "Loki", OrgID: 1, Type: "loki", JsonData: simplejson.New()},
+			}},
+			variableOptions: newVariableOptionsCache(time.Minute, nil),
+		}, fakeQueryService
+	}
+
+	testCases := []struct {
+		name      string
+		variables map[string]interface{}
+		valid     bool
+	}{
+		{name: "accepts the saved values", variables: map[string]interface{}{"env": "prod", "server": []interface{}{"web-1"}, "region": "eu"}, valid: true},
+		{name: "accepts the options of custom variables", variables: map[string]interface{}{"env": "stage"}, valid: true},
+		{name: "rejects values outside the options of custom variables", variables: map[string]interface{}{"env": "dev"}},
+		{name: "rejects other values of constants", variables: map[string]interface{}{"region": "us"}},
+		{name: "rejects unknown variables", variables: map[string]interface{}{"unknown": "value"}},
+		{name: "rejects several values of single value variables", variables: map[string]interface{}{"env": []interface{}{"prod", "stage"}}},
+		{name: "accepts all for variables including all", variables: map[string]interface{}{"server": []interface{}{"$__all"}}, valid: true},
+		{name: "rejects all for other variables", variables: map[string]interface{}{"env": "$__all"}},
+		{name: "accepts the values returned by the variable query", variables: map[string]interface{}{"server": []interface{}{"web-1", "web-2"}}, valid: true},
+		{name: "rejects values not returned by the variable query", variables: map[string]interface{}{"server": []interface{}{"web-1", "db-1"}}},
+		{name: "accepts any value of text boxes", variables: map[string]interface{}{"search": "anything"}, valid: true},
+		{name: "rejects text box values longer than the default limit", variables: map[string]interface{}{"search": strings.Repeat("a", MaxTextboxVariableLength+1)}},
+		{name: "accepts datasources of the variable type", variables: map[string]interface{}{"ds": "prom-2"}, valid: true},
+		{name: "rejects datasources of other types", variables: map[string]interface{}{"ds": "loki"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			service, _ := setup()
+
+			err := service.validateVariables(context.Background(), dashboard, pubdash, tc.variables)
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidVariableValue)
+			}
+		})
+	}
+
+	t.Run("rejects unsafe values when enabled", func(t *testing.T) {
+		service, _ := setup()
+		variables := map[string]interface{}{"search": `x' OR 1=1 --`}
+		require.NoError(t, service.validateVariables(context.Background(), dashboard, pubdash, variables))
+
+		service.rejectUnsafeVariableValues = true
+		assert.ErrorIs(t, service.validateVariables(context.Background(), dashboard, pubdash, variables), ErrInvalidVariableValue)
+		assert.ErrorIs(t, service.validateVariables(context.Background(), dashboard, pubdash, map[string]interface{}{"search": "a\nb"}), ErrInvalidVariableValue)
+		assert.NoError(t, service.validateVariables(context.Background(), dashboard, pubdash, map[string]interface{}{"search": "anything"}))
+	})
+
+	t.Run("reuses the values of query variables", func(t *testing.T) {
+		service, fakeQueryService := setup()
+		variables := map[string]interface{}{"env": "prod", "server": []interface{}{"web-2"}}
+
+		require.NoError(t, service.validateVariables(context.Background(), dashboard, pubdash, variables))
+		require.NoError(t, service.validateVariables(context.Background(), dashboard, pubdash, variables))
+		fakeQueryService.AssertNumberOfCalls(t, "QueryData", 1)
+
+		// the query of the variable depends on env
+		require.NoError(t, service.validateVariables(context.Background(), dashboard, pubdash, map[string]interface{}{"env": "stage", "server": []interface{}{"web-2"}}))
+		fakeQueryService.AssertNumberOfCalls(t, "QueryData", 2)
+	})
+}
+
+func TestValidateTextboxValue(t *testing.T) {
+	variable := &variableDefinition{Name: "search", Type: textboxVariableType}
+	pubdash := &PublicDashboard{VariableConstraints: VariableConstraints{
+		"search": {Pattern: "[a-z0-9-]+", MaxLength: 8},
+	}}
+
+	testCases := []struct {
+		name  string
+		value interface{}
+		valid bool
+	}{
+		{name: "accepts values matching the pattern", value: "web-1", valid: true},
+		{name: "accepts empty values", value: nil, valid: true},
+		{name: "rejects values matching only part of the pattern", value: "web-1\"} or vector(1)"},
+		{name: "rejects values longer than the max length", value: "web-12345"},
+		{name: "rejects several values", value: []interface{}{"web-1", "web-2"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateTextboxValue(pubdash, variable, tc.value)
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidVariableValue)
+			}
+		})
+	}
+
+	t.Run("counts characters rather than bytes", func(t *testing.T) {
+		constrained := &PublicDashboard{VariableConstraints: VariableConstraints{"search": {MaxLength: 3}}}
+		assert.NoError(t, validateTextboxValue(constrained, variable, "äöü"))
+	})
+}
+
+func TestOverridableValues(t *testing.T) {
+	variables := map[string]interface{}{"env": "stage", "server": "web-2"}
+
+	t.Run("keeps every value without allowlist", func(t *testing.T) {
+		assert.Equal(t, variables, overridableValues(&PublicDashboard{}, variables))
+	})
+
+	t.Run("drops the values of variables outside the allowlist", func(t *testing.T) {
+		pubdash := &PublicDashboard{VariableOverridesAllowed: []string{"env", "filters"}}
+
+		assert.Equal(t, map[string]interface{}{"env": "stage"}, overridableValues(pubdash, variables))
+		assert.Equal(t, map[string][]AdhocFilterDTO{"filters": {{Key: "job", Operator: "=", Value: "node"}}}, overridableValues(pubdash, map[string][]AdhocFilterDTO{
+			"filters": {{Key: "job", Operator: "=", Value: "node"}},
+			"others":  {{Key: "job", Operator: "=", Value: "api"}},
+		}))
+	})
+
+	t.Run("drops every value with an empty allowlist", func(t *testing.T) {
+		assert.Empty(t, overridableValues(&PublicDashboard{VariableOverridesAllowed: []string{}}, variables))
+	})
+
+	t.Run("drops every value when variables are disabled", func(t *testing.T) {
+		disabled := false
+		assert.Empty(t, overridableValues(&PublicDashboard{VariablesEnabled: &disabled}, variables))
+		assert.Empty(t, overridableValues(&PublicDashboard{VariablesEnabled: &disabled, VariableOverridesAllowed: []string{"env"}}, variables))
+	})
+}
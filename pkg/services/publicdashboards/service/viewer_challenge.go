@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"math/bits"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// proofOfWorkChallengeTTL bounds how long viewers have to solve a proof-of-work challenge
+const proofOfWorkChallengeTTL = 5 * time.Minute
+
+// captchaVerifyURLs are the siteverify endpoints of the supported captcha services, they share the same API
+var captchaVerifyURLs = map[string]string{
+	"turnstile": "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+	"hcaptcha":  "https://api.hcaptcha.com/siteverify",
+	"recaptcha": "https://www.google.com/recaptcha/api/siteverify",
+}
+
+// ConfigViewerChallenger challenges viewers with the proof-of-work or the captcha of the public dashboards settings
+type ConfigViewerChallenger struct {
+	challenge        string
+	orgs             []int64
+	difficulty       int
+	captchaProvider  string
+	captchaSiteKey   string
+	captchaSecret    string
+	captchaVerifyURL string
+	key              []byte
+	httpClient       *http.Client
+}
+
+var _ publicdashboards.ViewerChallenger = (*ConfigViewerChallenger)(nil)
+
+func ProvideViewerChallenger(cfg *setting.Cfg) *ConfigViewerChallenger {
+	h := hmac.New(sha256.New, []byte(cfg.SecretKey))
+	h.Write([]byte("publicdashboards.viewerChallenge"))
+
+	return &ConfigViewerChallenger{
+		challenge:        cfg.PublicDashboardsViewerChallenge,
+		orgs:             cfg.PublicDashboardsViewerChallengeOrgs,
+		difficulty:       cfg.PublicDashboardsViewerChallengeDifficulty,
+		captchaProvider:  cfg.PublicDashboardsViewerChallengeCaptchaProvider,
+		captchaSiteKey:   cfg.PublicDashboardsViewerChallengeCaptchaSiteKey,
+		captchaSecret:    cfg.PublicDashboardsViewerChallengeCaptchaSecretKey,
+		captchaVerifyURL: captchaVerifyURLs[cfg.PublicDashboardsViewerChallengeCaptchaProvider],
+		key:              h.Sum(nil),
+		httpClient:       &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// proofOfWorkClaims are the claims of proof-of-work challenges. They're signed, so challenges don't have to be stored
+// and can't be made easier, and bound to the public dashboard and the client they were issued for
+type proofOfWorkClaims struct {
+	jwt.RegisteredClaims
+	Client     string `json:"cli"`
+	Difficulty int    `json:"dif"`
+}
+
+// NewChallenge returns a signed proof-of-work challenge or the captcha widget settings when viewers of the org of the
+// public dashboard are challenged
+func (v *ConfigViewerChallenger) NewChallenge(_ context.Context, pubdash *PublicDashboard, client ViewerClient) (*ViewerChallenge, error) {
+	switch v.challengeOf(pubdash) {
+	case ViewerChallengeTypeProofOfWork:
+		nonce, err := util.GetRandomString(16)
+		if err != nil {
+			return nil, ErrInternalServerError.Errorf("NewChallenge: failed to generate a nonce: %w", err)
+		}
+
+		now := time.Now()
+		claims := proofOfWorkClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        nonce,
+				Subject:   pubdash.Uid,
+				IssuedAt:  jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(proofOfWorkChallengeTTL)),
+			},
+			Client:     viewerTokenBinding(v.key, client.IP, client.UserAgent),
+			Difficulty: v.difficulty,
+		}
+		challenge, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(v.key)
+		if err != nil {
+			return nil, ErrInternalServerError.Errorf("NewChallenge: failed to sign challenge: %w", err)
+		}
+		return &ViewerChallenge{Type: ViewerChallengeTypeProofOfWork, Challenge: challenge, Difficulty: v.difficulty}, nil
+	case ViewerChallengeTypeCaptcha:
+		return &ViewerChallenge{Type: ViewerChallengeTypeCaptcha, Provider: v.captchaProvider, SiteKey: v.captchaSiteKey}, nil
+	default:
+		return &ViewerChallenge{Type: ViewerChallengeTypeNone}, nil
+	}
+}
+
+// VerifySolution checks the nonce of proof-of-work challenges, and the response of captchas with the captcha service
+func (v *ConfigViewerChallenger) VerifySolution(ctx context.Context, pubdash *PublicDashboard, client ViewerClient, solution *ViewerChallengeSolution) error {
+	challenge := v.challengeOf(pubdash)
+	if challenge == ViewerChallengeTypeNone {
+		return nil
+	}
+	if solution == nil {
+		return ErrViewerChallengeRequired.Errorf("VerifySolution: missing solution of the %s challenge", challenge)
+	}
+
+	if challenge == ViewerChallengeTypeProofOfWork {
+		return v.verifyProofOfWork(pubdash, client, solution)
+	}
+	return v.verifyCaptcha(ctx, client, solution)
+}
+
+func (v *ConfigViewerChallenger) challengeOf(pubdash *PublicDashboard) string {
+	if v.challenge == "" || (len(v.orgs) > 0 && !slices.Contains(v.orgs, pubdash.OrgId)) {
+		return ViewerChallengeTypeNone
+	}
+	return v.challenge
+}
+
+func (v *ConfigViewerChallenger) verifyProofOfWork(pubdash *PublicDashboard, client ViewerClient, solution *ViewerChallengeSolution) error {
+	claims := &proofOfWorkClaims{}
+	_, err := jwt.ParseWithClaims(solution.Challenge, claims, func(_ *jwt.Token) (any, error) {
+		return v.key, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return ErrViewerChallengeFailed.Errorf("verifyProofOfWork: %w", err)
+	}
+
+	if claims.Subject != pubdash.Uid {
+		return ErrViewerChallengeFailed.Errorf("verifyProofOfWork: challenge issued for another public dashboard")
+	}
+	if !hmac.Equal([]byte(claims.Client), []byte(viewerTokenBinding(v.key, client.IP, client.UserAgent))) {
+		return ErrViewerChallengeFailed.Errorf("verifyProofOfWork: challenge issued to another client")
+	}
+	if !solvesProofOfWork(solution.Challenge, solution.Nonce, claims.Difficulty) {
+		return ErrViewerChallengeFailed.Errorf("verifyProofOfWork: nonce doesn't solve the challenge")
+	}
+	return nil
+}
+
+// solvesProofOfWork reports whether the SHA-256 of the challenge, a colon and the nonce starts with difficulty zero bits
+func solvesProofOfWork(challenge string, nonce string, difficulty int) bool {
+	if nonce == "" || len(nonce) > 64 {
+		return false
+	}
+
+	sum := sha256.Sum256([]byte(challenge + ":" + nonce))
+	zeros := 0
+	for _, b := range sum {
+		if b != 0 {
+			zeros += bits.LeadingZeros8(b)
+			break
+		}
+		zeros += 8
+	}
+	return zeros >= difficulty
+}
+
+func (v *ConfigViewerChallenger) verifyCaptcha(ctx context.Context, client ViewerClient, solution *ViewerChallengeSolution) error {
+	if solution.Response == "" {
+		return ErrViewerChallengeFailed.Errorf("verifyCaptcha: missing captcha response")
+	}
+
+	form := url.Values{
+		"secret":   {v.captchaSecret},
+		"response": {solution.Response},
+		"remoteip": {client.IP},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.captchaVerifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return ErrInternalServerError.Errorf("verifyCaptcha: failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return ErrInternalServerError.Errorf("verifyCaptcha: failed to verify captcha response: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return ErrInternalServerError.Errorf("verifyCaptcha: captcha service responded with status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Success    bool     `json:"success"`
+		ErrorCodes []string `json:"error-codes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return ErrInternalServerError.Errorf("verifyCaptcha: failed to decode captcha service response: %w", err)
+	}
+	if !result.Success {
+		return ErrViewerChallengeFailed.Errorf("verifyCaptcha: captcha response rejected: %s", strings.Join(result.ErrorCodes, ","))
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func solveProofOfWork(t *testing.T, challenge *ViewerChallenge) string {
+	t.Helper()
+	for i := 0; i < 1<<20; i++ {
+		nonce := strconv.Itoa(i)
+		if solvesProofOfWork(challenge.Challenge, nonce, challenge.Difficulty) {
+			return nonce
+		}
+	}
+	t.Fatal("proof-of-work not solved")
+	return ""
+}
+
+func TestConfigViewerChallenger(t *testing.T) {
+	pubdash := &PublicDashboard{Uid: "uid1", OrgId: 1}
+	client := ViewerClient{IP: "10.0.0.1", UserAgent: "Mozilla/5.0"}
+
+	newChallenger := func(challenge string, orgs ...int64) *ConfigViewerChallenger {
+		cfg := setting.NewCfg()
+		cfg.SecretKey = "secret"
+		cfg.PublicDashboardsViewerChallenge = challenge
+		cfg.PublicDashboardsViewerChallengeOrgs = orgs
+		cfg.PublicDashboardsViewerChallengeDifficulty = 8
+		cfg.PublicDashboardsViewerChallengeCaptchaProvider = "turnstile"
+		cfg.PublicDashboardsViewerChallengeCaptchaSiteKey = "site"
+		cfg.PublicDashboardsViewerChallengeCaptchaSecretKey = "captcha-secret"
+		return ProvideViewerChallenger(cfg)
+	}
+
+	t.Run("doesn't challenge viewers when disabled", func(t *testing.T) {
+		challenger := newChallenger("")
+		challenge, err := challenger.NewChallenge(context.Background(), pubdash, client)
+		require.NoError(t, err)
+		assert.Equal(t, ViewerChallengeTypeNone, challenge.Type)
+		require.NoError(t, challenger.VerifySolution(context.Background(), pubdash, client, nil))
+	})
+
+	t.Run("only challenges viewers of the configured orgs", func(t *testing.T) {
+		challenger := newChallenger(ViewerChallengeTypeProofOfWork, 2)
+		challenge, err := challenger.NewChallenge(context.Background(), pubdash, client)
+		require.NoError(t, err)
+		assert.Equal(t, ViewerChallengeTypeNone, challenge.Type)
+
+		challenge, err = challenger.NewChallenge(context.Background(), &PublicDashboard{Uid: "uid2", OrgId: 2}, client)
+		require.NoError(t, err)
+		assert.Equal(t, ViewerChallengeTypeProofOfWork, challenge.Type)
+	})
+
+	t.Run("accepts solved proof-of-work challenges", func(t *testing.T) {
+		challenger := newChallenger(ViewerChallengeTypeProofOfWork)
+		challenge, err := challenger.NewChallenge(context.Background(), pubdash, client)
+		require.NoError(t, err)
+		assert.Equal(t, 8, challenge.Difficulty)
+
+		solution := &ViewerChallengeSolution{Challenge: challenge.Challenge, Nonce: solveProofOfWork(t, challenge)}
+		require.NoError(t, challenger.VerifySolution(context.Background(), pubdash, client, solution))
+	})
+
+	t.Run("rejects unsolved and foreign proof-of-work challenges", func(t *testing.T) {
+		challenger := newChallenger(ViewerChallengeTypeProofOfWork)
+		challenge, err := challenger.NewChallenge(context.Background(), pubdash, client)
+		require.NoError(t, err)
+		nonce := solveProofOfWork(t, challenge)
+
+		err = challenger.VerifySolution(context.Background(), pubdash, client, nil)
+		require.ErrorIs(t, err, ErrViewerChallengeRequired)
+
+		wrongNonce := "0"
+		for i := 1; solvesProofOfWork(challenge.Challenge, wrongNonce, challenge.Difficulty); i++ {
+			wrongNonce = strconv.Itoa(i)
+		}
+		err = challenger.VerifySolution(context.Background(), pubdash, client, &ViewerChallengeSolution{Challenge: challenge.Challenge, Nonce: wrongNonce})
+		require.ErrorIs(t, err, ErrViewerChallengeFailed)
+
+		err = challenger.VerifySolution(context.Background(), pubdash, ViewerClient{IP: "10.0.0.2", UserAgent: client.UserAgent}, &ViewerChallengeSolution{Challenge: challenge.Challenge, Nonce: nonce})
+		require.ErrorIs(t, err, ErrViewerChallengeFailed)
+
+		err = challenger.VerifySolution(context.Background(), &PublicDashboard{Uid: "uid2", OrgId: 1}, client, &ViewerChallengeSolution{Challenge: challenge.Challenge, Nonce: nonce})
+		require.ErrorIs(t, err, ErrViewerChallengeFailed)
+
+		err = challenger.VerifySolution(context.Background(), pubdash, client, &ViewerChallengeSolution{Challenge: challenge.Challenge + "x", Nonce: nonce})
+		require.ErrorIs(t, err, ErrViewerChallengeFailed)
+	})
+
+	t.Run("verifies captcha responses with the captcha service", func(t *testing.T) {
+		captchaService := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, "captcha-secret", r.PostForm.Get("secret"))
+			assert.Equal(t, client.IP, r.PostForm.Get("remoteip"))
+			if r.PostForm.Get("response") == "valid" {
+				_, _ = w.Write([]byte(`{"success":true}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"success":false,"error-codes":["invalid-input-response"]}`))
+		}))
+		defer captchaService.Close()
+
+		challenger := newChallenger(ViewerChallengeTypeCaptcha)
+		challenger.captchaVerifyURL = captchaService.URL
+
+		challenge, err := challenger.NewChallenge(context.Background(), pubdash, client)
+		require.NoError(t, err)
+		assert.Equal(t, &ViewerChallenge{Type: ViewerChallengeTypeCaptcha, Provider: "turnstile", SiteKey: "site"}, challenge)
+
+		require.NoError(t, challenger.VerifySolution(context.Background(), pubdash, client, &ViewerChallengeSolution{Response: "valid"}))
+		err = challenger.VerifySolution(context.Background(), pubdash, client, &ViewerChallengeSolution{Response: "invalid"})
+		require.ErrorIs(t, err, ErrViewerChallengeFailed)
+		err = challenger.VerifySolution(context.Background(), pubdash, client, &ViewerChallengeSolution{})
+		require.ErrorIs(t, err, ErrViewerChallengeFailed)
+	})
+}
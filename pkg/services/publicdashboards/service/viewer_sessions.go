@@ -0,0 +1,65 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// viewerSessionLimiter keeps track of the viewer sessions of the public dashboards limiting their concurrent viewers,
+// keyed by public dashboard uid. Sessions are identified by the client their viewer tokens are bound to, so a viewer
+// getting a new token keeps its session, and end once they didn't send a request for viewerSessionTimeout. State is
+// kept in memory, so limits apply per Grafana instance. A nil limiter doesn't limit anything
+type viewerSessionLimiter struct {
+	mu         sync.Mutex
+	dashboards map[string]map[string]time.Time
+}
+
+func newViewerSessionLimiter() *viewerSessionLimiter {
+	return &viewerSessionLimiter{dashboards: map[string]map[string]time.Time{}}
+}
+
+// admit marks the session as active when it already is or the public dashboard has fewer sessions than the limit.
+// Otherwise it returns false and how long until the oldest session ends. A limit of zero admits every session
+func (l *viewerSessionLimiter) admit(uid string, session string, limit int64, now time.Time) (bool, time.Duration) {
+	if l == nil || limit <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	sessions, ok := l.dashboards[uid]
+	if !ok {
+		sessions = map[string]time.Time{}
+		l.dashboards[uid] = sessions
+	}
+
+	var oldest time.Time
+	for id, lastSeen := range sessions {
+		if now.Sub(lastSeen) > viewerSessionTimeout {
+			delete(sessions, id)
+			continue
+		}
+		if oldest.IsZero() || lastSeen.Before(oldest) {
+			oldest = lastSeen
+		}
+	}
+
+	if _, ok := sessions[session]; !ok && int64(len(sessions)) >= limit {
+		return false, oldest.Add(viewerSessionTimeout).Sub(now)
+	}
+	sessions[session] = now
+	return true, 0
+}
+
+// forget drops the sessions of a public dashboard, used when it's deleted or revoked
+func (l *viewerSessionLimiter) forget(uid string) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.dashboards, uid)
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestViewerSessionLimiter(t *testing.T) {
+	now := time.Now()
+
+	t.Run("admits sessions up to the limit", func(t *testing.T) {
+		limiter := newViewerSessionLimiter()
+		admitted, _ := limiter.admit("uid", "a", 2, now)
+		assert.True(t, admitted)
+		admitted, _ = limiter.admit("uid", "b", 2, now.Add(10*time.Second))
+		assert.True(t, admitted)
+
+		admitted, retryAfter := limiter.admit("uid", "c", 2, now.Add(30*time.Second))
+		assert.False(t, admitted)
+		assert.Equal(t, viewerSessionTimeout-30*time.Second, retryAfter, "until the oldest session ends")
+
+		admitted, _ = limiter.admit("uid", "a", 2, now.Add(30*time.Second))
+		assert.True(t, admitted, "active sessions are admitted")
+		admitted, _ = limiter.admit("other", "c", 2, now.Add(30*time.Second))
+		assert.True(t, admitted, "limits are per public dashboard")
+	})
+
+	t.Run("ends sessions without requests", func(t *testing.T) {
+		limiter := newViewerSessionLimiter()
+		limiter.admit("uid", "a", 1, now)
+
+		admitted, _ := limiter.admit("uid", "b", 1, now.Add(viewerSessionTimeout+time.Second))
+		assert.True(t, admitted)
+		admitted, _ = limiter.admit("uid", "a", 1, now.Add(viewerSessionTimeout+time.Second))
+		assert.False(t, admitted)
+	})
+
+	t.Run("forgets deleted public dashboards", func(t *testing.T) {
+		limiter := newViewerSessionLimiter()
+		limiter.admit("uid", "a", 1, now)
+		limiter.forget("uid")
+
+		admitted, _ := limiter.admit("uid", "b", 1, now)
+		assert.True(t, admitted)
+	})
+
+	t.Run("zero doesn't limit anything", func(t *testing.T) {
+		limiter := newViewerSessionLimiter()
+		for _, session := range []string{"a", "b", "c"} {
+			admitted, _ := limiter.admit("uid", session, 0, now)
+			assert.True(t, admitted)
+		}
+		assert.Empty(t, limiter.dashboards)
+	})
+
+	t.Run("nil limiter doesn't limit anything", func(t *testing.T) {
+		var limiter *viewerSessionLimiter
+		admitted, _ := limiter.admit("uid", "a", 1, now)
+		assert.True(t, admitted)
+		limiter.forget("uid")
+	})
+}
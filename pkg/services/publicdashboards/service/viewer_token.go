@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+)
+
+const viewerTokenAudience = "grafana-public-dashboards"
+
+// viewerTokenClaims are the claims of viewer tokens. The token is bound to the access token it was exchanged for and
+// to the client it was issued to by keyed hashes, so neither ends up in the token. MaxViewers is the limit of
+// concurrent viewers of the public dashboard when the token was issued
+type viewerTokenClaims struct {
+	jwt.RegisteredClaims
+	AccessToken string `json:"ath"`
+	Client      string `json:"cli"`
+	MaxViewers  int64  `json:"mcv,omitempty"`
+}
+
+// GetViewerChallenge returns the challenge the client solves before it gets a viewer token for the access token or
+// slug of an enabled public dashboard
+func (pd *PublicDashboardServiceImpl) GetViewerChallenge(ctx context.Context, accessToken string, client ViewerClient) (*ViewerChallenge, error) {
+	ctx, span := tracer.Start(ctx, "publicdashboards.GetViewerChallenge")
+	defer span.End()
+
+	pubdash, _, err := pd.FindEnabledPublicDashboardAndDashboardByAccessToken(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if pd.viewerChallenger == nil {
+		return &ViewerChallenge{Type: ViewerChallengeTypeNone}, nil
+	}
+	return pd.viewerChallenger.NewChallenge(ctx, pubdash, client)
+}
+
+// IssueViewerToken exchanges the access token or slug of an enabled public dashboard for a short-lived signed viewer
+// token bound to the client, once the client solved the viewer challenge and when the public dashboard has room for
+// another viewer
+func (pd *PublicDashboardServiceImpl) IssueViewerToken(ctx context.Context, accessToken string, client ViewerClient, solution *ViewerChallengeSolution) (*ViewerToken, error) {
+	ctx, span := tracer.Start(ctx, "publicdashboards.IssueViewerToken")
+	defer span.End()
+
+	pubdash, _, err := pd.FindEnabledPublicDashboardAndDashboardByAccessToken(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if pd.viewerChallenger != nil {
+		if err := pd.viewerChallenger.VerifySolution(ctx, pubdash, client, solution); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(pd.cfg.PublicDashboardsViewerTokenTTL)
+	// viewer tokens don't outlive the public dashboard
+	if !pubdash.ExpiresAt.IsZero() && pubdash.ExpiresAt.Before(expiresAt) {
+		expiresAt = pubdash.ExpiresAt
+	}
+
+	key := pd.viewerTokenKey()
+	claims := viewerTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   pubdash.Uid,
+			Audience:  jwt.ClaimStrings{viewerTokenAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		AccessToken: viewerTokenBinding(key, accessToken),
+		Client:      viewerTokenBinding(key, client.IP, client.UserAgent),
+		MaxViewers:  pubdash.MaxConcurrentViewers,
+	}
+
+	if err := pd.admitViewer("IssueViewerToken", pubdash.Uid, claims.Client, claims.MaxViewers, now); err != nil {
+		return nil, err
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+	if err != nil {
+		return nil, ErrInternalServerError.Errorf("IssueViewerToken: failed to sign viewer token: %w", err)
+	}
+
+	return &ViewerToken{Token: signed, ExpiresAt: expiresAt.UTC()}, nil
+}
+
+// ValidateViewerToken checks the viewer token was issued for the access token or slug to the client and hasn't
+// expired, and keeps the viewer session active. Viewers whose session ended are admitted again if the public dashboard
+// has room for them. It doesn't check the public dashboard, which is left to the handlers
+func (pd *PublicDashboardServiceImpl) ValidateViewerToken(ctx context.Context, accessToken string, token string, client ViewerClient) error {
+	_, span := tracer.Start(ctx, "publicdashboards.ValidateViewerToken")
+	defer span.End()
+
+	if token == "" {
+		return ErrInvalidViewerToken.Errorf("ValidateViewerToken: missing viewer token")
+	}
+
+	key := pd.viewerTokenKey()
+	claims := &viewerTokenClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(_ *jwt.Token) (any, error) {
+		return key, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return ErrInvalidViewerToken.Errorf("ValidateViewerToken: %w", err)
+	}
+
+	if !claims.VerifyAudience(viewerTokenAudience, true) {
+		return ErrInvalidViewerToken.Errorf("ValidateViewerToken: unexpected audience")
+	}
+	if !hmac.Equal([]byte(claims.AccessToken), []byte(viewerTokenBinding(key, accessToken))) {
+		return ErrInvalidViewerToken.Errorf("ValidateViewerToken: token issued for another public dashboard")
+	}
+	if !hmac.Equal([]byte(claims.Client), []byte(viewerTokenBinding(key, client.IP, client.UserAgent))) {
+		return ErrInvalidViewerToken.Errorf("ValidateViewerToken: token issued to another client")
+	}
+
+	return pd.admitViewer("ValidateViewerToken", claims.Subject, claims.Client, claims.MaxViewers, time.Now())
+}
+
+// admitViewer returns an error with the number of seconds to wait before trying again when the public dashboard has
+// reached its limit of concurrent viewers
+func (pd *PublicDashboardServiceImpl) admitViewer(caller string, uid string, session string, limit int64, now time.Time) error {
+	admitted, retryAfter := pd.viewerSessions.admit(uid, session, limit, now)
+	if admitted {
+		return nil
+	}
+
+	err := ErrViewerLimitReached.Errorf("%s: public dashboard %s has reached its limit of %d concurrent viewers", caller, uid, limit)
+	err.PublicPayload = map[string]any{"retryAfterSeconds": int(math.Ceil(retryAfter.Seconds()))}
+	return err
+}
+
+// viewerTokenKey derives the signing key of viewer tokens from the secret key, so it's not the key used elsewhere
+func (pd *PublicDashboardServiceImpl) viewerTokenKey() []byte {
+	h := hmac.New(sha256.New, []byte(pd.cfg.SecretKey))
+	h.Write([]byte("publicdashboards.viewerToken"))
+	return h.Sum(nil)
+}
+
+func viewerTokenBinding(key []byte, values ...string) string {
+	h := hmac.New(sha256.New, key)
+	for _, value := range values {
+		h.Write([]byte(value))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
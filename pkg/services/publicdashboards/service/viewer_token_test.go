@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/licensing/licensingtest"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards"
+	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestViewerToken(t *testing.T) {
+	accessToken := "e71950f4b5fc4a9d8a34a2d1b2d7e5e0"
+	otherAccessToken := "a0c3b9c4f1e54d5b9a7e8f6d5c4b3a21"
+	client := ViewerClient{IP: "10.0.0.1", UserAgent: "Mozilla/5.0"}
+
+	setup := func(t *testing.T, pubdash *PublicDashboard) *PublicDashboardServiceImpl {
+		fakeStore := &FakePublicDashboardStore{}
+		fakeStore.On("FindByAccessToken", mock.Anything, mock.AnythingOfType("string")).Return(pubdash, nil)
+		fakeDashboardService := &dashboards.FakeDashboardService{}
+		fakeDashboardService.On("GetDashboard", mock.Anything, mock.Anything, mock.Anything).Return(&dashboards.Dashboard{UID: pubdash.DashboardUid, OrgID: 1, Data: simplejson.New()}, nil)
+
+		license := licensingtest.NewFakeLicensing()
+		license.On("FeatureEnabled", FeaturePublicDashboardsEmailSharing).Return(false)
+
+		cfg := setting.NewCfg()
+		cfg.SecretKey = "secret"
+		cfg.PublicDashboardsViewerTokenTTL = 10 * time.Minute
+		return &PublicDashboardServiceImpl{
+			log:              log.NewNopLogger(),
+			cfg:              cfg,
+			store:            fakeStore,
+			dashboardService: fakeDashboardService,
+			license:          license,
+			viewerSessions:   newViewerSessionLimiter(),
+		}
+	}
+	pubdash := &PublicDashboard{Uid: "uid1", IsEnabled: true, OrgId: 1, DashboardUid: "dash1", AccessToken: accessToken}
+
+	t.Run("issued tokens are valid for the client", func(t *testing.T) {
+		service := setup(t, pubdash)
+		token, err := service.IssueViewerToken(context.Background(), accessToken, client, nil)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(10*time.Minute), token.ExpiresAt, time.Minute)
+
+		require.NoError(t, service.ValidateViewerToken(context.Background(), accessToken, token.Token, client))
+	})
+
+	t.Run("tokens aren't valid for other clients", func(t *testing.T) {
+		service := setup(t, pubdash)
+		token, err := service.IssueViewerToken(context.Background(), accessToken, client, nil)
+		require.NoError(t, err)
+
+		err = service.ValidateViewerToken(context.Background(), accessToken, token.Token, ViewerClient{IP: "10.0.0.2", UserAgent: client.UserAgent})
+		require.ErrorIs(t, err, ErrInvalidViewerToken)
+		err = service.ValidateViewerToken(context.Background(), accessToken, token.Token, ViewerClient{IP: client.IP, UserAgent: "curl/8.0"})
+		require.ErrorIs(t, err, ErrInvalidViewerToken)
+	})
+
+	t.Run("tokens aren't valid for other public dashboards", func(t *testing.T) {
+		service := setup(t, pubdash)
+		token, err := service.IssueViewerToken(context.Background(), accessToken, client, nil)
+		require.NoError(t, err)
+
+		err = service.ValidateViewerToken(context.Background(), otherAccessToken, token.Token, client)
+		require.ErrorIs(t, err, ErrInvalidViewerToken)
+	})
+
+	t.Run("expired tokens aren't valid", func(t *testing.T) {
+		service := setup(t, pubdash)
+		service.cfg.PublicDashboardsViewerTokenTTL = -time.Minute
+		token, err := service.IssueViewerToken(context.Background(), accessToken, client, nil)
+		require.NoError(t, err)
+
+		err = service.ValidateViewerToken(context.Background(), accessToken, token.Token, client)
+		require.ErrorIs(t, err, ErrInvalidViewerToken)
+	})
+
+	t.Run("tokens signed with another key aren't valid", func(t *testing.T) {
+		token, err := setup(t, pubdash).IssueViewerToken(context.Background(), accessToken, client, nil)
+		require.NoError(t, err)
+
+		service := setup(t, pubdash)
+		service.cfg.SecretKey = "other"
+		err = service.ValidateViewerToken(context.Background(), accessToken, token.Token, client)
+		require.ErrorIs(t, err, ErrInvalidViewerToken)
+	})
+
+	t.Run("missing tokens aren't valid", func(t *testing.T) {
+		err := setup(t, pubdash).ValidateViewerToken(context.Background(), accessToken, "", client)
+		require.ErrorIs(t, err, ErrInvalidViewerToken)
+	})
+
+	t.Run("tokens don't outlive the public dashboard", func(t *testing.T) {
+		expiresAt := time.Now().Add(time.Minute).Truncate(time.Second)
+		expiring := *pubdash
+		expiring.ExpiresAt = expiresAt
+
+		token, err := setup(t, &expiring).IssueViewerToken(context.Background(), accessToken, client, nil)
+		require.NoError(t, err)
+		assert.True(t, expiresAt.Equal(token.ExpiresAt))
+	})
+
+	t.Run("viewers who didn't solve the challenge don't get tokens", func(t *testing.T) {
+		service := setup(t, pubdash)
+		challenger := NewFakeViewerChallenger(t)
+		challenger.On("VerifySolution", mock.Anything, pubdash, client, (*ViewerChallengeSolution)(nil)).Return(ErrViewerChallengeRequired.Errorf("missing solution"))
+		service.viewerChallenger = challenger
+
+		_, err := service.IssueViewerToken(context.Background(), accessToken, client, nil)
+		require.ErrorIs(t, err, ErrViewerChallengeRequired)
+	})
+
+	t.Run("disabled public dashboards don't get tokens", func(t *testing.T) {
+		disabled := *pubdash
+		disabled.IsEnabled = false
+
+		_, err := setup(t, &disabled).IssueViewerToken(context.Background(), accessToken, client, nil)
+		require.ErrorIs(t, err, ErrPublicDashboardNotEnabled)
+	})
+
+	t.Run("viewers above the limit of concurrent viewers don't get tokens", func(t *testing.T) {
+		limited := *pubdash
+		limited.MaxConcurrentViewers = 1
+		service := setup(t, &limited)
+
+		token, err := service.IssueViewerToken(context.Background(), accessToken, client, nil)
+		require.NoError(t, err)
+		_, err = service.IssueViewerToken(context.Background(), accessToken, client, nil)
+		require.NoError(t, err, "viewers getting a new token keep their session")
+
+		_, err = service.IssueViewerToken(context.Background(), accessToken, ViewerClient{IP: "10.0.0.2", UserAgent: client.UserAgent}, nil)
+		require.ErrorIs(t, err, ErrViewerLimitReached)
+		var limitErr errutil.Error
+		require.ErrorAs(t, err, &limitErr)
+		assert.Equal(t, 90, limitErr.PublicPayload["retryAfterSeconds"])
+
+		require.NoError(t, service.ValidateViewerToken(context.Background(), accessToken, token.Token, client))
+	})
+
+	t.Run("viewers whose session ended are admitted again when there is room", func(t *testing.T) {
+		limited := *pubdash
+		limited.MaxConcurrentViewers = 1
+		service := setup(t, &limited)
+
+		token, err := service.IssueViewerToken(context.Background(), accessToken, client, nil)
+		require.NoError(t, err)
+		// another viewer took the only session once the session of the client ended
+		service.viewerSessions.forget(limited.Uid)
+		_, err = service.IssueViewerToken(context.Background(), accessToken, ViewerClient{IP: "10.0.0.2", UserAgent: client.UserAgent}, nil)
+		require.NoError(t, err)
+
+		err = service.ValidateViewerToken(context.Background(), accessToken, token.Token, client)
+		require.ErrorIs(t, err, ErrViewerLimitReached)
+	})
+}
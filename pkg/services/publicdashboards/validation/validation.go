@@ -1,10 +1,14 @@
 package validation
 
 import (
+	"regexp"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
 	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
 	"github.com/grafana/grafana/pkg/util"
+	"golang.org/x/text/language"
 )
 
 func ValidatePublicDashboard(dto *SavePublicDashboardDTO) error {
@@ -13,6 +17,65 @@ func ValidatePublicDashboard(dto *SavePublicDashboardDTO) error {
 		return ErrInvalidShareType.Errorf("ValidateSavePublicDashboard: invalid share type")
 	}
 
+	// if it is empty we keep the existing value, or default to normal on creation
+	if dto.PublicDashboard.QueryCachingMode != "" && !IsValidQueryCachingMode(dto.PublicDashboard.QueryCachingMode) {
+		return ErrInvalidQueryCachingMode.Errorf("ValidateSavePublicDashboard: invalid query caching mode")
+	}
+
+	if dto.PublicDashboard.ExportLocale != "" && !IsValidExportLocale(dto.PublicDashboard.ExportLocale) {
+		return ErrInvalidExportLocale.Errorf("ValidateSavePublicDashboard: invalid export locale")
+	}
+
+	if err := ValidateGeoRestriction(dto.PublicDashboard.GeoRestriction); err != nil {
+		return err
+	}
+
+	if err := ValidateAnnotationSettings(dto.PublicDashboard.AnnotationSettings); err != nil {
+		return err
+	}
+
+	if err := ValidateAllowedDomains(dto.PublicDashboard.AllowedDomains); err != nil {
+		return err
+	}
+
+	if err := ValidateVariableConstraints(dto.PublicDashboard.VariableConstraints); err != nil {
+		return err
+	}
+
+	if err := ValidateVariableOverridesAllowed(dto.PublicDashboard.VariableOverridesAllowed); err != nil {
+		return err
+	}
+
+	if err := ValidatePinnedVariables(dto.PublicDashboard.PinnedVariables); err != nil {
+		return err
+	}
+
+	if err := ValidateVariableDefaults(dto.PublicDashboard.VariableDefaults); err != nil {
+		return err
+	}
+
+	if slug := dto.PublicDashboard.Slug; slug != nil && *slug != "" && !IsValidSlug(*slug) {
+		return ErrInvalidSlug.Errorf("ValidateSavePublicDashboard: invalid slug %s", *slug)
+	}
+
+	if expiresAt := dto.PublicDashboard.ExpiresAt; expiresAt != nil && !expiresAt.IsZero() && !expiresAt.After(time.Now()) {
+		return ErrInvalidExpiresAt.Errorf("ValidateSavePublicDashboard: expiration time %s is not in the future", expiresAt.UTC())
+	}
+
+	if panelId := dto.PublicDashboard.PanelId; panelId != nil && *panelId < 0 {
+		return ErrInvalidPanelId.Errorf("ValidateSavePublicDashboard: invalid panel id %d", *panelId)
+	}
+
+	for _, panelId := range dto.PublicDashboard.HiddenPanelIds {
+		if panelId <= 0 {
+			return ErrInvalidHiddenPanelIds.Errorf("ValidateSavePublicDashboard: invalid hidden panel id %d", panelId)
+		}
+	}
+
+	if limit := dto.PublicDashboard.MaxConcurrentViewers; limit != nil && (*limit < 0 || *limit > MaxConcurrentViewersLimit) {
+		return ErrInvalidMaxConcurrentViewers.Errorf("ValidateSavePublicDashboard: max concurrent viewers %d is not between 0 and %d", *limit, MaxConcurrentViewersLimit)
+	}
+
 	return nil
 }
 
@@ -41,9 +104,132 @@ func ValidateQueryPublicDashboardRequest(req PublicDashboardQueryDTO, pd *Public
 	return nil
 }
 
-// IsValidAccessToken asserts that an accessToken is a valid uuid
+// ValidateBatchQueryPanels asserts that a batch query selects every panel or a bounded list of panels
+func ValidateBatchQueryPanels(panels BatchQueryPanels) error {
+	if panels.All {
+		return nil
+	}
+
+	if len(panels.Ids) == 0 {
+		return ErrInvalidBatchQueryPanels.Errorf("ValidateBatchQueryPanels: no panel selected")
+	}
+
+	if len(panels.Ids) > MaxBatchQueryPanels {
+		return ErrInvalidBatchQueryPanels.Errorf("ValidateBatchQueryPanels: more than %d panels selected", MaxBatchQueryPanels)
+	}
+
+	return nil
+}
+
+// ValidateVariableOverridesAllowed asserts that the variables viewers can change are named
+func ValidateVariableOverridesAllowed(names []string) error {
+	for _, name := range names {
+		if name == "" {
+			return ErrInvalidVariableOverridesAllowed.Errorf("ValidateVariableOverridesAllowed: variable name is empty")
+		}
+	}
+
+	return nil
+}
+
+// ValidatePinnedVariables asserts that pinned variables are named and pinned to a string or a list of strings
+func ValidatePinnedVariables(pinned PinnedVariables) error {
+	for name, value := range pinned {
+		if name == "" {
+			return ErrInvalidPinnedVariables.Errorf("ValidatePinnedVariables: variable name is empty")
+		}
+
+		switch v := value.(type) {
+		case string:
+		case []interface{}:
+			for _, item := range v {
+				if _, ok := item.(string); !ok {
+					return ErrInvalidPinnedVariables.Errorf("ValidatePinnedVariables: values of variable %s should be strings", name)
+				}
+			}
+		default:
+			return ErrInvalidPinnedVariables.Errorf("ValidatePinnedVariables: value of variable %s should be a string or a list of strings", name)
+		}
+	}
+
+	return nil
+}
+
+// ValidateVariableDefaults asserts that default variable values are named and set to a string or a list of strings
+func ValidateVariableDefaults(defaults VariableDefaults) error {
+	for name, value := range defaults {
+		if name == "" {
+			return ErrInvalidVariableDefaults.Errorf("ValidateVariableDefaults: variable name is empty")
+		}
+
+		switch v := value.(type) {
+		case string:
+		case []interface{}:
+			for _, item := range v {
+				if _, ok := item.(string); !ok {
+					return ErrInvalidVariableDefaults.Errorf("ValidateVariableDefaults: default values of variable %s should be strings", name)
+				}
+			}
+		default:
+			return ErrInvalidVariableDefaults.Errorf("ValidateVariableDefaults: default value of variable %s should be a string or a list of strings", name)
+		}
+	}
+
+	return nil
+}
+
+// ValidateVariableQueryRequest asserts that the pagination of a variable query is valid
+func ValidateVariableQueryRequest(req PublicDashboardVariableQueryDTO) error {
+	if req.Limit < 0 || req.Limit > MaxVariableOptionsLimit {
+		return ErrInvalidPagination.Errorf("ValidateVariableQueryRequest: limit should be between 0 and %d", MaxVariableOptionsLimit)
+	}
+
+	if req.Page < 0 {
+		return ErrInvalidPagination.Errorf("ValidateVariableQueryRequest: page should be greater than 0")
+	}
+
+	return nil
+}
+
+var (
+	// hexAccessTokenPattern and base62AccessTokenPattern match the access tokens of every configured length. Base62
+	// access tokens always have an uppercase letter, so lowercase slugs of the same length aren't taken for access
+	// tokens
+	hexAccessTokenPattern    = regexp.MustCompile(`^[0-9a-f]{32,64}$`)
+	base62AccessTokenPattern = regexp.MustCompile(`^[0-9A-Za-z]{32,64}$`)
+	uppercasePattern         = regexp.MustCompile(`[A-Z]`)
+)
+
+// IsValidAccessToken asserts that an accessToken is a valid uuid, between 32 and 64 hex characters, or between 32 and
+// 64 alphanumeric characters with an uppercase letter
 func IsValidAccessToken(token string) bool {
-	_, err := uuid.Parse(token)
+	if _, err := uuid.Parse(token); err == nil {
+		return true
+	}
+	if hexAccessTokenPattern.MatchString(token) {
+		return true
+	}
+	return base62AccessTokenPattern.MatchString(token) && uppercasePattern.MatchString(token)
+}
+
+// slugPattern matches lowercase words separated by single hyphens, like status-page
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// IsValidSlug asserts that a slug is between 3 and 64 characters of lowercase words separated by hyphens. Slugs can't
+// look like access tokens of any alphabet, so both can be used in the same URLs
+func IsValidSlug(slug string) bool {
+	return len(slug) >= 3 && len(slug) <= 64 && slugPattern.MatchString(slug) && !IsValidAccessToken(slug) &&
+		!base62AccessTokenPattern.MatchString(slug)
+}
+
+// IsValidAccessTokenOrSlug asserts that a public dashboard URL identifies it by a valid access token or slug
+func IsValidAccessTokenOrSlug(token string) bool {
+	return IsValidAccessToken(token) || IsValidSlug(token)
+}
+
+// IsValidViewerSessionId asserts that the session id sent by a viewer heartbeat is a valid uuid
+func IsValidViewerSessionId(sessionId string) bool {
+	_, err := uuid.Parse(sessionId)
 	return err == nil
 }
 
@@ -61,3 +247,158 @@ func IsValidShareType(shareType ShareType) bool {
 	}
 	return false
 }
+
+// ValidatePublicDashboardListQuery asserts that the list of public dashboards is sorted by a known field and direction.
+// Empty values use the default sort
+func ValidatePublicDashboardListQuery(query *PublicDashboardListQuery) error {
+	if query.Sort != "" && !IsValidListSort(query.Sort) {
+		return ErrInvalidListSort.Errorf("ValidatePublicDashboardListQuery: invalid sort %s", query.Sort)
+	}
+
+	if query.Direction != "" && !IsValidSortDirection(query.Direction) {
+		return ErrInvalidListSort.Errorf("ValidatePublicDashboardListQuery: invalid direction %s", query.Direction)
+	}
+
+	if query.Share != "" && !IsValidShareType(query.Share) {
+		return ErrInvalidShareType.Errorf("ValidatePublicDashboardListQuery: invalid share type %s", query.Share)
+	}
+
+	return nil
+}
+
+func IsValidListSort(listSort PublicDashboardListSort) bool {
+	for _, s := range ValidListSorts {
+		if s == listSort {
+			return true
+		}
+	}
+	return false
+}
+
+func IsValidSortDirection(direction SortDirection) bool {
+	for _, d := range ValidSortDirections {
+		if d == direction {
+			return true
+		}
+	}
+	return false
+}
+
+func IsValidQueryCachingMode(mode QueryCachingMode) bool {
+	for _, m := range ValidQueryCachingModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidExportLocale asserts that the locale is a well-formed BCP 47 language tag
+func IsValidExportLocale(locale string) bool {
+	_, err := language.Parse(locale)
+	return err == nil
+}
+
+// ValidateGeoRestriction asserts that the mode is known and that countries are ISO 3166-1 alpha-2 codes. An empty mode
+// without countries removes the restriction
+func ValidateGeoRestriction(gr *GeoRestriction) error {
+	if gr == nil {
+		return nil
+	}
+
+	if gr.Mode == "" {
+		if len(gr.Countries) > 0 {
+			return ErrInvalidGeoRestriction.Errorf("ValidateGeoRestriction: countries require a mode")
+		}
+		return nil
+	}
+
+	if !IsValidGeoRestrictionMode(gr.Mode) {
+		return ErrInvalidGeoRestriction.Errorf("ValidateGeoRestriction: invalid mode %s", gr.Mode)
+	}
+
+	if gr.Mode == GeoRestrictionModeAllow && len(gr.Countries) == 0 {
+		return ErrInvalidGeoRestriction.Errorf("ValidateGeoRestriction: allow mode requires at least one country")
+	}
+
+	for _, country := range gr.Countries {
+		if !IsValidCountryCode(country) {
+			return ErrInvalidGeoRestriction.Errorf("ValidateGeoRestriction: invalid country code %s", country)
+		}
+	}
+
+	return nil
+}
+
+// ValidateAnnotationSettings asserts that hidden annotation queries are named and that hidden panel ids are positive
+func ValidateAnnotationSettings(as *AnnotationSettings) error {
+	if as == nil {
+		return nil
+	}
+
+	for _, name := range as.HiddenQueries {
+		if name == "" {
+			return ErrInvalidAnnotationSettings.Errorf("ValidateAnnotationSettings: annotation query name is empty")
+		}
+	}
+
+	for _, panelId := range as.HiddenPanelIds {
+		if panelId <= 0 {
+			return ErrInvalidAnnotationSettings.Errorf("ValidateAnnotationSettings: invalid panel id %d", panelId)
+		}
+	}
+
+	return nil
+}
+
+// domainPattern matches host names, optionally starting with "*." to match their subdomains
+var domainPattern = regexp.MustCompile(`^(\*\.)?([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?\.)*[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+
+// ValidateAllowedDomains asserts that the allowed domains are host names without scheme, port or path, like
+// portal.example.com or *.example.com
+func ValidateAllowedDomains(domains []string) error {
+	for _, domain := range domains {
+		if len(domain) > 253 || !domainPattern.MatchString(domain) {
+			return ErrInvalidAllowedDomains.Errorf("ValidateAllowedDomains: invalid domain %s", domain)
+		}
+	}
+	return nil
+}
+
+func IsValidGeoRestrictionMode(mode GeoRestrictionMode) bool {
+	for _, m := range ValidGeoRestrictionModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidCountryCode asserts that the code is an ISO 3166-1 alpha-2 country code
+func IsValidCountryCode(code string) bool {
+	if len(code) != 2 {
+		return false
+	}
+	region, err := language.ParseRegion(code)
+	return err == nil && region.IsCountry()
+}
+
+// ValidateVariableConstraints asserts that the patterns of the text box variables compile and that their max length
+// doesn't exceed the limit of public dashboards
+func ValidateVariableConstraints(constraints VariableConstraints) error {
+	for name, constraint := range constraints {
+		if name == "" {
+			return ErrInvalidVariableConstraint.Errorf("ValidateVariableConstraints: variable name is empty")
+		}
+
+		if constraint.MaxLength < 0 || constraint.MaxLength > MaxTextboxVariableLength {
+			return ErrInvalidVariableConstraint.Errorf("ValidateVariableConstraints: max length of variable %s should be between 0 and %d", name, MaxTextboxVariableLength)
+		}
+
+		if _, err := regexp.Compile(constraint.Pattern); err != nil {
+			return ErrInvalidVariableConstraint.Errorf("ValidateVariableConstraints: invalid pattern for variable %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
@@ -1,7 +1,9 @@
 package validation
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	. "github.com/grafana/grafana/pkg/services/publicdashboards/models"
 	"github.com/stretchr/testify/assert"
@@ -22,6 +24,228 @@ func TestValidatePublicDashboard(t *testing.T) {
 		err := ValidatePublicDashboard(dto)
 		require.Error(t, err)
 	})
+
+	t.Run("Returns no error when valid queryCachingMode value is received", func(t *testing.T) {
+		dto := &SavePublicDashboardDTO{DashboardUid: "abc123", UserId: 1, PublicDashboard: &PublicDashboardDTO{QueryCachingMode: QueryCachingModeForce}}
+
+		err := ValidatePublicDashboard(dto)
+		require.NoError(t, err)
+	})
+
+	t.Run("Returns error when invalid queryCachingMode value", func(t *testing.T) {
+		dto := &SavePublicDashboardDTO{DashboardUid: "abc123", UserId: 1, PublicDashboard: &PublicDashboardDTO{QueryCachingMode: "invalid"}}
+
+		err := ValidatePublicDashboard(dto)
+		require.ErrorIs(t, err, ErrInvalidQueryCachingMode)
+	})
+
+	t.Run("Returns no error when valid exportLocale value is received", func(t *testing.T) {
+		dto := &SavePublicDashboardDTO{DashboardUid: "abc123", UserId: 1, PublicDashboard: &PublicDashboardDTO{ExportLocale: "de-DE"}}
+
+		err := ValidatePublicDashboard(dto)
+		require.NoError(t, err)
+	})
+
+	t.Run("Returns error when invalid exportLocale value", func(t *testing.T) {
+		dto := &SavePublicDashboardDTO{DashboardUid: "abc123", UserId: 1, PublicDashboard: &PublicDashboardDTO{ExportLocale: "not a locale"}}
+
+		err := ValidatePublicDashboard(dto)
+		require.ErrorIs(t, err, ErrInvalidExportLocale)
+	})
+
+	t.Run("Returns no error when valid geoRestriction value is received", func(t *testing.T) {
+		dto := &SavePublicDashboardDTO{DashboardUid: "abc123", UserId: 1, PublicDashboard: &PublicDashboardDTO{
+			GeoRestriction: &GeoRestriction{Mode: GeoRestrictionModeAllow, Countries: []string{"DE", "fr"}},
+		}}
+
+		err := ValidatePublicDashboard(dto)
+		require.NoError(t, err)
+	})
+
+	t.Run("Returns no error when geoRestriction is removed", func(t *testing.T) {
+		dto := &SavePublicDashboardDTO{DashboardUid: "abc123", UserId: 1, PublicDashboard: &PublicDashboardDTO{GeoRestriction: &GeoRestriction{}}}
+
+		err := ValidatePublicDashboard(dto)
+		require.NoError(t, err)
+	})
+
+	t.Run("Returns error when invalid geoRestriction value", func(t *testing.T) {
+		invalid := []*GeoRestriction{
+			{Mode: "invalid", Countries: []string{"DE"}},
+			{Mode: GeoRestrictionModeAllow},
+			{Countries: []string{"DE"}},
+			{Mode: GeoRestrictionModeDeny, Countries: []string{"Germany"}},
+			{Mode: GeoRestrictionModeDeny, Countries: []string{"EU"}},
+		}
+
+		for _, gr := range invalid {
+			dto := &SavePublicDashboardDTO{DashboardUid: "abc123", UserId: 1, PublicDashboard: &PublicDashboardDTO{GeoRestriction: gr}}
+
+			err := ValidatePublicDashboard(dto)
+			require.ErrorIs(t, err, ErrInvalidGeoRestriction)
+		}
+	})
+
+	t.Run("Returns no error when valid variableConstraints value is received", func(t *testing.T) {
+		dto := &SavePublicDashboardDTO{DashboardUid: "abc123", UserId: 1, PublicDashboard: &PublicDashboardDTO{
+			VariableConstraints: VariableConstraints{"search": {Pattern: "[a-z]+", MaxLength: 32}, "filter": {}},
+		}}
+
+		err := ValidatePublicDashboard(dto)
+		require.NoError(t, err)
+	})
+
+	t.Run("Returns error when invalid variableConstraints value", func(t *testing.T) {
+		invalid := []VariableConstraints{
+			{"search": {Pattern: "[a-z"}},
+			{"search": {MaxLength: -1}},
+			{"search": {MaxLength: MaxTextboxVariableLength + 1}},
+			{"": {Pattern: "[a-z]+"}},
+		}
+
+		for _, vc := range invalid {
+			dto := &SavePublicDashboardDTO{DashboardUid: "abc123", UserId: 1, PublicDashboard: &PublicDashboardDTO{VariableConstraints: vc}}
+
+			err := ValidatePublicDashboard(dto)
+			require.ErrorIs(t, err, ErrInvalidVariableConstraint)
+		}
+	})
+
+	t.Run("Returns no error when valid variableOverridesAllowed value is received", func(t *testing.T) {
+		for _, names := range [][]string{{"env", "server"}, {}} {
+			dto := &SavePublicDashboardDTO{DashboardUid: "abc123", UserId: 1, PublicDashboard: &PublicDashboardDTO{VariableOverridesAllowed: names}}
+
+			err := ValidatePublicDashboard(dto)
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("Returns error when invalid variableOverridesAllowed value", func(t *testing.T) {
+		dto := &SavePublicDashboardDTO{DashboardUid: "abc123", UserId: 1, PublicDashboard: &PublicDashboardDTO{VariableOverridesAllowed: []string{"env", ""}}}
+
+		err := ValidatePublicDashboard(dto)
+		require.ErrorIs(t, err, ErrInvalidVariableOverridesAllowed)
+	})
+
+	t.Run("Returns no error when valid pinnedVariables value is received", func(t *testing.T) {
+		dto := &SavePublicDashboardDTO{DashboardUid: "abc123", UserId: 1, PublicDashboard: &PublicDashboardDTO{
+			PinnedVariables: PinnedVariables{"tenant": "acme", "regions": []interface{}{"eu", "us"}},
+		}}
+
+		err := ValidatePublicDashboard(dto)
+		require.NoError(t, err)
+	})
+
+	t.Run("Returns error when invalid pinnedVariables value", func(t *testing.T) {
+		invalid := []PinnedVariables{
+			{"": "acme"},
+			{"tenant": 1},
+			{"tenant": nil},
+			{"regions": []interface{}{"eu", 1}},
+		}
+
+		for _, pv := range invalid {
+			dto := &SavePublicDashboardDTO{DashboardUid: "abc123", UserId: 1, PublicDashboard: &PublicDashboardDTO{PinnedVariables: pv}}
+
+			err := ValidatePublicDashboard(dto)
+			require.ErrorIs(t, err, ErrInvalidPinnedVariables)
+		}
+	})
+
+	t.Run("Returns no error when valid variableDefaults value is received", func(t *testing.T) {
+		dto := &SavePublicDashboardDTO{DashboardUid: "abc123", UserId: 1, PublicDashboard: &PublicDashboardDTO{
+			VariableDefaults: VariableDefaults{"env": "stage", "regions": []interface{}{"eu"}},
+		}}
+
+		err := ValidatePublicDashboard(dto)
+		require.NoError(t, err)
+	})
+
+	t.Run("Returns error when invalid variableDefaults value", func(t *testing.T) {
+		invalid := []VariableDefaults{
+			{"": "stage"},
+			{"env": 1},
+			{"env": nil},
+			{"regions": []interface{}{"eu", true}},
+		}
+
+		for _, vd := range invalid {
+			dto := &SavePublicDashboardDTO{DashboardUid: "abc123", UserId: 1, PublicDashboard: &PublicDashboardDTO{VariableDefaults: vd}}
+
+			err := ValidatePublicDashboard(dto)
+			require.ErrorIs(t, err, ErrInvalidVariableDefaults)
+		}
+	})
+
+	t.Run("Returns no error when expiresAt is in the future or removed", func(t *testing.T) {
+		for _, expiresAt := range []time.Time{time.Now().Add(time.Hour), {}} {
+			dto := &SavePublicDashboardDTO{DashboardUid: "abc123", UserId: 1, PublicDashboard: &PublicDashboardDTO{ExpiresAt: &expiresAt}}
+
+			err := ValidatePublicDashboard(dto)
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("Returns error when expiresAt is in the past", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Minute)
+		dto := &SavePublicDashboardDTO{DashboardUid: "abc123", UserId: 1, PublicDashboard: &PublicDashboardDTO{ExpiresAt: &expiresAt}}
+
+		err := ValidatePublicDashboard(dto)
+		require.ErrorIs(t, err, ErrInvalidExpiresAt)
+	})
+
+	t.Run("Returns error when panelId is negative", func(t *testing.T) {
+		panelId := int64(-1)
+		dto := &SavePublicDashboardDTO{DashboardUid: "abc123", UserId: 1, PublicDashboard: &PublicDashboardDTO{PanelId: &panelId}}
+
+		err := ValidatePublicDashboard(dto)
+		require.ErrorIs(t, err, ErrInvalidPanelId)
+	})
+
+	t.Run("Returns error when maxConcurrentViewers is out of range", func(t *testing.T) {
+		for _, limit := range []int64{-1, MaxConcurrentViewersLimit + 1} {
+			dto := &SavePublicDashboardDTO{DashboardUid: "abc123", UserId: 1, PublicDashboard: &PublicDashboardDTO{MaxConcurrentViewers: &limit}}
+
+			err := ValidatePublicDashboard(dto)
+			require.ErrorIs(t, err, ErrInvalidMaxConcurrentViewers)
+		}
+	})
+
+	t.Run("Returns error when a hidden panel id isn't positive", func(t *testing.T) {
+		for _, panelId := range []int64{0, -1} {
+			dto := &SavePublicDashboardDTO{DashboardUid: "abc123", UserId: 1, PublicDashboard: &PublicDashboardDTO{HiddenPanelIds: []int64{1, panelId}}}
+
+			err := ValidatePublicDashboard(dto)
+			require.ErrorIs(t, err, ErrInvalidHiddenPanelIds)
+		}
+	})
+
+	t.Run("Returns error when annotationSettings hide an unnamed query or an invalid panel", func(t *testing.T) {
+		for _, settings := range []*AnnotationSettings{{HiddenQueries: []string{"Incidents", ""}}, {HiddenPanelIds: []int64{2, 0}}} {
+			dto := &SavePublicDashboardDTO{DashboardUid: "abc123", UserId: 1, PublicDashboard: &PublicDashboardDTO{AnnotationSettings: settings}}
+
+			err := ValidatePublicDashboard(dto)
+			require.ErrorIs(t, err, ErrInvalidAnnotationSettings)
+		}
+	})
+
+	t.Run("Returns no error when valid allowedDomains value is received", func(t *testing.T) {
+		dto := &SavePublicDashboardDTO{DashboardUid: "abc123", UserId: 1, PublicDashboard: &PublicDashboardDTO{
+			AllowedDomains: []string{"portal.example.com", "*.example.org", "localhost"},
+		}}
+
+		err := ValidatePublicDashboard(dto)
+		require.NoError(t, err)
+	})
+
+	t.Run("Returns error when invalid allowedDomains value", func(t *testing.T) {
+		for _, domain := range []string{"", "https://portal.example.com", "portal.example.com:443", "portal.example.com/path", "*", "a.*.example.com"} {
+			dto := &SavePublicDashboardDTO{DashboardUid: "abc123", UserId: 1, PublicDashboard: &PublicDashboardDTO{AllowedDomains: []string{domain}}}
+
+			err := ValidatePublicDashboard(dto)
+			require.ErrorIs(t, err, ErrInvalidAllowedDomains, domain)
+		}
+	})
 }
 
 func TestValidateQueryPublicDashboardRequest(t *testing.T) {
@@ -139,6 +363,34 @@ func TestValidateQueryPublicDashboardRequest(t *testing.T) {
 	}
 }
 
+func TestValidateVariableQueryRequest(t *testing.T) {
+	t.Run("Returns no error when pagination is valid", func(t *testing.T) {
+		for _, req := range []PublicDashboardVariableQueryDTO{{}, {Limit: 100, Page: 3}, {Limit: MaxVariableOptionsLimit}} {
+			require.NoError(t, ValidateVariableQueryRequest(req))
+		}
+	})
+
+	t.Run("Returns error when pagination is invalid", func(t *testing.T) {
+		for _, req := range []PublicDashboardVariableQueryDTO{{Limit: -1}, {Limit: MaxVariableOptionsLimit + 1}, {Limit: 10, Page: -1}} {
+			require.ErrorIs(t, ValidateVariableQueryRequest(req), ErrInvalidPagination)
+		}
+	})
+}
+
+func TestValidateBatchQueryPanels(t *testing.T) {
+	t.Run("Returns no error when every panel or a list of panels is selected", func(t *testing.T) {
+		for _, panels := range []BatchQueryPanels{{All: true}, {Ids: []int64{1, 2}}} {
+			require.NoError(t, ValidateBatchQueryPanels(panels))
+		}
+	})
+
+	t.Run("Returns error when no panel or too many panels are selected", func(t *testing.T) {
+		for _, panels := range []BatchQueryPanels{{}, {Ids: []int64{}}, {Ids: make([]int64, MaxBatchQueryPanels+1)}} {
+			require.ErrorIs(t, ValidateBatchQueryPanels(panels), ErrInvalidBatchQueryPanels)
+		}
+	})
+}
+
 func TestValidAccessToken(t *testing.T) {
 	t.Run("true", func(t *testing.T) {
 		uuid := "da82510c2aa64d78a2e87fef36c58e89"
@@ -149,9 +401,50 @@ func TestValidAccessToken(t *testing.T) {
 		assert.False(t, IsValidAccessToken(""))
 	})
 
-	t.Run("false when can't be parsed by uuid lib", func(t *testing.T) {
-		// too long
-		assert.False(t, IsValidAccessToken("0123456789012345678901234567890123456789"))
+	t.Run("true when alphanumeric with a configurable length", func(t *testing.T) {
+		assert.True(t, IsValidAccessToken("0123456789012345678901234567890123456789"))
+		assert.True(t, IsValidAccessToken("Zx81kQ0pLm3vB7nR2tY6wE9uI4oA5sD1fG8hJ0kL"))
+	})
+
+	t.Run("false when lowercase without being hex, like slugs", func(t *testing.T) {
+		assert.False(t, IsValidAccessToken("statuspageofthecustomersupportteam"))
+	})
+
+	t.Run("false when too short, too long or not alphanumeric", func(t *testing.T) {
+		assert.False(t, IsValidAccessToken("da82510c2aa64d78a2e87fef36c58e8"))
+		assert.False(t, IsValidAccessToken(strings.Repeat("a", 65)))
+		assert.False(t, IsValidAccessToken("da82510c2aa64d78a2e87fef36c58e89_"))
+	})
+}
+
+func TestValidSlug(t *testing.T) {
+	t.Run("true", func(t *testing.T) {
+		assert.True(t, IsValidSlug("status-page"))
+		assert.True(t, IsValidSlug("team42"))
+		assert.True(t, IsValidSlug("status-page-of-the-customer-support-team"))
+	})
+
+	t.Run("false when too short or too long", func(t *testing.T) {
+		assert.False(t, IsValidSlug("ab"))
+		assert.False(t, IsValidSlug(strings.Repeat("a", 65)))
+	})
+
+	t.Run("false when not lowercase words separated by hyphens", func(t *testing.T) {
+		assert.False(t, IsValidSlug("Status-Page"))
+		assert.False(t, IsValidSlug("status--page"))
+		assert.False(t, IsValidSlug("-status-page"))
+		assert.False(t, IsValidSlug("status_page"))
+	})
+
+	t.Run("false when it is a valid access token", func(t *testing.T) {
+		assert.False(t, IsValidSlug("da82510c2aa64d78a2e87fef36c58e89"))
+		assert.False(t, IsValidSlug(strings.Repeat("a", 64)))
+		assert.False(t, IsValidSlug("da82510c-2aa6-4d78-a2e8-7fef36c58e89"))
+	})
+
+	t.Run("false when it has the shape of an access token", func(t *testing.T) {
+		assert.False(t, IsValidSlug("statuspageofthecustomersupportteam"))
+		assert.False(t, IsValidSlug("zx81kq0plm3vb7nr2ty6we9ui4oa5sd1"))
 	})
 }
 
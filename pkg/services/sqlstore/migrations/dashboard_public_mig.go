@@ -96,4 +96,198 @@ func addPublicDashboardMigration(mg *Migrator) {
 	mg.AddMigration("backfill empty share column fields with default of public", NewRawSQLMigration(
 		"UPDATE dashboard_public SET share='public' WHERE share=''",
 	))
+
+	mg.AddMigration("add query_caching_mode column", NewAddColumnMigration(dashboardPublicCfgV2, &Column{
+		Name:     "query_caching_mode",
+		Type:     DB_NVarchar,
+		Length:   16,
+		Nullable: false,
+		Default:  "'normal'",
+	}))
+
+	mg.AddMigration("add export_locale column", NewAddColumnMigration(dashboardPublicCfgV2, &Column{
+		Name:     "export_locale",
+		Type:     DB_NVarchar,
+		Length:   35,
+		Nullable: false,
+		Default:  "''",
+	}))
+
+	mg.AddMigration("add geo_restriction column", NewAddColumnMigration(dashboardPublicCfgV2, &Column{
+		Name:     "geo_restriction",
+		Type:     DB_Text,
+		Nullable: true,
+	}))
+
+	mg.AddMigration("add last_accessed_at column", NewAddColumnMigration(dashboardPublicCfgV2, &Column{
+		Name:     "last_accessed_at",
+		Type:     DB_DateTime,
+		Nullable: true,
+	}))
+
+	mg.AddMigration("add variable_constraints column", NewAddColumnMigration(dashboardPublicCfgV2, &Column{
+		Name:     "variable_constraints",
+		Type:     DB_Text,
+		Nullable: true,
+	}))
+
+	mg.AddMigration("add variable_overrides_allowed column", NewAddColumnMigration(dashboardPublicCfgV2, &Column{
+		Name:     "variable_overrides_allowed",
+		Type:     DB_Text,
+		Nullable: true,
+	}))
+
+	mg.AddMigration("add pinned_variables column", NewAddColumnMigration(dashboardPublicCfgV2, &Column{
+		Name:     "pinned_variables",
+		Type:     DB_Text,
+		Nullable: true,
+	}))
+
+	mg.AddMigration("add variable_snapshot column", NewAddColumnMigration(dashboardPublicCfgV2, &Column{
+		Name:     "variable_snapshot",
+		Type:     DB_MediumText,
+		Nullable: true,
+	}))
+
+	mg.AddMigration("add variable_defaults column", NewAddColumnMigration(dashboardPublicCfgV2, &Column{
+		Name:     "variable_defaults",
+		Type:     DB_Text,
+		Nullable: true,
+	}))
+
+	mg.AddMigration("add previous_access_token column", NewAddColumnMigration(dashboardPublicCfgV2, &Column{
+		Name:     "previous_access_token",
+		Type:     DB_NVarchar,
+		Length:   64,
+		Nullable: true,
+	}))
+
+	mg.AddMigration("add previous_access_token_expires_at column", NewAddColumnMigration(dashboardPublicCfgV2, &Column{
+		Name:     "previous_access_token_expires_at",
+		Type:     DB_DateTime,
+		Nullable: true,
+	}))
+
+	mg.AddMigration("add index on previous_access_token", NewAddIndexMigration(dashboardPublicCfgV2, &Index{
+		Cols: []string{"previous_access_token"},
+	}))
+
+	mg.AddMigration("add slug column", NewAddColumnMigration(dashboardPublicCfgV2, &Column{
+		Name:     "slug",
+		Type:     DB_NVarchar,
+		Length:   64,
+		Nullable: true,
+	}))
+
+	// slug URLs don't carry the org, so slugs are unique across orgs. Public dashboards without a slug leave it NULL,
+	// which the unique index allows several times
+	mg.AddMigration("add unique index on slug", NewAddIndexMigration(dashboardPublicCfgV2, &Index{
+		Cols: []string{"slug"},
+		Type: UniqueIndex,
+	}))
+
+	mg.AddMigration("add expires_at column", NewAddColumnMigration(dashboardPublicCfgV2, &Column{
+		Name:     "expires_at",
+		Type:     DB_DateTime,
+		Nullable: true,
+	}))
+
+	mg.AddMigration("add panel_id column", NewAddColumnMigration(dashboardPublicCfgV2, &Column{
+		Name:     "panel_id",
+		Type:     DB_BigInt,
+		Nullable: false,
+		Default:  "0",
+	}))
+
+	mg.AddMigration("add allowed_domains column", NewAddColumnMigration(dashboardPublicCfgV2, &Column{
+		Name:     "allowed_domains",
+		Type:     DB_Text,
+		Nullable: true,
+	}))
+
+	mg.AddMigration("add access_token_created_at column", NewAddColumnMigration(dashboardPublicCfgV2, &Column{
+		Name:     "access_token_created_at",
+		Type:     DB_DateTime,
+		Nullable: true,
+	}))
+
+	mg.AddMigration("add access_token_rotation_due column", NewAddColumnMigration(dashboardPublicCfgV2, &Column{
+		Name:     "access_token_rotation_due",
+		Type:     DB_Bool,
+		Nullable: false,
+		Default:  "0",
+	}))
+
+	// access tokens can be up to 64 characters long, SQLite doesn't enforce the length
+	mg.AddMigration("increase access_token length to 64", NewRawSQLMigration("").
+		Postgres("ALTER TABLE dashboard_public ALTER COLUMN access_token TYPE VARCHAR(64);").
+		Mysql("ALTER TABLE dashboard_public MODIFY access_token VARCHAR(64) NOT NULL;"))
+
+	dashboardPublicAuditLogV1 := Table{
+		Name: "dashboard_public_audit_log",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "public_dashboard_uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "access_token", Type: DB_NVarchar, Length: 64, Nullable: false},
+			{Name: "ip_hash", Type: DB_NVarchar, Length: 64, Nullable: false},
+			{Name: "user_agent", Type: DB_NVarchar, Length: 255, Nullable: false},
+			{Name: "action", Type: DB_NVarchar, Length: 16, Nullable: false},
+			{Name: "panel_ids", Type: DB_Text, Nullable: true},
+			{Name: "time_from", Type: DB_NVarchar, Length: 64, Nullable: true},
+			{Name: "time_to", Type: DB_NVarchar, Length: 64, Nullable: true},
+			{Name: "created_at", Type: DB_DateTime, Nullable: false},
+		},
+		Indices: []*Index{
+			{Cols: []string{"org_id", "public_dashboard_uid", "created_at"}},
+			{Cols: []string{"created_at"}},
+		},
+	}
+
+	mg.AddMigration("create dashboard public audit log table v1", NewAddTableMigration(dashboardPublicAuditLogV1))
+	addTableIndicesMigrations(mg, "v1", dashboardPublicAuditLogV1)
+
+	mg.AddMigration("add max_concurrent_viewers column", NewAddColumnMigration(dashboardPublicCfgV2, &Column{
+		Name:     "max_concurrent_viewers",
+		Type:     DB_BigInt,
+		Nullable: false,
+		Default:  "0",
+	}))
+
+	mg.AddMigration("add hidden_panel_ids column", NewAddColumnMigration(dashboardPublicCfgV2, &Column{
+		Name:     "hidden_panel_ids",
+		Type:     DB_Text,
+		Nullable: true,
+	}))
+
+	dashboardPublicUsageV1 := Table{
+		Name: "dashboard_public_usage",
+		Columns: []*Column{
+			{Name: "id", Type: DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: DB_BigInt, Nullable: false},
+			{Name: "public_dashboard_uid", Type: DB_NVarchar, Length: 40, Nullable: false},
+			{Name: "views", Type: DB_BigInt, Nullable: false, Default: "0"},
+			{Name: "queries", Type: DB_BigInt, Nullable: false, Default: "0"},
+			{Name: "last_queried_at", Type: DB_DateTime, Nullable: true},
+		},
+		Indices: []*Index{
+			{Cols: []string{"public_dashboard_uid"}, Type: UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create dashboard public usage table v1", NewAddTableMigration(dashboardPublicUsageV1))
+	addTableIndicesMigrations(mg, "v1", dashboardPublicUsageV1)
+
+	mg.AddMigration("add annotation_settings column", NewAddColumnMigration(dashboardPublicCfgV2, &Column{
+		Name:     "annotation_settings",
+		Type:     DB_Text,
+		Nullable: true,
+	}))
+
+	// null for existing public dashboards, which keep passing the variables of their viewers to queries
+	mg.AddMigration("add variables_enabled column", NewAddColumnMigration(dashboardPublicCfgV2, &Column{
+		Name:     "variables_enabled",
+		Type:     DB_Bool,
+		Nullable: true,
+	}))
 }
@@ -52,6 +52,8 @@ type SQLStore struct {
 	tracer                       tracing.Tracer
 	recursiveQueriesAreSupported *bool
 	recursiveQueriesMu           sync.Mutex
+	// readReplica is set for read replicas, which share the connection metrics of the primary database
+	readReplica bool
 }
 
 func ProvideService(cfg *setting.Cfg,
@@ -237,12 +239,15 @@ func (ss *SQLStore) initEngine(engine *xorm.Engine) error {
 		return nil
 	}
 
-	dbCfg, err := NewDatabaseConfig(ss.cfg, ss.features)
-	if err != nil {
-		return err
-	}
+	// read replicas are given their database config up front
+	if ss.dbCfg == nil {
+		dbCfg, err := NewDatabaseConfig(ss.cfg, ss.features)
+		if err != nil {
+			return err
+		}
 
-	ss.dbCfg = dbCfg
+		ss.dbCfg = dbCfg
+	}
 
 	if ss.cfg.DatabaseInstrumentQueries {
 		ss.dbCfg.Type = WrapDatabaseDriverWithHooks(ss.dbCfg.Type, ss.tracer)
@@ -321,6 +326,11 @@ func (ss *SQLStore) initEngine(engine *xorm.Engine) error {
 		engine.ShowExecTime(true)
 	}
 
+	ss.engine = engine
+	if ss.readReplica {
+		return nil
+	}
+
 	// initialize and register metrics wrapper around the *sql.DB
 	db := engine.DB().DB
 
@@ -334,7 +344,6 @@ func (ss *SQLStore) initEngine(engine *xorm.Engine) error {
 		ss.log.Warn("Failed to register sqlstore metrics", "error", err)
 	}
 
-	ss.engine = engine
 	return nil
 }
 
@@ -47,6 +47,8 @@ type SystemStats struct {
 	DataKeys                  int64
 	ActiveDataKeys            int64
 	PublicDashboards          int64
+	PublicDashboardViews      int64
+	PublicDashboardQueries    int64
 	Correlations              int64
 	DatabaseCreatedTime       *time.Time
 	Repositories              int64
@@ -557,7 +557,112 @@ type Cfg struct {
 	DatabaseInstrumentQueries bool
 
 	// Public dashboards
-	PublicDashboardsEnabled bool
+	PublicDashboardsEnabled             bool
+	PublicDashboardsQueryMaxConcurrency int
+	PublicDashboardsQueryMaxQueueSize   int
+	PublicDashboardsQueryQueueTimeout   time.Duration
+	// Number of panels of a batch query queried at the same time, for each request and across requests. The limit across
+	// requests is disabled with 0
+	PublicDashboardsBatchQueryConcurrency    int
+	PublicDashboardsBatchQueryMaxConcurrency int
+	// Maximum time a panel of a batch query waits for a slot and is queried before it fails, 0 disables the timeout
+	PublicDashboardsBatchQueryPanelTimeout time.Duration
+	// Maximum number of query, annotation and variable requests per minute for each access token and each client IP,
+	// 0 disables the limit
+	PublicDashboardsRateLimitPerAccessToken int
+	PublicDashboardsRateLimitPerIP          int
+	// Where the rate limiter keeps its buckets, memory or remote_cache
+	PublicDashboardsRateLimitBackend string
+	// Addresses and CIDR ranges of the proxies trusted to set the X-Real-IP and X-Forwarded-For headers of clients
+	PublicDashboardsTrustedProxies     []string
+	PublicDashboardsGeoIPCountryHeader string
+	// Public dashboards not accessed for this many days are disabled, 0 disables the policy
+	PublicDashboardsDisableInactiveAfterDays int
+	// Per org overrides of PublicDashboardsDisableInactiveAfterDays
+	PublicDashboardsDisableInactiveAfterDaysByOrg map[int64]int
+	// Options of query variables are reused for this long, 0 disables the cache
+	PublicDashboardsVariableOptionsCacheTTL time.Duration
+	// Per org overrides of PublicDashboardsVariableOptionsCacheTTL
+	PublicDashboardsVariableOptionsCacheTTLByOrg map[int64]time.Duration
+	// Reject variable values with quotes, backslashes, semicolons or control characters
+	PublicDashboardsRejectUnsafeVariableValues bool
+	// Options of the variables of public dashboards watched over Live are refreshed at this interval, 0 disables it
+	PublicDashboardsLiveVariablesRefreshInterval time.Duration
+	// Viewers of a public dashboard can have at most this many subscriptions to its bridged streams, 0 disables the limit
+	PublicDashboardsLiveMaxConnections int
+	// Maximum number of PDF exports of a public dashboard per minute, 0 disables the limit
+	PublicDashboardsPDFExportRateLimit int
+	// Maximum page size of PDF exports, in pixels
+	PublicDashboardsPDFExportMaxWidth  int
+	PublicDashboardsPDFExportMaxHeight int
+	// PDF exports larger than this are rejected, 0 disables the limit
+	PublicDashboardsPDFExportMaxSizeBytes int64
+	PublicDashboardsPDFExportTimeout      time.Duration
+	// Maximum number of panel images rendered for a public dashboard per minute, 0 disables the limit
+	PublicDashboardsRenderRateLimit int
+	// Maximum size of rendered panel images, in pixels
+	PublicDashboardsRenderMaxWidth  int
+	PublicDashboardsRenderMaxHeight int
+	PublicDashboardsRenderTimeout   time.Duration
+	// Frame-ancestors sources allowed to embed public dashboards, only Grafana itself when empty
+	PublicDashboardsEmbedFrameAncestors []string
+	// How long the viewer tokens exchanged for the access token of a public dashboard are valid
+	PublicDashboardsViewerTokenTTL time.Duration
+	// Challenge viewers solve before they get viewer tokens, proof_of_work or captcha. Empty disables it
+	PublicDashboardsViewerChallenge string
+	// Orgs whose viewers are challenged, all orgs when empty
+	PublicDashboardsViewerChallengeOrgs []int64
+	// Number of leading zero bits of the proof-of-work hash
+	PublicDashboardsViewerChallengeDifficulty int
+	// Captcha service verifying the responses of viewers, turnstile, hcaptcha or recaptcha
+	PublicDashboardsViewerChallengeCaptchaProvider  string
+	PublicDashboardsViewerChallengeCaptchaSiteKey   string
+	PublicDashboardsViewerChallengeCaptchaSecretKey string
+	// How queries and responses of public dashboards are sanitized, the zero value is the default secure policy
+	PublicDashboardsSanitizationPolicy PublicDashboardsSanitizationPolicy
+	// Per org overrides of PublicDashboardsSanitizationPolicy
+	PublicDashboardsSanitizationPolicyByOrg map[int64]PublicDashboardsSanitizationPolicy
+	// Record the views and queries of public dashboards in the audit log
+	PublicDashboardsAuditLogEnabled bool
+	// How long the entries of the audit log are kept
+	PublicDashboardsAuditLogRetention time.Duration
+	// Webhook the surrogate keys of revoked access tokens are posted to, so CDNs purge their cached responses
+	PublicDashboardsCDNPurgeURL string
+	// Number of characters of new access tokens, between 32 and 64
+	PublicDashboardsAccessTokenLength int
+	// Characters of new access tokens, hex or base62
+	PublicDashboardsAccessTokenAlphabet string
+	// Access tokens older than this are flagged or rotated, 0 disables the policy
+	PublicDashboardsAccessTokenMaxAge time.Duration
+	// Per org overrides of PublicDashboardsAccessTokenMaxAge
+	PublicDashboardsAccessTokenMaxAgeByOrg map[int64]time.Duration
+	// What happens to access tokens older than the max age, notify or rotate
+	PublicDashboardsAccessTokenMaxAgeAction string
+	// Only dashboards in these folders or their subfolders can be shared publicly, empty allows all folders
+	PublicDashboardsAllowedFolderUIDs []string
+	// Dashboards in these folders or their subfolders can't be shared publicly
+	PublicDashboardsDeniedFolderUIDs []string
+	// Scores public dashboard requests by bot heuristics and throttles or blocks the client IPs with high scores
+	PublicDashboardsBotDetectionEnabled bool
+	// Score from which client IPs are throttled to PublicDashboardsBotDetectionThrottleRate requests per minute
+	PublicDashboardsBotDetectionThrottleScore int
+	PublicDashboardsBotDetectionThrottleRate  int
+	// Score from which client IPs are blocked for PublicDashboardsBotDetectionBlockDuration
+	PublicDashboardsBotDetectionBlockScore    int
+	PublicDashboardsBotDetectionBlockDuration time.Duration
+	// Footnote added to the exports and renders of public dashboards, with {org}, {access_token_hint} and {timestamp}
+	// placeholders. Empty disables it
+	PublicDashboardsExportWatermark string
+	// Orgs whose exports are watermarked, all orgs when empty
+	PublicDashboardsExportWatermarkOrgs []int64
+	// Public dashboards and their dashboards found by access token are reused for this long, 0 disables the cache
+	PublicDashboardsAccessTokenCacheTTL time.Duration
+	// How often public dashboards whose dashboards were deleted are deleted, 0 disables the cleanup
+	PublicDashboardsOrphanedCleanupInterval time.Duration
+	// Only log and count the public dashboards the orphaned cleanup would delete
+	PublicDashboardsOrphanedCleanupDryRun bool
+	// Url of the database read replica that public dashboards, their dashboards and annotations are read from by viewers
+	PublicDashboardsReadReplicaURL string
 
 	// Cloud Migration
 	CloudMigration CloudMigrationSettings
@@ -2194,9 +2299,229 @@ func (cfg *Cfg) readProvisioningSettings(iniFile *ini.File) error {
 	return nil
 }
 
+// PublicDashboardsSanitizationPolicy tunes the rules sanitizing what viewers of public dashboards get and send. The
+// zero value is the default secure policy
+type PublicDashboardsSanitizationPolicy struct {
+	// Fields removed from the targets of the panels, nil removes the default query expression fields
+	StripTargetFields []string
+	// Keep the executed query strings in the metadata of the frames
+	KeepExecutedQueryString bool
+	// Let viewers run the queries with another query caching TTL than the one of the panel
+	AllowQueryOverrides bool
+	// Labels whose values are redacted from the frames
+	RedactLabels []string
+}
+
 func (cfg *Cfg) readPublicDashboardsSettings() {
 	publicDashboards := cfg.Raw.Section("public_dashboards")
 	cfg.PublicDashboardsEnabled = publicDashboards.Key("enabled").MustBool(true)
+	cfg.PublicDashboardsQueryMaxConcurrency = publicDashboards.Key("query_max_concurrency").MustInt(0)
+	cfg.PublicDashboardsQueryMaxQueueSize = publicDashboards.Key("query_max_queue_size").MustInt(100)
+	cfg.PublicDashboardsQueryQueueTimeout = publicDashboards.Key("query_queue_timeout").MustDuration(10 * time.Second)
+	cfg.PublicDashboardsBatchQueryConcurrency = publicDashboards.Key("batch_query_concurrency").MustInt(10)
+	cfg.PublicDashboardsBatchQueryMaxConcurrency = publicDashboards.Key("batch_query_max_concurrency").MustInt(0)
+	cfg.PublicDashboardsBatchQueryPanelTimeout = publicDashboards.Key("batch_query_panel_timeout").MustDuration(30 * time.Second)
+	cfg.PublicDashboardsRateLimitPerAccessToken = publicDashboards.Key("rate_limit_per_access_token").MustInt(0)
+	cfg.PublicDashboardsRateLimitPerIP = publicDashboards.Key("rate_limit_per_ip").MustInt(0)
+	cfg.PublicDashboardsRateLimitBackend = publicDashboards.Key("rate_limit_backend").In("memory", []string{"memory", "remote_cache"})
+	cfg.PublicDashboardsTrustedProxies = util.SplitString(publicDashboards.Key("trusted_proxies").MustString(""))
+	cfg.PublicDashboardsGeoIPCountryHeader = publicDashboards.Key("geoip_country_header").MustString("")
+	cfg.PublicDashboardsDisableInactiveAfterDays = publicDashboards.Key("disable_inactive_after_days").MustInt(0)
+	cfg.PublicDashboardsDisableInactiveAfterDaysByOrg = make(map[int64]int)
+	for _, override := range util.SplitString(publicDashboards.Key("disable_inactive_after_days_org_overrides").MustString("")) {
+		orgID, days, found := strings.Cut(override, ":")
+		id, idErr := strconv.ParseInt(orgID, 10, 64)
+		n, daysErr := strconv.Atoi(days)
+		if !found || idErr != nil || daysErr != nil {
+			cfg.Logger.Warn("Ignoring invalid [public_dashboards] disable_inactive_after_days_org_overrides entry, expected <orgId>:<days>", "entry", override)
+			continue
+		}
+		cfg.PublicDashboardsDisableInactiveAfterDaysByOrg[id] = n
+	}
+	cfg.PublicDashboardsVariableOptionsCacheTTL = publicDashboards.Key("variable_options_cache_ttl").MustDuration(time.Minute)
+	cfg.PublicDashboardsVariableOptionsCacheTTLByOrg = make(map[int64]time.Duration)
+	for _, override := range util.SplitString(publicDashboards.Key("variable_options_cache_ttl_org_overrides").MustString("")) {
+		orgID, ttl, found := strings.Cut(override, ":")
+		id, idErr := strconv.ParseInt(orgID, 10, 64)
+		d, ttlErr := time.ParseDuration(ttl)
+		if !found || idErr != nil || ttlErr != nil {
+			cfg.Logger.Warn("Ignoring invalid [public_dashboards] variable_options_cache_ttl_org_overrides entry, expected <orgId>:<duration>", "entry", override)
+			continue
+		}
+		cfg.PublicDashboardsVariableOptionsCacheTTLByOrg[id] = d
+	}
+	cfg.PublicDashboardsRejectUnsafeVariableValues = publicDashboards.Key("reject_unsafe_variable_values").MustBool(false)
+	cfg.PublicDashboardsLiveVariablesRefreshInterval = publicDashboards.Key("live_variables_refresh_interval").MustDuration(time.Minute)
+	cfg.PublicDashboardsLiveMaxConnections = publicDashboards.Key("live_max_connections").MustInt(100)
+	cfg.PublicDashboardsPDFExportRateLimit = publicDashboards.Key("pdf_export_rate_limit").MustInt(10)
+	cfg.PublicDashboardsPDFExportMaxWidth = publicDashboards.Key("pdf_export_max_width").MustInt(3000)
+	cfg.PublicDashboardsPDFExportMaxHeight = publicDashboards.Key("pdf_export_max_height").MustInt(3000)
+	cfg.PublicDashboardsPDFExportMaxSizeBytes = publicDashboards.Key("pdf_export_max_size_bytes").MustInt64(20971520)
+	cfg.PublicDashboardsPDFExportTimeout = publicDashboards.Key("pdf_export_timeout").MustDuration(time.Minute)
+	cfg.PublicDashboardsRenderRateLimit = publicDashboards.Key("render_rate_limit").MustInt(60)
+	cfg.PublicDashboardsRenderMaxWidth = publicDashboards.Key("render_max_width").MustInt(3000)
+	cfg.PublicDashboardsRenderMaxHeight = publicDashboards.Key("render_max_height").MustInt(3000)
+	cfg.PublicDashboardsRenderTimeout = publicDashboards.Key("render_timeout").MustDuration(30 * time.Second)
+	cfg.PublicDashboardsEmbedFrameAncestors = []string{}
+	for _, source := range util.SplitString(publicDashboards.Key("embed_frame_ancestors").MustString("")) {
+		if strings.ContainsAny(source, ";,'\"") {
+			cfg.Logger.Warn("Ignoring invalid [public_dashboards] embed_frame_ancestors entry, expected a frame-ancestors source", "entry", source)
+			continue
+		}
+		cfg.PublicDashboardsEmbedFrameAncestors = append(cfg.PublicDashboardsEmbedFrameAncestors, source)
+	}
+	cfg.PublicDashboardsViewerTokenTTL = publicDashboards.Key("viewer_token_ttl").MustDuration(10 * time.Minute)
+	if cfg.PublicDashboardsViewerTokenTTL <= 0 {
+		cfg.Logger.Warn("Ignoring invalid [public_dashboards] viewer_token_ttl, expected a positive duration", "value", cfg.PublicDashboardsViewerTokenTTL)
+		cfg.PublicDashboardsViewerTokenTTL = 10 * time.Minute
+	}
+	cfg.PublicDashboardsViewerChallenge = publicDashboards.Key("viewer_challenge").In("", []string{"", "proof_of_work", "captcha"})
+	cfg.PublicDashboardsViewerChallengeOrgs = []int64{}
+	for _, org := range util.SplitString(publicDashboards.Key("viewer_challenge_orgs").MustString("")) {
+		id, err := strconv.ParseInt(org, 10, 64)
+		if err != nil {
+			cfg.Logger.Warn("Ignoring invalid [public_dashboards] viewer_challenge_orgs entry, expected an org id", "entry", org)
+			continue
+		}
+		cfg.PublicDashboardsViewerChallengeOrgs = append(cfg.PublicDashboardsViewerChallengeOrgs, id)
+	}
+	cfg.PublicDashboardsViewerChallengeDifficulty = publicDashboards.Key("viewer_challenge_difficulty").MustInt(16)
+	if cfg.PublicDashboardsViewerChallengeDifficulty < 1 || cfg.PublicDashboardsViewerChallengeDifficulty > 32 {
+		cfg.Logger.Warn("Ignoring invalid [public_dashboards] viewer_challenge_difficulty, expected between 1 and 32", "value", cfg.PublicDashboardsViewerChallengeDifficulty)
+		cfg.PublicDashboardsViewerChallengeDifficulty = 16
+	}
+	cfg.PublicDashboardsViewerChallengeCaptchaProvider = publicDashboards.Key("viewer_challenge_captcha_provider").In("turnstile", []string{"turnstile", "hcaptcha", "recaptcha"})
+	cfg.PublicDashboardsViewerChallengeCaptchaSiteKey = publicDashboards.Key("viewer_challenge_captcha_site_key").MustString("")
+	cfg.PublicDashboardsViewerChallengeCaptchaSecretKey = publicDashboards.Key("viewer_challenge_captcha_secret_key").MustString("")
+	if cfg.PublicDashboardsViewerChallenge == "captcha" && (cfg.PublicDashboardsViewerChallengeCaptchaSiteKey == "" || cfg.PublicDashboardsViewerChallengeCaptchaSecretKey == "") {
+		cfg.Logger.Warn("Disabling the [public_dashboards] viewer_challenge, the captcha site key and secret key are required")
+		cfg.PublicDashboardsViewerChallenge = ""
+	}
+	cfg.readPublicDashboardsSanitizationPolicy(publicDashboards)
+	cfg.PublicDashboardsAuditLogEnabled = publicDashboards.Key("audit_log_enabled").MustBool(false)
+	cfg.PublicDashboardsAuditLogRetention = publicDashboards.Key("audit_log_retention").MustDuration(30 * 24 * time.Hour)
+	if cfg.PublicDashboardsAuditLogRetention <= 0 {
+		cfg.Logger.Warn("Ignoring invalid [public_dashboards] audit_log_retention, expected a positive duration", "value", cfg.PublicDashboardsAuditLogRetention)
+		cfg.PublicDashboardsAuditLogRetention = 30 * 24 * time.Hour
+	}
+	cfg.PublicDashboardsCDNPurgeURL = publicDashboards.Key("cdn_purge_url").MustString("")
+	cfg.PublicDashboardsAccessTokenLength = publicDashboards.Key("access_token_length").MustInt(32)
+	if cfg.PublicDashboardsAccessTokenLength < 32 || cfg.PublicDashboardsAccessTokenLength > 64 {
+		cfg.Logger.Warn("Ignoring invalid [public_dashboards] access_token_length, expected between 32 and 64", "value", cfg.PublicDashboardsAccessTokenLength)
+		cfg.PublicDashboardsAccessTokenLength = 32
+	}
+	cfg.PublicDashboardsAccessTokenAlphabet = publicDashboards.Key("access_token_alphabet").In("hex", []string{"hex", "base62"})
+	cfg.PublicDashboardsAccessTokenMaxAge = publicDashboards.Key("access_token_max_age").MustDuration(0)
+	cfg.PublicDashboardsAccessTokenMaxAgeByOrg = make(map[int64]time.Duration)
+	for _, override := range util.SplitString(publicDashboards.Key("access_token_max_age_org_overrides").MustString("")) {
+		orgID, maxAge, found := strings.Cut(override, ":")
+		id, idErr := strconv.ParseInt(orgID, 10, 64)
+		d, maxAgeErr := time.ParseDuration(maxAge)
+		if !found || idErr != nil || maxAgeErr != nil {
+			cfg.Logger.Warn("Ignoring invalid [public_dashboards] access_token_max_age_org_overrides entry, expected <orgId>:<duration>", "entry", override)
+			continue
+		}
+		cfg.PublicDashboardsAccessTokenMaxAgeByOrg[id] = d
+	}
+	cfg.PublicDashboardsAccessTokenMaxAgeAction = publicDashboards.Key("access_token_max_age_action").In("notify", []string{"notify", "rotate"})
+	cfg.PublicDashboardsAllowedFolderUIDs = util.SplitString(publicDashboards.Key("allowed_folder_uids").MustString(""))
+	cfg.PublicDashboardsDeniedFolderUIDs = util.SplitString(publicDashboards.Key("denied_folder_uids").MustString(""))
+	cfg.PublicDashboardsBotDetectionEnabled = publicDashboards.Key("bot_detection_enabled").MustBool(false)
+	cfg.PublicDashboardsBotDetectionThrottleScore = publicDashboards.Key("bot_detection_throttle_score").MustInt(50)
+	if cfg.PublicDashboardsBotDetectionThrottleScore <= 0 {
+		cfg.Logger.Warn("Ignoring invalid [public_dashboards] bot_detection_throttle_score, expected a positive score", "value", cfg.PublicDashboardsBotDetectionThrottleScore)
+		cfg.PublicDashboardsBotDetectionThrottleScore = 50
+	}
+	cfg.PublicDashboardsBotDetectionThrottleRate = publicDashboards.Key("bot_detection_throttle_rate").MustInt(10)
+	if cfg.PublicDashboardsBotDetectionThrottleRate <= 0 {
+		cfg.Logger.Warn("Ignoring invalid [public_dashboards] bot_detection_throttle_rate, expected a positive number of requests per minute", "value", cfg.PublicDashboardsBotDetectionThrottleRate)
+		cfg.PublicDashboardsBotDetectionThrottleRate = 10
+	}
+	cfg.PublicDashboardsBotDetectionBlockScore = publicDashboards.Key("bot_detection_block_score").MustInt(100)
+	if cfg.PublicDashboardsBotDetectionBlockScore <= 0 {
+		cfg.Logger.Warn("Ignoring invalid [public_dashboards] bot_detection_block_score, expected a positive score", "value", cfg.PublicDashboardsBotDetectionBlockScore)
+		cfg.PublicDashboardsBotDetectionBlockScore = 100
+	}
+	cfg.PublicDashboardsBotDetectionBlockDuration = publicDashboards.Key("bot_detection_block_duration").MustDuration(15 * time.Minute)
+	if cfg.PublicDashboardsBotDetectionBlockDuration <= 0 {
+		cfg.Logger.Warn("Ignoring invalid [public_dashboards] bot_detection_block_duration, expected a positive duration", "value", cfg.PublicDashboardsBotDetectionBlockDuration)
+		cfg.PublicDashboardsBotDetectionBlockDuration = 15 * time.Minute
+	}
+	cfg.PublicDashboardsExportWatermark = strings.TrimSpace(publicDashboards.Key("export_watermark").MustString(""))
+	cfg.PublicDashboardsExportWatermarkOrgs = []int64{}
+	for _, org := range util.SplitString(publicDashboards.Key("export_watermark_orgs").MustString("")) {
+		id, err := strconv.ParseInt(org, 10, 64)
+		if err != nil {
+			cfg.Logger.Warn("Ignoring invalid [public_dashboards] export_watermark_orgs entry, expected an org id", "entry", org)
+			continue
+		}
+		cfg.PublicDashboardsExportWatermarkOrgs = append(cfg.PublicDashboardsExportWatermarkOrgs, id)
+	}
+	cfg.PublicDashboardsAccessTokenCacheTTL = publicDashboards.Key("access_token_cache_ttl").MustDuration(10 * time.Second)
+	cfg.PublicDashboardsOrphanedCleanupInterval = publicDashboards.Key("orphaned_cleanup_interval").MustDuration(time.Hour)
+	if cfg.PublicDashboardsOrphanedCleanupInterval < 0 {
+		cfg.Logger.Warn("Ignoring invalid [public_dashboards] orphaned_cleanup_interval, expected a positive duration or 0", "value", cfg.PublicDashboardsOrphanedCleanupInterval)
+		cfg.PublicDashboardsOrphanedCleanupInterval = time.Hour
+	}
+	cfg.PublicDashboardsOrphanedCleanupDryRun = publicDashboards.Key("orphaned_cleanup_dry_run").MustBool(false)
+	cfg.PublicDashboardsReadReplicaURL = publicDashboards.Key("read_replica_url").MustString("")
+}
+
+func (cfg *Cfg) readPublicDashboardsSanitizationPolicy(publicDashboards *ini.Section) {
+	policy := PublicDashboardsSanitizationPolicy{
+		StripTargetFields:       util.SplitString(publicDashboards.Key("sanitize_target_fields").MustString("expr query rawSql")),
+		KeepExecutedQueryString: publicDashboards.Key("sanitize_keep_executed_query").MustBool(false),
+		AllowQueryOverrides:     publicDashboards.Key("allow_query_overrides").MustBool(false),
+		RedactLabels:            util.SplitString(publicDashboards.Key("sanitize_redact_labels").MustString("")),
+	}
+	cfg.PublicDashboardsSanitizationPolicy = policy
+	cfg.PublicDashboardsSanitizationPolicyByOrg = make(map[int64]PublicDashboardsSanitizationPolicy)
+
+	// the org overrides are <orgId>:<value> entries, lists are separated by | in the value
+	splitList := func(value string) []string {
+		return strings.FieldsFunc(value, func(r rune) bool { return r == '|' })
+	}
+	overrides := []struct {
+		key   string
+		apply func(p *PublicDashboardsSanitizationPolicy, value string) error
+	}{
+		{"sanitize_target_fields_org_overrides", func(p *PublicDashboardsSanitizationPolicy, value string) error {
+			p.StripTargetFields = splitList(value)
+			return nil
+		}},
+		{"sanitize_keep_executed_query_org_overrides", func(p *PublicDashboardsSanitizationPolicy, value string) (err error) {
+			p.KeepExecutedQueryString, err = strconv.ParseBool(value)
+			return err
+		}},
+		{"allow_query_overrides_org_overrides", func(p *PublicDashboardsSanitizationPolicy, value string) (err error) {
+			p.AllowQueryOverrides, err = strconv.ParseBool(value)
+			return err
+		}},
+		{"sanitize_redact_labels_org_overrides", func(p *PublicDashboardsSanitizationPolicy, value string) error {
+			p.RedactLabels = splitList(value)
+			return nil
+		}},
+	}
+	for _, override := range overrides {
+		for _, entry := range util.SplitString(publicDashboards.Key(override.key).MustString("")) {
+			orgID, value, found := strings.Cut(entry, ":")
+			id, err := strconv.ParseInt(orgID, 10, 64)
+			if !found || err != nil {
+				cfg.Logger.Warn("Ignoring invalid [public_dashboards] "+override.key+" entry, expected <orgId>:<value>", "entry", entry)
+				continue
+			}
+
+			orgPolicy, ok := cfg.PublicDashboardsSanitizationPolicyByOrg[id]
+			if !ok {
+				orgPolicy = policy
+			}
+			if err := override.apply(&orgPolicy, value); err != nil {
+				cfg.Logger.Warn("Ignoring invalid [public_dashboards] "+override.key+" entry", "entry", entry, "error", err)
+				continue
+			}
+			cfg.PublicDashboardsSanitizationPolicyByOrg[id] = orgPolicy
+		}
+	}
 }
 
 func (cfg *Cfg) DefaultOrgID() int64 {
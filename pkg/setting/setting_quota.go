@@ -6,10 +6,14 @@ type OrgQuota struct {
 	Dashboard  int64 `target:"dashboard"`
 	ApiKey     int64 `target:"api_key"`
 	AlertRule  int64 `target:"alert_rule"`
+
+	PublicDashboard int64 `target:"public_dashboard"`
 }
 
 type UserQuota struct {
 	Org int64 `target:"org_user"`
+
+	PublicDashboard int64 `target:"public_dashboard"`
 }
 
 type GlobalQuota struct {
@@ -22,6 +26,8 @@ type GlobalQuota struct {
 	AlertRule    int64 `target:"alert_rule"`
 	File         int64 `target:"file"`
 	Correlations int64 `target:"correlations"`
+
+	PublicDashboard int64 `target:"public_dashboard"`
 }
 
 type QuotaSettings struct {
@@ -43,11 +49,15 @@ func (cfg *Cfg) readQuotaSettings() {
 		Dashboard:  quota.Key("org_dashboard").MustInt64(10),
 		ApiKey:     quota.Key("org_api_key").MustInt64(10),
 		AlertRule:  quota.Key("org_alert_rule").MustInt64(100),
+
+		PublicDashboard: quota.Key("org_public_dashboard").MustInt64(-1),
 	}
 
 	// per User limits
 	cfg.Quota.User = UserQuota{
 		Org: quota.Key("user_org").MustInt64(10),
+
+		PublicDashboard: quota.Key("user_public_dashboard").MustInt64(-1),
 	}
 
 	// Global Limits
@@ -61,5 +71,7 @@ func (cfg *Cfg) readQuotaSettings() {
 		File:         quota.Key("global_file").MustInt64(-1),
 		AlertRule:    quota.Key("global_alert_rule").MustInt64(-1),
 		Correlations: quota.Key("global_correlations").MustInt64(-1),
+
+		PublicDashboard: quota.Key("global_public_dashboard").MustInt64(-1),
 	}
 }
@@ -76,6 +76,8 @@ function PublicDashboardSceneRenderer({ model }: SceneComponentProps<DashboardSc
   const [variableValues, setVariableValues] = useState<Record<string, string | string[]>>({});
   const { controls, title, body } = model.useState();
   const { timePicker, refreshPicker, hideTimeControls } = controls!.useState();
+  const timeRangeObject = sceneGraph.getTimeRange(model);
+  const { value: timeRange } = timeRangeObject.useState();
   const styles = useStyles2(getStyles);
   const conf = useGetPublicDashboardConfig();
 
@@ -163,6 +165,11 @@ function PublicDashboardSceneRenderer({ model }: SceneComponentProps<DashboardSc
         variables={variables}
         onVariableChange={handleVariableChange}
         values={variableValues}
+        timeRange={{
+          from: timeRange.from.valueOf().toString(),
+          to: timeRange.to.valueOf().toString(),
+          timezone: timeRangeObject.getTimeZone(),
+        }}
       />
       <div className={styles.body}>
         <body.Component model={body} />
@@ -2,7 +2,11 @@ import { css } from '@emotion/css';
 import { useCallback, useEffect, useState } from 'react';
 
 import { GrafanaTheme2, VariableHide } from '@grafana/data';
-import { fetchPublicDashboardVariableOptions, MetricFindValue } from '@grafana/runtime';
+import {
+  fetchPublicDashboardVariableOptions,
+  MetricFindValue,
+  PublicDashboardVariableTimeRange,
+} from '@grafana/runtime';
 import { Select, useStyles2, InlineField, MultiSelect, AsyncSelect, AsyncMultiSelect } from '@grafana/ui';
 
 /**
@@ -14,6 +18,7 @@ export interface PublicDashboardVariable {
   hide?: VariableHide;
   type?: string;
   multi?: boolean;
+  includeAll?: boolean;
   options?: Array<{
     text: string | string[];
     value: string | string[];
@@ -29,6 +34,7 @@ interface PublicDashboardVariableRendererProps {
   variables: PublicDashboardVariable[];
   onVariableChange: (name: string, value: string | string[]) => void;
   values: Record<string, string | string[]>;
+  timeRange?: PublicDashboardVariableTimeRange;
 }
 
 /**
@@ -41,6 +47,7 @@ export function PublicDashboardVariableRenderer({
   variables,
   onVariableChange,
   values,
+  timeRange,
 }: PublicDashboardVariableRendererProps) {
   const styles = useStyles2(getStyles);
 
@@ -62,6 +69,7 @@ export function PublicDashboardVariableRenderer({
           value={values[variable.name]}
           onChange={(value) => onVariableChange(variable.name, value)}
           allValues={values}
+          timeRange={timeRange}
         />
       ))}
     </div>
@@ -73,9 +81,28 @@ interface VariableControlProps {
   value: string | string[] | undefined;
   onChange: (value: string | string[]) => void;
   allValues: Record<string, string | string[]>;
+  timeRange?: PublicDashboardVariableTimeRange;
 }
 
-function VariableControl({ variable, value, onChange, allValues }: VariableControlProps) {
+const ALL_VARIABLE_TEXT = 'All';
+const ALL_VARIABLE_VALUE = '$__all';
+
+/**
+ * Keeps "All" exclusive in multi-value selections: selecting All clears the other values, and selecting another
+ * value clears All
+ */
+export function selectMultiValues(previous: string[], selected: string[]): string[] {
+  const added = selected.filter((v) => !previous.includes(v));
+  if (added.includes(ALL_VARIABLE_VALUE)) {
+    return [ALL_VARIABLE_VALUE];
+  }
+  if (selected.length > 1) {
+    return selected.filter((v) => v !== ALL_VARIABLE_VALUE);
+  }
+  return selected;
+}
+
+function VariableControl({ variable, value, onChange, allValues, timeRange }: VariableControlProps) {
   const styles = useStyles2(getStyles);
   const [dynamicOptions, setDynamicOptions] = useState<Array<{ label: string; value: string }>>([]);
   const [isLoading, setIsLoading] = useState(false);
@@ -89,6 +116,9 @@ function VariableControl({ variable, value, onChange, allValues }: VariableContr
       label: Array.isArray(opt.text) ? opt.text.join(', ') : String(opt.text),
       value: Array.isArray(opt.value) ? opt.value.join(',') : String(opt.value),
     })) ?? [];
+  if (variable.includeAll && !staticOptions.some((opt) => opt.value === ALL_VARIABLE_VALUE)) {
+    staticOptions.unshift({ label: ALL_VARIABLE_TEXT, value: ALL_VARIABLE_VALUE });
+  }
 
   // Use dynamic options for query variables, static options otherwise
   const options = isQueryVariable && dynamicOptions.length > 0 ? dynamicOptions : staticOptions;
@@ -110,7 +140,7 @@ function VariableControl({ variable, value, onChange, allValues }: VariableContr
           }
         }
 
-        const results = await fetchPublicDashboardVariableOptions(variable.name, otherVariables);
+        const results = await fetchPublicDashboardVariableOptions(variable.name, otherVariables, undefined, timeRange);
         const newOptions = results.map((opt: MetricFindValue) => ({
           label: opt.text,
           value: opt.value,
@@ -126,9 +156,9 @@ function VariableControl({ variable, value, onChange, allValues }: VariableContr
     };
 
     fetchOptions();
-    // Re-fetch when other variable values change (for cascading variables)
+    // Re-fetch when other variable values or the time range change (for cascading variables)
     // eslint-disable-next-line react-hooks/exhaustive-deps
-  }, [variable.name, variable.type, JSON.stringify(allValues)]);
+  }, [variable.name, variable.type, JSON.stringify(allValues), timeRange?.from, timeRange?.to]);
 
   // Load options callback for async select (used for search filtering)
   const loadOptions = useCallback(
@@ -141,7 +171,7 @@ function VariableControl({ variable, value, onChange, allValues }: VariableContr
           }
         }
 
-        const results = await fetchPublicDashboardVariableOptions(variable.name, otherVariables, inputValue);
+        const results = await fetchPublicDashboardVariableOptions(variable.name, otherVariables, inputValue, timeRange);
         return results.map((opt: MetricFindValue) => ({
           label: opt.text,
           value: opt.value,
@@ -151,7 +181,7 @@ function VariableControl({ variable, value, onChange, allValues }: VariableContr
         return [];
       }
     },
-    [variable.name, allValues]
+    [variable.name, allValues, timeRange]
   );
 
   // Get current value, falling back to the variable's current value or first option
@@ -163,6 +193,10 @@ function VariableControl({ variable, value, onChange, allValues }: VariableContr
         : variable.current.value
       : options[0]?.value);
 
+  // Selected values show the text of their option, the All option is returned by the variable endpoint
+  const optionLabel = (v: string) =>
+    v === ALL_VARIABLE_VALUE ? ALL_VARIABLE_TEXT : (options.find((opt) => opt.value === v)?.label ?? v);
+
   const label = variable.label || variable.name;
   const hideLabel = variable.hide === VariableHide.hideLabel;
 
@@ -176,8 +210,8 @@ function VariableControl({ variable, value, onChange, allValues }: VariableContr
           <AsyncMultiSelect
             loadOptions={loadOptions}
             defaultOptions={options}
-            value={multiValue.map((v) => ({ label: v, value: v }))}
-            onChange={(selected) => onChange(selected.map((s) => s.value!))}
+            value={multiValue.map((v) => ({ label: optionLabel(v), value: v }))}
+            onChange={(selected) => onChange(selectMultiValues(multiValue, selected.map((s) => s.value!)))}
             className={styles.select}
             isClearable={false}
             isLoading={isLoading}
@@ -191,7 +225,7 @@ function VariableControl({ variable, value, onChange, allValues }: VariableContr
         <MultiSelect
           options={options}
           value={multiValue}
-          onChange={(selected) => onChange(selected.map((s) => s.value!))}
+          onChange={(selected) => onChange(selectMultiValues(multiValue, selected.map((s) => s.value!)))}
           className={styles.select}
           isClearable={false}
         />
@@ -207,7 +241,7 @@ function VariableControl({ variable, value, onChange, allValues }: VariableContr
         <AsyncSelect
           loadOptions={loadOptions}
           defaultOptions={options}
-          value={selectedValue ? { label: selectedValue, value: selectedValue } : undefined}
+          value={selectedValue ? { label: optionLabel(selectedValue), value: selectedValue } : undefined}
           onChange={(selected) => onChange(selected?.value ?? '')}
           className={styles.select}
           isClearable={false}
@@ -34,5 +34,18 @@ export const getPublicDashboardRoutes = (): RouteDescriptor[] => {
           )
       ),
     },
+    {
+      path: '/public-dashboards/:accessToken/embed',
+      pageClass: 'page-dashboard',
+      allowAnonymous: true,
+      routeName: DashboardRoutes.Public,
+      chromeless: true,
+      component: SafeDynamicImport(
+        () =>
+          import(
+            /* webpackChunkName: "PublicDashboardPage" */ '../../features/dashboard/containers/PublicDashboardPageProxy'
+          )
+      ),
+    },
   ];
 };
@@ -79,6 +79,11 @@ export enum AccessControlAction {
   DashboardsPermissionsRead = 'dashboards.permissions:read',
   DashboardsPermissionsWrite = 'dashboards.permissions:write',
   DashboardsPublicWrite = 'dashboards.public:write',
+  DashboardsPublicShare = 'dashboards.public:share',
+  DashboardsPublicTimeSelectionWrite = 'dashboards.public.timeselection:write',
+  DashboardsPublicAnnotationsWrite = 'dashboards.public.annotations:write',
+  DashboardsPublicVariableOverridesWrite = 'dashboards.public.variableoverrides:write',
+  DashboardsPublicExpirationWrite = 'dashboards.public.expiration:write',
   SnapshotsCreate = 'snapshots:create',
   SnapshotsDelete = 'snapshots:delete',
   SnapshotsRead = 'snapshots:read',
@@ -70,6 +70,7 @@ export interface DashboardMeta {
   hasUnsavedFolderChange?: boolean;
   annotationsPermissions?: AnnotationsPermissions;
   publicDashboardEnabled?: boolean;
+  publicDashboardReadOnlyVariables?: string[];
   isEmbedded?: boolean;
   isNew?: boolean;
   version?: number;